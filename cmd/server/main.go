@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
@@ -17,15 +18,17 @@ import (
 	"github.com/automax/backend/internal/services"
 	"github.com/automax/backend/internal/storage"
 	"github.com/automax/backend/pkg/utils"
-	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/compress"
 	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/etag"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 )
 
 func main() {
 	cfg := config.Load()
+	utils.ExposeInternalErrors = cfg.Server.ExposeInternalErrors
 
 	db, err := database.Connect(&cfg.Database)
 	if err != nil {
@@ -33,8 +36,13 @@ func main() {
 	}
 	defer database.Close(db)
 
-	if err := database.Migrate(db); err != nil {
-		log.Fatalf("Failed to run migrations: %v", err)
+	if cfg.Database.AutoMigrate {
+		if err := database.Migrate(db); err != nil {
+			log.Fatalf("Failed to run migrations: %v", err)
+		}
+	}
+	if err := database.VerifySchema(db); err != nil {
+		log.Fatalf("Schema verification failed: %v", err)
 	}
 
 	// Seed default data
@@ -54,6 +62,11 @@ func main() {
 	}
 
 	jwtManager := utils.NewJWTManager(cfg.JWT.Secret, cfg.JWT.ExpireHour)
+	jwtManager.SetIssuanceLogger(repository.NewTokenIssuanceLogger(db))
+	jwtManager.SetSigningKeys(cfg.JWT.SigningKeys, cfg.JWT.ActiveKID)
+	if err := jwtManager.ValidateKeyRotation(); err != nil {
+		log.Fatalf("Invalid JWT signing keys: %v", err)
+	}
 	sessionStore := database.NewSessionStore(redisClient)
 
 	// Initialize repositories
@@ -69,6 +82,24 @@ func main() {
 	reportRepo := repository.NewReportRepository(db)
 	reportTemplateRepo := repository.NewReportTemplateRepository(db)
 	lookupRepo := repository.NewLookupRepository(db)
+	if cfg.Lookup.LogQueries {
+		lookupRepo = repository.NewLoggingLookupRepository(lookupRepo, slog.Default())
+	}
+	// The database only keeps codes unique per org; existing cross-org reuse
+	// is allowed to stay but reported when the global scope is configured
+	if cfg.Lookup.CategoryCodeScope == config.CategoryCodeScopeGlobal {
+		collisions, err := lookupRepo.CategoryCodeCollisions(context.Background(), false)
+		if err != nil {
+			log.Printf("Warning: Failed to check lookup category codes: %v", err)
+		}
+		for _, collision := range collisions {
+			log.Printf("Warning: lookup category code %s is used by %d categories", collision.Code, collision.Count)
+		}
+	}
+	// The resolver reads through the plain repository; everything else writes
+	// through the invalidating wrapper so cached code→ID mappings stay current
+	lookupResolver := services.NewLookupResolver(lookupRepo, cfg.Lookup.ResolverCacheTTL)
+	lookupRepo = repository.NewInvalidatingLookupRepository(lookupRepo, lookupResolver.Invalidate)
 
 	// Initialize services
 	userService := services.NewUserService(userRepo, jwtManager, sessionStore, minioStorage, cfg)
@@ -84,8 +115,14 @@ func main() {
 	slaMonitor.Start(ctx)
 	defer slaMonitor.Stop()
 
+	if cfg.Lookup.PurgeRetention > 0 {
+		lookupPurger := services.NewLookupPurger(lookupRepo, cfg.Lookup.PurgeRetention, cfg.Lookup.PurgeInterval)
+		lookupPurger.Start(ctx)
+		defer lookupPurger.Stop()
+	}
+
 	// Initialize validator
-	validate := validator.New()
+	validate := utils.NewValidator()
 
 	// Initialize handlers
 	userHandler := handlers.NewUserHandler(userService, minioStorage)
@@ -99,10 +136,14 @@ func main() {
 	incidentHandler := handlers.NewIncidentHandler(incidentService, userRepo, minioStorage)
 	reportHandler := handlers.NewReportHandler(reportService)
 	reportTemplateHandler := handlers.NewReportTemplateHandler(reportTemplateService)
-	lookupHandler := handlers.NewLookupHandler(lookupRepo)
+	lookupHandler := handlers.NewLookupHandler(lookupRepo, jwtManager, cfg.Lookup)
+	authKeysHandler := handlers.NewAuthKeysHandler(jwtManager, repository.NewTokenIssuanceRepository(db))
+	lookupReadOnly := middleware.NewReadOnlySwitch(cfg.Lookup.ReadOnly, cfg.Lookup.ReadOnlyRetryAfter)
+	maintenanceHandler := handlers.NewMaintenanceHandler(lookupReadOnly, validate)
 
 	// Initialize middleware
 	authMiddleware := middleware.NewAuthMiddleware(jwtManager, sessionStore, userRepo)
+	idempotency := middleware.Idempotency(middleware.NewMemoryIdempotencyStore(), 24*time.Hour)
 
 	app := fiber.New(fiber.Config{
 		AppName:      "Automax Backend",
@@ -110,15 +151,39 @@ func main() {
 	})
 
 	app.Use(recover.New())
+	// Uploads, downloads and exports move data in proportion to its size, so
+	// they run without the request deadline
+	app.Use(middleware.Timeout(cfg.Server.RequestTimeout,
+		"/api/v1/users/me/avatar",
+		"/api/v1/incidents/:id/attachments",
+		"/api/v1/complaints/:id/attachments",
+		"/api/v1/queries/:id/attachments",
+		"/api/v1/attachments/:attachment_id",
+		"/api/v1/audit/export.csv",
+		"/api/v1/admin/users/export",
+		"/api/v1/admin/classifications/export",
+		"/api/v1/admin/locations/export",
+		"/api/v1/admin/departments/export",
+		"/api/v1/admin/roles/export",
+		"/api/v1/admin/workflows/:id/export",
+		"/api/v1/admin/reports/export",
+		"/api/v1/admin/lookups/export/all.zip",
+		"/api/v1/admin/lookups/export.json",
+		"/api/v1/admin/lookups/import",
+		"/api/v1/admin/lookups/categories/:category_id/export.csv",
+		"/api/v1/admin/lookups/categories/:category_id/import.csv",
+	))
 	app.Use(logger.New(logger.Config{
 		Format: "[${time}] ${status} - ${latency} ${method} ${path}\n",
 	}))
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     "http://localhost:3000,http://localhost:5173",
 		AllowMethods:     "GET,POST,PUT,DELETE,PATCH,OPTIONS",
-		AllowHeaders:     "Origin,Content-Type,Accept,Authorization",
+		AllowHeaders:     "Origin,Content-Type,Accept,Authorization,Idempotency-Key," + utils.RawResponseHeader + "," + utils.AcceptVersionHeader,
+		ExposeHeaders:    "X-Total-Count,X-Page,X-Limit,X-Total-Pages," + utils.HasMoreHeader + ",Link," + utils.WarningsHeader + "," + utils.CorrelationIDHeader + "," + middleware.RateLimitLimitHeader + "," + middleware.RateLimitRemainingHeader + "," + middleware.RateLimitResetHeader + "," + utils.APIVersionHeader,
 		AllowCredentials: true,
 	}))
+	app.Use(middleware.APIVersion())
 
 	api := app.Group("/api")
 	v1 := api.Group("/v1")
@@ -126,6 +191,7 @@ func main() {
 	// Health routes
 	v1.Get("/health", healthHandler.Health)
 	v1.Get("/ready", healthHandler.Ready)
+	v1.Get("/version", healthHandler.Version)
 
 	// Auth routes
 	auth := v1.Group("/auth")
@@ -133,6 +199,8 @@ func main() {
 	auth.Post("/login", userHandler.Login)
 	auth.Post("/refresh", userHandler.RefreshToken)
 	auth.Post("/logout", authMiddleware.Authenticate(), userHandler.Logout)
+	auth.Post("/introspect", middleware.RequireServiceToken(cfg.JWT.IntrospectionSecret), userHandler.Introspect)
+	auth.Get("/keys", authMiddleware.Authenticate(), authMiddleware.RequirePermission("settings:view"), authKeysHandler.ListKeys)
 
 	// User routes
 	users := v1.Group("/users")
@@ -212,6 +280,9 @@ func main() {
 	// Admin routes
 	admin := v1.Group("/admin", authMiddleware.Authenticate())
 
+	// Build of the running binary; the public /version only lists API versions
+	admin.Get("/version", authMiddleware.RequirePermission("settings:view"), healthHandler.BuildInfo)
+
 	// User management
 	admin.Get("/users", authMiddleware.RequirePermission("users:view"), userHandler.ListUsers)
 	admin.Post("/users", authMiddleware.RequirePermission("users:create"), userHandler.AdminCreateUser)
@@ -222,6 +293,9 @@ func main() {
 	admin.Put("/users/:id", authMiddleware.RequirePermission("users:update"), userHandler.AdminUpdateUser)
 
 	// Classification routes
+	// Hard-delete lookups soft-deleted before the retention window
+	admin.Post("/purge", authMiddleware.RequirePermission("lookups:delete"), lookupReadOnly.Middleware(), lookupHandler.PurgeDeleted)
+
 	classifications := admin.Group("/classifications")
 	classifications.Post("/", authMiddleware.RequirePermission("classifications:create"), classificationHandler.Create)
 	classifications.Get("/", authMiddleware.RequirePermission("classifications:view"), classificationHandler.List)
@@ -288,6 +362,12 @@ func main() {
 	actionLogs.Get("/:id", authMiddleware.RequirePermission("action-logs:view"), actionLogHandler.GetActionLog)
 	actionLogs.Delete("/cleanup", authMiddleware.RequirePermission("action-logs:delete"), actionLogHandler.CleanupOldLogs)
 
+	// Audit export routes
+	audit := v1.Group("/audit", authMiddleware.Authenticate())
+	audit.Get("/export.csv", authMiddleware.RequirePermission("action-logs:export"), middleware.RateLimit(5, time.Minute, func(c *fiber.Ctx) string {
+		return fmt.Sprint(c.Locals("user_id"))
+	}), actionLogHandler.ExportActionLogs)
+
 	// Workflow routes
 	workflows := admin.Group("/workflows")
 	workflows.Post("/", authMiddleware.RequirePermission("workflows:create"), workflowHandler.CreateWorkflow)
@@ -353,21 +433,120 @@ func main() {
 	reportTemplates.Post("/:id/duplicate", authMiddleware.RequirePermission("reports:create"), reportTemplateHandler.DuplicateTemplate)
 	reportTemplates.Post("/:id/set-default", authMiddleware.RequirePermission("reports:update"), reportTemplateHandler.SetDefaultTemplate)
 
+	// Lookup maintenance mode; registered ahead of the lookups group so the
+	// switch can still be turned off while lookup writes are rejected
+	admin.Get("/lookups/read-only", authMiddleware.RequirePermission("settings:view"), maintenanceHandler.GetLookupReadOnly)
+	admin.Put("/lookups/read-only", authMiddleware.RequirePermission("settings:update"), maintenanceHandler.SetLookupReadOnly)
+
 	// Lookup routes (admin)
-	lookups := admin.Group("/lookups")
-	lookups.Post("/categories", authMiddleware.RequirePermission("lookups:create"), lookupHandler.CreateCategory)
+	// POSTs that only read stay open in maintenance mode
+	lookups := admin.Group("/lookups", lookupReadOnly.Middleware(
+		"/api/v1/admin/lookups/categories/batch",
+		"/api/v1/admin/lookups/categories/:category_id/values/validate",
+	))
+	lookups.Post("/categories", authMiddleware.RequirePermission("lookups:create"), idempotency, lookupHandler.CreateCategory)
 	lookups.Get("/categories", authMiddleware.RequirePermission("lookups:view"), lookupHandler.ListCategories)
-	lookups.Get("/categories/:id", authMiddleware.RequirePermission("lookups:view"), lookupHandler.GetCategoryByID)
-	lookups.Put("/categories/:id", authMiddleware.RequirePermission("lookups:update"), lookupHandler.UpdateCategory)
-	lookups.Delete("/categories/:id", authMiddleware.RequirePermission("lookups:delete"), lookupHandler.DeleteCategory)
-	lookups.Post("/categories/:id/values", authMiddleware.RequirePermission("lookups:create"), lookupHandler.CreateValue)
-	lookups.Get("/categories/:id/values", authMiddleware.RequirePermission("lookups:view"), lookupHandler.ListValuesByCategory)
-	lookups.Get("/values/:id", authMiddleware.RequirePermission("lookups:view"), lookupHandler.GetValueByID)
-	lookups.Put("/values/:id", authMiddleware.RequirePermission("lookups:update"), lookupHandler.UpdateValue)
-	lookups.Delete("/values/:id", authMiddleware.RequirePermission("lookups:delete"), lookupHandler.DeleteValue)
+	lookups.Get("/templates", authMiddleware.RequirePermission("lookups:view"), lookupHandler.ListTemplates)
+	lookups.Post("/categories/from-template/:template_code", authMiddleware.RequirePermission("lookups:create"), lookupHandler.CreateCategoryFromTemplate)
+	lookups.Get("/recent", authMiddleware.RequirePermission("lookups:view"), lookupHandler.ListRecent)
+	lookups.Get("/stats", authMiddleware.RequirePermission("lookups:view"), lookupHandler.GetStats)
+	lookups.Get("/diagnostics/inactive-defaults", authMiddleware.RequirePermission("lookups:view"), lookupHandler.GetInactiveDefaults)
+	lookups.Get("/diagnostics/missing-defaults", authMiddleware.RequirePermission("lookups:view"), lookupHandler.GetMissingDefaults)
+	lookups.Get("/export/all.zip", authMiddleware.RequirePermission("lookups:view"), lookupHandler.ExportAllZip)
+	lookups.Get("/export.json", authMiddleware.RequirePermission("lookups:view"), lookupHandler.ExportJSON)
+	lookups.Post("/import", authMiddleware.RequirePermission("lookups:create"), lookupHandler.ImportJSON)
+	lookups.Put("/categories/code/:code", authMiddleware.RequirePermission("lookups:update"), lookupHandler.UpsertCategoryByCode)
+	lookups.Get("/categories/groups", authMiddleware.RequirePermission("lookups:view"), lookupHandler.ListCategoryGroups)
+	lookups.Get("/categories/code-suggestions", authMiddleware.RequirePermission("lookups:view"), lookupHandler.SuggestCategoryCodes)
+	lookups.Post("/categories/batch", authMiddleware.RequirePermission("lookups:view"), lookupHandler.GetCategoriesBatch)
+	lookups.Patch("/categories/active", authMiddleware.RequirePermission("lookups:update"), lookupHandler.SetCategoriesActive)
+	lookups.Patch("/categories/incident-form", authMiddleware.RequirePermission("lookups:update"), lookupHandler.SetCategoriesIncidentForm)
+	lookups.Get("/categories/:category_id", authMiddleware.RequirePermission("lookups:view"), lookupHandler.GetCategoryByID)
+	lookups.Put("/categories/:category_id", authMiddleware.RequirePermission("lookups:update"), lookupHandler.UpdateCategory)
+	lookups.Patch("/categories/:category_id/apply", authMiddleware.RequirePermission("lookups:update"), lookupHandler.ApplyCategoryChanges)
+	lookups.Delete("/categories/:category_id", authMiddleware.RequirePermission("lookups:delete"), lookupHandler.DeleteCategory)
+	lookups.Get("/categories/:category_id/incident-form-preview", authMiddleware.RequirePermission("lookups:view"), lookupHandler.PreviewIncidentFormCategory)
+	lookups.Post("/categories/:category_id/transfer-default", authMiddleware.RequirePermission("lookups:update"), lookupHandler.TransferDefault)
+	lookups.Get("/categories/:category_id/delete-preview", authMiddleware.RequirePermission("lookups:delete"), lookupHandler.PreviewDeleteCategory)
+	lookups.Post("/categories/:category_id/reset", authMiddleware.RequirePermission("lookups:update"), lookupHandler.ResetCategory)
+	lookups.Post("/categories/:category_id/touch", authMiddleware.RequirePermission("lookups:update"), lookupHandler.TouchCategory)
+	lookups.Post("/categories/:category_id/archive", authMiddleware.RequirePermission("lookups:update"), lookupHandler.ArchiveCategory)
+	lookups.Post("/categories/:category_id/unarchive", authMiddleware.RequirePermission("lookups:update"), lookupHandler.UnarchiveCategory)
+	lookups.Get("/categories/:category_id/related", authMiddleware.RequirePermission("lookups:view"), lookupHandler.ListRelatedCategories)
+	lookups.Post("/categories/:category_id/related", authMiddleware.RequirePermission("lookups:update"), lookupHandler.LinkCategory)
+	lookups.Delete("/categories/:category_id/related/:related_id", authMiddleware.RequirePermission("lookups:update"), lookupHandler.UnlinkCategory)
+	lookups.Get("/categories/:category_id/export.csv", authMiddleware.RequirePermission("lookups:view"), lookupHandler.ExportCategoryCSV)
+	lookups.Post("/categories/:category_id/import.csv", authMiddleware.RequirePermission("lookups:create"), lookupHandler.ImportCategoryCSV)
+	lookups.Post("/categories/:category_id/translations/import", authMiddleware.RequirePermission("lookups:update"), lookupHandler.ImportCategoryTranslations)
+	lookups.Post("/categories/:category_id/values", authMiddleware.RequirePermission("lookups:create"), idempotency, lookupHandler.CreateValue)
+	lookups.Get("/categories/:category_id/values", authMiddleware.RequirePermission("lookups:view"), lookupHandler.ListValuesByCategory)
+	lookups.Post("/categories/:category_id/values/bulk", authMiddleware.RequirePermission("lookups:create"), lookupHandler.BulkCreateValues)
+	lookups.Put("/categories/:category_id/values/bulk", authMiddleware.RequirePermission("lookups:update"), lookupHandler.UpsertValues)
+	lookups.Post("/categories/:category_id/values/validate", authMiddleware.RequirePermission("lookups:create"), lookupHandler.ValidateValues)
+	lookups.Post("/categories/:category_id/values/move", authMiddleware.RequirePermission("lookups:update"), lookupHandler.MoveValues)
+	lookups.Post("/categories/:category_id/values/restore", authMiddleware.RequirePermission("lookups:update"), lookupHandler.RestoreValues)
+	lookups.Post("/categories/:category_id/values/normalize-order", authMiddleware.RequirePermission("lookups:update"), lookupHandler.NormalizeValueOrder)
+	lookups.Get("/categories/:category_id/values/autocomplete", authMiddleware.RequirePermission("lookups:view"), lookupHandler.AutocompleteValues)
+	lookups.Get("/categories/:category_id/values/tree", authMiddleware.RequirePermission("lookups:view"), lookupHandler.GetValueTree)
+	lookups.Get("/categories/:category_id/values/by-metadata", authMiddleware.RequirePermission("lookups:view"), lookupHandler.ListValuesByMetadata)
+	lookups.Get("/values/colors", authMiddleware.RequirePermission("lookups:view"), lookupHandler.ListColorUsage)
+	lookups.Post("/values/recolor", authMiddleware.RequirePermission("lookups:update"), lookupHandler.RecolorValues)
+	lookups.Get("/values/orphans", authMiddleware.RequirePermission("lookups:view"), lookupHandler.ListOrphanValues)
+	lookups.Post("/values/orphans/cleanup", authMiddleware.RequirePermission("lookups:delete"), lookupHandler.CleanupOrphanValues)
+	lookups.Get("/values/search", authMiddleware.RequirePermission("lookups:view"), lookupHandler.SearchValues)
+	lookups.Get("/values/:value_id", authMiddleware.RequirePermission("lookups:view"), lookupHandler.GetValueByID)
+	lookups.Get("/values/:value_id/share", authMiddleware.RequirePermission("lookups:view"), lookupHandler.ShareValue)
+	lookups.Get("/values/:value_id/ancestors", authMiddleware.RequirePermission("lookups:view"), lookupHandler.GetValueAncestors)
+	lookups.Put("/values/:value_id", authMiddleware.RequirePermission("lookups:update"), lookupHandler.UpdateValue)
+	lookups.Patch("/values/:value_id", authMiddleware.RequirePermission("lookups:update"), lookupHandler.PatchValue)
+	lookups.Delete("/values/:value_id", authMiddleware.RequirePermission("lookups:delete"), lookupHandler.DeleteValue)
+	lookups.Post("/values/defaults", authMiddleware.RequirePermission("lookups:update"), lookupHandler.SetDefaultValues)
+	lookups.Post("/values/:value_id/clone", authMiddleware.RequirePermission("lookups:create"), lookupHandler.CloneValue)
+	lookups.Post("/values/:value_id/set-default", authMiddleware.RequirePermission("lookups:update"), lookupHandler.SetDefaultValue)
+	lookups.Get("/values/:value_id/aliases", authMiddleware.RequirePermission("lookups:view"), lookupHandler.ListAliases)
+	lookups.Post("/values/:value_id/aliases", authMiddleware.RequirePermission("lookups:create"), lookupHandler.CreateAlias)
+	lookups.Delete("/values/:value_id/aliases/:alias_id", authMiddleware.RequirePermission("lookups:delete"), lookupHandler.DeleteAlias)
+	lookups.Get("/values/:value_id/translations", authMiddleware.RequirePermission("lookups:view"), lookupHandler.ListValueTranslations)
+	lookups.Put("/values/:value_id/translations/:locale", authMiddleware.RequirePermission("lookups:update"), lookupHandler.SetValueTranslation)
+	lookups.Post("/tags", authMiddleware.RequirePermission("lookups:create"), lookupHandler.CreateTag)
+	lookups.Get("/tags", authMiddleware.RequirePermission("lookups:view"), lookupHandler.ListTags)
+	lookups.Get("/tags/:tag_code/values", authMiddleware.RequirePermission("lookups:view"), lookupHandler.ListValuesByTag)
+	lookups.Post("/tags/:tag_id/values", authMiddleware.RequirePermission("lookups:update"), lookupHandler.AttachTagValues)
+	lookups.Delete("/tags/:tag_id/values/:value_id", authMiddleware.RequirePermission("lookups:update"), lookupHandler.DetachTagValue)
 
 	// Public lookup endpoint (by category code) - accessible to authenticated users
-	v1.Get("/lookups/:code", authMiddleware.Authenticate(), lookupHandler.GetValuesByCategoryCode)
+	v1.Get("/lookups", authMiddleware.Authenticate(), etag.New(), lookupHandler.GetAllLookups)
+	v1.Get("/lookups/:code", authMiddleware.Authenticate(), etag.New(), lookupHandler.GetValuesByCategoryCode)
+	v1.Get("/lookups/:code/default", authMiddleware.Authenticate(), etag.New(), lookupHandler.GetDefaultValue)
+	v1.Get("/lookups/:code/resolve/:alias", authMiddleware.Authenticate(), etag.New(), lookupHandler.ResolveValue)
+
+	// Incremental lookup sync for offline clients
+	v1.Get("/sync/lookups", authMiddleware.Authenticate(), lookupHandler.SyncLookups)
+
+	// Batch code resolution for importers
+	v1.Post("/public/resolve", authMiddleware.Authenticate(), middleware.RateLimit(cfg.Lookup.PublicRateLimit, time.Minute, func(c *fiber.Ctx) string {
+		return fmt.Sprint(c.Locals("user_id"))
+	}), lookupHandler.ResolveCodes)
+
+	// Every active category and value grouped by code prefix; large, so
+	// compressed
+	v1.Get("/public/lookups/tree", authMiddleware.Authenticate(), middleware.RateLimit(cfg.Lookup.PublicRateLimit, time.Minute, func(c *fiber.Ctx) string {
+		return fmt.Sprint(c.Locals("user_id"))
+	}), compress.New(), etag.New(), lookupHandler.GetLookupTree)
+
+	// Lookups in the shape the incident form renders
+	v1.Get("/public/incident-form/lookups", authMiddleware.Authenticate(), middleware.RateLimit(cfg.Lookup.PublicRateLimit, time.Minute, func(c *fiber.Ctx) string {
+		return fmt.Sprint(c.Locals("user_id"))
+	}), etag.New(), lookupHandler.GetIncidentFormLookups)
+
+	// Shared lookup values - the share token is the credential
+	v1.Get("/public/shared/:token", middleware.RateLimit(cfg.Lookup.PublicRateLimit, time.Minute, nil), lookupHandler.GetSharedValue)
+
+	// JSON Schema for lookup request bodies
+	v1.Get("/schema/lookup", authMiddleware.Authenticate(), lookupHandler.GetSchema)
+
+	// OpenAPI document for the lookup endpoints
+	v1.Get("/openapi.json", authMiddleware.Authenticate(), lookupHandler.GetOpenAPI)
 
 	go func() {
 		addr := fmt.Sprintf("%s:%s", cfg.Server.Host, cfg.Server.Port)
@@ -389,16 +568,14 @@ func main() {
 }
 
 func customErrorHandler(c *fiber.Ctx, err error) error {
-	code := fiber.StatusInternalServerError
-	message := "Internal Server Error"
-
-	if e, ok := err.(*fiber.Error); ok {
-		code = e.Code
-		message = e.Message
+	e, ok := err.(*fiber.Error)
+	if !ok {
+		return utils.InternalErrorResponse(c, err)
 	}
 
-	return c.Status(code).JSON(fiber.Map{
+	return c.Status(e.Code).JSON(fiber.Map{
 		"success": false,
-		"error":   message,
+		"error":   e.Message,
+		"code":    utils.ErrorCodeForStatus(e.Code),
 	})
 }
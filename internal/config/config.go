@@ -3,6 +3,8 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
@@ -11,11 +13,18 @@ type Config struct {
 	Redis    RedisConfig
 	MinIO    MinIOConfig
 	JWT      JWTConfig
+	Lookup   LookupConfig
 }
 
 type ServerConfig struct {
 	Port string
 	Host string
+	// RequestTimeout bounds how long a request may spend in handlers and
+	// queries; zero disables it
+	RequestTimeout time.Duration
+	// ExposeInternalErrors sends the underlying error of 500 responses to
+	// clients; keep it off outside local development
+	ExposeInternalErrors bool
 }
 
 type DatabaseConfig struct {
@@ -25,6 +34,9 @@ type DatabaseConfig struct {
 	Password string
 	DBName   string
 	SSLMode  string
+	// AutoMigrate runs the migrations at boot; turn it off when they are run
+	// separately. The schema is verified at boot either way.
+	AutoMigrate bool
 }
 
 type RedisConfig struct {
@@ -45,21 +57,78 @@ type MinIOConfig struct {
 type JWTConfig struct {
 	Secret     string
 	ExpireHour int
+	// IntrospectionSecret is the service credential required by
+	// POST /auth/introspect; the endpoint rejects every call while it is empty
+	IntrospectionSecret string
+	// SigningKeys are kid-tagged access token secrets for key rotation, from
+	// JWT_SIGNING_KEYS as "kid:secret" pairs separated by commas
+	SigningKeys map[string]string
+	// ActiveKID names the key of SigningKeys that signs new access tokens;
+	// empty signs with Secret
+	ActiveKID string
 }
 
+type LookupConfig struct {
+	// CacheMaxAge is the max-age, in seconds, sent on lookup read responses
+	CacheMaxAge int
+	// LogQueries wraps the lookup repository with operation logging
+	LogQueries bool
+	// ResolverCacheTTL is how long the lookup resolver keeps a code→ID mapping
+	ResolverCacheTTL time.Duration
+	// CategoryCodeScope is CategoryCodeScopeOrg or CategoryCodeScopeGlobal
+	CategoryCodeScope string
+	// MaxValuesPerCategory caps the active values of a category; 0 disables the cap
+	MaxValuesPerCategory int
+	// ValuesSoftLimitPercent is the share of MaxValuesPerCategory from which
+	// value creation succeeds with a warning
+	ValuesSoftLimitPercent int
+	// ShareTokenTTL is how long a value share link stays valid
+	ShareTokenTTL time.Duration
+	// PublicRateLimit is how many requests per minute a client may make to each
+	// /public lookup endpoint; 0 disables the limit
+	PublicRateLimit int
+	// ClampListLimit lowers a list limit above the maximum page size to the
+	// maximum; otherwise such a request is rejected with 400
+	ClampListLimit bool
+	// ServerTiming adds a Server-Timing header with the database and total
+	// time to the public lookup reads; meant for non-production debugging
+	ServerTiming bool
+	// PurgeRetention is how long soft-deleted lookups are kept before a
+	// background job hard-deletes them; 0 disables the job
+	PurgeRetention time.Duration
+	// PurgeInterval is how often the purge job runs
+	PurgeInterval time.Duration
+	// ReadOnly starts the server with lookup writes rejected for maintenance;
+	// admins can flip it at runtime
+	ReadOnly bool
+	// ReadOnlyRetryAfter is the Retry-After sent with writes rejected in
+	// read-only mode
+	ReadOnlyRetryAfter time.Duration
+}
+
+// Category code uniqueness scopes. The database enforces uniqueness per org;
+// the global scope additionally stops two orgs from using the same code.
+const (
+	CategoryCodeScopeOrg    = "org"
+	CategoryCodeScopeGlobal = "global"
+)
+
 func Load() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
-			Host: getEnv("SERVER_HOST", "0.0.0.0"),
+			Port:                 getEnv("SERVER_PORT", "8080"),
+			Host:                 getEnv("SERVER_HOST", "0.0.0.0"),
+			RequestTimeout:       time.Duration(getEnvAsInt("REQUEST_TIMEOUT_SECONDS", 30)) * time.Second,
+			ExposeInternalErrors: getEnvAsBool("EXPOSE_INTERNAL_ERRORS", false),
 		},
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnv("DB_PORT", "5432"),
-			User:     getEnv("DB_USER", "automax"),
-			Password: getEnv("DB_PASSWORD", "automax123"),
-			DBName:   getEnv("DB_NAME", "automax"),
-			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+			Host:        getEnv("DB_HOST", "localhost"),
+			Port:        getEnv("DB_PORT", "5432"),
+			User:        getEnv("DB_USER", "automax"),
+			Password:    getEnv("DB_PASSWORD", "automax123"),
+			DBName:      getEnv("DB_NAME", "automax"),
+			SSLMode:     getEnv("DB_SSLMODE", "disable"),
+			AutoMigrate: getEnvAsBool("DB_AUTO_MIGRATE", true),
 		},
 		Redis: RedisConfig{
 			Host:     getEnv("REDIS_HOST", "localhost"),
@@ -75,8 +144,27 @@ func Load() *Config {
 			BucketName:      getEnv("MINIO_BUCKET", "automax"),
 		},
 		JWT: JWTConfig{
-			Secret:     getEnv("JWT_SECRET", "your-super-secret-jwt-key-change-in-production"),
-			ExpireHour: getEnvAsInt("JWT_EXPIRE_HOUR", 24),
+			Secret:              getEnv("JWT_SECRET", "your-super-secret-jwt-key-change-in-production"),
+			ExpireHour:          getEnvAsInt("JWT_EXPIRE_HOUR", 24),
+			IntrospectionSecret: getEnv("JWT_INTROSPECTION_SECRET", ""),
+			SigningKeys:         getEnvAsPairs("JWT_SIGNING_KEYS"),
+			ActiveKID:           getEnv("JWT_ACTIVE_KID", ""),
+		},
+		Lookup: LookupConfig{
+			CacheMaxAge:            getEnvAsInt("LOOKUP_CACHE_MAX_AGE", 300),
+			LogQueries:             getEnvAsBool("LOOKUP_LOG_QUERIES", false),
+			ResolverCacheTTL:       time.Duration(getEnvAsInt("LOOKUP_RESOLVER_CACHE_TTL_SECONDS", 60)) * time.Second,
+			CategoryCodeScope:      getEnv("LOOKUP_CATEGORY_CODE_SCOPE", CategoryCodeScopeOrg),
+			MaxValuesPerCategory:   getEnvAsInt("LOOKUP_MAX_VALUES_PER_CATEGORY", 0),
+			ValuesSoftLimitPercent: getEnvAsInt("LOOKUP_VALUES_SOFT_LIMIT_PERCENT", 80),
+			ShareTokenTTL:          time.Duration(getEnvAsInt("LOOKUP_SHARE_TOKEN_TTL_MINUTES", 60)) * time.Minute,
+			PublicRateLimit:        getEnvAsInt("LOOKUP_PUBLIC_RATE_LIMIT", 60),
+			ClampListLimit:         getEnvAsBool("LOOKUP_CLAMP_LIST_LIMIT", false),
+			ServerTiming:           getEnvAsBool("LOOKUP_SERVER_TIMING", false),
+			PurgeRetention:         time.Duration(getEnvAsInt("LOOKUP_PURGE_RETENTION_DAYS", 0)) * 24 * time.Hour,
+			PurgeInterval:          time.Duration(getEnvAsInt("LOOKUP_PURGE_INTERVAL_HOURS", 24)) * time.Hour,
+			ReadOnly:               getEnvAsBool("LOOKUP_READ_ONLY", false),
+			ReadOnlyRetryAfter:     time.Duration(getEnvAsInt("LOOKUP_READ_ONLY_RETRY_AFTER_SECONDS", 60)) * time.Second,
 		},
 	}
 }
@@ -105,3 +193,16 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 	}
 	return defaultValue
 }
+
+// getEnvAsPairs reads "key:value" pairs separated by commas. Entries without a
+// colon are ignored; a value may itself contain colons.
+func getEnvAsPairs(key string) map[string]string {
+	pairs := make(map[string]string)
+	for _, entry := range strings.Split(os.Getenv(key), ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(entry), ":")
+		if found && name != "" {
+			pairs[name] = value
+		}
+	}
+	return pairs
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/automax/backend/internal/config"
 	"github.com/automax/backend/internal/models"
@@ -34,6 +35,10 @@ func Connect(cfg *config.DatabaseConfig) (*gorm.DB, error) {
 
 func Migrate(db *gorm.DB) error {
 	log.Println("Running database migrations...")
+	// Checked before AutoMigrate adds it, so the backfill below runs once
+	addsValueStatus := db.Migrator().HasTable(&models.LookupValue{}) &&
+		!db.Migrator().HasColumn(&models.LookupValue{}, "status")
+
 	err := db.AutoMigrate(
 		&models.Permission{},
 		&models.Role{},
@@ -42,9 +47,13 @@ func Migrate(db *gorm.DB) error {
 		&models.Department{},
 		&models.User{},
 		&models.ActionLog{},
+		&models.TokenIssuance{},
 		// Lookup models
 		&models.LookupCategory{},
 		&models.LookupValue{},
+		&models.LookupValueAlias{},
+		&models.LookupValueTranslation{},
+		&models.LookupTag{},
 		// Workflow models
 		&models.Workflow{},
 		&models.WorkflowState{},
@@ -66,10 +75,100 @@ func Migrate(db *gorm.DB) error {
 	if err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
+
+	// The status column is added with default 'active'; when it is first added,
+	// carry inactive values over as archived. Later boots leave statuses alone.
+	if addsValueStatus {
+		if err := db.Exec("UPDATE lookup_values SET status = ? WHERE is_active = ? AND status = ?",
+			models.LookupValueStatusArchived, false, models.LookupValueStatusActive).Error; err != nil {
+			return fmt.Errorf("failed to migrate lookup value statuses: %w", err)
+		}
+	}
+
+	// incident_form_intent is added unset; categories on the incident form intend to be
+	if err := db.Exec("UPDATE lookup_categories SET incident_form_intent = ? WHERE add_to_incident_form = ? AND incident_form_intent = ?",
+		true, true, false).Error; err != nil {
+		return fmt.Errorf("failed to migrate lookup category incident form intent: %w", err)
+	}
+
+	if err := migrateCategoryCodeIndexes(db); err != nil {
+		return err
+	}
+	if err := migrateTagCodeIndexes(db); err != nil {
+		return err
+	}
+
+	// Full-text search over value names and descriptions. The 'simple'
+	// configuration is used because values mix English and Arabic text.
+	if db.Dialector.Name() == "postgres" {
+		if err := db.Exec(`ALTER TABLE lookup_values ADD COLUMN IF NOT EXISTS search_vector tsvector
+			GENERATED ALWAYS AS (to_tsvector('simple',
+				coalesce(name, '') || ' ' || coalesce(name_ar, '') || ' ' || coalesce(description, ''))) STORED`).Error; err != nil {
+			return fmt.Errorf("failed to add lookup value search vector: %w", err)
+		}
+		if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_lookup_values_search_vector ON lookup_values USING GIN (search_vector)").Error; err != nil {
+			return fmt.Errorf("failed to index lookup value search vector: %w", err)
+		}
+	}
+
 	log.Println("Database migrations completed")
 	return nil
 }
 
+// migrateCategoryCodeIndexes makes category codes unique per org instead of
+// globally. A unique index on (org_id, code) alone would let any number of
+// global categories (org_id NULL) share a code, so global codes get their own
+// partial index. Existing rows are checked first so a collision is reported
+// by code rather than as an index build failure.
+func migrateCategoryCodeIndexes(db *gorm.DB) error {
+	var collisions []models.LookupCodeCollision
+	err := db.Unscoped().Model(&models.LookupCategory{}).
+		Select("org_id, code, COUNT(*) AS count").
+		Group("org_id, code").
+		Having("COUNT(*) > 1").
+		Order("code ASC").
+		Scan(&collisions).Error
+	if err != nil {
+		return fmt.Errorf("failed to check lookup category codes: %w", err)
+	}
+	if len(collisions) > 0 {
+		codes := make([]string, len(collisions))
+		for i, c := range collisions {
+			codes[i] = fmt.Sprintf("%s (%d)", c.Code, c.Count)
+		}
+		return fmt.Errorf("duplicate lookup category codes within an org, resolve before migrating: %s", strings.Join(codes, ", "))
+	}
+
+	statements := []string{
+		"DROP INDEX IF EXISTS idx_lookup_categories_code",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_lookup_categories_global_code ON lookup_categories (code) WHERE org_id IS NULL",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_lookup_categories_org_code ON lookup_categories (org_id, code) WHERE org_id IS NOT NULL",
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("failed to migrate lookup category code indexes: %w", err)
+		}
+	}
+	return nil
+}
+
+// migrateTagCodeIndexes makes tag codes unique per org, with the same pair of
+// partial indexes as migrateCategoryCodeIndexes. Tag codes used to be unique
+// across all orgs, so existing rows cannot collide.
+func migrateTagCodeIndexes(db *gorm.DB) error {
+	statements := []string{
+		"DROP INDEX IF EXISTS idx_lookup_tags_code",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_lookup_tags_global_code ON lookup_tags (code) WHERE org_id IS NULL",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_lookup_tags_org_code ON lookup_tags (org_id, code) WHERE org_id IS NOT NULL",
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("failed to migrate lookup tag code indexes: %w", err)
+		}
+	}
+	return nil
+}
+
 func Seed(db *gorm.DB) error {
 	log.Println("Seeding database...")
 
@@ -170,6 +269,7 @@ func Seed(db *gorm.DB) error {
 		// Action Log permissions
 		{Name: "View Action Logs", Code: "action-logs:view", Module: "action-logs", Action: "view", Description: "View action logs"},
 		{Name: "Delete Action Logs", Code: "action-logs:delete", Module: "action-logs", Action: "delete", Description: "Delete/cleanup action logs"},
+		{Name: "Export Action Logs", Code: "action-logs:export", Module: "action-logs", Action: "export", Description: "Export the full action log as CSV"},
 
 		// Lookup permissions
 		{Name: "View Lookups", Code: "lookups:view", Module: "lookups", Action: "view", Description: "View lookup categories and values"},
@@ -275,64 +375,30 @@ func Seed(db *gorm.DB) error {
 }
 
 func seedLookupCategories(db *gorm.DB) {
-	// Priority category
-	var priorityCategory models.LookupCategory
-	result := db.Where("code = ?", "PRIORITY").First(&priorityCategory)
-	if result.Error == gorm.ErrRecordNotFound {
-		priorityCategory = models.LookupCategory{
-			Code:        "PRIORITY",
-			Name:        "Priority",
-			NameAr:      "الأولوية",
-			Description: "Incident priority levels",
-			IsSystem:    true,
-			IsActive:    true,
-		}
-		if err := db.Create(&priorityCategory).Error; err != nil {
-			log.Printf("Failed to create priority category: %v", err)
-		} else {
-			// Create priority values
-			priorityValues := []models.LookupValue{
-				{CategoryID: priorityCategory.ID, Code: "CRITICAL", Name: "Critical", NameAr: "حرج", SortOrder: 1, Color: "#EF4444", IsDefault: false, IsActive: true},
-				{CategoryID: priorityCategory.ID, Code: "HIGH", Name: "High", NameAr: "عالي", SortOrder: 2, Color: "#F97316", IsDefault: false, IsActive: true},
-				{CategoryID: priorityCategory.ID, Code: "MEDIUM", Name: "Medium", NameAr: "متوسط", SortOrder: 3, Color: "#EAB308", IsDefault: true, IsActive: true},
-				{CategoryID: priorityCategory.ID, Code: "LOW", Name: "Low", NameAr: "منخفض", SortOrder: 4, Color: "#3B82F6", IsDefault: false, IsActive: true},
-				{CategoryID: priorityCategory.ID, Code: "VERY_LOW", Name: "Very Low", NameAr: "منخفض جداً", SortOrder: 5, Color: "#6B7280", IsDefault: false, IsActive: true},
-			}
-			for _, v := range priorityValues {
-				if err := db.Create(&v).Error; err != nil {
-					log.Printf("Failed to create priority value %s: %v", v.Code, err)
-				}
-			}
+	for _, seed := range models.SystemLookupSeeds {
+		var category models.LookupCategory
+		result := db.Where("code = ?", seed.Code).First(&category)
+		if result.Error != gorm.ErrRecordNotFound {
+			continue
 		}
-	}
 
-	// Severity category
-	var severityCategory models.LookupCategory
-	result = db.Where("code = ?", "SEVERITY").First(&severityCategory)
-	if result.Error == gorm.ErrRecordNotFound {
-		severityCategory = models.LookupCategory{
-			Code:        "SEVERITY",
-			Name:        "Severity",
-			NameAr:      "الخطورة",
-			Description: "Incident severity levels",
+		category = models.LookupCategory{
+			Code:        seed.Code,
+			Name:        seed.Name,
+			NameAr:      seed.NameAr,
+			Description: seed.Description,
 			IsSystem:    true,
 			IsActive:    true,
 		}
-		if err := db.Create(&severityCategory).Error; err != nil {
-			log.Printf("Failed to create severity category: %v", err)
-		} else {
-			// Create severity values
-			severityValues := []models.LookupValue{
-				{CategoryID: severityCategory.ID, Code: "CRITICAL", Name: "Critical", NameAr: "حرج", SortOrder: 1, Color: "#EF4444", IsDefault: false, IsActive: true},
-				{CategoryID: severityCategory.ID, Code: "MAJOR", Name: "Major", NameAr: "رئيسي", SortOrder: 2, Color: "#F97316", IsDefault: false, IsActive: true},
-				{CategoryID: severityCategory.ID, Code: "MODERATE", Name: "Moderate", NameAr: "معتدل", SortOrder: 3, Color: "#EAB308", IsDefault: true, IsActive: true},
-				{CategoryID: severityCategory.ID, Code: "MINOR", Name: "Minor", NameAr: "ثانوي", SortOrder: 4, Color: "#3B82F6", IsDefault: false, IsActive: true},
-				{CategoryID: severityCategory.ID, Code: "COSMETIC", Name: "Cosmetic", NameAr: "تجميلي", SortOrder: 5, Color: "#6B7280", IsDefault: false, IsActive: true},
-			}
-			for _, v := range severityValues {
-				if err := db.Create(&v).Error; err != nil {
-					log.Printf("Failed to create severity value %s: %v", v.Code, err)
-				}
+		if err := db.Create(&category).Error; err != nil {
+			log.Printf("Failed to create %s category: %v", seed.Code, err)
+			continue
+		}
+
+		for _, valueSeed := range seed.Values {
+			v := valueSeed.NewValue(&category)
+			if err := db.Create(&v).Error; err != nil {
+				log.Printf("Failed to create %s value %s: %v", seed.Code, v.Code, err)
 			}
 		}
 	}
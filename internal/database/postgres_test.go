@@ -0,0 +1,116 @@
+package database
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/automax/backend/internal/models"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB opens an unmigrated in-memory SQLite database private to the test
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", url.PathEscape(t.Name()))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func TestMigrateBackfillsValueStatusOnlyWhenAddingTheColumn(t *testing.T) {
+	db := newTestDB(t)
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	category := &models.LookupCategory{Code: "PRIORITY", Name: "Priority", IsActive: true}
+	if err := db.Create(category).Error; err != nil {
+		t.Fatalf("create category: %v", err)
+	}
+	value := &models.LookupValue{CategoryID: category.ID, Code: "HIGH", Name: "High", IsActive: true}
+	if err := db.Omit("Category").Create(value).Error; err != nil {
+		t.Fatalf("create value: %v", err)
+	}
+	status := func() string {
+		t.Helper()
+		var s string
+		if err := db.Model(&models.LookupValue{}).Where("id = ?", value.ID).Pluck("status", &s).Error; err != nil {
+			t.Fatalf("read status: %v", err)
+		}
+		return s
+	}
+
+	// A database from before the status column, holding an inactive value
+	if err := db.Migrator().DropIndex(&models.LookupValue{}, "Status"); err != nil {
+		t.Fatalf("drop status index: %v", err)
+	}
+	if err := db.Migrator().DropColumn(&models.LookupValue{}, "status"); err != nil {
+		t.Fatalf("drop status column: %v", err)
+	}
+	if err := db.Exec("UPDATE lookup_values SET is_active = ? WHERE id = ?", false, value.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate adding status: %v", err)
+	}
+	if got := status(); got != models.LookupValueStatusArchived {
+		t.Errorf("status after adding the column = %q, want archived", got)
+	}
+
+	// Later boots leave the statuses as they are
+	if err := db.Exec("UPDATE lookup_values SET status = ? WHERE id = ?", models.LookupValueStatusActive, value.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate again: %v", err)
+	}
+	if got := status(); got != models.LookupValueStatusActive {
+		t.Errorf("status after a later boot = %q, want it left active", got)
+	}
+}
+
+func TestVerifySchemaListsWhatIsMissing(t *testing.T) {
+	db := newTestDB(t)
+	err := VerifySchema(db)
+	if err == nil || !strings.Contains(err.Error(), "table lookup_categories") || !strings.Contains(err.Error(), "table lookup_values") {
+		t.Fatalf("VerifySchema before migrating = %v, want both tables reported", err)
+	}
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if err := VerifySchema(db); err != nil {
+		t.Fatalf("VerifySchema after migrating: %v", err)
+	}
+
+	// A database one deploy behind the code
+	if err := db.Migrator().DropIndex(&models.LookupCategory{}, lookupCodeIndexes[0]); err != nil {
+		t.Fatalf("drop index: %v", err)
+	}
+	if err := db.Migrator().DropColumn(&models.LookupCategory{}, "add_to_incident_form"); err != nil {
+		t.Fatalf("drop column: %v", err)
+	}
+	err = VerifySchema(db)
+	for _, want := range []string{"lookup_categories.add_to_incident_form", "lookup_categories index " + lookupCodeIndexes[0]} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("VerifySchema = %v, want %s reported", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "lookup_values") {
+		t.Errorf("VerifySchema = %v, want only what was dropped", err)
+	}
+	if db.Migrator().HasColumn(&models.LookupCategory{}, "add_to_incident_form") {
+		t.Error("VerifySchema added the missing column, want it only reported")
+	}
+}
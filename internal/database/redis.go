@@ -93,6 +93,14 @@ func (s *SessionStore) BlacklistToken(ctx context.Context, token string, expirat
 	return s.client.Set(ctx, key, "1", expiration).Err()
 }
 
+// ClaimToken blacklists token unless it already is, in one atomic SET NX, and
+// reports whether this call blacklisted it. A single-use token is spent by the
+// caller that claims it; false means it was used before.
+func (s *SessionStore) ClaimToken(ctx context.Context, token string, expiration time.Duration) (bool, error) {
+	key := fmt.Sprintf("blacklist:%s", token)
+	return s.client.SetNX(ctx, key, "1", expiration).Result()
+}
+
 func (s *SessionStore) IsTokenBlacklisted(ctx context.Context, token string) (bool, error) {
 	key := fmt.Sprintf("blacklist:%s", token)
 	return s.Exists(ctx, key)
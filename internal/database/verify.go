@@ -0,0 +1,85 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/automax/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// lookupCodeIndexes are the category code indexes created by
+// migrateCategoryCodeIndexes rather than declared on the model
+var lookupCodeIndexes = []string{"idx_lookup_categories_global_code", "idx_lookup_categories_org_code"}
+
+// VerifySchema checks that the tables, columns, indexes and join tables the
+// lookup models expect exist, and returns an error listing everything missing.
+// It only reads the schema; run Migrate to create what is missing. Calling it
+// at boot turns a pending migration into one clear startup error instead of
+// confusing query failures at runtime.
+func VerifySchema(db *gorm.DB) error {
+	var missing []string
+	for _, model := range []interface{}{&models.LookupCategory{}, &models.LookupValue{}} {
+		m, err := missingSchema(db, model)
+		if err != nil {
+			return err
+		}
+		missing = append(missing, m...)
+	}
+
+	migrator := db.Migrator()
+	for _, index := range lookupCodeIndexes {
+		if !migrator.HasIndex(&models.LookupCategory{}, index) {
+			missing = append(missing, "lookup_categories index "+index)
+		}
+	}
+	if db.Dialector.Name() == "postgres" {
+		if !migrator.HasColumn(&models.LookupValue{}, "search_vector") {
+			missing = append(missing, "lookup_values.search_vector")
+		}
+		if !migrator.HasIndex(&models.LookupValue{}, "idx_lookup_values_search_vector") {
+			missing = append(missing, "lookup_values index idx_lookup_values_search_vector")
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("database schema is behind the code, run migrations; missing: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// missingSchema lists the table, columns, indexes and many2many join tables of
+// model that the database lacks
+func missingSchema(db *gorm.DB, model interface{}) ([]string, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, fmt.Errorf("failed to parse %T: %w", model, err)
+	}
+	table := stmt.Schema.Table
+	migrator := db.Migrator()
+
+	if !migrator.HasTable(model) {
+		return []string{"table " + table}, nil
+	}
+
+	var missing []string
+	for _, field := range stmt.Schema.Fields {
+		if field.DBName == "" || field.IgnoreMigration {
+			continue
+		}
+		if !migrator.HasColumn(model, field.DBName) {
+			missing = append(missing, table+"."+field.DBName)
+		}
+	}
+	for _, index := range stmt.Schema.ParseIndexes() {
+		if !migrator.HasIndex(model, index.Name) {
+			missing = append(missing, table+" index "+index.Name)
+		}
+	}
+	for _, rel := range stmt.Schema.Relationships.Relations {
+		if rel.JoinTable != nil && !migrator.HasTable(rel.JoinTable.Table) {
+			missing = append(missing, "join table "+rel.JoinTable.Table)
+		}
+	}
+	return missing, nil
+}
@@ -1,6 +1,10 @@
 package handlers
 
 import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
 	"strconv"
 	"time"
 
@@ -26,6 +30,68 @@ func NewActionLogHandler(service services.ActionLogService, validator *validator
 
 // ListActionLogs handles GET /admin/action-logs
 func (h *ActionLogHandler) ListActionLogs(c *fiber.Ctx) error {
+	filter := parseActionLogFilter(c)
+
+	logs, total, err := h.service.ListActionLogs(c.UserContext(), filter)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	totalPages := (int(total) + filter.Limit - 1) / filter.Limit
+
+	return c.JSON(fiber.Map{
+		"success":     true,
+		"data":        logs,
+		"total_items": total,
+		"total_pages": totalPages,
+		"page":        filter.Page,
+		"limit":       filter.Limit,
+	})
+}
+
+// ExportActionLogs handles GET /audit/export.csv. It accepts the same filters
+// as ListActionLogs and streams every matching row.
+func (h *ActionLogHandler) ExportActionLogs(c *fiber.Ctx) error {
+	filter := parseActionLogFilter(c)
+
+	c.Set("Content-Type", "text/csv; charset=utf-8")
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=action_logs_%s.csv", time.Now().Format("20060102_150405")))
+
+	// The body is written after the handler returns, when middleware may have
+	// canceled the request context, so the stream keeps its values but is
+	// canceled by the client going away instead.
+	ctx, cancel := context.WithCancel(context.WithoutCancel(c.UserContext()))
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+		if err := h.service.ExportActionLogsCSV(ctx, filter, &cancelOnErrorWriter{w: w, cancel: cancel}); err != nil {
+			log.Printf("action log export failed: %v", err)
+		}
+		w.Flush()
+	})
+
+	return nil
+}
+
+// cancelOnErrorWriter cancels the export as soon as a write to the client
+// fails, so a dropped connection stops the remaining page queries.
+type cancelOnErrorWriter struct {
+	w      *bufio.Writer
+	cancel context.CancelFunc
+}
+
+func (cw *cancelOnErrorWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	if err == nil {
+		err = cw.w.Flush()
+	}
+	if err != nil {
+		cw.cancel()
+	}
+	return n, err
+}
+
+// parseActionLogFilter reads the action log filters from the query string
+func parseActionLogFilter(c *fiber.Ctx) *models.ActionLogFilter {
 	filter := &models.ActionLogFilter{
 		Page:  1,
 		Limit: 20,
@@ -75,32 +141,17 @@ func (h *ActionLogHandler) ListActionLogs(c *fiber.Ctx) error {
 		}
 	}
 
-	logs, total, err := h.service.ListActionLogs(c.Context(), filter)
-	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
-	}
-
-	totalPages := (int(total) + filter.Limit - 1) / filter.Limit
-
-	return c.JSON(fiber.Map{
-		"success":     true,
-		"data":        logs,
-		"total_items": total,
-		"total_pages": totalPages,
-		"page":        filter.Page,
-		"limit":       filter.Limit,
-	})
+	return filter
 }
 
 // GetActionLog handles GET /admin/action-logs/:id
 func (h *ActionLogHandler) GetActionLog(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid action log ID")
+		return err
 	}
 
-	log, err := h.service.GetActionLog(c.Context(), id)
+	log, err := h.service.GetActionLog(c.UserContext(), id)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "Action log not found")
 	}
@@ -110,9 +161,9 @@ func (h *ActionLogHandler) GetActionLog(c *fiber.Ctx) error {
 
 // GetStats handles GET /admin/action-logs/stats
 func (h *ActionLogHandler) GetStats(c *fiber.Ctx) error {
-	stats, err := h.service.GetStats(c.Context())
+	stats, err := h.service.GetStats(c.UserContext())
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Stats retrieved successfully", stats)
@@ -120,9 +171,9 @@ func (h *ActionLogHandler) GetStats(c *fiber.Ctx) error {
 
 // GetFilterOptions handles GET /admin/action-logs/filter-options
 func (h *ActionLogHandler) GetFilterOptions(c *fiber.Ctx) error {
-	options, err := h.service.GetFilterOptions(c.Context())
+	options, err := h.service.GetFilterOptions(c.UserContext())
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Filter options retrieved successfully", options)
@@ -130,18 +181,17 @@ func (h *ActionLogHandler) GetFilterOptions(c *fiber.Ctx) error {
 
 // GetUserActions handles GET /admin/action-logs/user/:id
 func (h *ActionLogHandler) GetUserActions(c *fiber.Ctx) error {
-	userIDStr := c.Params("id")
-	userID, err := uuid.Parse(userIDStr)
+	userID, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid user ID")
+		return err
 	}
 
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	limit, _ := strconv.Atoi(c.Query("limit", "20"))
 
-	logs, total, err := h.service.GetUserActions(c.Context(), userID, page, limit)
+	logs, total, err := h.service.GetUserActions(c.UserContext(), userID, page, limit)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	totalPages := (int(total) + limit - 1) / limit
@@ -163,9 +213,9 @@ func (h *ActionLogHandler) CleanupOldLogs(c *fiber.Ctx) error {
 		retentionDays = 7 // Minimum 7 days retention
 	}
 
-	deleted, err := h.service.CleanupOldLogs(c.Context(), retentionDays)
+	deleted, err := h.service.CleanupOldLogs(c.UserContext(), retentionDays)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Old logs cleaned up", fiber.Map{
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"sort"
+
+	"github.com/automax/backend/internal/models"
+	"github.com/automax/backend/internal/repository"
+	"github.com/automax/backend/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuthKeysHandler reports the access token signing keys so operators can check
+// a key rotation before retiring the old key
+type AuthKeysHandler struct {
+	jwtManager   *utils.JWTManager
+	issuanceRepo repository.TokenIssuanceRepository
+}
+
+func NewAuthKeysHandler(jwtManager *utils.JWTManager, issuanceRepo repository.TokenIssuanceRepository) *AuthKeysHandler {
+	return &AuthKeysHandler{
+		jwtManager:   jwtManager,
+		issuanceRepo: issuanceRepo,
+	}
+}
+
+// ListKeys lists the configured signing keys, which one signs new tokens and
+// how many unexpired tokens each signed. A key is safe to retire once its live
+// token count reaches zero; kids that have live tokens but are no longer
+// configured are listed as unconfigured.
+func (h *AuthKeysHandler) ListKeys(c *fiber.Ctx) error {
+	live, err := h.issuanceRepo.CountLiveByKID(c.UserContext())
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	resp := models.SigningKeysResponse{
+		Keys:         []models.SigningKeyStatus{},
+		Unconfigured: []models.SigningKeyStatus{},
+	}
+	for _, key := range h.jwtManager.SigningKeys() {
+		resp.Keys = append(resp.Keys, models.SigningKeyStatus{
+			KID:        key.KID,
+			Active:     key.Active,
+			Configured: true,
+			LiveTokens: live[key.KID],
+		})
+		delete(live, key.KID)
+	}
+	for kid, count := range live {
+		resp.Unconfigured = append(resp.Unconfigured, models.SigningKeyStatus{KID: kid, LiveTokens: count})
+	}
+	sort.Slice(resp.Unconfigured, func(a, b int) bool { return resp.Unconfigured[a].KID < resp.Unconfigured[b].KID })
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Signing keys retrieved", resp)
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/automax/backend/internal/models"
+	"github.com/automax/backend/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// liveTokenCounts reports fixed live token counts per kid
+type liveTokenCounts map[string]int64
+
+func (l liveTokenCounts) CountLiveByKID(ctx context.Context) (map[string]int64, error) {
+	counts := make(map[string]int64, len(l))
+	for kid, n := range l {
+		counts[kid] = n
+	}
+	return counts, nil
+}
+
+func TestListKeysReportsTheActiveKeyAndLiveTokens(t *testing.T) {
+	jwtManager := utils.NewJWTManager("test-secret", 1)
+	jwtManager.SetSigningKeys(map[string]string{"2026-01": "old-secret", "2026-07": "new-secret"}, "2026-07")
+	h := NewAuthKeysHandler(jwtManager, liveTokenCounts{"2026-01": 4, "2026-07": 9, "2025-07": 1})
+	app := fiber.New()
+	app.Get("/auth/keys", h.ListKeys)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/auth/keys", nil))
+	if err != nil {
+		t.Fatalf("GET /auth/keys: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("GET /auth/keys = %d, want 200", resp.StatusCode)
+	}
+	var envelope struct {
+		Data models.SigningKeysResponse `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		t.Fatalf("decode /auth/keys: %v", err)
+	}
+
+	want := []models.SigningKeyStatus{
+		{KID: "", Configured: true},
+		{KID: "2026-01", Configured: true, LiveTokens: 4},
+		{KID: "2026-07", Active: true, Configured: true, LiveTokens: 9},
+	}
+	keys := envelope.Data.Keys
+	if len(keys) != len(want) {
+		t.Fatalf("keys = %+v, want %+v", keys, want)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Errorf("keys = %+v, want %+v", keys, want)
+			break
+		}
+	}
+	unconfigured := envelope.Data.Unconfigured
+	if len(unconfigured) != 1 || unconfigured[0] != (models.SigningKeyStatus{KID: "2025-07", LiveTokens: 1}) {
+		t.Errorf("unconfigured = %+v, want the retired 2025-07 with its live token", unconfigured)
+	}
+}
@@ -22,7 +22,7 @@ type ClassificationHandler struct {
 func NewClassificationHandler(repo repository.ClassificationRepository) *ClassificationHandler {
 	return &ClassificationHandler{
 		repo:      repo,
-		validator: validator.New(),
+		validator: utils.NewValidator(),
 	}
 }
 
@@ -33,7 +33,7 @@ func (h *ClassificationHandler) Create(c *fiber.Ctx) error {
 	}
 
 	if err := h.validator.Struct(&req); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return utils.FormatValidationError(c, err)
 	}
 
 	classType := "both"
@@ -50,21 +50,20 @@ func (h *ClassificationHandler) Create(c *fiber.Ctx) error {
 		IsActive:    true,
 	}
 
-	if err := h.repo.Create(c.Context(), classification); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	if err := h.repo.Create(c.UserContext(), classification); err != nil {
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusCreated, "Classification created", models.ToClassificationResponse(classification))
 }
 
 func (h *ClassificationHandler) GetByID(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
-	classification, err := h.repo.FindByID(c.Context(), id)
+	classification, err := h.repo.FindByID(c.UserContext(), id)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "Classification not found")
 	}
@@ -73,10 +72,9 @@ func (h *ClassificationHandler) GetByID(c *fiber.Ctx) error {
 }
 
 func (h *ClassificationHandler) Update(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
 	var req models.ClassificationUpdateRequest
@@ -84,7 +82,7 @@ func (h *ClassificationHandler) Update(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	classification, err := h.repo.FindByID(c.Context(), id)
+	classification, err := h.repo.FindByID(c.UserContext(), id)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "Classification not found")
 	}
@@ -105,22 +103,21 @@ func (h *ClassificationHandler) Update(c *fiber.Ctx) error {
 		classification.SortOrder = *req.SortOrder
 	}
 
-	if err := h.repo.Update(c.Context(), classification); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	if err := h.repo.Update(c.UserContext(), classification); err != nil {
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Classification updated", models.ToClassificationResponse(classification))
 }
 
 func (h *ClassificationHandler) Delete(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
-	if err := h.repo.Delete(c.Context(), id); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	if err := h.repo.Delete(c.UserContext(), id); err != nil {
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Classification deleted", nil)
@@ -132,12 +129,12 @@ func (h *ClassificationHandler) List(c *fiber.Ctx) error {
 
 	classType := c.Query("type")
 	if classType != "" {
-		classifications, err = h.repo.ListByType(c.Context(), classType)
+		classifications, err = h.repo.ListByType(c.UserContext(), classType)
 	} else {
-		classifications, err = h.repo.List(c.Context())
+		classifications, err = h.repo.List(c.UserContext())
 	}
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	responses := make([]models.ClassificationResponse, len(classifications))
@@ -154,12 +151,12 @@ func (h *ClassificationHandler) GetTree(c *fiber.Ctx) error {
 
 	classType := c.Query("type")
 	if classType != "" {
-		tree, err = h.repo.GetTreeByType(c.Context(), classType)
+		tree, err = h.repo.GetTreeByType(c.UserContext(), classType)
 	} else {
-		tree, err = h.repo.GetTree(c.Context())
+		tree, err = h.repo.GetTree(c.UserContext())
 	}
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	responses := make([]models.ClassificationResponse, len(tree))
@@ -177,17 +174,17 @@ func (h *ClassificationHandler) GetChildren(c *fiber.Ctx) error {
 	var err error
 
 	if parentIDStr == "" {
-		children, err = h.repo.GetByParentID(c.Context(), nil)
+		children, err = h.repo.GetByParentID(c.UserContext(), nil)
 	} else {
 		parentID, parseErr := uuid.Parse(parentIDStr)
 		if parseErr != nil {
 			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid parent ID")
 		}
-		children, err = h.repo.GetByParentID(c.Context(), &parentID)
+		children, err = h.repo.GetByParentID(c.UserContext(), &parentID)
 	}
 
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	responses := make([]models.ClassificationResponse, len(children))
@@ -200,9 +197,9 @@ func (h *ClassificationHandler) GetChildren(c *fiber.Ctx) error {
 
 // Export exports all classifications as JSON
 func (h *ClassificationHandler) Export(c *fiber.Ctx) error {
-	classifications, err := h.repo.List(c.Context())
+	classifications, err := h.repo.List(c.UserContext())
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	// Filter out invalid records (with corrupted paths or invalid UUIDs)
@@ -310,7 +307,7 @@ func (h *ClassificationHandler) Import(c *fiber.Ctx) error {
 			SortOrder:   data.SortOrder,
 		}
 
-		if err := h.repo.Create(c.Context(), classification); err != nil {
+		if err := h.repo.Create(c.UserContext(), classification); err != nil {
 			skipped++
 			errors = append(errors, data.Name+" (Level "+fmt.Sprintf("%d", data.Level)+") - "+err.Error())
 		} else {
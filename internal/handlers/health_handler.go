@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"github.com/automax/backend/pkg/utils"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -22,3 +23,16 @@ func (h *HealthHandler) Ready(c *fiber.Ctx) error {
 		"status": "ready",
 	})
 }
+
+// Version reports the API versions served. Like the health checks it is sent
+// without the envelope, so clients can read it before they know which envelope
+// version to ask for. It is public, so the build is left to BuildInfo.
+func (h *HealthHandler) Version(c *fiber.Ctx) error {
+	return c.JSON(utils.ReadAPIVersionInfo())
+}
+
+// BuildInfo reports the API versions served and the build of the binary, for
+// admins checking what is deployed
+func (h *HealthHandler) BuildInfo(c *fiber.Ctx) error {
+	return c.JSON(utils.ReadBuildInfo())
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/automax/backend/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// getJSONFields requests path and returns the top-level fields of the JSON
+// object in the response
+func getJSONFields(t *testing.T, app *fiber.App, path string) map[string]json.RawMessage {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil))
+	if err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("GET %s = %d, want 200", path, resp.StatusCode)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&fields); err != nil {
+		t.Fatalf("decode %s: %v", path, err)
+	}
+	return fields
+}
+
+func TestVersionPublishesOnlyTheAPIVersions(t *testing.T) {
+	h := NewHealthHandler()
+	app := fiber.New()
+	app.Get("/version", h.Version)
+	app.Get("/admin/version", h.BuildInfo)
+
+	public := getJSONFields(t, app, "/version")
+	names := make([]string, 0, len(public))
+	for name := range public {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if got, want := strings.Join(names, ","), "api_version,supported_api_versions"; got != want {
+		t.Errorf("GET /version fields = %s, want %s", got, want)
+	}
+	var version string
+	if err := json.Unmarshal(public["api_version"], &version); err != nil || version != utils.APIVersion {
+		t.Errorf("GET /version api_version = %s, want %q", public["api_version"], utils.APIVersion)
+	}
+
+	build := getJSONFields(t, app, "/admin/version")
+	for _, name := range []string{"api_version", "supported_api_versions", "version"} {
+		if _, ok := build[name]; !ok {
+			t.Errorf("GET /admin/version has no %s", name)
+		}
+	}
+}
@@ -27,14 +27,14 @@ func NewIncidentHandler(service services.IncidentService, userRepo repository.Us
 		service:   service,
 		userRepo:  userRepo,
 		storage:   storage,
-		validator: validator.New(),
+		validator: utils.NewValidator(),
 	}
 }
 
 // Helper to get user's role IDs
 func (h *IncidentHandler) getUserRoleIDs(c *fiber.Ctx) []uuid.UUID {
 	userID := c.Locals("user_id").(uuid.UUID)
-	roles, err := h.userRepo.GetUserRoles(c.Context(), userID)
+	roles, err := h.userRepo.GetUserRoles(c.UserContext(), userID)
 	if err != nil {
 		return []uuid.UUID{}
 	}
@@ -62,22 +62,21 @@ func (h *IncidentHandler) CreateIncident(c *fiber.Ctx) error {
 
 	userID := c.Locals("user_id").(uuid.UUID)
 
-	incident, err := h.service.CreateIncident(c.Context(), &req, userID)
+	incident, err := h.service.CreateIncident(c.UserContext(), &req, userID)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusCreated, "Incident created", incident)
 }
 
 func (h *IncidentHandler) GetIncident(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
-	incident, err := h.service.GetIncident(c.Context(), id)
+	incident, err := h.service.GetIncident(c.UserContext(), id)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "Incident not found")
 	}
@@ -166,9 +165,9 @@ func (h *IncidentHandler) ListIncidents(c *fiber.Ctx) error {
 		}
 	}
 
-	incidents, total, err := h.service.ListIncidents(c.Context(), filter)
+	incidents, total, err := h.service.ListIncidents(c.UserContext(), filter)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	totalPages := (int(total) + filter.Limit - 1) / filter.Limit
@@ -184,10 +183,9 @@ func (h *IncidentHandler) ListIncidents(c *fiber.Ctx) error {
 }
 
 func (h *IncidentHandler) UpdateIncident(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
 	userID := c.Locals("user_id").(uuid.UUID)
@@ -197,23 +195,22 @@ func (h *IncidentHandler) UpdateIncident(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	incident, err := h.service.UpdateIncident(c.Context(), id, &req, userID)
+	incident, err := h.service.UpdateIncident(c.UserContext(), id, &req, userID)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Incident updated", incident)
 }
 
 func (h *IncidentHandler) DeleteIncident(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
-	if err := h.service.DeleteIncident(c.Context(), id); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	if err := h.service.DeleteIncident(c.UserContext(), id); err != nil {
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Incident deleted", nil)
@@ -221,10 +218,9 @@ func (h *IncidentHandler) DeleteIncident(c *fiber.Ctx) error {
 
 // ConvertToRequest converts an incident to a request
 func (h *IncidentHandler) ConvertToRequest(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
 	var req models.ConvertToRequestRequest
@@ -233,13 +229,13 @@ func (h *IncidentHandler) ConvertToRequest(c *fiber.Ctx) error {
 	}
 
 	if err := h.validator.Struct(&req); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return utils.FormatValidationError(c, err)
 	}
 
 	userID := c.Locals("user_id").(uuid.UUID)
 	roleIDs := h.getUserRoleIDs(c)
 
-	result, err := h.service.ConvertToRequest(c.Context(), id, &req, userID, roleIDs)
+	result, err := h.service.ConvertToRequest(c.UserContext(), id, &req, userID, roleIDs)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
 	}
@@ -249,17 +245,16 @@ func (h *IncidentHandler) ConvertToRequest(c *fiber.Ctx) error {
 
 // CanConvertToRequest checks if the user can convert the incident to a request
 func (h *IncidentHandler) CanConvertToRequest(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
 	roleIDs := h.getUserRoleIDs(c)
 
-	canConvert, reason, err := h.service.CanConvertToRequest(c.Context(), id, roleIDs)
+	canConvert, reason, err := h.service.CanConvertToRequest(c.UserContext(), id, roleIDs)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Permission check completed", fiber.Map{
@@ -271,10 +266,9 @@ func (h *IncidentHandler) CanConvertToRequest(c *fiber.Ctx) error {
 // State transitions
 
 func (h *IncidentHandler) ExecuteTransition(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
 	var req models.IncidentTransitionRequest
@@ -283,13 +277,13 @@ func (h *IncidentHandler) ExecuteTransition(c *fiber.Ctx) error {
 	}
 
 	if err := h.validator.Struct(&req); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return utils.FormatValidationError(c, err)
 	}
 
 	userID := c.Locals("user_id").(uuid.UUID)
 	roleIDs := h.getUserRoleIDs(c)
 
-	incident, err := h.service.ExecuteTransition(c.Context(), id, &req, userID, roleIDs)
+	incident, err := h.service.ExecuteTransition(c.UserContext(), id, &req, userID, roleIDs)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
 	}
@@ -298,32 +292,30 @@ func (h *IncidentHandler) ExecuteTransition(c *fiber.Ctx) error {
 }
 
 func (h *IncidentHandler) GetAvailableTransitions(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
 	roleIDs := h.getUserRoleIDs(c)
 
-	transitions, err := h.service.GetAvailableTransitions(c.Context(), id, roleIDs)
+	transitions, err := h.service.GetAvailableTransitions(c.UserContext(), id, roleIDs)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Available transitions retrieved", transitions)
 }
 
 func (h *IncidentHandler) GetTransitionHistory(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
-	history, err := h.service.GetTransitionHistory(c.Context(), id)
+	history, err := h.service.GetTransitionHistory(c.UserContext(), id)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Transition history retrieved", history)
@@ -332,10 +324,9 @@ func (h *IncidentHandler) GetTransitionHistory(c *fiber.Ctx) error {
 // Comments
 
 func (h *IncidentHandler) AddComment(c *fiber.Ctx) error {
-	incidentIDStr := c.Params("id")
-	incidentID, err := uuid.Parse(incidentIDStr)
+	incidentID, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid incident ID")
+		return err
 	}
 
 	var req models.IncidentCommentRequest
@@ -344,39 +335,37 @@ func (h *IncidentHandler) AddComment(c *fiber.Ctx) error {
 	}
 
 	if err := h.validator.Struct(&req); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return utils.FormatValidationError(c, err)
 	}
 
 	userID := c.Locals("user_id").(uuid.UUID)
 
-	comment, err := h.service.AddComment(c.Context(), incidentID, &req, userID)
+	comment, err := h.service.AddComment(c.UserContext(), incidentID, &req, userID)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusCreated, "Comment added", comment)
 }
 
 func (h *IncidentHandler) ListComments(c *fiber.Ctx) error {
-	incidentIDStr := c.Params("id")
-	incidentID, err := uuid.Parse(incidentIDStr)
+	incidentID, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid incident ID")
+		return err
 	}
 
-	comments, err := h.service.ListComments(c.Context(), incidentID)
+	comments, err := h.service.ListComments(c.UserContext(), incidentID)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Comments retrieved", comments)
 }
 
 func (h *IncidentHandler) UpdateComment(c *fiber.Ctx) error {
-	commentIDStr := c.Params("comment_id")
-	commentID, err := uuid.Parse(commentIDStr)
+	commentID, err := utils.ParseUUIDParam(c, "comment_id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid comment ID")
+		return err
 	}
 
 	var req models.IncidentCommentRequest
@@ -386,7 +375,7 @@ func (h *IncidentHandler) UpdateComment(c *fiber.Ctx) error {
 
 	userID := c.Locals("user_id").(uuid.UUID)
 
-	comment, err := h.service.UpdateComment(c.Context(), commentID, &req, userID)
+	comment, err := h.service.UpdateComment(c.UserContext(), commentID, &req, userID)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
 	}
@@ -395,15 +384,14 @@ func (h *IncidentHandler) UpdateComment(c *fiber.Ctx) error {
 }
 
 func (h *IncidentHandler) DeleteComment(c *fiber.Ctx) error {
-	commentIDStr := c.Params("comment_id")
-	commentID, err := uuid.Parse(commentIDStr)
+	commentID, err := utils.ParseUUIDParam(c, "comment_id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid comment ID")
+		return err
 	}
 
 	userID := c.Locals("user_id").(uuid.UUID)
 
-	if err := h.service.DeleteComment(c.Context(), commentID, userID); err != nil {
+	if err := h.service.DeleteComment(c.UserContext(), commentID, userID); err != nil {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
 	}
 
@@ -413,10 +401,9 @@ func (h *IncidentHandler) DeleteComment(c *fiber.Ctx) error {
 // Attachments
 
 func (h *IncidentHandler) UploadAttachment(c *fiber.Ctx) error {
-	incidentIDStr := c.Params("id")
-	incidentID, err := uuid.Parse(incidentIDStr)
+	incidentID, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid incident ID")
+		return err
 	}
 
 	file, err := c.FormFile("file")
@@ -433,7 +420,7 @@ func (h *IncidentHandler) UploadAttachment(c *fiber.Ctx) error {
 
 	// Upload to storage
 	folder := fmt.Sprintf("incidents/%s", incidentID.String())
-	filePath, err := h.storage.UploadFile(c.Context(), src, file, folder)
+	filePath, err := h.storage.UploadFile(c.UserContext(), src, file, folder)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to upload file")
 	}
@@ -448,39 +435,37 @@ func (h *IncidentHandler) UploadAttachment(c *fiber.Ctx) error {
 		UploadedByID: userID,
 	}
 
-	result, err := h.service.AddAttachment(c.Context(), incidentID, attachment)
+	result, err := h.service.AddAttachment(c.UserContext(), incidentID, attachment)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusCreated, "Attachment uploaded", result)
 }
 
 func (h *IncidentHandler) ListAttachments(c *fiber.Ctx) error {
-	incidentIDStr := c.Params("id")
-	incidentID, err := uuid.Parse(incidentIDStr)
+	incidentID, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid incident ID")
+		return err
 	}
 
-	attachments, err := h.service.ListAttachments(c.Context(), incidentID)
+	attachments, err := h.service.ListAttachments(c.UserContext(), incidentID)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Attachments retrieved", attachments)
 }
 
 func (h *IncidentHandler) DeleteAttachment(c *fiber.Ctx) error {
-	attachmentIDStr := c.Params("attachment_id")
-	attachmentID, err := uuid.Parse(attachmentIDStr)
+	attachmentID, err := utils.ParseUUIDParam(c, "attachment_id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid attachment ID")
+		return err
 	}
 
 	userID := c.Locals("user_id").(uuid.UUID)
 
-	if err := h.service.DeleteAttachment(c.Context(), attachmentID, userID); err != nil {
+	if err := h.service.DeleteAttachment(c.UserContext(), attachmentID, userID); err != nil {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
 	}
 
@@ -488,18 +473,17 @@ func (h *IncidentHandler) DeleteAttachment(c *fiber.Ctx) error {
 }
 
 func (h *IncidentHandler) DownloadAttachment(c *fiber.Ctx) error {
-	attachmentIDStr := c.Params("attachment_id")
-	attachmentID, err := uuid.Parse(attachmentIDStr)
+	attachmentID, err := utils.ParseUUIDParam(c, "attachment_id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid attachment ID")
+		return err
 	}
 
-	attachment, err := h.service.GetAttachment(c.Context(), attachmentID)
+	attachment, err := h.service.GetAttachment(c.UserContext(), attachmentID)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "Attachment not found")
 	}
 
-	file, err := h.storage.GetFile(c.Context(), attachment.FilePath)
+	file, err := h.storage.GetFile(c.UserContext(), attachment.FilePath)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to retrieve file")
 	}
@@ -512,10 +496,9 @@ func (h *IncidentHandler) DownloadAttachment(c *fiber.Ctx) error {
 // Assignment
 
 func (h *IncidentHandler) AssignIncident(c *fiber.Ctx) error {
-	incidentIDStr := c.Params("id")
-	incidentID, err := uuid.Parse(incidentIDStr)
+	incidentID, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid incident ID")
+		return err
 	}
 
 	var req struct {
@@ -532,9 +515,9 @@ func (h *IncidentHandler) AssignIncident(c *fiber.Ctx) error {
 
 	userID := c.Locals("user_id").(uuid.UUID)
 
-	incident, err := h.service.AssignIncident(c.Context(), incidentID, assigneeID, userID)
+	incident, err := h.service.AssignIncident(c.UserContext(), incidentID, assigneeID, userID)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Incident assigned", incident)
@@ -565,9 +548,9 @@ func (h *IncidentHandler) GetStats(c *fiber.Ctx) error {
 	// Add user role IDs for state visibility filtering
 	filter.UserRoleIDs = h.getUserRoleIDs(c)
 
-	stats, err := h.service.GetStats(c.Context(), filter)
+	stats, err := h.service.GetStats(c.UserContext(), filter)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Stats retrieved", stats)
@@ -582,9 +565,9 @@ func (h *IncidentHandler) GetMyAssigned(c *fiber.Ctx) error {
 	limit, _ := strconv.Atoi(c.Query("limit", "20"))
 	recordType := c.Query("record_type", "") // Optional filter: incident, request, complaint
 
-	incidents, total, err := h.service.GetMyAssigned(c.Context(), userID, recordType, page, limit)
+	incidents, total, err := h.service.GetMyAssigned(c.UserContext(), userID, recordType, page, limit)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	totalPages := (int(total) + limit - 1) / limit
@@ -606,9 +589,9 @@ func (h *IncidentHandler) GetMyReported(c *fiber.Ctx) error {
 	limit, _ := strconv.Atoi(c.Query("limit", "20"))
 	recordType := c.Query("record_type", "") // Optional filter: incident, request, complaint
 
-	incidents, total, err := h.service.GetMyReported(c.Context(), userID, recordType, page, limit)
+	incidents, total, err := h.service.GetMyReported(c.UserContext(), userID, recordType, page, limit)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	totalPages := (int(total) + limit - 1) / limit
@@ -624,9 +607,9 @@ func (h *IncidentHandler) GetMyReported(c *fiber.Ctx) error {
 }
 
 func (h *IncidentHandler) GetSLABreached(c *fiber.Ctx) error {
-	incidents, err := h.service.GetSLABreached(c.Context())
+	incidents, err := h.service.GetSLABreached(c.UserContext())
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "SLA breached incidents retrieved", incidents)
@@ -635,10 +618,9 @@ func (h *IncidentHandler) GetSLABreached(c *fiber.Ctx) error {
 // Revisions
 
 func (h *IncidentHandler) ListRevisions(c *fiber.Ctx) error {
-	idParam := c.Params("id")
-	incidentID, err := uuid.Parse(idParam)
+	incidentID, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid incident ID")
+		return err
 	}
 
 	page, _ := strconv.Atoi(c.Query("page", "1"))
@@ -677,9 +659,9 @@ func (h *IncidentHandler) ListRevisions(c *fiber.Ctx) error {
 		}
 	}
 
-	revisions, total, err := h.service.ListRevisions(c.Context(), incidentID, filter)
+	revisions, total, err := h.service.ListRevisions(c.UserContext(), incidentID, filter)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	totalPages := (int(total) + limit - 1) / limit
@@ -703,14 +685,14 @@ func (h *IncidentHandler) CreateComplaint(c *fiber.Ctx) error {
 	}
 
 	if err := h.validator.Struct(&req); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return utils.FormatValidationError(c, err)
 	}
 
 	userID := c.Locals("user_id").(uuid.UUID)
 
-	complaint, err := h.service.CreateComplaint(c.Context(), &req, userID)
+	complaint, err := h.service.CreateComplaint(c.UserContext(), &req, userID)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusCreated, "Complaint created", complaint)
@@ -790,9 +772,9 @@ func (h *IncidentHandler) ListComplaints(c *fiber.Ctx) error {
 		}
 	}
 
-	complaints, total, err := h.service.ListIncidents(c.Context(), filter)
+	complaints, total, err := h.service.ListIncidents(c.UserContext(), filter)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	totalPages := (int(total) + filter.Limit - 1) / filter.Limit
@@ -808,13 +790,12 @@ func (h *IncidentHandler) ListComplaints(c *fiber.Ctx) error {
 }
 
 func (h *IncidentHandler) GetComplaint(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
-	complaint, err := h.service.GetIncident(c.Context(), id)
+	complaint, err := h.service.GetIncident(c.UserContext(), id)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "Complaint not found")
 	}
@@ -828,20 +809,19 @@ func (h *IncidentHandler) GetComplaint(c *fiber.Ctx) error {
 }
 
 func (h *IncidentHandler) IncrementEvaluation(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
-	if err := h.service.IncrementEvaluationCount(c.Context(), id); err != nil {
+	if err := h.service.IncrementEvaluationCount(c.UserContext(), id); err != nil {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
 	}
 
 	// Return updated complaint
-	complaint, err := h.service.GetIncident(c.Context(), id)
+	complaint, err := h.service.GetIncident(c.UserContext(), id)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Evaluation count incremented", complaint)
@@ -856,14 +836,14 @@ func (h *IncidentHandler) CreateQuery(c *fiber.Ctx) error {
 	}
 
 	if err := h.validator.Struct(&req); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return utils.FormatValidationError(c, err)
 	}
 
 	userID := c.Locals("user_id").(uuid.UUID)
 
-	query, err := h.service.CreateQuery(c.Context(), &req, userID)
+	query, err := h.service.CreateQuery(c.UserContext(), &req, userID)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusCreated, "Query created", query)
@@ -943,9 +923,9 @@ func (h *IncidentHandler) ListQueries(c *fiber.Ctx) error {
 		}
 	}
 
-	queries, total, err := h.service.ListIncidents(c.Context(), filter)
+	queries, total, err := h.service.ListIncidents(c.UserContext(), filter)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	totalPages := (int(total) + filter.Limit - 1) / filter.Limit
@@ -961,13 +941,12 @@ func (h *IncidentHandler) ListQueries(c *fiber.Ctx) error {
 }
 
 func (h *IncidentHandler) GetQuery(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
-	query, err := h.service.GetIncident(c.Context(), id)
+	query, err := h.service.GetIncident(c.UserContext(), id)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "Query not found")
 	}
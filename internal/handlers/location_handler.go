@@ -21,7 +21,7 @@ type LocationHandler struct {
 func NewLocationHandler(repo repository.LocationRepository) *LocationHandler {
 	return &LocationHandler{
 		repo:      repo,
-		validator: validator.New(),
+		validator: utils.NewValidator(),
 	}
 }
 
@@ -32,7 +32,7 @@ func (h *LocationHandler) Create(c *fiber.Ctx) error {
 	}
 
 	if err := h.validator.Struct(&req); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return utils.FormatValidationError(c, err)
 	}
 
 	location := &models.Location{
@@ -48,21 +48,20 @@ func (h *LocationHandler) Create(c *fiber.Ctx) error {
 		IsActive:    true,
 	}
 
-	if err := h.repo.Create(c.Context(), location); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	if err := h.repo.Create(c.UserContext(), location); err != nil {
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusCreated, "Location created", models.ToLocationResponse(location))
 }
 
 func (h *LocationHandler) GetByID(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
-	location, err := h.repo.FindByID(c.Context(), id)
+	location, err := h.repo.FindByID(c.UserContext(), id)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "Location not found")
 	}
@@ -71,10 +70,9 @@ func (h *LocationHandler) GetByID(c *fiber.Ctx) error {
 }
 
 func (h *LocationHandler) Update(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
 	var req models.LocationUpdateRequest
@@ -82,7 +80,7 @@ func (h *LocationHandler) Update(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	location, err := h.repo.FindByID(c.Context(), id)
+	location, err := h.repo.FindByID(c.UserContext(), id)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "Location not found")
 	}
@@ -115,31 +113,30 @@ func (h *LocationHandler) Update(c *fiber.Ctx) error {
 		location.SortOrder = *req.SortOrder
 	}
 
-	if err := h.repo.Update(c.Context(), location); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	if err := h.repo.Update(c.UserContext(), location); err != nil {
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Location updated", models.ToLocationResponse(location))
 }
 
 func (h *LocationHandler) Delete(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
-	if err := h.repo.Delete(c.Context(), id); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	if err := h.repo.Delete(c.UserContext(), id); err != nil {
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Location deleted", nil)
 }
 
 func (h *LocationHandler) List(c *fiber.Ctx) error {
-	locations, err := h.repo.List(c.Context())
+	locations, err := h.repo.List(c.UserContext())
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	responses := make([]models.LocationResponse, len(locations))
@@ -151,9 +148,9 @@ func (h *LocationHandler) List(c *fiber.Ctx) error {
 }
 
 func (h *LocationHandler) GetTree(c *fiber.Ctx) error {
-	tree, err := h.repo.GetTree(c.Context())
+	tree, err := h.repo.GetTree(c.UserContext())
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	responses := make([]models.LocationResponse, len(tree))
@@ -171,17 +168,17 @@ func (h *LocationHandler) GetChildren(c *fiber.Ctx) error {
 	var err error
 
 	if parentIDStr == "" {
-		children, err = h.repo.GetByParentID(c.Context(), nil)
+		children, err = h.repo.GetByParentID(c.UserContext(), nil)
 	} else {
 		parentID, parseErr := uuid.Parse(parentIDStr)
 		if parseErr != nil {
 			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid parent ID")
 		}
-		children, err = h.repo.GetByParentID(c.Context(), &parentID)
+		children, err = h.repo.GetByParentID(c.UserContext(), &parentID)
 	}
 
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	responses := make([]models.LocationResponse, len(children))
@@ -198,9 +195,9 @@ func (h *LocationHandler) GetByType(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Type is required")
 	}
 
-	locations, err := h.repo.GetByType(c.Context(), locationType)
+	locations, err := h.repo.GetByType(c.UserContext(), locationType)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	responses := make([]models.LocationResponse, len(locations))
@@ -213,9 +210,9 @@ func (h *LocationHandler) GetByType(c *fiber.Ctx) error {
 
 // Export exports all locations as JSON
 func (h *LocationHandler) Export(c *fiber.Ctx) error {
-	locations, err := h.repo.List(c.Context())
+	locations, err := h.repo.List(c.UserContext())
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	// Convert to export format - NO FILTERING to match classification behavior
@@ -322,7 +319,7 @@ func (h *LocationHandler) Import(c *fiber.Ctx) error {
 			SortOrder:   data.SortOrder,
 		}
 
-		if err := h.repo.Create(c.Context(), location); err != nil {
+		if err := h.repo.Create(c.UserContext(), location); err != nil {
 			skipped++
 			errors = append(errors, data.Name+" (Level "+fmt.Sprintf("%d", data.Level)+") - "+err.Error())
 		} else {
@@ -1,28 +1,186 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
+	"github.com/automax/backend/internal/config"
 	"github.com/automax/backend/internal/models"
 	"github.com/automax/backend/internal/repository"
 	"github.com/automax/backend/pkg/utils"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type LookupHandler struct {
-	repo      repository.LookupRepository
-	validator *validator.Validate
+	repo       repository.LookupRepository
+	jwtManager *utils.JWTManager
+	validator  *validator.Validate
+	config     config.LookupConfig
+	// codePatterns caches compiled ValueCodePatterns by category ID
+	codePatterns sync.Map
 }
 
-func NewLookupHandler(repo repository.LookupRepository) *LookupHandler {
+func NewLookupHandler(repo repository.LookupRepository, jwtManager *utils.JWTManager, cfg config.LookupConfig) *LookupHandler {
 	return &LookupHandler{
-		repo:      repo,
-		validator: validator.New(),
+		repo:       repo,
+		jwtManager: jwtManager,
+		validator:  utils.NewValidator(),
+		config:     cfg,
 	}
 }
 
+// cachedCodePattern is a compiled ValueCodePattern and the source it came from
+type cachedCodePattern struct {
+	source string
+	re     *regexp.Regexp
+}
+
+// valueCodeErrors checks code against the category's ValueCodePattern and
+// returns the field errors to send, nil when the code matches or the category
+// has no pattern. Compiled patterns are cached per category and recompiled
+// once the category's pattern changes.
+func (h *LookupHandler) valueCodeErrors(category *models.LookupCategory, code string) ([]utils.ValidationError, error) {
+	if category == nil || category.ValueCodePattern == "" {
+		return nil, nil
+	}
+
+	cached, ok := h.codePatterns.Load(category.ID)
+	pattern, _ := cached.(cachedCodePattern)
+	if !ok || pattern.source != category.ValueCodePattern {
+		re, err := utils.CompileFullMatch(category.ValueCodePattern)
+		if err != nil {
+			return nil, fmt.Errorf("value code pattern of category %s: %w", category.Code, err)
+		}
+		pattern = cachedCodePattern{source: category.ValueCodePattern, re: re}
+		h.codePatterns.Store(category.ID, pattern)
+	}
+
+	if pattern.re.MatchString(code) {
+		return nil, nil
+	}
+	return []utils.ValidationError{{
+		Field:   "code",
+		Message: fmt.Sprintf("code must match the pattern %s of category %s", category.ValueCodePattern, category.Code),
+	}}, nil
+}
+
+// valueColorErrors checks color against the category's AllowedColors. A value
+// without a color always passes.
+func valueColorErrors(category *models.LookupCategory, color string) []utils.ValidationError {
+	if category == nil || len(category.AllowedColors) == 0 || color == "" {
+		return nil
+	}
+	normalized := utils.NormalizeColor(color)
+	for _, allowed := range category.AllowedColors {
+		if allowed == normalized {
+			return nil
+		}
+	}
+	return []utils.ValidationError{{
+		Field:   "color",
+		Message: fmt.Sprintf("color must be one of: %s", strings.Join(category.AllowedColors, " ")),
+	}}
+}
+
+// normalizeColors normalizes a palette and drops repeated colors; an empty
+// palette becomes nil
+func normalizeColors(colors []string) []string {
+	var normalized []string
+	seen := make(map[string]bool, len(colors))
+	for _, color := range colors {
+		color = utils.NormalizeColor(color)
+		if !seen[color] {
+			seen[color] = true
+			normalized = append(normalized, color)
+		}
+	}
+	return normalized
+}
+
+// requesterOrgID returns the tenant of the authenticated user, nil for global users
+func requesterOrgID(c *fiber.Ctx) *uuid.UUID {
+	orgID, _ := c.Locals("org_id").(*uuid.UUID)
+	return orgID
+}
+
+// requestContext returns the context for repository calls, scoped to the
+// requester's tenant. Super admins may also change global lookups.
+func (h *LookupHandler) requestContext(c *fiber.Ctx) context.Context {
+	ctx := repository.WithOrgID(c.UserContext(), requesterOrgID(c))
+	if isSuperAdmin(c) {
+		ctx = repository.WithGlobalWrites(ctx)
+	}
+	return ctx
+}
+
+// readContext is requestContext for the endpoints that offer values to
+// users: values restricted to a role the requester's token doesn't carry are
+// left out. Admin listings use requestContext and see every value.
+func (h *LookupHandler) readContext(c *fiber.Ctx) context.Context {
+	role, _ := c.Locals("role").(string)
+	return repository.WithRequesterRole(h.requestContext(c), role)
+}
+
+// lookupNotWritableMessage answers writes to lookups the requester can see but not change
+const lookupNotWritableMessage = "Global lookups can only be changed by a super admin"
+
+// Messages for the writes the repository refuses with 422
+const (
+	lookupDefaultLockedMessage    = "The default value of this category is locked"
+	lookupSystemValueFixedMessage = "The code, default flag and category of a system category's values are fixed, and they can't be deleted"
+)
+
+// lookupInvalidStatusMessage answers a value saved with an unknown status
+const lookupInvalidStatusMessage = "status must be active, deprecated or archived"
+
+// lookupWriteError answers a failed repository write: 403 for a global row
+// written by a tenant, see repository.ErrLookupNotWritable, or for a change to
+// a locked default, 422 for a change to a system category's values, 400 for an
+// unknown value status, otherwise 500
+func lookupWriteError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, repository.ErrLookupNotWritable) {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, lookupNotWritableMessage)
+	}
+	if errors.Is(err, repository.ErrLookupDefaultLocked) {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, lookupDefaultLockedMessage)
+	}
+	if errors.Is(err, repository.ErrLookupSystemValueFixed) {
+		return utils.ErrorResponse(c, fiber.StatusUnprocessableEntity, lookupSystemValueFixedMessage)
+	}
+	if errors.Is(err, models.ErrInvalidLookupValueStatus) {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, lookupInvalidStatusMessage)
+	}
+	return utils.InternalErrorResponse(c, err)
+}
+
+// isSuperAdmin reports whether the user loaded by RequirePermission is a super admin
+func isSuperAdmin(c *fiber.Ctx) bool {
+	user, ok := c.Locals("user").(*models.User)
+	return ok && user.IsSuperAdmin
+}
+
+// hasPermission reports whether the user loaded by RequirePermission has permission
+func hasPermission(c *fiber.Ctx, permission string) bool {
+	user, ok := c.Locals("user").(*models.User)
+	return ok && user.HasPermission(permission)
+}
+
 // Category handlers
 
 func (h *LookupHandler) CreateCategory(c *fiber.Ctx) error {
@@ -32,13 +190,14 @@ func (h *LookupHandler) CreateCategory(c *fiber.Ctx) error {
 	}
 
 	if err := h.validator.Struct(&req); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return utils.FormatValidationError(c, err)
 	}
 
 	// Normalize code to uppercase
 	req.Code = strings.ToUpper(req.Code)
 
 	category := &models.LookupCategory{
+		OrgID:             requesterOrgID(c),
 		Code:              req.Code,
 		Name:              req.Name,
 		NameAr:            req.NameAr,
@@ -52,50 +211,223 @@ func (h *LookupHandler) CreateCategory(c *fiber.Ctx) error {
 		category.IsActive = *req.IsActive
 	}
 	if req.AddToIncidentForm != nil {
-		category.AddToIncidentForm = *req.AddToIncidentForm
+		category.SetIncidentForm(*req.AddToIncidentForm)
+	}
+	if req.DefaultSortDesc != nil {
+		category.DefaultSortDesc = *req.DefaultSortDesc
+	}
+	category.ValueCodePattern = req.ValueCodePattern
+	category.DefaultColor = req.DefaultColor
+	category.AllowedColors = normalizeColors(req.AllowedColors)
+	if req.LockDefault != nil && *req.LockDefault {
+		if !isSuperAdmin(c) {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, "Only super admins can lock a category's default value")
+		}
+		category.LockDefault = true
+	}
+
+	if err := h.validateIncidentFormCategory(category); err != nil {
+		return utils.FormatValidationError(c, err)
+	}
+
+	if h.categoryCodeTakenGlobally(c, category.Code, uuid.Nil) {
+		return categoryCodeTakenGlobally(c)
+	}
+
+	conflict, err := h.incidentFormPrefixConflict(c, nil, category)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+	if conflict != nil {
+		return incidentFormPrefixTaken(c, conflict)
 	}
 
-	if err := h.repo.CreateCategory(c.Context(), category); err != nil {
+	if err := h.repo.CreateCategory(h.requestContext(c), category); err != nil {
 		if strings.Contains(err.Error(), "duplicate") || strings.Contains(err.Error(), "unique") {
-			return utils.ErrorResponse(c, fiber.StatusConflict, "Category with this code already exists")
+			return h.categoryCodeConflict(c, category.Code)
 		}
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return lookupWriteError(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusCreated, "Category created", models.ToLookupCategoryResponse(category))
 }
 
+// ListTemplates returns the built-in category templates with their values
+func (h *LookupHandler) ListTemplates(c *fiber.Ctx) error {
+	return utils.SuccessResponse(c, fiber.StatusOK, "Templates retrieved", models.LookupTemplates)
+}
+
+// CreateCategoryFromTemplate creates a category and its values from a built-in
+// template, owned by the caller's org. The category takes the template code
+// unless the optional body names another.
+func (h *LookupHandler) CreateCategoryFromTemplate(c *fiber.Ctx) error {
+	template, ok := models.FindLookupTemplate(strings.ToUpper(c.Params("template_code")))
+	if !ok {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Template not found")
+	}
+
+	var req models.LookupTemplateInstantiateRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+		}
+		if err := h.validator.Struct(&req); err != nil {
+			return utils.FormatValidationError(c, err)
+		}
+	}
+
+	code := template.Code
+	if req.Code != "" {
+		code = strings.ToUpper(req.Code)
+	}
+
+	category := &models.LookupCategory{
+		ID:          uuid.New(),
+		OrgID:       requesterOrgID(c),
+		Code:        code,
+		Name:        template.Name,
+		NameAr:      template.NameAr,
+		Description: template.Description,
+		IsActive:    true,
+	}
+	category.Values = make([]models.LookupValue, len(template.Values))
+	for i, seed := range template.Values {
+		category.Values[i] = seed.NewValue(category)
+	}
+
+	if h.categoryCodeTakenGlobally(c, category.Code, uuid.Nil) {
+		return categoryCodeTakenGlobally(c)
+	}
+
+	if err := h.repo.CreateCategory(h.requestContext(c), category); err != nil {
+		if strings.Contains(err.Error(), "duplicate") || strings.Contains(err.Error(), "unique") {
+			return h.categoryCodeConflict(c, category.Code)
+		}
+		return lookupWriteError(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusCreated, "Category created from template", models.ToLookupCategoryResponse(category))
+}
+
 func (h *LookupHandler) GetCategoryByID(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "category_id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
-	category, err := h.repo.FindCategoryByID(c.Context(), id)
+	category, err := h.repo.FindCategoryByID(h.requestContext(c), id)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "Category not found")
 	}
 
+	lastModified, err := h.lastModified(c, category.ID, category.Values)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+	if category.UpdatedAt.After(lastModified) {
+		lastModified = category.UpdatedAt
+	}
+	if utils.CheckNotModified(c, lastModified) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
 	return utils.SuccessResponse(c, fiber.StatusOK, "Category retrieved", models.ToLookupCategoryResponse(category))
 }
 
 func (h *LookupHandler) UpdateCategory(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "category_id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
+	changedOnly, err := utils.ParseReturnChanged(c)
+	if err != nil {
+		return err
+	}
+
+	if len(bytes.TrimSpace(c.Body())) == 0 {
+		return noFieldsToUpdate(c)
+	}
 	var req models.LookupCategoryUpdateRequest
 	if err := c.BodyParser(&req); err != nil {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
 	}
+	if req == (models.LookupCategoryUpdateRequest{}) {
+		return noFieldsToUpdate(c)
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		return utils.FormatValidationError(c, err)
+	}
 
-	category, err := h.repo.FindCategoryByID(c.Context(), id)
+	category, err := h.repo.FindCategoryByID(h.requestContext(c), id)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "Category not found")
 	}
+	before := models.ToLookupCategoryResponse(category)
+
+	if err := h.applyCategoryUpdate(c, category, &req); err != nil {
+		return err
+	}
+
+	if err := h.validateIncidentFormCategory(category); err != nil {
+		return utils.FormatValidationError(c, err)
+	}
+
+	conflict, err := h.incidentFormPrefixConflict(c, &before, category)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+	if conflict != nil {
+		return incidentFormPrefixTaken(c, conflict)
+	}
+
+	if err := h.repo.UpdateCategory(h.requestContext(c), category); err != nil {
+		if strings.Contains(err.Error(), "duplicate") || strings.Contains(err.Error(), "unique") {
+			return h.categoryCodeConflict(c, category.Code)
+		}
+		return lookupWriteError(c, err)
+	}
+
+	data, err := updateResponseData(changedOnly, before, models.ToLookupCategoryResponse(category))
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	// Values without Arabic names don't block the update but show up untranslated on the form
+	if category.AddToIncidentForm && category.IsActive {
+		missing, err := h.repo.ValuesMissingArabicNames(h.requestContext(c), category.ID)
+		if err == nil && len(missing) > 0 {
+			warning := fmt.Sprintf("Values missing an Arabic name: %s", strings.Join(missing, ", "))
+			return utils.SuccessResponseWithWarnings(c, fiber.StatusOK, "Category updated", data, []string{warning})
+		}
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Category updated", data)
+}
+
+// applyCategoryUpdate sets the fields of an update request on category; system
+// categories keep their code and active state. Failures are returned for the
+// error handler to render.
+func (h *LookupHandler) applyCategoryUpdate(c *fiber.Ctx, category *models.LookupCategory, req *models.LookupCategoryUpdateRequest) error {
+	if req.LockDefault != nil && *req.LockDefault != category.LockDefault {
+		if !isSuperAdmin(c) {
+			return fiber.NewError(fiber.StatusForbidden, "Only super admins can lock or unlock a category's default value")
+		}
+		category.LockDefault = *req.LockDefault
+	}
+	if req.DefaultSortDesc != nil {
+		category.DefaultSortDesc = *req.DefaultSortDesc
+	}
+	if req.ValueCodePattern != nil {
+		category.ValueCodePattern = *req.ValueCodePattern
+	}
+	if req.DefaultColor != nil {
+		category.DefaultColor = *req.DefaultColor
+	}
+	if req.AllowedColors != nil {
+		category.AllowedColors = normalizeColors(*req.AllowedColors)
+	}
 
 	// System categories can only have limited updates (no code/isActive changes)
 	if category.IsSystem {
@@ -110,11 +442,14 @@ func (h *LookupHandler) UpdateCategory(c *fiber.Ctx) error {
 			category.Description = req.Description
 		}
 		if req.AddToIncidentForm != nil {
-			category.AddToIncidentForm = *req.AddToIncidentForm
+			category.SetIncidentForm(*req.AddToIncidentForm)
 		}
 	} else {
 		if req.Code != "" {
 			category.Code = strings.ToUpper(req.Code)
+			if h.categoryCodeTakenGlobally(c, category.Code, category.ID) {
+				return fiber.NewError(fiber.StatusConflict, "Category code is already used by another organization")
+			}
 		}
 		if req.Name != "" {
 			category.Name = req.Name
@@ -125,240 +460,3317 @@ func (h *LookupHandler) UpdateCategory(c *fiber.Ctx) error {
 		if req.Description != "" {
 			category.Description = req.Description
 		}
+		// Reactivating restores the incident form flag unless the request sets it
 		if req.IsActive != nil {
-			category.IsActive = *req.IsActive
+			category.SetActive(*req.IsActive)
 		}
 		if req.AddToIncidentForm != nil {
-			category.AddToIncidentForm = *req.AddToIncidentForm
+			category.SetIncidentForm(*req.AddToIncidentForm)
 		}
 	}
-
-	if err := h.repo.UpdateCategory(c.Context(), category); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
-	}
-
-	return utils.SuccessResponse(c, fiber.StatusOK, "Category updated", models.ToLookupCategoryResponse(category))
+	return nil
 }
 
-func (h *LookupHandler) DeleteCategory(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+// ApplyCategoryChanges applies a whole admin save of a category, see
+// models.LookupCategoryApplyRequest, in one transaction and returns the
+// resulting category. The change set is checked as a whole against the state
+// it leads to, under the rules of the single-item endpoints, and the problems
+// found are reported together, each under its place in the request such as
+// update[2].code. Nothing is saved unless all of it can be.
+func (h *LookupHandler) ApplyCategoryChanges(c *fiber.Ctx) error {
+	id, err := utils.ParseUUIDParam(c, "category_id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
+	}
+
+	var req models.LookupCategoryApplyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if reflect.ValueOf(req).IsZero() {
+		return noFieldsToUpdate(c)
+	}
+	if err := h.validator.Struct(&req); err != nil {
+		return utils.FormatValidationError(c, err)
+	}
+	// The route needs lookups:update; creates and deletes also need their own
+	if len(req.Create) > 0 && !hasPermission(c, "lookups:create") || len(req.Delete) > 0 && !hasPermission(c, "lookups:delete") {
+		return utils.ErrorResponse(c, fiber.StatusForbidden, "Insufficient permissions")
 	}
 
-	category, err := h.repo.FindCategoryByID(c.Context(), id)
+	ctx := h.requestContext(c)
+	category, err := h.repo.FindCategoryByID(ctx, id)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "Category not found")
 	}
+	activeBefore := countActiveValues(category.Values)
 
-	// System categories cannot be deleted
-	if category.IsSystem {
-		return utils.ErrorResponse(c, fiber.StatusForbidden, "System categories cannot be deleted")
+	if req.Category != nil {
+		before := models.ToLookupCategoryResponse(category)
+		if err := h.applyCategoryUpdate(c, category, req.Category); err != nil {
+			return err
+		}
+		if err := h.validateIncidentFormCategory(category); err != nil {
+			return utils.FormatValidationError(c, err)
+		}
+		conflict, err := h.incidentFormPrefixConflict(c, &before, category)
+		if err != nil {
+			return utils.InternalErrorResponse(c, err)
+		}
+		if conflict != nil {
+			return incidentFormPrefixTaken(c, conflict)
+		}
 	}
 
-	if err := h.repo.DeleteCategory(c.Context(), id); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	plan, problems, err := h.planValueChanges(c, category, &req)
+	if err != nil {
+		return err
+	}
+	if problems != nil {
+		return utils.ValidationFailedResponse(c, problems)
+	}
+	if limit := h.config.MaxValuesPerCategory; limit > 0 && plan.active > limit && plan.active > activeBefore {
+		return utils.ErrorResponse(c, fiber.StatusUnprocessableEntity,
+			fmt.Sprintf("Category would exceed the maximum of %d active values", limit))
 	}
 
-	return utils.SuccessResponse(c, fiber.StatusOK, "Category deleted", nil)
-}
-
-func (h *LookupHandler) ListCategories(c *fiber.Ctx) error {
-	categories, err := h.repo.ListCategories(c.Context())
+	err = h.repo.WithDefaultLock(ctx, category.ID, func(tx repository.LookupRepository) error {
+		if req.Category != nil {
+			// The values are saved below, not as an association of the category
+			saved := *category
+			saved.Values = nil
+			if err := tx.UpdateCategory(ctx, &saved); err != nil {
+				return err
+			}
+		}
+		for _, valueID := range req.Delete {
+			if err := tx.DeleteValue(ctx, valueID); err != nil {
+				return err
+			}
+		}
+		if plan.replaceDefault {
+			if _, err := tx.ClearDefaultForCategory(ctx, category.ID); err != nil {
+				return err
+			}
+		}
+		for i := range plan.update {
+			if err := tx.UpdateValue(ctx, &plan.update[i]); err != nil {
+				return err
+			}
+		}
+		for i := range plan.create {
+			if err := tx.CreateValue(ctx, &plan.create[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		if req.Category != nil && (strings.Contains(err.Error(), "duplicate") || strings.Contains(err.Error(), "unique")) {
+			return h.categoryCodeConflict(c, category.Code)
+		}
+		return lookupWriteError(c, err)
 	}
 
-	responses := make([]models.LookupCategoryResponse, len(categories))
-	for i, cat := range categories {
-		responses[i] = models.ToLookupCategoryResponse(&cat)
+	applied, err := h.repo.FindCategoryByID(ctx, category.ID)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
 	}
-
-	return utils.SuccessResponse(c, fiber.StatusOK, "Categories retrieved", responses)
+	return utils.SuccessResponse(c, fiber.StatusOK, "Category changes applied", models.ToLookupCategoryResponse(applied))
 }
 
-// Value handlers
+// valueChangePlan is what an apply request does to the values of a category
+// once checked: the values to create and save with their final fields, and
+// whether a value becoming the default replaces the current one
+type valueChangePlan struct {
+	create, update []models.LookupValue
+	replaceDefault bool
+	active         int // active values once the plan is applied
+}
 
-func (h *LookupHandler) CreateValue(c *fiber.Ctx) error {
-	categoryIDStr := c.Params("id")
-	categoryID, err := uuid.Parse(categoryIDStr)
-	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid category ID")
+// planValueChanges checks the value changes of an apply request against
+// category, whose own changes are already set on it, and returns the plan or
+// the problems found. The error is for the error handler to render.
+func (h *LookupHandler) planValueChanges(c *fiber.Ctx, category *models.LookupCategory, req *models.LookupCategoryApplyRequest) (*valueChangePlan, []utils.ValidationError, error) {
+	var problems []utils.ValidationError
+	report := func(field, message string) {
+		problems = append(problems, utils.ValidationError{Field: field, Message: message})
 	}
 
-	// Verify category exists
-	category, err := h.repo.FindCategoryByID(c.Context(), categoryID)
-	if err != nil && category == nil {
-		return utils.ErrorResponse(c, fiber.StatusNotFound, "Category not found")
+	current := make(map[uuid.UUID]*models.LookupValue, len(category.Values))
+	for i := range category.Values {
+		current[category.Values[i].ID] = &category.Values[i]
 	}
 
-	var req models.LookupValueCreateRequest
-	if err := c.BodyParser(&req); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	deleted := make(map[uuid.UUID]bool, len(req.Delete))
+	for i, id := range req.Delete {
+		field := fmt.Sprintf("delete[%d]", i)
+		switch {
+		case current[id] == nil:
+			report(field, "value is not in the category")
+		case deleted[id]:
+			report(field, "value is listed twice")
+		}
+		deleted[id] = true
 	}
 
-	if err := h.validator.Struct(&req); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
-	}
+	plan := &valueChangePlan{}
+	// Fields of the values becoming the default, e.g. create[0].is_default
+	var newDefaults []string
+	touched := make(map[uuid.UUID]string, len(req.Update)+len(req.Create))
 
-	// Normalize code to uppercase
-	req.Code = strings.ToUpper(req.Code)
+	for i := range req.Update {
+		item := &req.Update[i]
+		prefix := fmt.Sprintf("update[%d]", i)
+		value, ok := current[item.ID]
+		switch {
+		case !ok:
+			report(prefix+".id", "value is not in the category")
+			continue
+		case deleted[item.ID]:
+			report(prefix+".id", "value is deleted in the same change set")
+			continue
+		case touched[item.ID] != "":
+			report(prefix+".id", "value is updated more than once")
+			continue
+		case reflect.ValueOf(item.LookupValueUpdateRequest).IsZero():
+			report(prefix, "No fields to update")
+			continue
+		}
+		touched[item.ID] = prefix
 
-	value := &models.LookupValue{
-		CategoryID:  categoryID,
-		Code:        req.Code,
-		Name:        req.Name,
-		NameAr:      req.NameAr,
-		Description: req.Description,
-		SortOrder:   req.SortOrder,
-		Color:       req.Color,
-		IsDefault:   req.IsDefault,
-		IsActive:    true,
+		changes, errs := updateRequestChanges(&item.LookupValueUpdateRequest)
+		next := *value
+		more, err := h.applyValueChanges(c, category, &next, changes, deleted)
+		if err != nil {
+			return nil, nil, err
+		}
+		problems = append(problems, prefixFields(prefix, append(errs, more...))...)
+		if next.IsDefault && !value.IsDefault {
+			newDefaults = append(newDefaults, prefix+".is_default")
+		}
+		plan.update = append(plan.update, next)
 	}
 
-	if req.IsActive != nil {
-		value.IsActive = *req.IsActive
+	var nextOrder int
+	if len(req.Create) > 0 {
+		var err error
+		if nextOrder, err = h.repo.NextSortOrder(h.requestContext(c), category.ID); err != nil {
+			return nil, nil, err
+		}
+	}
+	for i := range req.Create {
+		item := &req.Create[i]
+		prefix := fmt.Sprintf("create[%d]", i)
+		id, err := h.clientValueID(c, item.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if id == uuid.Nil {
+			id = uuid.New()
+		} else if touched[id] != "" {
+			report(prefix+".id", fmt.Sprintf("id is also used by %s", touched[id]))
+			continue
+		}
+		touched[id] = prefix
+
+		value, errs, err := h.newApplyValue(c, category, item, deleted)
+		if err != nil {
+			return nil, nil, err
+		}
+		value.ID = id
+		problems = append(problems, prefixFields(prefix, errs)...)
+		if value.IsDefault {
+			newDefaults = append(newDefaults, prefix+".is_default")
+		}
+		if item.SortOrder == nil {
+			value.SortOrder = nextOrder
+			nextOrder++
+		}
+		plan.create = append(plan.create, *value)
 	}
 
-	// If this is set as default, clear other defaults for this category
-	if value.IsDefault {
-		if err := h.repo.ClearDefaultForCategory(c.Context(), categoryID); err != nil {
-			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to clear existing defaults")
+	// The values as they stand once the change set is applied, untouched ones first
+	final := make([]*models.LookupValue, 0, len(category.Values)+len(plan.create))
+	codes := make(map[string]uuid.UUID, cap(final))
+	for i := range category.Values {
+		if value := &category.Values[i]; !deleted[value.ID] && touched[value.ID] == "" {
+			final = append(final, value)
+			codes[value.Code] = value.ID
+		}
+	}
+	for _, saved := range [][]models.LookupValue{plan.update, plan.create} {
+		for i := range saved {
+			value := &saved[i]
+			if owner, taken := codes[value.Code]; taken && owner != value.ID {
+				report(touched[value.ID]+".code", fmt.Sprintf("code %s is already used in the category", value.Code))
+			}
+			codes[value.Code] = value.ID
+			final = append(final, value)
 		}
 	}
 
-	if err := h.repo.CreateValue(c.Context(), value); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	if len(newDefaults) > 1 {
+		for _, field := range newDefaults {
+			report(field, fmt.Sprintf("Only one value can be the default, %d would become it", len(newDefaults)))
+		}
+	}
+	if len(newDefaults) == 1 {
+		plan.replaceDefault = true
+		for _, value := range final {
+			if value.IsDefault && touched[value.ID]+".is_default" != newDefaults[0] {
+				value.IsDefault = false
+			}
+		}
 	}
 
-	// Reload to get the updated category values count
-	category, _ = h.repo.FindCategoryByID(c.Context(), categoryID)
+	for _, value := range final {
+		if value.IsActive {
+			plan.active++
+		}
+	}
 
-	return utils.SuccessResponse(c, fiber.StatusCreated, "Value created", models.ToLookupValueResponse(value))
+	if req.Order != nil {
+		reordered, orderProblems := orderValues(category, final, req.Order, touched)
+		problems = append(problems, orderProblems...)
+		plan.update = append(plan.update, reordered...)
+	}
+	return plan, problems, nil
 }
 
-func (h *LookupHandler) GetValueByID(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
-	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+// orderValues renumbers the sort orders of final, the values of category once
+// an apply request is applied, so they list in the order of codes followed by
+// the values codes leaves out in their current order. It returns the untouched
+// values whose sort order changed, which need saving too.
+func orderValues(category *models.LookupCategory, final []*models.LookupValue, codes []string, touched map[uuid.UUID]string) ([]models.LookupValue, []utils.ValidationError) {
+	var problems []utils.ValidationError
+	byCode := make(map[string]*models.LookupValue, len(final))
+	for _, value := range final {
+		byCode[value.Code] = value
 	}
-
-	value, err := h.repo.FindValueByID(c.Context(), id)
-	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusNotFound, "Value not found")
+	position := make(map[uuid.UUID]int, len(codes))
+	for i, code := range codes {
+		field := fmt.Sprintf("order[%d]", i)
+		code = strings.ToUpper(code)
+		value, ok := byCode[code]
+		switch {
+		case !ok:
+			problems = append(problems, utils.ValidationError{Field: field, Message: fmt.Sprintf("no value has code %s", code)})
+		case position[value.ID] > 0:
+			problems = append(problems, utils.ValidationError{Field: field, Message: fmt.Sprintf("code %s is listed twice", code)})
+		default:
+			position[value.ID] = i + 1
+		}
+	}
+	if problems != nil {
+		return nil, problems
 	}
 
-	return utils.SuccessResponse(c, fiber.StatusOK, "Value retrieved", models.ToLookupValueResponse(value))
-}
+	ordered := append([]*models.LookupValue(nil), final...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		pi, pj := position[ordered[i].ID], position[ordered[j].ID]
+		switch {
+		case pi > 0 && pj > 0:
+			return pi < pj
+		case pi > 0 || pj > 0:
+			return pi > 0
+		case category.DefaultSortDesc:
+			return ordered[i].SortOrder > ordered[j].SortOrder
+		default:
+			return ordered[i].SortOrder < ordered[j].SortOrder
+		}
+	})
 
-func (h *LookupHandler) UpdateValue(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
-	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+	var reordered []models.LookupValue
+	for i, value := range ordered {
+		// Categories listed by descending sort order get the order reversed
+		sortOrder := i
+		if category.DefaultSortDesc {
+			sortOrder = len(ordered) - 1 - i
+		}
+		if value.SortOrder == sortOrder {
+			continue
+		}
+		value.SortOrder = sortOrder
+		if touched[value.ID] == "" {
+			reordered = append(reordered, *value)
+		}
 	}
+	return reordered, nil
+}
 
-	var req models.LookupValueUpdateRequest
-	if err := c.BodyParser(&req); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
-	}
+// applyValueChanges applies changes to value under the rules of updateValue,
+// returning the broken ones as field errors instead of responses. A parent
+// deleted by the same apply request is refused.
+func (h *LookupHandler) applyValueChanges(c *fiber.Ctx, category *models.LookupCategory, value *models.LookupValue, changes valueChanges, deleted map[uuid.UUID]bool) ([]utils.ValidationError, error) {
+	var errs []utils.ValidationError
 
-	value, err := h.repo.FindValueByID(c.Context(), id)
-	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusNotFound, "Value not found")
+	probe := *value
+	probe.Category = category
+	for _, field := range protectedSystemValueFields(&probe, changes) {
+		errs = append(errs, utils.ValidationError{Field: field, Message: "cannot be changed on a system category's values"})
 	}
 
-	if req.Code != "" {
-		value.Code = strings.ToUpper(req.Code)
+	if changes.Code != nil {
+		value.Code = strings.ToUpper(*changes.Code)
+		codeErrors, err := h.valueCodeErrors(category, value.Code)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, codeErrors...)
 	}
-	if req.Name != "" {
-		value.Name = req.Name
+	if changes.Name != nil {
+		value.Name = *changes.Name
 	}
-	if req.NameAr != "" {
-		value.NameAr = req.NameAr
+	if changes.NameAr != nil {
+		value.NameAr = *changes.NameAr
 	}
-	if req.Description != "" {
-		value.Description = req.Description
+	if changes.Description != nil {
+		value.Description = *changes.Description
 	}
-	if req.SortOrder != nil {
-		value.SortOrder = *req.SortOrder
+	if changes.SortOrder != nil {
+		value.SortOrder = *changes.SortOrder
 	}
-	if req.Color != "" {
-		value.Color = req.Color
+	if changes.ParentID != nil {
+		errs = append(errs, h.applyParentErrors(c, category.ID, value.ID, *changes.ParentID, deleted)...)
+		value.ParentID = changes.ParentID
 	}
-	if req.IsDefault != nil {
-		// If setting as default, clear other defaults first
-		if *req.IsDefault && !value.IsDefault {
-			if err := h.repo.ClearDefaultForCategory(c.Context(), value.CategoryID); err != nil {
-				return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to clear existing defaults")
-			}
+	if changes.Color != nil {
+		errs = append(errs, valueColorErrors(category, *changes.Color)...)
+		value.Color = *changes.Color
+	}
+	if changes.IsDefault != nil {
+		if *changes.IsDefault != value.IsDefault && category.LockDefault {
+			errs = append(errs, utils.ValidationError{Field: "is_default", Message: "The default value of this category is locked"})
 		}
-		value.IsDefault = *req.IsDefault
+		value.IsDefault = *changes.IsDefault
 	}
-	if req.IsActive != nil {
-		value.IsActive = *req.IsActive
+	if changes.DefaultWeight != nil {
+		if *changes.DefaultWeight != value.DefaultWeight && category.LockDefault {
+			errs = append(errs, utils.ValidationError{Field: "default_weight", Message: "The default value of this category is locked"})
+		}
+		value.DefaultWeight = *changes.DefaultWeight
 	}
-
-	if err := h.repo.UpdateValue(c.Context(), value); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	if changes.IsActive != nil {
+		value.IsActive = *changes.IsActive
 	}
-
-	return utils.SuccessResponse(c, fiber.StatusOK, "Value updated", models.ToLookupValueResponse(value))
+	if changes.Status != nil {
+		value.SetStatus(*changes.Status)
+	}
+	// An explicit status change means the admin, not an archive, now owns it
+	if changes.IsActive != nil || changes.Status != nil {
+		value.ArchivedAt = nil
+	}
+	if changes.EffectiveFrom != nil {
+		value.EffectiveFrom = changes.EffectiveFrom
+	}
+	if changes.EffectiveTo != nil {
+		value.EffectiveTo = changes.EffectiveTo
+	}
+	if changes.RequiredRole != nil {
+		value.RequiredRole = *changes.RequiredRole
+	}
+	if changes.Metadata != nil {
+		value.Metadata = *changes.Metadata
+	}
+	if checkEffectiveWindow(value) != nil {
+		errs = append(errs, utils.ValidationError{Field: "effective_to", Message: "effective_from must not be after effective_to"})
+	}
+	return errs, nil
 }
 
-func (h *LookupHandler) DeleteValue(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
-	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+// newApplyValue builds a value of category from a create item of an apply
+// request under the rules of CreateValue, returning the broken ones as field
+// errors. The caller sets the ID, and the sort order when the item has none.
+func (h *LookupHandler) newApplyValue(c *fiber.Ctx, category *models.LookupCategory, item *models.LookupValueCreateRequest, deleted map[uuid.UUID]bool) (*models.LookupValue, []utils.ValidationError, error) {
+	value := &models.LookupValue{
+		OrgID:       requesterOrgID(c),
+		CategoryID:  category.ID,
+		Code:        strings.ToUpper(item.Code),
+		Name:        item.Name,
+		NameAr:      item.NameAr,
+		Description: item.Description,
+		Color:       item.Color,
+		IsDefault:   item.WantsDefault(),
+		IsActive:    true,
+
+		DefaultWeight: item.DefaultWeight,
+		EffectiveFrom: item.EffectiveFrom,
+		EffectiveTo:   item.EffectiveTo,
+		RequiredRole:  item.RequiredRole,
+	}
+	if item.IsActive != nil {
+		value.IsActive = *item.IsActive
+	}
+	if item.Status != "" {
+		value.SetStatus(item.Status)
+	}
+	if item.SortOrder != nil {
+		value.SortOrder = *item.SortOrder
 	}
 
-	_, err = h.repo.FindValueByID(c.Context(), id)
+	errs, err := h.valueCodeErrors(category, value.Code)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusNotFound, "Value not found")
+		return nil, nil, err
 	}
-
-	if err := h.repo.DeleteValue(c.Context(), id); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	errs = append(errs, valueColorErrors(category, item.Color)...)
+	if item.Metadata != nil {
+		metadata, metadataErrors := decodeValueMetadata(item.Metadata)
+		errs = append(errs, metadataErrors...)
+		value.Metadata = metadata
 	}
-
+	if item.ParentID != nil {
+		errs = append(errs, h.applyParentErrors(c, category.ID, uuid.Nil, *item.ParentID, deleted)...)
+		value.ParentID = item.ParentID
+	}
+	if value.IsDefault && category.LockDefault {
+		errs = append(errs, utils.ValidationError{Field: "is_default", Message: "The default value of this category is locked"})
+	}
+	if checkEffectiveWindow(value) != nil {
+		errs = append(errs, utils.ValidationError{Field: "effective_to", Message: "effective_from must not be after effective_to"})
+	}
+	return value, errs, nil
+}
+
+// applyParentErrors runs validateParent for a value of an apply request, whose
+// parent must also survive the request's deletes
+func (h *LookupHandler) applyParentErrors(c *fiber.Ctx, categoryID, valueID, parentID uuid.UUID, deleted map[uuid.UUID]bool) []utils.ValidationError {
+	msg := h.validateParent(c, categoryID, valueID, parentID)
+	if msg == "" && deleted[parentID] {
+		msg = "Parent value is deleted in the same change set"
+	}
+	if msg == "" {
+		return nil
+	}
+	return []utils.ValidationError{{Field: "parent_id", Message: msg}}
+}
+
+// prefixFields places field errors of one item of a request under its place,
+// e.g. code under create[1].code
+func prefixFields(prefix string, errs []utils.ValidationError) []utils.ValidationError {
+	for i := range errs {
+		errs[i].Field = prefix + "." + errs[i].Field
+	}
+	return errs
+}
+
+// ResetCategory restores a system category's values to the seeded baseline.
+// Admin-added values are kept unless ?strict=true, which removes them.
+func (h *LookupHandler) ResetCategory(c *fiber.Ctx) error {
+	id, err := utils.ParseUUIDParam(c, "category_id")
+	if err != nil {
+		return err
+	}
+
+	category, err := h.repo.FindCategoryByID(h.requestContext(c), id)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Category not found")
+	}
+
+	if !category.IsSystem {
+		return utils.ErrorResponse(c, fiber.StatusUnprocessableEntity, "Only system categories can be reset")
+	}
+	seed, ok := models.FindLookupSeed(category.Code)
+	if !ok {
+		return utils.ErrorResponse(c, fiber.StatusUnprocessableEntity, "No seeded defaults exist for this category")
+	}
+
+	result, err := h.repo.ResetCategoryToSeed(h.requestContext(c), category, seed, c.QueryBool("strict", false))
+	if err != nil {
+		return lookupWriteError(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Category reset to defaults", result)
+}
+
+// TouchCategory bumps a category's updated_at without changing its data, so
+// clients holding an ETag or Last-Modified for it fetch it again
+func (h *LookupHandler) TouchCategory(c *fiber.Ctx) error {
+	id, err := utils.ParseUUIDParam(c, "category_id")
+	if err != nil {
+		return err
+	}
+
+	updatedAt, err := h.repo.TouchCategory(h.requestContext(c), id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Category not found")
+	}
+	if err != nil {
+		return lookupWriteError(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Category touched", models.LookupCategoryTouchResponse{
+		ID:        id,
+		UpdatedAt: updatedAt,
+	})
+}
+
+// ListCategoryGroups returns the categories grouped by code prefix, e.g. HR
+// for HR_LEAVE_TYPE, for grouped navigation
+func (h *LookupHandler) ListCategoryGroups(c *fiber.Ctx) error {
+	categories, err := h.repo.ListCategories(h.requestContext(c))
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Category groups retrieved", models.GroupLookupCategories(categories))
+}
+
+// SuggestCategoryCodes lists existing category codes starting with ?prefix and
+// suggests a free code based on it, e.g. PRIORITY_2 when PRIORITY is taken
+func (h *LookupHandler) SuggestCategoryCodes(c *fiber.Ctx) error {
+	prefix := strings.ToUpper(strings.TrimSpace(c.Query("prefix")))
+	if prefix == "" || len(prefix) > 50 {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "prefix must be 1-50 characters")
+	}
+
+	limit := c.QueryInt("limit", utils.DefaultListLimit)
+	if limit < 1 || limit > utils.MaxListLimit {
+		limit = utils.DefaultListLimit
+	}
+
+	matches, err := h.repo.ListCategoryCodesByPrefix(h.requestContext(c), prefix, limit)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+	suggestion, err := h.repo.SuggestCategoryCode(h.requestContext(c), prefix)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Code suggestions retrieved", models.LookupCodeSuggestions{
+		Prefix:     prefix,
+		Matches:    matches,
+		Suggestion: suggestion,
+	})
+}
+
+// SetCategoriesActive switches several categories on or off in one request.
+// System categories in the batch are skipped rather than failing it.
+func (h *LookupHandler) SetCategoriesActive(c *fiber.Ctx) error {
+	var req models.LookupCategoriesActiveRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		return utils.FormatValidationError(c, err)
+	}
+
+	result, err := h.repo.SetCategoriesActive(h.requestContext(c), req.IDs, *req.IsActive)
+	if err != nil {
+		return lookupWriteError(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Categories updated", result)
+}
+
+// SetCategoriesIncidentForm puts several categories on the incident form or
+// takes them off. Categories that cannot join the form are skipped with the
+// reason rather than failing the batch.
+func (h *LookupHandler) SetCategoriesIncidentForm(c *fiber.Ctx) error {
+	var req models.LookupCategoriesIncidentFormRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		return utils.FormatValidationError(c, err)
+	}
+
+	result, err := h.repo.SetCategoriesIncidentForm(h.requestContext(c), req.IDs, *req.AddToIncidentForm)
+	if err != nil {
+		return lookupWriteError(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Categories updated", result)
+}
+
+// GetCategoriesBatch returns the categories with the requested ids, with their
+// values, in request order. Ids that match no category are listed as missing.
+func (h *LookupHandler) GetCategoriesBatch(c *fiber.Ctx) error {
+	var req models.LookupCategoryBatchRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		return utils.FormatValidationError(c, err)
+	}
+
+	categories, err := h.repo.FindCategoriesByIDs(h.requestContext(c), req.IDs)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	byID := make(map[uuid.UUID]*models.LookupCategory, len(categories))
+	for i := range categories {
+		byID[categories[i].ID] = &categories[i]
+	}
+
+	resp := models.LookupCategoryBatchResponse{
+		Categories: make([]models.LookupCategoryResponse, 0, len(categories)),
+		Missing:    []uuid.UUID{},
+	}
+	seen := make(map[uuid.UUID]bool, len(req.IDs))
+	for _, id := range req.IDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if category, ok := byID[id]; ok {
+			resp.Categories = append(resp.Categories, models.ToLookupCategoryResponse(category))
+		} else {
+			resp.Missing = append(resp.Missing, id)
+		}
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Categories retrieved", resp)
+}
+
+// UpsertCategoryByCode creates the category named by the path code, or updates
+// it when it exists, answering 201 or 200 accordingly. System categories keep
+// their active state, as with UpdateCategory.
+func (h *LookupHandler) UpsertCategoryByCode(c *fiber.Ctx) error {
+	code := strings.ToUpper(c.Params("code"))
+	if code == "" || len(code) > 50 {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "code must be 1-50 characters")
+	}
+
+	var req models.LookupCategoryUpsertRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		return utils.FormatValidationError(c, err)
+	}
+
+	var validationErr error
+	var takenGlobally bool
+	var prefixConflict *models.LookupCategory
+	category, created, err := h.repo.UpsertCategoryByCode(h.requestContext(c), code, func(category *models.LookupCategory, created bool) error {
+		var before *models.LookupCategoryResponse
+		if created {
+			if takenGlobally = h.categoryCodeTakenGlobally(c, code, uuid.Nil); takenGlobally {
+				return errCategoryCodeTakenGlobally
+			}
+			category.OrgID = requesterOrgID(c)
+		} else {
+			existing := models.ToLookupCategoryResponse(category)
+			before = &existing
+		}
+		category.Name = req.Name
+		category.NameAr = req.NameAr
+		category.Description = req.Description
+		if req.IsActive != nil && !category.IsSystem {
+			category.SetActive(*req.IsActive)
+		}
+		if req.AddToIncidentForm != nil {
+			category.SetIncidentForm(*req.AddToIncidentForm)
+		}
+		if req.DefaultSortDesc != nil {
+			category.DefaultSortDesc = *req.DefaultSortDesc
+		}
+		if req.ValueCodePattern != nil {
+			category.ValueCodePattern = *req.ValueCodePattern
+		}
+		if req.DefaultColor != nil {
+			category.DefaultColor = *req.DefaultColor
+		}
+		if req.AllowedColors != nil {
+			category.AllowedColors = normalizeColors(*req.AllowedColors)
+		}
+
+		if validationErr = h.validateIncidentFormCategory(category); validationErr != nil {
+			return validationErr
+		}
+
+		conflict, err := h.incidentFormPrefixConflict(c, before, category)
+		if err != nil {
+			return err
+		}
+		if prefixConflict = conflict; conflict != nil {
+			return repository.ErrLookupIncidentFormPrefixTaken
+		}
+		return nil
+	})
+	if validationErr != nil {
+		return utils.FormatValidationError(c, validationErr)
+	}
+	if takenGlobally {
+		return categoryCodeTakenGlobally(c)
+	}
+	if prefixConflict != nil {
+		return incidentFormPrefixTaken(c, prefixConflict)
+	}
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate") || strings.Contains(err.Error(), "unique") {
+			return h.categoryCodeConflict(c, code)
+		}
+		return lookupWriteError(c, err)
+	}
+
+	if created {
+		return utils.SuccessResponse(c, fiber.StatusCreated, "Category created", models.ToLookupCategoryResponse(category))
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, "Category updated", models.ToLookupCategoryResponse(category))
+}
+
+// validateIncidentFormCategory requires an Arabic name on categories shown on
+// the bilingual incident form
+func (h *LookupHandler) validateIncidentFormCategory(category *models.LookupCategory) error {
+	// Inactive categories lose the flag on save, see LookupCategory.BeforeSave
+	if !category.AddToIncidentForm || !category.IsActive {
+		return nil
+	}
+	return h.validator.Struct(models.LookupIncidentFormCheck{NameAr: strings.TrimSpace(category.NameAr)})
+}
+
+// incidentFormPrefixConflict returns the incident-form category already holding
+// category's code prefix when saving category would put it on the form next to
+// it. before is the category as stored, nil when it is being created; a
+// category that was already on the form under the same code is not checked.
+func (h *LookupHandler) incidentFormPrefixConflict(c *fiber.Ctx, before *models.LookupCategoryResponse, category *models.LookupCategory) (*models.LookupCategory, error) {
+	if !category.AddToIncidentForm || !category.IsActive {
+		return nil, nil
+	}
+	if before != nil && before.AddToIncidentForm && before.IsActive && before.Code == category.Code {
+		return nil, nil
+	}
+	return h.repo.FindIncidentFormPrefixConflict(h.requestContext(c), category)
+}
+
+// incidentFormPrefixTaken reports the category already on the incident form
+// for the code prefix
+func incidentFormPrefixTaken(c *fiber.Ctx, conflict *models.LookupCategory) error {
+	return utils.ErrorResponseWithData(c, fiber.StatusConflict, "Another category with the same code prefix is already on the incident form", models.LookupConflictResponse{
+		ID:   conflict.ID,
+		Code: conflict.Code,
+		Name: conflict.Name,
+	})
+}
+
+func (h *LookupHandler) DeleteCategory(c *fiber.Ctx) error {
+	id, err := utils.ParseUUIDParam(c, "category_id")
+	if err != nil {
+		return err
+	}
+
+	category, err := h.repo.FindCategoryByID(h.requestContext(c), id)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Category not found")
+	}
+
+	// System categories cannot be deleted
+	if category.IsSystem {
+		return utils.ErrorResponse(c, fiber.StatusUnprocessableEntity, "System categories cannot be deleted")
+	}
+
+	if err := h.repo.DeleteCategory(h.requestContext(c), id); err != nil {
+		return lookupWriteError(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Category deleted", nil)
+}
+
+// PreviewDeleteCategory reports what deleting a category would remove: its
+// values, its default and how many incidents use its values. Nothing changes.
+func (h *LookupHandler) PreviewDeleteCategory(c *fiber.Ctx) error {
+	id, err := utils.ParseUUIDParam(c, "category_id")
+	if err != nil {
+		return err
+	}
+
+	category, err := h.repo.FindCategoryByID(h.requestContext(c), id)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Category not found")
+	}
+
+	usage, err := h.repo.CountCategoryUsage(h.requestContext(c), id)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Delete preview retrieved", models.NewLookupCategoryDeletePreview(category, usage))
+}
+
+// ListCategories returns a page of categories; see utils.ParseListOptions for
+// the supported query parameters
+func (h *LookupHandler) ListCategories(c *fiber.Ctx) error {
+	opts, err := utils.ParseListOptions(c, h.config.ClampListLimit)
+	if err != nil {
+		return err
+	}
+
+	compact, err := parseCompactView(c)
+	if err != nil {
+		return err
+	}
+
+	fields, err := utils.ParseFields(c, categoryShape(compact))
+	if err != nil {
+		return err
+	}
+
+	categories, total, err := h.repo.ListCategoriesPaged(h.requestContext(c), opts)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	responses := make([]models.LookupCategoryResponse, len(categories))
+	for i, cat := range categories {
+		responses[i] = models.ToLookupCategoryResponse(&cat)
+	}
+
+	data, err := utils.SelectFields(categoryView(compact, responses), fields)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	return utils.PaginatedSuccessResponse(c, data, opts.Page, opts.Limit, total)
+}
+
+// GetStats returns category and value totals for the admin dashboard
+func (h *LookupHandler) GetStats(c *fiber.Ctx) error {
+	stats, err := h.repo.GetLookupStats(h.requestContext(c))
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Lookup stats retrieved", stats)
+}
+
+// GetInactiveDefaults reports the categories left without a default because
+// their default value was deactivated
+func (h *LookupHandler) GetInactiveDefaults(c *fiber.Ctx) error {
+	defaults, err := h.repo.ListInactiveDefaults(h.requestContext(c))
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	categories := make(map[uuid.UUID]bool, len(defaults))
+	for _, d := range defaults {
+		categories[d.CategoryID] = true
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Inactive defaults retrieved", models.LookupInactiveDefaultsReport{
+		Count:    len(categories),
+		Defaults: defaults,
+	})
+}
+
+// GetMissingDefaults reports the active categories with active values but no
+// default, the ones GetInactiveDefaults misses because no value, inactive or
+// not, is flagged as the default
+func (h *LookupHandler) GetMissingDefaults(c *fiber.Ctx) error {
+	missing, err := h.repo.ListMissingDefaults(h.requestContext(c))
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Missing defaults retrieved", models.LookupMissingDefaultsReport{
+		Count:      len(missing),
+		Categories: missing,
+	})
+}
+
+// ListColorUsage returns each color assigned to values with its usage count,
+// for pruning the palette
+func (h *LookupHandler) ListColorUsage(c *fiber.Ctx) error {
+	usage, err := h.repo.ListColorUsage(h.requestContext(c))
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Color usage retrieved", usage)
+}
+
+// RecolorValues remaps value colors from a {"#old": "#new"} body, e.g. after a
+// rebrand, and reports how many values each old color changed
+func (h *LookupHandler) RecolorValues(c *fiber.Ctx) error {
+	var body map[string]string
+	if err := c.BodyParser(&body); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Body must map old colors to new colors")
+	}
+	if len(body) == 0 {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "At least one color mapping is required")
+	}
+	if len(body) > models.MaxLookupRecolorMappings {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest,
+			fmt.Sprintf("At most %d colors can be remapped per request", models.MaxLookupRecolorMappings))
+	}
+
+	mapping := make(map[string]string, len(body))
+	for old, replacement := range body {
+		old, replacement = utils.NormalizeColor(old), utils.NormalizeColor(replacement)
+		if old == "" || replacement == "" {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Colors cannot be empty")
+		}
+		if len(replacement) > 50 {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, fmt.Sprintf("Color %q is longer than 50 characters", replacement))
+		}
+		if existing, ok := mapping[old]; ok && existing != replacement {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, fmt.Sprintf("Color %s is mapped more than once", old))
+		}
+		mapping[old] = replacement
+	}
+
+	result, err := h.repo.RecolorValues(h.requestContext(c), mapping)
+	if err != nil {
+		return lookupWriteError(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Values recolored", result)
+}
+
+// ListRecent returns the most recently changed categories and values
+func (h *LookupHandler) ListRecent(c *fiber.Ctx) error {
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	changes, err := h.repo.ListRecentlyUpdated(h.requestContext(c), limit)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Recent changes retrieved", changes)
+}
+
+// errCategoryCodeTakenGlobally aborts an upsert whose code another org holds
+// under the global code scope
+var errCategoryCodeTakenGlobally = errors.New("category code is used by another organization")
+
+// categoryCodeTakenGlobally reports whether, with the global code scope, a
+// category other than self already uses code in any org. The lookup runs
+// without tenant scoping, and the conflicting category is not disclosed.
+func (h *LookupHandler) categoryCodeTakenGlobally(c *fiber.Ctx, code string, self uuid.UUID) bool {
+	if h.config.CategoryCodeScope != config.CategoryCodeScopeGlobal {
+		return false
+	}
+	existing, err := h.repo.FindCategoryByCodeAnyStatus(c.UserContext(), code)
+	return err == nil && existing.ID != self
+}
+
+func categoryCodeTakenGlobally(c *fiber.Ctx) error {
+	return utils.ErrorResponse(c, fiber.StatusConflict, "Category code is already used by another organization")
+}
+
+// categoryCodeConflict reports a duplicate category code, pointing at the
+// category that already owns it when the requester can see it.
+func (h *LookupHandler) categoryCodeConflict(c *fiber.Ctx, code string) error {
+	existing, err := h.repo.FindCategoryByCodeAnyStatus(h.requestContext(c), code)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusConflict, "Category with this code already exists")
+	}
+	return utils.ErrorResponseWithData(c, fiber.StatusConflict, "Category with this code already exists", models.LookupConflictResponse{
+		ID:   existing.ID,
+		Code: existing.Code,
+		Name: existing.Name,
+	})
+}
+
+// valueCodeConflict reports a duplicate value code within a category
+func valueCodeConflict(c *fiber.Ctx, existing *models.LookupValue) error {
+	return utils.ErrorResponseWithData(c, fiber.StatusConflict, "Value with this code already exists in the category", models.LookupConflictResponse{
+		ID:   existing.ID,
+		Code: existing.Code,
+		Name: existing.Name,
+	})
+}
+
+// Value handlers
+
+func (h *LookupHandler) CreateValue(c *fiber.Ctx) error {
+	categoryID, err := utils.ParseUUIDParam(c, "category_id")
+	if err != nil {
+		return err
+	}
+
+	// Verify category exists
+	category, err := h.repo.FindCategoryByID(h.requestContext(c), categoryID)
+	if err != nil && category == nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Category not found")
+	}
+
+	var req models.LookupValueCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		return utils.FormatValidationError(c, err)
+	}
+
+	// Normalize code to uppercase
+	req.Code = strings.ToUpper(req.Code)
+
+	id, err := h.clientValueID(c, req.ID)
+	if err != nil {
+		return err
+	}
+
+	codeErrors, err := h.valueCodeErrors(category, req.Code)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+	codeErrors = append(codeErrors, valueColorErrors(category, req.Color)...)
+	var metadata models.LookupMetadata
+	if req.Metadata != nil {
+		var metadataErrors []utils.ValidationError
+		metadata, metadataErrors = decodeValueMetadata(req.Metadata)
+		codeErrors = append(codeErrors, metadataErrors...)
+	}
+	if codeErrors != nil {
+		return utils.ValidationFailedResponse(c, codeErrors)
+	}
+
+	if existing, err := h.repo.FindValueByCode(h.requestContext(c), categoryID, req.Code); err == nil {
+		return valueCodeConflict(c, existing)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	value := &models.LookupValue{
+		ID:          id,
+		OrgID:       requesterOrgID(c),
+		CategoryID:  categoryID,
+		Code:        req.Code,
+		Name:        req.Name,
+		NameAr:      req.NameAr,
+		Description: req.Description,
+		Color:       req.Color,
+		IsDefault:   req.WantsDefault(),
+		IsActive:    true,
+
+		DefaultWeight: req.DefaultWeight,
+		EffectiveFrom: req.EffectiveFrom,
+		EffectiveTo:   req.EffectiveTo,
+		RequiredRole:  req.RequiredRole,
+		Metadata:      metadata,
+	}
+	if err := checkEffectiveWindow(value); err != nil {
+		return err
+	}
+
+	if req.IsActive != nil {
+		value.IsActive = *req.IsActive
+	}
+	if req.Status != "" {
+		value.SetStatus(req.Status)
+	}
+
+	if req.ParentID != nil {
+		if msg := h.validateParent(c, categoryID, uuid.Nil, *req.ParentID); msg != "" {
+			return utils.ErrorResponse(c, fiber.StatusUnprocessableEntity, msg)
+		}
+		value.ParentID = req.ParentID
+	}
+
+	// Values created without an explicit sort order land at the end of the category
+	if req.SortOrder != nil {
+		value.SortOrder = *req.SortOrder
+	} else {
+		nextOrder, err := h.repo.NextSortOrder(h.requestContext(c), categoryID)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to determine sort order")
+		}
+		value.SortOrder = nextOrder
+	}
+
+	// Computed before saving, while category.Values holds only the existing values
+	activeValues := countActiveValues(category.Values)
+	if value.IsActive && h.config.MaxValuesPerCategory > 0 && activeValues >= h.config.MaxValuesPerCategory {
+		return utils.ErrorResponse(c, fiber.StatusUnprocessableEntity,
+			fmt.Sprintf("Category already has the maximum of %d active values", h.config.MaxValuesPerCategory))
+	}
+	warnings := nearDuplicateWarnings(value, category.Values)
+	if value.IsActive {
+		if warning := h.valueLimitWarning(activeValues + 1); warning != "" {
+			warnings = append(warnings, warning)
+		}
+	}
+
+	if err := h.saveValue(c, value, req.SortOrder != nil, true, value.IsDefault); err != nil {
+		return err
+	}
+
+	if len(warnings) > 0 {
+		return utils.SuccessResponseWithWarnings(c, fiber.StatusCreated, "Value created", models.ToLookupValueResponse(value), warnings)
+	}
+	return utils.SuccessResponse(c, fiber.StatusCreated, "Value created", models.ToLookupValueResponse(value))
+}
+
+// CloneValue creates a value in the same category under a new code and name,
+// copying the Arabic name, description, color and required role of the
+// source. The clone is active, not the default, and goes after the category's
+// last value.
+func (h *LookupHandler) CloneValue(c *fiber.Ctx) error {
+	id, err := utils.ParseUUIDParam(c, "value_id")
+	if err != nil {
+		return err
+	}
+
+	var req models.LookupValueCloneRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if err := h.validator.Struct(&req); err != nil {
+		return utils.FormatValidationError(c, err)
+	}
+	req.Code = strings.ToUpper(req.Code)
+
+	source, err := h.repo.FindValueByID(h.requestContext(c), id)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Value not found")
+	}
+	category, err := h.repo.FindCategoryByID(h.requestContext(c), source.CategoryID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Category not found")
+	}
+
+	codeErrors, err := h.valueCodeErrors(category, req.Code)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+	codeErrors = append(codeErrors, valueColorErrors(category, source.Color)...)
+	if codeErrors != nil {
+		return utils.ValidationFailedResponse(c, codeErrors)
+	}
+	if existing, err := h.repo.FindValueByCode(h.requestContext(c), category.ID, req.Code); err == nil {
+		return valueCodeConflict(c, existing)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	activeValues := countActiveValues(category.Values)
+	if h.config.MaxValuesPerCategory > 0 && activeValues >= h.config.MaxValuesPerCategory {
+		return utils.ErrorResponse(c, fiber.StatusUnprocessableEntity,
+			fmt.Sprintf("Category already has the maximum of %d active values", h.config.MaxValuesPerCategory))
+	}
+
+	nextOrder, err := h.repo.NextSortOrder(h.requestContext(c), category.ID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to determine sort order")
+	}
+
+	clone := &models.LookupValue{
+		OrgID:       requesterOrgID(c),
+		CategoryID:  category.ID,
+		Code:        req.Code,
+		Name:        req.Name,
+		NameAr:      source.NameAr,
+		Description: source.Description,
+		Color:       source.Color,
+		SortOrder:   nextOrder,
+		IsActive:    true,
+
+		RequiredRole: source.RequiredRole,
+	}
+	if err := h.repo.CreateValue(h.requestContext(c), clone); err != nil {
+		return lookupWriteError(c, err)
+	}
+
+	if warning := h.valueLimitWarning(activeValues + 1); warning != "" {
+		return utils.SuccessResponseWithWarnings(c, fiber.StatusCreated, "Value cloned", models.ToLookupValueResponse(clone), []string{warning})
+	}
+	return utils.SuccessResponse(c, fiber.StatusCreated, "Value cloned", models.ToLookupValueResponse(clone))
+}
+
+// clientValueID parses the ID a client chose for a value it creates, uuid.Nil
+// when it chose none. An ID already in use is a 409, so an offline client
+// retrying a create it already synced can tell.
+func (h *LookupHandler) clientValueID(c *fiber.Ctx, raw string) (uuid.UUID, error) {
+	if raw == "" {
+		return uuid.Nil, nil
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil || id == uuid.Nil {
+		return uuid.Nil, fiber.NewError(fiber.StatusBadRequest, "id must be a non-nil UUID")
+	}
+	exists, err := h.repo.ValueIDExists(h.requestContext(c), id)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if exists {
+		return uuid.Nil, fiber.NewError(fiber.StatusConflict, fmt.Sprintf("A value with id %s already exists", id))
+	}
+	return id, nil
+}
+
+func countActiveValues(values []models.LookupValue) int {
+	active := 0
+	for _, v := range values {
+		if v.IsActive {
+			active++
+		}
+	}
+	return active
+}
+
+// valueLimitWarning returns a heads-up once a category holds activeValues active
+// values and that reaches the configured share of MaxValuesPerCategory, or ""
+// below it or when no cap is configured
+func (h *LookupHandler) valueLimitWarning(activeValues int) string {
+	limit := h.config.MaxValuesPerCategory
+	if limit <= 0 {
+		return ""
+	}
+	// Integer form of activeValues/limit >= percent/100, so 80% of 10 is exactly 8
+	if activeValues*100 < limit*h.config.ValuesSoftLimitPercent {
+		return ""
+	}
+	return fmt.Sprintf("Category has %d of at most %d active values", activeValues, limit)
+}
+
+// nearDuplicateWarnings names the active values whose name is close enough to
+// the new value's to likely be the same entry mistyped, e.g. "Hight" and "High".
+// They are reported as warnings and never block creation.
+func nearDuplicateWarnings(value *models.LookupValue, existing []models.LookupValue) []string {
+	var warnings []string
+	for _, other := range existing {
+		if !other.IsActive {
+			continue
+		}
+		if utils.NameSimilarity(value.Name, other.Name) >= utils.NearDuplicateThreshold {
+			warnings = append(warnings, fmt.Sprintf("Name %q is very similar to existing value %s (%q)", value.Name, other.Code, other.Name))
+		}
+	}
+	return warnings
+}
+
+// BulkCreateValues creates many values in a category at once. Codes that already
+// exist in the category are rejected.
+func (h *LookupHandler) BulkCreateValues(c *fiber.Ctx) error {
+	return h.bulkSaveValues(c, false)
+}
+
+// UpsertValues creates or updates many values in a category at once, matching
+// existing values on code.
+func (h *LookupHandler) UpsertValues(c *fiber.Ctx) error {
+	return h.bulkSaveValues(c, true)
+}
+
+// ValidateValues runs the create-time checks over a proposed value set without
+// saving anything, for inline validation in the admin UI. Each value gets its
+// own result; duplicate codes, several defaults and the category's value cap
+// are reported at set level. The response is 200 whether or not the set is
+// valid; only a malformed body is rejected.
+func (h *LookupHandler) ValidateValues(c *fiber.Ctx) error {
+	categoryID, err := utils.ParseUUIDParam(c, "category_id")
+	if err != nil {
+		return err
+	}
+
+	category, err := h.repo.FindCategoryByID(h.requestContext(c), categoryID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Category not found")
+	}
+
+	var req models.LookupValueBulkRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	// Only the shape of the set is enforced here; the items are checked below
+	if err := h.validator.Var(req.Values, "required,min=1,max=500"); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "values must hold 1 to 500 items")
+	}
+
+	existing := make(map[string]bool, len(category.Values))
+	for _, v := range category.Values {
+		existing[v.Code] = true
+	}
+
+	result := models.LookupValueSetValidation{
+		Items:     make([]models.LookupValueCheck, len(req.Values)),
+		SetErrors: []string{},
+	}
+	positions := make(map[string][]int, len(req.Values))
+	var codes, defaults []string
+	active := 0
+	for i, item := range req.Values {
+		code := strings.ToUpper(item.Code)
+		check := models.LookupValueCheck{Index: i, Code: code, Errors: []utils.ValidationError{}}
+
+		if err := h.validator.Struct(&item); err != nil {
+			check.Errors = append(check.Errors, utils.ValidationDetails(err)...)
+		}
+		if code != "" {
+			codeErrors, err := h.valueCodeErrors(category, code)
+			if err != nil {
+				return utils.InternalErrorResponse(c, err)
+			}
+			check.Errors = append(check.Errors, codeErrors...)
+			if existing[code] {
+				check.Errors = append(check.Errors, utils.ValidationError{Field: "code", Message: fmt.Sprintf("code %s is already used in the category", code)})
+			}
+			if len(positions[code]) == 0 {
+				codes = append(codes, code)
+			}
+			positions[code] = append(positions[code], i)
+		}
+		if item.ParentID != nil {
+			if msg := h.validateParent(c, categoryID, uuid.Nil, *item.ParentID); msg != "" {
+				check.Errors = append(check.Errors, utils.ValidationError{Field: "parent_id", Message: msg})
+			}
+		}
+		if item.EffectiveFrom != nil && item.EffectiveTo != nil && item.EffectiveFrom.After(*item.EffectiveTo) {
+			check.Errors = append(check.Errors, utils.ValidationError{Field: "effective_to", Message: "effective_from must not be after effective_to"})
+		}
+		check.Errors = append(check.Errors, valueColorErrors(category, item.Color)...)
+		if item.WantsDefault() {
+			defaults = append(defaults, code)
+			if category.LockDefault {
+				check.Errors = append(check.Errors, utils.ValidationError{Field: "is_default", Message: "The default value of this category is locked"})
+			}
+		}
+		if item.IsActive == nil || *item.IsActive {
+			if item.Status == "" || item.Status == models.LookupValueStatusActive {
+				active++
+			}
+		}
+
+		check.Valid = len(check.Errors) == 0
+		result.Items[i] = check
+	}
+
+	for _, code := range codes {
+		if len(positions[code]) > 1 {
+			result.SetErrors = append(result.SetErrors, fmt.Sprintf("Code %s appears %d times in the set", code, len(positions[code])))
+		}
+	}
+	if len(defaults) > 1 {
+		result.SetErrors = append(result.SetErrors, fmt.Sprintf("Only one value can be the default, %d are: %s", len(defaults), strings.Join(defaults, ", ")))
+	}
+	if limit := h.config.MaxValuesPerCategory; limit > 0 && countActiveValues(category.Values)+active > limit {
+		result.SetErrors = append(result.SetErrors, fmt.Sprintf("Category would exceed the maximum of %d active values", limit))
+	}
+
+	result.Valid = len(result.SetErrors) == 0
+	for _, check := range result.Items {
+		result.Valid = result.Valid && check.Valid
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, "Values validated", result)
+}
+
+// bulkSaveValues backs the bulk create and upsert endpoints. With
+// ?include_category=true the category is returned once at the top level.
+func (h *LookupHandler) bulkSaveValues(c *fiber.Ctx, upsert bool) error {
+	categoryID, err := utils.ParseUUIDParam(c, "category_id")
+	if err != nil {
+		return err
+	}
+
+	category, err := h.repo.FindCategoryByID(h.requestContext(c), categoryID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Category not found")
+	}
+
+	var req models.LookupValueBulkRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		return utils.FormatValidationError(c, err)
+	}
+
+	existing := make(map[string]*models.LookupValue, len(category.Values))
+	for i := range category.Values {
+		existing[category.Values[i].Code] = &category.Values[i]
+	}
+
+	nextOrder, err := h.repo.NextSortOrder(h.requestContext(c), categoryID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to determine sort order")
+	}
+
+	seen := make(map[string]bool, len(req.Values))
+	defaults := 0
+	seenIDs := make(map[string]bool)
+	var toCreate, toUpdate []models.LookupValue
+	for _, item := range req.Values {
+		code := strings.ToUpper(item.Code)
+		if seen[code] {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, fmt.Sprintf("Duplicate code %s in request", code))
+		}
+		seen[code] = true
+		if id := strings.ToLower(item.ID); id != "" {
+			if seenIDs[id] {
+				return utils.ErrorResponse(c, fiber.StatusBadRequest, fmt.Sprintf("Duplicate id %s in request", id))
+			}
+			seenIDs[id] = true
+		}
+
+		codeErrors, err := h.valueCodeErrors(category, code)
+		if err != nil {
+			return utils.InternalErrorResponse(c, err)
+		}
+		codeErrors = append(codeErrors, valueColorErrors(category, item.Color)...)
+		if codeErrors != nil {
+			return utils.ValidationFailedResponse(c, codeErrors)
+		}
+
+		if item.WantsDefault() {
+			defaults++
+			if defaults > 1 {
+				return utils.ErrorResponse(c, fiber.StatusUnprocessableEntity, "Only one value can be the default")
+			}
+		}
+
+		if item.ParentID != nil {
+			if msg := h.validateParent(c, categoryID, uuid.Nil, *item.ParentID); msg != "" {
+				return utils.ErrorResponse(c, fiber.StatusUnprocessableEntity, fmt.Sprintf("%s: %s", code, msg))
+			}
+		}
+
+		current, exists := existing[code]
+		if exists && !upsert {
+			return valueCodeConflict(c, current)
+		}
+
+		if exists {
+			if category.IsSystem && item.IsDefault != nil && *item.IsDefault != current.IsDefault {
+				return systemValueFieldsRejected(c, []string{"is_default"})
+			}
+			value := *current
+			value.Name = item.Name
+			value.NameAr = item.NameAr
+			value.Description = item.Description
+			value.Color = item.Color
+			if item.IsDefault != nil {
+				value.IsDefault = *item.IsDefault
+			}
+			if item.SortOrder != nil {
+				value.SortOrder = *item.SortOrder
+			}
+			if item.ParentID != nil {
+				value.ParentID = item.ParentID
+			}
+			if item.IsActive != nil {
+				value.IsActive = *item.IsActive
+			}
+			if item.Status != "" {
+				value.SetStatus(item.Status)
+			}
+			toUpdate = append(toUpdate, value)
+			continue
+		}
+
+		id, err := h.clientValueID(c, item.ID)
+		if err != nil {
+			return err
+		}
+		if id == uuid.Nil {
+			id = uuid.New()
+		}
+		value := models.LookupValue{
+			ID:          id,
+			OrgID:       requesterOrgID(c),
+			CategoryID:  categoryID,
+			ParentID:    item.ParentID,
+			Code:        code,
+			Name:        item.Name,
+			NameAr:      item.NameAr,
+			Description: item.Description,
+			Color:       item.Color,
+			IsDefault:   item.WantsDefault(),
+			IsActive:    true,
+		}
+		if item.IsActive != nil {
+			value.IsActive = *item.IsActive
+		}
+		if item.Status != "" {
+			value.SetStatus(item.Status)
+		}
+		if item.SortOrder != nil {
+			value.SortOrder = *item.SortOrder
+		} else {
+			value.SortOrder = nextOrder
+			nextOrder++
+		}
+		toCreate = append(toCreate, value)
+	}
+
+	if err := h.repo.BulkSaveValues(h.requestContext(c), categoryID, toCreate, toUpdate); err != nil {
+		return lookupWriteError(c, err)
+	}
+
+	resp := models.LookupBulkValuesResponse{
+		Created: make([]models.LookupValueResponse, len(toCreate)),
+		Updated: make([]models.LookupValueResponse, len(toUpdate)),
+	}
+	for i := range toCreate {
+		resp.Created[i] = models.ToLookupValueResponse(&toCreate[i])
+	}
+	for i := range toUpdate {
+		resp.Updated[i] = models.ToLookupValueResponse(&toUpdate[i])
+	}
+	if c.QueryBool("include_category", false) {
+		// Reload so the embedded category reflects the saved values
+		if updated, err := h.repo.FindCategoryByID(h.requestContext(c), categoryID); err == nil {
+			category = updated
+		}
+		catResp := models.ToLookupCategoryResponse(category)
+		resp.Category = &catResp
+	}
+
+	status := fiber.StatusCreated
+	if upsert {
+		status = fiber.StatusOK
+	}
+	return utils.SuccessResponse(c, status, "Values saved", resp)
+}
+
+// ListOrphanValues lists values whose category no longer exists; their
+// category_id is the dead category they pointed to
+func (h *LookupHandler) ListOrphanValues(c *fiber.Ctx) error {
+	values, err := h.repo.ListOrphanValues(h.requestContext(c))
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	responses := make([]models.LookupValueResponse, len(values))
+	for i := range values {
+		responses[i] = models.ToLookupValueResponse(&values[i])
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, "Orphaned values retrieved", responses)
+}
+
+// CleanupOrphanValues soft-deletes orphaned values or reassigns them to an
+// existing category
+func (h *LookupHandler) CleanupOrphanValues(c *fiber.Ctx) error {
+	var req models.LookupOrphanCleanupRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		return utils.FormatValidationError(c, err)
+	}
+
+	var target *uuid.UUID
+	if req.Action == models.LookupOrphanReassign {
+		target = req.CategoryID
+	}
+
+	result, err := h.repo.CleanupOrphanValues(h.requestContext(c), req.IDs, target)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Category not found")
+	}
+	if err != nil {
+		return lookupWriteError(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Orphaned values cleaned up", result)
+}
+
+// RestoreValues brings back soft-deleted values of the category, those listed
+// in ids or all of them with {"all": true}, to undo a bad bulk delete. Values
+// whose code has been taken again stay deleted and are reported as skipped.
+func (h *LookupHandler) RestoreValues(c *fiber.Ctx) error {
+	categoryID, err := utils.ParseUUIDParam(c, "category_id")
+	if err != nil {
+		return err
+	}
+
+	var req models.LookupValueRestoreRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if err := h.validator.Struct(&req); err != nil {
+		return utils.FormatValidationError(c, err)
+	}
+
+	restored, skipped, err := h.repo.RestoreValues(h.requestContext(c), categoryID, req.IDs)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Category not found")
+	}
+	if err != nil {
+		return lookupWriteError(c, err)
+	}
+
+	result := models.LookupValueRestoreResult{
+		Restored: make([]models.LookupValueResponse, len(restored)),
+		Skipped:  skipped,
+	}
+	for i := range restored {
+		result.Restored[i] = models.ToLookupValueResponse(&restored[i])
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, "Values restored", result)
+}
+
+// SearchValues searches active values across categories by code, name, Arabic
+// name or description, a page at a time. With ?fulltext=true it uses ranked
+// full-text search.
+func (h *LookupHandler) SearchValues(c *fiber.Ctx) error {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "q is required")
+	}
+
+	opts, err := utils.ParseListOptions(c, h.config.ClampListLimit)
+	if err != nil {
+		return err
+	}
+
+	var values []models.LookupValue
+	var total int64
+	if c.QueryBool("fulltext", false) {
+		values, total, err = h.repo.FullTextSearchValues(h.readContext(c), query, opts)
+	} else {
+		values, total, err = h.repo.SearchValues(h.readContext(c), query, opts)
+	}
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	responses := make([]models.LookupValueResponse, len(values))
+	for i := range values {
+		responses[i] = models.ToLookupValueResponse(&values[i])
+	}
+
+	return utils.PaginatedSuccessResponse(c, responses, opts.Page, opts.Limit, total)
+}
+
+// AutocompleteValues suggests the active values of a category for type-ahead:
+// those whose code, name or Arabic name starts with ?q come first, then those
+// containing it. ?limit is 1-50, 10 by default.
+func (h *LookupHandler) AutocompleteValues(c *fiber.Ctx) error {
+	categoryID, err := utils.ParseUUIDParam(c, "category_id")
+	if err != nil {
+		return err
+	}
+
+	query := utils.NormalizeSearchQuery(c.Query("q"))
+	if query == "" {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "q is required")
+	}
+
+	limit := c.QueryInt("limit", 10)
+	if limit < 1 || limit > 50 {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "limit must be between 1 and 50")
+	}
+
+	values, err := h.repo.AutocompleteValues(h.readContext(c), categoryID, query, limit)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	responses := make([]models.CompactLookupValueResponse, len(values))
+	for i := range values {
+		responses[i] = models.ToCompactLookupValueResponse(models.ToLookupValueResponse(&values[i]))
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Suggestions retrieved", responses)
+}
+
+func (h *LookupHandler) GetValueByID(c *fiber.Ctx) error {
+	id, err := utils.ParseUUIDParam(c, "value_id")
+	if err != nil {
+		return err
+	}
+
+	value, err := h.repo.FindValueByID(h.requestContext(c), id)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Value not found")
+	}
+
+	// ?include=category embeds the full category instead of just its code and name
+	if c.Query("include") == "category" {
+		return utils.SuccessResponse(c, fiber.StatusOK, "Value retrieved", models.ToLookupValueResponseWithCategory(value))
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Value retrieved", models.ToLookupValueResponse(value))
+}
+
+// ShareValue issues a short-lived token that resolves to the value through
+// GET /public/shared/:token, e.g. for a QR code scanned by the field app
+func (h *LookupHandler) ShareValue(c *fiber.Ctx) error {
+	id, err := utils.ParseUUIDParam(c, "value_id")
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.repo.FindValueByID(h.requestContext(c), id); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Value not found")
+	}
+
+	token, expiresAt, err := h.jwtManager.GenerateShareToken(id, h.config.ShareTokenTTL)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Share link created", models.LookupValueShareResponse{
+		Token:     token,
+		Path:      lookupSharedPath + "/" + token,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// GetSharedValue returns the value a share token points to. The token is the
+// only credential, so the lookup is not scoped to a tenant.
+func (h *LookupHandler) GetSharedValue(c *fiber.Ctx) error {
+	claims, err := h.jwtManager.ValidateShareToken(c.Params("token"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusUnauthorized, err.Error())
+	}
+
+	value, err := h.repo.FindValueByID(repository.WithRequesterRole(c.UserContext(), ""), claims.ValueID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Value not found")
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Value retrieved", models.ToLookupValueResponseWithCategory(value))
+}
+
+func (h *LookupHandler) UpdateValue(c *fiber.Ctx) error {
+	id, err := utils.ParseUUIDParam(c, "value_id")
+	if err != nil {
+		return err
+	}
+
+	changedOnly, err := utils.ParseReturnChanged(c)
+	if err != nil {
+		return err
+	}
+
+	if len(bytes.TrimSpace(c.Body())) == 0 {
+		return noFieldsToUpdate(c)
+	}
+	var req models.LookupValueUpdateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if reflect.ValueOf(req).IsZero() {
+		return noFieldsToUpdate(c)
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		return utils.FormatValidationError(c, err)
+	}
+
+	value, err := h.repo.FindValueByID(h.requestContext(c), id)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Value not found")
+	}
+
+	changes, metadataErrors := updateRequestChanges(&req)
+	if metadataErrors != nil {
+		return utils.ValidationFailedResponse(c, metadataErrors)
+	}
+
+	return h.updateValue(c, value, changes, changedOnly)
+}
+
+// updateRequestChanges returns the changes a PUT body makes; only its metadata
+// can fail to decode
+func updateRequestChanges(req *models.LookupValueUpdateRequest) (valueChanges, []utils.ValidationError) {
+	// Empty strings and omitted fields both mean "keep"; PATCH can clear fields
+	changes := valueChanges{
+		Code:          nonEmpty(req.Code),
+		Name:          nonEmpty(req.Name),
+		NameAr:        nonEmpty(req.NameAr),
+		Description:   nonEmpty(req.Description),
+		Color:         nonEmpty(req.Color),
+		Status:        nonEmpty(req.Status),
+		SortOrder:     req.SortOrder,
+		ParentID:      req.ParentID,
+		IsDefault:     req.IsDefault,
+		DefaultWeight: req.DefaultWeight,
+		IsActive:      req.IsActive,
+		EffectiveFrom: req.EffectiveFrom,
+		EffectiveTo:   req.EffectiveTo,
+		RequiredRole:  req.RequiredRole,
+	}
+	if req.Metadata != nil {
+		metadata, metadataErrors := decodeValueMetadata(req.Metadata)
+		if metadataErrors != nil {
+			return changes, metadataErrors
+		}
+		changes.Metadata = &metadata
+	}
+	return changes, nil
+}
+
+// decodeValueMetadata decodes the metadata of a create or update request; null
+// decodes to nil
+func decodeValueMetadata(raw json.RawMessage) (models.LookupMetadata, []utils.ValidationError) {
+	var metadata models.LookupMetadata
+	if err := json.Unmarshal(raw, &metadata); err != nil {
+		return nil, []utils.ValidationError{{Field: "metadata", Message: models.ErrLookupMetadataNotObject.Error()}}
+	}
+	return metadata, valueMetadataErrors(metadata)
+}
+
+// valueMetadataErrors checks metadata against models.MaxLookupMetadataBytes
+func valueMetadataErrors(metadata models.LookupMetadata) []utils.ValidationError {
+	encoded, err := json.Marshal(metadata)
+	if err == nil && len(encoded) <= models.MaxLookupMetadataBytes {
+		return nil
+	}
+	return []utils.ValidationError{{
+		Field:   "metadata",
+		Message: fmt.Sprintf("metadata must be at most %d bytes of JSON", models.MaxLookupMetadataBytes),
+	}}
+}
+
+// noFieldsToUpdate rejects an update body that sets no field, which would
+// otherwise save the row unchanged and still bump its updated_at
+func noFieldsToUpdate(c *fiber.Ctx) error {
+	return utils.ErrorResponse(c, fiber.StatusBadRequest, "No fields to update")
+}
+
+func nonEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// protectedSystemValueFields lists the fields changes would alter that are
+// fixed on the values of a system category: integrations match those values by
+// code, so only their display fields and sort order can change
+func protectedSystemValueFields(value *models.LookupValue, changes valueChanges) []string {
+	if value.Category == nil || !value.Category.IsSystem {
+		return nil
+	}
+	var fields []string
+	if changes.Code != nil && strings.ToUpper(*changes.Code) != value.Code {
+		fields = append(fields, "code")
+	}
+	if changes.IsDefault != nil && *changes.IsDefault != value.IsDefault {
+		fields = append(fields, "is_default")
+	}
+	return fields
+}
+
+func systemValueFieldsRejected(c *fiber.Ctx, fields []string) error {
+	return utils.ErrorResponseWithData(c, fiber.StatusUnprocessableEntity,
+		fmt.Sprintf("%s cannot be changed on a system category's values", strings.Join(fields, " and ")),
+		fiber.Map{"fields": fields})
+}
+
+// valueChanges are the fields an update sets; nil fields are left alone
+type valueChanges struct {
+	Code, Name, NameAr, Description, Color, Status *string
+	RequiredRole                                   *string
+	SortOrder                                      *int
+	ParentID                                       *uuid.UUID
+	// ClearParent detaches the value from its parent; ParentID is nil then
+	ClearParent   bool
+	IsDefault     *bool
+	DefaultWeight *int
+	IsActive      *bool
+	// ClearEffectiveFrom and ClearEffectiveTo open that side of the window
+	EffectiveFrom, EffectiveTo           *time.Time
+	ClearEffectiveFrom, ClearEffectiveTo bool
+	// Metadata replaces the metadata; pointing at nil removes it
+	Metadata *models.LookupMetadata
+}
+
+// updateValue applies changes to value under the rules shared by PUT and PATCH,
+// saves it and writes the response
+func (h *LookupHandler) updateValue(c *fiber.Ctx, value *models.LookupValue, changes valueChanges, changedOnly bool) error {
+	before := models.ToLookupValueResponse(value)
+
+	if fields := protectedSystemValueFields(value, changes); len(fields) > 0 {
+		return systemValueFieldsRejected(c, fields)
+	}
+
+	if changes.Code != nil {
+		code := strings.ToUpper(*changes.Code)
+		codeErrors, err := h.valueCodeErrors(value.Category, code)
+		if err != nil {
+			return utils.InternalErrorResponse(c, err)
+		}
+		if codeErrors != nil {
+			return utils.ValidationFailedResponse(c, codeErrors)
+		}
+		existing, err := h.repo.FindValueByCode(h.requestContext(c), value.CategoryID, code)
+		switch {
+		case err == nil && existing.ID != value.ID:
+			return valueCodeConflict(c, existing)
+		case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+			return utils.InternalErrorResponse(c, err)
+		}
+		value.Code = code
+	}
+	if changes.Name != nil {
+		value.Name = *changes.Name
+	}
+	if changes.NameAr != nil {
+		value.NameAr = *changes.NameAr
+	}
+	if changes.Description != nil {
+		value.Description = *changes.Description
+	}
+	if changes.SortOrder != nil {
+		value.SortOrder = *changes.SortOrder
+	}
+	if changes.ParentID != nil {
+		if msg := h.validateParent(c, value.CategoryID, value.ID, *changes.ParentID); msg != "" {
+			return utils.ErrorResponse(c, fiber.StatusUnprocessableEntity, msg)
+		}
+		value.ParentID = changes.ParentID
+	} else if changes.ClearParent {
+		value.ParentID = nil
+	}
+	if changes.Color != nil {
+		if colorErrors := valueColorErrors(value.Category, *changes.Color); colorErrors != nil {
+			return utils.ValidationFailedResponse(c, colorErrors)
+		}
+		value.Color = *changes.Color
+	}
+	var becomesDefault bool
+	if changes.IsDefault != nil {
+		if *changes.IsDefault != value.IsDefault && value.Category != nil && value.Category.LockDefault {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, lookupDefaultLockedMessage)
+		}
+		// A value becoming the default replaces the current one when saved
+		becomesDefault = *changes.IsDefault && !value.IsDefault
+		value.IsDefault = *changes.IsDefault
+	}
+	if changes.DefaultWeight != nil {
+		if *changes.DefaultWeight != value.DefaultWeight && value.Category != nil && value.Category.LockDefault {
+			return utils.ErrorResponse(c, fiber.StatusForbidden, lookupDefaultLockedMessage)
+		}
+		value.DefaultWeight = *changes.DefaultWeight
+	}
+	if changes.IsActive != nil {
+		value.IsActive = *changes.IsActive
+	}
+	if changes.Status != nil {
+		value.SetStatus(*changes.Status)
+	}
+	// An explicit status change means the admin, not an archive, now owns it
+	if changes.IsActive != nil || changes.Status != nil {
+		value.ArchivedAt = nil
+	}
+	if changes.EffectiveFrom != nil || changes.ClearEffectiveFrom {
+		value.EffectiveFrom = changes.EffectiveFrom
+	}
+	if changes.EffectiveTo != nil || changes.ClearEffectiveTo {
+		value.EffectiveTo = changes.EffectiveTo
+	}
+	if changes.RequiredRole != nil {
+		value.RequiredRole = *changes.RequiredRole
+	}
+	if changes.Metadata != nil {
+		value.Metadata = *changes.Metadata
+	}
+	if err := checkEffectiveWindow(value); err != nil {
+		return err
+	}
+
+	if err := h.saveValue(c, value, changes.SortOrder != nil, false, becomesDefault); err != nil {
+		return err
+	}
+
+	data, err := updateResponseData(changedOnly, before, models.ToLookupValueResponse(value))
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Value updated", data)
+}
+
+// checkEffectiveWindow rejects a value whose effective window ends before it
+// starts
+func checkEffectiveWindow(value *models.LookupValue) error {
+	if value.EffectiveFrom != nil && value.EffectiveTo != nil && value.EffectiveFrom.After(*value.EffectiveTo) {
+		return fiber.NewError(fiber.StatusBadRequest, "effective_from must not be after effective_to")
+	}
+	return nil
+}
+
+// updateResponseData returns the updated object, or with ?return=changed only
+// the fields that differ from before plus id and updated_at
+func updateResponseData(changedOnly bool, before, after interface{}) (interface{}, error) {
+	if !changedOnly {
+		return after, nil
+	}
+	return utils.ChangedFields(before, after, "id", "updated_at")
+}
+
+// saveValue creates or updates the value. With ?shift=true and an explicit
+// sort order, values already at or after that slot move down to make room
+// instead of sharing it. With replaceDefault the category's current default is
+// cleared first, under the category's default lock so that concurrent requests
+// cannot both leave a default behind. Failures are returned for the error
+// handler to render, so callers must stop on a non-nil error.
+func (h *LookupHandler) saveValue(c *fiber.Ctx, value *models.LookupValue, explicitOrder, create, replaceDefault bool) error {
+	ctx := h.requestContext(c)
+	shift := explicitOrder && c.QueryBool("shift", false)
+	save := func(repo repository.LookupRepository) error {
+		switch {
+		case create && shift:
+			return repo.CreateValueAtSortOrder(ctx, value)
+		case create:
+			return repo.CreateValue(ctx, value)
+		case shift:
+			return repo.UpdateValueAtSortOrder(ctx, value)
+		default:
+			return repo.UpdateValue(ctx, value)
+		}
+	}
+
+	var err error
+	if replaceDefault {
+		err = h.repo.WithDefaultLock(ctx, value.CategoryID, func(tx repository.LookupRepository) error {
+			if _, err := tx.ClearDefaultForCategory(ctx, value.CategoryID); err != nil {
+				return err
+			}
+			return save(tx)
+		})
+	} else {
+		err = save(h.repo)
+	}
+
+	if errors.Is(err, repository.ErrLookupSortOrderExhausted) {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, fmt.Sprintf("Cannot shift values past sort_order %d", models.MaxLookupSortOrder))
+	}
+	if errors.Is(err, repository.ErrLookupNotWritable) {
+		return fiber.NewError(fiber.StatusForbidden, lookupNotWritableMessage)
+	}
+	if errors.Is(err, repository.ErrLookupDefaultLocked) {
+		return fiber.NewError(fiber.StatusForbidden, lookupDefaultLockedMessage)
+	}
+	if errors.Is(err, repository.ErrLookupSystemValueFixed) {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, lookupSystemValueFixedMessage)
+	}
+	if errors.Is(err, models.ErrInvalidLookupValueStatus) {
+		return fiber.NewError(fiber.StatusBadRequest, lookupInvalidStatusMessage)
+	}
+	return err
+}
+
+// SetDefaultValue makes the value the default of its category. An optional
+// {"category_id"} body guards against pointing at a value of another category.
+func (h *LookupHandler) SetDefaultValue(c *fiber.Ctx) error {
+	id, err := utils.ParseUUIDParam(c, "value_id")
+	if err != nil {
+		return err
+	}
+
+	var req models.LookupSetDefaultRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+		}
+	}
+
+	value, err := h.repo.FindValueByID(h.requestContext(c), id)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Value not found")
+	}
+
+	categoryID := value.CategoryID
+	if req.CategoryID != nil {
+		categoryID = *req.CategoryID
+	}
+
+	isDefault := true
+	if fields := protectedSystemValueFields(value, valueChanges{IsDefault: &isDefault}); len(fields) > 0 {
+		return systemValueFieldsRejected(c, fields)
+	}
+
+	if err := h.repo.SwapDefault(h.requestContext(c), categoryID, value.ID); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrLookupValueNotFound):
+			return utils.ErrorResponse(c, fiber.StatusNotFound, "Value not found")
+		case errors.Is(err, repository.ErrLookupValueWrongCategory):
+			return utils.ErrorResponse(c, fiber.StatusUnprocessableEntity, "Value does not belong to the category")
+		case errors.Is(err, repository.ErrLookupValueInactive):
+			return utils.ErrorResponse(c, fiber.StatusUnprocessableEntity, "Only an active value can be the default")
+		}
+		return lookupWriteError(c, err)
+	}
+	value.IsDefault = true
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Default value set", models.ToLookupValueResponse(value))
+}
+
+// TransferDefault clears the default of a category and makes a value of another
+// category that category's default, in one transaction, for moving the default
+// along with values when categories are merged
+func (h *LookupHandler) TransferDefault(c *fiber.Ctx) error {
+	id, err := utils.ParseUUIDParam(c, "category_id")
+	if err != nil {
+		return err
+	}
+
+	var req models.LookupDefaultTransferRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if err := h.validator.Struct(&req); err != nil {
+		return utils.FormatValidationError(c, err)
+	}
+	if req.ToCategoryID == id {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "to_category_id must be another category")
+	}
+
+	source, err := h.repo.FindCategoryByID(h.requestContext(c), id)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Category not found")
+	}
+	target, err := h.repo.FindCategoryByID(h.requestContext(c), req.ToCategoryID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Target category not found")
+	}
+	value, err := h.repo.FindValueByCode(h.requestContext(c), target.ID, strings.ToUpper(req.ValueCode))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Value not found in the target category")
+	}
+
+	if source.IsSystem || target.IsSystem {
+		return systemValueFieldsRejected(c, []string{"is_default"})
+	}
+	// Both default locks are taken in ID order, as SetDefaultValues takes them,
+	// so a transfer and a batch over the same categories can't deadlock
+	first, second := source.ID, target.ID
+	if first.String() > second.String() {
+		first, second = second, first
+	}
+	ctx := h.requestContext(c)
+	var cleared int64
+	err = h.repo.WithDefaultLock(ctx, first, func(tx repository.LookupRepository) error {
+		return tx.WithDefaultLock(ctx, second, func(tx repository.LookupRepository) error {
+			var err error
+			if cleared, err = tx.ClearDefaultForCategory(ctx, source.ID); err != nil {
+				return err
+			}
+			return tx.SwapDefault(ctx, target.ID, value.ID)
+		})
+	})
+	if errors.Is(err, repository.ErrLookupValueInactive) {
+		return utils.ErrorResponse(c, fiber.StatusUnprocessableEntity, "Only an active value can be the default")
+	}
+	if err != nil {
+		return lookupWriteError(c, err)
+	}
+	value.IsDefault = true
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Default transferred", models.LookupDefaultTransferResponse{
+		SourceCategoryID: source.ID,
+		Cleared:          cleared,
+		Default:          models.ToLookupValueResponse(value),
+	})
+}
+
+// SetDefaultValues sets the defaults of several categories at once from a list
+// of {category_code, value_code} pairs. The batch is atomic: if any pair is
+// invalid nothing changes and the per-pair results explain why.
+func (h *LookupHandler) SetDefaultValues(c *fiber.Ctx) error {
+	var req []models.LookupDefaultAssignment
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if len(req) == 0 {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "At least one default is required")
+	}
+	for i := range req {
+		if err := h.validator.Struct(&req[i]); err != nil {
+			return utils.FormatValidationError(c, err)
+		}
+	}
+
+	results, err := h.repo.SetDefaultValues(h.requestContext(c), req)
+	if errors.Is(err, repository.ErrLookupDefaultsRejected) {
+		return utils.ErrorResponseWithData(c, fiber.StatusUnprocessableEntity, err.Error(), results)
+	}
+	if err != nil {
+		return lookupWriteError(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Defaults set", results)
+}
+
+func (h *LookupHandler) DeleteValue(c *fiber.Ctx) error {
+	id, err := utils.ParseUUIDParam(c, "value_id")
+	if err != nil {
+		return err
+	}
+
+	_, err = h.repo.FindValueByID(h.requestContext(c), id)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Value not found")
+	}
+
+	if err := h.repo.DeleteValue(h.requestContext(c), id); err != nil {
+		return lookupWriteError(c, err)
+	}
+
 	return utils.SuccessResponse(c, fiber.StatusOK, "Value deleted", nil)
 }
 
-func (h *LookupHandler) ListValuesByCategory(c *fiber.Ctx) error {
-	categoryIDStr := c.Params("id")
-	categoryID, err := uuid.Parse(categoryIDStr)
+// ListValuesByCategory returns a page of a category's values; see
+// utils.ParseListOptions for the supported query parameters. ?tag=CODE keeps
+// the values carrying that tag.
+func (h *LookupHandler) ListValuesByCategory(c *fiber.Ctx) error {
+	categoryID, err := utils.ParseUUIDParam(c, "category_id")
+	if err != nil {
+		return err
+	}
+
+	opts, err := utils.ParseListOptions(c, h.config.ClampListLimit)
+	if err != nil {
+		return err
+	}
+	if err := utils.ParseIncludeTotal(c, &opts); err != nil {
+		return err
+	}
+
+	compact, err := parseCompactView(c)
+	if err != nil {
+		return err
+	}
+
+	fields, err := utils.ParseFields(c, valueShape(compact))
+	if err != nil {
+		return err
+	}
+
+	tagCode := strings.ToUpper(strings.TrimSpace(c.Query("tag")))
+	values, total, err := h.repo.ListValuesByCategoryPaged(h.requestContext(c), categoryID, tagCode, opts)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	responses := make([]models.LookupValueResponse, len(values))
+	for i, v := range values {
+		responses[i] = models.ToLookupValueResponse(&v)
+	}
+
+	data, err := utils.SelectFields(valueView(compact, responses), fields)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	if opts.SkipTotal {
+		hasMore := total > int64(opts.Offset()+opts.Limit)
+		return utils.UncountedPaginatedSuccessResponse(c, data, opts.Page, opts.Limit, hasMore)
+	}
+	return utils.PaginatedSuccessResponse(c, data, opts.Page, opts.Limit, total)
+}
+
+// GetValueAncestors returns the value's parent chain from the root down to the
+// value itself, for breadcrumbs in cascading dropdowns
+func (h *LookupHandler) GetValueAncestors(c *fiber.Ctx) error {
+	id, err := utils.ParseUUIDParam(c, "value_id")
+	if err != nil {
+		return err
+	}
+
+	values, err := h.repo.FindValueAncestors(h.requestContext(c), id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Value not found")
+	}
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	response := make([]models.LookupValueResponse, len(values))
+	for i := range values {
+		response[i] = models.ToLookupValueResponse(&values[i])
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, "Value ancestors retrieved", response)
+}
+
+// NormalizeValueOrder renumbers the active values of a category 0..n-1 in
+// their current order, closing gaps and ties left by earlier edits
+func (h *LookupHandler) NormalizeValueOrder(c *fiber.Ctx) error {
+	categoryID, err := utils.ParseUUIDParam(c, "category_id")
+	if err != nil {
+		return err
+	}
+
+	values, err := h.repo.NormalizeSortOrder(h.requestContext(c), categoryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Category not found")
+	}
+	if err != nil {
+		return lookupWriteError(c, err)
+	}
+
+	response := make([]models.LookupValueResponse, len(values))
+	for i := range values {
+		response[i] = models.ToLookupValueResponse(&values[i])
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, "Value order normalized", response)
+}
+
+// ListValuesByMetadata lists the values of a category whose metadata holds
+// ?value under ?key, e.g. to find the value mapped to an external system ID
+func (h *LookupHandler) ListValuesByMetadata(c *fiber.Ctx) error {
+	categoryID, err := utils.ParseUUIDParam(c, "category_id")
+	if err != nil {
+		return err
+	}
+
+	key := strings.TrimSpace(c.Query("key"))
+	if key == "" {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "key is required")
+	}
+
+	values, err := h.repo.ListValuesByMetadataKey(h.requestContext(c), categoryID, key, c.Query("value"))
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	responses := make([]models.LookupValueResponse, len(values))
+	for i := range values {
+		responses[i] = models.ToLookupValueResponse(&values[i])
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, "Values retrieved", responses)
+}
+
+// GetValueTree returns the active values of a category nested by parent.
+// Values whose parent is inactive or deleted appear at the root with
+// "orphaned": true rather than being hidden.
+func (h *LookupHandler) GetValueTree(c *fiber.Ctx) error {
+	categoryID, err := utils.ParseUUIDParam(c, "category_id")
+	if err != nil {
+		return err
+	}
+
+	values, err := h.repo.ListValuesByCategory(h.requestContext(c), categoryID)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	active := make([]models.LookupValue, 0, len(values))
+	for _, v := range values {
+		if v.IsActive {
+			active = append(active, v)
+		}
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Value tree retrieved", models.BuildLookupValueTree(active))
+}
+
+// validateParent checks that parentID can become the parent of valueID (uuid.Nil
+// for a value not created yet). It returns a message describing the problem,
+// or "" when the parent is acceptable.
+func (h *LookupHandler) validateParent(c *fiber.Ctx, categoryID, valueID, parentID uuid.UUID) string {
+	if parentID == valueID {
+		return "A value cannot be its own parent"
+	}
+
+	parent, err := h.repo.FindValueByID(h.requestContext(c), parentID)
+	if err != nil {
+		return "Parent value not found"
+	}
+	if parent.CategoryID != categoryID {
+		return "Parent value must belong to the same category"
+	}
+
+	// Walk up from the parent to make sure the value is not one of its ancestors
+	if valueID != uuid.Nil {
+		seen := map[uuid.UUID]bool{parent.ID: true}
+		for ancestor := parent; ancestor.ParentID != nil; {
+			if *ancestor.ParentID == valueID {
+				return "Parent value would create a cycle"
+			}
+			if seen[*ancestor.ParentID] {
+				break
+			}
+			seen[*ancestor.ParentID] = true
+			ancestor, err = h.repo.FindValueByID(h.requestContext(c), *ancestor.ParentID)
+			if err != nil {
+				break
+			}
+		}
+	}
+
+	return ""
+}
+
+// Public endpoint - Get values by category code
+func (h *LookupHandler) GetValuesByCategoryCode(c *fiber.Ctx) error {
+	timing := utils.StartServerTiming(h.config.ServerTiming)
+	code := strings.ToUpper(c.Params("code"))
+
+	filter := models.LookupValueFilter{
+		IncludeDeprecated: c.QueryBool("include_deprecated", false),
+		AsOf:              time.Now(),
+	}
+	if asOf := c.Query("as_of"); asOf != "" {
+		t, err := time.Parse(time.RFC3339, asOf)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "as_of must be an RFC 3339 timestamp")
+		}
+		filter.AsOf = t
+	}
+
+	compact, err := parseCompactView(c)
+	if err != nil {
+		return err
+	}
+
+	fields, err := utils.ParseFields(c, valueShape(compact))
+	if err != nil {
+		return err
+	}
+
+	// Without a limit the whole category comes back as a flat array, as it
+	// always has; a limit switches to the paginated envelope
+	paginate := c.Query("limit") != ""
+	var opts utils.ListOptions
+	if paginate {
+		if opts, err = utils.ParseListOptions(c, h.config.ClampListLimit); err != nil {
+			return err
+		}
+	}
+
+	dbStart := time.Now()
+	values, err := h.repo.ListValuesByCategoryCode(h.readContext(c), code, filter)
+	timing.DB(dbStart)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	h.setCacheHeaders(c)
+	if len(values) > 0 {
+		dbStart = time.Now()
+		lastModified, err := h.lastModified(c, values[0].CategoryID, values)
+		timing.DB(dbStart)
+		if err != nil {
+			return utils.InternalErrorResponse(c, err)
+		}
+		if utils.CheckNotModified(c, lastModified) {
+			timing.Write(c)
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+	}
+
+	// The page is cut after the role filter so pages and the total only count
+	// values the requester may see
+	total := len(values)
+	if paginate {
+		values = utils.PageOf(values, opts)
+	}
+
+	responses := make([]models.LookupValueResponse, len(values))
+	for i, v := range values {
+		responses[i] = models.ToLookupValueResponse(&v)
+	}
+	dbStart = time.Now()
+	err = h.localizeValues(c, values, responses)
+	timing.DB(dbStart)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	data, err := utils.SelectFields(valueView(compact, responses), fields)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	timing.Write(c)
+	if paginate {
+		return utils.PaginatedSuccessResponse(c, data, opts.Page, opts.Limit, int64(total))
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, "Values retrieved", data)
+}
+
+// lastModified is the latest change among the returned values and any value of
+// the category that has since been deactivated or deleted
+func (h *LookupHandler) lastModified(c *fiber.Ctx, categoryID uuid.UUID, values []models.LookupValue) (time.Time, error) {
+	lastModified := utils.MaxModifiedTime(values, func(v models.LookupValue) time.Time { return v.UpdatedAt })
+	changedAt, err := h.repo.ValuesChangedAt(h.requestContext(c), categoryID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if changedAt.After(lastModified) {
+		lastModified = changedAt
+	}
+	return lastModified, nil
+}
+
+// GetDefaultValue returns the active default value of a category. When values
+// carry a default_weight, the default is drawn among them by weight and flagged
+// with is_weighted. With ?fallback=true a category whose default is missing or
+// deactivated returns its first active value instead, flagged with is_fallback.
+func (h *LookupHandler) GetDefaultValue(c *fiber.Ctx) error {
+	timing := utils.StartServerTiming(h.config.ServerTiming)
+	code := strings.ToUpper(c.Params("code"))
+
+	isFallback := false
+	dbStart := time.Now()
+	value, isWeighted, err := h.repo.GetWeightedDefault(h.readContext(c), code)
+	if errors.Is(err, gorm.ErrRecordNotFound) && c.QueryBool("fallback", false) {
+		value, isFallback, err = h.repo.GetEffectiveDefaultValue(h.readContext(c), code)
+	}
+	timing.DB(dbStart)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Default value not found")
+	}
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	// A weighted draw must not be cached, or every client keeps its first draw
+	if isWeighted {
+		c.Set(fiber.HeaderCacheControl, "no-store")
+	} else {
+		h.setCacheHeaders(c)
+	}
+	timing.Write(c)
+	return utils.SuccessResponse(c, fiber.StatusOK, "Default value retrieved", models.LookupDefaultValueResponse{
+		LookupValueResponse: models.ToLookupValueResponse(value),
+		IsFallback:          isFallback,
+		IsWeighted:          isWeighted,
+	})
+}
+
+// Public endpoint - Get all active categories with their active values
+func (h *LookupHandler) GetAllLookups(c *fiber.Ctx) error {
+	timing := utils.StartServerTiming(h.config.ServerTiming)
+	compact, err := parseCompactView(c)
+	if err != nil {
+		return err
+	}
+
+	fields, err := utils.ParseFields(c, categoryShape(compact))
+	if err != nil {
+		return err
+	}
+
+	dbStart := time.Now()
+	categories, err := h.repo.ListActiveCategories(h.readContext(c))
+	timing.DB(dbStart)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	responses := make([]models.LookupCategoryResponse, len(categories))
+	for i, cat := range categories {
+		responses[i] = models.ToLookupCategoryResponse(&cat)
+	}
+
+	data, err := utils.SelectFields(categoryView(compact, responses), fields)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	h.setCacheHeaders(c)
+	timing.Write(c)
+	return utils.SuccessResponse(c, fiber.StatusOK, "Lookups retrieved", data)
+}
+
+// GetLookupTree returns the active categories grouped by code prefix, each
+// with its active values, as one document for settings screens
+func (h *LookupHandler) GetLookupTree(c *fiber.Ctx) error {
+	timing := utils.StartServerTiming(h.config.ServerTiming)
+	dbStart := time.Now()
+	categories, err := h.repo.ListActiveCategories(h.readContext(c))
+	timing.DB(dbStart)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	h.setCacheHeaders(c)
+	timing.Write(c)
+	return utils.SuccessResponse(c, fiber.StatusOK, "Lookup tree retrieved", models.BuildLookupCategoryTree(categories))
+}
+
+// GetIncidentFormLookups returns the categories on the incident form with the
+// values the form can offer, in the shape the form component renders
+func (h *LookupHandler) GetIncidentFormLookups(c *fiber.Ctx) error {
+	categories, err := h.repo.ListIncidentFormCategories(h.readContext(c))
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	now := time.Now()
+	form := make([]models.LookupIncidentFormCategory, len(categories))
+	for i := range categories {
+		form[i] = models.ToLookupIncidentFormCategory(&categories[i], now)
+	}
+
+	h.setCacheHeaders(c)
+	return utils.SuccessResponse(c, fiber.StatusOK, "Incident form lookups retrieved", form)
+}
+
+// PreviewIncidentFormCategory returns a category as the incident form would
+// receive it from GetIncidentFormLookups, whether or not it is on the form yet
+func (h *LookupHandler) PreviewIncidentFormCategory(c *fiber.Ctx) error {
+	id, err := utils.ParseUUIDParam(c, "category_id")
+	if err != nil {
+		return err
+	}
+
+	category, err := h.repo.FindCategoryByID(h.requestContext(c), id)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Category not found")
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Incident form preview retrieved", models.ToLookupIncidentFormCategory(category, time.Now()))
+}
+
+// parseCompactView reads ?view=, full by default. compact selects the
+// Compact*Response shapes, a named contract unlike ?fields= selection.
+func parseCompactView(c *fiber.Ctx) (bool, error) {
+	switch c.Query("view", "full") {
+	case "full":
+		return false, nil
+	case "compact":
+		return true, nil
+	}
+	return false, fiber.NewError(fiber.StatusBadRequest, "view must be full or compact")
+}
+
+// valueShape is the item type ?fields= is checked against for the view
+func valueShape(compact bool) interface{} {
+	if compact {
+		return models.CompactLookupValueResponse{}
+	}
+	return models.LookupValueResponse{}
+}
+
+// categoryShape is the item type ?fields= is checked against for the view
+func categoryShape(compact bool) interface{} {
+	if compact {
+		return models.CompactLookupCategoryResponse{}
+	}
+	return models.LookupCategoryResponse{}
+}
+
+// valueView returns responses in the requested view
+func valueView(compact bool, responses []models.LookupValueResponse) interface{} {
+	if !compact {
+		return responses
+	}
+	view := make([]models.CompactLookupValueResponse, len(responses))
+	for i, r := range responses {
+		view[i] = models.ToCompactLookupValueResponse(r)
+	}
+	return view
+}
+
+// categoryView returns responses in the requested view
+func categoryView(compact bool, responses []models.LookupCategoryResponse) interface{} {
+	if !compact {
+		return responses
+	}
+	view := make([]models.CompactLookupCategoryResponse, len(responses))
+	for i, r := range responses {
+		view[i] = models.ToCompactLookupCategoryResponse(r)
+	}
+	return view
+}
+
+// setCacheHeaders marks a lookup read as cacheable by the client for the
+// configured max-age. The results depend on the requester's tenant and role,
+// so the response is private: shared caches and CDNs often ignore Vary on
+// Authorization and would hand one tenant's lookups to another. must-revalidate
+// makes the client check the ETag once it goes stale.
+func (h *LookupHandler) setCacheHeaders(c *fiber.Ctx) {
+	c.Set(fiber.HeaderCacheControl, fmt.Sprintf("private, max-age=%d, must-revalidate", h.config.CacheMaxAge))
+	c.Vary(fiber.HeaderAcceptEncoding, fiber.HeaderAuthorization)
+}
+
+// GetSchema returns JSON Schema documents for the lookup request bodies,
+// generated from the request structs' validation tags.
+func (h *LookupHandler) GetSchema(c *fiber.Ctx) error {
+	schemas := make(map[string]utils.JSONSchema)
+	for name, req := range models.LookupRequestTypes() {
+		schemas[name] = utils.GenerateJSONSchema(name, req)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Schemas retrieved", schemas)
+}
+
+// Alias handlers
+
+func (h *LookupHandler) ListAliases(c *fiber.Ctx) error {
+	valueID, err := utils.ParseUUIDParam(c, "value_id")
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.repo.FindValueByID(h.requestContext(c), valueID); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Value not found")
+	}
+
+	aliases, err := h.repo.ListAliases(h.requestContext(c), valueID)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	responses := make([]models.LookupValueAliasResponse, len(aliases))
+	for i, a := range aliases {
+		responses[i] = models.ToLookupValueAliasResponse(&a)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Aliases retrieved", responses)
+}
+
+func (h *LookupHandler) CreateAlias(c *fiber.Ctx) error {
+	valueID, err := utils.ParseUUIDParam(c, "value_id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid category ID")
+		return err
 	}
 
-	values, err := h.repo.ListValuesByCategory(c.Context(), categoryID)
+	value, err := h.repo.FindValueByID(h.requestContext(c), valueID)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Value not found")
 	}
 
-	responses := make([]models.LookupValueResponse, len(values))
+	var req models.LookupValueAliasCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		return utils.FormatValidationError(c, err)
+	}
+
+	// Aliases share the code space of the category, so they must not resolve to anything yet
+	req.AliasCode = strings.ToUpper(req.AliasCode)
+	if value.Category != nil {
+		if existing, err := h.repo.ResolveValueByAlias(h.requestContext(c), value.Category.Code, req.AliasCode); err == nil {
+			return valueCodeConflict(c, existing)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.InternalErrorResponse(c, err)
+		}
+	}
+
+	alias := &models.LookupValueAlias{
+		ValueID:   value.ID,
+		AliasCode: req.AliasCode,
+	}
+
+	if err := h.repo.CreateAlias(h.requestContext(c), alias); err != nil {
+		if strings.Contains(err.Error(), "duplicate") || strings.Contains(err.Error(), "unique") {
+			return utils.ErrorResponse(c, fiber.StatusConflict, "Alias already exists for this value")
+		}
+		return lookupWriteError(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusCreated, "Alias created", models.ToLookupValueAliasResponse(alias))
+}
+
+func (h *LookupHandler) DeleteAlias(c *fiber.Ctx) error {
+	valueID, err := utils.ParseUUIDParam(c, "value_id")
+	if err != nil {
+		return err
+	}
+
+	aliasID, err := utils.ParseUUIDParam(c, "alias_id")
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.repo.FindValueByID(h.requestContext(c), valueID); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Value not found")
+	}
+
+	if err := h.repo.DeleteAlias(h.requestContext(c), valueID, aliasID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.ErrorResponse(c, fiber.StatusNotFound, "Alias not found")
+		}
+		return lookupWriteError(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Alias deleted", nil)
+}
+
+// ResolveValue returns the canonical value for a code or registered alias
+func (h *LookupHandler) ResolveValue(c *fiber.Ctx) error {
+	timing := utils.StartServerTiming(h.config.ServerTiming)
+	categoryCode := strings.ToUpper(c.Params("code"))
+
+	dbStart := time.Now()
+	value, err := h.repo.ResolveValueByAlias(h.readContext(c), categoryCode, c.Params("alias"))
+	timing.DB(dbStart)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Value not found")
+	}
+
+	h.setCacheHeaders(c)
+	timing.Write(c)
+	return utils.SuccessResponse(c, fiber.StatusOK, "Value resolved", models.ToLookupValueResponse(value))
+}
+
+// ResolveCodes resolves a batch of category code and value code pairs to value
+// IDs, in input order. Pairs that do not name an active value come back with a
+// null value_id and a reason.
+func (h *LookupHandler) ResolveCodes(c *fiber.Ctx) error {
+	var pairs []models.LookupCodePair
+	if err := c.BodyParser(&pairs); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if len(pairs) == 0 {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "At least one pair is required")
+	}
+	if len(pairs) > models.MaxLookupResolvePairs {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest,
+			fmt.Sprintf("At most %d pairs can be resolved per request", models.MaxLookupResolvePairs))
+	}
+	if err := h.validator.Var(pairs, "dive"); err != nil {
+		return utils.FormatValidationError(c, err)
+	}
+
+	results, err := h.repo.ResolveValueCodes(h.readContext(c), pairs)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Codes resolved", results)
+}
+
+// Translation handlers
+
+// ListValueTranslations returns the value's translations. name_ar stays the
+// source of the Arabic name, and is listed as the Arabic translation when no
+// "ar" row exists.
+func (h *LookupHandler) ListValueTranslations(c *fiber.Ctx) error {
+	valueID, err := utils.ParseUUIDParam(c, "value_id")
+	if err != nil {
+		return err
+	}
+
+	value, err := h.repo.FindValueByID(h.requestContext(c), valueID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Value not found")
+	}
+
+	translations, err := h.repo.ListValueTranslations(h.requestContext(c), valueID)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	responses := make([]models.LookupValueTranslationResponse, 0, len(translations)+1)
+	hasArabic := false
+	for _, t := range translations {
+		resp := models.ToLookupValueTranslationResponse(&t)
+		if t.Locale == "ar" {
+			hasArabic = true
+			if value.NameAr != "" {
+				resp.Name = value.NameAr
+			}
+		}
+		responses = append(responses, resp)
+	}
+	if !hasArabic && value.NameAr != "" {
+		responses = append(responses, models.LookupValueTranslationResponse{
+			ValueID:   value.ID,
+			Locale:    "ar",
+			Name:      value.NameAr,
+			UpdatedAt: value.UpdatedAt,
+		})
+		sort.Slice(responses, func(i, j int) bool { return responses[i].Locale < responses[j].Locale })
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Translations retrieved", responses)
+}
+
+// SetValueTranslation creates or replaces the value's translation for the
+// locale in the path
+func (h *LookupHandler) SetValueTranslation(c *fiber.Ctx) error {
+	valueID, err := utils.ParseUUIDParam(c, "value_id")
+	if err != nil {
+		return err
+	}
+
+	locale, ok := utils.NormalizeLocale(c.Params("locale"))
+	if !ok {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "locale must be a language tag such as fr or fr-CA")
+	}
+
+	if _, err := h.repo.FindValueByID(h.requestContext(c), valueID); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Value not found")
+	}
+
+	var req models.LookupValueTranslationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		return utils.FormatValidationError(c, err)
+	}
+
+	translation := &models.LookupValueTranslation{
+		ValueID:     valueID,
+		Locale:      locale,
+		Name:        req.Name,
+		Description: req.Description,
+	}
+	if err := h.repo.SetValueTranslation(h.requestContext(c), translation); err != nil {
+		return lookupWriteError(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Translation saved", models.ToLookupValueTranslationResponse(translation))
+}
+
+// ImportCategoryTranslations sets the Arabic names and descriptions of a
+// category's existing values from a CSV file with code, name_ar and
+// description_ar columns, sent as the multipart "file" field. Rows are matched
+// to values by code; codes the category lacks are reported, not created. A
+// file with invalid rows saves nothing.
+func (h *LookupHandler) ImportCategoryTranslations(c *fiber.Ctx) error {
+	id, err := utils.ParseUUIDParam(c, "category_id")
+	if err != nil {
+		return err
+	}
+
+	category, err := h.repo.FindCategoryByID(h.requestContext(c), id)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Category not found")
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "No file uploaded")
+	}
+	f, err := file.Open()
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+	defer f.Close()
+
+	rows, rowErrors, err := readArabicTranslationsCSV(f, category.Code)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid CSV file: "+err.Error())
+	}
+	if len(rowErrors) > 0 {
+		return utils.ErrorResponseWithData(c, fiber.StatusBadRequest, "Import has invalid rows, nothing was saved", fiber.Map{"errors": rowErrors})
+	}
+
+	result, err := h.repo.ImportArabicTranslations(h.requestContext(c), category.ID, rows)
+	if err != nil {
+		return lookupWriteError(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Translations imported", result)
+}
+
+// readArabicTranslationsCSV reads code, name_ar and description_ar columns,
+// matched by header name in any order; description_ar may be left out. Rows
+// without a code or Arabic name, or repeating a code, come back as row errors.
+func readArabicTranslationsCSV(r io.Reader, categoryCode string) ([]models.LookupTranslationImportRow, []models.LookupImportRowError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, required := range []string{"code", "name_ar"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("missing %s column", required)
+		}
+	}
+
+	var rows []models.LookupTranslationImportRow
+	var rowErrors []models.LookupImportRowError
+	seen := make(map[string]bool)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		row := models.LookupTranslationImportRow{
+			Location:      fmt.Sprintf("line %d", line),
+			Code:          strings.ToUpper(field("code")),
+			NameAr:        field("name_ar"),
+			DescriptionAr: field("description_ar"),
+		}
+		var problem string
+		switch {
+		case row.Code == "":
+			problem = "code is required"
+		case row.NameAr == "":
+			problem = "name_ar is required"
+		case utf8.RuneCountInString(row.NameAr) > 100:
+			problem = "name_ar must be at most 100 characters"
+		case utf8.RuneCountInString(row.DescriptionAr) > 500:
+			problem = "description_ar must be at most 500 characters"
+		case seen[row.Code]:
+			problem = "code appears more than once"
+		}
+		if problem != "" {
+			rowErrors = append(rowErrors, models.LookupImportRowError{
+				Location: row.Location, Type: "value", CategoryCode: categoryCode, Code: row.Code, Error: problem,
+			})
+			continue
+		}
+		seen[row.Code] = true
+		rows = append(rows, row)
+	}
+	return rows, rowErrors, nil
+}
+
+// localizeValues replaces the name and description of each response with its
+// translation in the most preferred Accept-Language locale that has one. The
+// base names are English, so "en" ends the search. The Arabic name always comes
+// from name_ar, which an "ar" translation only adds a description to.
+func (h *LookupHandler) localizeValues(c *fiber.Ctx, values []models.LookupValue, responses []models.LookupValueResponse) error {
+	c.Vary(fiber.HeaderAcceptLanguage)
+	locales := utils.ParseAcceptLanguage(c.Get(fiber.HeaderAcceptLanguage))
+	if len(locales) == 0 || len(values) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(values))
 	for i, v := range values {
-		responses[i] = models.ToLookupValueResponse(&v)
+		ids[i] = v.ID
+	}
+	translations, err := h.repo.FindValueTranslations(h.requestContext(c), ids, locales)
+	if err != nil {
+		return err
+	}
+	byValue := make(map[uuid.UUID]map[string]models.LookupValueTranslation, len(translations))
+	for _, t := range translations {
+		if byValue[t.ValueID] == nil {
+			byValue[t.ValueID] = make(map[string]models.LookupValueTranslation)
+		}
+		byValue[t.ValueID][t.Locale] = t
 	}
 
-	return utils.SuccessResponse(c, fiber.StatusOK, "Values retrieved", responses)
+	for i := range responses {
+		for _, locale := range locales {
+			if locale == "en" {
+				break
+			}
+			t, ok := byValue[values[i].ID][locale]
+			if locale == "ar" && values[i].NameAr != "" {
+				t.Name, ok = values[i].NameAr, true
+			}
+			if !ok {
+				continue
+			}
+			responses[i].Name = t.Name
+			if t.Description != "" {
+				responses[i].Description = t.Description
+			}
+			responses[i].Locale = locale
+			break
+		}
+	}
+	return nil
 }
 
-// Public endpoint - Get values by category code
-func (h *LookupHandler) GetValuesByCategoryCode(c *fiber.Ctx) error {
-	code := strings.ToUpper(c.Params("code"))
+// Related category handlers
+
+// ListRelatedCategories returns summaries of the categories linked to a category
+func (h *LookupHandler) ListRelatedCategories(c *fiber.Ctx) error {
+	id, err := utils.ParseUUIDParam(c, "category_id")
+	if err != nil {
+		return err
+	}
+
+	return h.relatedCategoriesResponse(c, id, fiber.StatusOK, "Related categories retrieved")
+}
+
+// relatedCategoriesResponse answers with the summaries of the categories
+// related to id
+func (h *LookupHandler) relatedCategoriesResponse(c *fiber.Ctx, id uuid.UUID, status int, message string) error {
+	categories, err := h.repo.ListRelatedCategories(h.requestContext(c), id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Category not found")
+	}
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	summaries := make([]models.LookupCategorySummary, len(categories))
+	for i := range categories {
+		summaries[i] = models.ToLookupCategorySummary(&categories[i])
+	}
+	return utils.SuccessResponse(c, status, message, summaries)
+}
+
+// LinkCategory relates two categories, e.g. Priority and SLA. The link works
+// both ways; a category cannot be linked to itself or linked twice.
+func (h *LookupHandler) LinkCategory(c *fiber.Ctx) error {
+	id, err := utils.ParseUUIDParam(c, "category_id")
+	if err != nil {
+		return err
+	}
+
+	var req models.LookupCategoryLinkRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		return utils.FormatValidationError(c, err)
+	}
+
+	err = h.repo.LinkCategories(h.requestContext(c), id, req.RelatedCategoryID)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Category not found")
+	case errors.Is(err, repository.ErrLookupCategorySelfLink):
+		return utils.ErrorResponse(c, fiber.StatusUnprocessableEntity, "A category cannot be related to itself")
+	case errors.Is(err, repository.ErrLookupCategoryAlreadyLinked):
+		return utils.ErrorResponse(c, fiber.StatusConflict, "The categories are already related")
+	case err != nil:
+		return lookupWriteError(c, err)
+	}
+
+	return h.relatedCategoriesResponse(c, id, fiber.StatusCreated, "Categories linked")
+}
+
+// UnlinkCategory removes the link between two categories
+func (h *LookupHandler) UnlinkCategory(c *fiber.Ctx) error {
+	id, err := utils.ParseUUIDParam(c, "category_id")
+	if err != nil {
+		return err
+	}
+
+	relatedID, err := utils.ParseUUIDParam(c, "related_id")
+	if err != nil {
+		return err
+	}
+
+	err = h.repo.UnlinkCategories(h.requestContext(c), id, relatedID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Categories are not related")
+	}
+	if err != nil {
+		return lookupWriteError(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Categories unlinked", nil)
+}
+
+// Tag handlers
+
+func (h *LookupHandler) CreateTag(c *fiber.Ctx) error {
+	var req models.LookupTagCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		return utils.FormatValidationError(c, err)
+	}
+
+	tag := &models.LookupTag{
+		OrgID:       requesterOrgID(c),
+		Code:        strings.ToUpper(req.Code),
+		Name:        req.Name,
+		Description: req.Description,
+	}
+
+	if err := h.repo.CreateTag(h.requestContext(c), tag); err != nil {
+		if strings.Contains(err.Error(), "duplicate") || strings.Contains(err.Error(), "unique") {
+			return utils.ErrorResponse(c, fiber.StatusConflict, "Tag code already exists")
+		}
+		return lookupWriteError(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusCreated, "Tag created", models.ToLookupTagResponse(tag))
+}
+
+func (h *LookupHandler) ListTags(c *fiber.Ctx) error {
+	tags, err := h.repo.ListTags(h.requestContext(c))
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	responses := make([]models.LookupTagResponse, len(tags))
+	for i := range tags {
+		responses[i] = models.ToLookupTagResponse(&tags[i])
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Tags retrieved", responses)
+}
+
+// AttachTagValues adds values from any category to a tag
+func (h *LookupHandler) AttachTagValues(c *fiber.Ctx) error {
+	tagID, err := utils.ParseUUIDParam(c, "tag_id")
+	if err != nil {
+		return err
+	}
+
+	tag, err := h.repo.FindTagByID(h.requestContext(c), tagID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Tag not found")
+	}
+
+	var req models.LookupTagValuesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		return utils.FormatValidationError(c, err)
+	}
+
+	result, err := h.repo.AttachTagValues(h.requestContext(c), tag, req.ValueIDs)
+	if err != nil {
+		return lookupWriteError(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Values tagged", result)
+}
 
-	values, err := h.repo.ListValuesByCategoryCode(c.Context(), code)
+func (h *LookupHandler) DetachTagValue(c *fiber.Ctx) error {
+	tagID, err := utils.ParseUUIDParam(c, "tag_id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return err
+	}
+
+	valueID, err := utils.ParseUUIDParam(c, "value_id")
+	if err != nil {
+		return err
+	}
+
+	tag, err := h.repo.FindTagByID(h.requestContext(c), tagID)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Tag not found")
+	}
+
+	if err := h.repo.DetachTagValue(h.requestContext(c), tag, valueID); err != nil {
+		return lookupWriteError(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Value untagged", nil)
+}
+
+// ListValuesByTag returns the active values carrying a tag, across categories
+func (h *LookupHandler) ListValuesByTag(c *fiber.Ctx) error {
+	code := strings.ToUpper(c.Params("tag_code"))
+
+	values, err := h.repo.ListValuesByTagCode(h.requestContext(c), code)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	responses := make([]models.LookupValueResponse, len(values))
-	for i, v := range values {
-		responses[i] = models.ToLookupValueResponse(&v)
+	for i := range values {
+		responses[i] = models.ToLookupValueResponse(&values[i])
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Values retrieved", responses)
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/automax/backend/internal/models"
+	"github.com/automax/backend/internal/repository"
+	"github.com/automax/backend/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// ArchiveCategory takes a category out of use until it is unarchived. Its
+// values keep their status unless ?cascade_values=true, which deactivates its
+// active values along with it.
+func (h *LookupHandler) ArchiveCategory(c *fiber.Ctx) error {
+	return h.setCategoryArchived(c, true)
+}
+
+// UnarchiveCategory puts an archived category back in use. With
+// ?with_values=true the values archived along with it are reactivated too.
+func (h *LookupHandler) UnarchiveCategory(c *fiber.Ctx) error {
+	return h.setCategoryArchived(c, false)
+}
+
+func (h *LookupHandler) setCategoryArchived(c *fiber.Ctx, archive bool) error {
+	id, err := utils.ParseUUIDParam(c, "category_id")
+	if err != nil {
+		return err
+	}
+
+	category, err := h.repo.FindCategoryByID(h.requestContext(c), id)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Category not found")
+	}
+	if category.IsSystem {
+		return utils.ErrorResponse(c, fiber.StatusUnprocessableEntity, "System categories cannot be archived")
+	}
+
+	var values int64
+	message := "Category archived"
+	if archive {
+		values, err = h.repo.ArchiveCategory(h.requestContext(c), id, c.QueryBool("cascade_values", false))
+	} else {
+		message = "Category unarchived"
+		values, err = h.repo.UnarchiveCategory(h.requestContext(c), id, c.QueryBool("with_values", false))
+	}
+	switch {
+	case errors.Is(err, repository.ErrLookupCategoryArchived), errors.Is(err, repository.ErrLookupCategoryNotArchived):
+		return utils.ErrorResponse(c, fiber.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Category not found")
+	case err != nil:
+		return lookupWriteError(c, err)
+	}
+
+	category, err = h.repo.FindCategoryByID(h.requestContext(c), id)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, message, models.LookupArchiveResult{
+		Category: models.ToLookupCategoryResponse(category),
+		Values:   values,
+	})
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/automax/backend/internal/models"
+	"github.com/automax/backend/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// lookupValueCSVHeader is the column layout shared by all lookup CSV exports
+var lookupValueCSVHeader = []string{"code", "name", "name_ar", "description", "sort_order", "color", "is_default", "is_active"}
+
+// writeLookupValuesCSV writes the values of a category as CSV rows.
+// effectiveColors appends an effective_color column after the shared layout.
+func writeLookupValuesCSV(w io.Writer, category *models.LookupCategory, effectiveColors bool) error {
+	writer := csv.NewWriter(w)
+	header := lookupValueCSVHeader
+	if effectiveColors {
+		header = append(header[:len(header):len(header)], "effective_color")
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, v := range category.Values {
+		record := []string{
+			v.Code,
+			v.Name,
+			v.NameAr,
+			v.Description,
+			strconv.Itoa(v.SortOrder),
+			v.Color,
+			strconv.FormatBool(v.IsDefault),
+			strconv.FormatBool(v.IsActive),
+		}
+		if effectiveColors {
+			record = append(record, v.EffectiveColor(category))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ExportCategoryCSV exports a single category's values as CSV. With
+// ?effective=true each row also carries the color the value is shown in.
+func (h *LookupHandler) ExportCategoryCSV(c *fiber.Ctx) error {
+	id, err := utils.ParseUUIDParam(c, "category_id")
+	if err != nil {
+		return err
+	}
+
+	category, err := h.repo.FindCategoryByID(h.requestContext(c), id)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Category not found")
+	}
+
+	c.Set("Content-Type", "text/csv; charset=utf-8")
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.csv", category.Code))
+	return writeLookupValuesCSV(c, category, c.QueryBool("effective", false))
+}
+
+// ExportJSON exports every category with its values as a JSON document that
+// ImportJSON accepts back. ?effective=true adds each value's effective color.
+func (h *LookupHandler) ExportJSON(c *fiber.Ctx) error {
+	categories, err := h.repo.ListCategories(h.requestContext(c))
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	c.Set("Content-Disposition", "attachment; filename=lookups_export.json")
+	return c.JSON(models.ToLookupExport(categories, c.QueryBool("effective", false)))
+}
+
+// lookupExportManifestEntry describes one CSV file inside the zip export
+type lookupExportManifestEntry struct {
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	File     string `json:"file"`
+	RowCount int    `json:"row_count"`
+}
+
+// ExportAllZip streams a zip archive with one CSV per category plus a manifest.json
+func (h *LookupHandler) ExportAllZip(c *fiber.Ctx) error {
+	categories, err := h.repo.ListCategoriesWithoutValues(h.requestContext(c))
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	c.Set("Content-Type", "application/zip")
+	c.Set("Content-Disposition", "attachment; filename=lookups_export.zip")
+
+	// The archive is written straight to the connection, one entry at a time,
+	// and each category's values are loaded only when its entry is written, so
+	// neither the zip nor the full set of values is held in memory. The body is
+	// written after the handler returns, so the value queries run on a context
+	// that outlives the request and is canceled when the client goes away.
+	ctx, cancel := context.WithCancel(context.WithoutCancel(h.requestContext(c)))
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+		zw := zip.NewWriter(&cancelOnErrorWriter{w: w, cancel: cancel})
+		manifest := struct {
+			ExportedAt time.Time                   `json:"exported_at"`
+			Categories []lookupExportManifestEntry `json:"categories"`
+		}{
+			ExportedAt: time.Now().UTC(),
+			Categories: make([]lookupExportManifestEntry, 0, len(categories)),
+		}
+
+		for i := range categories {
+			category := &categories[i]
+			values, err := h.repo.ListValuesByCategory(ctx, category.ID)
+			if err != nil {
+				log.Printf("lookup zip export: failed to load values of %s: %v", category.Code, err)
+				return
+			}
+			category.Values = values
+
+			fileName := category.Code + ".csv"
+			f, err := zw.Create(fileName)
+			if err != nil {
+				log.Printf("lookup zip export: failed to create %s: %v", fileName, err)
+				return
+			}
+			if err := writeLookupValuesCSV(f, category, false); err != nil {
+				log.Printf("lookup zip export: failed to write %s: %v", fileName, err)
+				return
+			}
+			manifest.Categories = append(manifest.Categories, lookupExportManifestEntry{
+				Code:     category.Code,
+				Name:     category.Name,
+				File:     fileName,
+				RowCount: len(values),
+			})
+			category.Values = nil
+		}
+
+		f, err := zw.Create("manifest.json")
+		if err != nil {
+			log.Printf("lookup zip export: failed to create manifest: %v", err)
+			return
+		}
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(manifest); err != nil {
+			log.Printf("lookup zip export: failed to write manifest: %v", err)
+			return
+		}
+		if err := zw.Close(); err != nil {
+			log.Printf("lookup zip export: failed to finalize archive: %v", err)
+		}
+	})
+
+	return nil
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/automax/backend/internal/models"
+	"github.com/automax/backend/internal/repository"
+	"github.com/automax/backend/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// readLookupValuesCSV reads values written in the lookupValueCSVHeader layout.
+// Columns are matched by header name in any order and only code and name are
+// required; is_active defaults to true. Rows whose numbers or flags cannot be
+// parsed come back as row errors, while a malformed file is an error.
+func readLookupValuesCSV(r io.Reader, categoryCode string) ([]models.LookupValueExport, []models.LookupImportRowError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, required := range []string{"code", "name"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("missing %s column", required)
+		}
+	}
+
+	var values []models.LookupValueExport
+	var rowErrors []models.LookupImportRowError
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		value := models.LookupValueExport{
+			Location:    fmt.Sprintf("line %d", line),
+			Code:        field("code"),
+			Name:        field("name"),
+			NameAr:      field("name_ar"),
+			Description: field("description"),
+			Color:       field("color"),
+			IsActive:    true,
+		}
+		var problems []string
+		if s := field("sort_order"); s != "" {
+			if value.SortOrder, err = strconv.Atoi(s); err != nil {
+				problems = append(problems, "sort_order must be a whole number")
+			}
+		}
+		if s := field("is_default"); s != "" {
+			if value.IsDefault, err = strconv.ParseBool(s); err != nil {
+				problems = append(problems, "is_default must be true or false")
+			}
+		}
+		if s := field("is_active"); s != "" {
+			if value.IsActive, err = strconv.ParseBool(s); err != nil {
+				problems = append(problems, "is_active must be true or false")
+			}
+		}
+
+		if len(problems) > 0 {
+			rowErrors = append(rowErrors, models.LookupImportRowError{
+				Location: value.Location, Type: "value", CategoryCode: categoryCode, Code: strings.ToUpper(value.Code),
+				Error: strings.Join(problems, "; "),
+			})
+			continue
+		}
+		values = append(values, value)
+	}
+	return values, rowErrors, nil
+}
+
+// ImportJSON creates or updates categories and values from an export document.
+// With ?dry_run=true nothing is saved; the response shows what would change.
+// ?validation=lenient saves the valid rows and reports the rest; the default,
+// strict, saves nothing when any row fails.
+func (h *LookupHandler) ImportJSON(c *fiber.Ctx) error {
+	var req models.LookupExport
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if len(req.Categories) == 0 {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "categories is required")
+	}
+
+	for i := range req.Categories {
+		req.Categories[i].Location = fmt.Sprintf("categories[%d]", i)
+		for j := range req.Categories[i].Values {
+			req.Categories[i].Values[j].Location = fmt.Sprintf("categories[%d].values[%d]", i, j)
+		}
+	}
+
+	return h.runImport(c, &req, nil)
+}
+
+// ImportCategoryCSV creates or updates the values of a category from a CSV file
+// in the ExportCategoryCSV layout, uploaded as the "file" form field. It takes
+// the same dry_run and validation options as ImportJSON.
+func (h *LookupHandler) ImportCategoryCSV(c *fiber.Ctx) error {
+	id, err := utils.ParseUUIDParam(c, "category_id")
+	if err != nil {
+		return err
+	}
+
+	category, err := h.repo.FindCategoryByID(h.requestContext(c), id)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Category not found")
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "No file uploaded")
+	}
+	f, err := file.Open()
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+	defer f.Close()
+
+	values, rowErrors, err := readLookupValuesCSV(f, category.Code)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid CSV file: "+err.Error())
+	}
+
+	// The category itself is sent as it stands, so only its values change
+	data := models.LookupExport{Categories: []models.LookupCategoryExport{{
+		Code:              category.Code,
+		Name:              category.Name,
+		NameAr:            category.NameAr,
+		Description:       category.Description,
+		IsActive:          category.IsActive,
+		AddToIncidentForm: category.AddToIncidentForm,
+		Values:            values,
+	}}}
+	return h.runImport(c, &data, rowErrors)
+}
+
+// runImport validates and applies an import document in the mode named by
+// ?validation=. rowErrors holds rows the caller already rejected while reading
+// the file; they count against a strict import like invalid rows do.
+func (h *LookupHandler) runImport(c *fiber.Ctx, data *models.LookupExport, rowErrors []models.LookupImportRowError) error {
+	validation := strings.ToLower(c.Query("validation", models.LookupImportStrict))
+	if validation != models.LookupImportStrict && validation != models.LookupImportLenient {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "validation must be strict or lenient")
+	}
+	opts := models.LookupImportOptions{DryRun: c.QueryBool("dry_run", false), Validation: validation}
+
+	valid, invalid := h.checkImportRows(data)
+	rowErrors = append(rowErrors, invalid...)
+	if len(rowErrors) > 0 && validation == models.LookupImportStrict {
+		result := models.NewLookupImportResult(opts)
+		for _, rowErr := range rowErrors {
+			result.Fail(rowErr)
+		}
+		return utils.ErrorResponseWithData(c, fiber.StatusBadRequest, "Import has invalid rows, nothing was saved", result)
+	}
+
+	result, err := h.repo.ImportLookups(h.requestContext(c), valid, opts)
+	if errors.Is(err, repository.ErrLookupImportRowFailed) {
+		return utils.ErrorResponseWithData(c, fiber.StatusUnprocessableEntity, "Import row could not be saved, nothing was saved", result)
+	}
+	if err != nil {
+		return lookupWriteError(c, err)
+	}
+	result.Errors = append(rowErrors, result.Errors...)
+	result.Failed += len(rowErrors)
+
+	message := "Lookups imported"
+	switch {
+	case opts.DryRun:
+		message = "Import dry run completed, no changes were saved"
+	case result.Failed > 0:
+		message = fmt.Sprintf("Lookups imported, %d rows skipped", result.Failed)
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, message, result)
+}
+
+// checkImportRows validates each category and value of an import on its own.
+// It returns the document without the invalid rows and an error for each of
+// them; an invalid category is left out with all its values.
+func (h *LookupHandler) checkImportRows(data *models.LookupExport) (*models.LookupExport, []models.LookupImportRowError) {
+	valid := &models.LookupExport{ExportedAt: data.ExportedAt, Categories: make([]models.LookupCategoryExport, 0, len(data.Categories))}
+	var rowErrors []models.LookupImportRowError
+
+	for _, category := range data.Categories {
+		code := strings.ToUpper(category.Code)
+		if err := h.validator.StructExcept(category, "Values"); err != nil {
+			rowErrors = append(rowErrors, models.LookupImportRowError{
+				Location: category.Location, Type: "category", CategoryCode: code, Code: code,
+				Error: utils.ValidationSummary(utils.ValidationDetails(err)),
+			})
+			continue
+		}
+
+		values := category.Values
+		category.Values = make([]models.LookupValueExport, 0, len(values))
+		for _, value := range values {
+			if err := h.validator.Struct(value); err != nil {
+				rowErrors = append(rowErrors, models.LookupImportRowError{
+					Location: value.Location, Type: "value", CategoryCode: code, Code: strings.ToUpper(value.Code),
+					Error: utils.ValidationSummary(utils.ValidationDetails(err)),
+				})
+				continue
+			}
+			category.Values = append(category.Values, value)
+		}
+		valid.Categories = append(valid.Categories, category)
+	}
+	return valid, rowErrors
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/automax/backend/internal/models"
+	"github.com/automax/backend/internal/repository"
+	"github.com/automax/backend/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// MoveValues moves values of the category into another one in a single
+// transaction. Every value must belong to the source category and the target
+// must not be a system category; moved values lose their default flag and are
+// appended to the target's sort order.
+func (h *LookupHandler) MoveValues(c *fiber.Ctx) error {
+	sourceID, err := utils.ParseUUIDParam(c, "category_id")
+	if err != nil {
+		return err
+	}
+
+	var req models.LookupValueMoveRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		return utils.FormatValidationError(c, err)
+	}
+	if req.TargetCategoryID == sourceID {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "target_category_id must differ from the source category")
+	}
+
+	values, err := h.repo.MoveValues(h.requestContext(c), sourceID, req.TargetCategoryID, req.ValueIDs)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Category not found")
+	case errors.Is(err, repository.ErrLookupValueWrongCategory):
+		return utils.ErrorResponse(c, fiber.StatusUnprocessableEntity, "Every value must belong to the source category")
+	case errors.Is(err, repository.ErrLookupMoveToSystemCategory):
+		return utils.ErrorResponse(c, fiber.StatusUnprocessableEntity, "Values cannot be moved into a system category")
+	case errors.Is(err, repository.ErrLookupMoveCodeTaken):
+		return utils.ErrorResponse(c, fiber.StatusConflict, "A value code is already used in the target category")
+	case err != nil:
+		return lookupWriteError(c, err)
+	}
+
+	responses := make([]models.LookupValueResponse, len(values))
+	for i := range values {
+		responses[i] = models.ToLookupValueResponseWithCategory(&values[i])
+	}
+	return utils.SuccessResponse(c, fiber.StatusOK, "Values moved", responses)
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"github.com/automax/backend/internal/models"
+	"github.com/automax/backend/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// PatchValue applies a JSON merge patch (RFC 7386) to a value: omitted fields
+// are kept, fields set to null are cleared and other fields are replaced.
+// Required fields such as code and name cannot be cleared.
+func (h *LookupHandler) PatchValue(c *fiber.Ctx) error {
+	id, err := utils.ParseUUIDParam(c, "value_id")
+	if err != nil {
+		return err
+	}
+
+	changedOnly, err := utils.ParseReturnChanged(c)
+	if err != nil {
+		return err
+	}
+
+	value, err := h.repo.FindValueByID(h.requestContext(c), id)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusNotFound, "Value not found")
+	}
+
+	doc := models.NewLookupValuePatch(value)
+	touched, err := utils.ApplyMergePatch(c.Body(), &doc)
+	if err != nil {
+		return err
+	}
+	if len(touched) == 0 {
+		return noFieldsToUpdate(c)
+	}
+	if err := h.validator.Struct(&doc); err != nil {
+		return utils.FormatValidationError(c, err)
+	}
+
+	var changes valueChanges
+	if touched["code"] {
+		changes.Code = &doc.Code
+	}
+	if touched["name"] {
+		changes.Name = &doc.Name
+	}
+	if touched["name_ar"] {
+		changes.NameAr = &doc.NameAr
+	}
+	if touched["description"] {
+		changes.Description = &doc.Description
+	}
+	if touched["color"] {
+		changes.Color = &doc.Color
+	}
+	if touched["sort_order"] {
+		changes.SortOrder = &doc.SortOrder
+	}
+	if touched["parent_id"] {
+		changes.ParentID = doc.ParentID
+		changes.ClearParent = doc.ParentID == nil
+	}
+	if touched["is_default"] {
+		changes.IsDefault = &doc.IsDefault
+	}
+	if touched["default_weight"] {
+		changes.DefaultWeight = &doc.DefaultWeight
+	}
+	if touched["is_active"] {
+		changes.IsActive = &doc.IsActive
+	}
+	if touched["status"] {
+		changes.Status = &doc.Status
+	}
+	if touched["effective_from"] {
+		changes.EffectiveFrom = doc.EffectiveFrom
+		changes.ClearEffectiveFrom = doc.EffectiveFrom == nil
+	}
+	if touched["effective_to"] {
+		changes.EffectiveTo = doc.EffectiveTo
+		changes.ClearEffectiveTo = doc.EffectiveTo == nil
+	}
+	if touched["required_role"] {
+		changes.RequiredRole = &doc.RequiredRole
+	}
+	if touched["metadata"] {
+		if metadataErrors := valueMetadataErrors(doc.Metadata); metadataErrors != nil {
+			return utils.ValidationFailedResponse(c, metadataErrors)
+		}
+		changes.Metadata = &doc.Metadata
+	}
+
+	return h.updateValue(c, value, changes, changedOnly)
+}
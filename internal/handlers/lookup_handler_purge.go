@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"github.com/automax/backend/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// PurgeDeleted hard-deletes the lookups soft-deleted longer ago than
+// ?older_than, e.g. 30d or 12h
+func (h *LookupHandler) PurgeDeleted(c *fiber.Ctx) error {
+	olderThan, err := utils.ParseRetention(c.Query("older_than"))
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "older_than must be a positive duration such as 30d or 12h")
+	}
+
+	result, err := h.repo.PurgeDeletedOlderThan(h.requestContext(c), olderThan)
+	if err != nil {
+		return lookupWriteError(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Deleted lookups purged", result)
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/automax/backend/internal/models"
+	"github.com/automax/backend/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SyncLookups returns the categories and values changed after the cursor
+// query parameter, the next_cursor of the previous sync, plus the IDs deleted
+// since then, for incremental client sync. Clients that still send since (RFC
+// 3339) get the changes at or after that time. Without either everything is
+// returned.
+func (h *LookupHandler) SyncLookups(c *fiber.Ctx) error {
+	var after models.LookupSyncCursor
+	if raw := c.Query("cursor"); raw != "" {
+		parsed, err := models.ParseLookupSyncCursor(raw)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "cursor must be the next_cursor of a previous sync")
+		}
+		after = parsed
+	} else if raw := c.Query("since"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return utils.ErrorResponse(c, fiber.StatusBadRequest, "since must be an RFC 3339 time")
+		}
+		after.UpdatedAt = parsed
+	}
+
+	serverTime := time.Now().UTC()
+	changes, err := h.repo.ListChangesSince(h.readContext(c), after)
+	if err != nil {
+		return utils.InternalErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.StatusOK, "Lookup changes retrieved", models.ToLookupSyncResponse(changes, serverTime))
+}
@@ -0,0 +1,2831 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/automax/backend/internal/config"
+	"github.com/automax/backend/internal/database"
+	"github.com/automax/backend/internal/models"
+	"github.com/automax/backend/internal/repository"
+	"github.com/automax/backend/pkg/utils"
+	"github.com/glebarez/sqlite"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB opens a migrated in-memory SQLite database private to the test
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", url.PathEscape(t.Name()))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+	return db
+}
+
+// newRoleTestApp serves the lookup read endpoints to a requester with role,
+// over a SEVERITY category on the incident form holding an unrestricted LOW
+// value and a CRITICAL value restricted to security staff
+func newRoleTestApp(t *testing.T, role string) *fiber.App {
+	t.Helper()
+	db := newTestDB(t)
+	category := &models.LookupCategory{Code: "SEVERITY", Name: "Severity", IsActive: true, AddToIncidentForm: true}
+	if err := db.Create(category).Error; err != nil {
+		t.Fatalf("create category: %v", err)
+	}
+	for _, v := range []models.LookupValue{
+		{CategoryID: category.ID, Code: "LOW", Name: "Severity low"},
+		{CategoryID: category.ID, Code: "CRITICAL", Name: "Severity critical", RequiredRole: "security", SortOrder: 1},
+	} {
+		v.IsActive = true
+		v.Status = models.LookupValueStatusActive
+		if err := db.Omit("Category").Create(&v).Error; err != nil {
+			t.Fatalf("create value %s: %v", v.Code, err)
+		}
+	}
+
+	h := NewLookupHandler(repository.NewLookupRepository(db), nil, config.LookupConfig{})
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("role", role)
+		return c.Next()
+	})
+	app.Get("/lookups/:code", h.GetValuesByCategoryCode)
+	app.Get("/incident-form/lookups", h.GetIncidentFormLookups)
+	app.Get("/values/search", h.SearchValues)
+	return app
+}
+
+// getValueCodes requests path and returns the sorted codes of the values in
+// the response, found under data or under each category's values
+func getValueCodes(t *testing.T, app *fiber.App, path string) []string {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil))
+	if err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("GET %s = %d, want 200", path, resp.StatusCode)
+	}
+	var body struct {
+		Data []struct {
+			Code   string `json:"code"`
+			Values []struct {
+				Code string `json:"code"`
+			} `json:"values"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode %s: %v", path, err)
+	}
+
+	var codes []string
+	for _, item := range body.Data {
+		if item.Values == nil {
+			codes = append(codes, item.Code)
+			continue
+		}
+		for _, v := range item.Values {
+			codes = append(codes, v.Code)
+		}
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+func TestRestrictedValuesAreFilteredOnEveryReadPath(t *testing.T) {
+	paths := []string{"/lookups/SEVERITY", "/incident-form/lookups", "/values/search?q=severity"}
+	cases := []struct {
+		role string
+		want []string
+	}{
+		{"user", []string{"LOW"}},
+		{"security", []string{"CRITICAL", "LOW"}},
+		{models.LookupAdminRole, []string{"CRITICAL", "LOW"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.role, func(t *testing.T) {
+			app := newRoleTestApp(t, tc.role)
+			for _, path := range paths {
+				got := getValueCodes(t, app, path)
+				if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+					t.Errorf("GET %s = %v, want %v", path, got, tc.want)
+				}
+			}
+		})
+	}
+}
+
+// newSuperAdminApp serves the bulk upsert endpoint to a super admin
+func newSuperAdminApp(db *gorm.DB) *fiber.App {
+	h := NewLookupHandler(repository.NewLookupRepository(db), nil, config.LookupConfig{})
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user", &models.User{IsSuperAdmin: true})
+		return c.Next()
+	})
+	app.Put("/categories/:category_id/values/bulk", h.UpsertValues)
+	return app
+}
+
+// newAdminTestApp serves the routes register adds to a super admin, over db
+func newAdminTestApp(db *gorm.DB, cfg config.LookupConfig, register func(app *fiber.App, h *LookupHandler)) *fiber.App {
+	h := NewLookupHandler(repository.NewLookupRepository(db), nil, cfg)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user", &models.User{IsSuperAdmin: true})
+		return c.Next()
+	})
+	register(app, h)
+	return app
+}
+
+// sendJSON sends body to path as JSON, without a body when it is empty, and
+// decodes the data field of the response into data unless data is nil
+func sendJSON(t *testing.T, app *fiber.App, method, path, body string, data interface{}) *http.Response {
+	t.Helper()
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != "" {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	if data != nil {
+		raw, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("read %s %s: %v", method, path, err)
+		}
+		var envelope struct {
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(raw, &envelope); err != nil {
+			t.Fatalf("decode %s %s: %v: %s", method, path, err, raw)
+		}
+		if len(envelope.Data) > 0 {
+			if err := json.Unmarshal(envelope.Data, data); err != nil {
+				t.Fatalf("decode data of %s %s: %v: %s", method, path, err, envelope.Data)
+			}
+		}
+	}
+	return resp
+}
+
+// seedCategory stores an active global category with an active value per
+// code, sorted in the order given
+func seedCategory(t *testing.T, db *gorm.DB, code string, valueCodes ...string) (*models.LookupCategory, []models.LookupValue) {
+	t.Helper()
+	category := &models.LookupCategory{Code: code, Name: code, IsActive: true}
+	if err := db.Create(category).Error; err != nil {
+		t.Fatalf("create category %s: %v", code, err)
+	}
+	values := make([]models.LookupValue, len(valueCodes))
+	for i, valueCode := range valueCodes {
+		values[i] = models.LookupValue{CategoryID: category.ID, Code: valueCode, Name: valueCode, SortOrder: i, IsActive: true, Status: models.LookupValueStatusActive}
+		if err := db.Omit("Category").Create(&values[i]).Error; err != nil {
+			t.Fatalf("create value %s: %v", valueCode, err)
+		}
+	}
+	return category, values
+}
+
+func TestUpsertValuesLeavesTheDefaultUnlessIsDefaultIsSent(t *testing.T) {
+	db := newTestDB(t)
+	category := &models.LookupCategory{Code: "PRIORITY", Name: "Priority", IsActive: true}
+	if err := db.Create(category).Error; err != nil {
+		t.Fatalf("create category: %v", err)
+	}
+	for i, code := range []string{"HIGH", "LOW"} {
+		v := models.LookupValue{CategoryID: category.ID, Code: code, Name: code, SortOrder: i, IsDefault: code == "HIGH", IsActive: true, Status: models.LookupValueStatusActive}
+		if err := db.Omit("Category").Create(&v).Error; err != nil {
+			t.Fatalf("create value %s: %v", code, err)
+		}
+	}
+	app := newSuperAdminApp(db)
+
+	upsert := func(body string) {
+		t.Helper()
+		req := httptest.NewRequest(fiber.MethodPut, "/categories/"+category.ID.String()+"/values/bulk", strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("upsert: %v", err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("upsert %s = %d, want 200", body, resp.StatusCode)
+		}
+	}
+	defaultCode := func() string {
+		t.Helper()
+		var codes []string
+		if err := db.Model(&models.LookupValue{}).Where("category_id = ? AND is_default = ?", category.ID, true).Pluck("code", &codes).Error; err != nil {
+			t.Fatalf("read default: %v", err)
+		}
+		return strings.Join(codes, ",")
+	}
+
+	upsert(`{"values":[{"code":"HIGH","name":"High"},{"code":"LOW","name":"Low"}]}`)
+	if got := defaultCode(); got != "HIGH" {
+		t.Fatalf("default after renaming = %q, want HIGH", got)
+	}
+
+	upsert(`{"values":[{"code":"LOW","name":"Low","is_default":true}]}`)
+	if got := defaultCode(); got != "LOW" {
+		t.Fatalf("default after moving it = %q, want LOW", got)
+	}
+}
+
+// failingCodeLookupRepository fails every value code lookup with err
+type failingCodeLookupRepository struct {
+	repository.LookupRepository
+	err error
+}
+
+func (r *failingCodeLookupRepository) FindValueByCode(ctx context.Context, categoryID uuid.UUID, code string) (*models.LookupValue, error) {
+	return nil, r.err
+}
+
+func TestCreateValueFailsWhenTheCodeCheckFails(t *testing.T) {
+	db := newTestDB(t)
+	category := &models.LookupCategory{Code: "PRIORITY", Name: "Priority", IsActive: true}
+	if err := db.Create(category).Error; err != nil {
+		t.Fatalf("create category: %v", err)
+	}
+
+	repo := &failingCodeLookupRepository{LookupRepository: repository.NewLookupRepository(db), err: errors.New("connection reset")}
+	h := NewLookupHandler(repo, nil, config.LookupConfig{})
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user", &models.User{IsSuperAdmin: true})
+		return c.Next()
+	})
+	app.Post("/categories/:category_id/values", h.CreateValue)
+
+	req := httptest.NewRequest(fiber.MethodPost, "/categories/"+category.ID.String()+"/values", strings.NewReader(`{"code":"HIGH","name":"High"}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("create value: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusInternalServerError {
+		t.Fatalf("create value with a failing code check = %d, want 500", resp.StatusCode)
+	}
+
+	var count int64
+	if err := db.Model(&models.LookupValue{}).Where("category_id = ?", category.ID).Count(&count).Error; err != nil {
+		t.Fatalf("count values: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("%d values created, want none", count)
+	}
+}
+
+func TestExportAllZipStreamsEveryCategory(t *testing.T) {
+	db := newTestDB(t)
+	for _, c := range []struct {
+		code   string
+		values []string
+	}{
+		{"PRIORITY", []string{"HIGH", "LOW"}},
+		{"SEVERITY", []string{"CRITICAL"}},
+	} {
+		category := &models.LookupCategory{Code: c.code, Name: c.code, IsActive: true}
+		if err := db.Create(category).Error; err != nil {
+			t.Fatalf("create category %s: %v", c.code, err)
+		}
+		for i, code := range c.values {
+			v := models.LookupValue{CategoryID: category.ID, Code: code, Name: code, SortOrder: i, IsActive: true, Status: models.LookupValueStatusActive}
+			if err := db.Omit("Category").Create(&v).Error; err != nil {
+				t.Fatalf("create value %s: %v", code, err)
+			}
+		}
+	}
+
+	h := NewLookupHandler(repository.NewLookupRepository(db), nil, config.LookupConfig{})
+	app := fiber.New()
+	app.Get("/export/all.zip", h.ExportAllZip)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/export/all.zip", nil))
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("export = %d, want 200", resp.StatusCode)
+	}
+	if resp.ContentLength != -1 {
+		t.Errorf("export Content-Length = %d, want a streamed body without one", resp.ContentLength)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read export: %v", err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatalf("open archive: %v", err)
+	}
+
+	var files []string
+	var manifest struct {
+		Categories []lookupExportManifestEntry `json:"categories"`
+	}
+	for _, f := range archive.File {
+		files = append(files, f.Name)
+		if f.Name != "manifest.json" {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("open manifest: %v", err)
+		}
+		err = json.NewDecoder(r).Decode(&manifest)
+		r.Close()
+		if err != nil {
+			t.Fatalf("decode manifest: %v", err)
+		}
+	}
+	if got, want := strings.Join(files, ","), "PRIORITY.csv,SEVERITY.csv,manifest.json"; got != want {
+		t.Errorf("archive files = %s, want %s", got, want)
+	}
+	counts := make(map[string]int)
+	for _, entry := range manifest.Categories {
+		counts[entry.Code] = entry.RowCount
+	}
+	if counts["PRIORITY"] != 2 || counts["SEVERITY"] != 1 {
+		t.Errorf("manifest row counts = %v, want PRIORITY 2 and SEVERITY 1", counts)
+	}
+}
+
+func TestLookupReadsAreCachedPrivately(t *testing.T) {
+	db := newTestDB(t)
+	category := &models.LookupCategory{Code: "PRIORITY", Name: "Priority", IsActive: true}
+	if err := db.Create(category).Error; err != nil {
+		t.Fatalf("create category: %v", err)
+	}
+	h := NewLookupHandler(repository.NewLookupRepository(db), nil, config.LookupConfig{CacheMaxAge: 120})
+	app := fiber.New()
+	app.Get("/lookups", h.GetAllLookups)
+	app.Get("/lookups/:code", h.GetValuesByCategoryCode)
+
+	for _, path := range []string{"/lookups", "/lookups/PRIORITY"} {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil))
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		if got, want := resp.Header.Get(fiber.HeaderCacheControl), "private, max-age=120, must-revalidate"; got != want {
+			t.Errorf("GET %s Cache-Control = %q, want %q", path, got, want)
+		}
+		if vary := resp.Header.Get(fiber.HeaderVary); !strings.Contains(vary, fiber.HeaderAuthorization) {
+			t.Errorf("GET %s Vary = %q, want it to name Authorization", path, vary)
+		}
+	}
+}
+
+// failingChangedAtRepository fails every lookup of a category's last change
+type failingChangedAtRepository struct {
+	repository.LookupRepository
+}
+
+func (r *failingChangedAtRepository) ValuesChangedAt(ctx context.Context, categoryID uuid.UUID) (time.Time, error) {
+	return time.Time{}, errors.New("connection reset")
+}
+
+func TestConditionalLookupReads(t *testing.T) {
+	db := newTestDB(t)
+	category := &models.LookupCategory{Code: "PRIORITY", Name: "Priority", IsActive: true}
+	if err := db.Create(category).Error; err != nil {
+		t.Fatalf("create category: %v", err)
+	}
+	value := models.LookupValue{CategoryID: category.ID, Code: "HIGH", Name: "High", IsActive: true, Status: models.LookupValueStatusActive}
+	if err := db.Omit("Category").Create(&value).Error; err != nil {
+		t.Fatalf("create value: %v", err)
+	}
+	lastWeek := time.Now().Add(-7 * 24 * time.Hour).UTC().Truncate(time.Second)
+	if err := db.Model(&models.LookupCategory{}).Where("id = ?", category.ID).UpdateColumn("updated_at", lastWeek).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&models.LookupValue{}).Where("id = ?", value.ID).UpdateColumn("updated_at", lastWeek).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	newApp := func(repo repository.LookupRepository) *fiber.App {
+		h := NewLookupHandler(repo, nil, config.LookupConfig{})
+		app := fiber.New()
+		app.Get("/lookups/:code", h.GetValuesByCategoryCode)
+		app.Get("/categories/:category_id", h.GetCategoryByID)
+		return app
+	}
+	app := newApp(repository.NewLookupRepository(db))
+	get := func(app *fiber.App, path string, since time.Time) *http.Response {
+		t.Helper()
+		req := httptest.NewRequest(fiber.MethodGet, path, nil)
+		if !since.IsZero() {
+			req.Header.Set(fiber.HeaderIfModifiedSince, since.Format(http.TimeFormat))
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		return resp
+	}
+	paths := []string{"/lookups/PRIORITY", "/categories/" + category.ID.String()}
+
+	for _, path := range paths {
+		resp := get(app, path, time.Time{})
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("GET %s = %d, want 200", path, resp.StatusCode)
+		}
+		if got := resp.Header.Get(fiber.HeaderLastModified); got != lastWeek.Format(http.TimeFormat) {
+			t.Errorf("GET %s Last-Modified = %q, want %q", path, got, lastWeek.Format(http.TimeFormat))
+		}
+		if resp := get(app, path, lastWeek); resp.StatusCode != fiber.StatusNotModified {
+			t.Errorf("GET %s since its Last-Modified = %d, want 304", path, resp.StatusCode)
+		}
+	}
+
+	// Deleting a value changes the listing even though no listed row moved
+	other := models.LookupValue{CategoryID: category.ID, Code: "LOW", Name: "Low", IsActive: true, Status: models.LookupValueStatusActive}
+	if err := db.Omit("Category").Create(&other).Error; err != nil {
+		t.Fatalf("create value: %v", err)
+	}
+	if err := db.Delete(&other).Error; err != nil {
+		t.Fatalf("delete value: %v", err)
+	}
+	for _, path := range paths {
+		if resp := get(app, path, lastWeek); resp.StatusCode != fiber.StatusOK {
+			t.Errorf("GET %s since before a change = %d, want 200", path, resp.StatusCode)
+		}
+	}
+
+	failing := newApp(&failingChangedAtRepository{LookupRepository: repository.NewLookupRepository(db)})
+	for _, path := range paths {
+		if resp := get(failing, path, lastWeek); resp.StatusCode != fiber.StatusInternalServerError {
+			t.Errorf("GET %s with a failing change lookup = %d, want 500", path, resp.StatusCode)
+		}
+	}
+}
+
+func TestGetSchemaDescribesEveryLookupRequest(t *testing.T) {
+	h := NewLookupHandler(nil, nil, config.LookupConfig{})
+	app := fiber.New()
+	app.Get("/schema/lookup", h.GetSchema)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/schema/lookup", nil))
+	if err != nil {
+		t.Fatalf("GET /schema/lookup: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("GET /schema/lookup = %d, want 200", resp.StatusCode)
+	}
+	var body struct {
+		Data map[string]struct {
+			Required   []string `json:"required"`
+			Properties map[string]struct {
+				MaxLength int `json:"maxLength"`
+			} `json:"properties"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode schemas: %v", err)
+	}
+
+	for name := range models.LookupRequestTypes() {
+		if _, ok := body.Data[name]; !ok {
+			t.Errorf("no schema for %s", name)
+		}
+	}
+	create := body.Data["LookupCategoryCreateRequest"]
+	if fmt.Sprint(create.Required) != "[code name]" {
+		t.Errorf("LookupCategoryCreateRequest required = %v, want [code name]", create.Required)
+	}
+	if got := create.Properties["code"].MaxLength; got != 50 {
+		t.Errorf("LookupCategoryCreateRequest code maxLength = %d, want 50", got)
+	}
+}
+
+func TestArchiveCategoryLeavesValuesActiveByDefault(t *testing.T) {
+	db := newTestDB(t)
+	category := &models.LookupCategory{Code: "SEASON", Name: "Season", IsActive: true}
+	if err := db.Create(category).Error; err != nil {
+		t.Fatalf("create category: %v", err)
+	}
+	value := models.LookupValue{CategoryID: category.ID, Code: "SUMMER", Name: "Summer", IsActive: true, Status: models.LookupValueStatusActive}
+	if err := db.Omit("Category").Create(&value).Error; err != nil {
+		t.Fatalf("create value: %v", err)
+	}
+
+	h := NewLookupHandler(repository.NewLookupRepository(db), nil, config.LookupConfig{})
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user", &models.User{IsSuperAdmin: true})
+		return c.Next()
+	})
+	app.Post("/categories/:category_id/archive", h.ArchiveCategory)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodPost, "/categories/"+category.ID.String()+"/archive", nil))
+	if err != nil {
+		t.Fatalf("archive: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("archive = %d, want 200", resp.StatusCode)
+	}
+	var stored models.LookupValue
+	if err := db.First(&stored, "id = ?", value.ID).Error; err != nil {
+		t.Fatalf("read value: %v", err)
+	}
+	if !stored.IsActive || stored.Status != models.LookupValueStatusActive {
+		t.Errorf("value after a plain archive: active=%v status=%q, want it left active", stored.IsActive, stored.Status)
+	}
+}
+
+func TestArchiveCategoryCascadesToActiveValuesWhenAsked(t *testing.T) {
+	db := newTestDB(t)
+	category, values := seedCategory(t, db, "SEASON", "SUMMER", "WINTER")
+	if err := db.Model(&values[1]).Update("is_active", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Post("/categories/:category_id/archive", h.ArchiveCategory)
+	})
+
+	var result models.LookupArchiveResult
+	if resp := sendJSON(t, app, fiber.MethodPost, "/categories/"+category.ID.String()+"/archive?cascade_values=true", "", &result); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("archive = %d, want 200", resp.StatusCode)
+	}
+	if result.Category.IsActive || result.Values != 1 {
+		t.Errorf("result category active=%v values=%d, want archived with 1 value cascaded", result.Category.IsActive, result.Values)
+	}
+	var stored []models.LookupValue
+	if err := db.Order("sort_order").Find(&stored, "category_id = ?", category.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if summer := stored[0]; summer.IsActive || summer.Status != models.LookupValueStatusArchived || summer.ArchivedAt == nil {
+		t.Errorf("SUMMER active=%v status=%q archived_at=%v, want archived with the category", summer.IsActive, summer.Status, summer.ArchivedAt)
+	}
+	if winter := stored[1]; winter.ArchivedAt != nil {
+		t.Errorf("WINTER archived_at = %v, want the value turned off earlier left unmarked", winter.ArchivedAt)
+	}
+}
+
+func TestLockedDefaultChangesAreForbidden(t *testing.T) {
+	db := newTestDB(t)
+	category := &models.LookupCategory{Code: "STATUS", Name: "Status", IsActive: true, LockDefault: true}
+	if err := db.Create(category).Error; err != nil {
+		t.Fatalf("create category: %v", err)
+	}
+	var low models.LookupValue
+	for i, code := range []string{"OPEN", "CLOSED"} {
+		v := models.LookupValue{CategoryID: category.ID, Code: code, Name: code, SortOrder: i, IsDefault: code == "OPEN", IsActive: true, Status: models.LookupValueStatusActive}
+		if err := db.Omit("Category").Create(&v).Error; err != nil {
+			t.Fatalf("create value %s: %v", code, err)
+		}
+		low = v
+	}
+
+	newApp := func(superAdmin bool) *fiber.App {
+		h := NewLookupHandler(repository.NewLookupRepository(db), nil, config.LookupConfig{})
+		app := fiber.New()
+		app.Use(func(c *fiber.Ctx) error {
+			c.Locals("user", &models.User{IsSuperAdmin: superAdmin})
+			return c.Next()
+		})
+		app.Put("/categories/:category_id", h.UpdateCategory)
+		app.Put("/values/:value_id", h.UpdateValue)
+		app.Post("/values/:value_id/set-default", h.SetDefaultValue)
+		return app
+	}
+	cases := []struct {
+		name       string
+		superAdmin bool
+		method     string
+		path       string
+		body       string
+	}{
+		{"set default", true, fiber.MethodPost, "/values/" + low.ID.String() + "/set-default", ""},
+		{"update is_default", true, fiber.MethodPut, "/values/" + low.ID.String(), `{"is_default":true}`},
+		{"unlock as an admin", false, fiber.MethodPut, "/categories/" + category.ID.String(), `{"lock_default":false}`},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := newApp(tc.superAdmin).Test(req)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if resp.StatusCode != fiber.StatusForbidden {
+			t.Errorf("%s = %d, want 403", tc.name, resp.StatusCode)
+		}
+	}
+
+	var defaults []string
+	if err := db.Model(&models.LookupValue{}).Where("category_id = ? AND is_default = ?", category.ID, true).Pluck("code", &defaults).Error; err != nil {
+		t.Fatalf("read default: %v", err)
+	}
+	if fmt.Sprint(defaults) != "[OPEN]" {
+		t.Errorf("defaults = %v, want [OPEN]", defaults)
+	}
+}
+
+func TestValueAliasesResolveToTheirValue(t *testing.T) {
+	db := newTestDB(t)
+	_, values := seedCategory(t, db, "PRIORITY", "HIGH", "LOW")
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Post("/values/:value_id/aliases", h.CreateAlias)
+		app.Get("/lookups/:code/resolve/:alias", h.ResolveValue)
+	})
+	aliases := "/values/" + values[0].ID.String() + "/aliases"
+
+	if resp := sendJSON(t, app, fiber.MethodPost, aliases, `{"alias_code":"urgent"}`, nil); resp.StatusCode != fiber.StatusCreated {
+		t.Fatalf("create alias = %d, want 201", resp.StatusCode)
+	}
+	for _, code := range []string{"URGENT", "urgent", "HIGH"} {
+		var resolved models.LookupValueResponse
+		if resp := sendJSON(t, app, fiber.MethodGet, "/lookups/PRIORITY/resolve/"+code, "", &resolved); resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("resolve %s = %d, want 200", code, resp.StatusCode)
+		}
+		if resolved.Code != "HIGH" {
+			t.Errorf("resolve %s = %s, want HIGH", code, resolved.Code)
+		}
+	}
+	if resp := sendJSON(t, app, fiber.MethodGet, "/lookups/PRIORITY/resolve/MEDIUM", "", nil); resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("resolve an unknown code = %d, want 404", resp.StatusCode)
+	}
+
+	for _, code := range []string{"LOW", "URGENT"} {
+		if resp := sendJSON(t, app, fiber.MethodPost, aliases, `{"alias_code":"`+code+`"}`, nil); resp.StatusCode != fiber.StatusConflict {
+			t.Errorf("alias %s, already taken in the category = %d, want 409", code, resp.StatusCode)
+		}
+	}
+}
+
+func TestGetValueTreeNestsActiveValuesUnderTheirParents(t *testing.T) {
+	db := newTestDB(t)
+	category, values := seedCategory(t, db, "LOCATION", "BUILDING", "FLOOR", "ROOM", "WING", "DESK")
+	parent := map[string]string{"FLOOR": "BUILDING", "ROOM": "FLOOR", "DESK": "WING"}
+	ids := make(map[string]uuid.UUID)
+	for _, v := range values {
+		ids[v.Code] = v.ID
+	}
+	for child, p := range parent {
+		if err := db.Model(&models.LookupValue{}).Where("id = ?", ids[child]).Update("parent_id", ids[p]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Model(&models.LookupValue{}).Where("id = ?", ids["WING"]).Update("is_active", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Get("/categories/:category_id/values/tree", h.GetValueTree)
+	})
+
+	var tree []*models.LookupValueTreeNode
+	if resp := sendJSON(t, app, fiber.MethodGet, "/categories/"+category.ID.String()+"/values/tree", "", &tree); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("tree = %d, want 200", resp.StatusCode)
+	}
+	var describe func(nodes []*models.LookupValueTreeNode) string
+	describe = func(nodes []*models.LookupValueTreeNode) string {
+		var parts []string
+		for _, n := range nodes {
+			part := n.Code
+			if n.Orphaned {
+				part += "!"
+			}
+			if len(n.Children) > 0 {
+				part += "(" + describe(n.Children) + ")"
+			}
+			parts = append(parts, part)
+		}
+		return strings.Join(parts, " ")
+	}
+	if got, want := describe(tree), "BUILDING(FLOOR(ROOM)) DESK!"; got != want {
+		t.Errorf("tree = %s, want %s", got, want)
+	}
+}
+
+func TestIncidentFormCategoriesNeedAnArabicName(t *testing.T) {
+	db := newTestDB(t)
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Post("/categories", h.CreateCategory)
+		app.Put("/categories/:category_id", h.UpdateCategory)
+	})
+
+	for _, tc := range []struct {
+		body string
+		want int
+	}{
+		{`{"code":"SEVERITY","name":"Severity","is_active":true,"add_to_incident_form":true}`, fiber.StatusBadRequest},
+		{`{"code":"SEVERITY","name":"Severity","name_ar":"  ","is_active":true,"add_to_incident_form":true}`, fiber.StatusBadRequest},
+		{`{"code":"REGION","name":"Region","is_active":false,"add_to_incident_form":true}`, fiber.StatusCreated},
+		{`{"code":"SEVERITY","name":"Severity","name_ar":"الخطورة","is_active":true,"add_to_incident_form":true}`, fiber.StatusCreated},
+	} {
+		if resp := sendJSON(t, app, fiber.MethodPost, "/categories", tc.body, nil); resp.StatusCode != tc.want {
+			t.Errorf("create %s = %d, want %d", tc.body, resp.StatusCode, tc.want)
+		}
+	}
+
+	category, _ := seedCategory(t, db, "PRIORITY", "HIGH", "LOW")
+	if err := db.Model(&models.LookupValue{}).Where("code = ?", "LOW").Update("name_ar", "منخفض").Error; err != nil {
+		t.Fatal(err)
+	}
+	resp := sendJSON(t, app, fiber.MethodPut, "/categories/"+category.ID.String(), `{"name_ar":"الأولوية","add_to_incident_form":true}`, nil)
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("update = %d, want 200", resp.StatusCode)
+	}
+	var body struct {
+		Warnings []string `json:"warnings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode update: %v", err)
+	}
+	if want := "[Values missing an Arabic name: HIGH]"; fmt.Sprint(body.Warnings) != want {
+		t.Errorf("update warnings = %v, want %s", body.Warnings, want)
+	}
+}
+
+func TestValueResponsesTellWhetherTheCategoryIsSystem(t *testing.T) {
+	db := newTestDB(t)
+	system, systemValues := seedCategory(t, db, "STATUS", "OPEN")
+	if err := db.Model(system).Update("is_system", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	_, customValues := seedCategory(t, db, "PRIORITY", "HIGH")
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Get("/values/:value_id", h.GetValueByID)
+	})
+
+	for _, tc := range []struct {
+		path string
+		want bool
+	}{
+		{"/values/" + systemValues[0].ID.String(), true},
+		{"/values/" + customValues[0].ID.String(), false},
+	} {
+		var value models.LookupValueResponse
+		if resp := sendJSON(t, app, fiber.MethodGet, tc.path, "", &value); resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("GET %s = %d, want 200", tc.path, resp.StatusCode)
+		}
+		if value.IsSystemCategory != tc.want {
+			t.Errorf("GET %s is_system_category = %v, want %v", tc.path, value.IsSystemCategory, tc.want)
+		}
+	}
+}
+
+func TestGetDefaultValueFallsBackOnlyWhenAsked(t *testing.T) {
+	db := newTestDB(t)
+	_, values := seedCategory(t, db, "PRIORITY", "HIGH", "MEDIUM", "LOW")
+	if err := db.Model(&values[1]).Updates(map[string]interface{}{"is_default": true, "is_active": false}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&values[0]).Update("is_active", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Get("/lookups/:code/default", h.GetDefaultValue)
+	})
+
+	if resp := sendJSON(t, app, fiber.MethodGet, "/lookups/PRIORITY/default", "", nil); resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("inactive default without fallback = %d, want 404", resp.StatusCode)
+	}
+	var fallback models.LookupDefaultValueResponse
+	if resp := sendJSON(t, app, fiber.MethodGet, "/lookups/PRIORITY/default?fallback=true", "", &fallback); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("inactive default with fallback = %d, want 200", resp.StatusCode)
+	}
+	if fallback.Code != "LOW" || !fallback.IsFallback {
+		t.Errorf("fallback = %s is_fallback=%v, want the first active value LOW flagged", fallback.Code, fallback.IsFallback)
+	}
+
+	if err := db.Model(&values[1]).Update("is_active", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	var configured models.LookupDefaultValueResponse
+	if resp := sendJSON(t, app, fiber.MethodGet, "/lookups/PRIORITY/default?fallback=true", "", &configured); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("active default = %d, want 200", resp.StatusCode)
+	}
+	if configured.Code != "MEDIUM" || configured.IsFallback {
+		t.Errorf("default = %s is_fallback=%v, want MEDIUM unflagged", configured.Code, configured.IsFallback)
+	}
+}
+
+func TestSetDefaultValueKeepsTheDefaultOnAnInvalidRequest(t *testing.T) {
+	db := newTestDB(t)
+	_, values := seedCategory(t, db, "PRIORITY", "HIGH", "MEDIUM", "LOW")
+	other, _ := seedCategory(t, db, "SEVERITY")
+	if err := db.Model(&values[0]).Update("is_default", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&values[2]).Update("is_active", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Post("/values/:value_id/set-default", h.SetDefaultValue)
+	})
+	defaults := func() string {
+		t.Helper()
+		var codes []string
+		db.Model(&models.LookupValue{}).Where("is_default = ?", true).Order("code").Pluck("code", &codes)
+		return fmt.Sprint(codes)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		value models.LookupValue
+		body  string
+		want  int
+	}{
+		{"value of another category", values[1], `{"category_id":"` + other.ID.String() + `"}`, fiber.StatusUnprocessableEntity},
+		{"inactive value", values[2], "", fiber.StatusUnprocessableEntity},
+		{"missing value", models.LookupValue{ID: uuid.New()}, "", fiber.StatusNotFound},
+	} {
+		if resp := sendJSON(t, app, fiber.MethodPost, "/values/"+tc.value.ID.String()+"/set-default", tc.body, nil); resp.StatusCode != tc.want {
+			t.Errorf("%s = %d, want %d", tc.name, resp.StatusCode, tc.want)
+		}
+		if got := defaults(); got != "[HIGH]" {
+			t.Errorf("defaults after %s = %s, want [HIGH]", tc.name, got)
+		}
+	}
+
+	if resp := sendJSON(t, app, fiber.MethodPost, "/values/"+values[1].ID.String()+"/set-default", "", nil); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("set default = %d, want 200", resp.StatusCode)
+	}
+	if got := defaults(); got != "[MEDIUM]" {
+		t.Errorf("defaults = %s, want [MEDIUM]", got)
+	}
+}
+
+func TestLookupReadsReturnOnlyTheRequestedFields(t *testing.T) {
+	db := newTestDB(t)
+	seedCategory(t, db, "PRIORITY", "HIGH", "LOW")
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Get("/lookups/:code", h.GetValuesByCategoryCode)
+	})
+
+	var values []map[string]interface{}
+	if resp := sendJSON(t, app, fiber.MethodGet, "/lookups/PRIORITY?fields=code,+name", "", &values); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("fields=code,name = %d, want 200", resp.StatusCode)
+	}
+	if len(values) != 2 {
+		t.Fatalf("got %d values, want 2", len(values))
+	}
+	for _, v := range values {
+		var keys []string
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		if fmt.Sprint(keys) != "[code name]" {
+			t.Errorf("value fields = %v, want [code name]", keys)
+		}
+	}
+
+	if resp := sendJSON(t, app, fiber.MethodGet, "/lookups/PRIORITY?fields=code,password", "", nil); resp.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("unknown field = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestUpsertCategoryByCodeCreatesThenUpdates(t *testing.T) {
+	db := newTestDB(t)
+	system, _ := seedCategory(t, db, "STATUS")
+	if err := db.Model(system).Update("is_system", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Put("/categories/code/:code", h.UpsertCategoryByCode)
+	})
+
+	var created, updated models.LookupCategoryResponse
+	if resp := sendJSON(t, app, fiber.MethodPut, "/categories/code/priority", `{"name":"Priority"}`, &created); resp.StatusCode != fiber.StatusCreated {
+		t.Fatalf("first PUT = %d, want 201", resp.StatusCode)
+	}
+	if created.Code != "PRIORITY" {
+		t.Errorf("created code = %q, want PRIORITY", created.Code)
+	}
+	if resp := sendJSON(t, app, fiber.MethodPut, "/categories/code/PRIORITY", `{"name":"Urgency","is_active":false}`, &updated); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("second PUT = %d, want 200", resp.StatusCode)
+	}
+	if updated.ID != created.ID || updated.Name != "Urgency" || updated.IsActive {
+		t.Errorf("updated = %+v, want category %s renamed Urgency and inactive", updated, created.ID)
+	}
+
+	var status models.LookupCategoryResponse
+	if resp := sendJSON(t, app, fiber.MethodPut, "/categories/code/STATUS", `{"name":"State","is_active":false}`, &status); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("PUT system category = %d, want 200", resp.StatusCode)
+	}
+	if status.Name != "State" || !status.IsActive {
+		t.Errorf("system category = %+v, want renamed State and still active", status)
+	}
+}
+
+func TestLookupErrorsSeparateMalformedInputFromRuleViolations(t *testing.T) {
+	db := newTestDB(t)
+	system, _ := seedCategory(t, db, "STATUS")
+	if err := db.Model(system).Update("is_system", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	seedCategory(t, db, "PRIORITY")
+	cfg := config.LookupConfig{CategoryCodeScope: config.CategoryCodeScopeGlobal}
+	app := newAdminTestApp(db, cfg, func(app *fiber.App, h *LookupHandler) {
+		app.Post("/categories", h.CreateCategory)
+		app.Delete("/categories/:category_id", h.DeleteCategory)
+	})
+
+	for _, tc := range []struct {
+		name, method, path, body string
+		want                     int
+	}{
+		{"malformed JSON", fiber.MethodPost, "/categories", `{"code":`, fiber.StatusBadRequest},
+		{"failed validation", fiber.MethodPost, "/categories", `{"code":"SEVERITY"}`, fiber.StatusBadRequest},
+		{"code taken", fiber.MethodPost, "/categories", `{"code":"priority","name":"Priority"}`, fiber.StatusConflict},
+		{"system category delete", fiber.MethodDelete, "/categories/" + system.ID.String(), "", fiber.StatusUnprocessableEntity},
+	} {
+		if resp := sendJSON(t, app, tc.method, tc.path, tc.body, nil); resp.StatusCode != tc.want {
+			t.Errorf("%s: %s %s = %d, want %d", tc.name, tc.method, tc.path, resp.StatusCode, tc.want)
+		}
+	}
+}
+
+func TestValuesAreLocalizedByAcceptLanguage(t *testing.T) {
+	db := newTestDB(t)
+	_, values := seedCategory(t, db, "PRIORITY", "HIGH", "LOW")
+	repo := repository.NewLookupRepository(db)
+	for _, tr := range []models.LookupValueTranslation{
+		{ValueID: values[0].ID, Locale: "fr", Name: "Haute", Description: "Priorité haute"},
+		{ValueID: values[0].ID, Locale: "ar", Name: "عالية"},
+	} {
+		if err := repo.SetValueTranslation(context.Background(), &tr); err != nil {
+			t.Fatalf("SetValueTranslation %s: %v", tr.Locale, err)
+		}
+	}
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Get("/lookups/:code", h.GetValuesByCategoryCode)
+	})
+
+	for _, tc := range []struct {
+		header string
+		want   string
+	}{
+		{"", "HIGH/ LOW/"},
+		{"fr-CA", "HIGH/Haute LOW/"},
+		{"en, fr;q=0.5", "HIGH/ LOW/"},
+		{"ar", "HIGH/عالية LOW/"},
+	} {
+		req := httptest.NewRequest(fiber.MethodGet, "/lookups/PRIORITY", nil)
+		req.Header.Set(fiber.HeaderAcceptLanguage, tc.header)
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body struct {
+			Data []models.LookupValueResponse `json:"data"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, v := range body.Data {
+			name := ""
+			if v.Locale != "" {
+				name = v.Name
+			}
+			got = append(got, v.Code+"/"+name)
+		}
+		if strings.Join(got, " ") != tc.want {
+			t.Errorf("Accept-Language %q localized %v, want %s", tc.header, got, tc.want)
+		}
+	}
+}
+
+func TestUnarchiveCategoryReactivatesArchivedValuesOnlyWhenAsked(t *testing.T) {
+	for _, withValues := range []bool{false, true} {
+		t.Run(fmt.Sprintf("with_values=%v", withValues), func(t *testing.T) {
+			db := newTestDB(t)
+			category, values := seedCategory(t, db, "SEASON", "SUMMER", "WINTER")
+			// An admin turned WINTER off before the archive
+			if err := db.Model(&values[1]).Update("is_active", false).Error; err != nil {
+				t.Fatal(err)
+			}
+			app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+				app.Post("/categories/:category_id/archive", h.ArchiveCategory)
+				app.Post("/categories/:category_id/unarchive", h.UnarchiveCategory)
+			})
+			base := "/categories/" + category.ID.String()
+			if resp := sendJSON(t, app, fiber.MethodPost, base+"/archive?cascade_values=true", "", nil); resp.StatusCode != fiber.StatusOK {
+				t.Fatalf("archive = %d, want 200", resp.StatusCode)
+			}
+
+			var result models.LookupArchiveResult
+			path := fmt.Sprintf("%s/unarchive?with_values=%v", base, withValues)
+			if resp := sendJSON(t, app, fiber.MethodPost, path, "", &result); resp.StatusCode != fiber.StatusOK {
+				t.Fatalf("unarchive = %d, want 200", resp.StatusCode)
+			}
+			var active []string
+			if err := db.Model(&models.LookupValue{}).Where("category_id = ? AND is_active = ?", category.ID, true).Pluck("code", &active).Error; err != nil {
+				t.Fatal(err)
+			}
+			want := "[]"
+			if withValues {
+				want = "[SUMMER]"
+			}
+			if !result.Category.IsActive || fmt.Sprint(active) != want {
+				t.Errorf("after unarchive category active=%v, active values %v, want active and %s", result.Category.IsActive, active, want)
+			}
+			if resp := sendJSON(t, app, fiber.MethodPost, base+"/unarchive", "", nil); resp.StatusCode != fiber.StatusUnprocessableEntity {
+				t.Errorf("second unarchive = %d, want 422", resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestUpdateValueReturnsOnlyChangedFieldsWhenAsked(t *testing.T) {
+	db := newTestDB(t)
+	_, values := seedCategory(t, db, "PRIORITY", "HIGH")
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Put("/values/:value_id", h.UpdateValue)
+	})
+	path := "/values/" + values[0].ID.String()
+
+	var changed map[string]interface{}
+	if resp := sendJSON(t, app, fiber.MethodPut, path+"?return=changed", `{"name":"Urgent","code":"HIGH"}`, &changed); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("PUT ?return=changed = %d, want 200", resp.StatusCode)
+	}
+	var keys []string
+	for key := range changed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if want := "[id name updated_at]"; fmt.Sprint(keys) != want || changed["name"] != "Urgent" {
+		t.Errorf("changed fields = %v, want %s with the new name", changed, want)
+	}
+
+	var full map[string]interface{}
+	if resp := sendJSON(t, app, fiber.MethodPut, path, `{"name":"Critical"}`, &full); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("PUT = %d, want 200", resp.StatusCode)
+	}
+	if full["code"] != "HIGH" || full["name"] != "Critical" {
+		t.Errorf("full response = %v, want the whole value", full)
+	}
+}
+
+// createValueWarnings creates a value from body and returns the warnings of
+// the 201 response
+func createValueWarnings(t *testing.T, app *fiber.App, categoryID uuid.UUID, body string) []string {
+	t.Helper()
+	path := "/categories/" + categoryID.String() + "/values"
+	resp := sendJSON(t, app, fiber.MethodPost, path, body, nil)
+	if resp.StatusCode != fiber.StatusCreated {
+		t.Fatalf("POST %s %s = %d, want 201", path, body, resp.StatusCode)
+	}
+	var envelope struct {
+		Warnings []string `json:"warnings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		t.Fatalf("decode POST %s: %v", path, err)
+	}
+	return envelope.Warnings
+}
+
+func TestCreateValueWarnsAboutNearDuplicateNames(t *testing.T) {
+	db := newTestDB(t)
+	category, values := seedCategory(t, db, "PRIORITY", "HIGH", "LOW")
+	if err := db.Model(&values[1]).Update("is_active", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Post("/categories/:category_id/values", h.CreateValue)
+	})
+
+	warnings := createValueWarnings(t, app, category.ID, `{"code":"HIGHT","name":"Hight"}`)
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "HIGH") {
+		t.Errorf("warnings for Hight = %v, want one naming HIGH", warnings)
+	}
+	if warnings := createValueWarnings(t, app, category.ID, `{"code":"LOWE","name":"Lowe"}`); len(warnings) != 0 {
+		t.Errorf("warnings for Lowe = %v, want none since LOW is inactive", warnings)
+	}
+	if warnings := createValueWarnings(t, app, category.ID, `{"code":"MEDIUM","name":"Medium"}`); len(warnings) != 0 {
+		t.Errorf("warnings for Medium = %v, want none", warnings)
+	}
+}
+
+func TestCreateValueWarnsFromTheSoftLimit(t *testing.T) {
+	db := newTestDB(t)
+	category, _ := seedCategory(t, db, "ZONE", "Z1", "Z2", "Z3", "Z4", "Z5", "Z6")
+	cfg := config.LookupConfig{MaxValuesPerCategory: 10, ValuesSoftLimitPercent: 80}
+	app := newAdminTestApp(db, cfg, func(app *fiber.App, h *LookupHandler) {
+		app.Post("/categories/:category_id/values", h.CreateValue)
+	})
+
+	if warnings := createValueWarnings(t, app, category.ID, `{"code":"Z7","name":"North"}`); len(warnings) != 0 {
+		t.Errorf("7th of 10 values warned %v, want no warning below 80%%", warnings)
+	}
+	warnings := createValueWarnings(t, app, category.ID, `{"code":"Z8","name":"South"}`)
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "8 of at most 10") {
+		t.Errorf("8th of 10 values warned %v, want the soft-limit warning", warnings)
+	}
+	if warnings := createValueWarnings(t, app, category.ID, `{"code":"Z9","name":"East","is_active":false}`); len(warnings) != 0 {
+		t.Errorf("inactive value warned %v, want none since it does not count", warnings)
+	}
+}
+
+func TestSharedValuesResolveUntilTheTokenExpires(t *testing.T) {
+	db := newTestDB(t)
+	_, values := seedCategory(t, db, "PRIORITY", "HIGH")
+	jwtManager := utils.NewJWTManager("test-secret", 1)
+	newApp := func(ttl time.Duration) *fiber.App {
+		h := NewLookupHandler(repository.NewLookupRepository(db), jwtManager, config.LookupConfig{ShareTokenTTL: ttl})
+		app := fiber.New()
+		app.Get("/values/:value_id/share", h.ShareValue)
+		app.Get("/public/shared/:token", h.GetSharedValue)
+		return app
+	}
+	share := func(app *fiber.App) string {
+		t.Helper()
+		var link models.LookupValueShareResponse
+		if resp := sendJSON(t, app, fiber.MethodGet, "/values/"+values[0].ID.String()+"/share", "", &link); resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("share = %d, want 200", resp.StatusCode)
+		}
+		return link.Token
+	}
+
+	app := newApp(time.Hour)
+	var value models.LookupValueResponse
+	if resp := sendJSON(t, app, fiber.MethodGet, "/public/shared/"+share(app), "", &value); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("resolve = %d, want 200", resp.StatusCode)
+	}
+	if value.ID != values[0].ID {
+		t.Errorf("shared value = %s, want %s", value.ID, values[0].ID)
+	}
+
+	expired := newApp(-time.Minute)
+	if resp := sendJSON(t, expired, fiber.MethodGet, "/public/shared/"+share(expired), "", nil); resp.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("resolve an expired token = %d, want 401", resp.StatusCode)
+	}
+	if resp := sendJSON(t, app, fiber.MethodGet, "/values/"+uuid.NewString()+"/share", "", nil); resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("share an unknown value = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestPatchValueMergesTheRequestedFields(t *testing.T) {
+	db := newTestDB(t)
+	_, values := seedCategory(t, db, "LOCATION", "UAE", "DUBAI")
+	if err := db.Model(&values[1]).Updates(map[string]interface{}{"parent_id": values[0].ID, "description": "City", "color": "#FF0000"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Patch("/values/:value_id", h.PatchValue)
+	})
+	path := "/values/" + values[1].ID.String()
+
+	var patched models.LookupValueResponse
+	if resp := sendJSON(t, app, fiber.MethodPatch, path, `{"description":null,"parent_id":null,"name":"Dubai"}`, &patched); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("PATCH = %d, want 200", resp.StatusCode)
+	}
+	var stored models.LookupValue
+	if err := db.First(&stored, "id = ?", values[1].ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Name != "Dubai" || stored.Description != "" || stored.ParentID != nil || stored.Color != "#FF0000" || stored.Code != "DUBAI" {
+		t.Errorf("stored = name %q description %q parent %v color %q code %q, want the name set, description and parent cleared, the rest kept",
+			stored.Name, stored.Description, stored.ParentID, stored.Color, stored.Code)
+	}
+
+	for _, body := range []string{`{"name":null}`, `{"colour":"red"}`, `{"sort_order":-1}`, `{}`} {
+		if resp := sendJSON(t, app, fiber.MethodPatch, path, body, nil); resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("PATCH %s = %d, want 400", body, resp.StatusCode)
+		}
+	}
+}
+
+func TestRecolorValuesMapsNormalizedColorsOnce(t *testing.T) {
+	db := newTestDB(t)
+	_, values := seedCategory(t, db, "PRIORITY", "HIGH", "URGENT", "MEDIUM", "LOW")
+	for i, color := range []string{"#ff0000", " #FF0000", "#00FF00", "#0000FF"} {
+		if err := db.Model(&values[i]).Update("color", color).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Post("/values/recolor", h.RecolorValues)
+	})
+
+	var result models.LookupRecolorResult
+	body := `{"#FF0000":"#00ff00","#00ff00":"#0000ff","#123456":"#654321"}`
+	if resp := sendJSON(t, app, fiber.MethodPost, "/values/recolor", body, &result); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("recolor = %d, want 200", resp.StatusCode)
+	}
+	if result.Total != 3 || result.Changed["#FF0000"] != 2 || result.Changed["#00FF00"] != 1 || result.Changed["#123456"] != 0 {
+		t.Errorf("result = %+v, want 2 red, 1 green and 0 unused", result)
+	}
+	var colors []string
+	if err := db.Model(&models.LookupValue{}).Order("sort_order").Pluck("color", &colors).Error; err != nil {
+		t.Fatal(err)
+	}
+	if want := "[#00FF00 #00FF00 #0000FF #0000FF]"; fmt.Sprint(colors) != want {
+		t.Errorf("colors = %v, want %s without chaining red to blue", colors, want)
+	}
+
+	if resp := sendJSON(t, app, fiber.MethodPost, "/values/recolor", `{"#abc":"#111","#ABC":"#222"}`, nil); resp.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("one color mapped twice = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestCreateCategoryFromTemplateOwnsAFreshCopy(t *testing.T) {
+	db := newTestDB(t)
+	orgID := uuid.New()
+	h := NewLookupHandler(repository.NewLookupRepository(db), nil, config.LookupConfig{})
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("org_id", &orgID)
+		return c.Next()
+	})
+	app.Post("/categories/from-template/:template_code", h.CreateCategoryFromTemplate)
+
+	var first, second models.LookupCategoryResponse
+	if resp := sendJSON(t, app, fiber.MethodPost, "/categories/from-template/itil_priority", "", &first); resp.StatusCode != fiber.StatusCreated {
+		t.Fatalf("first instance = %d, want 201", resp.StatusCode)
+	}
+	if resp := sendJSON(t, app, fiber.MethodPost, "/categories/from-template/ITIL_PRIORITY", `{"code":"p_legacy"}`, &second); resp.StatusCode != fiber.StatusCreated {
+		t.Fatalf("instance under another code = %d, want 201", resp.StatusCode)
+	}
+	if resp := sendJSON(t, app, fiber.MethodPost, "/categories/from-template/NOPE", "", nil); resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("unknown template = %d, want 404", resp.StatusCode)
+	}
+
+	if first.Code != "ITIL_PRIORITY" || second.Code != "P_LEGACY" || first.ID == second.ID {
+		t.Errorf("instances %s/%s and %s/%s, want two categories under their codes", first.ID, first.Code, second.ID, second.Code)
+	}
+	var stored []models.LookupValue
+	if err := db.Where("category_id IN ?", []uuid.UUID{first.ID, second.ID}).Find(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	ids := make(map[uuid.UUID]bool)
+	defaults := 0
+	for _, v := range stored {
+		ids[v.ID] = true
+		if v.OrgID == nil || *v.OrgID != orgID {
+			t.Errorf("value %s owned by %v, want the requester's org", v.Code, v.OrgID)
+		}
+		if v.IsDefault {
+			defaults++
+		}
+	}
+	if len(stored) != 10 || len(ids) != 10 || defaults != 2 {
+		t.Errorf("stored %d values with %d IDs and %d defaults, want 10 fresh values and a default per copy", len(stored), len(ids), defaults)
+	}
+}
+
+// uploadCSV posts content as the "file" form field to path and decodes the
+// data of the response into data
+func uploadCSV(t *testing.T, app *fiber.App, path, content string, data interface{}) *http.Response {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "values.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(part, content)
+	form.Close()
+
+	req := httptest.NewRequest(fiber.MethodPost, path, &body)
+	req.Header.Set(fiber.HeaderContentType, form.FormDataContentType())
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("POST %s: %v", path, err)
+	}
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		t.Fatalf("decode POST %s: %v", path, err)
+	}
+	if err := json.Unmarshal(envelope.Data, data); err != nil {
+		t.Fatalf("decode data of POST %s: %v", path, err)
+	}
+	return resp
+}
+
+func TestImportValidationModes(t *testing.T) {
+	const document = `{"categories":[{"code":"SEVERITY","name":"Severity","is_active":true,"values":[
+		{"code":"MAJOR","name":"Major","is_active":true},
+		{"code":"MINOR","is_active":true}]}]}`
+	const file = "code,name,sort_order\nHIGH,High,0\nLOW,Low,first\n"
+
+	for _, validation := range []string{"", "strict", "lenient"} {
+		t.Run("validation="+validation, func(t *testing.T) {
+			db := newTestDB(t)
+			category, _ := seedCategory(t, db, "PRIORITY")
+			app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+				app.Post("/import", h.ImportJSON)
+				app.Post("/categories/:category_id/import.csv", h.ImportCategoryCSV)
+			})
+			strict := validation != "lenient"
+			wantStatus, wantValues := fiber.StatusOK, "[HIGH MAJOR]"
+			if strict {
+				wantStatus, wantValues = fiber.StatusBadRequest, "[]"
+			}
+
+			var jsonResult, csvResult models.LookupImportResult
+			jsonResp := sendJSON(t, app, fiber.MethodPost, "/import?validation="+validation, document, &jsonResult)
+			csvResp := uploadCSV(t, app, "/categories/"+category.ID.String()+"/import.csv?validation="+validation, file, &csvResult)
+			for name, tc := range map[string]struct {
+				resp   *http.Response
+				result models.LookupImportResult
+				failed string
+			}{
+				"JSON": {jsonResp, jsonResult, "categories[0].values[1]"},
+				"CSV":  {csvResp, csvResult, "line 3"},
+			} {
+				if tc.resp.StatusCode != wantStatus {
+					t.Errorf("%s import = %d, want %d", name, tc.resp.StatusCode, wantStatus)
+				}
+				if tc.result.Failed != 1 || len(tc.result.Errors) != 1 || tc.result.Errors[0].Location != tc.failed {
+					t.Errorf("%s import errors = %+v, want only %s", name, tc.result.Errors, tc.failed)
+				}
+			}
+
+			values := []string{}
+			if err := db.Model(&models.LookupValue{}).Order("code").Pluck("code", &values).Error; err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprint(values) != wantValues {
+				t.Errorf("saved values = %v, want %s", values, wantValues)
+			}
+		})
+	}
+	db := newTestDB(t)
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Post("/import", h.ImportJSON)
+	})
+	if resp := sendJSON(t, app, fiber.MethodPost, "/import?validation=loose", document, nil); resp.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("unknown validation mode = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestIncidentFormTakesOneCategoryPerCodePrefix(t *testing.T) {
+	db := newTestDB(t)
+	priority, _ := seedCategory(t, db, "PRIORITY")
+	if err := db.Model(priority).Updates(map[string]interface{}{"add_to_incident_form": true, "name_ar": "الأولوية"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Post("/categories", h.CreateCategory)
+		app.Put("/categories/:category_id", h.UpdateCategory)
+	})
+
+	var conflict models.LookupConflictResponse
+	body := `{"code":"PRIORITY_LEGACY","name":"Legacy","name_ar":"قديم","add_to_incident_form":true}`
+	if resp := sendJSON(t, app, fiber.MethodPost, "/categories", body, &conflict); resp.StatusCode != fiber.StatusConflict {
+		t.Fatalf("second PRIORITY category on the form = %d, want 409", resp.StatusCode)
+	}
+	if conflict.ID != priority.ID {
+		t.Errorf("conflict = %+v, want PRIORITY named", conflict)
+	}
+
+	var legacy models.LookupCategoryResponse
+	if resp := sendJSON(t, app, fiber.MethodPost, "/categories", `{"code":"PRIORITY_LEGACY","name":"Legacy","name_ar":"قديم"}`, &legacy); resp.StatusCode != fiber.StatusCreated {
+		t.Fatalf("PRIORITY_LEGACY off the form = %d, want 201", resp.StatusCode)
+	}
+	if resp := sendJSON(t, app, fiber.MethodPut, "/categories/"+legacy.ID.String(), `{"add_to_incident_form":true}`, nil); resp.StatusCode != fiber.StatusConflict {
+		t.Errorf("moving PRIORITY_LEGACY onto the form = %d, want 409", resp.StatusCode)
+	}
+	if resp := sendJSON(t, app, fiber.MethodPut, "/categories/"+priority.ID.String(), `{"name":"Priority","add_to_incident_form":true}`, nil); resp.StatusCode != fiber.StatusOK {
+		t.Errorf("updating PRIORITY on the form = %d, want 200", resp.StatusCode)
+	}
+	if resp := sendJSON(t, app, fiber.MethodPost, "/categories", `{"code":"PRIORITYX","name":"Other","name_ar":"أخرى","add_to_incident_form":true}`, nil); resp.StatusCode != fiber.StatusCreated {
+		t.Errorf("PRIORITYX, another prefix, on the form = %d, want 201", resp.StatusCode)
+	}
+}
+
+func TestGetCategoriesBatchKeepsTheRequestOrder(t *testing.T) {
+	db := newTestDB(t)
+	priority, _ := seedCategory(t, db, "PRIORITY", "HIGH", "LOW")
+	severity, _ := seedCategory(t, db, "SEVERITY", "MAJOR")
+	deleted, _ := seedCategory(t, db, "RETIRED")
+	if err := db.Delete(deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Post("/categories/batch", h.GetCategoriesBatch)
+	})
+
+	unknown := uuid.New()
+	body := fmt.Sprintf(`{"ids":["%s","%s","%s","%s","%s"]}`, severity.ID, unknown, priority.ID, severity.ID, deleted.ID)
+	var batch models.LookupCategoryBatchResponse
+	if resp := sendJSON(t, app, fiber.MethodPost, "/categories/batch", body, &batch); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("batch = %d, want 200", resp.StatusCode)
+	}
+	var got []string
+	for _, category := range batch.Categories {
+		got = append(got, fmt.Sprintf("%s:%d", category.Code, len(category.Values)))
+	}
+	if fmt.Sprint(got) != "[SEVERITY:1 PRIORITY:2]" {
+		t.Errorf("categories = %v, want [SEVERITY:1 PRIORITY:2] in request order with their values", got)
+	}
+	if fmt.Sprint(batch.Missing) != fmt.Sprint([]uuid.UUID{unknown, deleted.ID}) {
+		t.Errorf("missing = %v, want the unknown and deleted IDs", batch.Missing)
+	}
+
+	if resp := sendJSON(t, app, fiber.MethodPost, "/categories/batch", `{"ids":[]}`, nil); resp.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("empty batch = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestValuesAreListedWithinTheirEffectiveWindow(t *testing.T) {
+	db := newTestDB(t)
+	_, values := seedCategory(t, db, "PROMOTION", "ALWAYS", "ENDED", "CURRENT", "UPCOMING")
+	now := time.Now().UTC()
+	day := 24 * time.Hour
+	for i, window := range []map[string]interface{}{
+		{"effective_to": now.Add(-day)},
+		{"effective_from": now.Add(-day), "effective_to": now.Add(day)},
+		{"effective_from": now.Add(2 * day)},
+	} {
+		if err := db.Model(&values[i+1]).Updates(window).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Get("/lookups/:code", h.GetValuesByCategoryCode)
+	})
+
+	for _, tc := range []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"ALWAYS", "CURRENT"}},
+		{"?as_of=" + url.QueryEscape(now.Add(3*day).Format(time.RFC3339)), []string{"ALWAYS", "UPCOMING"}},
+		{"?as_of=" + url.QueryEscape(now.Add(-2*day).Format(time.RFC3339)), []string{"ALWAYS", "ENDED"}},
+	} {
+		if got := getValueCodes(t, app, "/lookups/PROMOTION"+tc.query); fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("GET /lookups/PROMOTION%s = %v, want %v", tc.query, got, tc.want)
+		}
+	}
+	if resp := sendJSON(t, app, fiber.MethodGet, "/lookups/PROMOTION?as_of=tomorrow", "", nil); resp.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("malformed as_of = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestMoveValuesAppendsThemToTheTargetInOneStep(t *testing.T) {
+	db := newTestDB(t)
+	source, values := seedCategory(t, db, "SEVERITY", "MINOR", "MAJOR", "CRITICAL")
+	target, _ := seedCategory(t, db, "IMPACT", "LOW", "HIGH")
+	system, _ := seedCategory(t, db, "STATUS")
+	if err := db.Model(system).Update("is_system", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&values[1]).Update("is_default", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&values[2]).Update("parent_id", values[1].ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	_, others := seedCategory(t, db, "OTHER", "MINOR")
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Post("/categories/:category_id/values/move", h.MoveValues)
+	})
+	path := "/categories/" + source.ID.String() + "/values/move"
+	move := func(target uuid.UUID, ids ...uuid.UUID) string {
+		quoted := make([]string, len(ids))
+		for i, id := range ids {
+			quoted[i] = `"` + id.String() + `"`
+		}
+		return fmt.Sprintf(`{"target_category_id":"%s","value_ids":[%s]}`, target, strings.Join(quoted, ","))
+	}
+
+	for _, tc := range []struct {
+		name string
+		body string
+		want int
+	}{
+		{"value of another category", move(target.ID, values[0].ID, others[0].ID), fiber.StatusUnprocessableEntity},
+		{"system target", move(system.ID, values[0].ID), fiber.StatusUnprocessableEntity},
+		{"same category", move(source.ID, values[0].ID), fiber.StatusBadRequest},
+		{"unknown target", move(uuid.New(), values[0].ID), fiber.StatusNotFound},
+	} {
+		if resp := sendJSON(t, app, fiber.MethodPost, path, tc.body, nil); resp.StatusCode != tc.want {
+			t.Errorf("move %s = %d, want %d", tc.name, resp.StatusCode, tc.want)
+		}
+	}
+	var count int64
+	db.Model(&models.LookupValue{}).Where("category_id = ?", source.ID).Count(&count)
+	if count != 3 {
+		t.Fatalf("source has %d values after rejected moves, want all 3", count)
+	}
+
+	var moved []models.LookupValueResponse
+	if resp := sendJSON(t, app, fiber.MethodPost, path, move(target.ID, values[1].ID, values[0].ID), &moved); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("move = %d, want 200", resp.StatusCode)
+	}
+	var got []string
+	for _, v := range moved {
+		if v.CategoryID != target.ID || v.IsDefault {
+			t.Errorf("moved %s: category %s default %v, want %s and not default", v.Code, v.CategoryID, v.IsDefault, target.ID)
+		}
+		got = append(got, fmt.Sprintf("%s:%d", v.Code, v.SortOrder))
+	}
+	if fmt.Sprint(got) != "[MINOR:2 MAJOR:3]" {
+		t.Errorf("moved = %v, want [MINOR:2 MAJOR:3] after the target's values", got)
+	}
+
+	var left models.LookupValue
+	if err := db.First(&left, "id = ?", values[2].ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if left.CategoryID != source.ID || left.ParentID != nil {
+		t.Errorf("CRITICAL left in %s with parent %v, want it in the source without its moved parent", left.CategoryID, left.ParentID)
+	}
+
+	if resp := sendJSON(t, app, fiber.MethodPost, "/categories/"+others[0].CategoryID.String()+"/values/move", move(target.ID, others[0].ID), nil); resp.StatusCode != fiber.StatusConflict {
+		t.Errorf("move onto a taken code = %d, want 409", resp.StatusCode)
+	}
+}
+
+func TestValueCodesMustMatchTheCategoryPattern(t *testing.T) {
+	db := newTestDB(t)
+	country, values := seedCategory(t, db, "COUNTRY", "SA")
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Put("/categories/:category_id", h.UpdateCategory)
+		app.Post("/categories/:category_id/values", h.CreateValue)
+		app.Put("/values/:value_id", h.UpdateValue)
+	})
+	categoryPath := "/categories/" + country.ID.String()
+	valuePath := "/values/" + values[0].ID.String()
+
+	if resp := sendJSON(t, app, fiber.MethodPut, categoryPath, `{"value_code_pattern":"[A-Z"}`, nil); resp.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("pattern that doesn't compile = %d, want 400", resp.StatusCode)
+	}
+	if resp := sendJSON(t, app, fiber.MethodPut, categoryPath, `{"value_code_pattern":"[A-Z]{2}"}`, nil); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("set pattern = %d, want 200", resp.StatusCode)
+	}
+
+	rejected := func(method, path, body string) {
+		t.Helper()
+		resp := sendJSON(t, app, method, path, body, nil)
+		var failure utils.ValidationErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&failure); err != nil {
+			t.Fatalf("decode %s %s: %v", method, path, err)
+		}
+		if resp.StatusCode != fiber.StatusBadRequest || len(failure.Details) != 1 || failure.Details[0].Field != "code" {
+			t.Errorf("%s %s %s = %d %+v, want 400 on the code field", method, path, body, resp.StatusCode, failure.Details)
+		}
+	}
+	rejected(fiber.MethodPost, categoryPath+"/values", `{"code":"SAU","name":"Saudi Arabia"}`)
+	rejected(fiber.MethodPut, valuePath, `{"code":"S1"}`)
+	createValueWarnings(t, app, country.ID, `{"code":"AE","name":"Emirates"}`)
+	if resp := sendJSON(t, app, fiber.MethodPut, valuePath, `{"code":"KSA"}`, nil); resp.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("update to KSA = %d, want 400", resp.StatusCode)
+	}
+
+	if resp := sendJSON(t, app, fiber.MethodPut, categoryPath, `{"value_code_pattern":"[A-Z]{3}"}`, nil); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("change pattern = %d, want 200", resp.StatusCode)
+	}
+	if resp := sendJSON(t, app, fiber.MethodPut, valuePath, `{"code":"KSA"}`, nil); resp.StatusCode != fiber.StatusOK {
+		t.Errorf("update to KSA after the pattern changed = %d, want 200", resp.StatusCode)
+	}
+	rejected(fiber.MethodPost, categoryPath+"/values", `{"code":"QA","name":"Qatar"}`)
+}
+
+func TestCompactViewReturnsTheMinimalShape(t *testing.T) {
+	db := newTestDB(t)
+	seedCategory(t, db, "PRIORITY", "HIGH", "LOW")
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Get("/categories", h.ListCategories)
+		app.Get("/lookups/:code", h.GetValuesByCategoryCode)
+	})
+	keys := func(item map[string]json.RawMessage) string {
+		names := make([]string, 0, len(item))
+		for name := range item {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return strings.Join(names, ",")
+	}
+
+	for _, tc := range []struct {
+		path string
+		want string
+	}{
+		{"/lookups/PRIORITY?view=compact", "code,color,id,is_default,name"},
+		{"/categories?view=compact", "code,id,name,values"},
+	} {
+		var items []map[string]json.RawMessage
+		if resp := sendJSON(t, app, fiber.MethodGet, tc.path, "", &items); resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("GET %s = %d, want 200", tc.path, resp.StatusCode)
+		}
+		if len(items) == 0 {
+			t.Fatalf("GET %s returned nothing", tc.path)
+		}
+		for _, item := range items {
+			if got := keys(item); got != tc.want {
+				t.Errorf("GET %s item fields = %s, want %s", tc.path, got, tc.want)
+			}
+		}
+	}
+
+	var full []map[string]json.RawMessage
+	sendJSON(t, app, fiber.MethodGet, "/lookups/PRIORITY", "", &full)
+	if len(full) == 0 || full[0]["name_ar"] == nil || full[0]["created_at"] == nil {
+		t.Errorf("default view = %v, want the full value shape", full)
+	}
+	if resp := sendJSON(t, app, fiber.MethodGet, "/lookups/PRIORITY?view=tiny", "", nil); resp.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("unknown view = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestValidationFailuresShareOneShape(t *testing.T) {
+	db := newTestDB(t)
+	category, values := seedCategory(t, db, "PRIORITY", "HIGH")
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Post("/categories", h.CreateCategory)
+		app.Put("/categories/:category_id", h.UpdateCategory)
+		app.Post("/categories/:category_id/values", h.CreateValue)
+		app.Post("/categories/:category_id/values/bulk", h.BulkCreateValues)
+		app.Put("/categories/:category_id/values/bulk", h.UpsertValues)
+		app.Put("/values/:value_id", h.UpdateValue)
+	})
+	categoryPath := "/categories/" + category.ID.String()
+	long := strings.Repeat("x", 101)
+
+	for _, tc := range []struct {
+		name, method, path, body string
+	}{
+		{"create category", fiber.MethodPost, "/categories", `{"code":"","name":""}`},
+		{"update category", fiber.MethodPut, categoryPath, `{"name":"` + long + `"}`},
+		{"create value", fiber.MethodPost, categoryPath + "/values", `{"code":"LOW"}`},
+		{"update value", fiber.MethodPut, "/values/" + values[0].ID.String(), `{"name":"` + long + `"}`},
+		{"bulk create", fiber.MethodPost, categoryPath + "/values/bulk", `{"values":[{"code":"LOW"}]}`},
+		{"bulk upsert", fiber.MethodPut, categoryPath + "/values/bulk", `{"values":[]}`},
+	} {
+		resp := sendJSON(t, app, tc.method, tc.path, tc.body, nil)
+		var failure utils.ValidationErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&failure); err != nil {
+			t.Fatalf("%s: decode: %v", tc.name, err)
+		}
+		if resp.StatusCode != fiber.StatusBadRequest || failure.Success || failure.Code != utils.ErrCodeValidation || len(failure.Details) == 0 || failure.Error == "" {
+			t.Errorf("%s = %d %+v, want 400 %s with field details", tc.name, resp.StatusCode, failure, utils.ErrCodeValidation)
+		}
+	}
+}
+
+func TestRelatedCategoriesLinkBothWaysOnce(t *testing.T) {
+	db := newTestDB(t)
+	priority, _ := seedCategory(t, db, "PRIORITY", "HIGH")
+	sla, _ := seedCategory(t, db, "SLA", "GOLD")
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Get("/categories/:category_id/related", h.ListRelatedCategories)
+		app.Post("/categories/:category_id/related", h.LinkCategory)
+		app.Delete("/categories/:category_id/related/:related_id", h.UnlinkCategory)
+	})
+	priorityPath := "/categories/" + priority.ID.String() + "/related"
+	slaPath := "/categories/" + sla.ID.String() + "/related"
+	link := func(id uuid.UUID) string { return `{"related_category_id":"` + id.String() + `"}` }
+	related := func(path string) []string {
+		t.Helper()
+		var summaries []map[string]interface{}
+		if resp := sendJSON(t, app, fiber.MethodGet, path, "", &summaries); resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("GET %s = %d, want 200", path, resp.StatusCode)
+		}
+		var codes []string
+		for _, summary := range summaries {
+			if _, ok := summary["values"]; ok {
+				t.Errorf("GET %s returned values, want summaries only", path)
+			}
+			codes = append(codes, summary["code"].(string))
+		}
+		return codes
+	}
+
+	if resp := sendJSON(t, app, fiber.MethodPost, priorityPath, link(sla.ID), nil); resp.StatusCode != fiber.StatusCreated {
+		t.Fatalf("link = %d, want 201", resp.StatusCode)
+	}
+	if got := related(priorityPath); fmt.Sprint(got) != "[SLA]" {
+		t.Errorf("PRIORITY related = %v, want [SLA]", got)
+	}
+	if got := related(slaPath); fmt.Sprint(got) != "[PRIORITY]" {
+		t.Errorf("SLA related = %v, want [PRIORITY]", got)
+	}
+
+	for _, tc := range []struct {
+		name, path, body string
+		want             int
+	}{
+		{"the same link again", priorityPath, link(sla.ID), fiber.StatusConflict},
+		{"the reverse link", slaPath, link(priority.ID), fiber.StatusConflict},
+		{"itself", priorityPath, link(priority.ID), fiber.StatusUnprocessableEntity},
+		{"an unknown category", priorityPath, link(uuid.New()), fiber.StatusNotFound},
+	} {
+		if resp := sendJSON(t, app, fiber.MethodPost, tc.path, tc.body, nil); resp.StatusCode != tc.want {
+			t.Errorf("link to %s = %d, want %d", tc.name, resp.StatusCode, tc.want)
+		}
+	}
+
+	if resp := sendJSON(t, app, fiber.MethodDelete, slaPath+"/"+priority.ID.String(), "", nil); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("unlink = %d, want 200", resp.StatusCode)
+	}
+	if got := related(priorityPath); len(got) != 0 {
+		t.Errorf("PRIORITY related after unlink = %v, want none", got)
+	}
+	if resp := sendJSON(t, app, fiber.MethodDelete, slaPath+"/"+priority.ID.String(), "", nil); resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("second unlink = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestValidateValuesReportsSetProblemsWithoutSaving(t *testing.T) {
+	db := newTestDB(t)
+	category, _ := seedCategory(t, db, "PRIORITY", "HIGH")
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Post("/categories/:category_id/values/validate", h.ValidateValues)
+	})
+	path := "/categories/" + category.ID.String() + "/values/validate"
+
+	var result models.LookupValueSetValidation
+	body := `{"values":[
+		{"code":"low","name":"Low","is_default":true},
+		{"code":"LOW","name":"Low again","is_default":true},
+		{"code":"high","name":"High"},
+		{"code":"MEDIUM"}
+	]}`
+	if resp := sendJSON(t, app, fiber.MethodPost, path, body, &result); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("validate = %d, want 200", resp.StatusCode)
+	}
+	if result.Valid || len(result.SetErrors) != 2 {
+		t.Fatalf("result = %+v, want invalid with a duplicate code and a second default", result)
+	}
+	if !strings.Contains(result.SetErrors[0], "LOW appears 2 times") || !strings.Contains(result.SetErrors[1], "Only one value can be the default") {
+		t.Errorf("set errors = %q, want the duplicate LOW then the defaults", result.SetErrors)
+	}
+	var valid []bool
+	for _, item := range result.Items {
+		valid = append(valid, item.Valid)
+	}
+	if fmt.Sprint(valid) != "[true true false false]" {
+		t.Errorf("items valid = %v, want HIGH taken and MEDIUM without a name", valid)
+	}
+
+	if resp := sendJSON(t, app, fiber.MethodPost, path, `{"values":[{"code":"LOW","name":"Low"}]}`, &result); resp.StatusCode != fiber.StatusOK || !result.Valid {
+		t.Errorf("valid set = %d %+v, want 200 and valid", resp.StatusCode, result)
+	}
+	var count int64
+	db.Model(&models.LookupValue{}).Count(&count)
+	if count != 1 {
+		t.Errorf("%d values stored, want validation to save nothing", count)
+	}
+	if resp := sendJSON(t, app, fiber.MethodPost, path, `{"values":[]}`, nil); resp.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("empty set = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestValuePagesWithoutATotalTellWhetherMoreFollow(t *testing.T) {
+	db := newTestDB(t)
+	category, _ := seedCategory(t, db, "COUNTRY", "AE", "BH", "KW", "OM", "SA")
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Get("/categories/:category_id/values", h.ListValuesByCategory)
+	})
+	path := "/categories/" + category.ID.String() + "/values"
+
+	for _, tc := range []struct {
+		query string
+		codes string
+		more  bool
+	}{
+		{"?include_total=false&limit=2&page=1", "[AE BH]", true},
+		{"?include_total=false&limit=2&page=2", "[KW OM]", true},
+		{"?include_total=false&limit=2&page=3", "[SA]", false},
+		{"?include_total=false&limit=5&page=1", "[AE BH KW OM SA]", false},
+	} {
+		resp := sendJSON(t, app, fiber.MethodGet, path+tc.query, "", nil)
+		var page struct {
+			utils.UncountedPaginatedResponse
+			Data       []models.LookupValueResponse `json:"data"`
+			TotalItems *int64                       `json:"total_items"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+			t.Fatalf("decode %s: %v", tc.query, err)
+		}
+		var codes []string
+		for _, v := range page.Data {
+			codes = append(codes, v.Code)
+		}
+		if fmt.Sprint(codes) != tc.codes || page.HasMore != tc.more || page.TotalItems != nil {
+			t.Errorf("%s = %v has_more=%v total=%v, want %s has_more=%v and no total", tc.query, codes, page.HasMore, page.TotalItems, tc.codes, tc.more)
+		}
+		if resp.Header.Get(utils.HasMoreHeader) != fmt.Sprint(tc.more) {
+			t.Errorf("%s %s = %q, want %v", tc.query, utils.HasMoreHeader, resp.Header.Get(utils.HasMoreHeader), tc.more)
+		}
+	}
+
+	if resp := sendJSON(t, app, fiber.MethodGet, path+"?limit=2", "", nil); resp.Header.Get("X-Total-Count") != "5" {
+		t.Errorf("default X-Total-Count = %q, want 5", resp.Header.Get("X-Total-Count"))
+	}
+	if resp := sendJSON(t, app, fiber.MethodGet, path+"?include_total=maybe", "", nil); resp.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("include_total=maybe = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestCategoryGroupsFollowTheCodePrefix(t *testing.T) {
+	db := newTestDB(t)
+	for _, code := range []string{"IT_ASSET", "PRIORITY", "HR_LEAVE", "_HIDDEN", "HR_GRADE"} {
+		seedCategory(t, db, code)
+	}
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Get("/categories/groups", h.ListCategoryGroups)
+	})
+
+	var groups []models.LookupCategoryGroup
+	if resp := sendJSON(t, app, fiber.MethodGet, "/categories/groups", "", &groups); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("groups = %d, want 200", resp.StatusCode)
+	}
+	var got []string
+	for _, group := range groups {
+		codes := make([]string, len(group.Categories))
+		for i, category := range group.Categories {
+			codes[i] = category.Code
+		}
+		sort.Strings(codes)
+		got = append(got, group.Prefix+"="+strings.Join(codes, ","))
+	}
+	want := "[HR=HR_GRADE,HR_LEAVE IT=IT_ASSET " + models.LookupUngroupedPrefix + "=PRIORITY,_HIDDEN]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("groups = %v, want %s", got, want)
+	}
+}
+
+func TestDeletePreviewMatchesTheCategoryContents(t *testing.T) {
+	db := newTestDB(t)
+	category, values := seedCategory(t, db, "PRIORITY", "HIGH", "MEDIUM", "LOW")
+	if err := db.Model(&values[1]).Update("is_default", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&values[2]).Update("is_active", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	// Two incidents use HIGH, one of them MEDIUM too
+	for i, used := range [][]models.LookupValue{{values[0], values[1]}, {values[0]}} {
+		incident := models.Incident{ID: uuid.New(), IncidentNumber: fmt.Sprintf("INC-%d", i), Title: "Outage", WorkflowID: uuid.New(), CurrentStateID: uuid.New()}
+		if err := db.Omit(clause.Associations).Create(&incident).Error; err != nil {
+			t.Fatalf("create incident: %v", err)
+		}
+		for _, v := range used {
+			if err := db.Exec("INSERT INTO incident_lookup_values (incident_id, lookup_value_id) VALUES (?, ?)", incident.ID, v.ID).Error; err != nil {
+				t.Fatalf("use value: %v", err)
+			}
+		}
+	}
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Get("/categories/:category_id/delete-preview", h.PreviewDeleteCategory)
+	})
+	path := "/categories/" + category.ID.String() + "/delete-preview"
+
+	var preview models.LookupCategoryDeletePreview
+	if resp := sendJSON(t, app, fiber.MethodGet, path, "", &preview); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("preview = %d, want 200", resp.StatusCode)
+	}
+	want := models.LookupCategoryDeletePreview{
+		CategoryID: category.ID, Code: "PRIORITY", CanDelete: true,
+		Values: 3, ActiveValues: 2, HasDefault: true, DefaultValueCode: "MEDIUM",
+		Usage: models.LookupCategoryUsage{Incidents: 2, ValuesInUse: 2},
+	}
+	if preview != want {
+		t.Errorf("preview = %+v, want %+v", preview, want)
+	}
+
+	var count int64
+	db.Model(&models.LookupValue{}).Where("category_id = ?", category.ID).Count(&count)
+	if count != 3 {
+		t.Errorf("%d values left after the preview, want all 3", count)
+	}
+	if resp := sendJSON(t, app, fiber.MethodGet, "/categories/"+uuid.NewString()+"/delete-preview", "", nil); resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("preview of an unknown category = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestValuesAreFilteredByTagWithinTheCategory(t *testing.T) {
+	db := newTestDB(t)
+	priority, values := seedCategory(t, db, "PRIORITY", "CRITICAL", "HIGH", "LOW")
+	_, others := seedCategory(t, db, "SEVERITY", "MAJOR")
+	if err := db.Model(&values[1]).Update("is_active", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	escalation := &models.LookupTag{Code: "ESCALATION", Name: "Escalation"}
+	if err := db.Create(escalation).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.LookupTag{Code: "UNUSED", Name: "Unused"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []models.LookupValue{values[0], values[1], others[0]} {
+		if err := db.Exec("INSERT INTO lookup_value_tags (lookup_tag_id, lookup_value_id) VALUES (?, ?)", escalation.ID, v.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Get("/categories/:category_id/values", h.ListValuesByCategory)
+	})
+	path := "/categories/" + priority.ID.String() + "/values"
+
+	for _, tc := range []struct {
+		query string
+		want  string
+	}{
+		{"?tag=escalation", "[CRITICAL HIGH]"},
+		{"?tag=ESCALATION&active=true", "[CRITICAL]"},
+		{"?tag=UNUSED", "[]"},
+		{"?tag=MISSING", "[]"},
+		{"", "[CRITICAL HIGH LOW]"},
+	} {
+		if got := getValueCodes(t, app, path+tc.query); fmt.Sprint(got) != tc.want {
+			t.Errorf("GET %s%s = %v, want %s", path, tc.query, got, tc.want)
+		}
+	}
+}
+
+func TestListLimitOverflowFollowsTheConfig(t *testing.T) {
+	db := newTestDB(t)
+	seedCategory(t, db, "PRIORITY", "HIGH")
+	path := fmt.Sprintf("/categories?limit=%d", utils.MaxListLimit+1)
+
+	for _, tc := range []struct {
+		clamp bool
+		want  int
+	}{
+		{false, fiber.StatusBadRequest},
+		{true, fiber.StatusOK},
+	} {
+		app := newAdminTestApp(db, config.LookupConfig{ClampListLimit: tc.clamp}, func(app *fiber.App, h *LookupHandler) {
+			app.Get("/categories", h.ListCategories)
+		})
+		resp := sendJSON(t, app, fiber.MethodGet, path, "", nil)
+		if resp.StatusCode != tc.want {
+			t.Errorf("clamp=%v GET %s = %d, want %d", tc.clamp, path, resp.StatusCode, tc.want)
+		}
+		if tc.clamp && resp.Header.Get("X-Limit") != fmt.Sprint(utils.MaxListLimit) {
+			t.Errorf("clamped X-Limit = %q, want %d", resp.Header.Get("X-Limit"), utils.MaxListLimit)
+		}
+	}
+}
+
+func TestCloneValueCopiesTheSourceAsANewValue(t *testing.T) {
+	db := newTestDB(t)
+	category, values := seedCategory(t, db, "PRIORITY", "HIGH", "LOW")
+	source := map[string]interface{}{"name_ar": "عالي", "description": "Needs a reply today", "color": "#FF0000", "is_default": true}
+	if err := db.Model(&values[0]).Updates(source).Error; err != nil {
+		t.Fatal(err)
+	}
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Post("/values/:value_id/clone", h.CloneValue)
+	})
+	path := "/values/" + values[0].ID.String() + "/clone"
+
+	var clone models.LookupValueResponse
+	if resp := sendJSON(t, app, fiber.MethodPost, path, `{"code":"urgent","name":"Urgent"}`, &clone); resp.StatusCode != fiber.StatusCreated {
+		t.Fatalf("clone = %d, want 201", resp.StatusCode)
+	}
+	if clone.ID == values[0].ID || clone.CategoryID != category.ID || clone.Code != "URGENT" || clone.Name != "Urgent" {
+		t.Errorf("clone = %+v, want a new URGENT value in PRIORITY", clone)
+	}
+	if clone.NameAr != "عالي" || clone.Description != "Needs a reply today" || clone.Color != "#FF0000" {
+		t.Errorf("clone name_ar=%q description=%q color=%q, want the source's", clone.NameAr, clone.Description, clone.Color)
+	}
+	if clone.IsDefault || !clone.IsActive || clone.SortOrder != 2 {
+		t.Errorf("clone default=%v active=%v sort_order=%d, want an active non-default value after LOW", clone.IsDefault, clone.IsActive, clone.SortOrder)
+	}
+
+	if resp := sendJSON(t, app, fiber.MethodPost, path, `{"code":"LOW","name":"Low again"}`, nil); resp.StatusCode != fiber.StatusConflict {
+		t.Errorf("clone onto a taken code = %d, want 409", resp.StatusCode)
+	}
+	if resp := sendJSON(t, app, fiber.MethodPost, "/values/"+uuid.NewString()+"/clone", `{"code":"X","name":"X"}`, nil); resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("clone of an unknown value = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestServerTimingIsReportedOnlyWhenEnabled(t *testing.T) {
+	db := newTestDB(t)
+	seedCategory(t, db, "PRIORITY", "HIGH")
+	format := regexp.MustCompile(`^db;dur=(\d+\.\d), total;dur=(\d+\.\d)$`)
+
+	for _, enabled := range []bool{true, false} {
+		app := newAdminTestApp(db, config.LookupConfig{ServerTiming: enabled}, func(app *fiber.App, h *LookupHandler) {
+			app.Get("/lookups/:code", h.GetValuesByCategoryCode)
+		})
+		resp := sendJSON(t, app, fiber.MethodGet, "/lookups/PRIORITY", "", nil)
+		header := resp.Header.Get(utils.ServerTimingHeader)
+		if !enabled {
+			if header != "" {
+				t.Errorf("disabled %s = %q, want none", utils.ServerTimingHeader, header)
+			}
+			continue
+		}
+		m := format.FindStringSubmatch(header)
+		if m == nil {
+			t.Fatalf("%s = %q, want db;dur=N.N, total;dur=N.N", utils.ServerTimingHeader, header)
+		}
+		dbDur, _ := strconv.ParseFloat(m[1], 64)
+		total, _ := strconv.ParseFloat(m[2], 64)
+		if dbDur > total {
+			t.Errorf("%s = %q, want the database time within the total", utils.ServerTimingHeader, header)
+		}
+	}
+}
+
+func TestCreateValueHonorsAClientSuppliedID(t *testing.T) {
+	db := newTestDB(t)
+	category, values := seedCategory(t, db, "PRIORITY", "HIGH")
+	if err := db.Delete(&values[0]).Error; err != nil {
+		t.Fatal(err)
+	}
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Post("/categories/:category_id/values", h.CreateValue)
+	})
+	path := "/categories/" + category.ID.String() + "/values"
+	id := uuid.New()
+	body := fmt.Sprintf(`{"id":"%s","code":"LOW","name":"Low"}`, id)
+
+	var created models.LookupValueResponse
+	if resp := sendJSON(t, app, fiber.MethodPost, path, body, &created); resp.StatusCode != fiber.StatusCreated {
+		t.Fatalf("create with an id = %d, want 201", resp.StatusCode)
+	}
+	if created.ID != id {
+		t.Errorf("created id = %s, want the client's %s", created.ID, id)
+	}
+
+	for _, tc := range []struct {
+		name, body string
+		want       int
+	}{
+		{"retried create", body, fiber.StatusConflict},
+		{"id of a deleted value", fmt.Sprintf(`{"id":"%s","code":"MEDIUM","name":"Medium"}`, values[0].ID), fiber.StatusConflict},
+		{"malformed id", `{"id":"not-a-uuid","code":"MEDIUM","name":"Medium"}`, fiber.StatusBadRequest},
+		{"nil id", fmt.Sprintf(`{"id":"%s","code":"MEDIUM","name":"Medium"}`, uuid.Nil), fiber.StatusBadRequest},
+	} {
+		if resp := sendJSON(t, app, fiber.MethodPost, path, tc.body, nil); resp.StatusCode != tc.want {
+			t.Errorf("%s = %d, want %d", tc.name, resp.StatusCode, tc.want)
+		}
+	}
+	var count int64
+	db.Model(&models.LookupValue{}).Count(&count)
+	if count != 1 {
+		t.Errorf("%d live values, want only the first create saved", count)
+	}
+}
+
+func TestCategoryThemeColorComesFromTheDefaultValue(t *testing.T) {
+	db := newTestDB(t)
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Get("/categories/:category_id", h.GetCategoryByID)
+	})
+
+	for _, tc := range []struct {
+		name          string
+		defaultColor  string
+		categoryColor string
+		want          string
+	}{
+		{"colored default", "#FF0000", "#0000FF", "#FF0000"},
+		{"uncolored default", "", "#0000FF", "#0000FF"},
+		{"no colors", "", "", ""},
+	} {
+		category, values := seedCategory(t, db, strings.ToUpper(strings.ReplaceAll(tc.name, " ", "_")), "HIGH", "LOW")
+		if err := db.Model(category).Update("default_color", tc.categoryColor).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Model(&values[0]).Updates(map[string]interface{}{"is_default": true, "color": tc.defaultColor}).Error; err != nil {
+			t.Fatal(err)
+		}
+		// A colored value that isn't the default never sets the theme
+		if err := db.Model(&values[1]).Update("color", "#00FF00").Error; err != nil {
+			t.Fatal(err)
+		}
+
+		var got models.LookupCategoryResponse
+		if resp := sendJSON(t, app, fiber.MethodGet, "/categories/"+category.ID.String(), "", &got); resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("%s: GET = %d, want 200", tc.name, resp.StatusCode)
+		}
+		if got.ThemeColor != tc.want {
+			t.Errorf("%s: theme_color = %q, want %q", tc.name, got.ThemeColor, tc.want)
+		}
+	}
+}
+
+func TestSetCategoriesIncidentFormSkipsCategoriesThatCannotBeShown(t *testing.T) {
+	db := newTestDB(t)
+	ready, _ := seedCategory(t, db, "PRIORITY", "HIGH")
+	inactive, _ := seedCategory(t, db, "SEVERITY", "MAJOR")
+	untranslated, _ := seedCategory(t, db, "IMPACT", "WIDE")
+	for _, update := range []struct {
+		category *models.LookupCategory
+		columns  map[string]interface{}
+	}{
+		{ready, map[string]interface{}{"name_ar": "الأولوية"}},
+		{inactive, map[string]interface{}{"name_ar": "الخطورة", "is_active": false}},
+	} {
+		if err := db.Model(update.category).Updates(update.columns).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Patch("/categories/incident-form", h.SetCategoriesIncidentForm)
+	})
+	unknown := uuid.New()
+	ids := fmt.Sprintf(`"%s","%s","%s","%s"`, ready.ID, inactive.ID, untranslated.ID, unknown)
+	onForm := func() []string {
+		t.Helper()
+		var codes []string
+		if err := db.Model(&models.LookupCategory{}).Where("add_to_incident_form = ?", true).Order("code").Pluck("code", &codes).Error; err != nil {
+			t.Fatal(err)
+		}
+		return codes
+	}
+
+	var result models.LookupCategoriesIncidentFormResult
+	if resp := sendJSON(t, app, fiber.MethodPatch, "/categories/incident-form", `{"ids":[`+ids+`],"add_to_incident_form":true}`, &result); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("add = %d, want 200", resp.StatusCode)
+	}
+	if fmt.Sprint(result.Updated) != fmt.Sprint([]uuid.UUID{ready.ID}) || fmt.Sprint(result.NotFound) != fmt.Sprint([]uuid.UUID{unknown}) {
+		t.Errorf("updated %v not found %v, want only PRIORITY updated and the unknown id reported", result.Updated, result.NotFound)
+	}
+	var skipped []string
+	for _, skip := range result.Skipped {
+		skipped = append(skipped, skip.Code+": "+skip.Reason)
+	}
+	want := "[SEVERITY: category is inactive IMPACT: an Arabic name is required on the incident form]"
+	if fmt.Sprint(skipped) != want {
+		t.Errorf("skipped = %v, want %s", skipped, want)
+	}
+	if got := onForm(); fmt.Sprint(got) != "[PRIORITY]" {
+		t.Errorf("on the form = %v, want [PRIORITY]", got)
+	}
+
+	if resp := sendJSON(t, app, fiber.MethodPatch, "/categories/incident-form", `{"ids":[`+ids+`],"add_to_incident_form":false}`, &result); resp.StatusCode != fiber.StatusOK || len(result.Skipped) != 0 {
+		t.Fatalf("remove = %d skipped %v, want 200 with nothing skipped", resp.StatusCode, result.Skipped)
+	}
+	if got := onForm(); len(got) != 0 {
+		t.Errorf("on the form after removing = %v, want none", got)
+	}
+}
+
+func TestIncidentFormPreviewMatchesThePublicForm(t *testing.T) {
+	db := newTestDB(t)
+	category, values := seedCategory(t, db, "PRIORITY", "HIGH", "LOW", "RETIRED")
+	if err := db.Model(category).Update("name_ar", "الأولوية").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&values[2]).Update("is_active", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Get("/categories/:category_id/incident-form-preview", h.PreviewIncidentFormCategory)
+		app.Get("/public/incident-form/lookups", h.GetIncidentFormLookups)
+	})
+
+	var preview json.RawMessage
+	if resp := sendJSON(t, app, fiber.MethodGet, "/categories/"+category.ID.String()+"/incident-form-preview", "", &preview); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("preview = %d, want 200", resp.StatusCode)
+	}
+	var form []json.RawMessage
+	sendJSON(t, app, fiber.MethodGet, "/public/incident-form/lookups", "", &form)
+	if len(form) != 0 {
+		t.Fatalf("public form before flagging = %s, want the category left off", form)
+	}
+
+	if err := db.Model(category).Update("add_to_incident_form", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	sendJSON(t, app, fiber.MethodGet, "/public/incident-form/lookups", "", &form)
+	if len(form) != 1 || !bytes.Equal(form[0], preview) {
+		t.Errorf("public form = %s, want exactly the preview %s", form, preview)
+	}
+	var shown models.LookupIncidentFormCategory
+	if err := json.Unmarshal(preview, &shown); err != nil {
+		t.Fatal(err)
+	}
+	if len(shown.Values) != 2 {
+		t.Errorf("preview values = %+v, want the 2 active ones", shown.Values)
+	}
+}
+
+func TestImportCategoryTranslationsUpdatesOnlyMatchingCodes(t *testing.T) {
+	db := newTestDB(t)
+	category, values := seedCategory(t, db, "PRIORITY", "HIGH", "LOW")
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Post("/categories/:category_id/translations/import", h.ImportCategoryTranslations)
+	})
+	path := "/categories/" + category.ID.String() + "/translations/import"
+	stored := func(id uuid.UUID) models.LookupValue {
+		t.Helper()
+		var v models.LookupValue
+		if err := db.First(&v, "id = ?", id).Error; err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	var failed struct {
+		Errors []models.LookupImportRowError `json:"errors"`
+	}
+	if resp := uploadCSV(t, app, path, "code,name_ar\nhigh,عالي\nlow,\n", &failed); resp.StatusCode != fiber.StatusBadRequest || len(failed.Errors) != 1 {
+		t.Fatalf("file with a row missing name_ar = %d %+v, want 400 naming the row", resp.StatusCode, failed.Errors)
+	}
+	if v := stored(values[0].ID); v.NameAr != "" {
+		t.Errorf("HIGH name_ar = %q after a rejected file, want nothing saved", v.NameAr)
+	}
+
+	var result models.LookupTranslationImportResult
+	if resp := uploadCSV(t, app, path, "description_ar,code,name_ar\nيحتاج رداً اليوم,high,عالي\n,urgent,عاجل\n", &result); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("import = %d, want 200", resp.StatusCode)
+	}
+	if result.Updated != 1 || fmt.Sprint(result.Unmatched) != "[URGENT]" {
+		t.Errorf("result = %+v, want HIGH updated and URGENT unmatched", result)
+	}
+	var translation models.LookupValueTranslation
+	if err := db.First(&translation, "value_id = ? AND locale = ?", values[0].ID, "ar").Error; err != nil {
+		t.Fatalf("read the Arabic translation of HIGH: %v", err)
+	}
+	if v := stored(values[0].ID); v.NameAr != "عالي" || translation.Name != "عالي" || translation.Description != "يحتاج رداً اليوم" {
+		t.Errorf("HIGH name_ar=%q, translation %q/%q, want the imported Arabic fields", v.NameAr, translation.Name, translation.Description)
+	}
+	if v := stored(values[1].ID); v.NameAr != "" {
+		t.Errorf("LOW name_ar = %q, want a value missing from the file left alone", v.NameAr)
+	}
+	var count int64
+	db.Model(&models.LookupValue{}).Count(&count)
+	if count != 2 {
+		t.Errorf("%d values, want the unmatched code not created", count)
+	}
+}
+
+func TestLookupTreeNestsActiveCategoriesAndValuesByGroup(t *testing.T) {
+	db := newTestDB(t)
+	seedCategory(t, db, "HR_LEAVE", "ANNUAL", "SICK")
+	_, values := seedCategory(t, db, "HR_GRADE", "G1", "G2")
+	if err := db.Model(&values[1]).Update("is_active", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	retired, _ := seedCategory(t, db, "IT_LEGACY", "MAINFRAME")
+	if err := db.Model(retired).Update("is_active", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	seedCategory(t, db, "PRIORITY", "HIGH")
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Get("/public/lookups/tree", h.GetLookupTree)
+	})
+
+	var tree []models.LookupCategoryTreeGroup
+	if resp := sendJSON(t, app, fiber.MethodGet, "/public/lookups/tree", "", &tree); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("tree = %d, want 200", resp.StatusCode)
+	}
+	var got []string
+	for _, group := range tree {
+		var categories []string
+		for _, category := range group.Categories {
+			codes := make([]string, len(category.Values))
+			for i, v := range category.Values {
+				codes[i] = v.Code
+			}
+			categories = append(categories, category.Code+"("+strings.Join(codes, ",")+")")
+		}
+		sort.Strings(categories)
+		got = append(got, group.Prefix+": "+strings.Join(categories, " "))
+	}
+	want := "[HR: HR_GRADE(G1) HR_LEAVE(ANNUAL,SICK) " + models.LookupUngroupedPrefix + ": PRIORITY(HIGH)]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("tree = %v, want %s", got, want)
+	}
+}
+
+func TestPurgeDeletedNeedsAPositiveWindow(t *testing.T) {
+	db := newTestDB(t)
+	_, values := seedCategory(t, db, "PRIORITY", "HIGH", "LOW")
+	if err := db.Model(&values[0]).Update("deleted_at", time.Now().Add(-40*24*time.Hour)).Error; err != nil {
+		t.Fatal(err)
+	}
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Post("/purge", h.PurgeDeleted)
+	})
+
+	for _, query := range []string{"", "?older_than=0d", "?older_than=soon"} {
+		if resp := sendJSON(t, app, fiber.MethodPost, "/purge"+query, "", nil); resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("POST /purge%s = %d, want 400", query, resp.StatusCode)
+		}
+	}
+
+	var result models.LookupPurgeResult
+	if resp := sendJSON(t, app, fiber.MethodPost, "/purge?older_than=30d", "", &result); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("POST /purge?older_than=30d = %d, want 200", resp.StatusCode)
+	}
+	if result.Values != 1 || result.Categories != 0 {
+		t.Errorf("purged %d values and %d categories, want 1 and 0", result.Values, result.Categories)
+	}
+}
+
+func TestEmptyUpdatesAreRejectedWithoutAWrite(t *testing.T) {
+	db := newTestDB(t)
+	category, values := seedCategory(t, db, "PRIORITY", "HIGH")
+	lastWeek := time.Now().Add(-7 * 24 * time.Hour).UTC().Truncate(time.Second)
+	for _, model := range []interface{}{category, &values[0]} {
+		if err := db.Model(model).UpdateColumn("updated_at", lastWeek).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Put("/categories/:category_id", h.UpdateCategory)
+		app.Put("/values/:value_id", h.UpdateValue)
+		app.Patch("/values/:value_id", h.PatchValue)
+	})
+
+	categoryPath, valuePath := "/categories/"+category.ID.String(), "/values/"+values[0].ID.String()
+	for _, tc := range []struct{ method, path, body string }{
+		{fiber.MethodPut, categoryPath, ""},
+		{fiber.MethodPut, categoryPath, " \n\t"},
+		{fiber.MethodPut, categoryPath, "{}"},
+		{fiber.MethodPut, valuePath, ""},
+		{fiber.MethodPut, valuePath, " \n\t"},
+		{fiber.MethodPut, valuePath, "{}"},
+		{fiber.MethodPatch, valuePath, "{}"},
+	} {
+		resp := sendJSON(t, app, tc.method, tc.path, tc.body, nil)
+		var failure utils.Response
+		if err := json.NewDecoder(resp.Body).Decode(&failure); err != nil {
+			t.Fatalf("decode %s %s: %v", tc.method, tc.path, err)
+		}
+		if resp.StatusCode != fiber.StatusBadRequest || failure.Error != "No fields to update" {
+			t.Errorf("%s %s %q = %d %q, want 400 No fields to update", tc.method, tc.path, tc.body, resp.StatusCode, failure.Error)
+		}
+	}
+
+	var stored models.LookupCategory
+	if err := db.Preload("Values").First(&stored, "id = ?", category.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !stored.UpdatedAt.Equal(lastWeek) || !stored.Values[0].UpdatedAt.Equal(lastWeek) {
+		t.Errorf("updated_at = %s and %s, want both left at %s", stored.UpdatedAt, stored.Values[0].UpdatedAt, lastWeek)
+	}
+}
+
+func TestValueColorsMustComeFromTheCategoryPalette(t *testing.T) {
+	db := newTestDB(t)
+	severity, values := seedCategory(t, db, "SEVERITY", "MAJOR")
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Put("/categories/:category_id", h.UpdateCategory)
+		app.Post("/categories/:category_id/values", h.CreateValue)
+		app.Put("/values/:value_id", h.UpdateValue)
+	})
+	categoryPath := "/categories/" + severity.ID.String()
+	valuePath := "/values/" + values[0].ID.String()
+
+	var updated models.LookupCategoryResponse
+	if resp := sendJSON(t, app, fiber.MethodPut, categoryPath, `{"allowed_colors":[" #ff0000","#FFBF00","#ff0000","#00ff00"]}`, &updated); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("set palette = %d, want 200", resp.StatusCode)
+	}
+	if fmt.Sprint(updated.AllowedColors) != "[#FF0000 #FFBF00 #00FF00]" {
+		t.Errorf("allowed_colors = %v, want normalized without repeats", updated.AllowedColors)
+	}
+
+	createValueWarnings(t, app, severity.ID, `{"code":"CRITICAL","name":"Critical","color":"#ff0000"}`)
+	createValueWarnings(t, app, severity.ID, `{"code":"MINOR","name":"Minor"}`)
+	if resp := sendJSON(t, app, fiber.MethodPut, valuePath, `{"color":"#00FF00"}`, nil); resp.StatusCode != fiber.StatusOK {
+		t.Errorf("update to an allowed color = %d, want 200", resp.StatusCode)
+	}
+
+	rejected := func(method, path, body string) {
+		t.Helper()
+		resp := sendJSON(t, app, method, path, body, nil)
+		var failure utils.ValidationErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&failure); err != nil {
+			t.Fatalf("decode %s %s: %v", method, path, err)
+		}
+		want := utils.ValidationError{Field: "color", Message: "color must be one of: #FF0000 #FFBF00 #00FF00"}
+		if resp.StatusCode != fiber.StatusBadRequest || len(failure.Details) != 1 || failure.Details[0] != want {
+			t.Errorf("%s %s %s = %d %+v, want 400 %+v", method, path, body, resp.StatusCode, failure.Details, want)
+		}
+	}
+	rejected(fiber.MethodPost, categoryPath+"/values", `{"code":"INFO","name":"Info","color":"#0000FF"}`)
+	rejected(fiber.MethodPut, valuePath, `{"color":"#0000FF"}`)
+
+	if resp := sendJSON(t, app, fiber.MethodPut, categoryPath, `{"allowed_colors":[]}`, nil); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("clear palette = %d, want 200", resp.StatusCode)
+	}
+	if resp := sendJSON(t, app, fiber.MethodPut, valuePath, `{"color":"#0000FF"}`, nil); resp.StatusCode != fiber.StatusOK {
+		t.Errorf("update to any color once the palette is cleared = %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestTransferDefaultMovesTheDefaultToTheTargetCategory(t *testing.T) {
+	db := newTestDB(t)
+	source, sourceValues := seedCategory(t, db, "PRIORITY", "HIGH", "LOW")
+	target, targetValues := seedCategory(t, db, "SEVERITY", "MAJOR", "MINOR", "INFO")
+	for _, v := range []*models.LookupValue{&sourceValues[0], &targetValues[1]} {
+		if err := db.Model(v).Update("is_default", true).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Model(&targetValues[2]).Update("is_active", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Post("/categories/:category_id/transfer-default", h.TransferDefault)
+	})
+	path := "/categories/" + source.ID.String() + "/transfer-default"
+	transfer := func(toCategoryID uuid.UUID, valueCode string) string {
+		return fmt.Sprintf(`{"to_category_id":%q,"value_code":%q}`, toCategoryID, valueCode)
+	}
+
+	for _, tc := range []struct {
+		name, body string
+		want       int
+	}{
+		{"same category", transfer(source.ID, "LOW"), fiber.StatusBadRequest},
+		{"unknown target", transfer(uuid.New(), "MAJOR"), fiber.StatusNotFound},
+		{"unknown code", transfer(target.ID, "NONE"), fiber.StatusNotFound},
+		{"inactive value", transfer(target.ID, "INFO"), fiber.StatusUnprocessableEntity},
+	} {
+		if resp := sendJSON(t, app, fiber.MethodPost, path, tc.body, nil); resp.StatusCode != tc.want {
+			t.Errorf("%s = %d, want %d", tc.name, resp.StatusCode, tc.want)
+		}
+	}
+	defaults := func(categoryID uuid.UUID) []string {
+		t.Helper()
+		var codes []string
+		if err := db.Model(&models.LookupValue{}).Where("category_id = ? AND is_default", categoryID).Order("code").Pluck("code", &codes).Error; err != nil {
+			t.Fatal(err)
+		}
+		return codes
+	}
+	if got := fmt.Sprint(defaults(source.ID), defaults(target.ID)); got != "[HIGH] [MINOR]" {
+		t.Fatalf("defaults after rejected transfers = %s, want them untouched", got)
+	}
+
+	var result models.LookupDefaultTransferResponse
+	if resp := sendJSON(t, app, fiber.MethodPost, path, transfer(target.ID, "major"), &result); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("transfer = %d, want 200", resp.StatusCode)
+	}
+	if result.SourceCategoryID != source.ID || result.Cleared != 1 || result.Default.ID != targetValues[0].ID || !result.Default.IsDefault {
+		t.Errorf("result = %+v, want one default cleared in %s and MAJOR the new default", result, source.ID)
+	}
+	if got := fmt.Sprint(defaults(source.ID), defaults(target.ID)); got != "[] [MAJOR]" {
+		t.Errorf("defaults = %s, want none in the source and MAJOR in the target", got)
+	}
+}
+
+func TestExportsResolveEffectiveColorsOnRequest(t *testing.T) {
+	db := newTestDB(t)
+	category, values := seedCategory(t, db, "PRIORITY", "HIGH", "LOW")
+	if err := db.Model(category).Update("default_color", "#888888").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&values[0]).Update("color", "#FF0000").Error; err != nil {
+		t.Fatal(err)
+	}
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Get("/categories/:category_id/export.csv", h.ExportCategoryCSV)
+		app.Get("/export.json", h.ExportJSON)
+	})
+	get := func(path string) io.Reader {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil), -1)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("GET %s = %d, want 200", path, resp.StatusCode)
+		}
+		return resp.Body
+	}
+
+	csvPath := "/categories/" + category.ID.String() + "/export.csv"
+	for query, want := range map[string]string{
+		"":                "[code color] [HIGH #FF0000] [LOW ]",
+		"?effective=true": "[code color effective_color] [HIGH #FF0000 #FF0000] [LOW  #888888]",
+	} {
+		records, err := csv.NewReader(get(csvPath + query)).ReadAll()
+		if err != nil {
+			t.Fatalf("read %s%s: %v", csvPath, query, err)
+		}
+		var got []string
+		for _, record := range records {
+			got = append(got, fmt.Sprint(append([]string{record[0], record[5]}, record[len(lookupValueCSVHeader):]...)))
+		}
+		if strings.Join(got, " ") != want {
+			t.Errorf("GET %s%s code and color columns = %s, want %s", csvPath, query, strings.Join(got, " "), want)
+		}
+	}
+
+	for query, want := range map[string]string{"": "HIGH= LOW=", "?effective=true": "HIGH=#FF0000 LOW=#888888"} {
+		var export models.LookupExport
+		if err := json.NewDecoder(get("/export.json" + query)).Decode(&export); err != nil {
+			t.Fatalf("decode /export.json%s: %v", query, err)
+		}
+		var got []string
+		for _, v := range export.Categories[0].Values {
+			got = append(got, v.Code+"="+v.EffectiveColor)
+		}
+		if strings.Join(got, " ") != want {
+			t.Errorf("GET /export.json%s effective colors = %s, want %s", query, strings.Join(got, " "), want)
+		}
+	}
+
+	var loaded models.LookupCategory
+	if err := db.Preload("Values", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order") }).First(&loaded, "id = ?", category.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	resp := models.ToLookupCategoryResponse(&loaded)
+	if got := resp.Values[0].EffectiveColor + " " + resp.Values[1].EffectiveColor; got != "#FF0000 #888888" {
+		t.Errorf("response effective colors = %s, want the value's own then the category default", got)
+	}
+}
+
+func TestValuesByCodePaginateOnlyWithALimit(t *testing.T) {
+	db := newTestDB(t)
+	_, values := seedCategory(t, db, "COUNTRY", "AE", "BH", "KW", "OM", "QA", "SA")
+	if err := db.Model(&values[1]).Updates(map[string]interface{}{"is_active": false, "status": models.LookupValueStatusArchived}).Error; err != nil {
+		t.Fatal(err)
+	}
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Get("/lookups/:code", h.GetValuesByCategoryCode)
+	})
+	get := func(path string) (utils.PaginatedResponse, string) {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil))
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("GET %s = %d, want 200", path, resp.StatusCode)
+		}
+		var body struct {
+			utils.PaginatedResponse
+			Data []models.LookupValueResponse `json:"data"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("decode %s: %v", path, err)
+		}
+		codes := make([]string, len(body.Data))
+		for i, v := range body.Data {
+			codes[i] = v.Code
+		}
+		return body.PaginatedResponse, strings.Join(codes, ",")
+	}
+
+	all, codes := get("/lookups/COUNTRY")
+	if codes != "AE,KW,OM,QA,SA" || all.TotalItems != 0 || all.Limit != 0 {
+		t.Errorf("without a limit = %s total %d limit %d, want every active value as a flat list", codes, all.TotalItems, all.Limit)
+	}
+
+	for _, tc := range []struct {
+		query, codes string
+		totalPages   int
+	}{
+		{"?limit=2", "AE,KW", 3},
+		{"?limit=2&page=3", "SA", 3},
+		{"?limit=2&page=4", "", 3},
+		{"?limit=10", "AE,KW,OM,QA,SA", 1},
+	} {
+		page, codes := get("/lookups/COUNTRY" + tc.query)
+		if codes != tc.codes || page.TotalItems != 5 || page.TotalPages != tc.totalPages || page.Limit == 0 {
+			t.Errorf("GET %s = %s total %d pages %d limit %d, want %s of 5 active values in %d pages",
+				tc.query, codes, page.TotalItems, page.TotalPages, page.Limit, tc.codes, tc.totalPages)
+		}
+	}
+}
+
+func TestValueMetadataMustBeASmallJSONObject(t *testing.T) {
+	db := newTestDB(t)
+	category, _ := seedCategory(t, db, "PRIORITY")
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Post("/categories/:category_id/values", h.CreateValue)
+		app.Get("/categories/:category_id/values/by-metadata", h.ListValuesByMetadata)
+		app.Get("/values/:value_id", h.GetValueByID)
+		app.Put("/values/:value_id", h.UpdateValue)
+		app.Patch("/values/:value_id", h.PatchValue)
+	})
+	categoryPath := "/categories/" + category.ID.String()
+
+	var created models.LookupValueResponse
+	if resp := sendJSON(t, app, fiber.MethodPost, categoryPath+"/values", `{"code":"HIGH","name":"High","metadata":{"external_id":"P1","weight":5}}`, &created); resp.StatusCode != fiber.StatusCreated {
+		t.Fatalf("create with metadata = %d, want 201", resp.StatusCode)
+	}
+	valuePath := "/values/" + created.ID.String()
+	metadataOf := func() string {
+		t.Helper()
+		var got models.LookupValueResponse
+		if resp := sendJSON(t, app, fiber.MethodGet, valuePath, "", &got); resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("GET %s = %d, want 200", valuePath, resp.StatusCode)
+		}
+		return fmt.Sprint(got.Metadata)
+	}
+	if got := metadataOf(); got != "map[external_id:P1 weight:5]" {
+		t.Errorf("metadata after create = %s, want the object sent", got)
+	}
+
+	oversized := fmt.Sprintf(`{"metadata":{"notes":%q}}`, strings.Repeat("x", models.MaxLookupMetadataBytes))
+	for _, tc := range []struct{ method, path, body string }{
+		{fiber.MethodPost, categoryPath + "/values", `{"code":"LOW","name":"Low","metadata":["P2"]}`},
+		{fiber.MethodPost, categoryPath + "/values", `{"code":"LOW","name":"Low","metadata":"P2"}`},
+		{fiber.MethodPut, valuePath, `{"metadata":42}`},
+		{fiber.MethodPut, valuePath, oversized},
+		{fiber.MethodPatch, valuePath, oversized},
+	} {
+		resp := sendJSON(t, app, tc.method, tc.path, tc.body, nil)
+		var failure utils.ValidationErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&failure); err != nil {
+			t.Fatalf("decode %s %s: %v", tc.method, tc.path, err)
+		}
+		if resp.StatusCode != fiber.StatusBadRequest || len(failure.Details) != 1 || failure.Details[0].Field != "metadata" {
+			t.Errorf("%s %s %.60s = %d %+v, want 400 on the metadata field", tc.method, tc.path, tc.body, resp.StatusCode, failure.Details)
+		}
+	}
+	if got := metadataOf(); got != "map[external_id:P1 weight:5]" {
+		t.Errorf("metadata after rejected writes = %s, want it unchanged", got)
+	}
+
+	for _, tc := range []struct{ method, body, want string }{
+		{fiber.MethodPatch, `{"metadata":{"weight":null,"tier":"gold"}}`, "map[external_id:P1 tier:gold]"},
+		{fiber.MethodPut, `{"metadata":{"external_id":"P2"}}`, "map[external_id:P2]"},
+		{fiber.MethodPut, `{"metadata":null}`, "map[]"},
+	} {
+		if resp := sendJSON(t, app, tc.method, valuePath, tc.body, nil); resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("%s %s = %d, want 200", tc.method, tc.body, resp.StatusCode)
+		}
+		if got := metadataOf(); got != tc.want {
+			t.Errorf("metadata after %s %s = %s, want %s", tc.method, tc.body, got, tc.want)
+		}
+	}
+
+	if resp := sendJSON(t, app, fiber.MethodGet, categoryPath+"/values/by-metadata?value=P1", "", nil); resp.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("by-metadata without a key = %d, want 400", resp.StatusCode)
+	}
+	sendJSON(t, app, fiber.MethodPut, valuePath, `{"metadata":{"external_id":"P1"}}`, nil)
+	var found []models.LookupValueResponse
+	if resp := sendJSON(t, app, fiber.MethodGet, categoryPath+"/values/by-metadata?key=external_id&value=P1", "", &found); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("by-metadata = %d, want 200", resp.StatusCode)
+	}
+	if len(found) != 1 || found[0].ID != created.ID {
+		t.Errorf("by-metadata = %+v, want HIGH", found)
+	}
+}
+
+func TestReactivatingACategoryRestoresItsIncidentFormIntent(t *testing.T) {
+	db := newTestDB(t)
+	category, _ := seedCategory(t, db, "PRIORITY", "HIGH")
+	if err := db.Model(category).Update("name_ar", "الأولوية").Error; err != nil {
+		t.Fatal(err)
+	}
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Put("/categories/:category_id", h.UpdateCategory)
+		app.Get("/public/incident-form/lookups", h.GetIncidentFormLookups)
+	})
+	path := "/categories/" + category.ID.String()
+
+	for _, tc := range []struct {
+		body           string
+		onForm, intent bool
+		public         int
+	}{
+		{`{"add_to_incident_form":true}`, true, true, 1},
+		{`{"is_active":false}`, false, true, 0},
+		{`{"is_active":true}`, true, true, 1},
+		{`{"is_active":false}`, false, true, 0},
+		{`{"add_to_incident_form":false}`, false, false, 0},
+		{`{"is_active":true}`, false, false, 0},
+	} {
+		var updated models.LookupCategoryResponse
+		if resp := sendJSON(t, app, fiber.MethodPut, path, tc.body, &updated); resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("PUT %s = %d, want 200", tc.body, resp.StatusCode)
+		}
+		if updated.AddToIncidentForm != tc.onForm || updated.IncidentFormIntent != tc.intent {
+			t.Errorf("after %s add_to_incident_form = %t intent = %t, want %t and %t",
+				tc.body, updated.AddToIncidentForm, updated.IncidentFormIntent, tc.onForm, tc.intent)
+		}
+		var form []json.RawMessage
+		sendJSON(t, app, fiber.MethodGet, "/public/incident-form/lookups", "", &form)
+		if len(form) != tc.public {
+			t.Errorf("after %s the public form has %d categories, want %d", tc.body, len(form), tc.public)
+		}
+	}
+}
+
+func TestApplyCategoryChangesSavesAMixedChangeSetAtOnce(t *testing.T) {
+	db := newTestDB(t)
+	category, values := seedCategory(t, db, "PRIORITY", "HIGH", "MEDIUM", "LOW", "OLD")
+	if err := db.Model(&values[0]).Update("is_default", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Patch("/categories/:category_id/apply", h.ApplyCategoryChanges)
+	})
+	path := "/categories/" + category.ID.String() + "/apply"
+
+	body := fmt.Sprintf(`{
+		"category": {"name": "Priority level"},
+		"create": [{"code": "CRITICAL", "name": "Critical", "is_default": true}],
+		"update": [{"id": %q, "name": "Normal"}],
+		"delete": [%q],
+		"order": ["CRITICAL", "HIGH", "MEDIUM", "LOW"]
+	}`, values[1].ID, values[3].ID)
+	var applied models.LookupCategoryResponse
+	if resp := sendJSON(t, app, fiber.MethodPatch, path, body, &applied); resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("apply = %d, want 200", resp.StatusCode)
+	}
+	if applied.Name != "Priority level" || len(applied.Values) != 4 {
+		t.Errorf("applied category %q with %d values, want it renamed with 4 values", applied.Name, len(applied.Values))
+	}
+
+	var stored []models.LookupValue
+	if err := db.Where("category_id = ?", category.ID).Order("sort_order").Find(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, v := range stored {
+		got = append(got, fmt.Sprintf("%s:%s:%t", v.Code, v.Name, v.IsDefault))
+	}
+	if want := "CRITICAL:Critical:true HIGH:HIGH:false MEDIUM:Normal:false LOW:LOW:false"; strings.Join(got, " ") != want {
+		t.Errorf("values = %s, want %s", strings.Join(got, " "), want)
+	}
+}
+
+func TestApplyCategoryChangesRollsBackWhenAPartFails(t *testing.T) {
+	db := newTestDB(t)
+	category, values := seedCategory(t, db, "PRIORITY", "HIGH", "LOW")
+	app := newAdminTestApp(db, config.LookupConfig{}, func(app *fiber.App, h *LookupHandler) {
+		app.Patch("/categories/:category_id/apply", h.ApplyCategoryChanges)
+	})
+	path := "/categories/" + category.ID.String() + "/apply"
+	unchanged := func(when string) {
+		t.Helper()
+		var stored models.LookupCategory
+		if err := db.Preload("Values", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order") }).First(&stored, "id = ?", category.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, v := range stored.Values {
+			got = append(got, v.Code+":"+v.Name)
+		}
+		if stored.Name != "PRIORITY" || strings.Join(got, " ") != "HIGH:HIGH LOW:LOW" {
+			t.Errorf("%s: category %q with values %v, want nothing changed", when, stored.Name, got)
+		}
+	}
+
+	// A part that fails the checks keeps all of it from being written
+	invalid := fmt.Sprintf(`{"category":{"name":"Renamed"},"update":[{"id":%q,"code":"HIGH"}],"delete":[%q]}`, values[1].ID, uuid.New())
+	resp := sendJSON(t, app, fiber.MethodPatch, path, invalid, nil)
+	var failure utils.ValidationErrorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&failure); err != nil {
+		t.Fatalf("decode apply: %v", err)
+	}
+	var fields []string
+	for _, detail := range failure.Details {
+		fields = append(fields, detail.Field)
+	}
+	sort.Strings(fields)
+	if resp.StatusCode != fiber.StatusBadRequest || strings.Join(fields, ",") != "delete[0],update[0].code" {
+		t.Errorf("invalid change set = %d %v, want 400 on delete[0] and update[0].code", resp.StatusCode, fields)
+	}
+	unchanged("after a rejected change set")
+
+	// A write failing midway rolls back the writes before it
+	if err := db.Callback().Create().Before("gorm:create").Register("test:fail_value_creates", func(tx *gorm.DB) {
+		if tx.Statement.Table == "lookup_values" {
+			tx.AddError(errors.New("disk full"))
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	failing := fmt.Sprintf(`{"category":{"name":"Renamed"},"update":[{"id":%q,"name":"Lowest"}],"delete":[%q],"create":[{"code":"NEW","name":"New"}]}`, values[1].ID, values[0].ID)
+	if resp := sendJSON(t, app, fiber.MethodPatch, path, failing, nil); resp.StatusCode != fiber.StatusInternalServerError {
+		t.Errorf("change set whose create fails = %d, want 500", resp.StatusCode)
+	}
+	unchanged("after a failed write")
+}
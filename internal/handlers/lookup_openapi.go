@@ -0,0 +1,452 @@
+package handlers
+
+import (
+	"github.com/automax/backend/internal/models"
+	"github.com/automax/backend/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	lookupAdminPath  = "/api/v1/admin/lookups"
+	lookupSharedPath = "/api/v1/public/shared"
+	lookupTag        = "Lookups"
+	lookupAdminTag   = "Lookups (admin)"
+)
+
+// listQuery documents the parameters read by utils.ParseListOptions
+var listQuery = map[string]string{
+	"page":   "Page number, starting at 1",
+	"limit":  "Page size, 1-100 (default 20)",
+	"search": "Case-insensitive match on code, name and Arabic name",
+	"active": "Only active (true) or inactive (false) rows",
+	"sort":   "Sort field",
+	"order":  "Sort direction, asc or desc",
+	"fields": fieldsQuery,
+}
+
+const fieldsQuery = "Comma-separated response fields to return, e.g. id,code,name"
+
+const viewQuery = "Response shape: full (default) or compact, the minimal id, code, name, color and is_default"
+
+// listViewQuery is listQuery plus view, for the lists with a compact shape
+var listViewQuery = withQuery(listQuery, map[string]string{"view": viewQuery})
+
+// valueListQuery documents the admin value list, which can skip its total
+var valueListQuery = withQuery(listViewQuery, map[string]string{
+	"include_total": "Count all matches (default true); false returns has_more instead, for large categories",
+	"tag":           "Only values carrying the tag with this code",
+})
+
+// withQuery returns the parameters of base and extra in a new map
+func withQuery(base, extra map[string]string) map[string]string {
+	query := make(map[string]string, len(base)+len(extra))
+	for name, doc := range base {
+		query[name] = doc
+	}
+	for name, doc := range extra {
+		query[name] = doc
+	}
+	return query
+}
+
+const returnQuery = "full (default) for the whole object, or changed for only the changed fields plus id and updated_at"
+
+const shiftQuery = "Move values at or after the requested sort_order down one slot instead of sharing it (true/false)"
+
+// lookupOpenAPIOperations documents the lookup routes, keyed by method and the
+// full path they are registered under in cmd/server/main.go. Schemas are
+// reflected from the model types at request time.
+var lookupOpenAPIOperations = map[string]utils.OpenAPIOperation{
+	"GET /api/v1/lookups": {
+		Summary: "List active categories with their values", Tag: lookupTag,
+		Response: []models.LookupCategoryResponse{},
+		Query:    map[string]string{"fields": fieldsQuery, "view": viewQuery},
+	},
+	"GET /api/v1/lookups/:code": {
+		Summary: "List the values of a category by code, localized from Accept-Language", Tag: lookupTag,
+		Response: []models.LookupValueResponse{},
+		Query: map[string]string{
+			"include_deprecated": "Include deprecated values (true/false)",
+			"as_of":              "RFC 3339 time whose effective values are listed; defaults to now",
+			"fields":             fieldsQuery,
+			"view":               viewQuery,
+			"page":               "Page number, starting at 1; used with limit",
+			"limit":              "Page size, 1-100; when given the values come back in the paginated envelope, otherwise all of them as a flat list",
+		},
+	},
+	"POST /api/v1/admin/purge": {
+		Summary: "Hard-delete the categories and values soft-deleted before the retention window; system categories and values incidents use are kept", Tag: lookupAdminTag,
+		Response: models.LookupPurgeResult{},
+		Query:    map[string]string{"older_than": "Retention window, e.g. 30d or 12h (required)"},
+	},
+	"GET " + lookupAdminPath + "/read-only": {
+		Summary: "Whether lookup maintenance mode is rejecting writes", Tag: lookupAdminTag,
+		Response: models.LookupReadOnlyStatus{},
+	},
+	"PUT " + lookupAdminPath + "/read-only": {
+		Summary: "Turn lookup maintenance mode on or off; while on, lookup writes get 503 with Retry-After", Tag: lookupAdminTag,
+		Request: models.LookupReadOnlyRequest{}, Response: models.LookupReadOnlyStatus{},
+	},
+	"GET /api/v1/public/lookups/tree": {
+		Summary: "Active categories grouped by code prefix with their active values; ungrouped categories come last", Tag: lookupTag,
+		Response: []models.LookupCategoryTreeGroup{},
+	},
+	"GET /api/v1/public/incident-form/lookups": {
+		Summary: "The categories on the incident form with their selectable values", Tag: lookupTag,
+		Response: []models.LookupIncidentFormCategory{},
+	},
+	"GET /api/v1/sync/lookups": {
+		Summary: "Categories and values changed since a cursor, plus the IDs deleted since", Tag: lookupTag,
+		Response: models.LookupSyncResponse{},
+		Query: map[string]string{
+			"cursor": "The next_cursor of the previous sync; omit for a full sync",
+			"since":  "RFC 3339 time, the server_time of the previous sync; superseded by cursor",
+		},
+	},
+	"GET /api/v1/lookups/:code/default": {
+		Summary: "Get the default value of a category, drawn by weight when its values have default weights", Tag: lookupTag,
+		Response: models.LookupDefaultValueResponse{},
+		Query:    map[string]string{"fallback": "Return the first active value when no active default is set (true/false)"},
+	},
+	"GET /api/v1/lookups/:code/resolve/:alias": {
+		Summary: "Resolve a legacy code or alias to its value", Tag: lookupTag,
+		Response: models.LookupValueResponse{},
+	},
+	"POST /api/v1/public/resolve": {
+		Summary: "Resolve category code and value code pairs to value IDs, in input order", Tag: lookupTag,
+		Request: []models.LookupCodePair{}, Response: []models.LookupCodeResolution{},
+	},
+	"GET " + lookupSharedPath + "/:token": {
+		Summary: "Get the value a share token points to", Tag: lookupTag,
+		Response: models.LookupValueResponse{}, Public: true,
+	},
+	"GET /api/v1/schema/lookup": {
+		Summary: "JSON Schemas for the lookup request bodies", Tag: lookupTag,
+		Response: map[string]utils.JSONSchema{},
+	},
+
+	"POST " + lookupAdminPath + "/categories": {
+		Summary: "Create a category", Tag: lookupAdminTag, Status: fiber.StatusCreated,
+		Request: models.LookupCategoryCreateRequest{}, Response: models.LookupCategoryResponse{},
+	},
+	"GET " + lookupAdminPath + "/categories": {
+		Summary: "List categories", Tag: lookupAdminTag,
+		Response: []models.LookupCategoryResponse{}, Paginated: true, Query: listViewQuery,
+	},
+	"GET " + lookupAdminPath + "/templates": {
+		Summary: "Built-in category templates", Tag: lookupAdminTag,
+		Response: []models.LookupCategorySeed{},
+	},
+	"POST " + lookupAdminPath + "/categories/from-template/:template_code": {
+		Summary: "Create a category with its values from a built-in template", Tag: lookupAdminTag, Status: fiber.StatusCreated,
+		Request: models.LookupTemplateInstantiateRequest{}, OptionalBody: true, Response: models.LookupCategoryResponse{},
+	},
+	"GET " + lookupAdminPath + "/recent": {
+		Summary: "Recently changed categories and values", Tag: lookupAdminTag,
+		Response: []models.LookupRecentChange{},
+		Query:    map[string]string{"limit": "Maximum number of changes, 1-100 (default 20)"},
+	},
+	"GET " + lookupAdminPath + "/diagnostics/inactive-defaults": {
+		Summary: "Categories whose default value is inactive, leaving them without a default", Tag: lookupAdminTag,
+		Response: models.LookupInactiveDefaultsReport{},
+	},
+	"GET " + lookupAdminPath + "/diagnostics/missing-defaults": {
+		Summary: "Active categories with active values but no default", Tag: lookupAdminTag,
+		Response: models.LookupMissingDefaultsReport{},
+	},
+	"GET " + lookupAdminPath + "/stats": {
+		Summary: "Category and value totals", Tag: lookupAdminTag,
+		Response: models.LookupStats{},
+	},
+	"GET " + lookupAdminPath + "/export/all.zip": {
+		Summary: "Export every category as CSV files in a zip archive", Tag: lookupAdminTag,
+		ContentType: "application/zip",
+	},
+	"GET " + lookupAdminPath + "/export.json": {
+		Summary: "Export all categories and values as JSON", Tag: lookupAdminTag,
+		ContentType: fiber.MIMEApplicationJSON, Response: models.LookupExport{},
+		Query: map[string]string{"effective": "Add each value's effective color, its own color else the category default (true/false)"},
+	},
+	"POST " + lookupAdminPath + "/import": {
+		Summary: "Import categories and values from an export document", Tag: lookupAdminTag,
+		Request: models.LookupExport{}, Response: models.LookupImportResult{},
+		Query: map[string]string{
+			"dry_run":    "Report the changes without saving them (true/false)",
+			"validation": "strict (default) saves nothing if any row fails; lenient saves the valid rows and reports the rest",
+		},
+	},
+	"PUT " + lookupAdminPath + "/categories/code/:code": {
+		Summary: "Create or update a category by code (201 when created)", Tag: lookupAdminTag,
+		Request: models.LookupCategoryUpsertRequest{}, Response: models.LookupCategoryResponse{},
+	},
+	"GET " + lookupAdminPath + "/categories/groups": {
+		Summary: "Categories grouped by the code prefix before the first underscore", Tag: lookupAdminTag,
+		Response: []models.LookupCategoryGroup{},
+	},
+	"GET " + lookupAdminPath + "/categories/code-suggestions": {
+		Summary: "Codes starting with a prefix and a free code based on it", Tag: lookupAdminTag,
+		Response: models.LookupCodeSuggestions{},
+		Query: map[string]string{
+			"prefix": "Code prefix (required)",
+			"limit":  "Maximum number of matching codes, 1-100 (default 20)",
+		},
+	},
+	"PATCH " + lookupAdminPath + "/categories/active": {
+		Summary: "Activate or deactivate several categories", Tag: lookupAdminTag,
+		Request: models.LookupCategoriesActiveRequest{}, Response: models.LookupCategoriesActiveResult{},
+	},
+	"PATCH " + lookupAdminPath + "/categories/incident-form": {
+		Summary: "Put several categories on the incident form or take them off, skipping those that cannot join", Tag: lookupAdminTag,
+		Request: models.LookupCategoriesIncidentFormRequest{}, Response: models.LookupCategoriesIncidentFormResult{},
+	},
+	"POST " + lookupAdminPath + "/categories/batch": {
+		Summary: "Categories with their values by id, in request order, plus the ids not found", Tag: lookupAdminTag,
+		Request: models.LookupCategoryBatchRequest{}, Response: models.LookupCategoryBatchResponse{},
+	},
+	"GET " + lookupAdminPath + "/categories/:category_id": {
+		Summary: "Get a category", Tag: lookupAdminTag,
+		Response: models.LookupCategoryResponse{},
+	},
+	"PUT " + lookupAdminPath + "/categories/:category_id": {
+		Summary: "Update a category", Tag: lookupAdminTag,
+		Request: models.LookupCategoryUpdateRequest{}, Response: models.LookupCategoryResponse{},
+		Query: map[string]string{"return": returnQuery},
+	},
+	"PATCH " + lookupAdminPath + "/categories/:category_id/apply": {
+		Summary: "Apply a category's field and value changes in one transaction", Tag: lookupAdminTag,
+		Request: models.LookupCategoryApplyRequest{}, Response: models.LookupCategoryResponse{},
+	},
+	"DELETE " + lookupAdminPath + "/categories/:category_id": {
+		Summary: "Delete a category", Tag: lookupAdminTag,
+	},
+	"GET " + lookupAdminPath + "/categories/:category_id/incident-form-preview": {
+		Summary: "A category as GET /public/incident-form/lookups would return it, even before it is on the form", Tag: lookupAdminTag,
+		Response: models.LookupIncidentFormCategory{},
+	},
+	"GET " + lookupAdminPath + "/categories/:category_id/delete-preview": {
+		Summary: "What deleting a category would remove, and how many incidents use its values", Tag: lookupAdminTag,
+		Response: models.LookupCategoryDeletePreview{},
+	},
+	"POST " + lookupAdminPath + "/categories/:category_id/reset": {
+		Summary: "Restore a system category's seeded values", Tag: lookupAdminTag,
+		Response: models.LookupResetResult{},
+		Query:    map[string]string{"strict": "Also remove values added by admins (true/false)"},
+	},
+	"POST " + lookupAdminPath + "/categories/:category_id/touch": {
+		Summary: "Bump updated_at without changing data, invalidating cached copies", Tag: lookupAdminTag,
+		Response: models.LookupCategoryTouchResponse{},
+	},
+	"POST " + lookupAdminPath + "/categories/:category_id/archive": {
+		Summary: "Archive a category, optionally deactivating its active values", Tag: lookupAdminTag,
+		Response: models.LookupArchiveResult{},
+		Query:    map[string]string{"cascade_values": "Also deactivate the category's active values (true/false, default false)"},
+	},
+	"POST " + lookupAdminPath + "/categories/:category_id/unarchive": {
+		Summary: "Unarchive a category", Tag: lookupAdminTag,
+		Response: models.LookupArchiveResult{},
+		Query:    map[string]string{"with_values": "Also reactivate the values deactivated by the archive (true/false)"},
+	},
+	"GET " + lookupAdminPath + "/categories/:category_id/related": {
+		Summary: "List the categories related to a category, without their values", Tag: lookupAdminTag,
+		Response: []models.LookupCategorySummary{},
+	},
+	"POST " + lookupAdminPath + "/categories/:category_id/related": {
+		Summary: "Relate two categories; the link works both ways", Tag: lookupAdminTag, Status: fiber.StatusCreated,
+		Request: models.LookupCategoryLinkRequest{}, Response: []models.LookupCategorySummary{},
+	},
+	"DELETE " + lookupAdminPath + "/categories/:category_id/related/:related_id": {
+		Summary: "Remove the link between two categories", Tag: lookupAdminTag,
+	},
+	"GET " + lookupAdminPath + "/categories/:category_id/export.csv": {
+		Summary: "Export the values of a category as CSV", Tag: lookupAdminTag,
+		ContentType: "text/csv",
+		Query:       map[string]string{"effective": "Add each value's effective color, its own color else the category default (true/false)"},
+	},
+	"POST " + lookupAdminPath + "/categories/:category_id/import.csv": {
+		Summary: "Import the values of a category from a CSV file sent as the multipart \"file\" field", Tag: lookupAdminTag,
+		Response: models.LookupImportResult{},
+		Query: map[string]string{
+			"dry_run":    "Report the changes without saving them (true/false)",
+			"validation": "strict (default) saves nothing if any row fails; lenient saves the valid rows and reports the rest",
+		},
+	},
+	"POST " + lookupAdminPath + "/categories/:category_id/translations/import": {
+		Summary: "Set the Arabic names and descriptions of existing values from a CSV file of code, name_ar and description_ar sent as the multipart \"file\" field", Tag: lookupAdminTag,
+		Response: models.LookupTranslationImportResult{},
+	},
+	"POST " + lookupAdminPath + "/categories/:category_id/values": {
+		Summary: "Create a value", Tag: lookupAdminTag, Status: fiber.StatusCreated,
+		Request: models.LookupValueCreateRequest{}, Response: models.LookupValueResponse{},
+		Query: map[string]string{"shift": shiftQuery},
+	},
+	"GET " + lookupAdminPath + "/categories/:category_id/values": {
+		Summary: "List the values of a category", Tag: lookupAdminTag,
+		Response: []models.LookupValueResponse{}, Paginated: true, Query: valueListQuery,
+	},
+	"GET " + lookupAdminPath + "/categories/:category_id/values/autocomplete": {
+		Summary: "Type-ahead suggestions among a category's active values, prefix matches first", Tag: lookupAdminTag,
+		Response: []models.CompactLookupValueResponse{},
+		Query: map[string]string{
+			"q":     "Text typed so far (required); case, Arabic letter variants and diacritics are ignored",
+			"limit": "Maximum number of suggestions, 1-50 (default 10)",
+		},
+	},
+	"POST " + lookupAdminPath + "/categories/:category_id/values/bulk": {
+		Summary: "Create values in bulk", Tag: lookupAdminTag, Status: fiber.StatusCreated,
+		Request: models.LookupValueBulkRequest{}, Response: models.LookupBulkValuesResponse{},
+		Query: map[string]string{"include_category": "Include the updated category in the response (true/false)"},
+	},
+	"PUT " + lookupAdminPath + "/categories/:category_id/values/bulk": {
+		Summary: "Create or update values in bulk by code", Tag: lookupAdminTag,
+		Request: models.LookupValueBulkRequest{}, Response: models.LookupBulkValuesResponse{},
+		Query: map[string]string{"include_category": "Include the updated category in the response (true/false)"},
+	},
+	"POST " + lookupAdminPath + "/categories/:category_id/values/validate": {
+		Summary: "Validate a proposed value set without saving it", Tag: lookupAdminTag,
+		Request: models.LookupValueBulkRequest{}, Response: models.LookupValueSetValidation{},
+	},
+	"POST " + lookupAdminPath + "/categories/:category_id/values/restore": {
+		Summary: "Restore soft-deleted values of a category, the listed ids or all of them; values whose code is taken again are skipped", Tag: lookupAdminTag,
+		Request: models.LookupValueRestoreRequest{}, Response: models.LookupValueRestoreResult{},
+	},
+	"POST " + lookupAdminPath + "/categories/:category_id/values/move": {
+		Summary: "Move values of a category into another category in one transaction", Tag: lookupAdminTag,
+		Request: models.LookupValueMoveRequest{}, Response: []models.LookupValueResponse{},
+	},
+	"POST " + lookupAdminPath + "/categories/:category_id/values/normalize-order": {
+		Summary: "Renumber the active values of a category 0..n-1 in their current order", Tag: lookupAdminTag,
+		Response: []models.LookupValueResponse{},
+	},
+	"GET " + lookupAdminPath + "/categories/:category_id/values/by-metadata": {
+		Summary: "Values of a category whose metadata holds a value under a key", Tag: lookupAdminTag,
+		Response: []models.LookupValueResponse{},
+		Query: map[string]string{
+			"key":   "Metadata member name (required)",
+			"value": "Member value, compared as text so 42 and true match numbers and booleans",
+		},
+	},
+	"GET " + lookupAdminPath + "/categories/:category_id/values/tree": {
+		Summary: "Active values of a category nested by parent", Tag: lookupAdminTag,
+		Response: []models.LookupValueTreeNode{},
+	},
+	"GET " + lookupAdminPath + "/values/colors": {
+		Summary: "Colors in use on values with their usage counts", Tag: lookupAdminTag,
+		Response: []models.LookupColorUsage{},
+	},
+	"POST " + lookupAdminPath + "/values/recolor": {
+		Summary: "Replace value colors from an old-to-new color map", Tag: lookupAdminTag,
+		Request: map[string]string{}, Response: models.LookupRecolorResult{},
+	},
+	"GET " + lookupAdminPath + "/values/orphans": {
+		Summary: "Values whose category no longer exists", Tag: lookupAdminTag,
+		Response: []models.LookupValueResponse{},
+	},
+	"POST " + lookupAdminPath + "/values/orphans/cleanup": {
+		Summary: "Delete orphaned values or reassign them to a category", Tag: lookupAdminTag,
+		Request: models.LookupOrphanCleanupRequest{}, Response: models.LookupOrphanCleanupResult{},
+	},
+	"GET " + lookupAdminPath + "/values/search": {
+		Summary: "Search values across categories", Tag: lookupAdminTag,
+		Response: []models.LookupValueResponse{}, Paginated: true,
+		Query: map[string]string{
+			"q":        "Search text (required)",
+			"fulltext": "Rank matches with full-text search instead of substring matching (true/false)",
+			"page":     "Page number, starting at 1",
+			"limit":    "Page size, 1-100 (default 20)",
+		},
+	},
+	"GET " + lookupAdminPath + "/values/:value_id": {
+		Summary: "Get a value", Tag: lookupAdminTag,
+		Response: models.LookupValueResponse{},
+		Query:    map[string]string{"include": "Set to \"category\" to embed the parent category"},
+	},
+	"PATCH " + lookupAdminPath + "/values/:value_id": {
+		Summary: "Update a value with a JSON merge patch; null clears optional fields", Tag: lookupAdminTag,
+		Request: models.LookupValuePatch{}, Response: models.LookupValueResponse{},
+		Query: map[string]string{"return": returnQuery, "shift": shiftQuery},
+	},
+	"GET " + lookupAdminPath + "/values/:value_id/share": {
+		Summary: "Create a short-lived share token for a value", Tag: lookupAdminTag,
+		Response: models.LookupValueShareResponse{},
+	},
+	"GET " + lookupAdminPath + "/values/:value_id/ancestors": {
+		Summary: "The parent chain of a value, from the root down to the value", Tag: lookupAdminTag,
+		Response: []models.LookupValueResponse{},
+	},
+	"PUT " + lookupAdminPath + "/values/:value_id": {
+		Summary: "Update a value", Tag: lookupAdminTag,
+		Request: models.LookupValueUpdateRequest{}, Response: models.LookupValueResponse{},
+		Query: map[string]string{"shift": shiftQuery, "return": returnQuery},
+	},
+	"DELETE " + lookupAdminPath + "/values/:value_id": {
+		Summary: "Delete a value", Tag: lookupAdminTag,
+	},
+	"POST " + lookupAdminPath + "/values/:value_id/clone": {
+		Summary: "Copy a value under a new code and name in the same category", Tag: lookupAdminTag, Status: fiber.StatusCreated,
+		Request: models.LookupValueCloneRequest{}, Response: models.LookupValueResponse{},
+	},
+	"POST " + lookupAdminPath + "/values/:value_id/set-default": {
+		Summary: "Make a value the default of its category", Tag: lookupAdminTag,
+		Request: models.LookupSetDefaultRequest{}, OptionalBody: true, Response: models.LookupValueResponse{},
+	},
+	"POST " + lookupAdminPath + "/categories/:category_id/transfer-default": {
+		Summary: "Clear a category's default and make a value of another category that category's default, in one transaction", Tag: lookupAdminTag,
+		Request: models.LookupDefaultTransferRequest{}, Response: models.LookupDefaultTransferResponse{},
+	},
+	"POST " + lookupAdminPath + "/values/defaults": {
+		Summary: "Set the defaults of several categories in one transaction", Tag: lookupAdminTag,
+		Request: []models.LookupDefaultAssignment{}, Response: []models.LookupDefaultResult{},
+	},
+	"GET " + lookupAdminPath + "/values/:value_id/aliases": {
+		Summary: "List the aliases of a value", Tag: lookupAdminTag,
+		Response: []models.LookupValueAliasResponse{},
+	},
+	"POST " + lookupAdminPath + "/values/:value_id/aliases": {
+		Summary: "Add an alias to a value", Tag: lookupAdminTag, Status: fiber.StatusCreated,
+		Request: models.LookupValueAliasCreateRequest{}, Response: models.LookupValueAliasResponse{},
+	},
+	"DELETE " + lookupAdminPath + "/values/:value_id/aliases/:alias_id": {
+		Summary: "Remove an alias", Tag: lookupAdminTag,
+	},
+	"GET " + lookupAdminPath + "/values/:value_id/translations": {
+		Summary: "List the translations of a value", Tag: lookupAdminTag,
+		Response: []models.LookupValueTranslationResponse{},
+	},
+	"PUT " + lookupAdminPath + "/values/:value_id/translations/:locale": {
+		Summary: "Set the translation of a value in a locale", Tag: lookupAdminTag,
+		Request: models.LookupValueTranslationRequest{}, Response: models.LookupValueTranslationResponse{},
+	},
+
+	"POST " + lookupAdminPath + "/tags": {
+		Summary: "Create a tag", Tag: lookupAdminTag, Status: fiber.StatusCreated,
+		Request: models.LookupTagCreateRequest{}, Response: models.LookupTagResponse{},
+	},
+	"GET " + lookupAdminPath + "/tags": {
+		Summary: "List tags", Tag: lookupAdminTag,
+		Response: []models.LookupTagResponse{},
+	},
+	"GET " + lookupAdminPath + "/tags/:tag_code/values": {
+		Summary: "Active values carrying a tag, across categories", Tag: lookupAdminTag,
+		Response: []models.LookupValueResponse{},
+	},
+	"POST " + lookupAdminPath + "/tags/:tag_id/values": {
+		Summary: "Attach values to a tag", Tag: lookupAdminTag,
+		Request: models.LookupTagValuesRequest{}, Response: models.LookupTagValuesResult{},
+	},
+	"DELETE " + lookupAdminPath + "/tags/:tag_id/values/:value_id": {
+		Summary: "Detach a value from a tag", Tag: lookupAdminTag,
+	},
+}
+
+// GetOpenAPI serves an OpenAPI 3 document for the lookup endpoints. Only routes
+// that are actually registered are described, and the schemas come from the
+// model structs, so the contract follows the code.
+func (h *LookupHandler) GetOpenAPI(c *fiber.Ctx) error {
+	doc := utils.GenerateOpenAPI(utils.OpenAPIInfo{
+		Title:       "Automax Lookups API",
+		Version:     "1.0.0",
+		Description: "Lookup categories and values. All responses use the standard envelope: {\"success\", \"message\", \"data\", \"error\"}.",
+	}, c.App().GetRoutes(true), lookupOpenAPIOperations)
+
+	return c.JSON(doc)
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"github.com/automax/backend/internal/middleware"
+	"github.com/automax/backend/internal/models"
+	"github.com/automax/backend/pkg/utils"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+// MaintenanceHandler reports and flips the lookup read-only switch
+type MaintenanceHandler struct {
+	lookupReadOnly *middleware.ReadOnlySwitch
+	validator      *validator.Validate
+}
+
+func NewMaintenanceHandler(lookupReadOnly *middleware.ReadOnlySwitch, validator *validator.Validate) *MaintenanceHandler {
+	return &MaintenanceHandler{
+		lookupReadOnly: lookupReadOnly,
+		validator:      validator,
+	}
+}
+
+// GetLookupReadOnly reports whether lookup writes are being rejected
+func (h *MaintenanceHandler) GetLookupReadOnly(c *fiber.Ctx) error {
+	return utils.SuccessResponse(c, fiber.StatusOK, "Lookup read-only mode retrieved", models.LookupReadOnlyStatus{
+		ReadOnly: h.lookupReadOnly.Enabled(),
+	})
+}
+
+// SetLookupReadOnly turns lookup read-only mode on or off. The setting lives
+// in memory, so it applies to this instance until it restarts.
+func (h *MaintenanceHandler) SetLookupReadOnly(c *fiber.Ctx) error {
+	var req models.LookupReadOnlyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if err := h.validator.Struct(&req); err != nil {
+		return utils.FormatValidationError(c, err)
+	}
+
+	h.lookupReadOnly.Set(*req.ReadOnly)
+	return utils.SuccessResponse(c, fiber.StatusOK, "Lookup read-only mode updated", models.LookupReadOnlyStatus{
+		ReadOnly: h.lookupReadOnly.Enabled(),
+	})
+}
@@ -19,7 +19,7 @@ type ReportHandler struct {
 func NewReportHandler(service services.ReportService) *ReportHandler {
 	return &ReportHandler{
 		service:   service,
-		validator: validator.New(),
+		validator: utils.NewValidator(),
 	}
 }
 
@@ -32,27 +32,26 @@ func (h *ReportHandler) CreateReport(c *fiber.Ctx) error {
 	}
 
 	if err := h.validator.Struct(&req); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return utils.FormatValidationError(c, err)
 	}
 
 	userID := c.Locals("user_id").(uuid.UUID)
 
-	report, err := h.service.CreateReport(c.Context(), &req, userID)
+	report, err := h.service.CreateReport(c.UserContext(), &req, userID)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusCreated, "Report created successfully", report)
 }
 
 func (h *ReportHandler) GetReport(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid report ID")
+		return err
 	}
 
-	report, err := h.service.GetReport(c.Context(), id)
+	report, err := h.service.GetReport(c.UserContext(), id)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "Report not found")
 	}
@@ -99,9 +98,9 @@ func (h *ReportHandler) ListReports(c *fiber.Ctx) error {
 		filter.CreatedByID = &userID
 	}
 
-	reports, total, err := h.service.ListReports(c.Context(), filter)
+	reports, total, err := h.service.ListReports(c.UserContext(), filter)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	totalPages := (int(total) + filter.Limit - 1) / filter.Limit
@@ -117,10 +116,9 @@ func (h *ReportHandler) ListReports(c *fiber.Ctx) error {
 }
 
 func (h *ReportHandler) UpdateReport(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid report ID")
+		return err
 	}
 
 	var req models.ReportUpdateRequest
@@ -130,7 +128,7 @@ func (h *ReportHandler) UpdateReport(c *fiber.Ctx) error {
 
 	userID := c.Locals("user_id").(uuid.UUID)
 
-	report, err := h.service.UpdateReport(c.Context(), id, &req, userID)
+	report, err := h.service.UpdateReport(c.UserContext(), id, &req, userID)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
 	}
@@ -139,15 +137,14 @@ func (h *ReportHandler) UpdateReport(c *fiber.Ctx) error {
 }
 
 func (h *ReportHandler) DeleteReport(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid report ID")
+		return err
 	}
 
 	userID := c.Locals("user_id").(uuid.UUID)
 
-	if err := h.service.DeleteReport(c.Context(), id, userID); err != nil {
+	if err := h.service.DeleteReport(c.UserContext(), id, userID); err != nil {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
 	}
 
@@ -155,17 +152,16 @@ func (h *ReportHandler) DeleteReport(c *fiber.Ctx) error {
 }
 
 func (h *ReportHandler) DuplicateReport(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid report ID")
+		return err
 	}
 
 	userID := c.Locals("user_id").(uuid.UUID)
 
-	report, err := h.service.DuplicateReport(c.Context(), id, userID)
+	report, err := h.service.DuplicateReport(c.UserContext(), id, userID)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusCreated, "Report duplicated successfully", report)
@@ -174,10 +170,9 @@ func (h *ReportHandler) DuplicateReport(c *fiber.Ctx) error {
 // Report Execution
 
 func (h *ReportHandler) ExecuteReport(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid report ID")
+		return err
 	}
 
 	var req models.ReportExecuteRequest
@@ -188,9 +183,9 @@ func (h *ReportHandler) ExecuteReport(c *fiber.Ctx) error {
 
 	userID := c.Locals("user_id").(uuid.UUID)
 
-	result, err := h.service.ExecuteReport(c.Context(), id, &req, userID)
+	result, err := h.service.ExecuteReport(c.UserContext(), id, &req, userID)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Report executed successfully", result)
@@ -206,9 +201,9 @@ func (h *ReportHandler) PreviewReport(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "data_source is required")
 	}
 
-	result, err := h.service.PreviewReport(c.Context(), &req)
+	result, err := h.service.PreviewReport(c.UserContext(), &req)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Preview generated successfully", result)
@@ -236,9 +231,9 @@ func (h *ReportHandler) QueryReport(c *fiber.Ctx) error {
 		req.Limit = 50
 	}
 
-	result, err := h.service.QueryReport(c.Context(), &req)
+	result, err := h.service.QueryReport(c.UserContext(), &req)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return c.JSON(result)
@@ -262,9 +257,9 @@ func (h *ReportHandler) ExportReport(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "format must be xlsx or pdf")
 	}
 
-	data, filename, contentType, err := h.service.ExportReport(c.Context(), &req)
+	data, filename, contentType, err := h.service.ExportReport(c.UserContext(), &req)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	c.Set("Content-Type", contentType)
@@ -273,10 +268,9 @@ func (h *ReportHandler) ExportReport(c *fiber.Ctx) error {
 }
 
 func (h *ReportHandler) GetExecutionHistory(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid report ID")
+		return err
 	}
 
 	page, _ := strconv.Atoi(c.Query("page", "1"))
@@ -289,9 +283,9 @@ func (h *ReportHandler) GetExecutionHistory(c *fiber.Ctx) error {
 		limit = 20
 	}
 
-	executions, total, err := h.service.GetExecutionHistory(c.Context(), id, page, limit)
+	executions, total, err := h.service.GetExecutionHistory(c.UserContext(), id, page, limit)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	totalPages := (int(total) + limit - 1) / limit
@@ -309,6 +303,6 @@ func (h *ReportHandler) GetExecutionHistory(c *fiber.Ctx) error {
 // Metadata
 
 func (h *ReportHandler) GetDataSources(c *fiber.Ctx) error {
-	dataSources := h.service.GetDataSources(c.Context())
+	dataSources := h.service.GetDataSources(c.UserContext())
 	return utils.SuccessResponse(c, fiber.StatusOK, "Data sources retrieved successfully", dataSources)
 }
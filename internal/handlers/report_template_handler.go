@@ -29,9 +29,9 @@ func (h *ReportTemplateHandler) CreateTemplate(c *fiber.Ctx) error {
 
 	userID := c.Locals("user_id").(uuid.UUID)
 
-	template, err := h.templateService.CreateTemplate(c.Context(), &req, userID)
+	template, err := h.templateService.CreateTemplate(c.UserContext(), &req, userID)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusCreated, "Template created successfully", template)
@@ -39,12 +39,12 @@ func (h *ReportTemplateHandler) CreateTemplate(c *fiber.Ctx) error {
 
 // GetTemplate retrieves a template by ID
 func (h *ReportTemplateHandler) GetTemplate(c *fiber.Ctx) error {
-	id, err := uuid.Parse(c.Params("id"))
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid template ID")
+		return err
 	}
 
-	template, err := h.templateService.GetTemplate(c.Context(), id)
+	template, err := h.templateService.GetTemplate(c.UserContext(), id)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "Template not found")
 	}
@@ -65,9 +65,9 @@ func (h *ReportTemplateHandler) ListTemplates(c *fiber.Ctx) error {
 		filter.IsPublic = &isPublic
 	}
 
-	templates, total, err := h.templateService.ListTemplates(c.Context(), filter)
+	templates, total, err := h.templateService.ListTemplates(c.UserContext(), filter)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.PaginatedSuccessResponse(c, templates, filter.Page, filter.Limit, total)
@@ -75,9 +75,9 @@ func (h *ReportTemplateHandler) ListTemplates(c *fiber.Ctx) error {
 
 // UpdateTemplate updates an existing template
 func (h *ReportTemplateHandler) UpdateTemplate(c *fiber.Ctx) error {
-	id, err := uuid.Parse(c.Params("id"))
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid template ID")
+		return err
 	}
 
 	var req models.ReportTemplateUpdateRequest
@@ -87,9 +87,9 @@ func (h *ReportTemplateHandler) UpdateTemplate(c *fiber.Ctx) error {
 
 	userID := c.Locals("user_id").(uuid.UUID)
 
-	template, err := h.templateService.UpdateTemplate(c.Context(), id, &req, userID)
+	template, err := h.templateService.UpdateTemplate(c.UserContext(), id, &req, userID)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Template updated successfully", template)
@@ -97,15 +97,15 @@ func (h *ReportTemplateHandler) UpdateTemplate(c *fiber.Ctx) error {
 
 // DeleteTemplate deletes a template
 func (h *ReportTemplateHandler) DeleteTemplate(c *fiber.Ctx) error {
-	id, err := uuid.Parse(c.Params("id"))
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid template ID")
+		return err
 	}
 
 	userID := c.Locals("user_id").(uuid.UUID)
 
-	if err := h.templateService.DeleteTemplate(c.Context(), id, userID); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	if err := h.templateService.DeleteTemplate(c.UserContext(), id, userID); err != nil {
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Template deleted successfully", nil)
@@ -113,16 +113,16 @@ func (h *ReportTemplateHandler) DeleteTemplate(c *fiber.Ctx) error {
 
 // DuplicateTemplate creates a copy of an existing template
 func (h *ReportTemplateHandler) DuplicateTemplate(c *fiber.Ctx) error {
-	id, err := uuid.Parse(c.Params("id"))
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid template ID")
+		return err
 	}
 
 	userID := c.Locals("user_id").(uuid.UUID)
 
-	template, err := h.templateService.DuplicateTemplate(c.Context(), id, userID)
+	template, err := h.templateService.DuplicateTemplate(c.UserContext(), id, userID)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusCreated, "Template duplicated successfully", template)
@@ -130,13 +130,13 @@ func (h *ReportTemplateHandler) DuplicateTemplate(c *fiber.Ctx) error {
 
 // SetDefaultTemplate sets a template as the default
 func (h *ReportTemplateHandler) SetDefaultTemplate(c *fiber.Ctx) error {
-	id, err := uuid.Parse(c.Params("id"))
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid template ID")
+		return err
 	}
 
-	if err := h.templateService.SetDefaultTemplate(c.Context(), id); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	if err := h.templateService.SetDefaultTemplate(c.UserContext(), id); err != nil {
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Default template set successfully", nil)
@@ -144,7 +144,7 @@ func (h *ReportTemplateHandler) SetDefaultTemplate(c *fiber.Ctx) error {
 
 // GetDefaultTemplate retrieves the default template
 func (h *ReportTemplateHandler) GetDefaultTemplate(c *fiber.Ctx) error {
-	template, err := h.templateService.GetDefaultTemplate(c.Context())
+	template, err := h.templateService.GetDefaultTemplate(c.UserContext())
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "No default template found")
 	}
@@ -161,9 +161,9 @@ func (h *ReportTemplateHandler) GenerateReport(c *fiber.Ctx) error {
 
 	userID := c.Locals("user_id").(uuid.UUID)
 
-	data, filename, contentType, err := h.templateService.GenerateReport(c.Context(), &req, userID)
+	data, filename, contentType, err := h.templateService.GenerateReport(c.UserContext(), &req, userID)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	c.Set("Content-Type", contentType)
@@ -189,9 +189,9 @@ func (h *ReportTemplateHandler) PreviewTemplate(c *fiber.Ctx) error {
 		req.Limit = 10
 	}
 
-	data, err := h.templateService.PreviewTemplate(c.Context(), &req.Template, req.DataSource, req.Limit)
+	data, err := h.templateService.PreviewTemplate(c.UserContext(), &req.Template, req.DataSource, req.Limit)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	c.Set("Content-Type", "application/pdf")
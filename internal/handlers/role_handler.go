@@ -21,7 +21,7 @@ func NewRoleHandler(roleRepo repository.RoleRepository, permissionRepo repositor
 	return &RoleHandler{
 		roleRepo:       roleRepo,
 		permissionRepo: permissionRepo,
-		validator:      validator.New(),
+		validator:      utils.NewValidator(),
 	}
 }
 
@@ -34,7 +34,7 @@ func (h *RoleHandler) CreateRole(c *fiber.Ctx) error {
 	}
 
 	if err := h.validator.Struct(&req); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return utils.FormatValidationError(c, err)
 	}
 
 	role := &models.Role{
@@ -45,29 +45,28 @@ func (h *RoleHandler) CreateRole(c *fiber.Ctx) error {
 		IsSystem:    false,
 	}
 
-	if err := h.roleRepo.Create(c.Context(), role); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	if err := h.roleRepo.Create(c.UserContext(), role); err != nil {
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	// Assign permissions if provided
 	if len(req.PermissionIDs) > 0 {
-		h.roleRepo.AssignPermissions(c.Context(), role.ID, req.PermissionIDs)
+		h.roleRepo.AssignPermissions(c.UserContext(), role.ID, req.PermissionIDs)
 	}
 
 	// Reload with permissions
-	role, _ = h.roleRepo.FindByID(c.Context(), role.ID)
+	role, _ = h.roleRepo.FindByID(c.UserContext(), role.ID)
 
 	return utils.SuccessResponse(c, fiber.StatusCreated, "Role created", models.ToRoleResponse(role))
 }
 
 func (h *RoleHandler) GetRole(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
-	role, err := h.roleRepo.FindByID(c.Context(), id)
+	role, err := h.roleRepo.FindByID(c.UserContext(), id)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "Role not found")
 	}
@@ -76,10 +75,9 @@ func (h *RoleHandler) GetRole(c *fiber.Ctx) error {
 }
 
 func (h *RoleHandler) UpdateRole(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
 	var req models.RoleUpdateRequest
@@ -87,7 +85,7 @@ func (h *RoleHandler) UpdateRole(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	role, err := h.roleRepo.FindByID(c.Context(), id)
+	role, err := h.roleRepo.FindByID(c.UserContext(), id)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "Role not found")
 	}
@@ -102,29 +100,28 @@ func (h *RoleHandler) UpdateRole(c *fiber.Ctx) error {
 		role.IsActive = *req.IsActive
 	}
 
-	if err := h.roleRepo.Update(c.Context(), role); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	if err := h.roleRepo.Update(c.UserContext(), role); err != nil {
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	// Update permissions if provided
 	if req.PermissionIDs != nil {
-		h.roleRepo.AssignPermissions(c.Context(), role.ID, req.PermissionIDs)
+		h.roleRepo.AssignPermissions(c.UserContext(), role.ID, req.PermissionIDs)
 	}
 
 	// Reload with permissions
-	role, _ = h.roleRepo.FindByID(c.Context(), role.ID)
+	role, _ = h.roleRepo.FindByID(c.UserContext(), role.ID)
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Role updated", models.ToRoleResponse(role))
 }
 
 func (h *RoleHandler) DeleteRole(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
-	role, err := h.roleRepo.FindByID(c.Context(), id)
+	role, err := h.roleRepo.FindByID(c.UserContext(), id)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "Role not found")
 	}
@@ -133,17 +130,17 @@ func (h *RoleHandler) DeleteRole(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusForbidden, "Cannot delete system role")
 	}
 
-	if err := h.roleRepo.Delete(c.Context(), id); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	if err := h.roleRepo.Delete(c.UserContext(), id); err != nil {
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Role deleted", nil)
 }
 
 func (h *RoleHandler) ListRoles(c *fiber.Ctx) error {
-	roles, err := h.roleRepo.List(c.Context())
+	roles, err := h.roleRepo.List(c.UserContext())
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	responses := make([]models.RoleResponse, len(roles))
@@ -155,10 +152,9 @@ func (h *RoleHandler) ListRoles(c *fiber.Ctx) error {
 }
 
 func (h *RoleHandler) AssignPermissions(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
 	var req struct {
@@ -168,11 +164,11 @@ func (h *RoleHandler) AssignPermissions(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	if err := h.roleRepo.AssignPermissions(c.Context(), id, req.PermissionIDs); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	if err := h.roleRepo.AssignPermissions(c.UserContext(), id, req.PermissionIDs); err != nil {
+		return utils.InternalErrorResponse(c, err)
 	}
 
-	role, _ := h.roleRepo.FindByID(c.Context(), id)
+	role, _ := h.roleRepo.FindByID(c.UserContext(), id)
 	return utils.SuccessResponse(c, fiber.StatusOK, "Permissions assigned", models.ToRoleResponse(role))
 }
 
@@ -185,7 +181,7 @@ func (h *RoleHandler) CreatePermission(c *fiber.Ctx) error {
 	}
 
 	if err := h.validator.Struct(&req); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return utils.FormatValidationError(c, err)
 	}
 
 	permission := &models.Permission{
@@ -197,21 +193,20 @@ func (h *RoleHandler) CreatePermission(c *fiber.Ctx) error {
 		IsActive:    true,
 	}
 
-	if err := h.permissionRepo.Create(c.Context(), permission); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	if err := h.permissionRepo.Create(c.UserContext(), permission); err != nil {
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusCreated, "Permission created", models.ToPermissionResponse(permission))
 }
 
 func (h *RoleHandler) GetPermission(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
-	permission, err := h.permissionRepo.FindByID(c.Context(), id)
+	permission, err := h.permissionRepo.FindByID(c.UserContext(), id)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "Permission not found")
 	}
@@ -220,10 +215,9 @@ func (h *RoleHandler) GetPermission(c *fiber.Ctx) error {
 }
 
 func (h *RoleHandler) UpdatePermission(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
 	var req models.PermissionUpdateRequest
@@ -231,7 +225,7 @@ func (h *RoleHandler) UpdatePermission(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	permission, err := h.permissionRepo.FindByID(c.Context(), id)
+	permission, err := h.permissionRepo.FindByID(c.UserContext(), id)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "Permission not found")
 	}
@@ -246,22 +240,21 @@ func (h *RoleHandler) UpdatePermission(c *fiber.Ctx) error {
 		permission.IsActive = *req.IsActive
 	}
 
-	if err := h.permissionRepo.Update(c.Context(), permission); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	if err := h.permissionRepo.Update(c.UserContext(), permission); err != nil {
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Permission updated", models.ToPermissionResponse(permission))
 }
 
 func (h *RoleHandler) DeletePermission(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
-	if err := h.permissionRepo.Delete(c.Context(), id); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	if err := h.permissionRepo.Delete(c.UserContext(), id); err != nil {
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Permission deleted", nil)
@@ -274,13 +267,13 @@ func (h *RoleHandler) ListPermissions(c *fiber.Ctx) error {
 	var err error
 
 	if module != "" {
-		permissions, err = h.permissionRepo.ListByModule(c.Context(), module)
+		permissions, err = h.permissionRepo.ListByModule(c.UserContext(), module)
 	} else {
-		permissions, err = h.permissionRepo.List(c.Context())
+		permissions, err = h.permissionRepo.List(c.UserContext())
 	}
 
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	responses := make([]models.PermissionResponse, len(permissions))
@@ -292,9 +285,9 @@ func (h *RoleHandler) ListPermissions(c *fiber.Ctx) error {
 }
 
 func (h *RoleHandler) GetModules(c *fiber.Ctx) error {
-	modules, err := h.permissionRepo.GetModules(c.Context())
+	modules, err := h.permissionRepo.GetModules(c.UserContext())
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Modules retrieved", modules)
@@ -302,9 +295,9 @@ func (h *RoleHandler) GetModules(c *fiber.Ctx) error {
 
 // Export roles to JSON
 func (h *RoleHandler) Export(c *fiber.Ctx) error {
-	roles, err := h.roleRepo.List(c.Context())
+	roles, err := h.roleRepo.List(c.UserContext())
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	type ExportRole struct {
@@ -379,7 +372,7 @@ func (h *RoleHandler) Import(c *fiber.Ctx) error {
 
 	for _, data := range importData {
 		// Check if role with same code already exists
-		existingRole, err := h.roleRepo.FindByCode(c.Context(), data.Code)
+		existingRole, err := h.roleRepo.FindByCode(c.UserContext(), data.Code)
 		if err == nil && existingRole != nil {
 			skipped++
 			errors = append(errors, data.Name+" - Role with code "+data.Code+" already exists, skipped")
@@ -395,14 +388,14 @@ func (h *RoleHandler) Import(c *fiber.Ctx) error {
 			IsSystem:    false, // Always set imported roles as non-system
 		}
 
-		if err := h.roleRepo.Create(c.Context(), role); err != nil {
+		if err := h.roleRepo.Create(c.UserContext(), role); err != nil {
 			errors = append(errors, data.Name+" - Failed to create: "+err.Error())
 			continue
 		}
 
 		// Assign permissions if provided
 		if len(data.PermissionIDs) > 0 {
-			if err := h.roleRepo.AssignPermissions(c.Context(), role.ID, data.PermissionIDs); err != nil {
+			if err := h.roleRepo.AssignPermissions(c.UserContext(), role.ID, data.PermissionIDs); err != nil {
 				errors = append(errors, data.Name+" - Role created but failed to assign permissions: "+err.Error())
 			}
 		}
@@ -25,7 +25,7 @@ func NewUserHandler(userService services.UserService, storage *storage.MinIOStor
 	return &UserHandler{
 		userService: userService,
 		storage:     storage,
-		validator:   validator.New(),
+		validator:   utils.NewValidator(),
 	}
 }
 
@@ -36,10 +36,10 @@ func (h *UserHandler) Register(c *fiber.Ctx) error {
 	}
 
 	if err := h.validator.Struct(&req); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return utils.FormatValidationError(c, err)
 	}
 
-	response, err := h.userService.Register(c.Context(), &req)
+	response, err := h.userService.Register(utils.WithClientIP(c.UserContext(), c.IP()), &req)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
 	}
@@ -54,10 +54,10 @@ func (h *UserHandler) Login(c *fiber.Ctx) error {
 	}
 
 	if err := h.validator.Struct(&req); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return utils.FormatValidationError(c, err)
 	}
 
-	response, err := h.userService.Login(c.Context(), &req)
+	response, err := h.userService.Login(utils.WithClientIP(c.UserContext(), c.IP()), &req)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusUnauthorized, err.Error())
 	}
@@ -68,7 +68,7 @@ func (h *UserHandler) Login(c *fiber.Ctx) error {
 func (h *UserHandler) Logout(c *fiber.Ctx) error {
 	token := c.Locals("token").(string)
 
-	if err := h.userService.Logout(c.Context(), token); err != nil {
+	if err := h.userService.Logout(c.UserContext(), token); err != nil {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to logout")
 	}
 
@@ -82,10 +82,10 @@ func (h *UserHandler) RefreshToken(c *fiber.Ctx) error {
 	}
 
 	if err := h.validator.Struct(&req); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return utils.FormatValidationError(c, err)
 	}
 
-	response, err := h.userService.RefreshToken(c.Context(), req.RefreshToken)
+	response, err := h.userService.RefreshToken(utils.WithClientIP(c.UserContext(), c.IP()), req.RefreshToken)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusUnauthorized, err.Error())
 	}
@@ -93,10 +93,31 @@ func (h *UserHandler) RefreshToken(c *fiber.Ctx) error {
 	return utils.SuccessResponse(c, fiber.StatusOK, "Token refreshed successfully", response)
 }
 
+// Introspect answers RFC 7662 token introspection for the API gateway. It is
+// always 200: unusable tokens come back as {"active": false}. The body is the
+// bare RFC 7662 document, not the usual response envelope.
+func (h *UserHandler) Introspect(c *fiber.Ctx) error {
+	var req models.TokenIntrospectionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		return utils.FormatValidationError(c, err)
+	}
+
+	response, err := h.userService.IntrospectToken(c.UserContext(), req.Token)
+	if err != nil {
+		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to introspect token")
+	}
+
+	return c.JSON(response)
+}
+
 func (h *UserHandler) GetProfile(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(uuid.UUID)
 
-	response, err := h.userService.GetProfile(c.Context(), userID)
+	response, err := h.userService.GetProfile(c.UserContext(), userID)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "User not found")
 	}
@@ -113,10 +134,10 @@ func (h *UserHandler) UpdateProfile(c *fiber.Ctx) error {
 	}
 
 	if err := h.validator.Struct(&req); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return utils.FormatValidationError(c, err)
 	}
 
-	response, err := h.userService.UpdateProfile(c.Context(), userID, &req)
+	response, err := h.userService.UpdateProfile(c.UserContext(), userID, &req)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
 	}
@@ -133,10 +154,10 @@ func (h *UserHandler) ChangePassword(c *fiber.Ctx) error {
 	}
 
 	if err := h.validator.Struct(&req); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return utils.FormatValidationError(c, err)
 	}
 
-	if err := h.userService.ChangePassword(c.Context(), userID, &req); err != nil {
+	if err := h.userService.ChangePassword(c.UserContext(), userID, &req); err != nil {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
 	}
 
@@ -166,7 +187,7 @@ func (h *UserHandler) UploadAvatar(c *fiber.Ctx) error {
 	}
 	defer src.Close()
 
-	response, err := h.userService.UploadAvatar(c.Context(), userID, src, file)
+	response, err := h.userService.UploadAvatar(c.UserContext(), userID, src, file)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to upload avatar")
 	}
@@ -177,7 +198,7 @@ func (h *UserHandler) UploadAvatar(c *fiber.Ctx) error {
 func (h *UserHandler) DeleteAccount(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(uuid.UUID)
 
-	if err := h.userService.DeleteUser(c.Context(), userID); err != nil {
+	if err := h.userService.DeleteUser(c.UserContext(), userID); err != nil {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to delete account")
 	}
 
@@ -195,7 +216,7 @@ func (h *UserHandler) ListUsers(c *fiber.Ctx) error {
 		limit = 10
 	}
 
-	users, total, err := h.userService.ListUsers(c.Context(), page, limit)
+	users, total, err := h.userService.ListUsers(c.UserContext(), page, limit)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to fetch users")
 	}
@@ -204,13 +225,12 @@ func (h *UserHandler) ListUsers(c *fiber.Ctx) error {
 }
 
 func (h *UserHandler) GetUser(c *fiber.Ctx) error {
-	userIDStr := c.Params("id")
-	userID, err := uuid.Parse(userIDStr)
+	userID, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid user ID")
+		return err
 	}
 
-	response, err := h.userService.GetUserByID(c.Context(), userID)
+	response, err := h.userService.GetUserByID(c.UserContext(), userID)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "User not found")
 	}
@@ -295,10 +315,10 @@ func (h *UserHandler) AdminCreateUser(c *fiber.Ctx) error {
 	}
 
 	if err := h.validator.Struct(&req); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return utils.FormatValidationError(c, err)
 	}
 
-	response, err := h.userService.Register(c.Context(), &req)
+	response, err := h.userService.Register(c.UserContext(), &req)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
 	}
@@ -314,11 +334,11 @@ func (h *UserHandler) AdminCreateUser(c *fiber.Ctx) error {
 
 				// Upload to storage
 				folder := fmt.Sprintf("avatars/%s", response.User.ID)
-				filePath, err := h.storage.UploadFile(c.Context(), src, file, folder)
+				filePath, err := h.storage.UploadFile(c.UserContext(), src, file, folder)
 				if err == nil {
 					// Update user's avatar URL
 					avatarURL := filePath
-					if updateErr := h.userService.UpdateAvatar(c.Context(), response.User.ID, avatarURL); updateErr == nil {
+					if updateErr := h.userService.UpdateAvatar(c.UserContext(), response.User.ID, avatarURL); updateErr == nil {
 						response.User.Avatar = avatarURL
 					}
 				}
@@ -330,10 +350,9 @@ func (h *UserHandler) AdminCreateUser(c *fiber.Ctx) error {
 }
 
 func (h *UserHandler) AdminUpdateUser(c *fiber.Ctx) error {
-	userIDStr := c.Params("id")
-	userID, err := uuid.Parse(userIDStr)
+	userID, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid user ID")
+		return err
 	}
 
 	var req models.UserUpdateRequest
@@ -342,10 +361,10 @@ func (h *UserHandler) AdminUpdateUser(c *fiber.Ctx) error {
 	}
 
 	if err := h.validator.Struct(&req); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return utils.FormatValidationError(c, err)
 	}
 
-	response, err := h.userService.UpdateProfile(c.Context(), userID, &req)
+	response, err := h.userService.UpdateProfile(c.UserContext(), userID, &req)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
 	}
@@ -402,9 +421,9 @@ func (h *UserHandler) MatchUsers(c *fiber.Ctx) error {
 		excludeUserID = &id
 	}
 
-	users, err := h.userService.FindMatchingUsers(c.Context(), roleID, classificationID, locationID, departmentID, excludeUserID)
+	users, err := h.userService.FindMatchingUsers(c.UserContext(), roleID, classificationID, locationID, departmentID, excludeUserID)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	// Build match response
@@ -424,9 +443,9 @@ func (h *UserHandler) MatchUsers(c *fiber.Ctx) error {
 // Export exports all users as JSON
 func (h *UserHandler) Export(c *fiber.Ctx) error {
 	// Get all users without pagination
-	users, _, err := h.userService.ListUsers(c.Context(), 1, 10000)
+	users, _, err := h.userService.ListUsers(c.UserContext(), 1, 10000)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	// Convert to export format
@@ -524,14 +543,14 @@ func (h *UserHandler) Import(c *fiber.Ctx) error {
 	// Import all users
 	for _, data := range importData {
 		// Check if user already exists by email or username
-		existingUser, _ := h.userService.GetUserByEmail(c.Context(), data.Email)
+		existingUser, _ := h.userService.GetUserByEmail(c.UserContext(), data.Email)
 		if existingUser != nil {
 			skipped++
 			errors = append(errors, data.Email+" - User already exists with this email, skipped")
 			continue
 		}
 
-		existingUser, _ = h.userService.GetUserByUsername(c.Context(), data.Username)
+		existingUser, _ = h.userService.GetUserByUsername(c.UserContext(), data.Username)
 		if existingUser != nil {
 			skipped++
 			errors = append(errors, data.Username+" - User already exists with this username, skipped")
@@ -587,7 +606,7 @@ func (h *UserHandler) Import(c *fiber.Ctx) error {
 		}
 
 		// Register user
-		_, err := h.userService.Register(c.Context(), req)
+		_, err := h.userService.Register(c.UserContext(), req)
 		if err != nil {
 			skipped++
 			errors = append(errors, data.Email+" - "+err.Error())
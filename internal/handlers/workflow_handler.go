@@ -20,7 +20,7 @@ type WorkflowHandler struct {
 func NewWorkflowHandler(service services.WorkflowService) *WorkflowHandler {
 	return &WorkflowHandler{
 		service:   service,
-		validator: validator.New(),
+		validator: utils.NewValidator(),
 	}
 }
 
@@ -33,28 +33,27 @@ func (h *WorkflowHandler) CreateWorkflow(c *fiber.Ctx) error {
 	}
 
 	if err := h.validator.Struct(&req); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return utils.FormatValidationError(c, err)
 	}
 
 	// Get user ID from context
 	userID := c.Locals("user_id").(uuid.UUID)
 
-	workflow, err := h.service.CreateWorkflow(c.Context(), &req, userID)
+	workflow, err := h.service.CreateWorkflow(c.UserContext(), &req, userID)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusCreated, "Workflow created", workflow)
 }
 
 func (h *WorkflowHandler) GetWorkflow(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
-	workflow, err := h.service.GetWorkflow(c.Context(), id)
+	workflow, err := h.service.GetWorkflow(c.UserContext(), id)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "Workflow not found")
 	}
@@ -70,22 +69,21 @@ func (h *WorkflowHandler) ListWorkflows(c *fiber.Ctx) error {
 	var err error
 
 	if recordType != "" {
-		workflows, err = h.service.ListWorkflowsByRecordType(c.Context(), recordType, activeOnly)
+		workflows, err = h.service.ListWorkflowsByRecordType(c.UserContext(), recordType, activeOnly)
 	} else {
-		workflows, err = h.service.ListWorkflows(c.Context(), activeOnly)
+		workflows, err = h.service.ListWorkflows(c.UserContext(), activeOnly)
 	}
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Workflows retrieved", workflows)
 }
 
 func (h *WorkflowHandler) UpdateWorkflow(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
 	var req models.WorkflowUpdateRequest
@@ -93,77 +91,73 @@ func (h *WorkflowHandler) UpdateWorkflow(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	workflow, err := h.service.UpdateWorkflow(c.Context(), id, &req)
+	workflow, err := h.service.UpdateWorkflow(c.UserContext(), id, &req)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Workflow updated", workflow)
 }
 
 func (h *WorkflowHandler) DeleteWorkflow(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
-	if err := h.service.DeleteWorkflow(c.Context(), id); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	if err := h.service.DeleteWorkflow(c.UserContext(), id); err != nil {
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Workflow deleted", nil)
 }
 
 func (h *WorkflowHandler) ListDeletedWorkflows(c *fiber.Ctx) error {
-	workflows, err := h.service.ListDeletedWorkflows(c.Context())
+	workflows, err := h.service.ListDeletedWorkflows(c.UserContext())
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Deleted workflows retrieved", workflows)
 }
 
 func (h *WorkflowHandler) PermanentDeleteWorkflow(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
-	if err := h.service.PermanentDeleteWorkflow(c.Context(), id); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	if err := h.service.PermanentDeleteWorkflow(c.UserContext(), id); err != nil {
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Workflow permanently deleted", nil)
 }
 
 func (h *WorkflowHandler) RestoreWorkflow(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
-	if err := h.service.RestoreWorkflow(c.Context(), id); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	if err := h.service.RestoreWorkflow(c.UserContext(), id); err != nil {
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Workflow restored", nil)
 }
 
 func (h *WorkflowHandler) DuplicateWorkflow(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
 	userID := c.Locals("user_id").(uuid.UUID)
 
-	workflow, err := h.service.DuplicateWorkflow(c.Context(), id, userID)
+	workflow, err := h.service.DuplicateWorkflow(c.UserContext(), id, userID)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusCreated, "Workflow duplicated", workflow)
@@ -172,10 +166,9 @@ func (h *WorkflowHandler) DuplicateWorkflow(c *fiber.Ctx) error {
 // Classification assignment
 
 func (h *WorkflowHandler) AssignClassifications(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
 	var req struct {
@@ -194,27 +187,26 @@ func (h *WorkflowHandler) AssignClassifications(c *fiber.Ctx) error {
 		classIDs = append(classIDs, classID)
 	}
 
-	if err := h.service.AssignClassifications(c.Context(), id, classIDs); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	if err := h.service.AssignClassifications(c.UserContext(), id, classIDs); err != nil {
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	// Fetch updated workflow
-	workflow, err := h.service.GetWorkflow(c.Context(), id)
+	workflow, err := h.service.GetWorkflow(c.UserContext(), id)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Classifications assigned", workflow)
 }
 
 func (h *WorkflowHandler) GetWorkflowByClassification(c *fiber.Ctx) error {
-	idStr := c.Params("classification_id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "classification_id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid classification ID")
+		return err
 	}
 
-	workflow, err := h.service.GetWorkflowByClassification(c.Context(), id)
+	workflow, err := h.service.GetWorkflowByClassification(c.UserContext(), id)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, err.Error())
 	}
@@ -225,10 +217,9 @@ func (h *WorkflowHandler) GetWorkflowByClassification(c *fiber.Ctx) error {
 // State management
 
 func (h *WorkflowHandler) CreateState(c *fiber.Ctx) error {
-	workflowIDStr := c.Params("id")
-	workflowID, err := uuid.Parse(workflowIDStr)
+	workflowID, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid workflow ID")
+		return err
 	}
 
 	var req models.WorkflowStateCreateRequest
@@ -237,37 +228,35 @@ func (h *WorkflowHandler) CreateState(c *fiber.Ctx) error {
 	}
 
 	if err := h.validator.Struct(&req); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return utils.FormatValidationError(c, err)
 	}
 
-	state, err := h.service.CreateState(c.Context(), workflowID, &req)
+	state, err := h.service.CreateState(c.UserContext(), workflowID, &req)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusCreated, "State created", state)
 }
 
 func (h *WorkflowHandler) ListStates(c *fiber.Ctx) error {
-	workflowIDStr := c.Params("id")
-	workflowID, err := uuid.Parse(workflowIDStr)
+	workflowID, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid workflow ID")
+		return err
 	}
 
-	states, err := h.service.ListStates(c.Context(), workflowID)
+	states, err := h.service.ListStates(c.UserContext(), workflowID)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "States retrieved", states)
 }
 
 func (h *WorkflowHandler) UpdateState(c *fiber.Ctx) error {
-	stateIDStr := c.Params("state_id")
-	stateID, err := uuid.Parse(stateIDStr)
+	stateID, err := utils.ParseUUIDParam(c, "state_id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid state ID")
+		return err
 	}
 
 	var req models.WorkflowStateUpdateRequest
@@ -275,23 +264,22 @@ func (h *WorkflowHandler) UpdateState(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	state, err := h.service.UpdateState(c.Context(), stateID, &req)
+	state, err := h.service.UpdateState(c.UserContext(), stateID, &req)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "State updated", state)
 }
 
 func (h *WorkflowHandler) DeleteState(c *fiber.Ctx) error {
-	stateIDStr := c.Params("state_id")
-	stateID, err := uuid.Parse(stateIDStr)
+	stateID, err := utils.ParseUUIDParam(c, "state_id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid state ID")
+		return err
 	}
 
-	if err := h.service.DeleteState(c.Context(), stateID); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	if err := h.service.DeleteState(c.UserContext(), stateID); err != nil {
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "State deleted", nil)
@@ -300,10 +288,9 @@ func (h *WorkflowHandler) DeleteState(c *fiber.Ctx) error {
 // Transition management
 
 func (h *WorkflowHandler) CreateTransition(c *fiber.Ctx) error {
-	workflowIDStr := c.Params("id")
-	workflowID, err := uuid.Parse(workflowIDStr)
+	workflowID, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid workflow ID")
+		return err
 	}
 
 	var req models.WorkflowTransitionCreateRequest
@@ -312,37 +299,35 @@ func (h *WorkflowHandler) CreateTransition(c *fiber.Ctx) error {
 	}
 
 	if err := h.validator.Struct(&req); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return utils.FormatValidationError(c, err)
 	}
 
-	transition, err := h.service.CreateTransition(c.Context(), workflowID, &req)
+	transition, err := h.service.CreateTransition(c.UserContext(), workflowID, &req)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusCreated, "Transition created", transition)
 }
 
 func (h *WorkflowHandler) ListTransitions(c *fiber.Ctx) error {
-	workflowIDStr := c.Params("id")
-	workflowID, err := uuid.Parse(workflowIDStr)
+	workflowID, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid workflow ID")
+		return err
 	}
 
-	transitions, err := h.service.ListTransitions(c.Context(), workflowID)
+	transitions, err := h.service.ListTransitions(c.UserContext(), workflowID)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Transitions retrieved", transitions)
 }
 
 func (h *WorkflowHandler) UpdateTransition(c *fiber.Ctx) error {
-	transitionIDStr := c.Params("transition_id")
-	transitionID, err := uuid.Parse(transitionIDStr)
+	transitionID, err := utils.ParseUUIDParam(c, "transition_id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid transition ID")
+		return err
 	}
 
 	var req models.WorkflowTransitionUpdateRequest
@@ -350,23 +335,22 @@ func (h *WorkflowHandler) UpdateTransition(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	transition, err := h.service.UpdateTransition(c.Context(), transitionID, &req)
+	transition, err := h.service.UpdateTransition(c.UserContext(), transitionID, &req)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Transition updated", transition)
 }
 
 func (h *WorkflowHandler) DeleteTransition(c *fiber.Ctx) error {
-	transitionIDStr := c.Params("transition_id")
-	transitionID, err := uuid.Parse(transitionIDStr)
+	transitionID, err := utils.ParseUUIDParam(c, "transition_id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid transition ID")
+		return err
 	}
 
-	if err := h.service.DeleteTransition(c.Context(), transitionID); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	if err := h.service.DeleteTransition(c.UserContext(), transitionID); err != nil {
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Transition deleted", nil)
@@ -375,10 +359,9 @@ func (h *WorkflowHandler) DeleteTransition(c *fiber.Ctx) error {
 // Transition configuration
 
 func (h *WorkflowHandler) SetTransitionRoles(c *fiber.Ctx) error {
-	transitionIDStr := c.Params("id")
-	transitionID, err := uuid.Parse(transitionIDStr)
+	transitionID, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid transition ID")
+		return err
 	}
 
 	var req struct {
@@ -397,18 +380,17 @@ func (h *WorkflowHandler) SetTransitionRoles(c *fiber.Ctx) error {
 		roleIDs = append(roleIDs, roleID)
 	}
 
-	if err := h.service.SetTransitionRoles(c.Context(), transitionID, roleIDs); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	if err := h.service.SetTransitionRoles(c.UserContext(), transitionID, roleIDs); err != nil {
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Transition roles updated", nil)
 }
 
 func (h *WorkflowHandler) SetTransitionRequirements(c *fiber.Ctx) error {
-	transitionIDStr := c.Params("id")
-	transitionID, err := uuid.Parse(transitionIDStr)
+	transitionID, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid transition ID")
+		return err
 	}
 
 	var req struct {
@@ -418,18 +400,17 @@ func (h *WorkflowHandler) SetTransitionRequirements(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	if err := h.service.SetTransitionRequirements(c.Context(), transitionID, req.Requirements); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	if err := h.service.SetTransitionRequirements(c.UserContext(), transitionID, req.Requirements); err != nil {
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Transition requirements updated", nil)
 }
 
 func (h *WorkflowHandler) SetTransitionActions(c *fiber.Ctx) error {
-	transitionIDStr := c.Params("id")
-	transitionID, err := uuid.Parse(transitionIDStr)
+	transitionID, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid transition ID")
+		return err
 	}
 
 	var req struct {
@@ -439,8 +420,8 @@ func (h *WorkflowHandler) SetTransitionActions(c *fiber.Ctx) error {
 		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	if err := h.service.SetTransitionActions(c.Context(), transitionID, req.Actions); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	if err := h.service.SetTransitionActions(c.UserContext(), transitionID, req.Actions); err != nil {
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Transition actions updated", nil)
@@ -449,28 +430,26 @@ func (h *WorkflowHandler) SetTransitionActions(c *fiber.Ctx) error {
 // Helper endpoints
 
 func (h *WorkflowHandler) GetTransitionsFromState(c *fiber.Ctx) error {
-	stateIDStr := c.Params("state_id")
-	stateID, err := uuid.Parse(stateIDStr)
+	stateID, err := utils.ParseUUIDParam(c, "state_id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid state ID")
+		return err
 	}
 
-	transitions, err := h.service.GetTransitionsFromState(c.Context(), stateID)
+	transitions, err := h.service.GetTransitionsFromState(c.UserContext(), stateID)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Transitions retrieved", transitions)
 }
 
 func (h *WorkflowHandler) GetInitialState(c *fiber.Ctx) error {
-	workflowIDStr := c.Params("id")
-	workflowID, err := uuid.Parse(workflowIDStr)
+	workflowID, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid workflow ID")
+		return err
 	}
 
-	state, err := h.service.GetInitialState(c.Context(), workflowID)
+	state, err := h.service.GetInitialState(c.UserContext(), workflowID)
 	if err != nil {
 		return utils.ErrorResponse(c, fiber.StatusNotFound, "Initial state not found")
 	}
@@ -490,9 +469,9 @@ func (h *WorkflowHandler) MatchWorkflow(c *fiber.Ctx) error {
 		req = models.WorkflowMatchRequest{}
 	}
 
-	result, err := h.service.MatchWorkflow(c.Context(), &req)
+	result, err := h.service.MatchWorkflow(c.UserContext(), &req)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Workflow matched", result)
@@ -500,15 +479,14 @@ func (h *WorkflowHandler) MatchWorkflow(c *fiber.Ctx) error {
 
 // ExportWorkflow exports a workflow as a JSON file
 func (h *WorkflowHandler) ExportWorkflow(c *fiber.Ctx) error {
-	idStr := c.Params("id")
-	id, err := uuid.Parse(idStr)
+	id, err := utils.ParseUUIDParam(c, "id")
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid ID")
+		return err
 	}
 
-	jsonBytes, filename, err := h.service.ExportWorkflow(c.Context(), id)
+	jsonBytes, filename, err := h.service.ExportWorkflow(c.UserContext(), id)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	// Set headers for file download
@@ -555,9 +533,9 @@ func (h *WorkflowHandler) ImportWorkflow(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(uuid.UUID)
 
 	// Import workflow
-	workflow, warnings, err := h.service.ImportWorkflow(c.Context(), &importData, userID)
+	workflow, warnings, err := h.service.ImportWorkflow(c.UserContext(), &importData, userID)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+		return utils.InternalErrorResponse(c, err)
 	}
 
 	// Build response
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/automax/backend/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// APIVersion negotiates the response envelope version from Accept-Version and
+// reports it in X-API-Version. Handlers read it with utils.RequestAPIVersion.
+// An unsupported version is rejected with 406 rather than silently answered
+// in a shape the client did not ask for.
+func APIVersion() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Vary(utils.AcceptVersionHeader)
+		version, ok := utils.NegotiateAPIVersion(c.Get(utils.AcceptVersionHeader))
+		if !ok {
+			c.Set(utils.APIVersionHeader, utils.APIVersion)
+			return utils.ErrorResponse(c, fiber.StatusNotAcceptable, fmt.Sprintf("Unsupported API version %q; supported versions: %s",
+				c.Get(utils.AcceptVersionHeader), strings.Join(utils.SupportedAPIVersions, ", ")))
+		}
+
+		c.Locals(utils.APIVersionLocal, version)
+		c.Set(utils.APIVersionHeader, version)
+		return c.Next()
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/automax/backend/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAPIVersionHeader(t *testing.T) {
+	app := fiber.New()
+	app.Use(APIVersion())
+	app.Get("/version", func(c *fiber.Ctx) error { return c.SendString(utils.RequestAPIVersion(c)) })
+
+	cases := []struct {
+		accept string
+		want   int
+	}{
+		{"", fiber.StatusOK},
+		{"v" + utils.APIVersion, fiber.StatusOK},
+		{"99", fiber.StatusNotAcceptable},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(fiber.MethodGet, "/version", nil)
+		if tc.accept != "" {
+			req.Header.Set(utils.AcceptVersionHeader, tc.accept)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("GET with Accept-Version %q: %v", tc.accept, err)
+		}
+		if resp.StatusCode != tc.want {
+			t.Errorf("GET with Accept-Version %q = %d, want %d", tc.accept, resp.StatusCode, tc.want)
+		}
+		if got := resp.Header.Get(utils.APIVersionHeader); got != utils.APIVersion {
+			t.Errorf("GET with Accept-Version %q %s = %q, want %q", tc.accept, utils.APIVersionHeader, got, utils.APIVersion)
+		}
+	}
+}
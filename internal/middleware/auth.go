@@ -63,6 +63,7 @@ func (m *AuthMiddleware) Authenticate() fiber.Handler {
 		c.Locals("user_id", claims.UserID)
 		c.Locals("email", claims.Email)
 		c.Locals("role", claims.Role)
+		c.Locals("org_id", claims.OrgID)
 		c.Locals("token", token)
 
 		return c.Next()
@@ -91,7 +92,7 @@ func (m *AuthMiddleware) RequirePermission(permissions ...string) fiber.Handler
 			return utils.ErrorResponse(c, fiber.StatusUnauthorized, "User not authenticated")
 		}
 
-		user, err := m.userRepo.FindByIDWithPermissions(c.Context(), userID)
+		user, err := m.userRepo.FindByIDWithPermissions(c.UserContext(), userID)
 		if err != nil {
 			return utils.ErrorResponse(c, fiber.StatusForbidden, "User not found")
 		}
@@ -141,6 +142,7 @@ func (m *AuthMiddleware) OptionalAuth() fiber.Handler {
 		c.Locals("user_id", claims.UserID)
 		c.Locals("email", claims.Email)
 		c.Locals("role", claims.Role)
+		c.Locals("org_id", claims.OrgID)
 		c.Locals("token", token)
 
 		return c.Next()
@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/automax/backend/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotencyRecord is the stored outcome of a request made with an Idempotency-Key
+type IdempotencyRecord struct {
+	BodyHash    string
+	StatusCode  int
+	ContentType string
+	Body        []byte
+	// Pending is set while the first request with the key is still being handled
+	Pending bool
+}
+
+// IdempotencyStore keeps idempotency records for a limited time
+type IdempotencyStore interface {
+	// Reserve stores a pending record for key unless one exists already, in which
+	// case the existing record is returned with reserved=false.
+	Reserve(key, bodyHash string, ttl time.Duration) (existing *IdempotencyRecord, reserved bool)
+	// Complete replaces the pending record for key with the final response.
+	Complete(key string, record *IdempotencyRecord, ttl time.Duration)
+	// Release drops a pending record so the request can be retried.
+	Release(key string)
+}
+
+type memoryIdempotencyEntry struct {
+	record    *IdempotencyRecord
+	expiresAt time.Time
+}
+
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryIdempotencyEntry
+}
+
+// NewMemoryIdempotencyStore returns an in-process IdempotencyStore. Records are
+// not shared between server instances.
+func NewMemoryIdempotencyStore() IdempotencyStore {
+	return &memoryIdempotencyStore{entries: make(map[string]memoryIdempotencyEntry)}
+}
+
+func (s *memoryIdempotencyStore) Reserve(key, bodyHash string, ttl time.Duration) (*IdempotencyRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.evictExpired(now)
+
+	if entry, ok := s.entries[key]; ok {
+		return entry.record, false
+	}
+	s.entries[key] = memoryIdempotencyEntry{
+		record:    &IdempotencyRecord{BodyHash: bodyHash, Pending: true},
+		expiresAt: now.Add(ttl),
+	}
+	return nil, true
+}
+
+func (s *memoryIdempotencyStore) Complete(key string, record *IdempotencyRecord, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = memoryIdempotencyEntry{record: record, expiresAt: time.Now().Add(ttl)}
+}
+
+func (s *memoryIdempotencyStore) Release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+}
+
+func (s *memoryIdempotencyStore) evictExpired(now time.Time) {
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// Idempotency replays the original response when a request is repeated with the
+// same Idempotency-Key header, instead of running the handler again. Keys are
+// scoped per user and route. Reusing a key with a different body is rejected
+// with 409. Only successful responses are remembered, so failed requests can be
+// retried with the same key. Requests without the header pass through untouched.
+func Idempotency(store IdempotencyStore, ttl time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(IdempotencyKeyHeader)
+		if key == "" {
+			return c.Next()
+		}
+
+		userID, _ := c.Locals("user_id").(uuid.UUID)
+		storeKey := fmt.Sprintf("%s:%s:%s:%s", userID, c.Method(), c.Path(), key)
+
+		sum := sha256.Sum256(c.Body())
+		bodyHash := hex.EncodeToString(sum[:])
+
+		existing, reserved := store.Reserve(storeKey, bodyHash, ttl)
+		if !reserved {
+			if existing.BodyHash != bodyHash {
+				return utils.ErrorResponse(c, fiber.StatusConflict, "Idempotency-Key was already used with a different request body")
+			}
+			if existing.Pending {
+				return utils.ErrorResponse(c, fiber.StatusConflict, "A request with this Idempotency-Key is still in progress")
+			}
+			c.Set("Idempotent-Replayed", "true")
+			if existing.ContentType != "" {
+				c.Set(fiber.HeaderContentType, existing.ContentType)
+			}
+			return c.Status(existing.StatusCode).Send(existing.Body)
+		}
+
+		if err := c.Next(); err != nil {
+			store.Release(storeKey)
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		if status < 200 || status >= 300 {
+			store.Release(storeKey)
+			return nil
+		}
+
+		store.Complete(storeKey, &IdempotencyRecord{
+			BodyHash:    bodyHash,
+			StatusCode:  status,
+			ContentType: string(c.Response().Header.ContentType()),
+			Body:        append([]byte(nil), c.Response().Body()...),
+		}, ttl)
+		return nil
+	}
+}
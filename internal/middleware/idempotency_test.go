@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// newIdempotencyApp serves a create endpoint that counts its calls and answers
+// with status, as the user in the X-User header
+func newIdempotencyApp(calls *int, status *int) *fiber.App {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		if id, err := uuid.Parse(c.Get("X-User")); err == nil {
+			c.Locals("user_id", id)
+		}
+		return c.Next()
+	})
+	app.Post("/categories", Idempotency(NewMemoryIdempotencyStore(), time.Hour), func(c *fiber.Ctx) error {
+		*calls++
+		return c.Status(*status).SendString(strings.Repeat("x", *calls))
+	})
+	return app
+}
+
+func postWithKey(t *testing.T, app *fiber.App, key, user, body string) *http.Response {
+	t.Helper()
+	req := httptest.NewRequest(fiber.MethodPost, "/categories", strings.NewReader(body))
+	req.Header.Set(IdempotencyKeyHeader, key)
+	req.Header.Set("X-User", user)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("POST /categories: %v", err)
+	}
+	return resp
+}
+
+func TestIdempotencyReplaysTheFirstResponse(t *testing.T) {
+	calls, status := 0, fiber.StatusCreated
+	app := newIdempotencyApp(&calls, &status)
+	user := uuid.NewString()
+
+	postWithKey(t, app, "k1", user, `{"code":"A"}`)
+	resp := postWithKey(t, app, "k1", user, `{"code":"A"}`)
+	if resp.StatusCode != fiber.StatusCreated {
+		t.Fatalf("replay = %d, want 201", resp.StatusCode)
+	}
+	if resp.Header.Get("Idempotent-Replayed") != "true" {
+		t.Errorf("replay Idempotent-Replayed = %q, want true", resp.Header.Get("Idempotent-Replayed"))
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "x" {
+		t.Errorf("replay body = %q, want the first response x", body)
+	}
+	if calls != 1 {
+		t.Errorf("handler ran %d times, want 1", calls)
+	}
+
+	if resp := postWithKey(t, app, "k1", user, `{"code":"B"}`); resp.StatusCode != fiber.StatusConflict {
+		t.Errorf("key reused with another body = %d, want 409", resp.StatusCode)
+	}
+	if resp := postWithKey(t, app, "k1", uuid.NewString(), `{"code":"A"}`); resp.StatusCode != fiber.StatusCreated || resp.Header.Get("Idempotent-Replayed") != "" {
+		t.Errorf("same key for another user = %d replayed=%q, want a fresh 201", resp.StatusCode, resp.Header.Get("Idempotent-Replayed"))
+	}
+}
+
+func TestIdempotencyLetsFailedRequestsBeRetried(t *testing.T) {
+	calls, status := 0, fiber.StatusInternalServerError
+	app := newIdempotencyApp(&calls, &status)
+	user := uuid.NewString()
+
+	postWithKey(t, app, "k1", user, `{}`)
+	status = fiber.StatusCreated
+	resp := postWithKey(t, app, "k1", user, `{}`)
+	if resp.StatusCode != fiber.StatusCreated || resp.Header.Get("Idempotent-Replayed") != "" {
+		t.Errorf("retry after a failure = %d replayed=%q, want a fresh 201", resp.StatusCode, resp.Header.Get("Idempotent-Replayed"))
+	}
+	if calls != 2 {
+		t.Errorf("handler ran %d times, want 2", calls)
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/automax/backend/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+)
+
+// Rate limit response headers. X-RateLimit-Reset is the number of seconds until
+// the current window ends.
+const (
+	RateLimitLimitHeader     = "X-RateLimit-Limit"
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+	RateLimitResetHeader     = "X-RateLimit-Reset"
+)
+
+// RateLimit allows each key max requests per window; a nil key limits by
+// client IP and a max of zero or less disables the limit. Every response, the
+// 429 included, carries the X-RateLimit headers so clients can pace
+// themselves; the 429 also carries Retry-After.
+func RateLimit(max int, window time.Duration, key func(*fiber.Ctx) string) fiber.Handler {
+	if max <= 0 {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+	if key == nil {
+		key = func(c *fiber.Ctx) string {
+			return c.IP()
+		}
+	}
+
+	return limiter.New(limiter.Config{
+		Max:          max,
+		Expiration:   window,
+		KeyGenerator: key,
+		LimitReached: func(c *fiber.Ctx) error {
+			// The limiter sets the rate limit headers only on allowed requests;
+			// Retry-After already holds the seconds left in the window
+			c.Set(RateLimitLimitHeader, strconv.Itoa(max))
+			c.Set(RateLimitRemainingHeader, "0")
+			c.Set(RateLimitResetHeader, c.GetRespHeader(fiber.HeaderRetryAfter))
+			return utils.ErrorResponse(c, fiber.StatusTooManyRequests, "Too many requests, please try again later")
+		},
+	})
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRateLimitHeadersCountDownToTheLimit(t *testing.T) {
+	app := fiber.New()
+	app.Get("/public/lookups", RateLimit(3, time.Minute, nil), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	for i, want := range []struct {
+		status    int
+		remaining string
+	}{
+		{fiber.StatusOK, "2"},
+		{fiber.StatusOK, "1"},
+		{fiber.StatusOK, "0"},
+		{fiber.StatusTooManyRequests, "0"},
+	} {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/public/lookups", nil))
+		if err != nil {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+		if resp.StatusCode != want.status {
+			t.Errorf("request %d = %d, want %d", i+1, resp.StatusCode, want.status)
+		}
+		if got := resp.Header.Get(RateLimitLimitHeader); got != "3" {
+			t.Errorf("request %d %s = %q, want 3", i+1, RateLimitLimitHeader, got)
+		}
+		if got := resp.Header.Get(RateLimitRemainingHeader); got != want.remaining {
+			t.Errorf("request %d %s = %q, want %s", i+1, RateLimitRemainingHeader, got, want.remaining)
+		}
+		reset, err := strconv.Atoi(resp.Header.Get(RateLimitResetHeader))
+		if err != nil || reset < 0 || reset > 60 {
+			t.Errorf("request %d %s = %q, want the seconds left in the minute", i+1, RateLimitResetHeader, resp.Header.Get(RateLimitResetHeader))
+		}
+	}
+}
+
+func TestRateLimitOffSetsNoHeaders(t *testing.T) {
+	app := fiber.New()
+	app.Get("/public/lookups", RateLimit(0, time.Minute, nil), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/public/lookups", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK || resp.Header.Get(RateLimitLimitHeader) != "" {
+		t.Errorf("disabled limit = %d with %s %q, want 200 and no headers", resp.StatusCode, RateLimitLimitHeader, resp.Header.Get(RateLimitLimitHeader))
+	}
+}
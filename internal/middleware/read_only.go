@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/automax/backend/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ReadOnlySwitch is a maintenance toggle that can be flipped at runtime. While
+// it is on, Middleware rejects writes and lets reads through.
+type ReadOnlySwitch struct {
+	enabled    atomic.Bool
+	retryAfter time.Duration
+}
+
+// NewReadOnlySwitch returns a switch in the given state whose rejections ask
+// clients to retry after retryAfter
+func NewReadOnlySwitch(enabled bool, retryAfter time.Duration) *ReadOnlySwitch {
+	s := &ReadOnlySwitch{retryAfter: retryAfter}
+	s.enabled.Store(enabled)
+	return s
+}
+
+// Enabled reports whether writes are being rejected
+func (s *ReadOnlySwitch) Enabled() bool {
+	return s.enabled.Load()
+}
+
+// Set turns read-only mode on or off
+func (s *ReadOnlySwitch) Set(enabled bool) {
+	s.enabled.Store(enabled)
+}
+
+// Middleware answers POST, PUT, PATCH and DELETE requests with a 503 and a
+// Retry-After header while the switch is on; other methods pass through. reads
+// lists the route patterns, such as "/api/v1/admin/lookups/categories/batch",
+// whose POSTs only read and so are let through as well; a ":name" segment
+// matches any single path segment.
+func (s *ReadOnlySwitch) Middleware(reads ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !s.Enabled() {
+			return c.Next()
+		}
+		switch c.Method() {
+		case fiber.MethodPost, fiber.MethodPut, fiber.MethodPatch, fiber.MethodDelete:
+			if c.Method() == fiber.MethodPost && matchesAnyRoute(reads, c.Path()) {
+				return c.Next()
+			}
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(s.retryAfter.Seconds())))
+			return utils.ErrorResponse(c, fiber.StatusServiceUnavailable, "Lookups are read-only during maintenance, please try again later")
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func newReadOnlyApp(s *ReadOnlySwitch) *fiber.App {
+	app := fiber.New()
+	lookups := app.Group("/api/v1/admin/lookups", s.Middleware(
+		"/api/v1/admin/lookups/categories/batch",
+		"/api/v1/admin/lookups/categories/:category_id/values/validate",
+	))
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	lookups.Get("/categories", ok)
+	lookups.Post("/categories", ok)
+	lookups.Post("/categories/batch", ok)
+	lookups.Post("/categories/:category_id/values", ok)
+	lookups.Post("/categories/:category_id/values/validate", ok)
+	lookups.Delete("/categories/:category_id", ok)
+	return app
+}
+
+func TestReadOnlyLetsReadPostsThrough(t *testing.T) {
+	app := newReadOnlyApp(NewReadOnlySwitch(true, time.Minute))
+
+	cases := []struct {
+		method, path string
+		want         int
+	}{
+		{fiber.MethodGet, "/api/v1/admin/lookups/categories", fiber.StatusOK},
+		{fiber.MethodPost, "/api/v1/admin/lookups/categories/batch", fiber.StatusOK},
+		{fiber.MethodPost, "/api/v1/admin/lookups/categories/batch/", fiber.StatusOK},
+		{fiber.MethodPost, "/api/v1/admin/lookups/categories/7d7c/values/validate", fiber.StatusOK},
+		{fiber.MethodPost, "/api/v1/admin/lookups/categories", fiber.StatusServiceUnavailable},
+		{fiber.MethodPost, "/api/v1/admin/lookups/categories/7d7c/values", fiber.StatusServiceUnavailable},
+		{fiber.MethodDelete, "/api/v1/admin/lookups/categories/7d7c", fiber.StatusServiceUnavailable},
+	}
+	for _, tc := range cases {
+		resp, err := app.Test(httptest.NewRequest(tc.method, tc.path, nil))
+		if err != nil {
+			t.Fatalf("%s %s: %v", tc.method, tc.path, err)
+		}
+		if resp.StatusCode != tc.want {
+			t.Errorf("%s %s = %d, want %d", tc.method, tc.path, resp.StatusCode, tc.want)
+		}
+		if tc.want == fiber.StatusServiceUnavailable && resp.Header.Get(fiber.HeaderRetryAfter) != "60" {
+			t.Errorf("%s %s Retry-After = %q, want 60", tc.method, tc.path, resp.Header.Get(fiber.HeaderRetryAfter))
+		}
+	}
+}
+
+func TestReadOnlyOffAllowsWrites(t *testing.T) {
+	app := newReadOnlyApp(NewReadOnlySwitch(false, time.Minute))
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodPost, "/api/v1/admin/lookups/categories", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Errorf("POST with read-only off = %d, want 200", resp.StatusCode)
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import "strings"
+
+// matchesAnyRoute reports whether path matches one of the route patterns
+func matchesAnyRoute(patterns []string, path string) bool {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, pattern := range patterns {
+		if matchesRoute(strings.Split(strings.Trim(pattern, "/"), "/"), segments) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesRoute(pattern, segments []string) bool {
+	if len(pattern) != len(segments) {
+		return false
+	}
+	for i, p := range pattern {
+		if strings.HasPrefix(p, ":") {
+			if segments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if p != segments[i] {
+			return false
+		}
+	}
+	return true
+}
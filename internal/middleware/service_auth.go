@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"crypto/subtle"
+
+	"github.com/automax/backend/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ServiceTokenHeader carries the shared secret of internal services such as the API gateway
+const ServiceTokenHeader = "X-Service-Token"
+
+// RequireServiceToken admits requests whose X-Service-Token header matches
+// secret. An empty secret rejects every request, so service-only endpoints stay
+// closed until a credential is configured.
+func RequireServiceToken(secret string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token := c.Get(ServiceTokenHeader)
+		if secret == "" || subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+			return utils.ErrorResponse(c, fiber.StatusUnauthorized, "Invalid service credential")
+		}
+		return c.Next()
+	}
+}
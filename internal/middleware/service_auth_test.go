@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRequireServiceToken(t *testing.T) {
+	for _, tc := range []struct {
+		secret, token string
+		want          int
+	}{
+		{"s3cret", "s3cret", fiber.StatusOK},
+		{"s3cret", "wrong", fiber.StatusUnauthorized},
+		{"s3cret", "", fiber.StatusUnauthorized},
+		{"", "", fiber.StatusUnauthorized},
+	} {
+		app := fiber.New()
+		app.Post("/introspect", RequireServiceToken(tc.secret), func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusOK)
+		})
+		req := httptest.NewRequest(fiber.MethodPost, "/introspect", nil)
+		if tc.token != "" {
+			req.Header.Set(ServiceTokenHeader, tc.token)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("POST /introspect: %v", err)
+		}
+		if resp.StatusCode != tc.want {
+			t.Errorf("secret %q, token %q = %d, want %d", tc.secret, tc.token, resp.StatusCode, tc.want)
+		}
+	}
+}
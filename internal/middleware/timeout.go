@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/automax/backend/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Timeout gives each request a deadline of d on c.UserContext(), which handlers
+// pass down to the repositories, so a slow query is canceled instead of tying
+// up a worker. A handler that fails with context.DeadlineExceeded is answered
+// with a 504; a response the handler already wrote is kept. exempt lists the
+// route patterns, in the form Middleware of ReadOnlySwitch takes, that run
+// without a deadline: uploads, downloads and exports whose time grows with the
+// data moved. A zero d disables the deadline.
+func Timeout(d time.Duration, exempt ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if d <= 0 || matchesAnyRoute(exempt, c.Path()) {
+			return c.Next()
+		}
+
+		ctx, cancel := context.WithTimeout(c.UserContext(), d)
+		defer cancel()
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+		if errors.Is(err, context.DeadlineExceeded) {
+			return utils.ErrorResponse(c, fiber.StatusGatewayTimeout, utils.RequestTimeoutMessage)
+		}
+		return err
+	}
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/automax/backend/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+func newTimeoutApp() *fiber.App {
+	app := fiber.New()
+	app.Use(Timeout(10*time.Millisecond, "/export/:id"))
+
+	// waitForDeadline blocks until the request deadline passes, as a slow
+	// query would
+	waitForDeadline := func(c *fiber.Ctx) error {
+		<-c.UserContext().Done()
+		return c.UserContext().Err()
+	}
+	app.Get("/slow", waitForDeadline)
+	app.Get("/slow-handled", func(c *fiber.Ctx) error {
+		return utils.InternalErrorResponse(c, waitForDeadline(c))
+	})
+	app.Get("/slow-ok", func(c *fiber.Ctx) error {
+		waitForDeadline(c)
+		return c.SendStatus(fiber.StatusOK)
+	})
+	app.Get("/slow-failed", func(c *fiber.Ctx) error {
+		waitForDeadline(c)
+		return fiber.NewError(fiber.StatusConflict, "conflict")
+	})
+	app.Get("/export/:id", func(c *fiber.Ctx) error {
+		if _, ok := c.UserContext().Deadline(); ok {
+			return errors.New("exempt route got a deadline")
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+	return app
+}
+
+func TestTimeoutAnswers504OnlyForDeadlineFailures(t *testing.T) {
+	app := newTimeoutApp()
+
+	cases := []struct {
+		path string
+		want int
+	}{
+		{"/slow", fiber.StatusGatewayTimeout},
+		{"/slow-handled", fiber.StatusGatewayTimeout},
+		{"/slow-ok", fiber.StatusOK},
+		{"/slow-failed", fiber.StatusConflict},
+		{"/export/7", fiber.StatusOK},
+	}
+	for _, tc := range cases {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, tc.path, nil), 1000)
+		if err != nil {
+			t.Fatalf("GET %s: %v", tc.path, err)
+		}
+		if resp.StatusCode != tc.want {
+			t.Errorf("GET %s = %d, want %d", tc.path, resp.StatusCode, tc.want)
+		}
+	}
+}
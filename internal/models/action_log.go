@@ -46,6 +46,12 @@ type ActionLogFilter struct {
 	Limit      int        `json:"limit"`
 }
 
+// ActionLogCursor marks the last row of a keyset page of action logs
+type ActionLogCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
 // ActionLogResponse is the response structure for action logs
 type ActionLogResponse struct {
 	ID          uuid.UUID     `json:"id"`
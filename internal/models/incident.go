@@ -626,7 +626,7 @@ func ToIncidentResponse(i *Incident) IncidentResponse {
 	if len(i.LookupValues) > 0 {
 		resp.LookupValues = make([]LookupValueResponse, len(i.LookupValues))
 		for idx, val := range i.LookupValues {
-			resp.LookupValues[idx] = ToLookupValueResponse(&val)
+			resp.LookupValues[idx] = ToLookupValueResponseWithCategory(&val)
 		}
 	}
 
@@ -1,8 +1,15 @@
 package models
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/automax/backend/pkg/utils"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -10,17 +17,33 @@ import (
 // LookupCategory represents a category of lookup values (e.g., Priority, Severity, Nationality)
 type LookupCategory struct {
 	ID                uuid.UUID      `gorm:"type:uuid;primary_key" json:"id"`
-	Code              string         `gorm:"size:50;uniqueIndex;not null" json:"code"`
+	OrgID             *uuid.UUID     `gorm:"type:uuid;index" json:"org_id"` // nil = global, shared by all tenants
+	Code              string         `gorm:"size:50;not null" json:"code"`  // unique per org, see database.Migrate
 	Name              string         `gorm:"size:100;not null" json:"name"`
 	NameAr            string         `gorm:"size:100" json:"name_ar"`
 	Description       string         `gorm:"size:500" json:"description"`
 	IsSystem          bool           `gorm:"default:false" json:"is_system"`
 	IsActive          bool           `gorm:"default:true" json:"is_active"`
 	AddToIncidentForm bool           `gorm:"default:false" json:"add_to_incident_form"` // New field
+	LockDefault       bool           `gorm:"default:false" json:"lock_default"`         // default value can't be changed while set
+	DefaultSortDesc   bool           `gorm:"default:false" json:"default_sort_desc"`    // list values by descending sort_order, e.g. High→Low
+	ValueCodePattern  string         `gorm:"size:200" json:"value_code_pattern"`        // regular expression every value code must match in full
+	DefaultColor      string         `gorm:"size:50" json:"default_color"`              // theme color when the default value has none
+	ArchivedAt        *time.Time     `json:"archived_at"`                               // set while archived; see LookupRepository.ArchiveCategory
 	Values            []LookupValue  `gorm:"foreignKey:CategoryID" json:"values,omitempty"`
 	CreatedAt         time.Time      `json:"created_at"`
-	UpdatedAt         time.Time      `json:"updated_at"`
+	UpdatedAt         time.Time      `gorm:"index" json:"updated_at"`
 	DeletedAt         gorm.DeletedAt `gorm:"index" json:"-"`
+	// RelatedCategories links logically related categories, e.g. Priority and
+	// SLA. Links are stored in both directions; see LookupRepository.LinkCategories
+	RelatedCategories []LookupCategory `gorm:"many2many:lookup_category_relations;joinForeignKey:CategoryID;joinReferences:RelatedCategoryID;constraint:OnDelete:CASCADE" json:"related_categories,omitempty"`
+	// AllowedColors, when not empty, are the only colors the category's values
+	// may have, normalized with utils.NormalizeColor
+	AllowedColors []string `gorm:"type:jsonb;serializer:json" json:"allowed_colors"`
+	// IncidentFormIntent is whether an admin wants the category on the
+	// incident form. Unlike AddToIncidentForm it survives deactivation, so
+	// reactivating the category puts it back; see SetActive.
+	IncidentFormIntent bool `gorm:"not null;default:false" json:"incident_form_intent"`
 }
 
 func (l *LookupCategory) BeforeCreate(tx *gorm.DB) error {
@@ -30,11 +53,44 @@ func (l *LookupCategory) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// BeforeSave clears the incident form flag on inactive categories, since the
+// incident form only ever loads active ones, and the archive marker on active
+// ones. IncidentFormIntent is left alone so reactivating restores the flag.
+func (l *LookupCategory) BeforeSave(tx *gorm.DB) error {
+	if !l.IsActive {
+		l.AddToIncidentForm = false
+	} else {
+		l.ArchivedAt = nil
+		if l.AddToIncidentForm {
+			l.IncidentFormIntent = true
+		}
+	}
+	return nil
+}
+
+// SetIncidentForm sets whether the category is on the incident form, both the
+// flag and the intent kept while the category is inactive
+func (l *LookupCategory) SetIncidentForm(add bool) {
+	l.AddToIncidentForm = add
+	l.IncidentFormIntent = add
+}
+
+// SetActive activates or deactivates the category. Reactivating an inactive
+// category restores AddToIncidentForm from IncidentFormIntent.
+func (l *LookupCategory) SetActive(active bool) {
+	if active && !l.IsActive {
+		l.AddToIncidentForm = l.IncidentFormIntent
+	}
+	l.IsActive = active
+}
+
 // LookupValue represents a single value in a lookup category
 type LookupValue struct {
 	ID          uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
+	OrgID       *uuid.UUID      `gorm:"type:uuid;index" json:"org_id"` // nil = global, shared by all tenants
 	CategoryID  uuid.UUID       `gorm:"type:uuid;index;not null" json:"category_id"`
 	Category    *LookupCategory `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
+	ParentID    *uuid.UUID      `gorm:"type:uuid;index" json:"parent_id"` // parent value in the same category, for cascading lists
 	Code        string          `gorm:"size:50;not null" json:"code"`
 	Name        string          `gorm:"size:100;not null" json:"name"`
 	NameAr      string          `gorm:"size:100" json:"name_ar"`
@@ -42,10 +98,51 @@ type LookupValue struct {
 	SortOrder   int             `gorm:"default:0" json:"sort_order"`
 	Color       string          `gorm:"size:50" json:"color"`
 	IsDefault   bool            `gorm:"default:false" json:"is_default"`
-	IsActive    bool            `gorm:"default:true" json:"is_active"`
-	CreatedAt   time.Time       `json:"created_at"`
-	UpdatedAt   time.Time       `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt  `gorm:"index" json:"-"`
+	// DefaultWeight makes the value a candidate for a weighted default; see
+	// repository.GetWeightedDefault
+	DefaultWeight int        `gorm:"not null;default:0" json:"default_weight"`
+	IsActive      bool       `gorm:"default:true" json:"is_active"` // kept in sync with Status: true only when active
+	Status        string     `gorm:"size:20;not null;default:active;index" json:"status"`
+	ArchivedAt    *time.Time `json:"archived_at,omitempty"` // set when archiving the category deactivated this value
+	// EffectiveFrom and EffectiveTo bound when the value may be selected, from
+	// inclusive to exclusive; nil leaves that side open
+	EffectiveFrom *time.Time     `gorm:"index" json:"effective_from"`
+	EffectiveTo   *time.Time     `gorm:"index" json:"effective_to"`
+	RequiredRole  string         `gorm:"size:50" json:"required_role"` // only requesters with this role are offered the value; empty for everyone
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `gorm:"index" json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+	// Metadata holds free-form attributes, such as an external system ID,
+	// that don't warrant a column of their own
+	Metadata LookupMetadata `gorm:"type:jsonb;serializer:json" json:"metadata,omitempty"`
+}
+
+// LookupMetadata is the free-form JSON object of a value's metadata
+type LookupMetadata map[string]interface{}
+
+// MaxLookupMetadataBytes caps the encoded size of a value's metadata
+const MaxLookupMetadataBytes = 4096
+
+// ErrLookupMetadataNotObject rejects metadata that is an array or a scalar
+var ErrLookupMetadataNotObject = errors.New("metadata must be a JSON object")
+
+// UnmarshalJSON accepts a JSON object or null and rejects anything else with
+// ErrLookupMetadataNotObject
+func (m *LookupMetadata) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*m = nil
+		return nil
+	}
+	if len(data) == 0 || data[0] != '{' {
+		return ErrLookupMetadataNotObject
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+	*m = object
+	return nil
 }
 
 func (l *LookupValue) BeforeCreate(tx *gorm.DB) error {
@@ -55,6 +152,145 @@ func (l *LookupValue) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// MaxLookupSortOrder is the largest sort_order a value may have
+const MaxLookupSortOrder = 10000
+
+// Lookup value statuses
+const (
+	// LookupValueStatusActive values are offered for new selections
+	LookupValueStatusActive = "active"
+	// LookupValueStatusDeprecated values stay valid on existing records but are
+	// hidden from new selections
+	LookupValueStatusDeprecated = "deprecated"
+	// LookupValueStatusArchived values are retired entirely
+	LookupValueStatusArchived = "archived"
+)
+
+// ErrInvalidLookupValueStatus is returned when a value is saved with a status
+// other than the Lookup value statuses
+var ErrInvalidLookupValueStatus = errors.New("lookup value status must be active, deprecated or archived")
+
+// ValidateLookupValueStatus returns ErrInvalidLookupValueStatus unless status
+// is one of the lookup value statuses
+func ValidateLookupValueStatus(status string) error {
+	switch status {
+	case LookupValueStatusActive, LookupValueStatusDeprecated, LookupValueStatusArchived:
+		return nil
+	}
+	return ErrInvalidLookupValueStatus
+}
+
+// LookupAdminRole is the JWT role that sees every value, whatever role the
+// value requires
+const LookupAdminRole = "admin"
+
+// EffectiveAt reports whether t falls within the value's effective window
+func (l *LookupValue) EffectiveAt(t time.Time) bool {
+	return (l.EffectiveFrom == nil || !t.Before(*l.EffectiveFrom)) &&
+		(l.EffectiveTo == nil || t.Before(*l.EffectiveTo))
+}
+
+// EffectiveColor is the color the value is shown in: its own color, else the
+// default color of category, which may be nil when it is not loaded
+func (l *LookupValue) EffectiveColor(category *LookupCategory) string {
+	if l.Color != "" || category == nil {
+		return l.Color
+	}
+	return category.DefaultColor
+}
+
+// SetStatus changes the status and keeps IsActive in sync
+func (l *LookupValue) SetStatus(status string) {
+	l.Status = status
+	l.IsActive = status == LookupValueStatusActive
+}
+
+// BeforeSave keeps Status and the legacy IsActive flag consistent. Callers that
+// only know about IsActive still work: turning it on activates the value and
+// turning it off archives an active one. An active value also loses the
+// marker left by archiving its category. A status outside the lookup value
+// statuses fails the save.
+func (l *LookupValue) BeforeSave(tx *gorm.DB) error {
+	if l.Status != "" {
+		if err := ValidateLookupValueStatus(l.Status); err != nil {
+			return err
+		}
+	}
+	if l.IsActive {
+		l.ArchivedAt = nil
+	}
+	switch {
+	case l.Status == "":
+		if l.IsActive {
+			l.Status = LookupValueStatusActive
+		} else {
+			l.Status = LookupValueStatusArchived
+		}
+	case l.IsActive && l.Status != LookupValueStatusActive:
+		l.Status = LookupValueStatusActive
+	case !l.IsActive && l.Status == LookupValueStatusActive:
+		l.Status = LookupValueStatusArchived
+	}
+	return nil
+}
+
+// LookupValueAlias maps a legacy or partner code onto a canonical lookup value
+type LookupValueAlias struct {
+	ID        uuid.UUID    `gorm:"type:uuid;primary_key" json:"id"`
+	ValueID   uuid.UUID    `gorm:"type:uuid;not null;uniqueIndex:idx_lookup_value_alias" json:"value_id"`
+	Value     *LookupValue `gorm:"foreignKey:ValueID;constraint:OnDelete:CASCADE" json:"value,omitempty"`
+	AliasCode string       `gorm:"size:50;not null;index;uniqueIndex:idx_lookup_value_alias" json:"alias_code"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+func (a *LookupValueAlias) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// LookupValueTranslation holds a value's name and description in one locale
+// (lowercase BCP 47 tag, e.g. "fr" or "fr-ca"). The base name is English;
+// Arabic also lives on LookupValue.NameAr, which an "ar" translation updates.
+type LookupValueTranslation struct {
+	ID          uuid.UUID    `gorm:"type:uuid;primary_key" json:"id"`
+	ValueID     uuid.UUID    `gorm:"type:uuid;not null;uniqueIndex:idx_lookup_value_translation" json:"value_id"`
+	Value       *LookupValue `gorm:"foreignKey:ValueID;constraint:OnDelete:CASCADE" json:"value,omitempty"`
+	Locale      string       `gorm:"size:35;not null;uniqueIndex:idx_lookup_value_translation" json:"locale"`
+	Name        string       `gorm:"size:100;not null" json:"name"`
+	Description string       `gorm:"size:500" json:"description"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+func (t *LookupValueTranslation) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// LookupTag groups values across categories, e.g. the priority and severity
+// values that are "escalation-relevant"
+type LookupTag struct {
+	ID          uuid.UUID     `gorm:"type:uuid;primary_key" json:"id"`
+	OrgID       *uuid.UUID    `gorm:"type:uuid;index" json:"org_id"` // nil = global, shared by all tenants
+	Code        string        `gorm:"size:50;not null" json:"code"`  // unique per org, see database.Migrate
+	Name        string        `gorm:"size:100;not null" json:"name"`
+	Description string        `gorm:"size:500" json:"description"`
+	Values      []LookupValue `gorm:"many2many:lookup_value_tags;constraint:OnDelete:CASCADE" json:"values,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+func (t *LookupTag) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
 // Request types
 
 // LookupCategoryCreateRequest for creating a new lookup category
@@ -65,6 +301,13 @@ type LookupCategoryCreateRequest struct {
 	Description       string `json:"description" validate:"max=500"`
 	IsActive          *bool  `json:"is_active"`
 	AddToIncidentForm *bool  `json:"add_to_incident_form"`
+	LockDefault       *bool  `json:"lock_default"` // super admins only
+	DefaultSortDesc   *bool  `json:"default_sort_desc"`
+	ValueCodePattern  string `json:"value_code_pattern" validate:"max=200,regexp"`
+	DefaultColor      string `json:"default_color" validate:"max=50"`
+	// AllowedColors restricts the colors of the category's values; empty
+	// allows any color
+	AllowedColors []string `json:"allowed_colors" validate:"max=50,dive,required,max=50"`
 }
 
 // LookupCategoryUpdateRequest for updating a lookup category
@@ -75,30 +318,302 @@ type LookupCategoryUpdateRequest struct {
 	Description       string `json:"description" validate:"max=500"`
 	IsActive          *bool  `json:"is_active"`
 	AddToIncidentForm *bool  `json:"add_to_incident_form"`
+	LockDefault       *bool  `json:"lock_default"` // super admins only
+	DefaultSortDesc   *bool  `json:"default_sort_desc"`
+	// ValueCodePattern replaces the pattern when set; an empty string removes it
+	ValueCodePattern *string `json:"value_code_pattern" validate:"omitempty,max=200,regexp"`
+	// DefaultColor replaces the color when set; an empty string removes it
+	DefaultColor *string `json:"default_color" validate:"omitempty,max=50"`
+	// AllowedColors replaces the palette when set; an empty array removes it
+	AllowedColors *[]string `json:"allowed_colors" validate:"omitempty,max=50,dive,required,max=50"`
+}
+
+// LookupCategoryUpsertRequest declares a category by code; the body holds the
+// desired state of its mutable fields
+type LookupCategoryUpsertRequest struct {
+	Name              string  `json:"name" validate:"required,min=1,max=100"`
+	NameAr            string  `json:"name_ar" validate:"max=100"`
+	Description       string  `json:"description" validate:"max=500"`
+	IsActive          *bool   `json:"is_active"`
+	AddToIncidentForm *bool   `json:"add_to_incident_form"`
+	DefaultSortDesc   *bool   `json:"default_sort_desc"`
+	ValueCodePattern  *string `json:"value_code_pattern" validate:"omitempty,max=200,regexp"`
+	DefaultColor      *string `json:"default_color" validate:"omitempty,max=50"`
+	// AllowedColors replaces the palette when set; an empty array removes it
+	AllowedColors *[]string `json:"allowed_colors" validate:"omitempty,max=50,dive,required,max=50"`
 }
 
 // LookupValueCreateRequest for creating a new lookup value
 type LookupValueCreateRequest struct {
-	Code        string `json:"code" validate:"required,min=1,max=50"`
-	Name        string `json:"name" validate:"required,min=1,max=100"`
-	NameAr      string `json:"name_ar" validate:"max=100"`
-	Description string `json:"description" validate:"max=500"`
-	SortOrder   int    `json:"sort_order"`
-	Color       string `json:"color" validate:"max=50"`
-	IsDefault   bool   `json:"is_default"`
-	IsActive    *bool  `json:"is_active"`
+	// ID lets offline clients pick the value's ID so a retried sync is
+	// recognized; omit it to have one generated
+	ID          string     `json:"id" validate:"omitempty,uuid"`
+	Code        string     `json:"code" validate:"required,min=1,max=50"`
+	Name        string     `json:"name" validate:"required,min=1,max=100"`
+	NameAr      string     `json:"name_ar" validate:"max=100"`
+	Description string     `json:"description" validate:"max=500"`
+	SortOrder   *int       `json:"sort_order" validate:"omitempty,min=0,max=10000"` // nil appends the value after the category's last one
+	ParentID    *uuid.UUID `json:"parent_id"`
+	Color       string     `json:"color" validate:"max=50"`
+	IsDefault   *bool      `json:"is_default"` // nil is false for a new value; a bulk upsert keeps an existing value's flag
+	IsActive    *bool      `json:"is_active"`
+	Status      string     `json:"status" validate:"omitempty,oneof=active deprecated archived"` // overrides is_active when set
+	// DefaultWeight > 0 makes the value a weighted default candidate
+	DefaultWeight int        `json:"default_weight" validate:"min=0,max=1000"`
+	EffectiveFrom *time.Time `json:"effective_from"`
+	EffectiveTo   *time.Time `json:"effective_to"`
+	RequiredRole  string     `json:"required_role" validate:"max=50"` // restricts the value to requesters with this role
+	// Metadata is a JSON object of free-form attributes
+	Metadata json.RawMessage `json:"metadata"`
+}
+
+// WantsDefault reports whether the request makes the value the default
+func (r *LookupValueCreateRequest) WantsDefault() bool {
+	return r.IsDefault != nil && *r.IsDefault
 }
 
 // LookupValueUpdateRequest for updating a lookup value
 type LookupValueUpdateRequest struct {
-	Code        string `json:"code" validate:"max=50"`
-	Name        string `json:"name" validate:"max=100"`
-	NameAr      string `json:"name_ar" validate:"max=100"`
+	Code        string     `json:"code" validate:"max=50"`
+	Name        string     `json:"name" validate:"max=100"`
+	NameAr      string     `json:"name_ar" validate:"max=100"`
+	Description string     `json:"description" validate:"max=500"`
+	SortOrder   *int       `json:"sort_order" validate:"omitempty,min=0,max=10000"`
+	ParentID    *uuid.UUID `json:"parent_id"`
+	Color       string     `json:"color" validate:"max=50"`
+	IsDefault   *bool      `json:"is_default"`
+	IsActive    *bool      `json:"is_active"`
+	Status      string     `json:"status" validate:"omitempty,oneof=active deprecated archived"` // overrides is_active when set
+	// DefaultWeight > 0 makes the value a weighted default candidate; 0 removes it
+	DefaultWeight *int       `json:"default_weight" validate:"omitempty,min=0,max=1000"`
+	EffectiveFrom *time.Time `json:"effective_from"`
+	EffectiveTo   *time.Time `json:"effective_to"`
+	RequiredRole  *string    `json:"required_role" validate:"omitempty,max=50"` // "" lifts the restriction
+	// Metadata replaces the metadata object when set; null removes it
+	Metadata json.RawMessage `json:"metadata"`
+}
+
+// LookupValuePatch is the patchable state of a value, the document a JSON merge
+// patch on PATCH /values/:value_id applies to. Strings that are not required
+// and the parent_id and effective dates can be cleared with null.
+type LookupValuePatch struct {
+	Code          string     `json:"code" validate:"required,min=1,max=50"`
+	Name          string     `json:"name" validate:"required,min=1,max=100"`
+	NameAr        string     `json:"name_ar" validate:"max=100"`
+	Description   string     `json:"description" validate:"max=500"`
+	SortOrder     int        `json:"sort_order" validate:"min=0,max=10000"`
+	ParentID      *uuid.UUID `json:"parent_id"`
+	Color         string     `json:"color" validate:"max=50"`
+	IsDefault     bool       `json:"is_default"`
+	DefaultWeight int        `json:"default_weight" validate:"min=0,max=1000"`
+	IsActive      bool       `json:"is_active"`
+	Status        string     `json:"status" validate:"required,oneof=active deprecated archived"`
+	EffectiveFrom *time.Time `json:"effective_from"`
+	EffectiveTo   *time.Time `json:"effective_to"`
+	RequiredRole  string     `json:"required_role" validate:"max=50"`
+	// Metadata is merged member by member, as the merge patch rules have it
+	Metadata LookupMetadata `json:"metadata"`
+}
+
+// NewLookupValuePatch returns the patchable state of v
+func NewLookupValuePatch(v *LookupValue) LookupValuePatch {
+	return LookupValuePatch{
+		Code:          v.Code,
+		Name:          v.Name,
+		NameAr:        v.NameAr,
+		Description:   v.Description,
+		SortOrder:     v.SortOrder,
+		ParentID:      v.ParentID,
+		Color:         v.Color,
+		IsDefault:     v.IsDefault,
+		DefaultWeight: v.DefaultWeight,
+		IsActive:      v.IsActive,
+		Status:        v.Status,
+		EffectiveFrom: v.EffectiveFrom,
+		EffectiveTo:   v.EffectiveTo,
+		RequiredRole:  v.RequiredRole,
+		Metadata:      v.Metadata,
+	}
+}
+
+// LookupValueAliasCreateRequest for registering an alias code on a value
+type LookupValueAliasCreateRequest struct {
+	AliasCode string `json:"alias_code" validate:"required,min=1,max=50"`
+}
+
+// LookupValueTranslationRequest sets a value's name and description in the
+// locale named by the path
+type LookupValueTranslationRequest struct {
+	Name        string `json:"name" validate:"required,min=1,max=100"`
 	Description string `json:"description" validate:"max=500"`
-	SortOrder   *int   `json:"sort_order"`
-	Color       string `json:"color" validate:"max=50"`
-	IsDefault   *bool  `json:"is_default"`
-	IsActive    *bool  `json:"is_active"`
+}
+
+// LookupTagCreateRequest for creating a new tag
+type LookupTagCreateRequest struct {
+	Code        string `json:"code" validate:"required,min=1,max=50"`
+	Name        string `json:"name" validate:"required,min=1,max=100"`
+	Description string `json:"description" validate:"max=500"`
+}
+
+// LookupCategoryLinkRequest for linking a related category
+type LookupCategoryLinkRequest struct {
+	RelatedCategoryID uuid.UUID `json:"related_category_id" validate:"required"`
+}
+
+// LookupTagValuesRequest for attaching values to a tag
+type LookupTagValuesRequest struct {
+	ValueIDs []uuid.UUID `json:"value_ids" validate:"required,min=1,max=500"`
+}
+
+// LookupIncidentFormCheck holds the fields a category must have filled in before
+// it can appear on the bilingual incident form
+type LookupIncidentFormCheck struct {
+	NameAr string `json:"name_ar" validate:"required"`
+}
+
+// LookupValueBulkRequest for creating or upserting many values of a category at once
+type LookupValueBulkRequest struct {
+	Values []LookupValueCreateRequest `json:"values" validate:"required,min=1,max=500,dive"`
+}
+
+// LookupCategoryApplyRequest is a whole admin save of a category, applied in
+// one transaction: the category's own fields, then deletes, updates and
+// creates of its values. Order, when set, lists value codes as they stand
+// after the change set in their new order; values it leaves out follow in
+// their current order.
+type LookupCategoryApplyRequest struct {
+	Category *LookupCategoryUpdateRequest `json:"category"`
+	Create   []LookupValueCreateRequest   `json:"create" validate:"max=500,dive"`
+	Update   []LookupValueApplyUpdate     `json:"update" validate:"max=500,dive"`
+	Delete   []uuid.UUID                  `json:"delete" validate:"max=500"`
+	Order    []string                     `json:"order" validate:"max=1000,dive,required,max=50"`
+}
+
+// LookupValueApplyUpdate updates the value with ID as part of a
+// LookupCategoryApplyRequest; the other fields work as in a PUT
+type LookupValueApplyUpdate struct {
+	ID uuid.UUID `json:"id" validate:"required"`
+	LookupValueUpdateRequest
+}
+
+// LookupCategoriesActiveRequest for switching several categories on or off at once
+type LookupCategoriesActiveRequest struct {
+	IDs      []uuid.UUID `json:"ids" validate:"required,min=1,max=500"`
+	IsActive *bool       `json:"is_active" validate:"required"`
+}
+
+// LookupCategoriesIncidentFormRequest puts several categories on the incident
+// form or takes them off at once
+type LookupCategoriesIncidentFormRequest struct {
+	IDs               []uuid.UUID `json:"ids" validate:"required,min=1,max=500"`
+	AddToIncidentForm *bool       `json:"add_to_incident_form" validate:"required"`
+}
+
+// LookupCategoryBatchRequest names up to 200 categories to fetch in one call
+type LookupCategoryBatchRequest struct {
+	IDs []uuid.UUID `json:"ids" validate:"required,min=1,max=200"`
+}
+
+// Orphan cleanup actions
+const (
+	LookupOrphanDelete   = "delete"
+	LookupOrphanReassign = "reassign"
+)
+
+// LookupOrphanCleanupRequest deletes orphaned values or moves them into
+// CategoryID. Without IDs every orphan visible to the caller is cleaned up.
+type LookupOrphanCleanupRequest struct {
+	Action     string      `json:"action" validate:"required,oneof=delete reassign"`
+	CategoryID *uuid.UUID  `json:"category_id" validate:"required_if=Action reassign"`
+	IDs        []uuid.UUID `json:"ids" validate:"omitempty,max=500"`
+}
+
+// LookupValueRestoreRequest restores soft-deleted values of a category: those
+// listed in IDs, or every deleted value with All
+type LookupValueRestoreRequest struct {
+	IDs []uuid.UUID `json:"ids" validate:"required_without=All,excluded_with=All,max=500"`
+	All bool        `json:"all"`
+}
+
+// LookupValueMoveRequest moves values of one category into TargetCategoryID
+type LookupValueMoveRequest struct {
+	ValueIDs         []uuid.UUID `json:"value_ids" validate:"required,min=1,max=200"`
+	TargetCategoryID uuid.UUID   `json:"target_category_id" validate:"required"`
+}
+
+// LookupValueCloneRequest names the copy made of a value
+type LookupValueCloneRequest struct {
+	Code string `json:"code" validate:"required,min=1,max=50"`
+	Name string `json:"name" validate:"required,min=1,max=100"`
+}
+
+// LookupSetDefaultRequest optionally names the category the value is expected
+// to belong to; the request fails instead of touching another category
+type LookupSetDefaultRequest struct {
+	CategoryID *uuid.UUID `json:"category_id"`
+}
+
+// LookupDefaultTransferRequest names the value of another category that takes
+// over as default when a category's default is cleared
+type LookupDefaultTransferRequest struct {
+	ToCategoryID uuid.UUID `json:"to_category_id" validate:"required"`
+	ValueCode    string    `json:"value_code" validate:"required,max=50"`
+}
+
+// LookupDefaultTransferResponse reports a default transfer
+type LookupDefaultTransferResponse struct {
+	SourceCategoryID uuid.UUID           `json:"source_category_id"`
+	Cleared          int64               `json:"cleared"` // defaults cleared in the source category
+	Default          LookupValueResponse `json:"default"` // the target category's new default
+}
+
+// LookupDefaultAssignment names a value that should become the default of its category
+type LookupDefaultAssignment struct {
+	CategoryCode string `json:"category_code" validate:"required"`
+	ValueCode    string `json:"value_code" validate:"required"`
+}
+
+// MaxLookupResolvePairs caps the pairs of one batch resolve request
+const MaxLookupResolvePairs = 5000
+
+// LookupCodePair names a value by its category code and its code or alias
+type LookupCodePair struct {
+	CategoryCode string `json:"category_code" validate:"required,max=50"`
+	ValueCode    string `json:"value_code" validate:"required,max=50"`
+}
+
+// LookupRequestTypes lists the lookup request bodies published as JSON Schema,
+// keyed by schema name.
+func LookupRequestTypes() map[string]interface{} {
+	return map[string]interface{}{
+		"LookupCategoryCreateRequest":   LookupCategoryCreateRequest{},
+		"LookupCategoryUpdateRequest":   LookupCategoryUpdateRequest{},
+		"LookupCategoryUpsertRequest":   LookupCategoryUpsertRequest{},
+		"LookupValueCreateRequest":      LookupValueCreateRequest{},
+		"LookupValueUpdateRequest":      LookupValueUpdateRequest{},
+		"LookupValuePatch":              LookupValuePatch{},
+		"LookupValueAliasCreateRequest": LookupValueAliasCreateRequest{},
+		"LookupValueTranslationRequest": LookupValueTranslationRequest{},
+		"LookupValueBulkRequest":        LookupValueBulkRequest{},
+		"LookupDefaultAssignment":       LookupDefaultAssignment{},
+		"LookupCategoriesActiveRequest": LookupCategoriesActiveRequest{},
+		"LookupTagCreateRequest":        LookupTagCreateRequest{},
+		"LookupTagValuesRequest":        LookupTagValuesRequest{},
+		"LookupCodePair":                LookupCodePair{},
+		"LookupOrphanCleanupRequest":    LookupOrphanCleanupRequest{},
+
+		"LookupTemplateInstantiateRequest": LookupTemplateInstantiateRequest{},
+		"LookupCategoryBatchRequest":       LookupCategoryBatchRequest{},
+		"LookupValueMoveRequest":           LookupValueMoveRequest{},
+		"LookupCategoryLinkRequest":        LookupCategoryLinkRequest{},
+		"LookupValueCloneRequest":          LookupValueCloneRequest{},
+
+		"LookupCategoriesIncidentFormRequest": LookupCategoriesIncidentFormRequest{},
+		"LookupDefaultTransferRequest":        LookupDefaultTransferRequest{},
+		"LookupReadOnlyRequest":               LookupReadOnlyRequest{},
+		"LookupValueRestoreRequest":           LookupValueRestoreRequest{},
+		"LookupCategoryApplyRequest":          LookupCategoryApplyRequest{},
+	}
 }
 
 // Response types
@@ -106,6 +621,7 @@ type LookupValueUpdateRequest struct {
 // LookupCategoryResponse for API responses
 type LookupCategoryResponse struct {
 	ID                uuid.UUID             `json:"id"`
+	OrgID             *uuid.UUID            `json:"org_id"`
 	Code              string                `json:"code"`
 	Name              string                `json:"name"`
 	NameAr            string                `json:"name_ar"`
@@ -113,33 +629,663 @@ type LookupCategoryResponse struct {
 	IsSystem          bool                  `json:"is_system"`
 	IsActive          bool                  `json:"is_active"`
 	AddToIncidentForm bool                  `json:"add_to_incident_form"`
+	LockDefault       bool                  `json:"lock_default"`
+	DefaultSortDesc   bool                  `json:"default_sort_desc"`
+	ValueCodePattern  string                `json:"value_code_pattern,omitempty"`
+	DefaultColor      string                `json:"default_color"`
+	ThemeColor        string                `json:"theme_color"` // the default value's color, else DefaultColor
+	AllowedColors     []string              `json:"allowed_colors,omitempty"`
+	ArchivedAt        *time.Time            `json:"archived_at,omitempty"`
 	ValuesCount       int                   `json:"values_count"`
 	Values            []LookupValueResponse `json:"values,omitempty"`
 	CreatedAt         time.Time             `json:"created_at"`
 	UpdatedAt         time.Time             `json:"updated_at"`
+	// IncidentFormIntent is kept while the category is inactive; see
+	// LookupCategory.IncidentFormIntent
+	IncidentFormIntent bool `json:"incident_form_intent"`
 }
 
 // LookupValueResponse for API responses
 type LookupValueResponse struct {
-	ID          uuid.UUID                `json:"id"`
-	CategoryID  uuid.UUID                `json:"category_id"`
-	Category    *LookupCategoryResponse  `json:"category,omitempty"`
-	Code        string                   `json:"code"`
-	Name        string                   `json:"name"`
-	NameAr      string                   `json:"name_ar"`
-	Description string                   `json:"description"`
-	SortOrder   int                      `json:"sort_order"`
-	Color       string                   `json:"color"`
-	IsDefault   bool                     `json:"is_default"`
-	IsActive    bool                     `json:"is_active"`
-	CreatedAt   time.Time                `json:"created_at"`
-	UpdatedAt   time.Time                `json:"updated_at"`
+	ID           uuid.UUID  `json:"id"`
+	OrgID        *uuid.UUID `json:"org_id"`
+	CategoryID   uuid.UUID  `json:"category_id"`
+	CategoryCode string     `json:"category_code,omitempty"`
+	CategoryName string     `json:"category_name,omitempty"`
+	// IsSystemCategory is taken from the preloaded category so clients can lock
+	// controls without fetching the category separately
+	IsSystemCategory bool                    `json:"is_system_category"`
+	Category         *LookupCategoryResponse `json:"category,omitempty"`
+	ParentID         *uuid.UUID              `json:"parent_id"`
+	Code             string                  `json:"code"`
+	Name             string                  `json:"name"`
+	NameAr           string                  `json:"name_ar"`
+	Description      string                  `json:"description"`
+	// Locale is set when name and description were replaced by a translation
+	// picked from Accept-Language
+	Locale        string     `json:"locale,omitempty"`
+	SortOrder     int        `json:"sort_order"`
+	Color         string     `json:"color"`
+	IsDefault     bool       `json:"is_default"`
+	DefaultWeight int        `json:"default_weight"`
+	IsActive      bool       `json:"is_active"`
+	Status        string     `json:"status"`
+	EffectiveFrom *time.Time `json:"effective_from"`
+	EffectiveTo   *time.Time `json:"effective_to"`
+	RequiredRole  string     `json:"required_role"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	// EffectiveColor is Color, else the category's default color; it is left
+	// empty when the category was not loaded
+	EffectiveColor string `json:"effective_color,omitempty"`
+	// Metadata is the value's free-form attributes
+	Metadata LookupMetadata `json:"metadata,omitempty"`
+}
+
+// CompactLookupValueResponse is the ?view=compact shape of a value, a stable
+// minimal contract for bandwidth-constrained clients such as mobile
+type CompactLookupValueResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	Color     string    `json:"color"`
+	IsDefault bool      `json:"is_default"`
+}
+
+// LookupIncidentFormCategory is a category as the incident form receives it
+type LookupIncidentFormCategory struct {
+	ID         uuid.UUID                 `json:"id"`
+	Code       string                    `json:"code"`
+	Name       string                    `json:"name"`
+	NameAr     string                    `json:"name_ar"`
+	ThemeColor string                    `json:"theme_color"`
+	Values     []LookupIncidentFormValue `json:"values"`
+}
+
+// LookupIncidentFormValue is a selectable value on the incident form
+type LookupIncidentFormValue struct {
+	ID        uuid.UUID  `json:"id"`
+	ParentID  *uuid.UUID `json:"parent_id"`
+	Code      string     `json:"code"`
+	Name      string     `json:"name"`
+	NameAr    string     `json:"name_ar"`
+	Color     string     `json:"color"`
+	IsDefault bool       `json:"is_default"`
+}
+
+// ToLookupIncidentFormCategory builds the incident form shape of a category
+// from its preloaded values, keeping those active and effective at now in
+// their current order. The public incident form endpoint and the admin
+// preview both use it, so the preview matches what the form gets.
+func ToLookupIncidentFormCategory(c *LookupCategory, now time.Time) LookupIncidentFormCategory {
+	form := LookupIncidentFormCategory{
+		ID:         c.ID,
+		Code:       c.Code,
+		Name:       c.Name,
+		NameAr:     c.NameAr,
+		ThemeColor: c.DefaultColor,
+		Values:     []LookupIncidentFormValue{},
+	}
+	for i := range c.Values {
+		v := &c.Values[i]
+		if !v.IsActive || !v.EffectiveAt(now) {
+			continue
+		}
+		if v.IsDefault && v.Color != "" {
+			form.ThemeColor = v.Color
+		}
+		form.Values = append(form.Values, LookupIncidentFormValue{
+			ID:        v.ID,
+			ParentID:  v.ParentID,
+			Code:      v.Code,
+			Name:      v.Name,
+			NameAr:    v.NameAr,
+			Color:     v.Color,
+			IsDefault: v.IsDefault,
+		})
+	}
+	return form
+}
+
+// CompactLookupCategoryResponse is the ?view=compact shape of a category;
+// Values is omitted when the full response has none
+type CompactLookupCategoryResponse struct {
+	ID     uuid.UUID                    `json:"id"`
+	Code   string                       `json:"code"`
+	Name   string                       `json:"name"`
+	Values []CompactLookupValueResponse `json:"values,omitempty"`
+}
+
+// LookupDefaultValueResponse is the default value of a category. IsFallback is
+// true when no active default is configured and the first active value by
+// sort order was returned instead. IsWeighted is true when the value was drawn
+// from the category's weighted candidates, so another request may differ.
+type LookupDefaultValueResponse struct {
+	LookupValueResponse
+	IsFallback bool `json:"is_fallback"`
+	IsWeighted bool `json:"is_weighted"`
+}
+
+// Reasons a LookupCodeResolution has no value ID
+const (
+	LookupResolveCategoryNotFound = "category_not_found"
+	LookupResolveCategoryInactive = "category_inactive"
+	LookupResolveValueNotFound    = "value_not_found"
+	LookupResolveValueInactive    = "value_inactive"
+)
+
+// LookupCodeResolution is the outcome of resolving one LookupCodePair. ValueID
+// is null when the pair does not name an active value, and Reason says why.
+type LookupCodeResolution struct {
+	CategoryCode string     `json:"category_code"`
+	ValueCode    string     `json:"value_code"`
+	ValueID      *uuid.UUID `json:"value_id"`
+	// ViaAlias is set when ValueCode matched an alias rather than the code
+	ViaAlias bool   `json:"via_alias,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// LookupValueShareResponse is a share link for a value. Path resolves to the
+// value without authentication until ExpiresAt.
+type LookupValueShareResponse struct {
+	Token     string    `json:"token"`
+	Path      string    `json:"path"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// LookupBulkValuesResponse for bulk value operations. The parent category is
+// included once, on request, instead of being embedded in every value.
+type LookupBulkValuesResponse struct {
+	Category *LookupCategoryResponse `json:"category,omitempty"`
+	Created  []LookupValueResponse   `json:"created"`
+	Updated  []LookupValueResponse   `json:"updated"`
+}
+
+// LookupValueCheck is the validation outcome of one proposed value; Index is
+// its position in the request
+type LookupValueCheck struct {
+	Index  int                     `json:"index"`
+	Code   string                  `json:"code"`
+	Valid  bool                    `json:"valid"`
+	Errors []utils.ValidationError `json:"errors"`
+}
+
+// LookupValueSetValidation reports a dry validation of a proposed value set.
+// Items holds the per-value checks and SetErrors the problems of the set as a
+// whole, such as duplicate codes or several defaults.
+type LookupValueSetValidation struct {
+	Valid     bool               `json:"valid"`
+	Items     []LookupValueCheck `json:"items"`
+	SetErrors []string           `json:"set_errors"`
+}
+
+// LookupDefaultResult reports the outcome of one assignment in a batch
+// default update. Error is set on the pairs that caused the batch to fail.
+type LookupDefaultResult struct {
+	CategoryCode      string     `json:"category_code"`
+	ValueCode         string     `json:"value_code"`
+	ValueID           *uuid.UUID `json:"value_id,omitempty"`
+	PreviousDefaultID *uuid.UUID `json:"previous_default_id,omitempty"`
+	Error             string     `json:"error,omitempty"`
+}
+
+// LookupCategoriesActiveResult reports a bulk activation toggle. System
+// categories, and global ones for tenants, are never toggled and are listed
+// under Skipped.
+type LookupCategoriesActiveResult struct {
+	Updated  int64       `json:"updated"`
+	Skipped  []uuid.UUID `json:"skipped"`
+	NotFound []uuid.UUID `json:"not_found"`
+}
+
+// LookupIncidentFormSkip is a category a bulk incident form update left alone
+type LookupIncidentFormSkip struct {
+	ID     uuid.UUID `json:"id"`
+	Code   string    `json:"code"`
+	Reason string    `json:"reason"`
+}
+
+// LookupCategoriesIncidentFormResult reports a bulk incident form update
+type LookupCategoriesIncidentFormResult struct {
+	Updated  []uuid.UUID              `json:"updated"`
+	Skipped  []LookupIncidentFormSkip `json:"skipped"`
+	NotFound []uuid.UUID              `json:"not_found"`
+}
+
+// LookupCategoryBatchResponse holds the categories of a batch fetch in request
+// order, and the requested ids that matched no category
+type LookupCategoryBatchResponse struct {
+	Categories []LookupCategoryResponse `json:"categories"`
+	Missing    []uuid.UUID              `json:"missing"`
+}
+
+// LookupOrphanCleanupResult counts the orphaned values deleted or reassigned.
+// Skipped lists orphans left alone because the target category already has a
+// value with their code.
+type LookupOrphanCleanupResult struct {
+	Action   string      `json:"action"`
+	Affected int64       `json:"affected"`
+	Skipped  []uuid.UUID `json:"skipped"`
+}
+
+// Reasons a restore leaves a requested value in the trash
+const (
+	LookupRestoreCodeTaken   = "code_taken"   // a live value of the category holds the code
+	LookupRestoreNotDeleted  = "not_deleted"  // the ID is not a deleted value of the category
+	LookupRestoreNotWritable = "not_writable" // the value is global and the requester isn't a super admin
+)
+
+// LookupValueRestoreSkip is a value a restore left alone
+type LookupValueRestoreSkip struct {
+	ID     uuid.UUID `json:"id"`
+	Code   string    `json:"code,omitempty"`
+	Reason string    `json:"reason"`
+}
+
+// LookupValueRestoreResult lists the values a restore brought back and those
+// it skipped
+type LookupValueRestoreResult struct {
+	Restored []LookupValueResponse    `json:"restored"`
+	Skipped  []LookupValueRestoreSkip `json:"skipped"`
+}
+
+// LookupArchiveResult is the category after an archive or unarchive, with the
+// number of values deactivated or reactivated along with it
+type LookupArchiveResult struct {
+	Category LookupCategoryResponse `json:"category"`
+	Values   int64                  `json:"values"`
+}
+
+// LookupCategoryTouchResponse is a category's updated_at after a touch
+type LookupCategoryTouchResponse struct {
+	ID        uuid.UUID `json:"id"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LookupValueAliasResponse for API responses
+type LookupValueAliasResponse struct {
+	ID        uuid.UUID `json:"id"`
+	ValueID   uuid.UUID `json:"value_id"`
+	AliasCode string    `json:"alias_code"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ToLookupValueAliasResponse converts a LookupValueAlias to LookupValueAliasResponse
+func ToLookupValueAliasResponse(a *LookupValueAlias) LookupValueAliasResponse {
+	return LookupValueAliasResponse{
+		ID:        a.ID,
+		ValueID:   a.ValueID,
+		AliasCode: a.AliasCode,
+		CreatedAt: a.CreatedAt,
+	}
+}
+
+// LookupValueTranslationResponse for API responses
+type LookupValueTranslationResponse struct {
+	ValueID     uuid.UUID `json:"value_id"`
+	Locale      string    `json:"locale"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ToLookupValueTranslationResponse converts a LookupValueTranslation to LookupValueTranslationResponse
+func ToLookupValueTranslationResponse(t *LookupValueTranslation) LookupValueTranslationResponse {
+	return LookupValueTranslationResponse{
+		ValueID:     t.ValueID,
+		Locale:      t.Locale,
+		Name:        t.Name,
+		Description: t.Description,
+		UpdatedAt:   t.UpdatedAt,
+	}
+}
+
+// LookupCategorySummary identifies a category without its values, e.g. in the
+// list of related categories
+type LookupCategorySummary struct {
+	ID       uuid.UUID `json:"id"`
+	Code     string    `json:"code"`
+	Name     string    `json:"name"`
+	NameAr   string    `json:"name_ar"`
+	IsSystem bool      `json:"is_system"`
+	IsActive bool      `json:"is_active"`
+}
+
+func ToLookupCategorySummary(c *LookupCategory) LookupCategorySummary {
+	return LookupCategorySummary{
+		ID:       c.ID,
+		Code:     c.Code,
+		Name:     c.Name,
+		NameAr:   c.NameAr,
+		IsSystem: c.IsSystem,
+		IsActive: c.IsActive,
+	}
+}
+
+// LookupUngroupedPrefix is the group of categories whose code has no prefix
+const LookupUngroupedPrefix = "ungrouped"
+
+// LookupCategoryGroup lists the categories sharing a code prefix, the part of
+// the code before its first underscore
+type LookupCategoryGroup struct {
+	Prefix     string                  `json:"prefix"`
+	Categories []LookupCategorySummary `json:"categories"`
+}
+
+// LookupCategoryTreeGroup is one group of the lookup tree: the categories
+// sharing a code prefix, each with its values
+type LookupCategoryTreeGroup struct {
+	Prefix     string                   `json:"prefix"`
+	Categories []LookupCategoryResponse `json:"categories"`
+}
+
+// GroupLookupCategories groups categories by code prefix, keeping their order
+// within each group. Groups are sorted by prefix, with the categories whose
+// code has no prefix last under LookupUngroupedPrefix.
+func GroupLookupCategories(categories []LookupCategory) []LookupCategoryGroup {
+	groups := []LookupCategoryGroup{}
+	groupByCodePrefix(categories, ToLookupCategorySummary, func(prefix string, members []LookupCategorySummary) {
+		groups = append(groups, LookupCategoryGroup{Prefix: prefix, Categories: members})
+	})
+	return groups
+}
+
+// BuildLookupCategoryTree groups categories by code prefix like
+// GroupLookupCategories, with each category's preloaded values included
+func BuildLookupCategoryTree(categories []LookupCategory) []LookupCategoryTreeGroup {
+	groups := []LookupCategoryTreeGroup{}
+	groupByCodePrefix(categories, ToLookupCategoryResponse, func(prefix string, members []LookupCategoryResponse) {
+		groups = append(groups, LookupCategoryTreeGroup{Prefix: prefix, Categories: members})
+	})
+	return groups
+}
+
+// groupByCodePrefix converts categories and passes them to add one group at a
+// time, in the order GroupLookupCategories documents
+func groupByCodePrefix[T any](categories []LookupCategory, convert func(*LookupCategory) T, add func(prefix string, members []T)) {
+	byPrefix := map[string][]T{}
+	var prefixes []string
+	var ungrouped []T
+	for i := range categories {
+		member := convert(&categories[i])
+		prefix, _, found := strings.Cut(categories[i].Code, "_")
+		if !found || prefix == "" {
+			ungrouped = append(ungrouped, member)
+			continue
+		}
+		if _, ok := byPrefix[prefix]; !ok {
+			prefixes = append(prefixes, prefix)
+		}
+		byPrefix[prefix] = append(byPrefix[prefix], member)
+	}
+
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		add(prefix, byPrefix[prefix])
+	}
+	if len(ungrouped) > 0 {
+		add(LookupUngroupedPrefix, ungrouped)
+	}
+}
+
+// LookupTagResponse for API responses
+type LookupTagResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	OrgID       *uuid.UUID `json:"org_id"`
+	Code        string     `json:"code"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func ToLookupTagResponse(t *LookupTag) LookupTagResponse {
+	return LookupTagResponse{
+		ID:          t.ID,
+		OrgID:       t.OrgID,
+		Code:        t.Code,
+		Name:        t.Name,
+		Description: t.Description,
+		CreatedAt:   t.CreatedAt,
+		UpdatedAt:   t.UpdatedAt,
+	}
+}
+
+// LookupTagValuesResult reports an attach request; values that don't exist or
+// aren't visible to the requester are listed under NotFound
+type LookupTagValuesResult struct {
+	Attached int         `json:"attached"`
+	NotFound []uuid.UUID `json:"not_found"`
+}
+
+// LookupStats holds the lookup totals shown on the admin dashboard
+type LookupStats struct {
+	TotalCategories    int64 `json:"total_categories"`
+	ActiveCategories   int64 `json:"active_categories"`
+	InactiveCategories int64 `json:"inactive_categories"`
+	SystemCategories   int64 `json:"system_categories"`
+	CustomCategories   int64 `json:"custom_categories"`
+	IncidentFormCount  int64 `json:"incident_form_categories"`
+	TotalValues        int64 `json:"total_values"`
+}
+
+// LookupCategoryUsage counts what references the values of a category
+type LookupCategoryUsage struct {
+	Incidents   int64 `json:"incidents"`     // incidents using any of its values
+	ValuesInUse int64 `json:"values_in_use"` // its values used by an incident
+}
+
+// LookupCategoryDeletePreview describes what deleting a category would remove
+type LookupCategoryDeletePreview struct {
+	CategoryID       uuid.UUID           `json:"category_id"`
+	Code             string              `json:"code"`
+	CanDelete        bool                `json:"can_delete"` // false for system categories
+	Values           int                 `json:"values"`
+	ActiveValues     int                 `json:"active_values"`
+	HasDefault       bool                `json:"has_default"`
+	DefaultValueCode string              `json:"default_value_code,omitempty"`
+	Usage            LookupCategoryUsage `json:"usage"`
+}
+
+// NewLookupCategoryDeletePreview builds the delete preview of a category with
+// preloaded values
+func NewLookupCategoryDeletePreview(c *LookupCategory, usage LookupCategoryUsage) LookupCategoryDeletePreview {
+	preview := LookupCategoryDeletePreview{
+		CategoryID: c.ID,
+		Code:       c.Code,
+		CanDelete:  !c.IsSystem,
+		Values:     len(c.Values),
+		Usage:      usage,
+	}
+	for _, v := range c.Values {
+		if v.IsActive {
+			preview.ActiveValues++
+		}
+		if v.IsDefault && !preview.HasDefault {
+			preview.HasDefault = true
+			preview.DefaultValueCode = v.Code
+		}
+	}
+	return preview
+}
+
+// LookupColorUsage is a color in use on lookup values and how many use it
+type LookupColorUsage struct {
+	Color string `json:"color"`
+	Count int64  `json:"count"`
+}
+
+// MaxLookupRecolorMappings caps the old colors of one recolor request
+const MaxLookupRecolorMappings = 200
+
+// LookupRecolorResult counts the values recolored per old color, keyed by the
+// normalized old color; colors that matched nothing count 0
+type LookupRecolorResult struct {
+	Changed map[string]int64 `json:"changed"`
+	Total   int64            `json:"total"`
+}
+
+// LookupRecentChange is one entry of the recently changed lookups feed
+type LookupRecentChange struct {
+	Type       string    `json:"type"` // "category" or "value"
+	ID         uuid.UUID `json:"id"`
+	CategoryID uuid.UUID `json:"category_id"`
+	Code       string    `json:"code"`
+	Name       string    `json:"name"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// LookupSyncCursor marks the last change sent by a lookup sync. Changes are
+// ordered by (timestamp, id), so changes sharing a timestamp are told apart by
+// ID and none is skipped or sent twice.
+type LookupSyncCursor struct {
+	UpdatedAt time.Time
+	ID        uuid.UUID
+}
+
+// ErrInvalidLookupSyncCursor is returned for a cursor that is not the
+// next_cursor of a previous sync
+var ErrInvalidLookupSyncCursor = errors.New("invalid lookup sync cursor")
+
+// String encodes the cursor as the opaque next_cursor sent to clients
+func (c LookupSyncCursor) String() string {
+	raw := c.UpdatedAt.UTC().Format(time.RFC3339Nano) + "/" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseLookupSyncCursor decodes a cursor encoded by LookupSyncCursor.String
+func ParseLookupSyncCursor(s string) (LookupSyncCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return LookupSyncCursor{}, ErrInvalidLookupSyncCursor
+	}
+	at, id, ok := strings.Cut(string(raw), "/")
+	if !ok {
+		return LookupSyncCursor{}, ErrInvalidLookupSyncCursor
+	}
+	updatedAt, err := time.Parse(time.RFC3339Nano, at)
+	if err != nil {
+		return LookupSyncCursor{}, ErrInvalidLookupSyncCursor
+	}
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return LookupSyncCursor{}, ErrInvalidLookupSyncCursor
+	}
+	return LookupSyncCursor{UpdatedAt: updatedAt, ID: parsedID}, nil
+}
+
+// LookupChanges is what changed in lookups after a sync cursor: the
+// categories and values created or updated, and the IDs of those deleted.
+// Next is the cursor after the last of them, or the cursor given when nothing
+// changed.
+type LookupChanges struct {
+	Categories        []LookupCategory
+	Values            []LookupValue
+	DeletedCategories []uuid.UUID
+	DeletedValues     []uuid.UUID
+	Next              LookupSyncCursor
+}
+
+// LookupSyncDeleted lists the IDs deleted since the sync cursor
+type LookupSyncDeleted struct {
+	Categories []uuid.UUID `json:"categories"`
+	Values     []uuid.UUID `json:"values"`
+}
+
+// LookupSyncResponse is one incremental sync of lookups. Categories carry no
+// values; changed values are listed separately. NextCursor is the cursor to
+// send on the next sync; ServerTime is kept for clients that still send since.
+type LookupSyncResponse struct {
+	Categories []LookupCategoryResponse `json:"categories"`
+	Values     []LookupValueResponse    `json:"values"`
+	Deleted    LookupSyncDeleted        `json:"deleted"`
+	NextCursor string                   `json:"next_cursor"`
+	ServerTime time.Time                `json:"server_time"`
+}
+
+// ToLookupSyncResponse converts the changes since a cursor to the sync response
+func ToLookupSyncResponse(changes *LookupChanges, serverTime time.Time) LookupSyncResponse {
+	resp := LookupSyncResponse{
+		Categories: make([]LookupCategoryResponse, len(changes.Categories)),
+		Values:     make([]LookupValueResponse, len(changes.Values)),
+		Deleted: LookupSyncDeleted{
+			Categories: append([]uuid.UUID{}, changes.DeletedCategories...),
+			Values:     append([]uuid.UUID{}, changes.DeletedValues...),
+		},
+		NextCursor: changes.Next.String(),
+		ServerTime: serverTime,
+	}
+	for i := range changes.Categories {
+		resp.Categories[i] = ToLookupCategoryResponse(&changes.Categories[i])
+	}
+	for i := range changes.Values {
+		resp.Values[i] = ToLookupValueResponse(&changes.Values[i])
+	}
+	return resp
+}
+
+// LookupCodeSuggestions lists the category codes starting with a prefix and a
+// code built from the prefix that is still free
+type LookupCodeSuggestions struct {
+	Prefix     string   `json:"prefix"`
+	Matches    []string `json:"matches"`
+	Suggestion string   `json:"suggestion"`
+}
+
+// LookupCodeCollision is a category code held by more than one category in the
+// same uniqueness scope. OrgID is nil when the scope is global.
+type LookupCodeCollision struct {
+	OrgID *uuid.UUID `json:"org_id"`
+	Code  string     `json:"code"`
+	Count int64      `json:"count"`
+}
+
+// LookupInactiveDefault is an inactive value still flagged as the default of an
+// active category that has no active default, so GetDefaultValue finds nothing
+type LookupInactiveDefault struct {
+	CategoryID   uuid.UUID `json:"category_id"`
+	CategoryCode string    `json:"category_code"`
+	CategoryName string    `json:"category_name"`
+	ValueID      uuid.UUID `json:"value_id"`
+	ValueCode    string    `json:"value_code"`
+	ValueName    string    `json:"value_name"`
+}
+
+// LookupInactiveDefaultsReport lists the inactive defaults and the number of
+// categories they leave without a default
+type LookupInactiveDefaultsReport struct {
+	Count    int                     `json:"count"`
+	Defaults []LookupInactiveDefault `json:"defaults"`
+}
+
+// LookupMissingDefault is an active category with active values none of which
+// is the default
+type LookupMissingDefault struct {
+	CategoryID   uuid.UUID `json:"category_id"`
+	CategoryCode string    `json:"category_code"`
+	CategoryName string    `json:"category_name"`
+	ActiveValues int64     `json:"active_values"`
+}
+
+// LookupMissingDefaultsReport lists the categories without a default
+type LookupMissingDefaultsReport struct {
+	Count      int                    `json:"count"`
+	Categories []LookupMissingDefault `json:"categories"`
+}
+
+// LookupConflictResponse identifies the existing category or value that owns a
+// code a create or update tried to reuse.
+type LookupConflictResponse struct {
+	ID   uuid.UUID `json:"id"`
+	Code string    `json:"code"`
+	Name string    `json:"name"`
 }
 
 // ToLookupCategoryResponse converts a LookupCategory to LookupCategoryResponse
 func ToLookupCategoryResponse(c *LookupCategory) LookupCategoryResponse {
 	resp := LookupCategoryResponse{
 		ID:                c.ID,
+		OrgID:             c.OrgID,
 		Code:              c.Code,
 		Name:              c.Name,
 		NameAr:            c.NameAr,
@@ -147,26 +1293,42 @@ func ToLookupCategoryResponse(c *LookupCategory) LookupCategoryResponse {
 		IsSystem:          c.IsSystem,
 		IsActive:          c.IsActive,
 		AddToIncidentForm: c.AddToIncidentForm,
+		LockDefault:       c.LockDefault,
+		DefaultSortDesc:   c.DefaultSortDesc,
+		ValueCodePattern:  c.ValueCodePattern,
+		DefaultColor:      c.DefaultColor,
+		ThemeColor:        c.DefaultColor,
+		AllowedColors:     c.AllowedColors,
+		ArchivedAt:        c.ArchivedAt,
 		ValuesCount:       len(c.Values),
 		CreatedAt:         c.CreatedAt,
 		UpdatedAt:         c.UpdatedAt,
 	}
+	resp.IncidentFormIntent = c.IncidentFormIntent
 
 	if len(c.Values) > 0 {
 		resp.Values = make([]LookupValueResponse, len(c.Values))
 		for i, v := range c.Values {
 			resp.Values[i] = ToLookupValueResponse(&v)
+			resp.Values[i].EffectiveColor = v.EffectiveColor(c)
+			if v.IsDefault && v.Color != "" {
+				resp.ThemeColor = v.Color
+			}
 		}
 	}
 
 	return resp
 }
 
-// ToLookupValueResponse converts a LookupValue to LookupValueResponse
+// ToLookupValueResponse converts a LookupValue to LookupValueResponse. When the
+// category is preloaded only its code and name are included; use
+// ToLookupValueResponseWithCategory for the full category object.
 func ToLookupValueResponse(v *LookupValue) LookupValueResponse {
 	resp := LookupValueResponse{
 		ID:          v.ID,
+		OrgID:       v.OrgID,
 		CategoryID:  v.CategoryID,
+		ParentID:    v.ParentID,
 		Code:        v.Code,
 		Name:        v.Name,
 		NameAr:      v.NameAr,
@@ -175,12 +1337,320 @@ func ToLookupValueResponse(v *LookupValue) LookupValueResponse {
 		Color:       v.Color,
 		IsDefault:   v.IsDefault,
 		IsActive:    v.IsActive,
+		Status:      v.Status,
 		CreatedAt:   v.CreatedAt,
 		UpdatedAt:   v.UpdatedAt,
+
+		DefaultWeight: v.DefaultWeight,
+		EffectiveFrom: v.EffectiveFrom,
+		EffectiveTo:   v.EffectiveTo,
+		RequiredRole:  v.RequiredRole,
+		Metadata:      v.Metadata,
 	}
+	if v.Category != nil {
+		resp.CategoryCode = v.Category.Code
+		resp.CategoryName = v.Category.Name
+		resp.IsSystemCategory = v.Category.IsSystem
+		resp.EffectiveColor = v.EffectiveColor(v.Category)
+	}
+	return resp
+}
+
+// ToCompactLookupValueResponse trims a value response to its compact shape.
+// It maps from the response so a localized name is kept.
+func ToCompactLookupValueResponse(v LookupValueResponse) CompactLookupValueResponse {
+	return CompactLookupValueResponse{
+		ID:        v.ID,
+		Code:      v.Code,
+		Name:      v.Name,
+		Color:     v.Color,
+		IsDefault: v.IsDefault,
+	}
+}
+
+// ToCompactLookupCategoryResponse trims a category response and its values to
+// their compact shapes
+func ToCompactLookupCategoryResponse(c LookupCategoryResponse) CompactLookupCategoryResponse {
+	resp := CompactLookupCategoryResponse{
+		ID:   c.ID,
+		Code: c.Code,
+		Name: c.Name,
+	}
+	if len(c.Values) > 0 {
+		resp.Values = make([]CompactLookupValueResponse, len(c.Values))
+		for i, v := range c.Values {
+			resp.Values[i] = ToCompactLookupValueResponse(v)
+		}
+	}
+	return resp
+}
+
+// ToLookupValueResponseWithCategory converts a LookupValue to LookupValueResponse
+// embedding the full preloaded category
+func ToLookupValueResponseWithCategory(v *LookupValue) LookupValueResponse {
+	resp := ToLookupValueResponse(v)
 	if v.Category != nil {
 		catResp := ToLookupCategoryResponse(v.Category)
 		resp.Category = &catResp
 	}
 	return resp
 }
+
+// LookupValueFilter narrows the public value listings
+type LookupValueFilter struct {
+	// IncludeDeprecated also returns deprecated values, e.g. to display them on existing records
+	IncludeDeprecated bool
+	// AsOf keeps only values whose effective window contains it; the zero
+	// time skips the window check
+	AsOf time.Time
+}
+
+// Statuses returns the value statuses matched by the filter
+func (f LookupValueFilter) Statuses() []string {
+	if f.IncludeDeprecated {
+		return []string{LookupValueStatusActive, LookupValueStatusDeprecated}
+	}
+	return []string{LookupValueStatusActive}
+}
+
+// LookupValueTreeNode is a value with its child values nested underneath
+type LookupValueTreeNode struct {
+	LookupValueResponse
+	// Orphaned marks a value whose parent is inactive or missing; such values
+	// are surfaced at the root instead of being dropped.
+	Orphaned bool                   `json:"orphaned,omitempty"`
+	Children []*LookupValueTreeNode `json:"children"`
+}
+
+// BuildLookupValueTree nests a flat list of values by ParentID. Siblings keep
+// the order of the input, so callers pass values sorted by sort_order. Values
+// whose parent is not in the list become roots flagged as Orphaned.
+func BuildLookupValueTree(values []LookupValue) []*LookupValueTreeNode {
+	nodes := make(map[uuid.UUID]*LookupValueTreeNode, len(values))
+	for i := range values {
+		nodes[values[i].ID] = &LookupValueTreeNode{
+			LookupValueResponse: ToLookupValueResponse(&values[i]),
+			Children:            []*LookupValueTreeNode{},
+		}
+	}
+
+	roots := []*LookupValueTreeNode{}
+	for i := range values {
+		node := nodes[values[i].ID]
+		parentID := values[i].ParentID
+		if parentID == nil {
+			roots = append(roots, node)
+			continue
+		}
+		parent, ok := nodes[*parentID]
+		if !ok {
+			node.Orphaned = true
+			roots = append(roots, node)
+			continue
+		}
+		parent.Children = append(parent.Children, node)
+	}
+	return roots
+}
+
+// LookupExport is the JSON document produced by the lookup export and accepted
+// by the import. Categories and values are matched on code.
+type LookupExport struct {
+	ExportedAt time.Time              `json:"exported_at"`
+	Categories []LookupCategoryExport `json:"categories" validate:"required,dive"`
+}
+
+// LookupCategoryExport is a category in a LookupExport
+type LookupCategoryExport struct {
+	// Location names the row in the imported file, for error reports
+	Location          string              `json:"-"`
+	Code              string              `json:"code" validate:"required,min=1,max=50"`
+	Name              string              `json:"name" validate:"required,min=1,max=100"`
+	NameAr            string              `json:"name_ar" validate:"max=100"`
+	Description       string              `json:"description" validate:"max=500"`
+	IsActive          bool                `json:"is_active"`
+	AddToIncidentForm bool                `json:"add_to_incident_form"`
+	DefaultSortDesc   bool                `json:"default_sort_desc"`
+	Values            []LookupValueExport `json:"values" validate:"dive"`
+}
+
+// LookupValueExport is a value in a LookupCategoryExport
+type LookupValueExport struct {
+	// Location names the row in the imported file, for error reports
+	Location    string `json:"-"`
+	Code        string `json:"code" validate:"required,min=1,max=50"`
+	Name        string `json:"name" validate:"required,min=1,max=100"`
+	NameAr      string `json:"name_ar" validate:"max=100"`
+	Description string `json:"description" validate:"max=500"`
+	SortOrder   int    `json:"sort_order"`
+	Color       string `json:"color" validate:"max=50"`
+	IsDefault   bool   `json:"is_default"`
+	IsActive    bool   `json:"is_active"`
+	// EffectiveColor is only exported on request and is ignored on import
+	EffectiveColor string `json:"effective_color,omitempty"`
+}
+
+// ToLookupExport converts categories with preloaded values into an export
+// document. effectiveColors also fills in each value's EffectiveColor, for
+// audits of the colors values are actually shown in.
+func ToLookupExport(categories []LookupCategory, effectiveColors bool) LookupExport {
+	export := LookupExport{
+		ExportedAt: time.Now().UTC(),
+		Categories: make([]LookupCategoryExport, len(categories)),
+	}
+	for i, c := range categories {
+		cat := LookupCategoryExport{
+			Code:              c.Code,
+			Name:              c.Name,
+			NameAr:            c.NameAr,
+			Description:       c.Description,
+			IsActive:          c.IsActive,
+			AddToIncidentForm: c.AddToIncidentForm,
+			DefaultSortDesc:   c.DefaultSortDesc,
+			Values:            make([]LookupValueExport, len(c.Values)),
+		}
+		for j, v := range c.Values {
+			cat.Values[j] = LookupValueExport{
+				Code:        v.Code,
+				Name:        v.Name,
+				NameAr:      v.NameAr,
+				Description: v.Description,
+				SortOrder:   v.SortOrder,
+				Color:       v.Color,
+				IsDefault:   v.IsDefault,
+				IsActive:    v.IsActive,
+			}
+			if effectiveColors {
+				cat.Values[j].EffectiveColor = v.EffectiveColor(&c)
+			}
+		}
+		export.Categories[i] = cat
+	}
+	return export
+}
+
+// Lookup import actions
+const (
+	LookupImportCreated   = "created"
+	LookupImportUpdated   = "updated"
+	LookupImportUnchanged = "unchanged"
+)
+
+// Lookup import validation modes. A strict import saves nothing when any row
+// fails; a lenient one saves the valid rows and reports the others.
+const (
+	LookupImportStrict  = "strict"
+	LookupImportLenient = "lenient"
+)
+
+// LookupImportOptions controls how ImportLookups applies a document
+type LookupImportOptions struct {
+	DryRun     bool
+	Validation string
+}
+
+// LookupImportRowError reports a category or value row an import skipped
+type LookupImportRowError struct {
+	Location     string `json:"location"` // e.g. "categories[0].values[2]" or "line 3"
+	Type         string `json:"type"`     // "category" or "value"
+	CategoryCode string `json:"category_code"`
+	Code         string `json:"code"`
+	Error        string `json:"error"`
+}
+
+// LookupTranslationImportRow is one row of an Arabic translation import: the
+// Arabic name and description for the value with Code
+type LookupTranslationImportRow struct {
+	Location      string
+	Code          string
+	NameAr        string
+	DescriptionAr string
+}
+
+// LookupTranslationImportResult reports an Arabic translation import
+type LookupTranslationImportResult struct {
+	Updated   int      `json:"updated"`
+	Unmatched []string `json:"unmatched"` // codes no value in the category has
+}
+
+// LookupPurgeResult counts the rows a purge of soft-deleted lookups removed
+type LookupPurgeResult struct {
+	Cutoff     time.Time `json:"cutoff"` // rows deleted before this were purged
+	Categories int64     `json:"categories"`
+	Values     int64     `json:"values"`
+}
+
+// LookupReadOnlyRequest turns lookup maintenance mode on or off
+type LookupReadOnlyRequest struct {
+	ReadOnly *bool `json:"read_only" validate:"required"`
+}
+
+// LookupReadOnlyStatus reports whether lookup writes are being rejected
+type LookupReadOnlyStatus struct {
+	ReadOnly bool `json:"read_only"`
+}
+
+// LookupFieldChange is a single field difference found by an import
+type LookupFieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// LookupImportDiff describes what an import did, or would do, to one category or value
+type LookupImportDiff struct {
+	Type         string                       `json:"type"` // "category" or "value"
+	CategoryCode string                       `json:"category_code"`
+	Code         string                       `json:"code"`
+	Action       string                       `json:"action"`
+	Changes      map[string]LookupFieldChange `json:"changes,omitempty"`
+}
+
+// LookupImportResult summarises an import
+type LookupImportResult struct {
+	DryRun     bool                   `json:"dry_run"`
+	Validation string                 `json:"validation"`
+	Created    int                    `json:"created"`
+	Updated    int                    `json:"updated"`
+	Skipped    int                    `json:"skipped"`
+	Failed     int                    `json:"failed"`
+	Diff       []LookupImportDiff     `json:"diff"`
+	Errors     []LookupImportRowError `json:"errors"`
+	Warnings   []LookupImportWarning  `json:"warnings"`
+}
+
+// LookupImportWarning reports a problem a lenient import worked around
+type LookupImportWarning struct {
+	Location     string `json:"location"`
+	CategoryCode string `json:"category_code"`
+	Message      string `json:"message"`
+}
+
+// NewLookupImportResult returns an empty result for an import run with opts
+func NewLookupImportResult(opts LookupImportOptions) *LookupImportResult {
+	return &LookupImportResult{
+		DryRun:     opts.DryRun,
+		Validation: opts.Validation,
+		Diff:       []LookupImportDiff{},
+		Errors:     []LookupImportRowError{},
+		Warnings:   []LookupImportWarning{},
+	}
+}
+
+// Record adds a diff entry and updates the matching counter
+func (r *LookupImportResult) Record(diff LookupImportDiff) {
+	switch diff.Action {
+	case LookupImportCreated:
+		r.Created++
+	case LookupImportUpdated:
+		r.Updated++
+	default:
+		r.Skipped++
+	}
+	r.Diff = append(r.Diff, diff)
+}
+
+// Fail adds a row error and counts the row as failed
+func (r *LookupImportResult) Fail(rowErr LookupImportRowError) {
+	r.Failed++
+	r.Errors = append(r.Errors, rowErr)
+}
@@ -0,0 +1,84 @@
+package models
+
+// LookupCategorySeed is the baseline of a system category: what the seeder
+// creates and what a reset restores.
+// The same shape describes the category templates in LookupTemplates.
+type LookupCategorySeed struct {
+	Code        string            `json:"code"`
+	Name        string            `json:"name"`
+	NameAr      string            `json:"name_ar"`
+	Description string            `json:"description"`
+	Values      []LookupValueSeed `json:"values"`
+}
+
+// LookupValueSeed is one seeded value of a system category
+type LookupValueSeed struct {
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+	NameAr    string `json:"name_ar"`
+	SortOrder int    `json:"sort_order"`
+	Color     string `json:"color"`
+	IsDefault bool   `json:"is_default"`
+}
+
+// SystemLookupSeeds lists the system categories seeded on first start
+var SystemLookupSeeds = []LookupCategorySeed{
+	{
+		Code:        "PRIORITY",
+		Name:        "Priority",
+		NameAr:      "الأولوية",
+		Description: "Incident priority levels",
+		Values: []LookupValueSeed{
+			{Code: "CRITICAL", Name: "Critical", NameAr: "حرج", SortOrder: 1, Color: "#EF4444"},
+			{Code: "HIGH", Name: "High", NameAr: "عالي", SortOrder: 2, Color: "#F97316"},
+			{Code: "MEDIUM", Name: "Medium", NameAr: "متوسط", SortOrder: 3, Color: "#EAB308", IsDefault: true},
+			{Code: "LOW", Name: "Low", NameAr: "منخفض", SortOrder: 4, Color: "#3B82F6"},
+			{Code: "VERY_LOW", Name: "Very Low", NameAr: "منخفض جداً", SortOrder: 5, Color: "#6B7280"},
+		},
+	},
+	{
+		Code:        "SEVERITY",
+		Name:        "Severity",
+		NameAr:      "الخطورة",
+		Description: "Incident severity levels",
+		Values: []LookupValueSeed{
+			{Code: "CRITICAL", Name: "Critical", NameAr: "حرج", SortOrder: 1, Color: "#EF4444"},
+			{Code: "MAJOR", Name: "Major", NameAr: "رئيسي", SortOrder: 2, Color: "#F97316"},
+			{Code: "MODERATE", Name: "Moderate", NameAr: "معتدل", SortOrder: 3, Color: "#EAB308", IsDefault: true},
+			{Code: "MINOR", Name: "Minor", NameAr: "ثانوي", SortOrder: 4, Color: "#3B82F6"},
+			{Code: "COSMETIC", Name: "Cosmetic", NameAr: "تجميلي", SortOrder: 5, Color: "#6B7280"},
+		},
+	},
+}
+
+// FindLookupSeed returns the seeded baseline of a system category
+func FindLookupSeed(code string) (*LookupCategorySeed, bool) {
+	for i := range SystemLookupSeeds {
+		if SystemLookupSeeds[i].Code == code {
+			return &SystemLookupSeeds[i], true
+		}
+	}
+	return nil, false
+}
+
+// NewValue builds the LookupValue for this seed in the given category
+func (s LookupValueSeed) NewValue(category *LookupCategory) LookupValue {
+	return LookupValue{
+		OrgID:      category.OrgID,
+		CategoryID: category.ID,
+		Code:       s.Code,
+		Name:       s.Name,
+		NameAr:     s.NameAr,
+		SortOrder:  s.SortOrder,
+		Color:      s.Color,
+		IsDefault:  s.IsDefault,
+		IsActive:   true,
+	}
+}
+
+// LookupResetResult lists the value codes changed by a category reset
+type LookupResetResult struct {
+	Created  []string `json:"created"`
+	Restored []string `json:"restored"`
+	Removed  []string `json:"removed"`
+}
@@ -0,0 +1,94 @@
+package models
+
+// LookupTemplates are ready-made categories tenants can instantiate with
+// POST /categories/from-template/:template_code. Unlike SystemLookupSeeds they
+// are never seeded; each instance is an ordinary category owned by the caller.
+var LookupTemplates = []LookupCategorySeed{
+	{
+		Code:        "ITIL_PRIORITY",
+		Name:        "ITIL Priority",
+		NameAr:      "الأولوية (ITIL)",
+		Description: "ITIL priority levels P1-P5",
+		Values: []LookupValueSeed{
+			{Code: "P1", Name: "P1 - Critical", NameAr: "P1 - حرج", SortOrder: 1, Color: "#EF4444"},
+			{Code: "P2", Name: "P2 - High", NameAr: "P2 - عالي", SortOrder: 2, Color: "#F97316"},
+			{Code: "P3", Name: "P3 - Moderate", NameAr: "P3 - متوسط", SortOrder: 3, Color: "#EAB308", IsDefault: true},
+			{Code: "P4", Name: "P4 - Low", NameAr: "P4 - منخفض", SortOrder: 4, Color: "#3B82F6"},
+			{Code: "P5", Name: "P5 - Planning", NameAr: "P5 - تخطيط", SortOrder: 5, Color: "#6B7280"},
+		},
+	},
+	{
+		Code:        "ITIL_IMPACT",
+		Name:        "ITIL Impact",
+		NameAr:      "التأثير (ITIL)",
+		Description: "How widely an incident affects the business",
+		Values: []LookupValueSeed{
+			{Code: "EXTENSIVE", Name: "Extensive/Widespread", NameAr: "واسع النطاق", SortOrder: 1, Color: "#EF4444"},
+			{Code: "SIGNIFICANT", Name: "Significant/Large", NameAr: "كبير", SortOrder: 2, Color: "#F97316"},
+			{Code: "MODERATE", Name: "Moderate/Limited", NameAr: "محدود", SortOrder: 3, Color: "#EAB308", IsDefault: true},
+			{Code: "MINOR", Name: "Minor/Localized", NameAr: "محلي", SortOrder: 4, Color: "#3B82F6"},
+		},
+	},
+	{
+		Code:        "ITIL_URGENCY",
+		Name:        "ITIL Urgency",
+		NameAr:      "الاستعجال (ITIL)",
+		Description: "How quickly an incident needs to be resolved",
+		Values: []LookupValueSeed{
+			{Code: "CRITICAL", Name: "Critical", NameAr: "حرج", SortOrder: 1, Color: "#EF4444"},
+			{Code: "HIGH", Name: "High", NameAr: "عالي", SortOrder: 2, Color: "#F97316"},
+			{Code: "MEDIUM", Name: "Medium", NameAr: "متوسط", SortOrder: 3, Color: "#EAB308", IsDefault: true},
+			{Code: "LOW", Name: "Low", NameAr: "منخفض", SortOrder: 4, Color: "#3B82F6"},
+		},
+	},
+	{
+		Code:        "NATIONALITY",
+		Name:        "Nationality",
+		NameAr:      "الجنسية",
+		Description: "Common nationalities, by ISO 3166-1 alpha-2 code",
+		Values: []LookupValueSeed{
+			{Code: "AE", Name: "Emirati", NameAr: "إماراتي", SortOrder: 1},
+			{Code: "SA", Name: "Saudi", NameAr: "سعودي", SortOrder: 2},
+			{Code: "KW", Name: "Kuwaiti", NameAr: "كويتي", SortOrder: 3},
+			{Code: "QA", Name: "Qatari", NameAr: "قطري", SortOrder: 4},
+			{Code: "BH", Name: "Bahraini", NameAr: "بحريني", SortOrder: 5},
+			{Code: "OM", Name: "Omani", NameAr: "عماني", SortOrder: 6},
+			{Code: "EG", Name: "Egyptian", NameAr: "مصري", SortOrder: 7},
+			{Code: "JO", Name: "Jordanian", NameAr: "أردني", SortOrder: 8},
+			{Code: "LB", Name: "Lebanese", NameAr: "لبناني", SortOrder: 9},
+			{Code: "SY", Name: "Syrian", NameAr: "سوري", SortOrder: 10},
+			{Code: "IN", Name: "Indian", NameAr: "هندي", SortOrder: 11},
+			{Code: "PK", Name: "Pakistani", NameAr: "باكستاني", SortOrder: 12},
+			{Code: "PH", Name: "Filipino", NameAr: "فلبيني", SortOrder: 13},
+			{Code: "GB", Name: "British", NameAr: "بريطاني", SortOrder: 14},
+			{Code: "US", Name: "American", NameAr: "أمريكي", SortOrder: 15},
+			{Code: "OTHER", Name: "Other", NameAr: "أخرى", SortOrder: 99},
+		},
+	},
+	{
+		Code:        "YES_NO",
+		Name:        "Yes/No",
+		NameAr:      "نعم/لا",
+		Description: "A simple yes or no choice",
+		Values: []LookupValueSeed{
+			{Code: "YES", Name: "Yes", NameAr: "نعم", SortOrder: 1, Color: "#22C55E"},
+			{Code: "NO", Name: "No", NameAr: "لا", SortOrder: 2, Color: "#EF4444"},
+		},
+	},
+}
+
+// FindLookupTemplate returns the category template with the given code
+func FindLookupTemplate(code string) (*LookupCategorySeed, bool) {
+	for i := range LookupTemplates {
+		if LookupTemplates[i].Code == code {
+			return &LookupTemplates[i], true
+		}
+	}
+	return nil, false
+}
+
+// LookupTemplateInstantiateRequest optionally renames the category created
+// from a template, e.g. when the template code is already taken
+type LookupTemplateInstantiateRequest struct {
+	Code string `json:"code" validate:"omitempty,min=1,max=50"`
+}
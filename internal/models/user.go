@@ -20,6 +20,7 @@ type User struct {
 	Department      *Department      `gorm:"foreignKey:DepartmentID" json:"department,omitempty"`
 	Departments     []Department     `gorm:"many2many:user_departments;" json:"departments,omitempty"`
 	LocationID      *uuid.UUID       `gorm:"type:uuid;index" json:"location_id"`
+	OrgID           *uuid.UUID       `gorm:"type:uuid;index" json:"org_id"`
 	Location        *Location        `gorm:"foreignKey:LocationID" json:"location,omitempty"`
 	Locations       []Location       `gorm:"many2many:user_locations;" json:"locations,omitempty"`
 	Classifications []Classification `gorm:"many2many:user_classifications;" json:"classifications,omitempty"`
@@ -160,6 +161,24 @@ type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" validate:"required"`
 }
 
+// TokenIntrospectionRequest accepts the token as JSON or, as RFC 7662 specifies,
+// as a form field
+type TokenIntrospectionRequest struct {
+	Token string `json:"token" form:"token" validate:"required"`
+}
+
+// TokenIntrospectionResponse follows RFC 7662: inactive tokens carry only
+// active=false, without saying why.
+type TokenIntrospectionResponse struct {
+	Active bool       `json:"active"`
+	UserID *uuid.UUID `json:"user_id,omitempty"`
+	Email  string     `json:"email,omitempty"`
+	Role   string     `json:"role,omitempty"`
+	OrgID  *uuid.UUID `json:"org_id,omitempty"`
+	Exp    int64      `json:"exp,omitempty"`
+	Scopes []string   `json:"scopes,omitempty"`
+}
+
 type ChangePasswordRequest struct {
 	OldPassword string `json:"old_password" validate:"required"`
 	NewPassword string `json:"new_password" validate:"required,min=6"`
@@ -13,6 +13,7 @@ type ActionLogRepository interface {
 	Create(ctx context.Context, log *models.ActionLog) error
 	FindByID(ctx context.Context, id uuid.UUID) (*models.ActionLog, error)
 	List(ctx context.Context, filter *models.ActionLogFilter) ([]models.ActionLog, int64, error)
+	ListAfter(ctx context.Context, filter *models.ActionLogFilter, after *models.ActionLogCursor, limit int) ([]models.ActionLog, error)
 	GetStats(ctx context.Context) (*models.ActionLogStats, error)
 	GetUserActions(ctx context.Context, userID uuid.UUID, page, limit int) ([]models.ActionLog, int64, error)
 	DeleteOlderThan(ctx context.Context, date time.Time) (int64, error)
@@ -47,9 +48,48 @@ func (r *actionLogRepository) List(ctx context.Context, filter *models.ActionLog
 	var logs []models.ActionLog
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&models.ActionLog{})
+	query := applyActionLogFilter(r.db.WithContext(ctx).Model(&models.ActionLog{}), filter)
 
-	// Apply filters
+	// Count total
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Apply pagination
+	offset := (filter.Page - 1) * filter.Limit
+	err := query.
+		Preload("User").
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(filter.Limit).
+		Find(&logs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
+
+// ListAfter returns up to limit logs matching filter in (created_at, id) order,
+// starting after the given cursor. It uses keyset pagination so exporting the
+// whole log costs the same per page no matter how deep the export goes.
+func (r *actionLogRepository) ListAfter(ctx context.Context, filter *models.ActionLogFilter, after *models.ActionLogCursor, limit int) ([]models.ActionLog, error) {
+	var logs []models.ActionLog
+
+	query := applyActionLogFilter(r.db.WithContext(ctx).Model(&models.ActionLog{}), filter)
+	if after != nil {
+		query = query.Where("(created_at, id) > (?, ?)", after.CreatedAt, after.ID)
+	}
+
+	err := query.
+		Order("created_at ASC, id ASC").
+		Limit(limit).
+		Find(&logs).Error
+	return logs, err
+}
+
+// applyActionLogFilter adds the filter conditions shared by the list and export queries
+func applyActionLogFilter(query *gorm.DB, filter *models.ActionLogFilter) *gorm.DB {
 	if filter.UserID != nil {
 		query = query.Where("user_id = ?", *filter.UserID)
 	}
@@ -75,25 +115,7 @@ func (r *actionLogRepository) List(ctx context.Context, filter *models.ActionLog
 		searchPattern := "%" + filter.Search + "%"
 		query = query.Where("description ILIKE ? OR ip_address ILIKE ?", searchPattern, searchPattern)
 	}
-
-	// Count total
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	// Apply pagination
-	offset := (filter.Page - 1) * filter.Limit
-	err := query.
-		Preload("User").
-		Order("created_at DESC").
-		Offset(offset).
-		Limit(filter.Limit).
-		Find(&logs).Error
-	if err != nil {
-		return nil, 0, err
-	}
-
-	return logs, total, nil
+	return query
 }
 
 func (r *actionLogRepository) GetStats(ctx context.Context) (*models.ActionLogStats, error) {
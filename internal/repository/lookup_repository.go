@@ -2,32 +2,171 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/automax/backend/internal/models"
+	"github.com/automax/backend/pkg/utils"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type LookupRepository interface {
+	// WithTx runs fn in a transaction, passing a repository whose methods all
+	// run in it. The transaction commits when fn returns nil and rolls back on
+	// an error or panic; WithTx on the passed repository nests a savepoint.
+	WithTx(ctx context.Context, fn func(txRepo LookupRepository) error) error
+	// WithDefaultLock is WithTx holding a lock on the defaults of categoryID,
+	// so two flows that clear and then set the category's default never
+	// interleave. Wrap every such flow in it.
+	WithDefaultLock(ctx context.Context, categoryID uuid.UUID, fn func(txRepo LookupRepository) error) error
+
 	// Categories
 	CreateCategory(ctx context.Context, category *models.LookupCategory) error
 	FindCategoryByID(ctx context.Context, id uuid.UUID) (*models.LookupCategory, error)
 	FindCategoryByCode(ctx context.Context, code string) (*models.LookupCategory, error)
+	FindCategoryByCodeAnyStatus(ctx context.Context, code string) (*models.LookupCategory, error)
+	FindCategoriesByIDs(ctx context.Context, ids []uuid.UUID) ([]models.LookupCategory, error)
+	FindIncidentFormPrefixConflict(ctx context.Context, category *models.LookupCategory) (*models.LookupCategory, error)
 	UpdateCategory(ctx context.Context, category *models.LookupCategory) error
+	UpsertCategoryByCode(ctx context.Context, code string, apply func(category *models.LookupCategory, created bool) error) (*models.LookupCategory, bool, error)
 	DeleteCategory(ctx context.Context, id uuid.UUID) error
 	ListCategories(ctx context.Context) ([]models.LookupCategory, error)
+	ListCategoriesWithoutValues(ctx context.Context) ([]models.LookupCategory, error)
+	ListCategoriesPaged(ctx context.Context, opts utils.ListOptions) ([]models.LookupCategory, int64, error)
+	CategoryCodeCollisions(ctx context.Context, perOrg bool) ([]models.LookupCodeCollision, error)
+	ListCategoryCodesByPrefix(ctx context.Context, prefix string, limit int) ([]string, error)
+	SuggestCategoryCode(ctx context.Context, base string) (string, error)
+	SetCategoriesActive(ctx context.Context, ids []uuid.UUID, active bool) (*models.LookupCategoriesActiveResult, error)
+	SetCategoriesIncidentForm(ctx context.Context, ids []uuid.UUID, add bool) (*models.LookupCategoriesIncidentFormResult, error)
+	ResetCategoryToSeed(ctx context.Context, category *models.LookupCategory, seed *models.LookupCategorySeed, strict bool) (*models.LookupResetResult, error)
+	ArchiveCategory(ctx context.Context, id uuid.UUID, cascadeValues bool) (int64, error)
+	UnarchiveCategory(ctx context.Context, id uuid.UUID, withValues bool) (int64, error)
+	TouchCategory(ctx context.Context, id uuid.UUID) (time.Time, error)
+	ListActiveCategories(ctx context.Context) ([]models.LookupCategory, error)
+	ListIncidentFormCategories(ctx context.Context) ([]models.LookupCategory, error)
+	GetLookupStats(ctx context.Context) (models.LookupStats, error)
+	ListColorUsage(ctx context.Context) ([]models.LookupColorUsage, error)
+	CountCategoryUsage(ctx context.Context, categoryID uuid.UUID) (models.LookupCategoryUsage, error)
+	RecolorValues(ctx context.Context, mapping map[string]string) (*models.LookupRecolorResult, error)
+	ListRecentlyUpdated(ctx context.Context, limit int) ([]models.LookupRecentChange, error)
+	ListChangesSince(ctx context.Context, after models.LookupSyncCursor) (*models.LookupChanges, error)
+	ImportLookups(ctx context.Context, data *models.LookupExport, opts models.LookupImportOptions) (*models.LookupImportResult, error)
 
 	// Values
 	CreateValue(ctx context.Context, value *models.LookupValue) error
 	FindValueByID(ctx context.Context, id uuid.UUID) (*models.LookupValue, error)
+	ValueIDExists(ctx context.Context, id uuid.UUID) (bool, error)
+	FindValueByCode(ctx context.Context, categoryID uuid.UUID, code string) (*models.LookupValue, error)
+	FindValueAncestors(ctx context.Context, id uuid.UUID) ([]models.LookupValue, error)
 	UpdateValue(ctx context.Context, value *models.LookupValue) error
+	CreateValueAtSortOrder(ctx context.Context, value *models.LookupValue) error
+	UpdateValueAtSortOrder(ctx context.Context, value *models.LookupValue) error
+	NormalizeSortOrder(ctx context.Context, categoryID uuid.UUID) ([]models.LookupValue, error)
+	ListOrphanValues(ctx context.Context) ([]models.LookupValue, error)
+	CleanupOrphanValues(ctx context.Context, ids []uuid.UUID, targetCategoryID *uuid.UUID) (*models.LookupOrphanCleanupResult, error)
+	MoveValues(ctx context.Context, sourceID, targetID uuid.UUID, valueIDs []uuid.UUID) ([]models.LookupValue, error)
 	DeleteValue(ctx context.Context, id uuid.UUID) error
+	RestoreValues(ctx context.Context, categoryID uuid.UUID, ids []uuid.UUID) ([]models.LookupValue, []models.LookupValueRestoreSkip, error)
 	ListValuesByCategory(ctx context.Context, categoryID uuid.UUID) ([]models.LookupValue, error)
-	ListValuesByCategoryCode(ctx context.Context, code string) ([]models.LookupValue, error)
+	ListValuesByMetadataKey(ctx context.Context, categoryID uuid.UUID, key, value string) ([]models.LookupValue, error)
+	ListValuesByCategoryPaged(ctx context.Context, categoryID uuid.UUID, tagCode string, opts utils.ListOptions) ([]models.LookupValue, int64, error)
+	SearchValues(ctx context.Context, query string, opts utils.ListOptions) ([]models.LookupValue, int64, error)
+	FullTextSearchValues(ctx context.Context, query string, opts utils.ListOptions) ([]models.LookupValue, int64, error)
+	AutocompleteValues(ctx context.Context, categoryID uuid.UUID, query string, limit int) ([]models.LookupValue, error)
+	ValuesMissingArabicNames(ctx context.Context, categoryID uuid.UUID) ([]string, error)
+	ValuesChangedAt(ctx context.Context, categoryID uuid.UUID) (time.Time, error)
+	ListValuesByCategoryCode(ctx context.Context, code string, filter models.LookupValueFilter) ([]models.LookupValue, error)
 	GetDefaultValue(ctx context.Context, categoryCode string) (*models.LookupValue, error)
-	ClearDefaultForCategory(ctx context.Context, categoryID uuid.UUID) error
+	ListInactiveDefaults(ctx context.Context) ([]models.LookupInactiveDefault, error)
+	ListMissingDefaults(ctx context.Context) ([]models.LookupMissingDefault, error)
+	PurgeDeletedOlderThan(ctx context.Context, d time.Duration) (*models.LookupPurgeResult, error)
+	GetEffectiveDefaultValue(ctx context.Context, categoryCode string) (*models.LookupValue, bool, error)
+	GetWeightedDefault(ctx context.Context, categoryCode string) (*models.LookupValue, bool, error)
+	// ClearDefaultForCategory returns how many values were the default, 0 when
+	// the category had none, so repeating it is a harmless no-op. It fails on a
+	// system category or one whose default is locked, see checkDefaultChangeable.
+	ClearDefaultForCategory(ctx context.Context, categoryID uuid.UUID) (int64, error)
+	NextSortOrder(ctx context.Context, categoryID uuid.UUID) (int, error)
+	SwapDefault(ctx context.Context, categoryID, newDefaultID uuid.UUID) error
+	SetDefaultValues(ctx context.Context, assignments []models.LookupDefaultAssignment) ([]models.LookupDefaultResult, error)
+	BulkSaveValues(ctx context.Context, categoryID uuid.UUID, toCreate, toUpdate []models.LookupValue) error
+
+	// Aliases
+	CreateAlias(ctx context.Context, alias *models.LookupValueAlias) error
+	ListAliases(ctx context.Context, valueID uuid.UUID) ([]models.LookupValueAlias, error)
+	DeleteAlias(ctx context.Context, valueID, aliasID uuid.UUID) error
+	ResolveValueByAlias(ctx context.Context, categoryCode, aliasOrCode string) (*models.LookupValue, error)
+	FindValueIDByCodes(ctx context.Context, categoryCode, valueCode string) (uuid.UUID, error)
+	ResolveValueCodes(ctx context.Context, pairs []models.LookupCodePair) ([]models.LookupCodeResolution, error)
+
+	// Translations
+	SetValueTranslation(ctx context.Context, translation *models.LookupValueTranslation) error
+	ListValueTranslations(ctx context.Context, valueID uuid.UUID) ([]models.LookupValueTranslation, error)
+	FindValueTranslations(ctx context.Context, valueIDs []uuid.UUID, locales []string) ([]models.LookupValueTranslation, error)
+	ImportArabicTranslations(ctx context.Context, categoryID uuid.UUID, rows []models.LookupTranslationImportRow) (*models.LookupTranslationImportResult, error)
+
+	// Related categories
+	LinkCategories(ctx context.Context, categoryID, relatedID uuid.UUID) error
+	UnlinkCategories(ctx context.Context, categoryID, relatedID uuid.UUID) error
+	ListRelatedCategories(ctx context.Context, categoryID uuid.UUID) ([]models.LookupCategory, error)
+
+	// Tags
+	CreateTag(ctx context.Context, tag *models.LookupTag) error
+	FindTagByID(ctx context.Context, id uuid.UUID) (*models.LookupTag, error)
+	ListTags(ctx context.Context) ([]models.LookupTag, error)
+	AttachTagValues(ctx context.Context, tag *models.LookupTag, valueIDs []uuid.UUID) (*models.LookupTagValuesResult, error)
+	DetachTagValue(ctx context.Context, tag *models.LookupTag, valueID uuid.UUID) error
+	ListValuesByTagCode(ctx context.Context, code string) ([]models.LookupValue, error)
+}
+
+// lookupCategorySortColumns and lookupValueSortColumns are the sort names the
+// list endpoints accept, mapped to their columns
+var (
+	lookupCategorySortColumns = map[string]string{
+		"code":       "code",
+		"name":       "name",
+		"created_at": "created_at",
+		"updated_at": "updated_at",
+	}
+	lookupValueSortColumns = map[string]string{
+		"code":       "code",
+		"name":       "name",
+		"sort_order": "sort_order",
+		"created_at": "created_at",
+		"updated_at": "updated_at",
+	}
+)
+
+// withCategoryCode restricts a query on lookup_categories, or joined to it, to
+// the category with code visible to ctx. Codes are unique per org, so a tenant
+// can own a category next to a global one with the same code; the tenant's
+// own category wins.
+func withCategoryCode(ctx context.Context, code string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		owner := db.Session(&gorm.Session{NewDB: true}).
+			Model(&models.LookupCategory{}).
+			Select("id").
+			Scopes(scopeToOrg(ctx, "lookup_categories")).
+			Where("code = ?", code).
+			Order("org_id IS NULL").
+			Limit(1)
+		return db.Where("lookup_categories.id = (?)", owner)
+	}
 }
 
+// joinActiveCategory joins values to their category while excluding soft-deleted
+// categories; GORM's soft-delete scope only applies to the queried model, not joins.
+const joinActiveCategory = "JOIN lookup_categories ON lookup_categories.id = lookup_values.category_id AND lookup_categories.deleted_at IS NULL"
+
 type lookupRepository struct {
 	db *gorm.DB
 }
@@ -36,9 +175,69 @@ func NewLookupRepository(db *gorm.DB) LookupRepository {
 	return &lookupRepository{db: db}
 }
 
+func (r *lookupRepository) WithTx(ctx context.Context, fn func(txRepo LookupRepository) error) error {
+	return r.withTx(ctx, func(tx *lookupRepository) error {
+		return fn(tx)
+	})
+}
+
+// withTx is WithTx for this repository's own methods, which need the
+// transactional *gorm.DB of the repository passed to fn
+func (r *lookupRepository) withTx(ctx context.Context, fn func(tx *lookupRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&lookupRepository{db: tx})
+	})
+}
+
+func (r *lookupRepository) WithDefaultLock(ctx context.Context, categoryID uuid.UUID, fn func(txRepo LookupRepository) error) error {
+	return r.withDefaultLock(ctx, categoryID, func(tx *lookupRepository) error {
+		return fn(tx)
+	})
+}
+
+// withDefaultLock is WithDefaultLock for this repository's own methods. On
+// Postgres the lock is a transaction-scoped advisory lock, held until the
+// outermost transaction ends. Other drivers have no advisory locks, so the
+// transaction runs serializable instead; nested in another transaction that
+// fallback cannot change the isolation level and adds nothing.
+func (r *lookupRepository) withDefaultLock(ctx context.Context, categoryID uuid.UUID, fn func(tx *lookupRepository) error) error {
+	if r.db.Dialector.Name() != "postgres" {
+		return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return fn(&lookupRepository{db: tx})
+		}, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	}
+	return r.withTx(ctx, func(tx *lookupRepository) error {
+		if err := lockCategoryDefaults(tx.db, categoryID); err != nil {
+			return err
+		}
+		return fn(tx)
+	})
+}
+
+// lockCategoryDefaults takes the Postgres advisory lock on the defaults of
+// categoryID for the rest of the transaction tx; on other drivers it does
+// nothing. The lock key is the first 8 bytes of the ID, so a collision at
+// worst serializes two unrelated categories.
+func lockCategoryDefaults(tx *gorm.DB, categoryID uuid.UUID) error {
+	if tx.Dialector.Name() != "postgres" {
+		return nil
+	}
+	key := int64(binary.BigEndian.Uint64(categoryID[:8]))
+	return tx.Exec("SELECT pg_advisory_xact_lock(?)", key).Error
+}
+
 // Category methods
 
+// lookupValueOrder lists values by sort_order, descending for categories with
+// DefaultSortDesc and ascending otherwise, then by name
+const lookupValueOrder = `CASE WHEN EXISTS (SELECT 1 FROM lookup_categories sort_category
+	WHERE sort_category.id = lookup_values.category_id AND sort_category.default_sort_desc)
+	THEN -lookup_values.sort_order ELSE lookup_values.sort_order END, lookup_values.name ASC`
+
 func (r *lookupRepository) CreateCategory(ctx context.Context, category *models.LookupCategory) error {
+	if err := checkOrgWritable(ctx, category.OrgID); err != nil {
+		return err
+	}
 	return r.db.WithContext(ctx).Create(category).Error
 }
 
@@ -46,8 +245,9 @@ func (r *lookupRepository) FindCategoryByID(ctx context.Context, id uuid.UUID) (
 	var category models.LookupCategory
 	err := r.db.WithContext(ctx).
 		Preload("Values", func(db *gorm.DB) *gorm.DB {
-			return db.Order("sort_order ASC, name ASC")
+			return db.Scopes(scopeToOrg(ctx, "lookup_values")).Order(lookupValueOrder)
 		}).
+		Scopes(scopeToOrg(ctx, "lookup_categories")).
 		First(&category, "id = ?", id).Error
 	if err != nil {
 		return nil, err
@@ -55,13 +255,41 @@ func (r *lookupRepository) FindCategoryByID(ctx context.Context, id uuid.UUID) (
 	return &category, nil
 }
 
+// FindCategoriesByIDs returns the categories with the given ids and their
+// values. Ids that match nothing are left out; the order is unspecified.
+func (r *lookupRepository) FindCategoriesByIDs(ctx context.Context, ids []uuid.UUID) ([]models.LookupCategory, error) {
+	var categories []models.LookupCategory
+	err := r.db.WithContext(ctx).
+		Preload("Values", func(db *gorm.DB) *gorm.DB {
+			return db.Scopes(scopeToOrg(ctx, "lookup_values")).Order(lookupValueOrder)
+		}).
+		Scopes(scopeToOrg(ctx, "lookup_categories")).
+		Where("id IN ?", ids).
+		Find(&categories).Error
+	return categories, err
+}
+
 func (r *lookupRepository) FindCategoryByCode(ctx context.Context, code string) (*models.LookupCategory, error) {
 	var category models.LookupCategory
 	err := r.db.WithContext(ctx).
 		Preload("Values", func(db *gorm.DB) *gorm.DB {
-			return db.Where("is_active = ?", true).Order("sort_order ASC, name ASC")
+			return db.Scopes(scopeToOrg(ctx, "lookup_values")).Where("is_active = ?", true).Order(lookupValueOrder)
 		}).
-		Where("code = ? AND is_active = ?", code, true).
+		Scopes(withCategoryCode(ctx, code)).
+		Where("is_active = ?", true).
+		First(&category).Error
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// FindCategoryByCodeAnyStatus finds a category by code regardless of whether it
+// is active, without preloading its values.
+func (r *lookupRepository) FindCategoryByCodeAnyStatus(ctx context.Context, code string) (*models.LookupCategory, error) {
+	var category models.LookupCategory
+	err := r.db.WithContext(ctx).
+		Scopes(withCategoryCode(ctx, code)).
 		First(&category).Error
 	if err != nil {
 		return nil, err
@@ -70,17 +298,392 @@ func (r *lookupRepository) FindCategoryByCode(ctx context.Context, code string)
 }
 
 func (r *lookupRepository) UpdateCategory(ctx context.Context, category *models.LookupCategory) error {
+	if err := checkRowWritable(ctx, r.db.WithContext(ctx), "lookup_categories", category.ID); err != nil {
+		return err
+	}
 	return r.db.WithContext(ctx).Save(category).Error
 }
 
+// UpsertCategoryByCode creates the category with the given code or updates the
+// existing one, in a transaction that locks the existing row. apply sets the
+// fields on either a fresh category (created=true) or the stored one; an error
+// from apply aborts the transaction and is returned as-is.
+func (r *lookupRepository) UpsertCategoryByCode(ctx context.Context, code string, apply func(category *models.LookupCategory, created bool) error) (*models.LookupCategory, bool, error) {
+	var category models.LookupCategory
+	created := false
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Scopes(withCategoryCode(ctx, code)).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&category).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			created = true
+			category = models.LookupCategory{Code: code, IsActive: true}
+			if err := apply(&category, true); err != nil {
+				return err
+			}
+			if err := checkOrgWritable(ctx, category.OrgID); err != nil {
+				return err
+			}
+			// Select all columns so an explicit is_active=false is kept
+			return tx.Select("*").Omit("Values").Create(&category).Error
+		}
+
+		if err := checkOrgWritable(ctx, category.OrgID); err != nil {
+			return err
+		}
+		if err := apply(&category, false); err != nil {
+			return err
+		}
+		return tx.Omit("Values").Save(&category).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return &category, created, nil
+}
+
+// TouchCategory sets the category's updated_at to now and changes nothing else,
+// so caches validated against it are refreshed. It returns the new timestamp.
+func (r *lookupRepository) TouchCategory(ctx context.Context, id uuid.UUID) (time.Time, error) {
+	if err := checkRowWritable(ctx, r.db.WithContext(ctx), "lookup_categories", id); err != nil {
+		return time.Time{}, err
+	}
+	now := time.Now()
+	// UpdateColumn skips the save hooks, which could otherwise adjust other fields
+	result := r.db.WithContext(ctx).
+		Model(&models.LookupCategory{}).
+		Scopes(scopeToOrgWrites(ctx, "lookup_categories")).
+		Where("id = ?", id).
+		UpdateColumn("updated_at", now)
+	if result.Error != nil {
+		return time.Time{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return time.Time{}, gorm.ErrRecordNotFound
+	}
+	return now, nil
+}
+
+// restoreIncidentForm puts the just reactivated categories whose
+// incident_form_intent is set back on the incident form. Categories that can't
+// join it now, see incidentFormSkipReason, keep the intent and stay off.
+func restoreIncidentForm(ctx context.Context, tx *gorm.DB, ids []uuid.UUID) error {
+	var categories []models.LookupCategory
+	err := tx.Scopes(scopeToOrgWrites(ctx, "lookup_categories")).
+		Where("id IN ? AND incident_form_intent = ? AND add_to_incident_form = ?", ids, true, false).
+		Order("code").
+		Find(&categories).Error
+	if err != nil || len(categories) == 0 {
+		return err
+	}
+
+	batchPrefixes := make(map[string]string, len(categories))
+	restore := make([]uuid.UUID, 0, len(categories))
+	for i := range categories {
+		reason, err := incidentFormSkipReason(ctx, tx, &categories[i], batchPrefixes)
+		if err != nil {
+			return err
+		}
+		if reason != "" {
+			continue
+		}
+		batchPrefixes[lookupCodePrefix(categories[i].Code)] = categories[i].Code
+		restore = append(restore, categories[i].ID)
+	}
+	if len(restore) == 0 {
+		return nil
+	}
+	return tx.Model(&models.LookupCategory{}).
+		Where("id IN ?", restore).
+		Update("add_to_incident_form", true).Error
+}
+
+// lockCategory loads the category visible to ctx with a row lock
+func lockCategory(ctx context.Context, tx *gorm.DB, id uuid.UUID) (*models.LookupCategory, error) {
+	var category models.LookupCategory
+	err := tx.Scopes(scopeToOrg(ctx, "lookup_categories")).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&category, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// valueStatusColumns adds status, and the is_active flag that goes with it, to
+// the columns of a map update of values. Map updates skip
+// LookupValue.BeforeSave, so the status is checked here instead.
+func valueStatusColumns(status string, columns map[string]interface{}) (map[string]interface{}, error) {
+	if err := models.ValidateLookupValueStatus(status); err != nil {
+		return nil, err
+	}
+	columns["status"] = status
+	columns["is_active"] = status == models.LookupValueStatusActive
+	return columns, nil
+}
+
+// lockWritableCategory is lockCategory for changes to the category itself,
+// which the tenant in ctx must own; see checkOrgWritable
+func lockWritableCategory(ctx context.Context, tx *gorm.DB, id uuid.UUID) (*models.LookupCategory, error) {
+	category, err := lockCategory(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkOrgWritable(ctx, category.OrgID); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// CategoryCodeCollisions lists category codes held by more than one category,
+// counting soft-deleted ones since the unique indexes cover them too. With
+// perOrg the codes are grouped per org, matching the indexes created by
+// database.Migrate; otherwise across all orgs. It ignores tenant scoping so it
+// can run before an index is created or the scope is tightened.
+func (r *lookupRepository) CategoryCodeCollisions(ctx context.Context, perOrg bool) ([]models.LookupCodeCollision, error) {
+	var collisions []models.LookupCodeCollision
+	query := r.db.WithContext(ctx).Unscoped().Model(&models.LookupCategory{})
+	if perOrg {
+		query = query.Select("org_id, code, COUNT(*) AS count").Group("org_id, code")
+	} else {
+		query = query.Select("code, COUNT(*) AS count").Group("code")
+	}
+	err := query.Having("COUNT(*) > 1").Order("code ASC").Scan(&collisions).Error
+	return collisions, err
+}
+
+// ListCategoryCodesByPrefix returns up to limit codes of visible categories
+// that start with prefix, in alphabetical order
+func (r *lookupRepository) ListCategoryCodesByPrefix(ctx context.Context, prefix string, limit int) ([]string, error) {
+	codes := []string{}
+	err := r.db.WithContext(ctx).
+		Model(&models.LookupCategory{}).
+		Scopes(scopeToOrg(ctx, "lookup_categories")).
+		Where("code LIKE ? ESCAPE '\\'", escapeLike(prefix)+"%").
+		Order("code ASC").
+		Limit(limit).
+		Pluck("code", &codes).Error
+	return codes, err
+}
+
+// SuggestCategoryCode returns base if no category visible to ctx uses it, or
+// else base_N with the smallest free N >= 2, so gaps left by deleted or renamed
+// categories are reused. Soft-deleted categories count as taken because the
+// unique indexes still cover them. base is shortened when needed to keep the
+// suggestion within the 50-character code limit.
+func (r *lookupRepository) SuggestCategoryCode(ctx context.Context, base string) (string, error) {
+	const maxCodeLength = 50
+	if len(base) > maxCodeLength {
+		base = base[:maxCodeLength]
+	}
+
+	var taken []string
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Model(&models.LookupCategory{}).
+		Scopes(scopeToOrg(ctx, "lookup_categories")).
+		Where("code = ? OR code LIKE ? ESCAPE '\\'", base, escapeLike(base)+"\\_%").
+		Pluck("code", &taken).Error
+	if err != nil {
+		return "", err
+	}
+
+	baseTaken := false
+	used := make(map[int]bool, len(taken))
+	for _, code := range taken {
+		if code == base {
+			baseTaken = true
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimPrefix(code, base+"_")); err == nil && n >= 2 {
+			used[n] = true
+		}
+	}
+	if !baseTaken {
+		return base, nil
+	}
+
+	n := 2
+	for used[n] {
+		n++
+	}
+	suffix := "_" + strconv.Itoa(n)
+	if len(base)+len(suffix) > maxCodeLength {
+		// A shorter base is a different code family; start over from it
+		return r.SuggestCategoryCode(ctx, base[:maxCodeLength-len(suffix)])
+	}
+	return base + suffix, nil
+}
+
+// ErrLookupIncidentFormPrefixTaken is returned when a category would join the
+// incident form while another category with the same code prefix is on it
+var ErrLookupIncidentFormPrefixTaken = errors.New("another incident form category uses the same code prefix")
+
+// lookupCodePrefix is the group a category code belongs to on the incident
+// form: the part before the first underscore, e.g. PRIORITY for PRIORITY_LEGACY
+func lookupCodePrefix(code string) string {
+	prefix, _, _ := strings.Cut(strings.ToUpper(code), "_")
+	return prefix
+}
+
+// SetCategoriesIncidentForm sets add_to_incident_form on the given categories
+// with a single UPDATE. Adding skips, with the reason, categories that are
+// inactive, lack an Arabic name, or share a code prefix with a category on the
+// form or one added earlier in the batch. Removing always applies.
+func (r *lookupRepository) SetCategoriesIncidentForm(ctx context.Context, ids []uuid.UUID, add bool) (*models.LookupCategoriesIncidentFormResult, error) {
+	result := &models.LookupCategoriesIncidentFormResult{
+		Updated:  []uuid.UUID{},
+		Skipped:  []models.LookupIncidentFormSkip{},
+		NotFound: []uuid.UUID{},
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var found []models.LookupCategory
+		err := tx.Scopes(scopeToOrg(ctx, "lookup_categories")).
+			Where("id IN ?", ids).
+			Find(&found).Error
+		if err != nil {
+			return err
+		}
+		byID := make(map[uuid.UUID]*models.LookupCategory, len(found))
+		for i := range found {
+			byID[found[i].ID] = &found[i]
+		}
+
+		seen := make(map[uuid.UUID]bool, len(ids))
+		batchPrefixes := make(map[string]string)
+		for _, id := range ids {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			category, ok := byID[id]
+			if !ok {
+				result.NotFound = append(result.NotFound, id)
+				continue
+			}
+			if checkOrgWritable(ctx, category.OrgID) != nil {
+				result.Skipped = append(result.Skipped, models.LookupIncidentFormSkip{ID: id, Code: category.Code, Reason: "global categories can only be changed by a super admin"})
+				continue
+			}
+
+			if add {
+				reason, err := incidentFormSkipReason(ctx, tx, category, batchPrefixes)
+				if err != nil {
+					return err
+				}
+				if reason != "" {
+					result.Skipped = append(result.Skipped, models.LookupIncidentFormSkip{ID: id, Code: category.Code, Reason: reason})
+					continue
+				}
+				batchPrefixes[lookupCodePrefix(category.Code)] = category.Code
+			}
+			result.Updated = append(result.Updated, id)
+		}
+		if len(result.Updated) == 0 {
+			return nil
+		}
+
+		return tx.Model(&models.LookupCategory{}).
+			Where("id IN ?", result.Updated).
+			Updates(map[string]interface{}{"add_to_incident_form": add, "incident_form_intent": add}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// incidentFormSkipReason says why category cannot join the incident form, or
+// "" when it can. batchPrefixes maps the code prefixes joining in the same
+// batch to the category code claiming each.
+func incidentFormSkipReason(ctx context.Context, tx *gorm.DB, category *models.LookupCategory, batchPrefixes map[string]string) (string, error) {
+	if !category.IsActive {
+		return "category is inactive", nil
+	}
+	if strings.TrimSpace(category.NameAr) == "" {
+		return "an Arabic name is required on the incident form", nil
+	}
+	if code, ok := batchPrefixes[lookupCodePrefix(category.Code)]; ok {
+		return fmt.Sprintf("%s, earlier in the batch, has the same code prefix", code), nil
+	}
+	probe := *category
+	probe.AddToIncidentForm = true
+	conflict, err := incidentFormPrefixConflict(ctx, tx, &probe)
+	if err != nil {
+		return "", err
+	}
+	if conflict != nil {
+		return fmt.Sprintf("%s, with the same code prefix, is already on the incident form", conflict.Code), nil
+	}
+	return "", nil
+}
+
+// FindIncidentFormPrefixConflict returns the active incident-form category,
+// other than category itself, whose code has the same prefix as category's, or
+// nil when there is none. The incident form shows one category per prefix.
+func (r *lookupRepository) FindIncidentFormPrefixConflict(ctx context.Context, category *models.LookupCategory) (*models.LookupCategory, error) {
+	return incidentFormPrefixConflict(ctx, r.db.WithContext(ctx), category)
+}
+
+func incidentFormPrefixConflict(ctx context.Context, db *gorm.DB, category *models.LookupCategory) (*models.LookupCategory, error) {
+	prefix := lookupCodePrefix(category.Code)
+
+	var conflict models.LookupCategory
+	err := db.Scopes(scopeToOrg(ctx, "lookup_categories")).
+		Where("add_to_incident_form = ? AND is_active = ?", true, true).
+		Where("code = ? OR code LIKE ? ESCAPE '\\'", prefix, escapeLike(prefix)+"\\_%").
+		Where("id <> ?", category.ID).
+		Order("code ASC").
+		First(&conflict).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &conflict, nil
+}
+
+// checkIncidentFormPrefix fails with ErrLookupIncidentFormPrefixTaken when
+// category is on the incident form and its prefix is already represented there
+func checkIncidentFormPrefix(ctx context.Context, tx *gorm.DB, category *models.LookupCategory) error {
+	if !category.AddToIncidentForm || !category.IsActive {
+		return nil
+	}
+	conflict, err := incidentFormPrefixConflict(ctx, tx, category)
+	if err != nil {
+		return err
+	}
+	if conflict != nil {
+		return fmt.Errorf("%w: %s", ErrLookupIncidentFormPrefixTaken, conflict.Code)
+	}
+	return nil
+}
+
+// escapeLike escapes the LIKE wildcards in s; codes often contain underscores
+func escapeLike(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(s)
+}
+
+// DeleteCategory soft-deletes the category together with all of its values.
+// Both remain available through Unscoped queries.
 func (r *lookupRepository) DeleteCategory(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// Delete all values in the category first
-		if err := tx.Where("category_id = ?", id).Delete(&models.LookupValue{}).Error; err != nil {
+	return r.withTx(ctx, func(tx *lookupRepository) error {
+		if err := checkRowWritable(ctx, tx.db, "lookup_categories", id); err != nil {
+			return err
+		}
+		// Soft-delete all values in the category first
+		if err := tx.db.Where("category_id = ?", id).Delete(&models.LookupValue{}).Error; err != nil {
 			return err
 		}
-		// Delete the category
-		return tx.Delete(&models.LookupCategory{}, "id = ?", id).Error
+		// Soft-delete the category
+		return tx.db.Delete(&models.LookupCategory{}, "id = ?", id).Error
 	})
 }
 
@@ -88,72 +691,2070 @@ func (r *lookupRepository) ListCategories(ctx context.Context) ([]models.LookupC
 	var categories []models.LookupCategory
 	err := r.db.WithContext(ctx).
 		Preload("Values", func(db *gorm.DB) *gorm.DB {
-			return db.Order("sort_order ASC, name ASC")
+			return db.Scopes(scopeToOrg(ctx, "lookup_values")).Order(lookupValueOrder)
 		}).
+		Scopes(scopeToOrg(ctx, "lookup_categories")).
 		Order("name ASC").
 		Find(&categories).Error
 	return categories, err
 }
 
-// Value methods
+// ListCategoriesWithoutValues returns the visible categories by name, leaving
+// Values empty, for callers that load each category's values in turn
+func (r *lookupRepository) ListCategoriesWithoutValues(ctx context.Context) ([]models.LookupCategory, error) {
+	var categories []models.LookupCategory
+	err := r.db.WithContext(ctx).
+		Scopes(scopeToOrg(ctx, "lookup_categories")).
+		Order("name ASC").
+		Find(&categories).Error
+	return categories, err
+}
 
-func (r *lookupRepository) CreateValue(ctx context.Context, value *models.LookupValue) error {
-	return r.db.WithContext(ctx).Create(value).Error
+// ListCategoriesPaged returns one page of categories matching the list options,
+// each with its values, plus the total number of matches
+func (r *lookupRepository) ListCategoriesPaged(ctx context.Context, opts utils.ListOptions) ([]models.LookupCategory, int64, error) {
+	var categories []models.LookupCategory
+	var total int64
+
+	query := r.db.WithContext(ctx).
+		Model(&models.LookupCategory{}).
+		Scopes(scopeToOrg(ctx, "lookup_categories"))
+	if opts.Search != "" {
+		searchPattern := "%" + opts.Search + "%"
+		query = query.Where("code ILIKE ? OR name ILIKE ? OR name_ar ILIKE ?", searchPattern, searchPattern, searchPattern)
+	}
+	if opts.Active != nil {
+		query = query.Where("is_active = ?", *opts.Active)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.
+		Preload("Values", func(db *gorm.DB) *gorm.DB {
+			return db.Scopes(scopeToOrg(ctx, "lookup_values")).Order(lookupValueOrder)
+		}).
+		Order(opts.OrderBy(lookupCategorySortColumns, "name ASC")).
+		Offset(opts.Offset()).
+		Limit(opts.Limit).
+		Find(&categories).Error
+	return categories, total, err
 }
 
-func (r *lookupRepository) FindValueByID(ctx context.Context, id uuid.UUID) (*models.LookupValue, error) {
-	var value models.LookupValue
-	err := r.db.WithContext(ctx).
-		Preload("Category").
-		First(&value, "id = ?", id).Error
+// SetCategoriesActive activates or deactivates the given categories with a
+// single UPDATE. System categories are left alone and reported as skipped.
+// Deactivating also clears add_to_incident_form, as LookupCategory.BeforeSave
+// does for single saves (hooks don't run on bulk updates); activating restores
+// it from incident_form_intent, see restoreIncidentForm.
+func (r *lookupRepository) SetCategoriesActive(ctx context.Context, ids []uuid.UUID, active bool) (*models.LookupCategoriesActiveResult, error) {
+	result := &models.LookupCategoriesActiveResult{Skipped: []uuid.UUID{}, NotFound: []uuid.UUID{}}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var found []models.LookupCategory
+		err := tx.Select("id", "org_id", "is_system").
+			Scopes(scopeToOrg(ctx, "lookup_categories")).
+			Where("id IN ?", ids).
+			Find(&found).Error
+		if err != nil {
+			return err
+		}
+
+		skip := make(map[uuid.UUID]bool, len(found))
+		for _, cat := range found {
+			skip[cat.ID] = cat.IsSystem || checkOrgWritable(ctx, cat.OrgID) != nil
+		}
+		toUpdate := make([]uuid.UUID, 0, len(found))
+		for _, id := range ids {
+			skipped, ok := skip[id]
+			switch {
+			case !ok:
+				result.NotFound = append(result.NotFound, id)
+			case skipped:
+				result.Skipped = append(result.Skipped, id)
+			default:
+				toUpdate = append(toUpdate, id)
+			}
+		}
+		if len(toUpdate) == 0 {
+			return nil
+		}
+
+		updates := map[string]interface{}{"is_active": active}
+		if !active {
+			updates["add_to_incident_form"] = false
+		} else {
+			updates["archived_at"] = nil
+		}
+		res := tx.Model(&models.LookupCategory{}).
+			Where("id IN ?", toUpdate).
+			Updates(updates)
+		if res.Error != nil {
+			return res.Error
+		}
+		result.Updated = res.RowsAffected
+		if !active {
+			return nil
+		}
+		return restoreIncidentForm(ctx, tx, toUpdate)
+	})
 	if err != nil {
 		return nil, err
 	}
-	return &value, nil
+	return result, nil
 }
 
-func (r *lookupRepository) UpdateValue(ctx context.Context, value *models.LookupValue) error {
-	return r.db.WithContext(ctx).Save(value).Error
-}
+// ResetCategoryToSeed restores the seeded values of a system category in one
+// transaction: missing or deleted seeded values are re-created, and all seeded
+// values are reactivated with their seeded sort order and default flag. Values
+// added by admins are kept unless strict is set, in which case they are
+// soft-deleted. The default is left alone on categories with a locked default
+// and is otherwise reset under the category's default lock.
+func (r *lookupRepository) ResetCategoryToSeed(ctx context.Context, category *models.LookupCategory, seed *models.LookupCategorySeed, strict bool) (*models.LookupResetResult, error) {
+	result := &models.LookupResetResult{Created: []string{}, Restored: []string{}, Removed: []string{}}
 
-func (r *lookupRepository) DeleteValue(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Delete(&models.LookupValue{}, "id = ?", id).Error
+	if err := checkOrgWritable(ctx, category.OrgID); err != nil {
+		return nil, err
+	}
+	err := r.withDefaultLock(ctx, category.ID, func(repo *lookupRepository) error {
+		tx := repo.db
+		var existing []models.LookupValue
+		err := tx.Unscoped().
+			Scopes(scopeToOrgWrites(ctx, "lookup_values")).
+			Where("category_id = ?", category.ID).
+			Find(&existing).Error
+		if err != nil {
+			return err
+		}
+		byCode := make(map[string]*models.LookupValue, len(existing))
+		for i := range existing {
+			byCode[existing[i].Code] = &existing[i]
+		}
+
+		seeded := make(map[string]bool, len(seed.Values))
+		for _, valueSeed := range seed.Values {
+			seeded[valueSeed.Code] = true
+
+			value, ok := byCode[valueSeed.Code]
+			if !ok {
+				v := valueSeed.NewValue(category)
+				if category.LockDefault {
+					v.IsDefault = false
+				}
+				if err := tx.Select("*").Omit("Category").Create(&v).Error; err != nil {
+					return err
+				}
+				result.Created = append(result.Created, v.Code)
+				continue
+			}
+
+			updates, err := valueStatusColumns(models.LookupValueStatusActive, map[string]interface{}{
+				"sort_order": valueSeed.SortOrder,
+				"deleted_at": nil,
+			})
+			if err != nil {
+				return err
+			}
+			if !category.LockDefault {
+				updates["is_default"] = valueSeed.IsDefault
+			}
+			if err := tx.Unscoped().Model(value).Updates(updates).Error; err != nil {
+				return err
+			}
+			result.Restored = append(result.Restored, value.Code)
+		}
+
+		for _, value := range existing {
+			if seeded[value.Code] || value.DeletedAt.Valid {
+				continue
+			}
+			if strict {
+				if err := tx.Delete(&models.LookupValue{}, "id = ?", value.ID).Error; err != nil {
+					return err
+				}
+				result.Removed = append(result.Removed, value.Code)
+				continue
+			}
+			// Custom values stay, but the seeded default wins
+			if value.IsDefault && !category.LockDefault {
+				if err := tx.Model(&value).Update("is_default", false).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
-func (r *lookupRepository) ListValuesByCategory(ctx context.Context, categoryID uuid.UUID) ([]models.LookupValue, error) {
-	var values []models.LookupValue
+// ListIncidentFormCategories returns the active categories on the incident
+// form with their active values
+func (r *lookupRepository) ListIncidentFormCategories(ctx context.Context) ([]models.LookupCategory, error) {
+	var categories []models.LookupCategory
 	err := r.db.WithContext(ctx).
-		Where("category_id = ?", categoryID).
-		Order("sort_order ASC, name ASC").
-		Find(&values).Error
-	return values, err
+		Preload("Values", func(db *gorm.DB) *gorm.DB {
+			return db.Scopes(scopeToOrg(ctx, "lookup_values"), scopeToRole(ctx, "lookup_values")).Where("is_active = ?", true).Order(lookupValueOrder)
+		}).
+		Scopes(scopeToOrg(ctx, "lookup_categories")).
+		Where("is_active = ? AND add_to_incident_form = ?", true, true).
+		Order("name ASC").
+		Find(&categories).Error
+	return categories, err
 }
 
-func (r *lookupRepository) ListValuesByCategoryCode(ctx context.Context, code string) ([]models.LookupValue, error) {
-	var values []models.LookupValue
+// ListActiveCategories returns active categories with their active values
+func (r *lookupRepository) ListActiveCategories(ctx context.Context) ([]models.LookupCategory, error) {
+	var categories []models.LookupCategory
 	err := r.db.WithContext(ctx).
-		Joins("JOIN lookup_categories ON lookup_categories.id = lookup_values.category_id").
-		Where("lookup_categories.code = ? AND lookup_categories.is_active = ? AND lookup_values.is_active = ?", code, true, true).
-		Order("lookup_values.sort_order ASC, lookup_values.name ASC").
-		Find(&values).Error
-	return values, err
+		Preload("Values", func(db *gorm.DB) *gorm.DB {
+			return db.Scopes(scopeToOrg(ctx, "lookup_values"), scopeToRole(ctx, "lookup_values")).Where("is_active = ?", true).Order(lookupValueOrder)
+		}).
+		Scopes(scopeToOrg(ctx, "lookup_categories")).
+		Where("is_active = ?", true).
+		Order("name ASC").
+		Find(&categories).Error
+	return categories, err
 }
 
-func (r *lookupRepository) GetDefaultValue(ctx context.Context, categoryCode string) (*models.LookupValue, error) {
-	var value models.LookupValue
+// GetLookupStats computes the dashboard totals with aggregate queries only
+func (r *lookupRepository) GetLookupStats(ctx context.Context) (models.LookupStats, error) {
+	var stats models.LookupStats
+
 	err := r.db.WithContext(ctx).
-		Joins("JOIN lookup_categories ON lookup_categories.id = lookup_values.category_id").
-		Where("lookup_categories.code = ? AND lookup_values.is_default = ? AND lookup_values.is_active = ?", categoryCode, true, true).
-		First(&value).Error
+		Model(&models.LookupCategory{}).
+		Scopes(scopeToOrg(ctx, "lookup_categories")).
+		Select(`COUNT(*) AS total_categories,
+			COUNT(*) FILTER (WHERE is_active) AS active_categories,
+			COUNT(*) FILTER (WHERE NOT is_active) AS inactive_categories,
+			COUNT(*) FILTER (WHERE is_system) AS system_categories,
+			COUNT(*) FILTER (WHERE NOT is_system) AS custom_categories,
+			COUNT(*) FILTER (WHERE add_to_incident_form) AS incident_form_count`).
+		Scan(&stats).Error
 	if err != nil {
-		return nil, err
+		return stats, err
 	}
-	return &value, nil
-}
 
-func (r *lookupRepository) ClearDefaultForCategory(ctx context.Context, categoryID uuid.UUID) error {
+	err = r.db.WithContext(ctx).
+		Model(&models.LookupValue{}).
+		Joins(joinActiveCategory).
+		Scopes(scopeToOrg(ctx, "lookup_values"), scopeToOrg(ctx, "lookup_categories")).
+		Count(&stats.TotalValues).Error
+	return stats, err
+}
+
+// CountCategoryUsage counts the incidents, not deleted, that use a value of the
+// category, and how many of its values they use
+func (r *lookupRepository) CountCategoryUsage(ctx context.Context, categoryID uuid.UUID) (models.LookupCategoryUsage, error) {
+	var usage models.LookupCategoryUsage
+	err := r.db.WithContext(ctx).
+		Table("incident_lookup_values").
+		Select("COUNT(DISTINCT incident_lookup_values.incident_id) AS incidents, COUNT(DISTINCT incident_lookup_values.lookup_value_id) AS values_in_use").
+		Joins("JOIN lookup_values ON lookup_values.id = incident_lookup_values.lookup_value_id AND lookup_values.deleted_at IS NULL").
+		Joins("JOIN incidents ON incidents.id = incident_lookup_values.incident_id AND incidents.deleted_at IS NULL").
+		Scopes(scopeToOrg(ctx, "lookup_values")).
+		Where("lookup_values.category_id = ?", categoryID).
+		Scan(&usage).Error
+	return usage, err
+}
+
+// ListColorUsage counts the values using each color, most used first. Colors
+// are upper-cased and trimmed before grouping so "#ff0000" and "#FF0000" count
+// as one; values without a color are left out.
+func (r *lookupRepository) ListColorUsage(ctx context.Context) ([]models.LookupColorUsage, error) {
+	var usage []models.LookupColorUsage
+	err := r.db.WithContext(ctx).
+		Model(&models.LookupValue{}).
+		Joins(joinActiveCategory).
+		Scopes(scopeToOrg(ctx, "lookup_values"), scopeToOrg(ctx, "lookup_categories")).
+		Select("UPPER(TRIM(lookup_values.color)) AS color, COUNT(*) AS count").
+		Where("TRIM(COALESCE(lookup_values.color, '')) <> ''").
+		Group("UPPER(TRIM(lookup_values.color))").
+		Order("count DESC, color ASC").
+		Scan(&usage).Error
+	return usage, err
+}
+
+// RecolorValues replaces colors across all values visible to ctx in one
+// transaction. mapping goes from old to new color, both normalized with
+// utils.NormalizeColor; old colors match values regardless of case and
+// surrounding spaces. Matches are collected before any update, so chained
+// mappings such as A→B and B→C do not turn A into C.
+func (r *lookupRepository) RecolorValues(ctx context.Context, mapping map[string]string) (*models.LookupRecolorResult, error) {
+	result := &models.LookupRecolorResult{Changed: make(map[string]int64, len(mapping))}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		matches := make(map[string][]uuid.UUID, len(mapping))
+		for old := range mapping {
+			var ids []uuid.UUID
+			err := tx.Model(&models.LookupValue{}).
+				Scopes(scopeToOrgWrites(ctx, "lookup_values")).
+				Where("UPPER(TRIM(lookup_values.color)) = ?", old).
+				Pluck("lookup_values.id", &ids).Error
+			if err != nil {
+				return err
+			}
+			matches[old] = ids
+		}
+
+		now := time.Now()
+		for old, ids := range matches {
+			result.Changed[old] = 0
+			if len(ids) == 0 {
+				continue
+			}
+			res := tx.Model(&models.LookupValue{}).
+				Where("id IN ?", ids).
+				UpdateColumns(map[string]interface{}{"color": mapping[old], "updated_at": now})
+			if res.Error != nil {
+				return res.Error
+			}
+			result.Changed[old] = res.RowsAffected
+			result.Total += res.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListRecentlyUpdated returns the most recently changed categories and values,
+// newest first. Each table is read through its updated_at index, at most limit
+// rows apiece, and the two lists are merged here.
+func (r *lookupRepository) ListRecentlyUpdated(ctx context.Context, limit int) ([]models.LookupRecentChange, error) {
+	var categories []models.LookupCategory
+	err := r.db.WithContext(ctx).
+		Scopes(scopeToOrg(ctx, "lookup_categories")).
+		Order("updated_at DESC").
+		Limit(limit).
+		Find(&categories).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var values []models.LookupValue
+	err = r.db.WithContext(ctx).
+		Joins(joinActiveCategory).
+		Scopes(scopeToOrg(ctx, "lookup_values"), scopeToOrg(ctx, "lookup_categories")).
+		Order("lookup_values.updated_at DESC").
+		Limit(limit).
+		Find(&values).Error
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]models.LookupRecentChange, 0, len(categories)+len(values))
+	for _, c := range categories {
+		changes = append(changes, models.LookupRecentChange{
+			Type:       "category",
+			ID:         c.ID,
+			CategoryID: c.ID,
+			Code:       c.Code,
+			Name:       c.Name,
+			UpdatedAt:  c.UpdatedAt,
+		})
+	}
+	for _, v := range values {
+		changes = append(changes, models.LookupRecentChange{
+			Type:       "value",
+			ID:         v.ID,
+			CategoryID: v.CategoryID,
+			Code:       v.Code,
+			Name:       v.Name,
+			UpdatedAt:  v.UpdatedAt,
+		})
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].UpdatedAt.After(changes[j].UpdatedAt)
+	})
+	if len(changes) > limit {
+		changes = changes[:limit]
+	}
+	return changes, nil
+}
+
+// Value methods
+
+func (r *lookupRepository) CreateValue(ctx context.Context, value *models.LookupValue) error {
+	if err := checkOrgWritable(ctx, value.OrgID); err != nil {
+		return err
+	}
+	if err := checkValueChange(r.db.WithContext(ctx), value); err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Create(value).Error
+}
+
+func (r *lookupRepository) FindValueByID(ctx context.Context, id uuid.UUID) (*models.LookupValue, error) {
+	var value models.LookupValue
+	err := r.db.WithContext(ctx).
+		Preload("Category").
+		Scopes(scopeToOrg(ctx, "lookup_values"), scopeToRole(ctx, "lookup_values")).
+		First(&value, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+// ValueIDExists reports whether any value has the ID, in any org and deleted
+// or not, since all of them hold the primary key
+func (r *lookupRepository) ValueIDExists(ctx context.Context, id uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Unscoped().
+		Model(&models.LookupValue{}).
+		Where("id = ?", id).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// FindValueByCode finds a value by code within a category, active or not
+func (r *lookupRepository) FindValueByCode(ctx context.Context, categoryID uuid.UUID, code string) (*models.LookupValue, error) {
+	var value models.LookupValue
+	err := r.db.WithContext(ctx).
+		Scopes(scopeToOrg(ctx, "lookup_values")).
+		Where("category_id = ? AND code = ?", categoryID, code).
+		First(&value).Error
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+// maxLookupValueDepth bounds the parent walk of FindValueAncestors, so a cycle
+// left in the data by a direct database edit cannot loop forever
+const maxLookupValueDepth = 32
+
+// FindValueAncestors returns the value's parent chain from the root down to the
+// value itself, or just the value when it has no parent. The chain stops at a
+// parent that is deleted or not visible to ctx, and at a repeated value.
+func (r *lookupRepository) FindValueAncestors(ctx context.Context, id uuid.UUID) ([]models.LookupValue, error) {
+	var chain []struct {
+		ID    uuid.UUID
+		Depth int
+	}
+	err := r.db.WithContext(ctx).Raw(`
+		WITH RECURSIVE chain AS (
+			SELECT id, parent_id, 0 AS depth FROM lookup_values
+			WHERE id = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT v.id, v.parent_id, chain.depth + 1 FROM lookup_values v
+			JOIN chain ON v.id = chain.parent_id
+			WHERE v.deleted_at IS NULL AND chain.depth < ?
+		)
+		SELECT id, depth FROM chain ORDER BY depth`, id, maxLookupValueDepth).
+		Scan(&chain).Error
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, len(chain))
+	for i, link := range chain {
+		ids[i] = link.ID
+	}
+	var found []models.LookupValue
+	if len(ids) > 0 {
+		err = r.db.WithContext(ctx).
+			Scopes(scopeToOrg(ctx, "lookup_values")).
+			Where("id IN ?", ids).
+			Find(&found).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	byID := make(map[uuid.UUID]models.LookupValue, len(found))
+	for _, v := range found {
+		byID[v.ID] = v
+	}
+
+	// chain runs from the value up; keep the visible, non-repeating prefix
+	ancestors := make([]models.LookupValue, 0, len(chain))
+	seen := make(map[uuid.UUID]bool, len(chain))
+	for _, link := range chain {
+		v, ok := byID[link.ID]
+		if !ok || seen[link.ID] {
+			break
+		}
+		seen[link.ID] = true
+		ancestors = append(ancestors, v)
+	}
+	if len(ancestors) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	for i, j := 0, len(ancestors)-1; i < j; i, j = i+1, j-1 {
+		ancestors[i], ancestors[j] = ancestors[j], ancestors[i]
+	}
+	return ancestors, nil
+}
+
+func (r *lookupRepository) UpdateValue(ctx context.Context, value *models.LookupValue) error {
+	if err := checkRowWritable(ctx, r.db.WithContext(ctx), "lookup_values", value.ID); err != nil {
+		return err
+	}
+	if err := checkValueChange(r.db.WithContext(ctx), value); err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Save(value).Error
+}
+
+var (
+	// ErrLookupDefaultLocked is returned when a write would change the default
+	// of a category whose default is locked
+	ErrLookupDefaultLocked = errors.New("the default value of this category is locked")
+	// ErrLookupSystemValueFixed is returned when a write would change what is
+	// fixed on the values of a system category. Integrations match those
+	// values by code, so their code, default flag and category stay and they
+	// can't be deleted.
+	ErrLookupSystemValueFixed = errors.New("the code, default flag and category of a system category's values are fixed")
+)
+
+// checkDefaultChangeable returns ErrLookupSystemValueFixed for a system
+// category and ErrLookupDefaultLocked for one whose default is locked, as the
+// default of neither may change. A missing category passes, leaving the write
+// to fail.
+func checkDefaultChangeable(tx *gorm.DB, categoryID uuid.UUID) error {
+	var category models.LookupCategory
+	err := tx.Select("id", "is_system", "lock_default").First(&category, "id = ?", categoryID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if category.IsSystem {
+		return ErrLookupSystemValueFixed
+	}
+	if category.LockDefault {
+		return ErrLookupDefaultLocked
+	}
+	return nil
+}
+
+// checkValueChange guards saving value, new or stored: a new value may only be
+// the default, and a stored one only change its default flag, where
+// checkDefaultChangeable allows. A system category's values keep their code.
+func checkValueChange(tx *gorm.DB, value *models.LookupValue) error {
+	var stored models.LookupValue
+	err := tx.Select("id", "code", "is_default").First(&stored, "id = ?", value.ID).Error
+	created := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !created {
+		return err
+	}
+	if stored.IsDefault != value.IsDefault {
+		return checkDefaultChangeable(tx, value.CategoryID)
+	}
+	if created || stored.Code == value.Code {
+		return nil
+	}
+	var system int64
+	err = tx.Model(&models.LookupCategory{}).
+		Where("id = ? AND is_system = ?", value.CategoryID, true).
+		Count(&system).Error
+	if err != nil {
+		return err
+	}
+	if system > 0 {
+		return ErrLookupSystemValueFixed
+	}
+	return nil
+}
+
+// ErrLookupSortOrderExhausted is returned when making room for a value would
+// push another value past models.MaxLookupSortOrder
+var ErrLookupSortOrderExhausted = errors.New("no room to shift values past the maximum sort_order")
+
+// CreateValueAtSortOrder creates the value, first moving the category's values
+// at or after its sort_order down by one when the slot is taken.
+func (r *lookupRepository) CreateValueAtSortOrder(ctx context.Context, value *models.LookupValue) error {
+	if err := checkOrgWritable(ctx, value.OrgID); err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkValueChange(tx, value); err != nil {
+			return err
+		}
+		if err := shiftSortOrders(ctx, tx, value); err != nil {
+			return err
+		}
+		return tx.Create(value).Error
+	})
+}
+
+// UpdateValueAtSortOrder saves the value like CreateValueAtSortOrder creates it
+func (r *lookupRepository) UpdateValueAtSortOrder(ctx context.Context, value *models.LookupValue) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkRowWritable(ctx, tx, "lookup_values", value.ID); err != nil {
+			return err
+		}
+		if err := checkValueChange(tx, value); err != nil {
+			return err
+		}
+		if err := shiftSortOrders(ctx, tx, value); err != nil {
+			return err
+		}
+		return tx.Save(value).Error
+	})
+}
+
+// shiftSortOrders frees value.SortOrder within its category. The category row
+// is locked so concurrent inserts into the same category shift one at a time.
+// Only values ctx may write are shifted; a tenant's value can share a slot
+// with a global one.
+func shiftSortOrders(ctx context.Context, tx *gorm.DB, value *models.LookupValue) error {
+	var category models.LookupCategory
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		First(&category, "id = ?", value.CategoryID).Error; err != nil {
+		return err
+	}
+
+	others := tx.Model(&models.LookupValue{}).
+		Scopes(scopeToOrgWrites(ctx, "lookup_values")).
+		Where("category_id = ? AND id <> ?", value.CategoryID, value.ID)
+
+	var occupied int64
+	if err := others.Session(&gorm.Session{}).Where("sort_order = ?", value.SortOrder).Count(&occupied).Error; err != nil {
+		return err
+	}
+	if occupied == 0 {
+		return nil
+	}
+
+	var maxOrder int
+	if err := others.Session(&gorm.Session{}).Select("COALESCE(MAX(sort_order), 0)").Scan(&maxOrder).Error; err != nil {
+		return err
+	}
+	if maxOrder >= models.MaxLookupSortOrder {
+		return ErrLookupSortOrderExhausted
+	}
+
+	return others.Session(&gorm.Session{}).
+		Where("sort_order >= ?", value.SortOrder).
+		Update("sort_order", gorm.Expr("sort_order + 1")).Error
+}
+
+// NormalizeSortOrder renumbers the category's active values 0..n-1, keeping
+// their current order (sort_order, then name). Inactive values are left alone.
+// It returns the active values in their new order.
+func (r *lookupRepository) NormalizeSortOrder(ctx context.Context, categoryID uuid.UUID) ([]models.LookupValue, error) {
+	var values []models.LookupValue
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := lockWritableCategory(ctx, tx, categoryID); err != nil {
+			return err
+		}
+
+		err := tx.Scopes(scopeToOrgWrites(ctx, "lookup_values")).
+			Where("category_id = ? AND is_active = ?", categoryID, true).
+			Order("sort_order ASC, name ASC").
+			Find(&values).Error
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for i := range values {
+			if values[i].SortOrder == i {
+				continue
+			}
+			err := tx.Model(&models.LookupValue{}).
+				Where("id = ?", values[i].ID).
+				UpdateColumns(map[string]interface{}{"sort_order": i, "updated_at": now}).Error
+			if err != nil {
+				return err
+			}
+			values[i].SortOrder = i
+			values[i].UpdatedAt = now
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// orphanValues selects the values whose category no longer exists or is
+// soft-deleted, as left behind by legacy hard deletes
+func orphanValues(ctx context.Context, db *gorm.DB) *gorm.DB {
+	return db.
+		Joins("LEFT JOIN lookup_categories ON lookup_categories.id = lookup_values.category_id AND lookup_categories.deleted_at IS NULL").
+		Scopes(scopeToOrg(ctx, "lookup_values")).
+		Where("lookup_categories.id IS NULL")
+}
+
+// ListOrphanValues returns the values whose category_id points to no live
+// category, ordered by their dead category
+func (r *lookupRepository) ListOrphanValues(ctx context.Context) ([]models.LookupValue, error) {
+	var values []models.LookupValue
+	err := orphanValues(ctx, r.db.WithContext(ctx)).
+		Order("lookup_values.category_id ASC, lookup_values.sort_order ASC, lookup_values.name ASC").
+		Find(&values).Error
+	return values, err
+}
+
+// CleanupOrphanValues soft-deletes the orphaned values among ids (all orphans
+// when ids is empty), or moves them into targetCategoryID when it is set.
+// Values that are not orphans are ignored. Moved values lose their default
+// flag; those whose code is already used in the target are skipped.
+func (r *lookupRepository) CleanupOrphanValues(ctx context.Context, ids []uuid.UUID, targetCategoryID *uuid.UUID) (*models.LookupOrphanCleanupResult, error) {
+	result := &models.LookupOrphanCleanupResult{Action: models.LookupOrphanDelete, Skipped: []uuid.UUID{}}
+	if targetCategoryID != nil {
+		result.Action = models.LookupOrphanReassign
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := orphanValues(ctx, tx).
+			Scopes(scopeToOrgWrites(ctx, "lookup_values")).
+			Select("lookup_values.id", "lookup_values.code")
+		if len(ids) > 0 {
+			query = query.Where("lookup_values.id IN ?", ids)
+		}
+		var orphans []models.LookupValue
+		if err := query.Find(&orphans).Error; err != nil {
+			return err
+		}
+		if len(orphans) == 0 {
+			return nil
+		}
+
+		if targetCategoryID == nil {
+			orphanIDs := make([]uuid.UUID, len(orphans))
+			for i, v := range orphans {
+				orphanIDs[i] = v.ID
+			}
+			res := tx.Where("id IN ?", orphanIDs).Delete(&models.LookupValue{})
+			result.Affected = res.RowsAffected
+			return res.Error
+		}
+
+		if _, err := lockCategory(ctx, tx, *targetCategoryID); err != nil {
+			return err
+		}
+		var existing []string
+		if err := tx.Model(&models.LookupValue{}).
+			Where("category_id = ?", *targetCategoryID).
+			Pluck("code", &existing).Error; err != nil {
+			return err
+		}
+		taken := make(map[string]bool, len(existing)+len(orphans))
+		for _, code := range existing {
+			taken[code] = true
+		}
+
+		now := time.Now()
+		for _, v := range orphans {
+			if taken[v.Code] {
+				result.Skipped = append(result.Skipped, v.ID)
+				continue
+			}
+			taken[v.Code] = true
+			err := tx.Model(&models.LookupValue{}).
+				Where("id = ?", v.ID).
+				UpdateColumns(map[string]interface{}{
+					"category_id": *targetCategoryID,
+					"is_default":  false,
+					"updated_at":  now,
+				}).Error
+			if err != nil {
+				return err
+			}
+			result.Affected++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeleteValue soft-deletes the value, unless it belongs to a system category
+func (r *lookupRepository) DeleteValue(ctx context.Context, id uuid.UUID) error {
+	if err := checkRowWritable(ctx, r.db.WithContext(ctx), "lookup_values", id); err != nil {
+		return err
+	}
+	var system int64
+	err := r.db.WithContext(ctx).
+		Model(&models.LookupValue{}).
+		Joins(joinActiveCategory).
+		Where("lookup_values.id = ? AND lookup_categories.is_system = ?", id, true).
+		Count(&system).Error
+	if err != nil {
+		return err
+	}
+	if system > 0 {
+		return ErrLookupSystemValueFixed
+	}
+	return r.db.WithContext(ctx).Delete(&models.LookupValue{}, "id = ?", id).Error
+}
+
+// RestoreValues brings back soft-deleted values of the category in one
+// transaction: those in ids, or all of them when ids is empty. A value whose
+// code a live value of the category now holds stays deleted and is skipped;
+// when several deleted values share a code, the most recently deleted one is
+// restored. IDs that are not deleted values of the category, or that ctx may
+// not write, are skipped too. It returns gorm.ErrRecordNotFound when the
+// category is not visible.
+func (r *lookupRepository) RestoreValues(ctx context.Context, categoryID uuid.UUID, ids []uuid.UUID) ([]models.LookupValue, []models.LookupValueRestoreSkip, error) {
+	var restored []models.LookupValue
+	skipped := []models.LookupValueRestoreSkip{}
+
+	err := r.withTx(ctx, func(tx *lookupRepository) error {
+		if _, err := lockCategory(ctx, tx.db, categoryID); err != nil {
+			return err
+		}
+
+		query := tx.db.Unscoped().
+			Scopes(scopeToOrg(ctx, "lookup_values")).
+			Where("category_id = ? AND deleted_at IS NOT NULL", categoryID)
+		if len(ids) > 0 {
+			query = query.Where("id IN ?", ids)
+		} else {
+			// Restoring everything means everything ctx may restore
+			query = query.Scopes(scopeToOrgWrites(ctx, "lookup_values"))
+		}
+		var deleted []models.LookupValue
+		if err := query.Order("deleted_at DESC").Find(&deleted).Error; err != nil {
+			return err
+		}
+
+		var live []string
+		if err := tx.db.Model(&models.LookupValue{}).
+			Where("category_id = ?", categoryID).
+			Pluck("code", &live).Error; err != nil {
+			return err
+		}
+		taken := make(map[string]bool, len(live)+len(deleted))
+		for _, code := range live {
+			taken[code] = true
+		}
+
+		found := make(map[uuid.UUID]bool, len(deleted))
+		for i := range deleted {
+			value := &deleted[i]
+			found[value.ID] = true
+			if checkOrgWritable(ctx, value.OrgID) != nil {
+				skipped = append(skipped, models.LookupValueRestoreSkip{ID: value.ID, Code: value.Code, Reason: models.LookupRestoreNotWritable})
+				continue
+			}
+			if taken[value.Code] {
+				skipped = append(skipped, models.LookupValueRestoreSkip{ID: value.ID, Code: value.Code, Reason: models.LookupRestoreCodeTaken})
+				continue
+			}
+			taken[value.Code] = true
+			if err := restoreValue(tx.db, value); err != nil {
+				return err
+			}
+			restored = append(restored, *value)
+		}
+		for _, id := range ids {
+			if !found[id] {
+				skipped = append(skipped, models.LookupValueRestoreSkip{ID: id, Reason: models.LookupRestoreNotDeleted})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return restored, skipped, nil
+}
+
+// restoreValue clears the soft delete of value. A restored value never takes
+// the default back, since the category may have a new default by now.
+func restoreValue(tx *gorm.DB, value *models.LookupValue) error {
+	now := time.Now()
+	err := tx.Unscoped().Model(&models.LookupValue{}).
+		Where("id = ?", value.ID).
+		UpdateColumns(map[string]interface{}{
+			"deleted_at": nil,
+			"is_default": false,
+			"updated_at": now,
+		}).Error
+	if err != nil {
+		return err
+	}
+	value.DeletedAt = gorm.DeletedAt{}
+	value.IsDefault = false
+	value.UpdatedAt = now
+	return nil
+}
+
+func (r *lookupRepository) ListValuesByCategory(ctx context.Context, categoryID uuid.UUID) ([]models.LookupValue, error) {
+	var values []models.LookupValue
+	err := r.db.WithContext(ctx).
+		Scopes(scopeToOrg(ctx, "lookup_values")).
+		Where("category_id = ?", categoryID).
+		Order(lookupValueOrder).
+		Find(&values).Error
+	return values, err
+}
+
+// ListValuesByMetadataKey returns the values of the category whose metadata
+// holds value under key. The member is compared as text, so "42" matches the
+// number 42 as well as the string, and "true" matches the boolean.
+func (r *lookupRepository) ListValuesByMetadataKey(ctx context.Context, categoryID uuid.UUID, key, value string) ([]models.LookupValue, error) {
+	var values []models.LookupValue
+	err := r.db.WithContext(ctx).
+		Scopes(scopeToOrg(ctx, "lookup_values")).
+		Where("category_id = ? AND metadata ->> ? = ?", categoryID, key, value).
+		Order(lookupValueOrder).
+		Find(&values).Error
+	return values, err
+}
+
+// ListValuesByCategoryPaged returns one page of a category's values matching
+// the list options, plus the total number of matches. A non-empty tagCode keeps
+// only the values carrying that tag. With opts.SkipTotal no
+// COUNT is run: one row past the page is fetched instead and the total is
+// only a lower bound, exact on the last page and one past the page otherwise,
+// so total > opts.Offset()+opts.Limit tells whether more pages follow.
+func (r *lookupRepository) ListValuesByCategoryPaged(ctx context.Context, categoryID uuid.UUID, tagCode string, opts utils.ListOptions) ([]models.LookupValue, int64, error) {
+	var values []models.LookupValue
+	var total int64
+
+	query := r.db.WithContext(ctx).
+		Model(&models.LookupValue{}).
+		Scopes(scopeToOrg(ctx, "lookup_values")).
+		Where("category_id = ?", categoryID)
+	if opts.Search != "" {
+		searchPattern := "%" + opts.Search + "%"
+		query = query.Where("code ILIKE ? OR name ILIKE ? OR name_ar ILIKE ?", searchPattern, searchPattern, searchPattern)
+	}
+	if opts.Active != nil {
+		query = query.Where("is_active = ?", *opts.Active)
+	}
+	if tagCode != "" {
+		tagged := r.db.WithContext(ctx).
+			Table("lookup_value_tags").
+			Select("lookup_value_tags.lookup_value_id").
+			Joins("JOIN lookup_tags ON lookup_tags.id = lookup_value_tags.lookup_tag_id").
+			Scopes(scopeToOrg(ctx, "lookup_tags")).
+			Where("lookup_tags.code = ?", tagCode)
+		query = query.Where("lookup_values.id IN (?)", tagged)
+	}
+
+	if opts.SkipTotal {
+		err := query.
+			Order(opts.OrderBy(lookupValueSortColumns, lookupValueOrder)).
+			Offset(opts.Offset()).
+			Limit(opts.Limit + 1).
+			Find(&values).Error
+		total = int64(opts.Offset() + len(values))
+		if len(values) > opts.Limit {
+			values = values[:opts.Limit]
+		}
+		return values, total, err
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.
+		Order(opts.OrderBy(lookupValueSortColumns, lookupValueOrder)).
+		Offset(opts.Offset()).
+		Limit(opts.Limit).
+		Find(&values).Error
+	return values, total, err
+}
+
+// searchableValues selects the active values of live categories across all
+// categories, with the category preloaded so results can name it
+func (r *lookupRepository) searchableValues(ctx context.Context) *gorm.DB {
 	return r.db.WithContext(ctx).
+		Model(&models.LookupValue{}).
+		Preload("Category").
+		Joins(joinActiveCategory).
+		Scopes(scopeToOrg(ctx, "lookup_values"), scopeToRole(ctx, "lookup_values"), scopeToOrg(ctx, "lookup_categories")).
+		Where("lookup_values.is_active = ?", true)
+}
+
+// SearchValues returns one page of the active values whose code, name, Arabic
+// name or description contains query, case-insensitively, across all
+// categories, plus the total number of matches
+func (r *lookupRepository) SearchValues(ctx context.Context, query string, opts utils.ListOptions) ([]models.LookupValue, int64, error) {
+	var values []models.LookupValue
+	var total int64
+
+	pattern := "%" + strings.ToLower(query) + "%"
+	search := r.searchableValues(ctx).
+		Where("LOWER(lookup_values.code) LIKE ? OR LOWER(lookup_values.name) LIKE ? OR LOWER(lookup_values.name_ar) LIKE ? OR LOWER(lookup_values.description) LIKE ?",
+			pattern, pattern, pattern, pattern)
+	if err := search.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// id breaks ties between equal names so pages never overlap or skip rows
+	err := search.
+		Order("lookup_values.name ASC, lookup_values.id ASC").
+		Offset(opts.Offset()).
+		Limit(opts.Limit).
+		Find(&values).Error
+	return values, total, err
+}
+
+// AutocompleteValues returns up to limit active values of a category whose
+// code, name or Arabic name contains query, those starting with it first.
+// query must already be normalized with utils.NormalizeSearchQuery; on
+// postgres the Arabic name is folded the same way before matching.
+func (r *lookupRepository) AutocompleteValues(ctx context.Context, categoryID uuid.UUID, query string, limit int) ([]models.LookupValue, error) {
+	nameAr := "lookup_values.name_ar"
+	var foldArgs []interface{}
+	if r.db.Dialector.Name() == "postgres" {
+		nameAr = "translate(lookup_values.name_ar, ?, ?)"
+		foldArgs = []interface{}{utils.ArabicFoldFrom, utils.ArabicFoldTo}
+	}
+	columns := []string{"LOWER(lookup_values.code)", "LOWER(lookup_values.name)", nameAr}
+
+	// like builds "col LIKE ? OR ..." over the columns with its arguments
+	like := func(pattern string) (string, []interface{}) {
+		conditions := make([]string, len(columns))
+		var args []interface{}
+		for i, column := range columns {
+			conditions[i] = column + " LIKE ?"
+			if column == nameAr {
+				args = append(args, foldArgs...)
+			}
+			args = append(args, pattern)
+		}
+		return strings.Join(conditions, " OR "), args
+	}
+	contains, containsArgs := like("%" + query + "%")
+	prefix, prefixArgs := like(query + "%")
+
+	var values []models.LookupValue
+	err := r.searchableValues(ctx).
+		Where("lookup_values.category_id = ?", categoryID).
+		Where(contains, containsArgs...).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "CASE WHEN " + prefix + " THEN 0 ELSE 1 END, lookup_values.name ASC, lookup_values.id ASC",
+			Vars:               prefixArgs,
+			WithoutParentheses: true,
+		}}).
+		Limit(limit).
+		Find(&values).Error
+	return values, err
+}
+
+// FullTextSearchValues matches query against the search_vector column (name,
+// Arabic name and description, see database.Migrate) and ranks results by
+// ts_rank. Other drivers have no tsvector support and fall back to SearchValues.
+func (r *lookupRepository) FullTextSearchValues(ctx context.Context, query string, opts utils.ListOptions) ([]models.LookupValue, int64, error) {
+	if r.db.Dialector.Name() != "postgres" {
+		return r.SearchValues(ctx, query, opts)
+	}
+
+	var values []models.LookupValue
+	var total int64
+
+	search := r.searchableValues(ctx).
+		Where("lookup_values.search_vector @@ websearch_to_tsquery('simple', ?)", query)
+	if err := search.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Ranks tie often, so name and then id keep the order stable across pages
+	err := search.
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "ts_rank(lookup_values.search_vector, websearch_to_tsquery('simple', ?)) DESC, lookup_values.name ASC, lookup_values.id ASC",
+			Vars:               []interface{}{query},
+			WithoutParentheses: true,
+		}}).
+		Offset(opts.Offset()).
+		Limit(opts.Limit).
+		Find(&values).Error
+	return values, total, err
+}
+
+// ValuesChangedAt returns the last time any value of the category was updated
+// or deleted, including values no longer listed (inactive or soft-deleted), so
+// removals also move a category's Last-Modified forward. Effective dates that
+// have passed count as changes too, since the listing changes when a value's
+// window opens or closes. It returns the zero time for a category that never
+// had values. The latest time is picked in Go rather than in SQL, since the
+// greatest-of-columns functions differ between databases.
+func (r *lookupRepository) ValuesChangedAt(ctx context.Context, categoryID uuid.UUID) (time.Time, error) {
+	var rows []struct {
+		UpdatedAt     time.Time
+		DeletedAt     *time.Time
+		EffectiveFrom *time.Time
+		EffectiveTo   *time.Time
+	}
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Model(&models.LookupValue{}).
+		Scopes(scopeToOrg(ctx, "lookup_values")).
+		Where("category_id = ?", categoryID).
+		Select("updated_at", "deleted_at", "effective_from", "effective_to").
+		Scan(&rows).Error
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	now := time.Now()
+	var changedAt time.Time
+	latest := func(at *time.Time) {
+		if at != nil && at.After(changedAt) {
+			changedAt = *at
+		}
+	}
+	for _, row := range rows {
+		latest(&row.UpdatedAt)
+		latest(row.DeletedAt)
+		for _, at := range []*time.Time{row.EffectiveFrom, row.EffectiveTo} {
+			if at != nil && !at.After(now) {
+				latest(at)
+			}
+		}
+	}
+	return changedAt, nil
+}
+
+// ValuesMissingArabicNames returns the codes of the category's active values
+// that have no Arabic name yet
+func (r *lookupRepository) ValuesMissingArabicNames(ctx context.Context, categoryID uuid.UUID) ([]string, error) {
+	var codes []string
+	err := r.db.WithContext(ctx).
+		Model(&models.LookupValue{}).
+		Scopes(scopeToOrg(ctx, "lookup_values")).
+		Where("category_id = ? AND is_active = ? AND TRIM(COALESCE(name_ar, '')) = ''", categoryID, true).
+		Order("sort_order ASC, code ASC").
+		Pluck("code", &codes).Error
+	return codes, err
+}
+
+func (r *lookupRepository) ListValuesByCategoryCode(ctx context.Context, code string, filter models.LookupValueFilter) ([]models.LookupValue, error) {
+	var values []models.LookupValue
+	query := r.db.WithContext(ctx).
+		Joins(joinActiveCategory).
+		Scopes(scopeToOrg(ctx, "lookup_values"), scopeToRole(ctx, "lookup_values"), withCategoryCode(ctx, code)).
+		Where("lookup_categories.is_active = ? AND lookup_values.status IN ?", true, filter.Statuses())
+	if !filter.AsOf.IsZero() {
+		query = query.Where("(lookup_values.effective_from IS NULL OR lookup_values.effective_from <= ?) AND (lookup_values.effective_to IS NULL OR lookup_values.effective_to > ?)",
+			filter.AsOf, filter.AsOf)
+	}
+	err := query.Order(lookupValueOrder).Find(&values).Error
+	return values, err
+}
+
+func (r *lookupRepository) GetDefaultValue(ctx context.Context, categoryCode string) (*models.LookupValue, error) {
+	var value models.LookupValue
+	err := r.db.WithContext(ctx).
+		Joins(joinActiveCategory).
+		Scopes(scopeToOrg(ctx, "lookup_values"), scopeToRole(ctx, "lookup_values"), withCategoryCode(ctx, categoryCode)).
+		Where("lookup_values.is_default = ? AND lookup_values.is_active = ?", true, true).
+		First(&value).Error
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+// ListInactiveDefaults returns the inactive values flagged as default in active
+// categories that have no active default, the case where GetDefaultValue
+// reports not found although a default is configured
+func (r *lookupRepository) ListInactiveDefaults(ctx context.Context) ([]models.LookupInactiveDefault, error) {
+	var defaults []models.LookupInactiveDefault
+	err := r.db.WithContext(ctx).
+		Model(&models.LookupValue{}).
+		Select(`lookup_categories.id AS category_id, lookup_categories.code AS category_code, lookup_categories.name AS category_name,
+			lookup_values.id AS value_id, lookup_values.code AS value_code, lookup_values.name AS value_name`).
+		Joins(joinActiveCategory).
+		Scopes(scopeToOrg(ctx, "lookup_values")).
+		Where("lookup_categories.is_active = ? AND lookup_values.is_default = ? AND lookup_values.is_active = ?", true, true, false).
+		Where(`NOT EXISTS (SELECT 1 FROM lookup_values active_default
+			WHERE active_default.category_id = lookup_values.category_id
+			AND active_default.is_default AND active_default.is_active AND active_default.deleted_at IS NULL)`).
+		Order("lookup_categories.code ASC, lookup_values.code ASC").
+		Scan(&defaults).Error
+	return defaults, err
+}
+
+// ListMissingDefaults returns the active categories that have active values
+// but no active default, with their active value counts
+func (r *lookupRepository) ListMissingDefaults(ctx context.Context) ([]models.LookupMissingDefault, error) {
+	var missing []models.LookupMissingDefault
+	err := r.db.WithContext(ctx).
+		Model(&models.LookupCategory{}).
+		Select(`lookup_categories.id AS category_id, lookup_categories.code AS category_code, lookup_categories.name AS category_name,
+			COUNT(lookup_values.id) AS active_values`).
+		Joins(`JOIN lookup_values ON lookup_values.category_id = lookup_categories.id
+			AND lookup_values.is_active AND lookup_values.deleted_at IS NULL`).
+		Scopes(scopeToOrg(ctx, "lookup_categories"), scopeToOrg(ctx, "lookup_values")).
+		Where("lookup_categories.is_active = ?", true).
+		Where(`NOT EXISTS (SELECT 1 FROM lookup_values active_default
+			WHERE active_default.category_id = lookup_categories.id
+			AND active_default.is_default AND active_default.is_active AND active_default.deleted_at IS NULL)`).
+		Group("lookup_categories.id, lookup_categories.code, lookup_categories.name").
+		Order("lookup_categories.code ASC").
+		Scan(&missing).Error
+	return missing, err
+}
+
+// GetEffectiveDefaultValue returns the active default of the category or, when
+// there is none (e.g. the configured default was deactivated), the first active
+// value by sort order. The bool reports whether the fallback was used. A
+// category without active values returns gorm.ErrRecordNotFound.
+func (r *lookupRepository) GetEffectiveDefaultValue(ctx context.Context, categoryCode string) (*models.LookupValue, bool, error) {
+	value, err := r.GetDefaultValue(ctx, categoryCode)
+	if err == nil {
+		return value, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+
+	var fallback models.LookupValue
+	err = r.db.WithContext(ctx).
+		Joins(joinActiveCategory).
+		Scopes(scopeToOrg(ctx, "lookup_values"), scopeToRole(ctx, "lookup_values"), withCategoryCode(ctx, categoryCode)).
+		Where("lookup_values.is_active = ?", true).
+		Order("lookup_values.sort_order ASC, lookup_values.name ASC").
+		First(&fallback).Error
+	if err != nil {
+		return nil, false, err
+	}
+	return &fallback, true, nil
+}
+
+// GetWeightedDefault draws the category's default among its active values with
+// a default_weight above zero, each picked with probability weight / total
+// weight. Without weighted values it returns GetDefaultValue. The bool reports
+// whether the value was drawn by weight.
+func (r *lookupRepository) GetWeightedDefault(ctx context.Context, categoryCode string) (*models.LookupValue, bool, error) {
+	var candidates []models.LookupValue
+	err := r.db.WithContext(ctx).
+		Joins(joinActiveCategory).
+		Scopes(scopeToOrg(ctx, "lookup_values"), scopeToRole(ctx, "lookup_values"), withCategoryCode(ctx, categoryCode)).
+		Where("lookup_values.is_active = ? AND lookup_values.default_weight > 0", true).
+		Order("lookup_values.sort_order ASC, lookup_values.id ASC").
+		Find(&candidates).Error
+	if err != nil {
+		return nil, false, err
+	}
+	if len(candidates) == 0 {
+		value, err := r.GetDefaultValue(ctx, categoryCode)
+		return value, false, err
+	}
+
+	value := pickWeighted(candidates, drawWeighted)
+	return &value, true, nil
+}
+
+// drawWeighted is the draw GetWeightedDefault hands to pickWeighted
+var drawWeighted = rand.IntN
+
+// pickWeighted returns the candidate whose cumulative weight range holds
+// draw(total weight), a number in [0, total weight). Candidates must have
+// positive weights.
+func pickWeighted(candidates []models.LookupValue, draw func(n int) int) models.LookupValue {
+	total := 0
+	for _, v := range candidates {
+		total += v.DefaultWeight
+	}
+	n := draw(total)
+	for _, v := range candidates {
+		if n < v.DefaultWeight {
+			return v
+		}
+		n -= v.DefaultWeight
+	}
+	return candidates[len(candidates)-1]
+}
+
+// ClearDefaultForCategory unsets the default flag on the category's live values
+// and returns how many rows actually were the default. Soft-deleted rows and
+// rows that are not the default are left alone, so their updated_at is kept.
+// Inactive defaults are cleared and counted too, so none is left to come back
+// when its value is reactivated. Callers clear the default to replace it, so
+// the call fails where checkDefaultChangeable forbids that.
+func (r *lookupRepository) ClearDefaultForCategory(ctx context.Context, categoryID uuid.UUID) (int64, error) {
+	return clearDefault(ctx, r.db.WithContext(ctx), categoryID)
+}
+
+// clearDefault is ClearDefaultForCategory within tx
+func clearDefault(ctx context.Context, tx *gorm.DB, categoryID uuid.UUID) (int64, error) {
+	if err := checkDefaultChangeable(tx, categoryID); err != nil {
+		return 0, err
+	}
+	result := tx.
+		Model(&models.LookupValue{}).
+		Scopes(scopeToOrgWrites(ctx, "lookup_values")).
+		Where("category_id = ? AND is_default = ? AND deleted_at IS NULL", categoryID, true).
+		Update("is_default", false)
+	return result.RowsAffected, result.Error
+}
+
+// Errors returned by SwapDefault when the value can't become the default
+var (
+	ErrLookupValueNotFound      = errors.New("value not found")
+	ErrLookupValueWrongCategory = errors.New("value does not belong to the category")
+	ErrLookupValueInactive      = errors.New("value is not active")
+)
+
+// SwapDefault makes newDefaultID the only default of categoryID. The value is
+// checked inside the transaction before anything is cleared, so an invalid
+// request never leaves the category without its current default. A single
+// UPDATE then flips is_default on just the rows whose flag is wrong, the old
+// default and the new one, so no other row is locked or gets a new updated_at.
+// It runs under the category's default lock, as concurrent swaps would
+// otherwise each miss the other's new default and leave two behind. Nor can
+// the default of a system category or a locked one be swapped, see
+// checkDefaultChangeable.
+func (r *lookupRepository) SwapDefault(ctx context.Context, categoryID, newDefaultID uuid.UUID) error {
+	return r.withDefaultLock(ctx, categoryID, func(tx *lookupRepository) error {
+		var value models.LookupValue
+		err := tx.db.Scopes(scopeToOrg(ctx, "lookup_values")).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&value, "id = ?", newDefaultID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrLookupValueNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if value.CategoryID != categoryID {
+			return ErrLookupValueWrongCategory
+		}
+		if !value.IsActive {
+			return ErrLookupValueInactive
+		}
+		if err := checkOrgWritable(ctx, value.OrgID); err != nil {
+			return err
+		}
+		if !value.IsDefault {
+			if err := checkDefaultChangeable(tx.db, categoryID); err != nil {
+				return err
+			}
+		}
+
+		return tx.db.Model(&models.LookupValue{}).
+			Scopes(scopeToOrgWrites(ctx, "lookup_values")).
+			Where("category_id = ? AND is_default <> (id = ?)", categoryID, newDefaultID).
+			UpdateColumns(map[string]interface{}{
+				"is_default": gorm.Expr("id = ?", newDefaultID),
+				"updated_at": time.Now(),
+			}).Error
+	})
+}
+
+// ErrLookupDefaultsRejected is returned by SetDefaultValues when at least one
+// assignment is invalid; the per-pair results say which ones and why.
+var ErrLookupDefaultsRejected = errors.New("one or more defaults could not be set")
+
+// SetDefaultValues makes each named value the only default of its category, all
+// in one transaction. Every assignment is checked before the batch is rejected,
+// so the results describe all problems at once; nothing is saved unless every
+// assignment is valid. The default locks of all the categories are taken up
+// front, in ID order, and held until the batch commits.
+func (r *lookupRepository) SetDefaultValues(ctx context.Context, assignments []models.LookupDefaultAssignment) ([]models.LookupDefaultResult, error) {
+	results := make([]models.LookupDefaultResult, len(assignments))
+	failed := false
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		categories := make([]*models.LookupCategory, len(assignments))
+		ids := make([]uuid.UUID, 0, len(assignments))
+		seen := make(map[string]bool, len(assignments))
+		for i, a := range assignments {
+			result := &results[i]
+			result.CategoryCode = a.CategoryCode
+			result.ValueCode = a.ValueCode
+
+			if seen[a.CategoryCode] {
+				result.Error = "category is listed more than once"
+				failed = true
+				continue
+			}
+			seen[a.CategoryCode] = true
+
+			var category models.LookupCategory
+			err := tx.Scopes(withCategoryCode(ctx, a.CategoryCode)).
+				First(&category).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				result.Error = "category not found"
+				failed = true
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			categories[i] = &category
+			ids = append(ids, category.ID)
+		}
+
+		// Lock in a fixed order, as MoveValues does, so batches naming the same
+		// categories in another order, or a TransferDefault, can't deadlock
+		sort.Slice(ids, func(i, j int) bool { return ids[i].String() < ids[j].String() })
+		for _, id := range ids {
+			if err := lockCategoryDefaults(tx, id); err != nil {
+				return err
+			}
+		}
+
+		for i, a := range assignments {
+			result := &results[i]
+			category := categories[i]
+			if category == nil {
+				continue
+			}
+
+			var value models.LookupValue
+			err := tx.Scopes(scopeToOrg(ctx, "lookup_values")).
+				Where("category_id = ? AND code = ?", category.ID, a.ValueCode).
+				First(&value).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				result.Error = "value not found in category"
+				failed = true
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			result.ValueID = &value.ID
+
+			switch {
+			case !value.IsActive:
+				result.Error = "value is not active"
+			case checkOrgWritable(ctx, value.OrgID) != nil:
+				result.Error = "global values can only be changed by a super admin"
+			case category.IsSystem && !value.IsDefault:
+				result.Error = "the default of a system category is fixed"
+			case category.LockDefault && !value.IsDefault:
+				result.Error = "the default value of this category is locked"
+			}
+			if result.Error != "" {
+				failed = true
+				continue
+			}
+
+			var previous models.LookupValue
+			err = tx.Scopes(scopeToOrg(ctx, "lookup_values")).
+				Where("category_id = ? AND is_default = ? AND id <> ?", category.ID, true, value.ID).
+				First(&previous).Error
+			if err == nil {
+				result.PreviousDefaultID = &previous.ID
+			} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+
+			if failed {
+				// The batch is already rejected; keep validating but skip the writes
+				continue
+			}
+			err = tx.Model(&models.LookupValue{}).
+				Scopes(scopeToOrgWrites(ctx, "lookup_values")).
+				Where("category_id = ? AND is_default = ? AND id <> ?", category.ID, true, value.ID).
+				Update("is_default", false).Error
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&value).Update("is_default", true).Error; err != nil {
+				return err
+			}
+		}
+
+		if failed {
+			return ErrLookupDefaultsRejected
+		}
+		return nil
+	})
+	return results, err
+}
+
+// BulkSaveValues creates and updates values of one category in a single
+// transaction. The default is only touched by a value that becomes it: the
+// category's current default is then cleared first, under the category's
+// default lock. Updates that keep their default flag leave the stored one as
+// it is. A change checkValueChange rejects fails the batch.
+func (r *lookupRepository) BulkSaveValues(ctx context.Context, categoryID uuid.UUID, toCreate, toUpdate []models.LookupValue) error {
+	return r.withDefaultLock(ctx, categoryID, func(repo *lookupRepository) error {
+		tx := repo.db
+		setsDefault := false
+		for i := range toCreate {
+			if err := checkOrgWritable(ctx, toCreate[i].OrgID); err != nil {
+				return err
+			}
+			if err := checkValueChange(tx, &toCreate[i]); err != nil {
+				return err
+			}
+			setsDefault = setsDefault || toCreate[i].IsDefault
+		}
+		keepsDefault := make([]bool, len(toUpdate))
+		for i := range toUpdate {
+			if err := checkRowWritable(ctx, tx, "lookup_values", toUpdate[i].ID); err != nil {
+				return err
+			}
+			if err := checkValueChange(tx, &toUpdate[i]); err != nil {
+				return err
+			}
+			var stored models.LookupValue
+			if err := tx.Select("id", "is_default").First(&stored, "id = ?", toUpdate[i].ID).Error; err != nil {
+				return err
+			}
+			keepsDefault[i] = stored.IsDefault == toUpdate[i].IsDefault
+			setsDefault = setsDefault || (toUpdate[i].IsDefault && !keepsDefault[i])
+		}
+		if setsDefault {
+			if _, err := clearDefault(ctx, tx, categoryID); err != nil {
+				return err
+			}
+		}
+		if len(toCreate) > 0 {
+			// Select all columns so false flags are not replaced by column defaults
+			if err := tx.Select("*").Omit("Category").Create(&toCreate).Error; err != nil {
+				return err
+			}
+		}
+		for i := range toUpdate {
+			omit := []string{"Category"}
+			if keepsDefault[i] {
+				omit = append(omit, "is_default")
+			}
+			if err := tx.Omit(omit...).Save(&toUpdate[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// NextSortOrder returns the sort order that places a new value after every
+// existing value in the category; the first value of a category gets 0.
+func (r *lookupRepository) NextSortOrder(ctx context.Context, categoryID uuid.UUID) (int, error) {
+	var maxOrder int
+	err := r.db.WithContext(ctx).
 		Model(&models.LookupValue{}).
 		Where("category_id = ?", categoryID).
-		Update("is_default", false).Error
+		Select("COALESCE(MAX(sort_order), -1)").
+		Scan(&maxOrder).Error
+	if err != nil {
+		return 0, err
+	}
+	return maxOrder + 1, nil
+}
+
+// Alias methods
+
+func (r *lookupRepository) CreateAlias(ctx context.Context, alias *models.LookupValueAlias) error {
+	if err := checkRowWritable(ctx, r.db.WithContext(ctx), "lookup_values", alias.ValueID); err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Create(alias).Error
+}
+
+func (r *lookupRepository) ListAliases(ctx context.Context, valueID uuid.UUID) ([]models.LookupValueAlias, error) {
+	var aliases []models.LookupValueAlias
+	err := r.db.WithContext(ctx).
+		Where("value_id = ?", valueID).
+		Order("alias_code ASC").
+		Find(&aliases).Error
+	return aliases, err
+}
+
+func (r *lookupRepository) DeleteAlias(ctx context.Context, valueID, aliasID uuid.UUID) error {
+	if err := checkRowWritable(ctx, r.db.WithContext(ctx), "lookup_values", valueID); err != nil {
+		return err
+	}
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND value_id = ?", aliasID, valueID).
+		Delete(&models.LookupValueAlias{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ResolveValueByAlias returns the active value in the category whose code or one
+// of whose aliases matches aliasOrCode. Canonical codes win over aliases.
+func (r *lookupRepository) ResolveValueByAlias(ctx context.Context, categoryCode, aliasOrCode string) (*models.LookupValue, error) {
+	code := strings.ToUpper(aliasOrCode)
+
+	query := func() *gorm.DB {
+		return r.db.WithContext(ctx).
+			Joins(joinActiveCategory).
+			Scopes(scopeToOrg(ctx, "lookup_values"), scopeToRole(ctx, "lookup_values"), withCategoryCode(ctx, categoryCode)).
+			Where("lookup_categories.is_active = ? AND lookup_values.is_active = ?", true, true)
+	}
+
+	var value models.LookupValue
+	err := query().Where("lookup_values.code = ?", code).First(&value).Error
+	if err == nil {
+		return &value, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	err = query().
+		Joins("JOIN lookup_value_aliases ON lookup_value_aliases.value_id = lookup_values.id").
+		Where("lookup_value_aliases.alias_code = ?", code).
+		First(&value).Error
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+// FindValueIDByCodes returns the ID of the active value with valueCode in the
+// active category categoryCode. Aliases are not considered.
+func (r *lookupRepository) FindValueIDByCodes(ctx context.Context, categoryCode, valueCode string) (uuid.UUID, error) {
+	var value models.LookupValue
+	err := r.db.WithContext(ctx).
+		Select("lookup_values.id").
+		Joins(joinActiveCategory).
+		Scopes(scopeToOrg(ctx, "lookup_values"), withCategoryCode(ctx, categoryCode)).
+		Where("lookup_categories.is_active = ?", true).
+		Where("lookup_values.code = ? AND lookup_values.is_active = ?", valueCode, true).
+		First(&value).Error
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return value.ID, nil
+}
+
+// ResolveValueCodes resolves each pair to the ID of the value it names, with
+// one query for the whole batch. Results are in the order of pairs. Codes are
+// matched case-insensitively, canonical codes win over aliases, and a tenant's
+// own category wins over a global one with the same code.
+func (r *lookupRepository) ResolveValueCodes(ctx context.Context, pairs []models.LookupCodePair) ([]models.LookupCodeResolution, error) {
+	categoryCodes := make([]string, 0, len(pairs))
+	valueCodes := make([]string, 0, len(pairs))
+	seenCategory := make(map[string]bool)
+	seenValue := make(map[string]bool)
+	for _, p := range pairs {
+		if code := strings.ToUpper(p.CategoryCode); !seenCategory[code] {
+			seenCategory[code] = true
+			categoryCodes = append(categoryCodes, code)
+		}
+		if code := strings.ToUpper(p.ValueCode); !seenValue[code] {
+			seenValue[code] = true
+			valueCodes = append(valueCodes, code)
+		}
+	}
+
+	// Categories are the driving table so a category without a matching value
+	// still shows up, telling "unknown value" apart from "unknown category"
+	valueJoin := "LEFT JOIN lookup_values ON lookup_values.category_id = lookup_categories.id" +
+		" AND lookup_values.deleted_at IS NULL" +
+		" AND (lookup_values.code IN ? OR EXISTS (SELECT 1 FROM lookup_value_aliases" +
+		" WHERE lookup_value_aliases.value_id = lookup_values.id AND lookup_value_aliases.alias_code IN ?))"
+	joinArgs := []interface{}{valueCodes, valueCodes}
+	if condition, args, ok := orgCondition(ctx, "lookup_values"); ok {
+		valueJoin += " AND " + condition
+		joinArgs = append(joinArgs, args...)
+	}
+	if condition, args, ok := roleCondition(ctx, "lookup_values"); ok {
+		valueJoin += " AND " + condition
+		joinArgs = append(joinArgs, args...)
+	}
+
+	var rows []struct {
+		CategoryID     uuid.UUID
+		CategoryCode   string
+		CategoryOrgID  *uuid.UUID
+		CategoryActive bool
+		ValueID        *uuid.UUID
+		ValueCode      *string
+		ValueActive    *bool
+		AliasCode      *string
+	}
+	err := r.db.WithContext(ctx).
+		Model(&models.LookupCategory{}).
+		Select("lookup_categories.id AS category_id, lookup_categories.code AS category_code, "+
+			"lookup_categories.org_id AS category_org_id, lookup_categories.is_active AS category_active, "+
+			"lookup_values.id AS value_id, lookup_values.code AS value_code, lookup_values.is_active AS value_active, "+
+			"lookup_value_aliases.alias_code AS alias_code").
+		Joins(valueJoin, joinArgs...).
+		Joins("LEFT JOIN lookup_value_aliases ON lookup_value_aliases.value_id = lookup_values.id AND lookup_value_aliases.alias_code IN ?", valueCodes).
+		Scopes(scopeToOrg(ctx, "lookup_categories")).
+		Where("lookup_categories.code IN ?", categoryCodes).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	type match struct {
+		id     uuid.UUID
+		active bool
+	}
+	type category struct {
+		id      uuid.UUID
+		owned   bool
+		active  bool
+		byCode  map[string]match
+		byAlias map[string]match
+	}
+	categories := make(map[string]*category)
+	byID := make(map[uuid.UUID]*category)
+	for _, row := range rows {
+		cat := byID[row.CategoryID]
+		if cat == nil {
+			cat = &category{
+				id:      row.CategoryID,
+				owned:   row.CategoryOrgID != nil,
+				active:  row.CategoryActive,
+				byCode:  make(map[string]match),
+				byAlias: make(map[string]match),
+			}
+			byID[row.CategoryID] = cat
+			if current := categories[row.CategoryCode]; current == nil || (cat.owned && !current.owned) {
+				categories[row.CategoryCode] = cat
+			}
+		}
+		if row.ValueID == nil {
+			continue
+		}
+		m := match{id: *row.ValueID, active: *row.ValueActive}
+		cat.byCode[*row.ValueCode] = m
+		if row.AliasCode != nil {
+			cat.byAlias[*row.AliasCode] = m
+		}
+	}
+
+	results := make([]models.LookupCodeResolution, len(pairs))
+	for i, p := range pairs {
+		result := models.LookupCodeResolution{CategoryCode: p.CategoryCode, ValueCode: p.ValueCode}
+		cat := categories[strings.ToUpper(p.CategoryCode)]
+		code := strings.ToUpper(p.ValueCode)
+		m, found := match{}, false
+		if cat != nil {
+			if m, found = cat.byCode[code]; !found {
+				m, found = cat.byAlias[code]
+				result.ViaAlias = found
+			}
+		}
+
+		switch {
+		case cat == nil:
+			result.Reason = models.LookupResolveCategoryNotFound
+		case !cat.active:
+			result.Reason = models.LookupResolveCategoryInactive
+		case !found:
+			result.Reason = models.LookupResolveValueNotFound
+		case !m.active:
+			result.Reason = models.LookupResolveValueInactive
+		default:
+			id := m.id
+			result.ValueID = &id
+		}
+		if result.ValueID == nil {
+			result.ViaAlias = false
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// Translation methods
+
+// SetValueTranslation creates or replaces the value's translation for its
+// locale. The value's updated_at is bumped so Last-Modified on the value lists
+// reflects the change, and an "ar" translation is mirrored into name_ar.
+func (r *lookupRepository) SetValueTranslation(ctx context.Context, translation *models.LookupValueTranslation) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkRowWritable(ctx, tx, "lookup_values", translation.ValueID); err != nil {
+			return err
+		}
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "value_id"}, {Name: "locale"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name", "description", "updated_at"}),
+		}).Create(translation).Error
+		if err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{"updated_at": time.Now()}
+		if translation.Locale == "ar" {
+			updates["name_ar"] = translation.Name
+		}
+		return tx.Model(&models.LookupValue{}).
+			Where("id = ?", translation.ValueID).
+			UpdateColumns(updates).Error
+	})
+}
+
+func (r *lookupRepository) ListValueTranslations(ctx context.Context, valueID uuid.UUID) ([]models.LookupValueTranslation, error) {
+	var translations []models.LookupValueTranslation
+	err := r.db.WithContext(ctx).
+		Where("value_id = ?", valueID).
+		Order("locale ASC").
+		Find(&translations).Error
+	return translations, err
+}
+
+// FindValueTranslations returns the translations of the given values in any
+// of the given locales
+func (r *lookupRepository) FindValueTranslations(ctx context.Context, valueIDs []uuid.UUID, locales []string) ([]models.LookupValueTranslation, error) {
+	var translations []models.LookupValueTranslation
+	if len(valueIDs) == 0 || len(locales) == 0 {
+		return translations, nil
+	}
+	err := r.db.WithContext(ctx).
+		Where("value_id IN ? AND locale IN ?", valueIDs, locales).
+		Find(&translations).Error
+	return translations, err
+}
+
+// ImportArabicTranslations sets the Arabic name and description of the
+// category's values matched by code, in one transaction, the same way an "ar"
+// SetValueTranslation does. No values are created; codes without a value ctx
+// may write are reported as unmatched.
+func (r *lookupRepository) ImportArabicTranslations(ctx context.Context, categoryID uuid.UUID, rows []models.LookupTranslationImportRow) (*models.LookupTranslationImportResult, error) {
+	result := &models.LookupTranslationImportResult{Unmatched: []string{}}
+	if len(rows) == 0 {
+		return result, nil
+	}
+
+	codes := make([]string, len(rows))
+	for i, row := range rows {
+		codes[i] = row.Code
+	}
+
+	err := r.withTx(ctx, func(tx *lookupRepository) error {
+		var values []models.LookupValue
+		err := tx.db.WithContext(ctx).
+			Scopes(scopeToOrgWrites(ctx, "lookup_values")).
+			Where("category_id = ? AND code IN ?", categoryID, codes).
+			Find(&values).Error
+		if err != nil {
+			return err
+		}
+		byCode := make(map[string]uuid.UUID, len(values))
+		for _, v := range values {
+			byCode[v.Code] = v.ID
+		}
+
+		now := time.Now()
+		for _, row := range rows {
+			valueID, ok := byCode[row.Code]
+			if !ok {
+				result.Unmatched = append(result.Unmatched, row.Code)
+				continue
+			}
+			err := tx.db.WithContext(ctx).Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "value_id"}, {Name: "locale"}},
+				DoUpdates: clause.AssignmentColumns([]string{"name", "description", "updated_at"}),
+			}).Create(&models.LookupValueTranslation{
+				ValueID:     valueID,
+				Locale:      "ar",
+				Name:        row.NameAr,
+				Description: row.DescriptionAr,
+			}).Error
+			if err != nil {
+				return err
+			}
+			err = tx.db.WithContext(ctx).Model(&models.LookupValue{}).
+				Where("id = ?", valueID).
+				UpdateColumns(map[string]interface{}{"name_ar": row.NameAr, "updated_at": now}).Error
+			if err != nil {
+				return err
+			}
+			result.Updated++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Related category methods
+
+// Errors returned by LinkCategories when the link can't be made
+var (
+	ErrLookupCategorySelfLink      = errors.New("a category cannot be related to itself")
+	ErrLookupCategoryAlreadyLinked = errors.New("the categories are already related")
+)
+
+// LinkCategories relates two categories. The link is symmetric, so a join row
+// is stored for each direction and either category lists the other when its
+// RelatedCategories are preloaded. Both categories must be visible to the
+// requester, otherwise gorm.ErrRecordNotFound is returned, and categoryID
+// writable, otherwise ErrLookupNotWritable.
+func (r *lookupRepository) LinkCategories(ctx context.Context, categoryID, relatedID uuid.UUID) error {
+	if categoryID == relatedID {
+		return ErrLookupCategorySelfLink
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var categories []models.LookupCategory
+		err := tx.Scopes(scopeToOrg(ctx, "lookup_categories")).
+			Where("id IN ?", []uuid.UUID{categoryID, relatedID}).
+			Find(&categories).Error
+		if err != nil {
+			return err
+		}
+		if len(categories) != 2 {
+			return gorm.ErrRecordNotFound
+		}
+		for _, category := range categories {
+			if category.ID != categoryID {
+				continue
+			}
+			if err := checkOrgWritable(ctx, category.OrgID); err != nil {
+				return err
+			}
+		}
+
+		var linked int64
+		err = tx.Table("lookup_category_relations").
+			Where("category_id = ? AND related_category_id = ?", categoryID, relatedID).
+			Count(&linked).Error
+		if err != nil {
+			return err
+		}
+		if linked > 0 {
+			return ErrLookupCategoryAlreadyLinked
+		}
+
+		return tx.Table("lookup_category_relations").Create([]map[string]interface{}{
+			{"category_id": categoryID, "related_category_id": relatedID},
+			{"category_id": relatedID, "related_category_id": categoryID},
+		}).Error
+	})
+}
+
+// UnlinkCategories removes the link between two categories in both
+// directions, returning gorm.ErrRecordNotFound when they weren't related
+func (r *lookupRepository) UnlinkCategories(ctx context.Context, categoryID, relatedID uuid.UUID) error {
+	category, err := r.FindCategoryByID(ctx, categoryID)
+	if err != nil {
+		return err
+	}
+	if err := checkOrgWritable(ctx, category.OrgID); err != nil {
+		return err
+	}
+	result := r.db.WithContext(ctx).Exec(
+		"DELETE FROM lookup_category_relations WHERE (category_id = ? AND related_category_id = ?) OR (category_id = ? AND related_category_id = ?)",
+		categoryID, relatedID, relatedID, categoryID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ListRelatedCategories returns the categories related to categoryID, ordered
+// by name, without their values
+func (r *lookupRepository) ListRelatedCategories(ctx context.Context, categoryID uuid.UUID) ([]models.LookupCategory, error) {
+	var category models.LookupCategory
+	err := r.db.WithContext(ctx).
+		Preload("RelatedCategories", func(db *gorm.DB) *gorm.DB {
+			return db.Scopes(scopeToOrg(ctx, "lookup_categories")).Order("lookup_categories.name ASC")
+		}).
+		Scopes(scopeToOrg(ctx, "lookup_categories")).
+		First(&category, "id = ?", categoryID).Error
+	if err != nil {
+		return nil, err
+	}
+	return category.RelatedCategories, nil
+}
+
+// Tag methods
+
+func (r *lookupRepository) CreateTag(ctx context.Context, tag *models.LookupTag) error {
+	if err := checkOrgWritable(ctx, tag.OrgID); err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Create(tag).Error
+}
+
+func (r *lookupRepository) FindTagByID(ctx context.Context, id uuid.UUID) (*models.LookupTag, error) {
+	var tag models.LookupTag
+	err := r.db.WithContext(ctx).
+		Scopes(scopeToOrg(ctx, "lookup_tags")).
+		First(&tag, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (r *lookupRepository) ListTags(ctx context.Context) ([]models.LookupTag, error) {
+	var tags []models.LookupTag
+	err := r.db.WithContext(ctx).
+		Scopes(scopeToOrg(ctx, "lookup_tags")).
+		Order("name ASC").
+		Find(&tags).Error
+	return tags, err
+}
+
+// AttachTagValues tags the given values. Values already carrying the tag are
+// left as they are; ids that don't resolve to a visible value are reported.
+// The tag and every value found must be writable by the requester.
+func (r *lookupRepository) AttachTagValues(ctx context.Context, tag *models.LookupTag, valueIDs []uuid.UUID) (*models.LookupTagValuesResult, error) {
+	if err := checkOrgWritable(ctx, tag.OrgID); err != nil {
+		return nil, err
+	}
+
+	var values []models.LookupValue
+	err := r.db.WithContext(ctx).
+		Scopes(scopeToOrg(ctx, "lookup_values")).
+		Where("id IN ?", valueIDs).
+		Find(&values).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range values {
+		if err := checkOrgWritable(ctx, v.OrgID); err != nil {
+			return nil, err
+		}
+	}
+
+	found := make(map[uuid.UUID]bool, len(values))
+	for _, v := range values {
+		found[v.ID] = true
+	}
+	result := &models.LookupTagValuesResult{Attached: len(values), NotFound: []uuid.UUID{}}
+	for _, id := range valueIDs {
+		if !found[id] {
+			result.NotFound = append(result.NotFound, id)
+		}
+	}
+
+	if len(values) > 0 {
+		// Append inserts the join rows with ON CONFLICT DO NOTHING
+		if err := r.db.WithContext(ctx).Model(tag).Omit("Values.*").Association("Values").Append(&values); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (r *lookupRepository) DetachTagValue(ctx context.Context, tag *models.LookupTag, valueID uuid.UUID) error {
+	if err := checkOrgWritable(ctx, tag.OrgID); err != nil {
+		return err
+	}
+	if err := checkRowWritable(ctx, r.db.WithContext(ctx), "lookup_values", valueID); err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Model(tag).Association("Values").Delete(&models.LookupValue{ID: valueID})
+}
+
+// ListValuesByTagCode returns the active values carrying the tag across all
+// categories, in a single query joined through lookup_value_tags
+func (r *lookupRepository) ListValuesByTagCode(ctx context.Context, code string) ([]models.LookupValue, error) {
+	var values []models.LookupValue
+	err := r.db.WithContext(ctx).
+		Preload("Category").
+		Joins(joinActiveCategory).
+		Joins("JOIN lookup_value_tags ON lookup_value_tags.lookup_value_id = lookup_values.id").
+		Joins("JOIN lookup_tags ON lookup_tags.id = lookup_value_tags.lookup_tag_id").
+		Scopes(scopeToOrg(ctx, "lookup_values"), scopeToOrg(ctx, "lookup_tags")).
+		Where("lookup_tags.code = ? AND lookup_values.is_active = ?", code, true).
+		Order("lookup_categories.name ASC, lookup_values.sort_order ASC, lookup_values.name ASC").
+		Find(&values).Error
+	return values, err
 }
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/automax/backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Errors returned by ArchiveCategory and UnarchiveCategory when the category is
+// already in the requested state
+var (
+	ErrLookupCategoryArchived    = errors.New("category is already archived")
+	ErrLookupCategoryNotArchived = errors.New("category is not archived")
+)
+
+// ArchiveCategory deactivates the category and, with cascadeValues, its active
+// values, marking the values with the category's archived_at so
+// UnarchiveCategory can tell them from values an admin turned off. It returns
+// the number of values deactivated.
+func (r *lookupRepository) ArchiveCategory(ctx context.Context, id uuid.UUID, cascadeValues bool) (int64, error) {
+	var archived int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		category, err := lockWritableCategory(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if category.ArchivedAt != nil {
+			return ErrLookupCategoryArchived
+		}
+
+		now := time.Now()
+		err = tx.Model(&models.LookupCategory{}).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{"is_active": false, "add_to_incident_form": false, "archived_at": now}).Error
+		if err != nil || !cascadeValues {
+			return err
+		}
+
+		columns, err := valueStatusColumns(models.LookupValueStatusArchived, map[string]interface{}{
+			"archived_at": now,
+			"updated_at":  now,
+		})
+		if err != nil {
+			return err
+		}
+		res := tx.Model(&models.LookupValue{}).
+			Scopes(scopeToOrgWrites(ctx, "lookup_values")).
+			Where("category_id = ? AND is_active = ?", id, true).
+			UpdateColumns(columns)
+		archived = res.RowsAffected
+		return res.Error
+	})
+	return archived, err
+}
+
+// UnarchiveCategory reactivates an archived category. With withValues, the
+// values deactivated by ArchiveCategory are reactivated too; values that were
+// already off stay off. Either way the archive markers are cleared. It returns
+// the number of values reactivated.
+func (r *lookupRepository) UnarchiveCategory(ctx context.Context, id uuid.UUID, withValues bool) (int64, error) {
+	var restored int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		category, err := lockWritableCategory(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if category.ArchivedAt == nil {
+			return ErrLookupCategoryNotArchived
+		}
+
+		err = tx.Model(&models.LookupCategory{}).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{"is_active": true, "archived_at": nil}).Error
+		if err != nil {
+			return err
+		}
+		if err := restoreIncidentForm(ctx, tx, []uuid.UUID{id}); err != nil {
+			return err
+		}
+
+		marked := tx.Model(&models.LookupValue{}).
+			Scopes(scopeToOrgWrites(ctx, "lookup_values")).
+			Where("category_id = ? AND archived_at IS NOT NULL", id)
+		if !withValues {
+			return marked.UpdateColumn("archived_at", nil).Error
+		}
+		columns, err := valueStatusColumns(models.LookupValueStatusActive, map[string]interface{}{
+			"archived_at": nil,
+			"updated_at":  time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		res := marked.UpdateColumns(columns)
+		restored = res.RowsAffected
+		return res.Error
+	})
+	return restored, err
+}
@@ -0,0 +1,298 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/automax/backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// errImportDryRun aborts the import transaction once a dry run has been diffed
+var errImportDryRun = errors.New("lookup import dry run")
+
+// ErrLookupImportRowFailed is returned by a strict import when a row could not
+// be saved; nothing is kept and the result names the row.
+var ErrLookupImportRowFailed = errors.New("an import row could not be saved")
+
+// ImportLookups creates or updates categories and values matched by code, all in
+// one transaction. With opts.DryRun the same work is done and diffed, then the
+// transaction is rolled back so nothing persists. A strict import stops at the
+// first failing row; a lenient one reports the row and carries on. A category
+// with several default values fails a strict import; a lenient one keeps the
+// first default by sort_order and adds a warning.
+func (r *lookupRepository) ImportLookups(ctx context.Context, data *models.LookupExport, opts models.LookupImportOptions) (*models.LookupImportResult, error) {
+	result := models.NewLookupImportResult(opts)
+	lenient := opts.Validation == models.LookupImportLenient
+	orgID, _ := OrgIDFromContext(ctx)
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, in := range data.Categories {
+			code := strings.ToUpper(in.Code)
+
+			if defaults := importDefaultCount(in.Values); defaults > 1 {
+				if !lenient {
+					result = models.NewLookupImportResult(opts)
+					result.Fail(models.LookupImportRowError{
+						Location: in.Location, Type: "category", CategoryCode: code, Code: code,
+						Error: fmt.Sprintf("%d values are marked as default, only one is allowed", defaults),
+					})
+					return ErrLookupImportRowFailed
+				}
+				var kept string
+				in.Values, kept = keepFirstImportDefault(in.Values)
+				result.Warnings = append(result.Warnings, models.LookupImportWarning{
+					Location:     in.Location,
+					CategoryCode: code,
+					Message:      fmt.Sprintf("%d values are marked as default; kept %s, the first by sort_order", defaults, strings.ToUpper(kept)),
+				})
+			}
+
+			var category models.LookupCategory
+			err := importRow(tx, lenient, func(tx *gorm.DB) error {
+				err := tx.Scopes(withCategoryCode(ctx, code)).First(&category).Error
+				switch {
+				case errors.Is(err, gorm.ErrRecordNotFound):
+					category = models.LookupCategory{
+						OrgID:             orgID,
+						Code:              code,
+						Name:              in.Name,
+						NameAr:            in.NameAr,
+						Description:       in.Description,
+						IsActive:          in.IsActive,
+						AddToIncidentForm: in.AddToIncidentForm,
+						DefaultSortDesc:   in.DefaultSortDesc,
+					}
+					category.IncidentFormIntent = in.AddToIncidentForm
+					if err := checkOrgWritable(ctx, orgID); err != nil {
+						return err
+					}
+					if err := checkIncidentFormPrefix(ctx, tx, &category); err != nil {
+						return err
+					}
+					// Select all columns so imported false flags are not replaced by column defaults
+					if err := tx.Select("*").Create(&category).Error; err != nil {
+						return err
+					}
+					result.Record(models.LookupImportDiff{Type: "category", CategoryCode: code, Code: code, Action: models.LookupImportCreated})
+					return nil
+				case err != nil:
+					return err
+				}
+
+				changes := map[string]models.LookupFieldChange{}
+				diffField(changes, "name", &category.Name, in.Name)
+				diffField(changes, "name_ar", &category.NameAr, in.NameAr)
+				diffField(changes, "description", &category.Description, in.Description)
+				diffField(changes, "add_to_incident_form", &category.AddToIncidentForm, in.AddToIncidentForm)
+				diffField(changes, "default_sort_desc", &category.DefaultSortDesc, in.DefaultSortDesc)
+				// System categories cannot be deactivated
+				if !category.IsSystem {
+					diffField(changes, "is_active", &category.IsActive, in.IsActive)
+				}
+				_, joined := changes["add_to_incident_form"]
+				_, activated := changes["is_active"]
+				// The document states the flag of active categories outright; an
+				// inactive one keeps its intent unless the flag is changed
+				if joined || category.IsActive {
+					category.IncidentFormIntent = category.AddToIncidentForm
+				}
+				if joined || activated {
+					if err := checkIncidentFormPrefix(ctx, tx, &category); err != nil {
+						return err
+					}
+				}
+				return recordUpdate(ctx, tx, result, &category, category.OrgID, changes, models.LookupImportDiff{Type: "category", CategoryCode: code, Code: code})
+			})
+			if err != nil {
+				rowErr := models.LookupImportRowError{Location: in.Location, Type: "category", CategoryCode: code, Code: code, Error: importRowMessage(err)}
+				if !lenient {
+					result = models.NewLookupImportResult(opts)
+					result.Fail(rowErr)
+					return ErrLookupImportRowFailed
+				}
+				// Its values have nowhere to go, so they are not attempted
+				result.Fail(rowErr)
+				continue
+			}
+
+			for _, inValue := range in.Values {
+				valueCode := strings.ToUpper(inValue.Code)
+
+				err := importRow(tx, lenient, func(tx *gorm.DB) error {
+					var value models.LookupValue
+					err := tx.Scopes(scopeToOrg(ctx, "lookup_values")).
+						Where("category_id = ? AND code = ?", category.ID, valueCode).
+						First(&value).Error
+					switch {
+					case errors.Is(err, gorm.ErrRecordNotFound):
+						value = models.LookupValue{
+							OrgID:       orgID,
+							CategoryID:  category.ID,
+							Code:        valueCode,
+							Name:        inValue.Name,
+							NameAr:      inValue.NameAr,
+							Description: inValue.Description,
+							SortOrder:   inValue.SortOrder,
+							Color:       inValue.Color,
+							IsDefault:   inValue.IsDefault,
+							IsActive:    inValue.IsActive,
+						}
+						if err := checkOrgWritable(ctx, orgID); err != nil {
+							return err
+						}
+						if value.IsDefault {
+							if err := replaceImportDefault(ctx, tx, category.ID); err != nil {
+								return err
+							}
+						}
+						// Select all columns so imported false flags are not replaced by column defaults
+						if err := tx.Select("*").Create(&value).Error; err != nil {
+							return err
+						}
+						result.Record(models.LookupImportDiff{Type: "value", CategoryCode: code, Code: valueCode, Action: models.LookupImportCreated})
+						return nil
+					case err != nil:
+						return err
+					}
+
+					changes := map[string]models.LookupFieldChange{}
+					diffField(changes, "name", &value.Name, inValue.Name)
+					diffField(changes, "name_ar", &value.NameAr, inValue.NameAr)
+					diffField(changes, "description", &value.Description, inValue.Description)
+					diffField(changes, "sort_order", &value.SortOrder, inValue.SortOrder)
+					diffField(changes, "color", &value.Color, inValue.Color)
+					diffField(changes, "is_default", &value.IsDefault, inValue.IsDefault)
+					diffField(changes, "is_active", &value.IsActive, inValue.IsActive)
+					if _, ok := changes["is_default"]; ok {
+						if value.IsDefault {
+							err = replaceImportDefault(ctx, tx, category.ID)
+						} else {
+							err = checkDefaultChangeable(tx, category.ID)
+						}
+						if err != nil {
+							return err
+						}
+					}
+					return recordUpdate(ctx, tx, result, &value, value.OrgID, changes, models.LookupImportDiff{Type: "value", CategoryCode: code, Code: valueCode})
+				})
+				if err != nil {
+					rowErr := models.LookupImportRowError{Location: inValue.Location, Type: "value", CategoryCode: code, Code: valueCode, Error: importRowMessage(err)}
+					if !lenient {
+						result = models.NewLookupImportResult(opts)
+						result.Fail(rowErr)
+						return ErrLookupImportRowFailed
+					}
+					result.Fail(rowErr)
+				}
+			}
+		}
+
+		if opts.DryRun {
+			return errImportDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, ErrLookupImportRowFailed) {
+		return result, err
+	}
+	if err != nil && !errors.Is(err, errImportDryRun) {
+		return nil, err
+	}
+	return result, nil
+}
+
+// replaceImportDefault clears the current default of the category before an
+// imported value takes it, under the category's default lock like
+// ClearDefaultForCategory callers. The lock is held until the import commits.
+func replaceImportDefault(ctx context.Context, tx *gorm.DB, categoryID uuid.UUID) error {
+	if err := lockCategoryDefaults(tx, categoryID); err != nil {
+		return err
+	}
+	_, err := clearDefault(ctx, tx, categoryID)
+	return err
+}
+
+// importDefaultCount counts the values of an imported category marked default
+func importDefaultCount(values []models.LookupValueExport) int {
+	count := 0
+	for _, v := range values {
+		if v.IsDefault {
+			count++
+		}
+	}
+	return count
+}
+
+// keepFirstImportDefault returns a copy of values in which only the default
+// with the lowest sort_order, the earliest in the document on a tie, is still
+// marked default, plus the code of that value
+func keepFirstImportDefault(values []models.LookupValueExport) ([]models.LookupValueExport, string) {
+	kept := -1
+	for i, v := range values {
+		if v.IsDefault && (kept < 0 || v.SortOrder < values[kept].SortOrder) {
+			kept = i
+		}
+	}
+	out := make([]models.LookupValueExport, len(values))
+	for i, v := range values {
+		v.IsDefault = i == kept
+		out[i] = v
+	}
+	return out, values[kept].Code
+}
+
+// importRow applies one import row. Lenient imports run it in a savepoint, so
+// a failed row is undone without aborting the rest of the transaction.
+func importRow(tx *gorm.DB, lenient bool, apply func(tx *gorm.DB) error) error {
+	if !lenient {
+		return apply(tx)
+	}
+	return tx.Transaction(apply)
+}
+
+// importRowMessage describes a database error on an import row without
+// exposing its details
+func importRowMessage(err error) string {
+	if errors.Is(err, ErrLookupIncidentFormPrefixTaken) || errors.Is(err, ErrLookupNotWritable) ||
+		errors.Is(err, ErrLookupDefaultLocked) || errors.Is(err, ErrLookupSystemValueFixed) {
+		return err.Error()
+	}
+	if strings.Contains(err.Error(), "duplicate") || strings.Contains(err.Error(), "unique") {
+		return "conflicts with an existing record"
+	}
+	return "could not be saved"
+}
+
+// diffField records a change when the current value differs from the incoming
+// one and applies the incoming value.
+func diffField[T comparable](changes map[string]models.LookupFieldChange, name string, current *T, incoming T) {
+	if *current == incoming {
+		return
+	}
+	changes[name] = models.LookupFieldChange{From: *current, To: incoming}
+	*current = incoming
+}
+
+// recordUpdate saves record, owned by orgID, when changes were found and adds
+// the diff entry. Unchanged rows pass even when ctx may not write them.
+func recordUpdate(ctx context.Context, tx *gorm.DB, result *models.LookupImportResult, record interface{}, orgID *uuid.UUID, changes map[string]models.LookupFieldChange, diff models.LookupImportDiff) error {
+	if len(changes) == 0 {
+		diff.Action = models.LookupImportUnchanged
+		result.Record(diff)
+		return nil
+	}
+	if err := checkOrgWritable(ctx, orgID); err != nil {
+		return err
+	}
+	if err := tx.Save(record).Error; err != nil {
+		return err
+	}
+	diff.Action = models.LookupImportUpdated
+	diff.Changes = changes
+	result.Record(diff)
+	return nil
+}
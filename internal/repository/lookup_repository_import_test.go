@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/automax/backend/internal/models"
+)
+
+// importDiffs describes the diff of an import result as type:code:action
+func importDiffs(result *models.LookupImportResult) []string {
+	var diffs []string
+	for _, d := range result.Diff {
+		diffs = append(diffs, d.Type+":"+d.Code+":"+d.Action)
+	}
+	return diffs
+}
+
+func TestImportLookupsDryRunDiffsWithoutSaving(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	category := createTestCategory(t, db, nil, "PRIORITY")
+	createTestValue(t, db, category, "HIGH", 0, false)
+
+	data := &models.LookupExport{Categories: []models.LookupCategoryExport{
+		{Code: "PRIORITY", Name: "PRIORITY", IsActive: true, Values: []models.LookupValueExport{
+			{Code: "HIGH", Name: "High", IsActive: true},
+			{Code: "LOW", Name: "Low", SortOrder: 1, IsActive: true},
+		}},
+		{Code: "SEVERITY", Name: "Severity", IsActive: true},
+	}}
+	result, err := repo.ImportLookups(context.Background(), data, models.LookupImportOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("ImportLookups: %v", err)
+	}
+
+	if !result.DryRun || result.Created != 2 || result.Updated != 1 {
+		t.Errorf("result dry_run=%v created=%d updated=%d, want a dry run creating 2 and updating 1", result.DryRun, result.Created, result.Updated)
+	}
+	want := "[category:PRIORITY:unchanged value:HIGH:updated value:LOW:created category:SEVERITY:created]"
+	if got := fmt.Sprint(importDiffs(result)); got != want {
+		t.Errorf("diff = %s, want %s", got, want)
+	}
+	if change := result.Diff[1].Changes["name"]; change.From != "HIGH" || change.To != "High" {
+		t.Errorf("HIGH name change = %+v, want HIGH to High", change)
+	}
+
+	var categories, values int64
+	db.Model(&models.LookupCategory{}).Count(&categories)
+	db.Model(&models.LookupValue{}).Where("code = ? OR name = ?", "LOW", "High").Count(&values)
+	if categories != 1 || values != 0 {
+		t.Errorf("a dry run saved %d categories and %d changed values, want 1 and none", categories, values)
+	}
+}
+
+func TestImportLookupsStrictStopsWhereLenientSkipsTheRow(t *testing.T) {
+	for _, validation := range []string{models.LookupImportStrict, models.LookupImportLenient} {
+		t.Run(validation, func(t *testing.T) {
+			db := newTestDB(t)
+			repo := NewLookupRepository(db)
+			onForm := createTestCategory(t, db, nil, "PRIORITY")
+			if err := db.Model(onForm).Updates(map[string]interface{}{"add_to_incident_form": true, "name_ar": "الأولوية"}).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			data := &models.LookupExport{Categories: []models.LookupCategoryExport{
+				{Location: "categories[0]", Code: "SEVERITY", Name: "Severity", IsActive: true, Values: []models.LookupValueExport{
+					{Code: "MAJOR", Name: "Major", IsActive: true},
+				}},
+				{Location: "categories[1]", Code: "PRIORITY_LEGACY", Name: "Legacy", NameAr: "قديم", IsActive: true, AddToIncidentForm: true, Values: []models.LookupValueExport{
+					{Code: "OLD", Name: "Old", IsActive: true},
+				}},
+			}}
+			result, err := repo.ImportLookups(context.Background(), data, models.LookupImportOptions{Validation: validation})
+
+			var saved []string
+			if err := db.Model(&models.LookupCategory{}).Order("code").Pluck("code", &saved).Error; err != nil {
+				t.Fatal(err)
+			}
+			if result == nil || result.Failed != 1 || len(result.Errors) != 1 || result.Errors[0].Location != "categories[1]" {
+				t.Fatalf("result = %+v, want the PRIORITY_LEGACY row reported", result)
+			}
+			if validation == models.LookupImportStrict {
+				if !errors.Is(err, ErrLookupImportRowFailed) || fmt.Sprint(saved) != "[PRIORITY]" || result.Created != 0 {
+					t.Errorf("strict import = %v, created %d, categories %v, want ErrLookupImportRowFailed and nothing saved", err, result.Created, saved)
+				}
+				return
+			}
+			if err != nil || fmt.Sprint(saved) != "[PRIORITY SEVERITY]" || result.Created != 2 {
+				t.Errorf("lenient import = %v, created %d, categories %v, want SEVERITY and MAJOR saved", err, result.Created, saved)
+			}
+			var old int64
+			db.Model(&models.LookupValue{}).Where("code = ?", "OLD").Count(&old)
+			if old != 0 {
+				t.Errorf("the failed category's value was saved")
+			}
+		})
+	}
+}
+
+func TestImportLookupsHandlesACategoryWithTwoDefaults(t *testing.T) {
+	data := func() *models.LookupExport {
+		return &models.LookupExport{Categories: []models.LookupCategoryExport{
+			{Location: "categories[0]", Code: "PRIORITY", Name: "Priority", IsActive: true, Values: []models.LookupValueExport{
+				{Code: "HIGH", Name: "High", SortOrder: 2, IsDefault: true, IsActive: true},
+				{Code: "MEDIUM", Name: "Medium", SortOrder: 1, IsDefault: true, IsActive: true},
+				{Code: "LOW", Name: "Low", SortOrder: 0, IsActive: true},
+			}},
+		}}
+	}
+
+	t.Run(models.LookupImportStrict, func(t *testing.T) {
+		db := newTestDB(t)
+		result, err := NewLookupRepository(db).ImportLookups(context.Background(), data(), models.LookupImportOptions{Validation: models.LookupImportStrict})
+		if !errors.Is(err, ErrLookupImportRowFailed) {
+			t.Fatalf("ImportLookups = %v, want ErrLookupImportRowFailed", err)
+		}
+		if result == nil || len(result.Errors) != 1 || result.Errors[0].Location != "categories[0]" || result.Errors[0].Error != "2 values are marked as default, only one is allowed" {
+			t.Errorf("result = %+v, want the category rejected for its two defaults", result)
+		}
+		var values int64
+		db.Model(&models.LookupValue{}).Count(&values)
+		if values != 0 {
+			t.Errorf("%d values saved, want none", values)
+		}
+	})
+
+	t.Run(models.LookupImportLenient, func(t *testing.T) {
+		db := newTestDB(t)
+		result, err := NewLookupRepository(db).ImportLookups(context.Background(), data(), models.LookupImportOptions{Validation: models.LookupImportLenient})
+		if err != nil {
+			t.Fatalf("ImportLookups: %v", err)
+		}
+		if len(result.Warnings) != 1 || result.Warnings[0].CategoryCode != "PRIORITY" || result.Warnings[0].Message != "2 values are marked as default; kept MEDIUM, the first by sort_order" {
+			t.Errorf("warnings = %+v, want MEDIUM kept", result.Warnings)
+		}
+		var defaults []string
+		db.Model(&models.LookupValue{}).Where("is_default = ?", true).Pluck("code", &defaults)
+		if fmt.Sprint(defaults) != "[MEDIUM]" {
+			t.Errorf("defaults = %v, want [MEDIUM]", defaults)
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/automax/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// invalidatingLookupRepository calls invalidate after every operation that can
+// change which value a category code and value code resolve to. Reads pass
+// straight through to the embedded repository.
+type invalidatingLookupRepository struct {
+	LookupRepository
+	invalidate func()
+}
+
+// NewInvalidatingLookupRepository wraps a LookupRepository so caches built on
+// lookup codes, such as services.LookupResolver, are dropped whenever a
+// category or value is written. invalidate also runs after failed writes, since
+// a transaction may have been partly visible to concurrent readers.
+func NewInvalidatingLookupRepository(next LookupRepository, invalidate func()) LookupRepository {
+	return &invalidatingLookupRepository{LookupRepository: next, invalidate: invalidate}
+}
+
+// WithTx invalidates once the transaction has ended. The repository passed to
+// fn does not invalidate, so caches are never rebuilt from uncommitted writes.
+func (r *invalidatingLookupRepository) WithTx(ctx context.Context, fn func(txRepo LookupRepository) error) error {
+	defer r.invalidate()
+	return r.LookupRepository.WithTx(ctx, fn)
+}
+
+// WithDefaultLock invalidates once the transaction has ended, like WithTx
+func (r *invalidatingLookupRepository) WithDefaultLock(ctx context.Context, categoryID uuid.UUID, fn func(txRepo LookupRepository) error) error {
+	defer r.invalidate()
+	return r.LookupRepository.WithDefaultLock(ctx, categoryID, fn)
+}
+
+// Category methods
+
+func (r *invalidatingLookupRepository) CreateCategory(ctx context.Context, category *models.LookupCategory) error {
+	defer r.invalidate()
+	return r.LookupRepository.CreateCategory(ctx, category)
+}
+
+func (r *invalidatingLookupRepository) UpdateCategory(ctx context.Context, category *models.LookupCategory) error {
+	defer r.invalidate()
+	return r.LookupRepository.UpdateCategory(ctx, category)
+}
+
+func (r *invalidatingLookupRepository) UpsertCategoryByCode(ctx context.Context, code string, apply func(category *models.LookupCategory, created bool) error) (*models.LookupCategory, bool, error) {
+	defer r.invalidate()
+	return r.LookupRepository.UpsertCategoryByCode(ctx, code, apply)
+}
+
+func (r *invalidatingLookupRepository) DeleteCategory(ctx context.Context, id uuid.UUID) error {
+	defer r.invalidate()
+	return r.LookupRepository.DeleteCategory(ctx, id)
+}
+
+func (r *invalidatingLookupRepository) SetCategoriesActive(ctx context.Context, ids []uuid.UUID, active bool) (*models.LookupCategoriesActiveResult, error) {
+	defer r.invalidate()
+	return r.LookupRepository.SetCategoriesActive(ctx, ids, active)
+}
+
+func (r *invalidatingLookupRepository) ResetCategoryToSeed(ctx context.Context, category *models.LookupCategory, seed *models.LookupCategorySeed, strict bool) (*models.LookupResetResult, error) {
+	defer r.invalidate()
+	return r.LookupRepository.ResetCategoryToSeed(ctx, category, seed, strict)
+}
+
+func (r *invalidatingLookupRepository) ArchiveCategory(ctx context.Context, id uuid.UUID, cascadeValues bool) (int64, error) {
+	defer r.invalidate()
+	return r.LookupRepository.ArchiveCategory(ctx, id, cascadeValues)
+}
+
+func (r *invalidatingLookupRepository) UnarchiveCategory(ctx context.Context, id uuid.UUID, withValues bool) (int64, error) {
+	defer r.invalidate()
+	return r.LookupRepository.UnarchiveCategory(ctx, id, withValues)
+}
+
+func (r *invalidatingLookupRepository) ImportLookups(ctx context.Context, data *models.LookupExport, opts models.LookupImportOptions) (*models.LookupImportResult, error) {
+	if !opts.DryRun {
+		defer r.invalidate()
+	}
+	return r.LookupRepository.ImportLookups(ctx, data, opts)
+}
+
+// Value methods
+
+func (r *invalidatingLookupRepository) CreateValue(ctx context.Context, value *models.LookupValue) error {
+	defer r.invalidate()
+	return r.LookupRepository.CreateValue(ctx, value)
+}
+
+func (r *invalidatingLookupRepository) UpdateValue(ctx context.Context, value *models.LookupValue) error {
+	defer r.invalidate()
+	return r.LookupRepository.UpdateValue(ctx, value)
+}
+
+func (r *invalidatingLookupRepository) CreateValueAtSortOrder(ctx context.Context, value *models.LookupValue) error {
+	defer r.invalidate()
+	return r.LookupRepository.CreateValueAtSortOrder(ctx, value)
+}
+
+func (r *invalidatingLookupRepository) UpdateValueAtSortOrder(ctx context.Context, value *models.LookupValue) error {
+	defer r.invalidate()
+	return r.LookupRepository.UpdateValueAtSortOrder(ctx, value)
+}
+
+func (r *invalidatingLookupRepository) NormalizeSortOrder(ctx context.Context, categoryID uuid.UUID) ([]models.LookupValue, error) {
+	defer r.invalidate()
+	return r.LookupRepository.NormalizeSortOrder(ctx, categoryID)
+}
+
+func (r *invalidatingLookupRepository) CleanupOrphanValues(ctx context.Context, ids []uuid.UUID, targetCategoryID *uuid.UUID) (*models.LookupOrphanCleanupResult, error) {
+	defer r.invalidate()
+	return r.LookupRepository.CleanupOrphanValues(ctx, ids, targetCategoryID)
+}
+
+func (r *invalidatingLookupRepository) RestoreValues(ctx context.Context, categoryID uuid.UUID, ids []uuid.UUID) ([]models.LookupValue, []models.LookupValueRestoreSkip, error) {
+	defer r.invalidate()
+	return r.LookupRepository.RestoreValues(ctx, categoryID, ids)
+}
+
+func (r *invalidatingLookupRepository) MoveValues(ctx context.Context, sourceID, targetID uuid.UUID, valueIDs []uuid.UUID) ([]models.LookupValue, error) {
+	defer r.invalidate()
+	return r.LookupRepository.MoveValues(ctx, sourceID, targetID, valueIDs)
+}
+
+func (r *invalidatingLookupRepository) DeleteValue(ctx context.Context, id uuid.UUID) error {
+	defer r.invalidate()
+	return r.LookupRepository.DeleteValue(ctx, id)
+}
+
+func (r *invalidatingLookupRepository) BulkSaveValues(ctx context.Context, categoryID uuid.UUID, toCreate, toUpdate []models.LookupValue) error {
+	defer r.invalidate()
+	return r.LookupRepository.BulkSaveValues(ctx, categoryID, toCreate, toUpdate)
+}
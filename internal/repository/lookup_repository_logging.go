@@ -0,0 +1,685 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/automax/backend/internal/models"
+	"github.com/automax/backend/pkg/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Logger is the structured logger used by the logging repositories. Its method
+// set matches *slog.Logger; other loggers such as zap's SugaredLogger can be
+// adapted with a thin wrapper.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+type loggingLookupRepository struct {
+	next   LookupRepository
+	logger Logger
+}
+
+// NewLoggingLookupRepository wraps a LookupRepository so every operation is
+// logged with its duration, key parameters and error. Successful calls and
+// not-found lookups log at debug level, failures at error level.
+func NewLoggingLookupRepository(next LookupRepository, logger Logger) LookupRepository {
+	return &loggingLookupRepository{next: next, logger: logger}
+}
+
+func (r *loggingLookupRepository) log(op string, start time.Time, err error, args ...interface{}) {
+	args = append([]interface{}{"op", op, "duration", time.Since(start)}, args...)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		r.logger.Error("lookup repository operation failed", append(args, "error", err)...)
+		return
+	}
+	if err != nil {
+		args = append(args, "error", err)
+	}
+	r.logger.Debug("lookup repository operation", args...)
+}
+
+// WithTx logs the transaction as a whole; the repository passed to fn logs
+// each operation inside it as well
+func (r *loggingLookupRepository) WithTx(ctx context.Context, fn func(txRepo LookupRepository) error) error {
+	start := time.Now()
+	err := r.next.WithTx(ctx, func(txRepo LookupRepository) error {
+		return fn(&loggingLookupRepository{next: txRepo, logger: r.logger})
+	})
+	r.log("WithTx", start, err)
+	return err
+}
+
+// WithDefaultLock logs the locked transaction as a whole, like WithTx
+func (r *loggingLookupRepository) WithDefaultLock(ctx context.Context, categoryID uuid.UUID, fn func(txRepo LookupRepository) error) error {
+	start := time.Now()
+	err := r.next.WithDefaultLock(ctx, categoryID, func(txRepo LookupRepository) error {
+		return fn(&loggingLookupRepository{next: txRepo, logger: r.logger})
+	})
+	r.log("WithDefaultLock", start, err, "category_id", categoryID)
+	return err
+}
+
+// Category methods
+
+func (r *loggingLookupRepository) CreateCategory(ctx context.Context, category *models.LookupCategory) error {
+	start := time.Now()
+	err := r.next.CreateCategory(ctx, category)
+	r.log("CreateCategory", start, err, "code", category.Code)
+	return err
+}
+
+func (r *loggingLookupRepository) FindCategoryByID(ctx context.Context, id uuid.UUID) (*models.LookupCategory, error) {
+	start := time.Now()
+	category, err := r.next.FindCategoryByID(ctx, id)
+	r.log("FindCategoryByID", start, err, "id", id)
+	return category, err
+}
+
+func (r *loggingLookupRepository) FindCategoryByCode(ctx context.Context, code string) (*models.LookupCategory, error) {
+	start := time.Now()
+	category, err := r.next.FindCategoryByCode(ctx, code)
+	r.log("FindCategoryByCode", start, err, "code", code)
+	return category, err
+}
+
+func (r *loggingLookupRepository) FindCategoryByCodeAnyStatus(ctx context.Context, code string) (*models.LookupCategory, error) {
+	start := time.Now()
+	category, err := r.next.FindCategoryByCodeAnyStatus(ctx, code)
+	r.log("FindCategoryByCodeAnyStatus", start, err, "code", code)
+	return category, err
+}
+
+func (r *loggingLookupRepository) ListInactiveDefaults(ctx context.Context) ([]models.LookupInactiveDefault, error) {
+	start := time.Now()
+	defaults, err := r.next.ListInactiveDefaults(ctx)
+	r.log("ListInactiveDefaults", start, err, "count", len(defaults))
+	return defaults, err
+}
+
+func (r *loggingLookupRepository) ListMissingDefaults(ctx context.Context) ([]models.LookupMissingDefault, error) {
+	start := time.Now()
+	missing, err := r.next.ListMissingDefaults(ctx)
+	r.log("ListMissingDefaults", start, err, "count", len(missing))
+	return missing, err
+}
+
+func (r *loggingLookupRepository) PurgeDeletedOlderThan(ctx context.Context, d time.Duration) (*models.LookupPurgeResult, error) {
+	start := time.Now()
+	result, err := r.next.PurgeDeletedOlderThan(ctx, d)
+	args := []interface{}{"older_than", d}
+	if result != nil {
+		args = append(args, "categories", result.Categories, "values", result.Values)
+	}
+	r.log("PurgeDeletedOlderThan", start, err, args...)
+	return result, err
+}
+
+func (r *loggingLookupRepository) FindCategoriesByIDs(ctx context.Context, ids []uuid.UUID) ([]models.LookupCategory, error) {
+	start := time.Now()
+	categories, err := r.next.FindCategoriesByIDs(ctx, ids)
+	r.log("FindCategoriesByIDs", start, err, "ids", len(ids), "count", len(categories))
+	return categories, err
+}
+
+func (r *loggingLookupRepository) FindIncidentFormPrefixConflict(ctx context.Context, category *models.LookupCategory) (*models.LookupCategory, error) {
+	start := time.Now()
+	conflict, err := r.next.FindIncidentFormPrefixConflict(ctx, category)
+	r.log("FindIncidentFormPrefixConflict", start, err, "code", category.Code)
+	return conflict, err
+}
+
+func (r *loggingLookupRepository) UpdateCategory(ctx context.Context, category *models.LookupCategory) error {
+	start := time.Now()
+	err := r.next.UpdateCategory(ctx, category)
+	r.log("UpdateCategory", start, err, "id", category.ID, "code", category.Code)
+	return err
+}
+
+func (r *loggingLookupRepository) UpsertCategoryByCode(ctx context.Context, code string, apply func(category *models.LookupCategory, created bool) error) (*models.LookupCategory, bool, error) {
+	start := time.Now()
+	category, created, err := r.next.UpsertCategoryByCode(ctx, code, apply)
+	r.log("UpsertCategoryByCode", start, err, "code", code, "created", created)
+	return category, created, err
+}
+
+func (r *loggingLookupRepository) DeleteCategory(ctx context.Context, id uuid.UUID) error {
+	start := time.Now()
+	err := r.next.DeleteCategory(ctx, id)
+	r.log("DeleteCategory", start, err, "id", id)
+	return err
+}
+
+func (r *loggingLookupRepository) ListCategories(ctx context.Context) ([]models.LookupCategory, error) {
+	start := time.Now()
+	categories, err := r.next.ListCategories(ctx)
+	r.log("ListCategories", start, err, "count", len(categories))
+	return categories, err
+}
+
+func (r *loggingLookupRepository) ListCategoriesWithoutValues(ctx context.Context) ([]models.LookupCategory, error) {
+	start := time.Now()
+	categories, err := r.next.ListCategoriesWithoutValues(ctx)
+	r.log("ListCategoriesWithoutValues", start, err, "count", len(categories))
+	return categories, err
+}
+
+func (r *loggingLookupRepository) ListCategoriesPaged(ctx context.Context, opts utils.ListOptions) ([]models.LookupCategory, int64, error) {
+	start := time.Now()
+	categories, total, err := r.next.ListCategoriesPaged(ctx, opts)
+	r.log("ListCategoriesPaged", start, err, "page", opts.Page, "limit", opts.Limit, "search", opts.Search, "total", total)
+	return categories, total, err
+}
+
+func (r *loggingLookupRepository) CategoryCodeCollisions(ctx context.Context, perOrg bool) ([]models.LookupCodeCollision, error) {
+	start := time.Now()
+	collisions, err := r.next.CategoryCodeCollisions(ctx, perOrg)
+	r.log("CategoryCodeCollisions", start, err, "per_org", perOrg, "count", len(collisions))
+	return collisions, err
+}
+
+func (r *loggingLookupRepository) ListCategoryCodesByPrefix(ctx context.Context, prefix string, limit int) ([]string, error) {
+	start := time.Now()
+	codes, err := r.next.ListCategoryCodesByPrefix(ctx, prefix, limit)
+	r.log("ListCategoryCodesByPrefix", start, err, "prefix", prefix, "count", len(codes))
+	return codes, err
+}
+
+func (r *loggingLookupRepository) SuggestCategoryCode(ctx context.Context, base string) (string, error) {
+	start := time.Now()
+	code, err := r.next.SuggestCategoryCode(ctx, base)
+	r.log("SuggestCategoryCode", start, err, "base", base, "suggestion", code)
+	return code, err
+}
+
+func (r *loggingLookupRepository) SetCategoriesActive(ctx context.Context, ids []uuid.UUID, active bool) (*models.LookupCategoriesActiveResult, error) {
+	start := time.Now()
+	result, err := r.next.SetCategoriesActive(ctx, ids, active)
+	r.log("SetCategoriesActive", start, err, "count", len(ids), "active", active)
+	return result, err
+}
+
+func (r *loggingLookupRepository) SetCategoriesIncidentForm(ctx context.Context, ids []uuid.UUID, add bool) (*models.LookupCategoriesIncidentFormResult, error) {
+	start := time.Now()
+	result, err := r.next.SetCategoriesIncidentForm(ctx, ids, add)
+	args := []interface{}{"ids", len(ids), "add", add}
+	if result != nil {
+		args = append(args, "updated", len(result.Updated), "skipped", len(result.Skipped), "not_found", len(result.NotFound))
+	}
+	r.log("SetCategoriesIncidentForm", start, err, args...)
+	return result, err
+}
+
+func (r *loggingLookupRepository) ResetCategoryToSeed(ctx context.Context, category *models.LookupCategory, seed *models.LookupCategorySeed, strict bool) (*models.LookupResetResult, error) {
+	start := time.Now()
+	result, err := r.next.ResetCategoryToSeed(ctx, category, seed, strict)
+	r.log("ResetCategoryToSeed", start, err, "category_id", category.ID, "strict", strict)
+	return result, err
+}
+
+func (r *loggingLookupRepository) TouchCategory(ctx context.Context, id uuid.UUID) (time.Time, error) {
+	start := time.Now()
+	updatedAt, err := r.next.TouchCategory(ctx, id)
+	r.log("TouchCategory", start, err, "id", id)
+	return updatedAt, err
+}
+
+func (r *loggingLookupRepository) ArchiveCategory(ctx context.Context, id uuid.UUID, cascadeValues bool) (int64, error) {
+	start := time.Now()
+	archived, err := r.next.ArchiveCategory(ctx, id, cascadeValues)
+	r.log("ArchiveCategory", start, err, "id", id, "cascade_values", cascadeValues, "values", archived)
+	return archived, err
+}
+
+func (r *loggingLookupRepository) UnarchiveCategory(ctx context.Context, id uuid.UUID, withValues bool) (int64, error) {
+	start := time.Now()
+	restored, err := r.next.UnarchiveCategory(ctx, id, withValues)
+	r.log("UnarchiveCategory", start, err, "id", id, "with_values", withValues, "values", restored)
+	return restored, err
+}
+
+func (r *loggingLookupRepository) ListActiveCategories(ctx context.Context) ([]models.LookupCategory, error) {
+	start := time.Now()
+	categories, err := r.next.ListActiveCategories(ctx)
+	r.log("ListActiveCategories", start, err, "count", len(categories))
+	return categories, err
+}
+
+func (r *loggingLookupRepository) ListIncidentFormCategories(ctx context.Context) ([]models.LookupCategory, error) {
+	start := time.Now()
+	categories, err := r.next.ListIncidentFormCategories(ctx)
+	r.log("ListIncidentFormCategories", start, err, "count", len(categories))
+	return categories, err
+}
+
+func (r *loggingLookupRepository) GetLookupStats(ctx context.Context) (models.LookupStats, error) {
+	start := time.Now()
+	stats, err := r.next.GetLookupStats(ctx)
+	r.log("GetLookupStats", start, err)
+	return stats, err
+}
+
+func (r *loggingLookupRepository) CountCategoryUsage(ctx context.Context, categoryID uuid.UUID) (models.LookupCategoryUsage, error) {
+	start := time.Now()
+	usage, err := r.next.CountCategoryUsage(ctx, categoryID)
+	r.log("CountCategoryUsage", start, err, "category_id", categoryID, "incidents", usage.Incidents)
+	return usage, err
+}
+
+func (r *loggingLookupRepository) ListColorUsage(ctx context.Context) ([]models.LookupColorUsage, error) {
+	start := time.Now()
+	usage, err := r.next.ListColorUsage(ctx)
+	r.log("ListColorUsage", start, err, "count", len(usage))
+	return usage, err
+}
+
+func (r *loggingLookupRepository) RecolorValues(ctx context.Context, mapping map[string]string) (*models.LookupRecolorResult, error) {
+	start := time.Now()
+	result, err := r.next.RecolorValues(ctx, mapping)
+	kv := []interface{}{"mappings", len(mapping)}
+	if result != nil {
+		kv = append(kv, "total", result.Total)
+	}
+	r.log("RecolorValues", start, err, kv...)
+	return result, err
+}
+
+func (r *loggingLookupRepository) ListRecentlyUpdated(ctx context.Context, limit int) ([]models.LookupRecentChange, error) {
+	start := time.Now()
+	changes, err := r.next.ListRecentlyUpdated(ctx, limit)
+	r.log("ListRecentlyUpdated", start, err, "limit", limit, "count", len(changes))
+	return changes, err
+}
+
+func (r *loggingLookupRepository) ListChangesSince(ctx context.Context, after models.LookupSyncCursor) (*models.LookupChanges, error) {
+	start := time.Now()
+	changes, err := r.next.ListChangesSince(ctx, after)
+	args := []interface{}{"since", after.UpdatedAt, "after_id", after.ID}
+	if changes != nil {
+		args = append(args, "categories", len(changes.Categories), "values", len(changes.Values),
+			"deleted", len(changes.DeletedCategories)+len(changes.DeletedValues))
+	}
+	r.log("ListChangesSince", start, err, args...)
+	return changes, err
+}
+
+func (r *loggingLookupRepository) ImportLookups(ctx context.Context, data *models.LookupExport, opts models.LookupImportOptions) (*models.LookupImportResult, error) {
+	start := time.Now()
+	result, err := r.next.ImportLookups(ctx, data, opts)
+	args := []interface{}{"categories", len(data.Categories), "dry_run", opts.DryRun, "validation", opts.Validation}
+	if result != nil {
+		args = append(args, "created", result.Created, "updated", result.Updated, "skipped", result.Skipped, "failed", result.Failed, "warnings", len(result.Warnings))
+	}
+	r.log("ImportLookups", start, err, args...)
+	return result, err
+}
+
+// Value methods
+
+func (r *loggingLookupRepository) CreateValue(ctx context.Context, value *models.LookupValue) error {
+	start := time.Now()
+	err := r.next.CreateValue(ctx, value)
+	r.log("CreateValue", start, err, "category_id", value.CategoryID, "code", value.Code)
+	return err
+}
+
+func (r *loggingLookupRepository) FindValueByID(ctx context.Context, id uuid.UUID) (*models.LookupValue, error) {
+	start := time.Now()
+	value, err := r.next.FindValueByID(ctx, id)
+	r.log("FindValueByID", start, err, "id", id)
+	return value, err
+}
+
+func (r *loggingLookupRepository) ValueIDExists(ctx context.Context, id uuid.UUID) (bool, error) {
+	start := time.Now()
+	exists, err := r.next.ValueIDExists(ctx, id)
+	r.log("ValueIDExists", start, err, "id", id, "exists", exists)
+	return exists, err
+}
+
+func (r *loggingLookupRepository) FindValueByCode(ctx context.Context, categoryID uuid.UUID, code string) (*models.LookupValue, error) {
+	start := time.Now()
+	value, err := r.next.FindValueByCode(ctx, categoryID, code)
+	r.log("FindValueByCode", start, err, "category_id", categoryID, "code", code)
+	return value, err
+}
+
+func (r *loggingLookupRepository) CreateValueAtSortOrder(ctx context.Context, value *models.LookupValue) error {
+	start := time.Now()
+	err := r.next.CreateValueAtSortOrder(ctx, value)
+	r.log("CreateValueAtSortOrder", start, err, "category_id", value.CategoryID, "code", value.Code, "sort_order", value.SortOrder)
+	return err
+}
+
+func (r *loggingLookupRepository) UpdateValueAtSortOrder(ctx context.Context, value *models.LookupValue) error {
+	start := time.Now()
+	err := r.next.UpdateValueAtSortOrder(ctx, value)
+	r.log("UpdateValueAtSortOrder", start, err, "id", value.ID, "code", value.Code, "sort_order", value.SortOrder)
+	return err
+}
+
+func (r *loggingLookupRepository) FindValueAncestors(ctx context.Context, id uuid.UUID) ([]models.LookupValue, error) {
+	start := time.Now()
+	values, err := r.next.FindValueAncestors(ctx, id)
+	r.log("FindValueAncestors", start, err, "id", id, "depth", len(values))
+	return values, err
+}
+
+func (r *loggingLookupRepository) NormalizeSortOrder(ctx context.Context, categoryID uuid.UUID) ([]models.LookupValue, error) {
+	start := time.Now()
+	values, err := r.next.NormalizeSortOrder(ctx, categoryID)
+	r.log("NormalizeSortOrder", start, err, "category_id", categoryID, "count", len(values))
+	return values, err
+}
+
+func (r *loggingLookupRepository) ListOrphanValues(ctx context.Context) ([]models.LookupValue, error) {
+	start := time.Now()
+	values, err := r.next.ListOrphanValues(ctx)
+	r.log("ListOrphanValues", start, err, "count", len(values))
+	return values, err
+}
+
+func (r *loggingLookupRepository) CleanupOrphanValues(ctx context.Context, ids []uuid.UUID, targetCategoryID *uuid.UUID) (*models.LookupOrphanCleanupResult, error) {
+	start := time.Now()
+	result, err := r.next.CleanupOrphanValues(ctx, ids, targetCategoryID)
+	kv := []interface{}{"ids", len(ids), "target_category_id", targetCategoryID}
+	if result != nil {
+		kv = append(kv, "affected", result.Affected, "skipped", len(result.Skipped))
+	}
+	r.log("CleanupOrphanValues", start, err, kv...)
+	return result, err
+}
+
+func (r *loggingLookupRepository) RestoreValues(ctx context.Context, categoryID uuid.UUID, ids []uuid.UUID) ([]models.LookupValue, []models.LookupValueRestoreSkip, error) {
+	start := time.Now()
+	restored, skipped, err := r.next.RestoreValues(ctx, categoryID, ids)
+	r.log("RestoreValues", start, err, "category_id", categoryID, "ids", len(ids), "restored", len(restored), "skipped", len(skipped))
+	return restored, skipped, err
+}
+
+func (r *loggingLookupRepository) MoveValues(ctx context.Context, sourceID, targetID uuid.UUID, valueIDs []uuid.UUID) ([]models.LookupValue, error) {
+	start := time.Now()
+	values, err := r.next.MoveValues(ctx, sourceID, targetID, valueIDs)
+	r.log("MoveValues", start, err, "source_category_id", sourceID, "target_category_id", targetID, "ids", len(valueIDs), "moved", len(values))
+	return values, err
+}
+
+func (r *loggingLookupRepository) UpdateValue(ctx context.Context, value *models.LookupValue) error {
+	start := time.Now()
+	err := r.next.UpdateValue(ctx, value)
+	r.log("UpdateValue", start, err, "id", value.ID, "code", value.Code)
+	return err
+}
+
+func (r *loggingLookupRepository) DeleteValue(ctx context.Context, id uuid.UUID) error {
+	start := time.Now()
+	err := r.next.DeleteValue(ctx, id)
+	r.log("DeleteValue", start, err, "id", id)
+	return err
+}
+
+func (r *loggingLookupRepository) ListValuesByCategory(ctx context.Context, categoryID uuid.UUID) ([]models.LookupValue, error) {
+	start := time.Now()
+	values, err := r.next.ListValuesByCategory(ctx, categoryID)
+	r.log("ListValuesByCategory", start, err, "category_id", categoryID, "count", len(values))
+	return values, err
+}
+
+func (r *loggingLookupRepository) ListValuesByMetadataKey(ctx context.Context, categoryID uuid.UUID, key, value string) ([]models.LookupValue, error) {
+	start := time.Now()
+	values, err := r.next.ListValuesByMetadataKey(ctx, categoryID, key, value)
+	r.log("ListValuesByMetadataKey", start, err, "category_id", categoryID, "key", key, "count", len(values))
+	return values, err
+}
+
+func (r *loggingLookupRepository) ListValuesByCategoryPaged(ctx context.Context, categoryID uuid.UUID, tagCode string, opts utils.ListOptions) ([]models.LookupValue, int64, error) {
+	start := time.Now()
+	values, total, err := r.next.ListValuesByCategoryPaged(ctx, categoryID, tagCode, opts)
+	r.log("ListValuesByCategoryPaged", start, err, "category_id", categoryID, "tag", tagCode, "page", opts.Page, "limit", opts.Limit, "search", opts.Search, "skip_total", opts.SkipTotal, "total", total)
+	return values, total, err
+}
+
+func (r *loggingLookupRepository) SearchValues(ctx context.Context, query string, opts utils.ListOptions) ([]models.LookupValue, int64, error) {
+	start := time.Now()
+	values, total, err := r.next.SearchValues(ctx, query, opts)
+	r.log("SearchValues", start, err, "query", query, "page", opts.Page, "limit", opts.Limit, "total", total)
+	return values, total, err
+}
+
+func (r *loggingLookupRepository) AutocompleteValues(ctx context.Context, categoryID uuid.UUID, query string, limit int) ([]models.LookupValue, error) {
+	start := time.Now()
+	values, err := r.next.AutocompleteValues(ctx, categoryID, query, limit)
+	r.log("AutocompleteValues", start, err, "category_id", categoryID, "query", query, "limit", limit, "count", len(values))
+	return values, err
+}
+
+func (r *loggingLookupRepository) FullTextSearchValues(ctx context.Context, query string, opts utils.ListOptions) ([]models.LookupValue, int64, error) {
+	start := time.Now()
+	values, total, err := r.next.FullTextSearchValues(ctx, query, opts)
+	r.log("FullTextSearchValues", start, err, "query", query, "page", opts.Page, "limit", opts.Limit, "total", total)
+	return values, total, err
+}
+
+func (r *loggingLookupRepository) ValuesChangedAt(ctx context.Context, categoryID uuid.UUID) (time.Time, error) {
+	start := time.Now()
+	changedAt, err := r.next.ValuesChangedAt(ctx, categoryID)
+	r.log("ValuesChangedAt", start, err, "category_id", categoryID)
+	return changedAt, err
+}
+
+func (r *loggingLookupRepository) ValuesMissingArabicNames(ctx context.Context, categoryID uuid.UUID) ([]string, error) {
+	start := time.Now()
+	codes, err := r.next.ValuesMissingArabicNames(ctx, categoryID)
+	r.log("ValuesMissingArabicNames", start, err, "category_id", categoryID, "count", len(codes))
+	return codes, err
+}
+
+func (r *loggingLookupRepository) ListValuesByCategoryCode(ctx context.Context, code string, filter models.LookupValueFilter) ([]models.LookupValue, error) {
+	start := time.Now()
+	values, err := r.next.ListValuesByCategoryCode(ctx, code, filter)
+	r.log("ListValuesByCategoryCode", start, err, "category_code", code, "include_deprecated", filter.IncludeDeprecated, "as_of", filter.AsOf, "count", len(values))
+	return values, err
+}
+
+func (r *loggingLookupRepository) GetDefaultValue(ctx context.Context, categoryCode string) (*models.LookupValue, error) {
+	start := time.Now()
+	value, err := r.next.GetDefaultValue(ctx, categoryCode)
+	r.log("GetDefaultValue", start, err, "category_code", categoryCode)
+	return value, err
+}
+
+func (r *loggingLookupRepository) GetEffectiveDefaultValue(ctx context.Context, categoryCode string) (*models.LookupValue, bool, error) {
+	start := time.Now()
+	value, isFallback, err := r.next.GetEffectiveDefaultValue(ctx, categoryCode)
+	r.log("GetEffectiveDefaultValue", start, err, "category_code", categoryCode, "fallback", isFallback)
+	return value, isFallback, err
+}
+
+func (r *loggingLookupRepository) GetWeightedDefault(ctx context.Context, categoryCode string) (*models.LookupValue, bool, error) {
+	start := time.Now()
+	value, weighted, err := r.next.GetWeightedDefault(ctx, categoryCode)
+	r.log("GetWeightedDefault", start, err, "category_code", categoryCode, "weighted", weighted)
+	return value, weighted, err
+}
+
+func (r *loggingLookupRepository) ClearDefaultForCategory(ctx context.Context, categoryID uuid.UUID) (int64, error) {
+	start := time.Now()
+	cleared, err := r.next.ClearDefaultForCategory(ctx, categoryID)
+	r.log("ClearDefaultForCategory", start, err, "category_id", categoryID, "cleared", cleared)
+	return cleared, err
+}
+
+func (r *loggingLookupRepository) NextSortOrder(ctx context.Context, categoryID uuid.UUID) (int, error) {
+	start := time.Now()
+	order, err := r.next.NextSortOrder(ctx, categoryID)
+	r.log("NextSortOrder", start, err, "category_id", categoryID, "sort_order", order)
+	return order, err
+}
+
+func (r *loggingLookupRepository) SwapDefault(ctx context.Context, categoryID, newDefaultID uuid.UUID) error {
+	start := time.Now()
+	err := r.next.SwapDefault(ctx, categoryID, newDefaultID)
+	r.log("SwapDefault", start, err, "id", newDefaultID, "category_id", categoryID)
+	return err
+}
+
+func (r *loggingLookupRepository) SetDefaultValues(ctx context.Context, assignments []models.LookupDefaultAssignment) ([]models.LookupDefaultResult, error) {
+	start := time.Now()
+	results, err := r.next.SetDefaultValues(ctx, assignments)
+	r.log("SetDefaultValues", start, err, "count", len(assignments))
+	return results, err
+}
+
+func (r *loggingLookupRepository) BulkSaveValues(ctx context.Context, categoryID uuid.UUID, toCreate, toUpdate []models.LookupValue) error {
+	start := time.Now()
+	err := r.next.BulkSaveValues(ctx, categoryID, toCreate, toUpdate)
+	r.log("BulkSaveValues", start, err, "category_id", categoryID, "created", len(toCreate), "updated", len(toUpdate))
+	return err
+}
+
+// Alias methods
+
+func (r *loggingLookupRepository) CreateAlias(ctx context.Context, alias *models.LookupValueAlias) error {
+	start := time.Now()
+	err := r.next.CreateAlias(ctx, alias)
+	r.log("CreateAlias", start, err, "value_id", alias.ValueID, "alias_code", alias.AliasCode)
+	return err
+}
+
+func (r *loggingLookupRepository) ListAliases(ctx context.Context, valueID uuid.UUID) ([]models.LookupValueAlias, error) {
+	start := time.Now()
+	aliases, err := r.next.ListAliases(ctx, valueID)
+	r.log("ListAliases", start, err, "value_id", valueID, "count", len(aliases))
+	return aliases, err
+}
+
+func (r *loggingLookupRepository) DeleteAlias(ctx context.Context, valueID, aliasID uuid.UUID) error {
+	start := time.Now()
+	err := r.next.DeleteAlias(ctx, valueID, aliasID)
+	r.log("DeleteAlias", start, err, "value_id", valueID, "alias_id", aliasID)
+	return err
+}
+
+func (r *loggingLookupRepository) ResolveValueByAlias(ctx context.Context, categoryCode, aliasOrCode string) (*models.LookupValue, error) {
+	start := time.Now()
+	value, err := r.next.ResolveValueByAlias(ctx, categoryCode, aliasOrCode)
+	r.log("ResolveValueByAlias", start, err, "category_code", categoryCode, "alias_or_code", aliasOrCode)
+	return value, err
+}
+
+func (r *loggingLookupRepository) FindValueIDByCodes(ctx context.Context, categoryCode, valueCode string) (uuid.UUID, error) {
+	start := time.Now()
+	id, err := r.next.FindValueIDByCodes(ctx, categoryCode, valueCode)
+	r.log("FindValueIDByCodes", start, err, "category_code", categoryCode, "value_code", valueCode)
+	return id, err
+}
+
+func (r *loggingLookupRepository) ResolveValueCodes(ctx context.Context, pairs []models.LookupCodePair) ([]models.LookupCodeResolution, error) {
+	start := time.Now()
+	results, err := r.next.ResolveValueCodes(ctx, pairs)
+	r.log("ResolveValueCodes", start, err, "pairs", len(pairs))
+	return results, err
+}
+
+// Translation methods
+
+func (r *loggingLookupRepository) SetValueTranslation(ctx context.Context, translation *models.LookupValueTranslation) error {
+	start := time.Now()
+	err := r.next.SetValueTranslation(ctx, translation)
+	r.log("SetValueTranslation", start, err, "value_id", translation.ValueID, "locale", translation.Locale)
+	return err
+}
+
+func (r *loggingLookupRepository) ImportArabicTranslations(ctx context.Context, categoryID uuid.UUID, rows []models.LookupTranslationImportRow) (*models.LookupTranslationImportResult, error) {
+	start := time.Now()
+	result, err := r.next.ImportArabicTranslations(ctx, categoryID, rows)
+	args := []interface{}{"category_id", categoryID, "rows", len(rows)}
+	if result != nil {
+		args = append(args, "updated", result.Updated, "unmatched", len(result.Unmatched))
+	}
+	r.log("ImportArabicTranslations", start, err, args...)
+	return result, err
+}
+
+func (r *loggingLookupRepository) ListValueTranslations(ctx context.Context, valueID uuid.UUID) ([]models.LookupValueTranslation, error) {
+	start := time.Now()
+	translations, err := r.next.ListValueTranslations(ctx, valueID)
+	r.log("ListValueTranslations", start, err, "value_id", valueID, "count", len(translations))
+	return translations, err
+}
+
+func (r *loggingLookupRepository) FindValueTranslations(ctx context.Context, valueIDs []uuid.UUID, locales []string) ([]models.LookupValueTranslation, error) {
+	start := time.Now()
+	translations, err := r.next.FindValueTranslations(ctx, valueIDs, locales)
+	r.log("FindValueTranslations", start, err, "values", len(valueIDs), "locales", locales, "count", len(translations))
+	return translations, err
+}
+
+// Tag methods
+
+func (r *loggingLookupRepository) LinkCategories(ctx context.Context, categoryID, relatedID uuid.UUID) error {
+	start := time.Now()
+	err := r.next.LinkCategories(ctx, categoryID, relatedID)
+	r.log("LinkCategories", start, err, "category_id", categoryID, "related_category_id", relatedID)
+	return err
+}
+
+func (r *loggingLookupRepository) UnlinkCategories(ctx context.Context, categoryID, relatedID uuid.UUID) error {
+	start := time.Now()
+	err := r.next.UnlinkCategories(ctx, categoryID, relatedID)
+	r.log("UnlinkCategories", start, err, "category_id", categoryID, "related_category_id", relatedID)
+	return err
+}
+
+func (r *loggingLookupRepository) ListRelatedCategories(ctx context.Context, categoryID uuid.UUID) ([]models.LookupCategory, error) {
+	start := time.Now()
+	categories, err := r.next.ListRelatedCategories(ctx, categoryID)
+	r.log("ListRelatedCategories", start, err, "category_id", categoryID, "count", len(categories))
+	return categories, err
+}
+
+func (r *loggingLookupRepository) CreateTag(ctx context.Context, tag *models.LookupTag) error {
+	start := time.Now()
+	err := r.next.CreateTag(ctx, tag)
+	r.log("CreateTag", start, err, "code", tag.Code)
+	return err
+}
+
+func (r *loggingLookupRepository) FindTagByID(ctx context.Context, id uuid.UUID) (*models.LookupTag, error) {
+	start := time.Now()
+	tag, err := r.next.FindTagByID(ctx, id)
+	r.log("FindTagByID", start, err, "id", id)
+	return tag, err
+}
+
+func (r *loggingLookupRepository) ListTags(ctx context.Context) ([]models.LookupTag, error) {
+	start := time.Now()
+	tags, err := r.next.ListTags(ctx)
+	r.log("ListTags", start, err, "count", len(tags))
+	return tags, err
+}
+
+func (r *loggingLookupRepository) AttachTagValues(ctx context.Context, tag *models.LookupTag, valueIDs []uuid.UUID) (*models.LookupTagValuesResult, error) {
+	start := time.Now()
+	result, err := r.next.AttachTagValues(ctx, tag, valueIDs)
+	r.log("AttachTagValues", start, err, "tag_id", tag.ID, "count", len(valueIDs))
+	return result, err
+}
+
+func (r *loggingLookupRepository) DetachTagValue(ctx context.Context, tag *models.LookupTag, valueID uuid.UUID) error {
+	start := time.Now()
+	err := r.next.DetachTagValue(ctx, tag, valueID)
+	r.log("DetachTagValue", start, err, "tag_id", tag.ID, "value_id", valueID)
+	return err
+}
+
+func (r *loggingLookupRepository) ListValuesByTagCode(ctx context.Context, code string) ([]models.LookupValue, error) {
+	start := time.Now()
+	values, err := r.next.ListValuesByTagCode(ctx, code)
+	r.log("ListValuesByTagCode", start, err, "code", code, "count", len(values))
+	return values, err
+}
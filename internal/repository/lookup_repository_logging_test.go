@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/automax/backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// recordingLogger keeps the level, op and error of every entry
+type recordingLogger struct {
+	entries []string
+}
+
+func (l *recordingLogger) record(level string, args ...interface{}) {
+	entry := level
+	for i := 0; i+1 < len(args); i += 2 {
+		if key := args[i]; key == "op" || key == "error" {
+			entry += fmt.Sprintf(" %s=%v", key, args[i+1])
+		}
+	}
+	l.entries = append(l.entries, entry)
+}
+
+func (l *recordingLogger) Debug(msg string, args ...interface{}) { l.record("debug", args...) }
+func (l *recordingLogger) Error(msg string, args ...interface{}) { l.record("error", args...) }
+
+// stubCategoryRepository answers FindCategoryByID with err
+type stubCategoryRepository struct {
+	LookupRepository
+	err error
+}
+
+func (r *stubCategoryRepository) FindCategoryByID(ctx context.Context, id uuid.UUID) (*models.LookupCategory, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &models.LookupCategory{ID: id}, nil
+}
+
+func TestLoggingLookupRepositoryLogsFailuresAtErrorLevel(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want string
+	}{
+		{nil, "debug op=FindCategoryByID"},
+		{gorm.ErrRecordNotFound, "debug op=FindCategoryByID error=record not found"},
+		{errors.New("connection reset"), "error op=FindCategoryByID error=connection reset"},
+	} {
+		logger := &recordingLogger{}
+		repo := NewLoggingLookupRepository(&stubCategoryRepository{err: tc.err}, logger)
+		if _, err := repo.FindCategoryByID(context.Background(), uuid.New()); err != tc.err {
+			t.Errorf("FindCategoryByID err = %v, want %v passed through", err, tc.err)
+		}
+		if fmt.Sprint(logger.entries) != fmt.Sprint([]string{tc.want}) {
+			t.Errorf("logged %v, want [%s]", logger.entries, tc.want)
+		}
+	}
+}
+
+func TestLoggingLookupRepositoryLogsInsideTransactions(t *testing.T) {
+	db := newTestDB(t)
+	category := createTestCategory(t, db, nil, "PRIORITY")
+	logger := &recordingLogger{}
+	repo := NewLoggingLookupRepository(NewLookupRepository(db), logger)
+
+	err := repo.WithTx(context.Background(), func(txRepo LookupRepository) error {
+		_, err := txRepo.FindCategoryByID(context.Background(), category.ID)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WithTx: %v", err)
+	}
+	if want := "[debug op=FindCategoryByID debug op=WithTx]"; fmt.Sprint(logger.entries) != want {
+		t.Errorf("logged %v, want %s", logger.entries, want)
+	}
+}
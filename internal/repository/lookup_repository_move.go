@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/automax/backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Errors returned by MoveValues when the move can't be made
+var (
+	ErrLookupMoveToSystemCategory = errors.New("values cannot be moved into a system category")
+	ErrLookupMoveCodeTaken        = errors.New("a value code is already used in the target category")
+)
+
+// MoveValues moves the values in valueIDs from sourceID into targetID in one
+// transaction and returns them with their new category preloaded. Every value
+// must belong to the source, otherwise ErrLookupValueWrongCategory is returned
+// and nothing moves. Moved values lose their default flag and weight, keep
+// their relative order after the target's last value, and keep their parent
+// only if it moves with them; values left in the source lose a moved parent.
+// A system category's values stay put, see ErrLookupSystemValueFixed.
+func (r *lookupRepository) MoveValues(ctx context.Context, sourceID, targetID uuid.UUID, valueIDs []uuid.UUID) ([]models.LookupValue, error) {
+	var moved []models.LookupValue
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock both categories in a fixed order so concurrent moves in
+		// opposite directions can't deadlock
+		first, second := sourceID, targetID
+		if first.String() > second.String() {
+			first, second = second, first
+		}
+		locked := make(map[uuid.UUID]*models.LookupCategory, 2)
+		for _, id := range []uuid.UUID{first, second} {
+			category, err := lockCategory(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+			locked[id] = category
+		}
+		if locked[targetID].IsSystem {
+			return ErrLookupMoveToSystemCategory
+		}
+		if locked[sourceID].IsSystem {
+			return ErrLookupSystemValueFixed
+		}
+
+		var values []models.LookupValue
+		err := tx.Scopes(scopeToOrg(ctx, "lookup_values")).
+			Where("id IN ?", valueIDs).
+			Order("sort_order ASC, name ASC").
+			Find(&values).Error
+		if err != nil {
+			return err
+		}
+		unique := make(map[uuid.UUID]bool, len(valueIDs))
+		for _, id := range valueIDs {
+			unique[id] = true
+		}
+		if len(values) != len(unique) {
+			return ErrLookupValueWrongCategory
+		}
+		codes := make([]string, len(values))
+		for i, v := range values {
+			if v.CategoryID != sourceID {
+				return ErrLookupValueWrongCategory
+			}
+			if err := checkOrgWritable(ctx, v.OrgID); err != nil {
+				return err
+			}
+			codes[i] = v.Code
+		}
+
+		var taken int64
+		err = tx.Model(&models.LookupValue{}).
+			Where("category_id = ? AND code IN ?", targetID, codes).
+			Count(&taken).Error
+		if err != nil {
+			return err
+		}
+		if taken > 0 {
+			return ErrLookupMoveCodeTaken
+		}
+
+		var maxOrder int
+		err = tx.Model(&models.LookupValue{}).
+			Where("category_id = ?", targetID).
+			Select("COALESCE(MAX(sort_order), 0)").
+			Scan(&maxOrder).Error
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for i, v := range values {
+			updates := map[string]interface{}{
+				"category_id":    targetID,
+				"sort_order":     maxOrder + i + 1,
+				"is_default":     false,
+				"default_weight": 0,
+				"updated_at":     now,
+			}
+			if v.ParentID != nil && !unique[*v.ParentID] {
+				updates["parent_id"] = nil
+			}
+			if err := tx.Model(&models.LookupValue{}).Where("id = ?", v.ID).UpdateColumns(updates).Error; err != nil {
+				return err
+			}
+		}
+
+		err = tx.Model(&models.LookupValue{}).
+			Scopes(scopeToOrgWrites(ctx, "lookup_values")).
+			Where("category_id = ? AND parent_id IN ?", sourceID, valueIDs).
+			UpdateColumns(map[string]interface{}{"parent_id": nil, "updated_at": now}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Preload("Category").
+			Where("id IN ?", valueIDs).
+			Order("sort_order ASC").
+			Find(&moved).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return moved, nil
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/automax/backend/internal/models"
+)
+
+// PurgeDeletedOlderThan hard-deletes the categories and values soft-deleted
+// more than d ago that ctx may write, see scopeToOrgWrites. Values incidents
+// still reference are kept, as are system categories, their values, and
+// categories that still have values.
+func (r *lookupRepository) PurgeDeletedOlderThan(ctx context.Context, d time.Duration) (*models.LookupPurgeResult, error) {
+	result := &models.LookupPurgeResult{Cutoff: time.Now().Add(-d)}
+	err := r.withTx(ctx, func(tx *lookupRepository) error {
+		values := tx.db.WithContext(ctx).Unscoped().
+			Scopes(scopeToOrgWrites(ctx, "lookup_values")).
+			Where("lookup_values.deleted_at < ?", result.Cutoff).
+			Where(`NOT EXISTS (SELECT 1 FROM lookup_categories system_category
+				WHERE system_category.id = lookup_values.category_id AND system_category.is_system)`).
+			Where(`NOT EXISTS (SELECT 1 FROM incident_lookup_values
+				WHERE incident_lookup_values.lookup_value_id = lookup_values.id)`).
+			Delete(&models.LookupValue{})
+		if values.Error != nil {
+			return values.Error
+		}
+		result.Values = values.RowsAffected
+
+		categories := tx.db.WithContext(ctx).Unscoped().
+			Scopes(scopeToOrgWrites(ctx, "lookup_categories")).
+			Where("lookup_categories.deleted_at < ? AND NOT lookup_categories.is_system", result.Cutoff).
+			Where("NOT EXISTS (SELECT 1 FROM lookup_values WHERE lookup_values.category_id = lookup_categories.id)").
+			Delete(&models.LookupCategory{})
+		if categories.Error != nil {
+			return categories.Error
+		}
+		result.Categories = categories.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/automax/backend/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ListChangesSince returns the categories and values updated after the
+// cursor, oldest first, and the IDs of those soft-deleted after it. Each list
+// is keyset-ordered by (timestamp, id), so changes sharing a timestamp with
+// the cursor are neither skipped nor sent again. Deleting a category
+// soft-deletes its values too, so their IDs are listed as well.
+func (r *lookupRepository) ListChangesSince(ctx context.Context, after models.LookupSyncCursor) (*models.LookupChanges, error) {
+	changes := &models.LookupChanges{Next: after}
+
+	err := r.db.WithContext(ctx).
+		Scopes(scopeToOrg(ctx, "lookup_categories")).
+		Where("(updated_at, id) > (?, ?)", after.UpdatedAt, after.ID).
+		Order("updated_at ASC, id ASC").
+		Find(&changes.Categories).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, category := range changes.Categories {
+		advanceSyncCursor(&changes.Next, category.UpdatedAt, category.ID)
+	}
+
+	err = r.db.WithContext(ctx).
+		Joins(joinActiveCategory).
+		Scopes(scopeToOrg(ctx, "lookup_values"), scopeToRole(ctx, "lookup_values"), scopeToOrg(ctx, "lookup_categories")).
+		Where("(lookup_values.updated_at, lookup_values.id) > (?, ?)", after.UpdatedAt, after.ID).
+		Order("lookup_values.updated_at ASC, lookup_values.id ASC").
+		Find(&changes.Values).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, value := range changes.Values {
+		advanceSyncCursor(&changes.Next, value.UpdatedAt, value.ID)
+	}
+
+	changes.DeletedCategories, err = listDeletedAfter(r.db.WithContext(ctx).Model(&models.LookupCategory{}).
+		Scopes(scopeToOrg(ctx, "lookup_categories")), after, &changes.Next)
+	if err != nil {
+		return nil, err
+	}
+	changes.DeletedValues, err = listDeletedAfter(r.db.WithContext(ctx).Model(&models.LookupValue{}).
+		Scopes(scopeToOrg(ctx, "lookup_values")), after, &changes.Next)
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// listDeletedAfter returns the IDs of the rows of query soft-deleted after the
+// cursor, in (deleted_at, id) order, moving next past each of them
+func listDeletedAfter(query *gorm.DB, after models.LookupSyncCursor, next *models.LookupSyncCursor) ([]uuid.UUID, error) {
+	var rows []struct {
+		ID        uuid.UUID
+		DeletedAt time.Time
+	}
+	err := query.Unscoped().
+		Select("id", "deleted_at").
+		Where("(deleted_at, id) > (?, ?)", after.UpdatedAt, after.ID).
+		Order("deleted_at ASC, id ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uuid.UUID, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+		advanceSyncCursor(next, row.DeletedAt, row.ID)
+	}
+	return ids, nil
+}
+
+// advanceSyncCursor moves cursor to (at, id) when that change comes after it
+func advanceSyncCursor(cursor *models.LookupSyncCursor, at time.Time, id uuid.UUID) {
+	if at.After(cursor.UpdatedAt) || (at.Equal(cursor.UpdatedAt) && id.String() > cursor.ID.String()) {
+		cursor.UpdatedAt = at
+		cursor.ID = id
+	}
+}
@@ -0,0 +1,2003 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/automax/backend/internal/database"
+	"github.com/automax/backend/internal/models"
+	"github.com/automax/backend/pkg/utils"
+	"github.com/glebarez/sqlite"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB opens a migrated in-memory SQLite database private to the test.
+// A single connection is used, so transactions run one at a time.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", url.PathEscape(t.Name()))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+	return db
+}
+
+// createTestCategory stores an active category owned by orgID, nil for global
+func createTestCategory(t *testing.T, db *gorm.DB, orgID *uuid.UUID, code string) *models.LookupCategory {
+	t.Helper()
+	category := &models.LookupCategory{OrgID: orgID, Code: code, Name: code, IsActive: true}
+	if err := db.Create(category).Error; err != nil {
+		t.Fatalf("create category %s: %v", code, err)
+	}
+	return category
+}
+
+// createTestValue stores an active value of category owned by the category's org
+func createTestValue(t *testing.T, db *gorm.DB, category *models.LookupCategory, code string, sortOrder int, isDefault bool) *models.LookupValue {
+	t.Helper()
+	value := &models.LookupValue{
+		OrgID:      category.OrgID,
+		CategoryID: category.ID,
+		Code:       code,
+		Name:       code,
+		SortOrder:  sortOrder,
+		IsDefault:  isDefault,
+		IsActive:   true,
+		Status:     models.LookupValueStatusActive,
+	}
+	if err := db.Omit("Category").Create(value).Error; err != nil {
+		t.Fatalf("create value %s: %v", code, err)
+	}
+	return value
+}
+
+func TestNextSortOrderIncreasesFromZero(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	ctx := context.Background()
+	category := createTestCategory(t, db, nil, "PRIORITY")
+
+	for want := 0; want < 3; want++ {
+		got, err := repo.NextSortOrder(ctx, category.ID)
+		if err != nil {
+			t.Fatalf("NextSortOrder: %v", err)
+		}
+		if got != want {
+			t.Fatalf("NextSortOrder = %d, want %d", got, want)
+		}
+		value := &models.LookupValue{CategoryID: category.ID, Code: fmt.Sprintf("V%d", want), Name: "v", SortOrder: got, IsActive: true}
+		if err := repo.CreateValueAtSortOrder(ctx, value); err != nil {
+			t.Fatalf("CreateValueAtSortOrder: %v", err)
+		}
+	}
+}
+
+func TestPurgeDeletedOlderThanKeepsSystemAndGlobalValues(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	orgID := uuid.New()
+	tenant := WithOrgID(context.Background(), &orgID)
+
+	system := createTestCategory(t, db, &orgID, "STATUS")
+	if err := db.Model(system).Update("is_system", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	own := createTestCategory(t, db, &orgID, "SEVERITY")
+	global := createTestCategory(t, db, nil, "PRIORITY")
+	systemValue := createTestValue(t, db, system, "OPEN", 0, false)
+	ownValue := createTestValue(t, db, own, "MAJOR", 0, false)
+	globalValue := createTestValue(t, db, global, "HIGH", 0, false)
+
+	longAgo := time.Now().Add(-48 * time.Hour)
+	for _, id := range []uuid.UUID{systemValue.ID, ownValue.ID, globalValue.ID} {
+		if err := db.Model(&models.LookupValue{}).Where("id = ?", id).Update("deleted_at", longAgo).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := repo.PurgeDeletedOlderThan(tenant, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("PurgeDeletedOlderThan: %v", err)
+	}
+	if result.Values != 1 {
+		t.Errorf("purged %d values, want 1", result.Values)
+	}
+
+	var left []uuid.UUID
+	db.Unscoped().Model(&models.LookupValue{}).Order("code").Pluck("id", &left)
+	if len(left) != 2 || left[0] != globalValue.ID || left[1] != systemValue.ID {
+		t.Errorf("values left = %v, want the global %s and system %s values", left, globalValue.ID, systemValue.ID)
+	}
+}
+
+func TestPurgeDeletedOlderThanKeepsRowsWithinTheWindow(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	longAgo, lately := time.Now().Add(-48*time.Hour), time.Now().Add(-time.Hour)
+	softDelete := func(model any, id uuid.UUID, at time.Time) {
+		t.Helper()
+		if err := db.Model(model).Where("id = ?", id).Update("deleted_at", at).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// SEVERITY and its value went long ago; REGION only lately
+	old := createTestCategory(t, db, nil, "SEVERITY")
+	softDelete(&models.LookupValue{}, createTestValue(t, db, old, "MAJOR", 0, false).ID, longAgo)
+	softDelete(&models.LookupCategory{}, old.ID, longAgo)
+	recent := createTestCategory(t, db, nil, "REGION")
+	softDelete(&models.LookupCategory{}, recent.ID, lately)
+	// SOURCE went long ago but an incident still uses its EMAIL value
+	used := createTestCategory(t, db, nil, "SOURCE")
+	email := createTestValue(t, db, used, "EMAIL", 0, false)
+	softDelete(&models.LookupValue{}, email.ID, longAgo)
+	softDelete(&models.LookupCategory{}, used.ID, longAgo)
+	if err := db.Exec("INSERT INTO incident_lookup_values (incident_id, lookup_value_id) VALUES (?, ?)", uuid.New(), email.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	// PRIORITY stays, one value went long ago and one lately
+	live := createTestCategory(t, db, nil, "PRIORITY")
+	softDelete(&models.LookupValue{}, createTestValue(t, db, live, "HIGH", 0, false).ID, longAgo)
+	softDelete(&models.LookupValue{}, createTestValue(t, db, live, "LOW", 1, false).ID, lately)
+	system := createTestCategory(t, db, nil, "STATUS")
+	if err := db.Model(system).Update("is_system", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	softDelete(&models.LookupCategory{}, system.ID, longAgo)
+
+	result, err := repo.PurgeDeletedOlderThan(context.Background(), 24*time.Hour)
+	if err != nil {
+		t.Fatalf("PurgeDeletedOlderThan: %v", err)
+	}
+	if result.Values != 2 || result.Categories != 1 {
+		t.Errorf("purged %d values and %d categories, want 2 and 1", result.Values, result.Categories)
+	}
+	if cutoff := time.Now().Add(-24 * time.Hour); result.Cutoff.Sub(cutoff).Abs() > time.Minute {
+		t.Errorf("cutoff = %s, want about %s", result.Cutoff, cutoff)
+	}
+
+	var categories, values []string
+	db.Unscoped().Model(&models.LookupCategory{}).Order("code").Pluck("code", &categories)
+	db.Unscoped().Model(&models.LookupValue{}).Order("code").Pluck("code", &values)
+	if fmt.Sprint(categories) != "[PRIORITY REGION SOURCE STATUS]" {
+		t.Errorf("categories left = %v, want all but the old SEVERITY", categories)
+	}
+	if fmt.Sprint(values) != "[EMAIL LOW]" {
+		t.Errorf("values left = %v, want the used EMAIL and the lately deleted LOW", values)
+	}
+}
+
+func TestLockedDefaultRejectsEveryWritePath(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	ctx := context.Background()
+	category := createTestCategory(t, db, nil, "PRIORITY")
+	current := createTestValue(t, db, category, "HIGH", 0, true)
+	other := createTestValue(t, db, category, "LOW", 1, false)
+	if err := db.Model(category).Update("lock_default", true).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	newDefault := func(code string) models.LookupValue {
+		return models.LookupValue{CategoryID: category.ID, Code: code, Name: code, IsDefault: true, IsActive: true}
+	}
+	for _, tc := range []struct {
+		name  string
+		write func() error
+	}{
+		{"create", func() error {
+			v := newDefault("URGENT")
+			return repo.CreateValue(ctx, &v)
+		}},
+		{"create at sort order", func() error {
+			v := newDefault("URGENT")
+			return repo.CreateValueAtSortOrder(ctx, &v)
+		}},
+		{"update", func() error {
+			v := *other
+			v.IsDefault = true
+			return repo.UpdateValue(ctx, &v)
+		}},
+		{"clear", func() error {
+			_, err := repo.ClearDefaultForCategory(ctx, category.ID)
+			return err
+		}},
+		{"swap", func() error { return repo.SwapDefault(ctx, category.ID, other.ID) }},
+		{"bulk create", func() error {
+			return repo.BulkSaveValues(ctx, category.ID, []models.LookupValue{newDefault("URGENT")}, nil)
+		}},
+	} {
+		if err := tc.write(); !errors.Is(err, ErrLookupDefaultLocked) {
+			t.Errorf("%s: err = %v, want ErrLookupDefaultLocked", tc.name, err)
+		}
+	}
+
+	var defaults []uuid.UUID
+	db.Model(&models.LookupValue{}).Where("is_default = ?", true).Pluck("id", &defaults)
+	if len(defaults) != 1 || defaults[0] != current.ID {
+		t.Errorf("defaults = %v, want only %s", defaults, current.ID)
+	}
+}
+
+func TestSystemCategoryValuesAreFixedOnEveryWritePath(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	ctx := context.Background()
+	system := createTestCategory(t, db, nil, "STATUS")
+	if err := db.Model(system).Update("is_system", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	open := createTestValue(t, db, system, "OPEN", 0, true)
+	closed := createTestValue(t, db, system, "CLOSED", 1, false)
+	custom := createTestCategory(t, db, nil, "CUSTOM")
+
+	for _, tc := range []struct {
+		name  string
+		write func() error
+	}{
+		{"delete", func() error { return repo.DeleteValue(ctx, closed.ID) }},
+		{"create a default", func() error {
+			return repo.CreateValue(ctx, &models.LookupValue{CategoryID: system.ID, Code: "NEW", Name: "New", IsDefault: true, IsActive: true})
+		}},
+		{"rename the code", func() error {
+			v := *closed
+			v.Code = "DONE"
+			return repo.UpdateValue(ctx, &v)
+		}},
+		{"swap the default", func() error { return repo.SwapDefault(ctx, system.ID, closed.ID) }},
+		{"move out", func() error {
+			_, err := repo.MoveValues(ctx, system.ID, custom.ID, []uuid.UUID{closed.ID})
+			return err
+		}},
+	} {
+		if err := tc.write(); !errors.Is(err, ErrLookupSystemValueFixed) {
+			t.Errorf("%s: err = %v, want ErrLookupSystemValueFixed", tc.name, err)
+		}
+	}
+
+	results, err := repo.SetDefaultValues(ctx, []models.LookupDefaultAssignment{{CategoryCode: "STATUS", ValueCode: "CLOSED"}})
+	if !errors.Is(err, ErrLookupDefaultsRejected) || results[0].Error == "" {
+		t.Errorf("SetDefaultValues: err = %v, result %+v, want the assignment rejected", err, results[0])
+	}
+
+	var values []models.LookupValue
+	db.Where("category_id = ?", system.ID).Order("sort_order").Find(&values)
+	if len(values) != 2 || values[0].ID != open.ID || !values[0].IsDefault || values[1].Code != "CLOSED" || values[1].IsDefault {
+		t.Errorf("system values changed: %+v", values)
+	}
+}
+
+func TestImportLookupsReplacesTheExistingDefault(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	ctx := context.Background()
+	category := createTestCategory(t, db, nil, "PRIORITY")
+	high := createTestValue(t, db, category, "HIGH", 0, true)
+
+	data := &models.LookupExport{Categories: []models.LookupCategoryExport{{
+		Code: "PRIORITY", Name: "PRIORITY", IsActive: true,
+		Values: []models.LookupValueExport{{Code: "LOW", Name: "Low", SortOrder: 1, IsDefault: true, IsActive: true}},
+	}}}
+	if _, err := repo.ImportLookups(ctx, data, models.LookupImportOptions{}); err != nil {
+		t.Fatalf("ImportLookups: %v", err)
+	}
+
+	var defaults []string
+	db.Model(&models.LookupValue{}).Where("category_id = ? AND is_default = ?", category.ID, true).Pluck("code", &defaults)
+	if len(defaults) != 1 || defaults[0] != "LOW" {
+		t.Fatalf("defaults = %v, want [LOW]", defaults)
+	}
+
+	// A locked default stays and the row is reported
+	if err := db.Model(category).Update("lock_default", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	data.Categories[0].Values = []models.LookupValueExport{{Code: "HIGH", Name: "HIGH", IsDefault: true, IsActive: true}}
+	result, err := repo.ImportLookups(ctx, data, models.LookupImportOptions{Validation: models.LookupImportLenient})
+	if err != nil {
+		t.Fatalf("lenient ImportLookups: %v", err)
+	}
+	if len(result.Errors) != 1 {
+		t.Errorf("row errors = %+v, want the HIGH row", result.Errors)
+	}
+	var stored models.LookupValue
+	db.First(&stored, "id = ?", high.ID)
+	if stored.IsDefault {
+		t.Error("import took over a locked default")
+	}
+}
+
+func TestConcurrentDefaultBatchesLeaveOneDefaultPerCategory(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	ctx := context.Background()
+	priority := createTestCategory(t, db, nil, "PRIORITY")
+	severity := createTestCategory(t, db, nil, "SEVERITY")
+	for _, category := range []*models.LookupCategory{priority, severity} {
+		createTestValue(t, db, category, "A", 0, true)
+		createTestValue(t, db, category, "B", 1, false)
+	}
+
+	forward := []models.LookupDefaultAssignment{{CategoryCode: "PRIORITY", ValueCode: "B"}, {CategoryCode: "SEVERITY", ValueCode: "B"}}
+	backward := []models.LookupDefaultAssignment{{CategoryCode: "SEVERITY", ValueCode: "A"}, {CategoryCode: "PRIORITY", ValueCode: "A"}}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 10; i++ {
+		for _, batch := range [][]models.LookupDefaultAssignment{forward, backward} {
+			wg.Add(1)
+			go func(batch []models.LookupDefaultAssignment) {
+				defer wg.Done()
+				_, err := repo.SetDefaultValues(ctx, batch)
+				errs <- err
+			}(batch)
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("SetDefaultValues: %v", err)
+		}
+	}
+
+	for _, category := range []*models.LookupCategory{priority, severity} {
+		var defaults int64
+		db.Model(&models.LookupValue{}).Where("category_id = ? AND is_default = ?", category.ID, true).Count(&defaults)
+		if defaults != 1 {
+			t.Errorf("%s has %d defaults, want 1", category.Code, defaults)
+		}
+	}
+}
+
+func TestPickWeightedCoversEachWeightRange(t *testing.T) {
+	candidates := []models.LookupValue{
+		{Code: "A", DefaultWeight: 1},
+		{Code: "B", DefaultWeight: 3},
+		{Code: "C", DefaultWeight: 6},
+	}
+
+	counts := make(map[string]int)
+	for n := 0; n < 10; n++ {
+		draw := func(total int) int {
+			if total != 10 {
+				t.Fatalf("draw over %d, want the total weight 10", total)
+			}
+			return n
+		}
+		counts[pickWeighted(candidates, draw).Code]++
+	}
+	if counts["A"] != 1 || counts["B"] != 3 || counts["C"] != 6 {
+		t.Errorf("picks over every draw = %v, want A:1 B:3 C:6", counts)
+	}
+}
+
+// setWeight gives value a default weight and active flag, bypassing hooks
+func setWeight(t *testing.T, db *gorm.DB, value *models.LookupValue, weight int, active bool) {
+	t.Helper()
+	err := db.Model(&models.LookupValue{}).Where("id = ?", value.ID).
+		UpdateColumns(map[string]interface{}{"default_weight": weight, "is_active": active}).Error
+	if err != nil {
+		t.Fatalf("set weight of %s: %v", value.Code, err)
+	}
+}
+
+// withDraws makes GetWeightedDefault draw 0, 1, 2, ... for the test's duration
+func withDraws(t *testing.T) {
+	t.Helper()
+	next := 0
+	drawWeighted = func(n int) int {
+		d := next % n
+		next++
+		return d
+	}
+	t.Cleanup(func() { drawWeighted = rand.IntN })
+}
+
+func TestGetWeightedDefaultDrawsByWeight(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	ctx := context.Background()
+	category := createTestCategory(t, db, nil, "PRIORITY")
+	low := createTestValue(t, db, category, "LOW", 0, true)
+	medium := createTestValue(t, db, category, "MEDIUM", 1, false)
+	high := createTestValue(t, db, category, "HIGH", 2, false)
+	setWeight(t, db, low, 1, true)
+	setWeight(t, db, medium, 0, true)
+	setWeight(t, db, high, 3, true)
+	withDraws(t)
+
+	counts := make(map[string]int)
+	for i := 0; i < 8; i++ {
+		value, weighted, err := repo.GetWeightedDefault(ctx, "PRIORITY")
+		if err != nil {
+			t.Fatalf("GetWeightedDefault: %v", err)
+		}
+		if !weighted {
+			t.Fatal("GetWeightedDefault fell back with weighted values present")
+		}
+		counts[value.Code]++
+	}
+	if counts["LOW"] != 2 || counts["HIGH"] != 6 || counts["MEDIUM"] != 0 {
+		t.Errorf("picks over 8 draws = %v, want LOW:2 HIGH:6 and never the zero-weight MEDIUM", counts)
+	}
+}
+
+func TestGetWeightedDefaultFallsBackWithoutActiveWeightedValues(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	ctx := context.Background()
+	category := createTestCategory(t, db, nil, "PRIORITY")
+	low := createTestValue(t, db, category, "LOW", 0, true)
+	high := createTestValue(t, db, category, "HIGH", 1, false)
+	withDraws(t)
+
+	// All weights zero: the flagged default
+	value, weighted, err := repo.GetWeightedDefault(ctx, "PRIORITY")
+	if err != nil || weighted || value.Code != "LOW" {
+		t.Fatalf("zero weights: value = %v, weighted = %v, err = %v; want LOW by flag", value, weighted, err)
+	}
+
+	// The only weighted value is inactive: still the flagged default
+	setWeight(t, db, high, 5, false)
+	value, weighted, err = repo.GetWeightedDefault(ctx, "PRIORITY")
+	if err != nil || weighted || value.Code != "LOW" {
+		t.Fatalf("inactive weighted value: value = %v, weighted = %v, err = %v; want LOW by flag", value, weighted, err)
+	}
+
+	// Every value inactive: nothing to offer
+	setWeight(t, db, low, 2, false)
+	if _, _, err := repo.GetWeightedDefault(ctx, "PRIORITY"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("all inactive: err = %v, want ErrRecordNotFound", err)
+	}
+}
+
+func TestValueStatusIsValidatedAndKeptInSync(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	ctx := context.Background()
+	category := createTestCategory(t, db, nil, "PRIORITY")
+	value := createTestValue(t, db, category, "HIGH", 0, false)
+
+	bogus := &models.LookupValue{CategoryID: category.ID, Code: "LOW", Name: "Low", IsActive: true, Status: "bogus"}
+	if err := db.Omit("Category").Create(bogus).Error; !errors.Is(err, models.ErrInvalidLookupValueStatus) {
+		t.Fatalf("create with status bogus err = %v, want ErrInvalidLookupValueStatus", err)
+	}
+	value.Status = "retired"
+	if err := db.Omit("Category").Save(value).Error; !errors.Is(err, models.ErrInvalidLookupValueStatus) {
+		t.Fatalf("save with status retired err = %v, want ErrInvalidLookupValueStatus", err)
+	}
+
+	stored := func() models.LookupValue {
+		t.Helper()
+		var v models.LookupValue
+		if err := db.First(&v, "id = ?", value.ID).Error; err != nil {
+			t.Fatalf("read value: %v", err)
+		}
+		return v
+	}
+
+	if _, err := repo.ArchiveCategory(ctx, category.ID, true); err != nil {
+		t.Fatalf("ArchiveCategory: %v", err)
+	}
+	if v := stored(); v.Status != models.LookupValueStatusArchived || v.IsActive {
+		t.Errorf("after archiving status = %q, is_active = %v, want archived and false", v.Status, v.IsActive)
+	}
+
+	if _, err := repo.UnarchiveCategory(ctx, category.ID, true); err != nil {
+		t.Fatalf("UnarchiveCategory: %v", err)
+	}
+	if v := stored(); v.Status != models.LookupValueStatusActive || !v.IsActive {
+		t.Errorf("after unarchiving status = %q, is_active = %v, want active and true", v.Status, v.IsActive)
+	}
+}
+
+func TestDeleteCategorySoftDeletesItsValues(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	ctx := context.Background()
+	category := createTestCategory(t, db, nil, "PRIORITY")
+	createTestValue(t, db, category, "HIGH", 0, true)
+	createTestValue(t, db, category, "LOW", 1, false)
+
+	if err := repo.DeleteCategory(ctx, category.ID); err != nil {
+		t.Fatalf("DeleteCategory: %v", err)
+	}
+
+	categories, err := repo.ListCategories(ctx)
+	if err != nil {
+		t.Fatalf("ListCategories: %v", err)
+	}
+	if len(categories) != 0 {
+		t.Errorf("ListCategories after delete = %d categories, want none", len(categories))
+	}
+	values, err := repo.ListValuesByCategory(ctx, category.ID)
+	if err != nil {
+		t.Fatalf("ListValuesByCategory: %v", err)
+	}
+	if len(values) != 0 {
+		t.Errorf("ListValuesByCategory after delete = %d values, want none", len(values))
+	}
+
+	var kept models.LookupCategory
+	if err := db.Unscoped().First(&kept, "id = ?", category.ID).Error; err != nil {
+		t.Fatalf("unscoped category: %v", err)
+	}
+	if !kept.DeletedAt.Valid {
+		t.Error("deleted category has no deleted_at")
+	}
+	var deletedValues int64
+	if err := db.Unscoped().Model(&models.LookupValue{}).Where("category_id = ? AND deleted_at IS NOT NULL", category.ID).Count(&deletedValues).Error; err != nil {
+		t.Fatalf("count unscoped values: %v", err)
+	}
+	if deletedValues != 2 {
+		t.Errorf("%d soft-deleted values kept, want 2", deletedValues)
+	}
+}
+
+func TestRestoreValuesBringsBackASoftDeletedValue(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	ctx := context.Background()
+	category := createTestCategory(t, db, nil, "PRIORITY")
+	high := createTestValue(t, db, category, "HIGH", 0, true)
+	createTestValue(t, db, category, "LOW", 1, false)
+
+	if err := repo.DeleteValue(ctx, high.ID); err != nil {
+		t.Fatalf("DeleteValue: %v", err)
+	}
+	if _, err := repo.FindValueByID(ctx, high.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("FindValueByID after delete err = %v, want ErrRecordNotFound", err)
+	}
+	var deleted models.LookupValue
+	if err := db.Unscoped().First(&deleted, "id = ?", high.ID).Error; err != nil {
+		t.Fatalf("unscoped value: %v", err)
+	}
+	if !deleted.DeletedAt.Valid {
+		t.Fatal("deleted value has no deleted_at")
+	}
+
+	restored, skipped, err := repo.RestoreValues(ctx, category.ID, []uuid.UUID{high.ID})
+	if err != nil {
+		t.Fatalf("RestoreValues: %v", err)
+	}
+	if len(restored) != 1 || len(skipped) != 0 {
+		t.Fatalf("RestoreValues restored %d and skipped %v, want 1 restored", len(restored), skipped)
+	}
+
+	value, err := repo.FindValueByID(ctx, high.ID)
+	if err != nil {
+		t.Fatalf("FindValueByID after restore: %v", err)
+	}
+	if value.DeletedAt.Valid {
+		t.Error("restored value still has deleted_at")
+	}
+	if value.IsDefault {
+		t.Error("restored value took the default back")
+	}
+
+	_, skipped, err = repo.RestoreValues(ctx, category.ID, []uuid.UUID{high.ID})
+	if err != nil {
+		t.Fatalf("RestoreValues again: %v", err)
+	}
+	if len(skipped) != 1 || skipped[0].Reason != models.LookupRestoreNotDeleted {
+		t.Errorf("restoring a live value skipped %v, want it skipped as not deleted", skipped)
+	}
+}
+
+func TestRestoreValuesSkipsACollidingCodeAndRestoresTheRest(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	ctx := context.Background()
+	category := createTestCategory(t, db, nil, "PRIORITY")
+	high := createTestValue(t, db, category, "HIGH", 0, false)
+	low := createTestValue(t, db, category, "LOW", 1, false)
+	medium := createTestValue(t, db, category, "MEDIUM", 2, false)
+	for _, id := range []uuid.UUID{high.ID, low.ID, medium.ID} {
+		if err := repo.DeleteValue(ctx, id); err != nil {
+			t.Fatalf("DeleteValue: %v", err)
+		}
+	}
+	// A new LOW took the code while the old one was in the trash
+	createTestValue(t, db, category, "LOW", 3, false)
+
+	restored, skipped, err := repo.RestoreValues(ctx, category.ID, []uuid.UUID{high.ID, low.ID, medium.ID})
+	if err != nil {
+		t.Fatalf("RestoreValues: %v", err)
+	}
+	var codes []string
+	for _, v := range restored {
+		codes = append(codes, v.Code)
+	}
+	sort.Strings(codes)
+	if fmt.Sprint(codes) != "[HIGH MEDIUM]" {
+		t.Errorf("restored %v, want [HIGH MEDIUM]", codes)
+	}
+	if len(skipped) != 1 || skipped[0].ID != low.ID || skipped[0].Reason != models.LookupRestoreCodeTaken {
+		t.Errorf("skipped %v, want the old LOW skipped as code_taken", skipped)
+	}
+
+	var live int64
+	if err := db.Model(&models.LookupValue{}).Where("category_id = ?", category.ID).Count(&live).Error; err != nil {
+		t.Fatalf("count values: %v", err)
+	}
+	if live != 3 {
+		t.Errorf("%d live values after the restore, want 3", live)
+	}
+}
+
+func TestListChangesSinceBreaksTimestampTiesByID(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	ctx := context.Background()
+	category := createTestCategory(t, db, nil, "PRIORITY")
+
+	// Every row shares one timestamp, as rows written in one batch can
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	var ids []string
+	for i, code := range []string{"HIGH", "MEDIUM", "LOW"} {
+		value := createTestValue(t, db, category, code, i, false)
+		if err := db.Model(&models.LookupValue{}).Where("id = ?", value.ID).UpdateColumn("updated_at", at).Error; err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, value.ID.String())
+	}
+	if err := db.Model(&models.LookupCategory{}).Where("id = ?", category.ID).UpdateColumn("updated_at", at).Error; err != nil {
+		t.Fatal(err)
+	}
+	ids = append(ids, category.ID.String())
+	sort.Strings(ids)
+
+	valueIDs := func(changes *models.LookupChanges) []string {
+		got := make([]string, len(changes.Values))
+		for i, v := range changes.Values {
+			got[i] = v.ID.String()
+		}
+		return got
+	}
+
+	full, err := repo.ListChangesSince(ctx, models.LookupSyncCursor{})
+	if err != nil {
+		t.Fatalf("full sync: %v", err)
+	}
+	if len(full.Categories) != 1 || len(full.Values) != 3 {
+		t.Fatalf("full sync = %d categories and %d values, want 1 and 3", len(full.Categories), len(full.Values))
+	}
+	if got := valueIDs(full); !sort.StringsAreSorted(got) {
+		t.Errorf("full sync values %v are not in id order", got)
+	}
+	if !full.Next.UpdatedAt.Equal(at) || full.Next.ID.String() != ids[len(ids)-1] {
+		t.Errorf("full sync next = %v, want %v and the highest id %s", full.Next, at, ids[len(ids)-1])
+	}
+
+	// A cursor inside the tie gets only the rows after it
+	middle, err := models.ParseLookupSyncCursor(models.LookupSyncCursor{UpdatedAt: at, ID: uuid.MustParse(ids[1])}.String())
+	if err != nil {
+		t.Fatalf("ParseLookupSyncCursor: %v", err)
+	}
+	partial, err := repo.ListChangesSince(ctx, middle)
+	if err != nil {
+		t.Fatalf("sync from the middle of the tie: %v", err)
+	}
+	if got := len(partial.Categories) + len(partial.Values); got != 2 {
+		t.Errorf("sync from the middle of the tie = %d rows, want the 2 after %s", got, ids[1])
+	}
+
+	again, err := repo.ListChangesSince(ctx, full.Next)
+	if err != nil {
+		t.Fatalf("sync from next: %v", err)
+	}
+	if len(again.Categories)+len(again.Values) != 0 || again.Next != full.Next {
+		t.Errorf("sync from next = %d categories, %d values, next %v, want nothing new", len(again.Categories), len(again.Values), again.Next)
+	}
+
+	deleted := full.Values[0].ID
+	if err := repo.DeleteValue(ctx, deleted); err != nil {
+		t.Fatalf("DeleteValue: %v", err)
+	}
+	afterDelete, err := repo.ListChangesSince(ctx, full.Next)
+	if err != nil {
+		t.Fatalf("sync after delete: %v", err)
+	}
+	if len(afterDelete.DeletedValues) != 1 || afterDelete.DeletedValues[0] != deleted {
+		t.Errorf("sync after delete deleted values = %v, want [%s]", afterDelete.DeletedValues, deleted)
+	}
+	if !afterDelete.Next.UpdatedAt.After(at) {
+		t.Errorf("sync after delete next = %v, want past the delete", afterDelete.Next)
+	}
+}
+
+func TestValuesChangedAtCountsDeletionsAndPassedEffectiveDates(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	ctx := context.Background()
+	category := createTestCategory(t, db, nil, "PRIORITY")
+
+	changedAt, err := repo.ValuesChangedAt(ctx, category.ID)
+	if err != nil {
+		t.Fatalf("ValuesChangedAt without values: %v", err)
+	}
+	if !changedAt.IsZero() {
+		t.Errorf("ValuesChangedAt without values = %v, want the zero time", changedAt)
+	}
+
+	updated := time.Now().Add(-3 * time.Hour).UTC().Truncate(time.Second)
+	deleted := updated.Add(time.Hour)
+	windowClosed := deleted.Add(time.Hour)
+	future := time.Now().Add(24 * time.Hour)
+	high := createTestValue(t, db, category, "HIGH", 0, false)
+	low := createTestValue(t, db, category, "LOW", 1, false)
+	columns := []struct {
+		id      uuid.UUID
+		columns map[string]interface{}
+	}{
+		{high.ID, map[string]interface{}{"updated_at": updated, "effective_to": windowClosed}},
+		{low.ID, map[string]interface{}{"updated_at": updated, "deleted_at": deleted, "effective_from": future}},
+	}
+	for _, c := range columns {
+		if err := db.Unscoped().Model(&models.LookupValue{}).Where("id = ?", c.id).UpdateColumns(c.columns).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	changedAt, err = repo.ValuesChangedAt(ctx, category.ID)
+	if err != nil {
+		t.Fatalf("ValuesChangedAt: %v", err)
+	}
+	if !changedAt.Equal(windowClosed) {
+		t.Errorf("ValuesChangedAt = %v, want the passed effective_to %v", changedAt, windowClosed)
+	}
+
+	if err := db.Unscoped().Model(&models.LookupValue{}).Where("id = ?", high.ID).UpdateColumn("effective_to", nil).Error; err != nil {
+		t.Fatal(err)
+	}
+	changedAt, err = repo.ValuesChangedAt(ctx, category.ID)
+	if err != nil {
+		t.Fatalf("ValuesChangedAt: %v", err)
+	}
+	if !changedAt.Equal(deleted) {
+		t.Errorf("ValuesChangedAt = %v, want the deletion %v", changedAt, deleted)
+	}
+}
+
+func TestArchiveCategoryCascadesToValuesOnlyWhenAsked(t *testing.T) {
+	for _, cascade := range []bool{false, true} {
+		t.Run(fmt.Sprintf("cascade=%v", cascade), func(t *testing.T) {
+			db := newTestDB(t)
+			repo := NewLookupRepository(db)
+			ctx := context.Background()
+			category := createTestCategory(t, db, nil, "SEASON")
+			summer := createTestValue(t, db, category, "SUMMER", 0, false)
+			winter := createTestValue(t, db, category, "WINTER", 1, false)
+			// An admin turned WINTER off before the archive
+			if err := db.Model(winter).Updates(map[string]interface{}{"is_active": false, "status": models.LookupValueStatusArchived}).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			archived, err := repo.ArchiveCategory(ctx, category.ID, cascade)
+			if err != nil {
+				t.Fatalf("ArchiveCategory: %v", err)
+			}
+			var stored models.LookupValue
+			if err := db.First(&stored, "id = ?", summer.ID).Error; err != nil {
+				t.Fatalf("read value: %v", err)
+			}
+			if cascade {
+				if archived != 1 || stored.IsActive || stored.Status != models.LookupValueStatusArchived || stored.ArchivedAt == nil {
+					t.Errorf("cascaded archive: %d archived, SUMMER active=%v status=%q archived_at=%v, want 1 and SUMMER archived with a marker",
+						archived, stored.IsActive, stored.Status, stored.ArchivedAt)
+				}
+			} else if archived != 0 || !stored.IsActive || stored.Status != models.LookupValueStatusActive {
+				t.Errorf("plain archive: %d archived, SUMMER active=%v status=%q, want 0 and SUMMER untouched", archived, stored.IsActive, stored.Status)
+			}
+
+			restored, err := repo.UnarchiveCategory(ctx, category.ID, true)
+			if err != nil {
+				t.Fatalf("UnarchiveCategory: %v", err)
+			}
+			if want := archived; restored != want {
+				t.Errorf("unarchive restored %d values, want %d", restored, want)
+			}
+			var active []string
+			if err := db.Model(&models.LookupValue{}).Where("category_id = ? AND is_active = ?", category.ID, true).Order("code").Pluck("code", &active).Error; err != nil {
+				t.Fatalf("read active values: %v", err)
+			}
+			if fmt.Sprint(active) != "[SUMMER]" {
+				t.Errorf("active values after unarchive = %v, want [SUMMER] with WINTER left off", active)
+			}
+		})
+	}
+}
+
+func TestGetLookupStatsCountsTheTenantsLookups(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	orgA, orgB := uuid.New(), uuid.New()
+
+	priority := createTestCategory(t, db, nil, "PRIORITY")
+	if err := db.Model(priority).Update("is_system", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	createTestValue(t, db, priority, "HIGH", 0, true)
+	createTestValue(t, db, priority, "LOW", 1, false)
+	severity := createTestCategory(t, db, &orgA, "SEVERITY")
+	if err := db.Model(severity).Updates(map[string]interface{}{"is_active": false, "add_to_incident_form": true}).Error; err != nil {
+		t.Fatal(err)
+	}
+	createTestValue(t, db, severity, "MAJOR", 0, false)
+	deleted := createTestCategory(t, db, &orgA, "REGION")
+	createTestValue(t, db, deleted, "NORTH", 0, false)
+	if err := db.Delete(deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+	other := createTestCategory(t, db, &orgB, "CHANNEL")
+	createTestValue(t, db, other, "EMAIL", 0, false)
+
+	stats, err := repo.GetLookupStats(WithOrgID(context.Background(), &orgA))
+	if err != nil {
+		t.Fatalf("GetLookupStats: %v", err)
+	}
+	want := models.LookupStats{
+		TotalCategories:    2,
+		ActiveCategories:   1,
+		InactiveCategories: 1,
+		SystemCategories:   1,
+		CustomCategories:   1,
+		IncidentFormCount:  1,
+		TotalValues:        3,
+	}
+	if stats != want {
+		t.Errorf("stats = %+v, want %+v", stats, want)
+	}
+}
+
+func TestListRecentlyUpdatedMergesCategoriesAndValuesNewestFirst(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	touch := func(model interface{}, id uuid.UUID, hours int) {
+		t.Helper()
+		if err := db.Model(model).Where("id = ?", id).UpdateColumn("updated_at", base.Add(time.Duration(hours)*time.Hour)).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	priority := createTestCategory(t, db, nil, "PRIORITY")
+	touch(&models.LookupCategory{}, priority.ID, 1)
+	high := createTestValue(t, db, priority, "HIGH", 0, false)
+	touch(&models.LookupValue{}, high.ID, 4)
+	low := createTestValue(t, db, priority, "LOW", 1, false)
+	touch(&models.LookupValue{}, low.ID, 2)
+	severity := createTestCategory(t, db, nil, "SEVERITY")
+	touch(&models.LookupCategory{}, severity.ID, 3)
+
+	changes, err := repo.ListRecentlyUpdated(context.Background(), 3)
+	if err != nil {
+		t.Fatalf("ListRecentlyUpdated: %v", err)
+	}
+	var got []string
+	for _, change := range changes {
+		got = append(got, change.Type+":"+change.Code)
+	}
+	if want := "[value:HIGH category:SEVERITY value:LOW]"; fmt.Sprint(got) != want {
+		t.Errorf("changes = %v, want %s", got, want)
+	}
+}
+
+func TestInactiveCategoriesAreNeverOnTheIncidentForm(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	ctx := context.Background()
+
+	created := &models.LookupCategory{Code: "SEVERITY", Name: "Severity", IsActive: false, AddToIncidentForm: true}
+	if err := repo.CreateCategory(ctx, created); err != nil {
+		t.Fatalf("CreateCategory: %v", err)
+	}
+	updated := createTestCategory(t, db, nil, "PRIORITY")
+	updated.IsActive = false
+	updated.AddToIncidentForm = true
+	if err := repo.UpdateCategory(ctx, updated); err != nil {
+		t.Fatalf("UpdateCategory: %v", err)
+	}
+
+	for _, id := range []uuid.UUID{created.ID, updated.ID} {
+		var stored models.LookupCategory
+		if err := db.First(&stored, "id = ?", id).Error; err != nil {
+			t.Fatal(err)
+		}
+		if stored.AddToIncidentForm {
+			t.Errorf("inactive category %s is on the incident form", stored.Code)
+		}
+	}
+}
+
+func TestClearDefaultForCategoryTouchesOnlyTheLiveDefault(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	category := createTestCategory(t, db, nil, "PRIORITY")
+	high := createTestValue(t, db, category, "HIGH", 0, true)
+	low := createTestValue(t, db, category, "LOW", 1, false)
+	deleted := createTestValue(t, db, category, "OLD", 2, false)
+	if err := db.Model(deleted).UpdateColumn("is_default", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Delete(deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+	past := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := db.Unscoped().Model(&models.LookupValue{}).Where("category_id = ?", category.ID).UpdateColumn("updated_at", past).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	cleared, err := repo.ClearDefaultForCategory(context.Background(), category.ID)
+	if err != nil {
+		t.Fatalf("ClearDefaultForCategory: %v", err)
+	}
+	if cleared != 1 {
+		t.Errorf("cleared = %d, want 1", cleared)
+	}
+
+	var values []models.LookupValue
+	if err := db.Unscoped().Where("category_id = ?", category.ID).Find(&values).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range values {
+		switch v.ID {
+		case high.ID:
+			if v.IsDefault {
+				t.Error("HIGH is still the default")
+			}
+		case low.ID:
+			if !v.UpdatedAt.Equal(past) {
+				t.Errorf("LOW, never the default, was updated at %v", v.UpdatedAt)
+			}
+		case deleted.ID:
+			if !v.IsDefault || !v.UpdatedAt.Equal(past) {
+				t.Errorf("deleted OLD default=%v updated_at=%v, want it untouched", v.IsDefault, v.UpdatedAt)
+			}
+		}
+	}
+}
+
+func TestClearDefaultForCategoryCountsOnlyAnExistingDefault(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	ctx := context.Background()
+	withDefault := createTestCategory(t, db, nil, "PRIORITY")
+	inactive := createTestValue(t, db, withDefault, "HIGH", 0, true)
+	createTestValue(t, db, withDefault, "LOW", 1, false)
+	if err := db.Model(inactive).Update("is_active", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	withoutDefault := createTestCategory(t, db, nil, "SEVERITY")
+	createTestValue(t, db, withoutDefault, "MAJOR", 0, false)
+
+	for _, tc := range []struct {
+		name     string
+		category *models.LookupCategory
+		want     int64
+	}{
+		{"inactive default", withDefault, 1},
+		{"cleared again", withDefault, 0},
+		{"no default", withoutDefault, 0},
+	} {
+		cleared, err := repo.ClearDefaultForCategory(ctx, tc.category.ID)
+		if err != nil {
+			t.Fatalf("%s: ClearDefaultForCategory: %v", tc.name, err)
+		}
+		if cleared != tc.want {
+			t.Errorf("%s: cleared = %d, want %d", tc.name, cleared, tc.want)
+		}
+	}
+
+	var defaults int64
+	db.Model(&models.LookupValue{}).Where("is_default").Count(&defaults)
+	if defaults != 0 {
+		t.Errorf("%d defaults left, want none", defaults)
+	}
+}
+
+func TestSetDefaultValuesIsAllOrNothing(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	ctx := context.Background()
+	priority := createTestCategory(t, db, nil, "PRIORITY")
+	high := createTestValue(t, db, priority, "HIGH", 0, true)
+	low := createTestValue(t, db, priority, "LOW", 1, false)
+	severity := createTestCategory(t, db, nil, "SEVERITY")
+	minor := createTestValue(t, db, severity, "MINOR", 0, false)
+	major := createTestValue(t, db, severity, "MAJOR", 1, false)
+	if err := db.Model(major).Update("is_active", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	defaults := func() string {
+		t.Helper()
+		var codes []string
+		db.Model(&models.LookupValue{}).Where("is_default = ?", true).Order("code").Pluck("code", &codes)
+		return fmt.Sprint(codes)
+	}
+
+	results, err := repo.SetDefaultValues(ctx, []models.LookupDefaultAssignment{
+		{CategoryCode: "PRIORITY", ValueCode: "LOW"},
+		{CategoryCode: "SEVERITY", ValueCode: "MAJOR"},
+		{CategoryCode: "REGION", ValueCode: "NORTH"},
+	})
+	if !errors.Is(err, ErrLookupDefaultsRejected) {
+		t.Fatalf("invalid batch err = %v, want ErrLookupDefaultsRejected", err)
+	}
+	var problems []string
+	for _, r := range results {
+		problems = append(problems, r.Error)
+	}
+	if want := "[ value is not active category not found]"; fmt.Sprint(problems) != want {
+		t.Errorf("result errors = %q, want %s", problems, want)
+	}
+	if got := defaults(); got != "[HIGH]" {
+		t.Errorf("defaults after a rejected batch = %s, want [HIGH]", got)
+	}
+
+	results, err = repo.SetDefaultValues(ctx, []models.LookupDefaultAssignment{
+		{CategoryCode: "PRIORITY", ValueCode: "LOW"},
+		{CategoryCode: "SEVERITY", ValueCode: "MINOR"},
+	})
+	if err != nil {
+		t.Fatalf("SetDefaultValues: %v", err)
+	}
+	if got := defaults(); got != "[LOW MINOR]" {
+		t.Errorf("defaults = %s, want [LOW MINOR]", got)
+	}
+	if r := results[0]; r.ValueID == nil || *r.ValueID != low.ID || r.PreviousDefaultID == nil || *r.PreviousDefaultID != high.ID {
+		t.Errorf("PRIORITY result = %+v, want LOW replacing HIGH", r)
+	}
+	if r := results[1]; r.ValueID == nil || *r.ValueID != minor.ID || r.PreviousDefaultID != nil {
+		t.Errorf("SEVERITY result = %+v, want MINOR without a previous default", r)
+	}
+}
+
+func TestSetCategoriesActiveSkipsSystemAndMissingCategories(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	severity := createTestCategory(t, db, nil, "SEVERITY")
+	if err := db.Model(severity).Updates(map[string]interface{}{"name_ar": "الخطورة", "add_to_incident_form": true, "incident_form_intent": true}).Error; err != nil {
+		t.Fatal(err)
+	}
+	priority := createTestCategory(t, db, nil, "PRIORITY")
+	status := createTestCategory(t, db, nil, "STATUS")
+	if err := db.Model(status).Update("is_system", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	missing := uuid.New()
+
+	result, err := repo.SetCategoriesActive(context.Background(), []uuid.UUID{severity.ID, priority.ID, status.ID, missing}, false)
+	if err != nil {
+		t.Fatalf("SetCategoriesActive: %v", err)
+	}
+	if result.Updated != 2 || fmt.Sprint(result.Skipped) != fmt.Sprint([]uuid.UUID{status.ID}) || fmt.Sprint(result.NotFound) != fmt.Sprint([]uuid.UUID{missing}) {
+		t.Errorf("result = %+v, want 2 updated, STATUS skipped and the missing ID not found", result)
+	}
+
+	var categories []models.LookupCategory
+	if err := db.Order("code").Find(&categories).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range categories {
+		if wantActive := c.Code == "STATUS"; c.IsActive != wantActive {
+			t.Errorf("%s active = %v, want %v", c.Code, c.IsActive, wantActive)
+		}
+		if c.AddToIncidentForm {
+			t.Errorf("%s is still on the incident form", c.Code)
+		}
+	}
+
+	if _, err := repo.SetCategoriesActive(context.Background(), []uuid.UUID{severity.ID}, true); err != nil {
+		t.Fatalf("reactivate: %v", err)
+	}
+	var stored models.LookupCategory
+	if err := db.First(&stored, "id = ?", severity.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !stored.IsActive || !stored.AddToIncidentForm {
+		t.Errorf("reactivated SEVERITY active=%v incident form=%v, want both back", stored.IsActive, stored.AddToIncidentForm)
+	}
+}
+
+func TestSearchValuesMatchesDescriptionsOfActiveValues(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	ctx := context.Background()
+	priority := createTestCategory(t, db, nil, "PRIORITY")
+	high := createTestValue(t, db, priority, "HIGH", 0, false)
+	low := createTestValue(t, db, priority, "LOW", 1, false)
+	retired := createTestValue(t, db, priority, "URGENT", 2, false)
+	for _, v := range []*models.LookupValue{high, low, retired} {
+		if err := db.Model(v).Update("description", "Needs a Fast response").Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Model(retired).Update("is_active", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	removed := createTestCategory(t, db, nil, "REMOVED")
+	createTestValue(t, db, removed, "FAST", 0, false)
+	if err := db.Delete(removed).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	opts := utils.ListOptions{Page: 1, Limit: 10}
+	for name, search := range map[string]func(context.Context, string, utils.ListOptions) ([]models.LookupValue, int64, error){
+		"SearchValues":         repo.SearchValues,
+		"FullTextSearchValues": repo.FullTextSearchValues,
+	} {
+		values, total, err := search(ctx, "fast", opts)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		var codes []string
+		for _, v := range values {
+			codes = append(codes, v.Code)
+			if v.Category == nil || v.Category.Code != "PRIORITY" {
+				t.Errorf("%s: %s has category %v, want PRIORITY preloaded", name, v.Code, v.Category)
+			}
+		}
+		if fmt.Sprint(codes) != "[HIGH LOW]" || total != 2 {
+			t.Errorf("%s = %v (total %d), want [HIGH LOW]", name, codes, total)
+		}
+	}
+}
+
+func TestResetCategoryToSeedRestoresTheSeededValues(t *testing.T) {
+	seed := &models.LookupCategorySeed{Code: "PRIORITY", Name: "Priority", Values: []models.LookupValueSeed{
+		{Code: "HIGH", Name: "High", SortOrder: 0, IsDefault: true},
+		{Code: "MEDIUM", Name: "Medium", SortOrder: 1},
+		{Code: "LOW", Name: "Low", SortOrder: 2},
+	}}
+	for _, strict := range []bool{false, true} {
+		t.Run(fmt.Sprintf("strict=%v", strict), func(t *testing.T) {
+			db := newTestDB(t)
+			repo := NewLookupRepository(db)
+			category := createTestCategory(t, db, nil, "PRIORITY")
+			createTestValue(t, db, category, "HIGH", 5, false)
+			medium := createTestValue(t, db, category, "MEDIUM", 6, false)
+			createTestValue(t, db, category, "CUSTOM", 0, true)
+			if err := db.Model(medium).Update("is_active", false).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := repo.ResetCategoryToSeed(context.Background(), category, seed, strict)
+			if err != nil {
+				t.Fatalf("ResetCategoryToSeed: %v", err)
+			}
+			wantRemoved := "[]"
+			if strict {
+				wantRemoved = "[CUSTOM]"
+			}
+			if got := fmt.Sprint(result.Created, result.Restored, result.Removed); got != "[LOW] [HIGH MEDIUM] "+wantRemoved {
+				t.Errorf("created, restored, removed = %s, want [LOW] [HIGH MEDIUM] %s", got, wantRemoved)
+			}
+
+			var values []models.LookupValue
+			if err := db.Where("category_id = ?", category.ID).Order("sort_order, code").Find(&values).Error; err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, v := range values {
+				got = append(got, fmt.Sprintf("%s:%d:active=%v:default=%v", v.Code, v.SortOrder, v.IsActive, v.IsDefault))
+			}
+			want := []string{"HIGH:0:active=true:default=true", "MEDIUM:1:active=true:default=false", "LOW:2:active=true:default=false"}
+			if !strict {
+				want = append([]string{"CUSTOM:0:active=true:default=false"}, want...)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("values = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestListColorUsageGroupsColorsRegardlessOfCase(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	category := createTestCategory(t, db, nil, "PRIORITY")
+	for i, color := range []string{"#ff0000", " #FF0000 ", "#00FF00", "", "  "} {
+		v := createTestValue(t, db, category, fmt.Sprintf("V%d", i), i, false)
+		if err := db.Model(v).Update("color", color).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	deleted := createTestCategory(t, db, nil, "REMOVED")
+	v := createTestValue(t, db, deleted, "OLD", 0, false)
+	if err := db.Model(v).Update("color", "#00FF00").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Delete(deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	usage, err := repo.ListColorUsage(context.Background())
+	if err != nil {
+		t.Fatalf("ListColorUsage: %v", err)
+	}
+	if want := "[{#FF0000 2} {#00FF00 1}]"; fmt.Sprint(usage) != want {
+		t.Errorf("usage = %v, want %s", usage, want)
+	}
+}
+
+func TestCreateValueAtSortOrderShiftsTheTakenSlot(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	category := createTestCategory(t, db, nil, "PRIORITY")
+	for i, code := range []string{"HIGH", "MEDIUM", "LOW"} {
+		createTestValue(t, db, category, code, i, false)
+	}
+
+	urgent := &models.LookupValue{CategoryID: category.ID, Code: "URGENT", Name: "Urgent", SortOrder: 1, IsActive: true, Status: models.LookupValueStatusActive}
+	if err := repo.CreateValueAtSortOrder(context.Background(), urgent); err != nil {
+		t.Fatalf("CreateValueAtSortOrder: %v", err)
+	}
+	var values []models.LookupValue
+	if err := db.Order("sort_order").Find(&values, "category_id = ?", category.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, v := range values {
+		got = append(got, fmt.Sprintf("%s:%d", v.Code, v.SortOrder))
+	}
+	if want := "[HIGH:0 URGENT:1 MEDIUM:2 LOW:3]"; fmt.Sprint(got) != want {
+		t.Errorf("orders = %v, want %s", got, want)
+	}
+
+	createTestValue(t, db, category, "LAST", models.MaxLookupSortOrder, false)
+	blocked := &models.LookupValue{CategoryID: category.ID, Code: "BLOCKED", Name: "Blocked", SortOrder: 0, IsActive: true, Status: models.LookupValueStatusActive}
+	if err := repo.CreateValueAtSortOrder(context.Background(), blocked); !errors.Is(err, ErrLookupSortOrderExhausted) {
+		t.Errorf("shift past the maximum = %v, want ErrLookupSortOrderExhausted", err)
+	}
+}
+
+func TestSuggestCategoryCodeReusesTheFirstGap(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	ctx := context.Background()
+	for _, code := range []string{"PRIORITY", "PRIORITY_3", "PRIORITY_X", "PRIORITYLEVEL"} {
+		createTestCategory(t, db, nil, code)
+	}
+	deleted := createTestCategory(t, db, nil, "PRIORITY_4")
+	if err := db.Delete(deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct{ base, want string }{
+		{"SEVERITY", "SEVERITY"},
+		{"PRIORITY", "PRIORITY_2"},
+		{"PRIORITY_3", "PRIORITY_3_2"},
+	} {
+		got, err := repo.SuggestCategoryCode(ctx, tc.base)
+		if err != nil {
+			t.Fatalf("SuggestCategoryCode(%s): %v", tc.base, err)
+		}
+		if got != tc.want {
+			t.Errorf("SuggestCategoryCode(%s) = %s, want %s", tc.base, got, tc.want)
+		}
+	}
+
+	createTestCategory(t, db, nil, "PRIORITY_2")
+	if got, _ := repo.SuggestCategoryCode(ctx, "PRIORITY"); got != "PRIORITY_5" {
+		t.Errorf("with 2-4 taken, suggestion = %s, want PRIORITY_5 past the deleted PRIORITY_4", got)
+	}
+
+	codes, err := repo.ListCategoryCodesByPrefix(ctx, "PRIORITY_", 10)
+	if err != nil {
+		t.Fatalf("ListCategoryCodesByPrefix: %v", err)
+	}
+	if want := "[PRIORITY_2 PRIORITY_3 PRIORITY_X]"; fmt.Sprint(codes) != want {
+		t.Errorf("codes with prefix PRIORITY_ = %v, want %s", codes, want)
+	}
+}
+
+func TestNormalizeSortOrderRenumbersActiveValuesInOrder(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	category := createTestCategory(t, db, nil, "PRIORITY")
+	createTestValue(t, db, category, "LOW", 90, false)
+	createTestValue(t, db, category, "MEDIUM", 5, false)
+	createTestValue(t, db, category, "CRITICAL", 0, false)
+	createTestValue(t, db, category, "HIGH", 0, false)
+	retired := createTestValue(t, db, category, "RETIRED", 1, false)
+	if err := db.Model(retired).Update("is_active", false).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := repo.NormalizeSortOrder(context.Background(), category.ID)
+	if err != nil {
+		t.Fatalf("NormalizeSortOrder: %v", err)
+	}
+	var got []string
+	for _, v := range values {
+		got = append(got, fmt.Sprintf("%s:%d", v.Code, v.SortOrder))
+	}
+	if want := "[CRITICAL:0 HIGH:1 MEDIUM:2 LOW:3]"; fmt.Sprint(got) != want {
+		t.Errorf("normalized = %v, want %s", got, want)
+	}
+
+	var stored models.LookupValue
+	if err := db.First(&stored, "code = ?", "LOW").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.First(retired, "id = ?", retired.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.SortOrder != 3 || retired.SortOrder != 1 {
+		t.Errorf("stored LOW=%d RETIRED=%d, want 3 and the inactive value left at 1", stored.SortOrder, retired.SortOrder)
+	}
+}
+
+func TestFindValueAncestorsWalksToTheRoot(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	ctx := context.Background()
+	category := createTestCategory(t, db, nil, "LOCATION")
+	country := createTestValue(t, db, category, "UAE", 0, false)
+	city := createTestValue(t, db, category, "DUBAI", 1, false)
+	district := createTestValue(t, db, category, "MARINA", 2, false)
+	setParent := func(child, parent *models.LookupValue) {
+		t.Helper()
+		if err := db.Model(child).Update("parent_id", parent.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	setParent(city, country)
+	setParent(district, city)
+
+	codes := func(id uuid.UUID) string {
+		t.Helper()
+		chain, err := repo.FindValueAncestors(ctx, id)
+		if err != nil {
+			t.Fatalf("FindValueAncestors: %v", err)
+		}
+		var got []string
+		for _, v := range chain {
+			got = append(got, v.Code)
+		}
+		return fmt.Sprint(got)
+	}
+	if got := codes(district.ID); got != "[UAE DUBAI MARINA]" {
+		t.Errorf("ancestors of MARINA = %s, want [UAE DUBAI MARINA]", got)
+	}
+	if got := codes(country.ID); got != "[UAE]" {
+		t.Errorf("ancestors of a root = %s, want just [UAE]", got)
+	}
+
+	// A cycle left by a direct database edit ends the walk instead of looping
+	setParent(country, district)
+	if got := codes(district.ID); got != "[UAE DUBAI MARINA]" {
+		t.Errorf("ancestors in a cycle = %s, want each value once", got)
+	}
+	if _, err := repo.FindValueAncestors(ctx, uuid.New()); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("ancestors of an unknown value = %v, want ErrRecordNotFound", err)
+	}
+}
+
+func TestResolveValueCodesKeepsTheInputOrder(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	orgID := uuid.New()
+	tenant := WithOrgID(context.Background(), &orgID)
+
+	priority := createTestCategory(t, db, nil, "PRIORITY")
+	high := createTestValue(t, db, priority, "HIGH", 0, false)
+	low := createTestValue(t, db, priority, "LOW", 1, false)
+	if err := db.Model(low).Update("is_active", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.LookupValueAlias{ValueID: high.ID, AliasCode: "URGENT"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	retired := createTestCategory(t, db, nil, "RETIRED")
+	createTestValue(t, db, retired, "OLD", 0, false)
+	if err := db.Model(retired).Update("is_active", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	// The tenant's own SEVERITY shadows the global one
+	createTestValue(t, db, createTestCategory(t, db, nil, "SEVERITY"), "MAJOR", 0, false)
+	ownMajor := createTestValue(t, db, createTestCategory(t, db, &orgID, "SEVERITY"), "MAJOR", 0, false)
+
+	results, err := repo.ResolveValueCodes(tenant, []models.LookupCodePair{
+		{CategoryCode: "severity", ValueCode: "major"},
+		{CategoryCode: "PRIORITY", ValueCode: "URGENT"},
+		{CategoryCode: "PRIORITY", ValueCode: "LOW"},
+		{CategoryCode: "PRIORITY", ValueCode: "NONE"},
+		{CategoryCode: "RETIRED", ValueCode: "OLD"},
+		{CategoryCode: "MISSING", ValueCode: "HIGH"},
+		{CategoryCode: "PRIORITY", ValueCode: "high"},
+	})
+	if err != nil {
+		t.Fatalf("ResolveValueCodes: %v", err)
+	}
+	want := []struct {
+		id       *uuid.UUID
+		viaAlias bool
+		reason   string
+	}{
+		{&ownMajor.ID, false, ""},
+		{&high.ID, true, ""},
+		{nil, false, models.LookupResolveValueInactive},
+		{nil, false, models.LookupResolveValueNotFound},
+		{nil, false, models.LookupResolveCategoryInactive},
+		{nil, false, models.LookupResolveCategoryNotFound},
+		{&high.ID, false, ""},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d", len(results), len(want))
+	}
+	for i, w := range want {
+		r := results[i]
+		if (r.ValueID == nil) != (w.id == nil) || (w.id != nil && *r.ValueID != *w.id) || r.ViaAlias != w.viaAlias || r.Reason != w.reason {
+			t.Errorf("result %d (%s/%s) = %v alias=%v reason=%q, want %v alias=%v reason=%q",
+				i, r.CategoryCode, r.ValueCode, r.ValueID, r.ViaAlias, r.Reason, w.id, w.viaAlias, w.reason)
+		}
+	}
+}
+
+func TestSearchValuesPagesThroughTiesWithoutOverlap(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	category := createTestCategory(t, db, nil, "ZONE")
+	var want []string
+	for i := 0; i < 7; i++ {
+		v := createTestValue(t, db, category, fmt.Sprintf("Z%d", i), i, false)
+		if err := db.Model(v).Update("name", "Zone").Error; err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, v.ID.String())
+	}
+	createTestValue(t, db, category, "OTHER", 7, false)
+	sort.Strings(want)
+
+	var got []string
+	for page := 1; page <= 3; page++ {
+		values, total, err := repo.SearchValues(context.Background(), "zone", utils.ListOptions{Page: page, Limit: 3})
+		if err != nil {
+			t.Fatalf("SearchValues page %d: %v", page, err)
+		}
+		if total != 7 {
+			t.Errorf("page %d total = %d, want the 7 matches", page, total)
+		}
+		for _, v := range values {
+			got = append(got, v.ID.String())
+		}
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("paged IDs = %v, want each tied match once in id order %v", got, want)
+	}
+}
+
+func TestOrphanValuesAreListedAndCleanedUp(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	ctx := context.Background()
+	live := createTestCategory(t, db, nil, "LIVE")
+	createTestValue(t, db, live, "KEPT", 0, false)
+	target := createTestCategory(t, db, nil, "TARGET")
+	createTestValue(t, db, target, "DUP", 0, false)
+
+	softDeleted := createTestCategory(t, db, nil, "SOFT")
+	first := createTestValue(t, db, softDeleted, "FIRST", 0, true)
+	dup := createTestValue(t, db, softDeleted, "DUP", 1, false)
+	if err := db.Delete(softDeleted).Error; err != nil {
+		t.Fatal(err)
+	}
+	hardDeleted := createTestCategory(t, db, nil, "HARD")
+	second := createTestValue(t, db, hardDeleted, "SECOND", 0, false)
+	if err := db.Exec("DELETE FROM lookup_categories WHERE id = ?", hardDeleted.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	orphans, err := repo.ListOrphanValues(ctx)
+	if err != nil {
+		t.Fatalf("ListOrphanValues: %v", err)
+	}
+	var codes []string
+	for _, v := range orphans {
+		codes = append(codes, v.Code)
+	}
+	sort.Strings(codes)
+	if fmt.Sprint(codes) != "[DUP FIRST SECOND]" {
+		t.Fatalf("orphans = %v, want [DUP FIRST SECOND]", codes)
+	}
+
+	result, err := repo.CleanupOrphanValues(ctx, nil, &target.ID)
+	if err != nil {
+		t.Fatalf("reassign orphans: %v", err)
+	}
+	if result.Action != models.LookupOrphanReassign || result.Affected != 2 || fmt.Sprint(result.Skipped) != fmt.Sprint([]uuid.UUID{dup.ID}) {
+		t.Errorf("reassign = %+v, want 2 moved and DUP skipped", result)
+	}
+	for _, v := range []*models.LookupValue{first, second} {
+		var stored models.LookupValue
+		if err := db.First(&stored, "id = ?", v.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if stored.CategoryID != target.ID || stored.IsDefault {
+			t.Errorf("%s after reassign: category %s default=%v, want TARGET and not the default", v.Code, stored.CategoryID, stored.IsDefault)
+		}
+	}
+
+	// Only orphans are touched, even when other IDs are named
+	result, err = repo.CleanupOrphanValues(ctx, []uuid.UUID{dup.ID, first.ID}, nil)
+	if err != nil {
+		t.Fatalf("delete orphans: %v", err)
+	}
+	if result.Action != models.LookupOrphanDelete || result.Affected != 1 {
+		t.Errorf("delete = %+v, want only DUP deleted", result)
+	}
+	if orphans, _ := repo.ListOrphanValues(ctx); len(orphans) != 0 {
+		t.Errorf("orphans left = %d, want none", len(orphans))
+	}
+	var kept int64
+	if err := db.Model(&models.LookupValue{}).Where("id = ?", first.ID).Count(&kept).Error; err != nil || kept != 1 {
+		t.Errorf("FIRST after the delete: %d found, %v, want it kept in TARGET", kept, err)
+	}
+}
+
+func TestTouchCategoryOnlyMovesUpdatedAt(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	category := createTestCategory(t, db, nil, "PRIORITY")
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := db.Model(category).UpdateColumns(map[string]interface{}{"updated_at": past, "is_active": false, "add_to_incident_form": true}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	touchedAt, err := repo.TouchCategory(context.Background(), category.ID)
+	if err != nil {
+		t.Fatalf("TouchCategory: %v", err)
+	}
+	var stored models.LookupCategory
+	if err := db.First(&stored, "id = ?", category.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !stored.UpdatedAt.After(past) || !stored.UpdatedAt.Equal(touchedAt) {
+		t.Errorf("updated_at = %v, want the returned %v after %v", stored.UpdatedAt, touchedAt, past)
+	}
+	if stored.IsActive || !stored.AddToIncidentForm || stored.Name != category.Name {
+		t.Errorf("touch changed the category to %+v, want only updated_at moved", stored)
+	}
+
+	orgID := uuid.New()
+	if _, err := repo.TouchCategory(context.Background(), uuid.New()); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("touch of an unknown category = %v, want ErrRecordNotFound", err)
+	}
+	if _, err := repo.TouchCategory(WithOrgID(context.Background(), &orgID), category.ID); !errors.Is(err, ErrLookupNotWritable) {
+		t.Errorf("tenant touch of a global category = %v, want ErrLookupNotWritable", err)
+	}
+}
+
+func TestValuesFollowTheCategorySortDirection(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	ctx := context.Background()
+	asc := createTestCategory(t, db, nil, "PRIORITY")
+	desc := createTestCategory(t, db, nil, "SEVERITY")
+	if err := db.Model(desc).Update("default_sort_desc", true).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, category := range []*models.LookupCategory{asc, desc} {
+		createTestValue(t, db, category, "LOW", 0, false)
+		createTestValue(t, db, category, "HIGH", 2, false)
+		createTestValue(t, db, category, "B_MEDIUM", 1, false)
+		createTestValue(t, db, category, "A_MEDIUM", 1, false)
+	}
+
+	codes := func(values []models.LookupValue) string {
+		var got []string
+		for _, v := range values {
+			got = append(got, v.Code)
+		}
+		return fmt.Sprint(got)
+	}
+	for _, tc := range []struct {
+		category *models.LookupCategory
+		want     string
+	}{
+		{asc, "[LOW A_MEDIUM B_MEDIUM HIGH]"},
+		{desc, "[HIGH A_MEDIUM B_MEDIUM LOW]"},
+	} {
+		byID, err := repo.ListValuesByCategory(ctx, tc.category.ID)
+		if err != nil {
+			t.Fatalf("ListValuesByCategory: %v", err)
+		}
+		byCode, err := repo.ListValuesByCategoryCode(ctx, tc.category.Code, models.LookupValueFilter{})
+		if err != nil {
+			t.Fatalf("ListValuesByCategoryCode: %v", err)
+		}
+		preloaded, err := repo.FindCategoryByID(ctx, tc.category.ID)
+		if err != nil {
+			t.Fatalf("FindCategoryByID: %v", err)
+		}
+		for name, values := range map[string][]models.LookupValue{
+			"ListValuesByCategory":     byID,
+			"ListValuesByCategoryCode": byCode,
+			"FindCategoryByID":         preloaded.Values,
+		} {
+			if got := codes(values); got != tc.want {
+				t.Errorf("%s(%s) = %s, want %s", name, tc.category.Code, got, tc.want)
+			}
+		}
+	}
+}
+
+func TestListInactiveDefaultsReportsCategoriesLeftWithoutADefault(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	deactivate := func(v interface{}) {
+		t.Helper()
+		if err := db.Model(v).Update("is_active", false).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	priority := createTestCategory(t, db, nil, "PRIORITY")
+	stale := createTestValue(t, db, priority, "HIGH", 0, true)
+	deactivate(stale)
+	createTestValue(t, db, priority, "LOW", 1, false)
+
+	// A live default next to the inactive one means nothing is broken
+	severity := createTestCategory(t, db, nil, "SEVERITY")
+	deactivate(createTestValue(t, db, severity, "MAJOR", 0, true))
+	createTestValue(t, db, severity, "MINOR", 1, true)
+
+	retired := createTestCategory(t, db, nil, "RETIRED")
+	deactivate(createTestValue(t, db, retired, "OLD", 0, true))
+	deactivate(retired)
+
+	removed := createTestCategory(t, db, nil, "REMOVED")
+	gone := createTestValue(t, db, removed, "GONE", 0, true)
+	deactivate(gone)
+	if err := db.Delete(gone).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	defaults, err := repo.ListInactiveDefaults(context.Background())
+	if err != nil {
+		t.Fatalf("ListInactiveDefaults: %v", err)
+	}
+	if len(defaults) != 1 || defaults[0].CategoryID != priority.ID || defaults[0].ValueID != stale.ID || defaults[0].ValueCode != "HIGH" {
+		t.Errorf("inactive defaults = %+v, want only PRIORITY's HIGH", defaults)
+	}
+}
+
+func TestSwapDefaultTouchesOnlyTheOldAndNewDefault(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	ctx := context.Background()
+	category := createTestCategory(t, db, nil, "PRIORITY")
+	createTestValue(t, db, category, "HIGH", 0, true)
+	next := createTestValue(t, db, category, "MEDIUM", 1, false)
+	createTestValue(t, db, category, "LOW", 2, false)
+	inactive := createTestValue(t, db, category, "NONE", 3, false)
+	other := createTestValue(t, db, createTestCategory(t, db, nil, "SEVERITY"), "MAJOR", 0, false)
+	if err := db.Model(inactive).Update("is_active", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := db.Model(&models.LookupValue{}).Where("1 = 1").UpdateColumn("updated_at", past).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		id   uuid.UUID
+		want error
+	}{
+		{"unknown value", uuid.New(), ErrLookupValueNotFound},
+		{"value of another category", other.ID, ErrLookupValueWrongCategory},
+		{"inactive value", inactive.ID, ErrLookupValueInactive},
+	} {
+		if err := repo.SwapDefault(ctx, category.ID, tc.id); !errors.Is(err, tc.want) {
+			t.Errorf("swap to the %s: err = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	if err := repo.SwapDefault(ctx, category.ID, next.ID); err != nil {
+		t.Fatalf("SwapDefault: %v", err)
+	}
+	var values []models.LookupValue
+	if err := db.Order("code").Find(&values).Error; err != nil {
+		t.Fatal(err)
+	}
+	var defaults, touched []string
+	for _, v := range values {
+		if v.IsDefault {
+			defaults = append(defaults, v.Code)
+		}
+		if v.UpdatedAt.After(past) {
+			touched = append(touched, v.Code)
+		}
+	}
+	if fmt.Sprint(defaults) != "[MEDIUM]" {
+		t.Errorf("defaults = %v, want [MEDIUM]", defaults)
+	}
+	if fmt.Sprint(touched) != "[HIGH MEDIUM]" {
+		t.Errorf("updated rows = %v, want only [HIGH MEDIUM]", touched)
+	}
+}
+
+func TestWithTxRollsBackEverythingFnDid(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	ctx := context.Background()
+	failure := errors.New("value rejected")
+	codes := func() []string {
+		t.Helper()
+		var codes []string
+		if err := db.Model(&models.LookupCategory{}).Order("code").Pluck("code", &codes).Error; err != nil {
+			t.Fatal(err)
+		}
+		return codes
+	}
+
+	err := repo.WithTx(ctx, func(txRepo LookupRepository) error {
+		category := &models.LookupCategory{Code: "PRIORITY", Name: "Priority", IsActive: true}
+		if err := txRepo.CreateCategory(ctx, category); err != nil {
+			return err
+		}
+		value := &models.LookupValue{CategoryID: category.ID, Code: "HIGH", Name: "High", IsActive: true}
+		if err := txRepo.CreateValue(ctx, value); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("WithTx = %v, want fn's error", err)
+	}
+	var values int64
+	db.Model(&models.LookupValue{}).Count(&values)
+	if got := codes(); len(got) != 0 || values != 0 {
+		t.Errorf("after a failed fn: categories %v and %d values, want nothing saved", got, values)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("WithTx swallowed the panic of fn")
+			}
+		}()
+		repo.WithTx(ctx, func(txRepo LookupRepository) error {
+			txRepo.CreateCategory(ctx, &models.LookupCategory{Code: "PANIC", Name: "Panic", IsActive: true})
+			panic("boom")
+		})
+	}()
+	if got := codes(); len(got) != 0 {
+		t.Errorf("after a panicking fn: categories %v, want nothing saved", got)
+	}
+
+	err = repo.WithTx(ctx, func(txRepo LookupRepository) error {
+		if err := txRepo.CreateCategory(ctx, &models.LookupCategory{Code: "OUTER", Name: "Outer", IsActive: true}); err != nil {
+			return err
+		}
+		inner := txRepo.WithTx(ctx, func(txRepo LookupRepository) error {
+			if err := txRepo.CreateCategory(ctx, &models.LookupCategory{Code: "INNER", Name: "Inner", IsActive: true}); err != nil {
+				return err
+			}
+			return failure
+		})
+		if !errors.Is(inner, failure) {
+			return fmt.Errorf("nested WithTx = %v, want fn's error", inner)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithTx: %v", err)
+	}
+	if got := codes(); fmt.Sprint(got) != "[OUTER]" {
+		t.Errorf("after a failed savepoint: categories %v, want only [OUTER]", got)
+	}
+}
+
+func TestAutocompleteValuesRanksPrefixMatchesFirst(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	city := createTestCategory(t, db, nil, "CITY")
+	for i, name := range []string{"Jalajil", "Riyadh", "Alula", "Al Khobar", "Al Ahsa"} {
+		v := createTestValue(t, db, city, fmt.Sprintf("C%d", i), i, false)
+		if err := db.Model(v).Update("name", name).Error; err != nil {
+			t.Fatal(err)
+		}
+		if name == "Al Ahsa" {
+			if err := db.Model(v).Update("is_active", false).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// Matched on its code rather than its name
+	if err := db.Model(createTestValue(t, db, city, "ALQ", 5, false)).Update("name", "Qassim").Error; err != nil {
+		t.Fatal(err)
+	}
+	createTestValue(t, db, createTestCategory(t, db, nil, "REGION"), "AL_BAHAH", 0, false)
+
+	names := func(limit int) []string {
+		t.Helper()
+		values, err := repo.AutocompleteValues(context.Background(), city.ID, "al", limit)
+		if err != nil {
+			t.Fatalf("AutocompleteValues: %v", err)
+		}
+		var names []string
+		for _, v := range values {
+			names = append(names, v.Name)
+		}
+		return names
+	}
+	if got := names(10); fmt.Sprint(got) != "[Al Khobar Alula Qassim Jalajil]" {
+		t.Errorf("suggestions = %v, want the prefix matches by name, then Jalajil", got)
+	}
+	if got := names(2); fmt.Sprint(got) != "[Al Khobar Alula]" {
+		t.Errorf("suggestions limited to 2 = %v, want the first two prefix matches", got)
+	}
+}
+
+func TestListMissingDefaultsFindsActiveCategoriesWithoutADefault(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	deactivate := func(model interface{}) {
+		t.Helper()
+		if err := db.Model(model).Update("is_active", false).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	priority := createTestCategory(t, db, nil, "PRIORITY")
+	createTestValue(t, db, priority, "HIGH", 0, false)
+	createTestValue(t, db, priority, "LOW", 1, false)
+	deactivate(createTestValue(t, db, priority, "OLD", 2, false))
+
+	// Only an inactive value is marked default
+	stale := createTestCategory(t, db, nil, "STALE")
+	deactivate(createTestValue(t, db, stale, "GONE", 0, true))
+	createTestValue(t, db, stale, "KEPT", 1, false)
+
+	severity := createTestCategory(t, db, nil, "SEVERITY")
+	createTestValue(t, db, severity, "MAJOR", 0, true)
+	createTestValue(t, db, severity, "MINOR", 1, false)
+
+	createTestCategory(t, db, nil, "EMPTY")
+
+	retired := createTestCategory(t, db, nil, "RETIRED")
+	createTestValue(t, db, retired, "ANY", 0, false)
+	deactivate(retired)
+
+	missing, err := repo.ListMissingDefaults(context.Background())
+	if err != nil {
+		t.Fatalf("ListMissingDefaults: %v", err)
+	}
+	var got []string
+	for _, m := range missing {
+		got = append(got, fmt.Sprintf("%s:%d", m.CategoryCode, m.ActiveValues))
+	}
+	if fmt.Sprint(got) != "[PRIORITY:2 STALE:1]" {
+		t.Errorf("missing defaults = %v, want [PRIORITY:2 STALE:1]", got)
+	}
+}
+
+func TestListValuesByMetadataKeyMatchesTheMemberWithinTheCategory(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	ctx := context.Background()
+	priority := createTestCategory(t, db, nil, "PRIORITY")
+	severity := createTestCategory(t, db, nil, "SEVERITY")
+	withMetadata := func(category *models.LookupCategory, code string, sortOrder int, metadata models.LookupMetadata) *models.LookupValue {
+		t.Helper()
+		value := createTestValue(t, db, category, code, sortOrder, false)
+		value.Metadata = metadata
+		if err := db.Model(value).Select("metadata").Updates(value).Error; err != nil {
+			t.Fatal(err)
+		}
+		return value
+	}
+	withMetadata(priority, "HIGH", 0, models.LookupMetadata{"external_id": "P1", "tier": "gold"})
+	withMetadata(priority, "URGENT", 1, models.LookupMetadata{"external_id": "P1"})
+	withMetadata(priority, "LOW", 2, models.LookupMetadata{"external_id": "P2", "tier": "gold"})
+	createTestValue(t, db, priority, "NONE", 3, false)
+	deleted := withMetadata(priority, "OLD", 4, models.LookupMetadata{"external_id": "P1"})
+	if err := db.Delete(deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+	withMetadata(severity, "MAJOR", 0, models.LookupMetadata{"external_id": "P1"})
+
+	for _, tc := range []struct {
+		key, value, want string
+	}{
+		{"external_id", "P1", "HIGH,URGENT"},
+		{"external_id", "P2", "LOW"},
+		{"tier", "gold", "HIGH,LOW"},
+		{"external_id", "P9", ""},
+		{"missing", "", ""},
+	} {
+		values, err := repo.ListValuesByMetadataKey(ctx, priority.ID, tc.key, tc.value)
+		if err != nil {
+			t.Fatalf("ListValuesByMetadataKey(%s, %s): %v", tc.key, tc.value, err)
+		}
+		codes := make([]string, len(values))
+		for i, v := range values {
+			codes[i] = v.Code
+		}
+		if got := strings.Join(codes, ","); got != tc.want {
+			t.Errorf("ListValuesByMetadataKey(%s, %s) = %s, want %s", tc.key, tc.value, got, tc.want)
+		}
+	}
+
+	values, err := repo.ListValuesByMetadataKey(ctx, priority.ID, "external_id", "P2")
+	if err != nil || len(values) != 1 {
+		t.Fatalf("ListValuesByMetadataKey = %v, %v", values, err)
+	}
+	if got := fmt.Sprint(values[0].Metadata); got != "map[external_id:P2 tier:gold]" {
+		t.Errorf("metadata read back = %s, want the stored object", got)
+	}
+}
+
+func TestSetCategoriesActiveRestoresTheIncidentFormIntent(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	ctx := context.Background()
+	priority := createTestCategory(t, db, nil, "PRIORITY")
+	severity := createTestCategory(t, db, nil, "SEVERITY")
+	impact := createTestCategory(t, db, nil, "IMPACT")
+	for _, update := range []struct {
+		category *models.LookupCategory
+		columns  map[string]interface{}
+	}{
+		{priority, map[string]interface{}{"name_ar": "الأولوية", "add_to_incident_form": true, "incident_form_intent": true}},
+		// The Arabic name was lost while SEVERITY was on the form
+		{severity, map[string]interface{}{"add_to_incident_form": true, "incident_form_intent": true}},
+		{impact, map[string]interface{}{"name_ar": "الأثر"}},
+	} {
+		if err := db.Model(update.category).Updates(update.columns).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	ids := []uuid.UUID{priority.ID, severity.ID, impact.ID}
+	flags := func() string {
+		t.Helper()
+		var categories []models.LookupCategory
+		if err := db.Order("code").Find(&categories).Error; err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, c := range categories {
+			got = append(got, fmt.Sprintf("%s:%t/%t", c.Code, c.AddToIncidentForm, c.IncidentFormIntent))
+		}
+		return strings.Join(got, " ")
+	}
+
+	if _, err := repo.SetCategoriesActive(ctx, ids, false); err != nil {
+		t.Fatalf("deactivate: %v", err)
+	}
+	if got, want := flags(), "IMPACT:false/false PRIORITY:false/true SEVERITY:false/true"; got != want {
+		t.Errorf("after deactivating flag/intent = %s, want %s", got, want)
+	}
+	if _, err := repo.SetCategoriesActive(ctx, ids, true); err != nil {
+		t.Fatalf("reactivate: %v", err)
+	}
+	if got, want := flags(), "IMPACT:false/false PRIORITY:true/true SEVERITY:false/true"; got != want {
+		t.Errorf("after reactivating flag/intent = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type orgContextKey struct{}
+
+type globalWritesContextKey struct{}
+
+// WithOrgID marks ctx as a tenant request. Tenant-aware repositories then only
+// return global rows (org_id IS NULL) plus rows owned by orgID; a nil orgID
+// sees global rows only. Contexts without the marker are not scoped at all,
+// which is what seeding and background jobs rely on. Writes are narrower, see
+// scopeToOrgWrites.
+func WithOrgID(ctx context.Context, orgID *uuid.UUID) context.Context {
+	return context.WithValue(ctx, orgContextKey{}, orgID)
+}
+
+// OrgIDFromContext returns the tenant carried by ctx and whether ctx is tenant-scoped.
+func OrgIDFromContext(ctx context.Context) (*uuid.UUID, bool) {
+	orgID, ok := ctx.Value(orgContextKey{}).(*uuid.UUID)
+	return orgID, ok
+}
+
+// WithGlobalWrites lets the tenant request in ctx change global rows as well
+// as its own, which only super admins may do
+func WithGlobalWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, globalWritesContextKey{}, true)
+}
+
+// ErrLookupNotWritable is returned when a tenant request changes a row it can
+// see but doesn't own: a global row without WithGlobalWrites
+var ErrLookupNotWritable = errors.New("row is global or owned by another org")
+
+// scopeToOrg restricts a query on table to the rows visible to the tenant in ctx.
+func scopeToOrg(ctx context.Context, table string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if condition, args, ok := orgCondition(ctx, table); ok {
+			return db.Where(condition, args...)
+		}
+		return db
+	}
+}
+
+// orgCondition is the SQL condition behind scopeToOrg, for places a scope
+// cannot go such as a join's ON clause. ok is false when ctx is not scoped.
+func orgCondition(ctx context.Context, table string) (condition string, args []interface{}, ok bool) {
+	orgID, ok := OrgIDFromContext(ctx)
+	if !ok {
+		return "", nil, false
+	}
+	if orgID == nil {
+		return table + ".org_id IS NULL", nil, true
+	}
+	return "(" + table + ".org_id IS NULL OR " + table + ".org_id = ?)", []interface{}{*orgID}, true
+}
+
+// scopeToOrgWrites restricts a query on table to the rows the tenant in ctx
+// may change: its own, plus global rows only under WithGlobalWrites. Mutating
+// methods use it in place of scopeToOrg, so a tenant reading a global row
+// can't update, delete, restore or move it.
+func scopeToOrgWrites(ctx context.Context, table string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if condition, args, ok := orgWriteCondition(ctx, table); ok {
+			return db.Where(condition, args...)
+		}
+		return db
+	}
+}
+
+// orgWriteCondition is the SQL condition behind scopeToOrgWrites
+func orgWriteCondition(ctx context.Context, table string) (condition string, args []interface{}, ok bool) {
+	orgID, ok := OrgIDFromContext(ctx)
+	if !ok {
+		return "", nil, false
+	}
+	global, _ := ctx.Value(globalWritesContextKey{}).(bool)
+	switch {
+	case orgID == nil && global:
+		return table + ".org_id IS NULL", nil, true
+	case orgID == nil:
+		return "1 = 0", nil, true
+	case global:
+		return "(" + table + ".org_id IS NULL OR " + table + ".org_id = ?)", []interface{}{*orgID}, true
+	default:
+		return table + ".org_id = ?", []interface{}{*orgID}, true
+	}
+}
+
+// checkOrgWritable returns ErrLookupNotWritable unless the tenant in ctx may
+// change, or create, a row owned by orgID
+func checkOrgWritable(ctx context.Context, orgID *uuid.UUID) error {
+	requester, ok := OrgIDFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	if orgID == nil {
+		if global, _ := ctx.Value(globalWritesContextKey{}).(bool); global {
+			return nil
+		}
+		return ErrLookupNotWritable
+	}
+	if requester == nil || *requester != *orgID {
+		return ErrLookupNotWritable
+	}
+	return nil
+}
+
+// checkRowWritable is checkOrgWritable for the row of table with the given ID,
+// deleted or not. A missing row passes, leaving the write to report it.
+func checkRowWritable(ctx context.Context, db *gorm.DB, table string, id uuid.UUID) error {
+	if _, ok := OrgIDFromContext(ctx); !ok {
+		return nil
+	}
+	var row struct{ OrgID *uuid.UUID }
+	res := db.Table(table).Select("org_id").Where("id = ?", id).Limit(1).Scan(&row)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return res.Error
+	}
+	return checkOrgWritable(ctx, row.OrgID)
+}
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/automax/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestTenantCannotWriteOtherOrgOrGlobalRows(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	orgA, orgB := uuid.New(), uuid.New()
+	tenantA := WithOrgID(context.Background(), &orgA)
+
+	global := createTestCategory(t, db, nil, "PRIORITY")
+	globalValue := createTestValue(t, db, global, "HIGH", 0, true)
+	other := createTestCategory(t, db, &orgB, "SEVERITY")
+	otherValue := createTestValue(t, db, other, "MAJOR", 0, false)
+
+	for _, tc := range []struct {
+		name  string
+		write func() error
+	}{
+		{"update global value", func() error {
+			v := *globalValue
+			v.Name = "changed"
+			return repo.UpdateValue(tenantA, &v)
+		}},
+		{"update other org's value", func() error {
+			v := *otherValue
+			v.Name = "changed"
+			return repo.UpdateValue(tenantA, &v)
+		}},
+		{"delete global value", func() error { return repo.DeleteValue(tenantA, globalValue.ID) }},
+		{"delete other org's value", func() error { return repo.DeleteValue(tenantA, otherValue.ID) }},
+		{"update global category", func() error {
+			c := *global
+			c.Name = "changed"
+			return repo.UpdateCategory(tenantA, &c)
+		}},
+		{"delete global category", func() error { return repo.DeleteCategory(tenantA, global.ID) }},
+		{"delete other org's category", func() error { return repo.DeleteCategory(tenantA, other.ID) }},
+		{"archive global category", func() error {
+			_, err := repo.ArchiveCategory(tenantA, global.ID, true)
+			return err
+		}},
+		{"create global value", func() error {
+			return repo.CreateValue(tenantA, &models.LookupValue{CategoryID: global.ID, Code: "LOW", Name: "Low"})
+		}},
+	} {
+		if err := tc.write(); !errors.Is(err, ErrLookupNotWritable) {
+			t.Errorf("%s: err = %v, want ErrLookupNotWritable", tc.name, err)
+		}
+	}
+
+	if _, err := repo.ClearDefaultForCategory(tenantA, global.ID); err != nil {
+		t.Fatalf("ClearDefaultForCategory: %v", err)
+	}
+
+	var values []models.LookupValue
+	if err := db.Order("code").Find(&values).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 2 {
+		t.Fatalf("got %d live values, want 2", len(values))
+	}
+	for _, v := range values {
+		if v.Name != v.Code {
+			t.Errorf("value %s was renamed to %q", v.Code, v.Name)
+		}
+		if v.ID == globalValue.ID && !v.IsDefault {
+			t.Error("the global default was cleared by a tenant")
+		}
+	}
+	var categories int64
+	db.Model(&models.LookupCategory{}).Where("name = code AND archived_at IS NULL").Count(&categories)
+	if categories != 2 {
+		t.Errorf("got %d untouched categories, want 2", categories)
+	}
+}
+
+func TestTenantWritesOwnRowsAndSuperAdminWritesGlobal(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	orgA := uuid.New()
+	tenantA := WithOrgID(context.Background(), &orgA)
+	superAdmin := WithGlobalWrites(WithOrgID(context.Background(), nil))
+
+	own := createTestCategory(t, db, &orgA, "SEVERITY")
+	ownValue := createTestValue(t, db, own, "MAJOR", 0, false)
+	if err := repo.DeleteValue(tenantA, ownValue.ID); err != nil {
+		t.Fatalf("tenant deleting its own value: %v", err)
+	}
+
+	global := createTestCategory(t, db, nil, "PRIORITY")
+	globalValue := createTestValue(t, db, global, "HIGH", 0, false)
+	if err := repo.DeleteValue(superAdmin, globalValue.ID); err != nil {
+		t.Fatalf("super admin deleting a global value: %v", err)
+	}
+	if err := repo.DeleteCategory(superAdmin, own.ID); !errors.Is(err, ErrLookupNotWritable) {
+		t.Errorf("global super admin deleting a tenant category: err = %v, want ErrLookupNotWritable", err)
+	}
+}
+
+func TestTagWritesAreScopedToTheTenant(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	orgA, orgB := uuid.New(), uuid.New()
+	tenantA := WithOrgID(context.Background(), &orgA)
+	tenantB := WithOrgID(context.Background(), &orgB)
+	superAdmin := WithGlobalWrites(WithOrgID(context.Background(), nil))
+
+	globalTag := &models.LookupTag{Code: "ESCALATION", Name: "Escalation"}
+	if err := repo.CreateTag(superAdmin, globalTag); err != nil {
+		t.Fatalf("create global tag: %v", err)
+	}
+	tagA := &models.LookupTag{OrgID: &orgA, Code: "ESCALATION", Name: "Escalation"}
+	if err := repo.CreateTag(tenantA, tagA); err != nil {
+		t.Fatalf("create org A tag with the global tag's code: %v", err)
+	}
+	if err := repo.CreateTag(tenantB, &models.LookupTag{OrgID: &orgB, Code: "ESCALATION", Name: "Escalation"}); err != nil {
+		t.Fatalf("create org B tag with org A's code: %v", err)
+	}
+	if err := repo.CreateTag(tenantA, &models.LookupTag{OrgID: &orgA, Code: "ESCALATION", Name: "Again"}); err == nil {
+		t.Fatal("created a second ESCALATION tag in org A")
+	}
+
+	global := createTestCategory(t, db, nil, "PRIORITY")
+	globalValue := createTestValue(t, db, global, "HIGH", 0, false)
+	own := createTestCategory(t, db, &orgA, "SEVERITY")
+	ownValue := createTestValue(t, db, own, "MAJOR", 0, false)
+
+	for _, tc := range []struct {
+		name  string
+		write func() error
+	}{
+		{"create tag for another org", func() error {
+			return repo.CreateTag(tenantA, &models.LookupTag{OrgID: &orgB, Code: "OTHER", Name: "Other"})
+		}},
+		{"create global tag", func() error {
+			return repo.CreateTag(tenantA, &models.LookupTag{Code: "OTHER", Name: "Other"})
+		}},
+		{"attach to global tag", func() error {
+			_, err := repo.AttachTagValues(tenantA, globalTag, []uuid.UUID{ownValue.ID})
+			return err
+		}},
+		{"attach global value", func() error {
+			_, err := repo.AttachTagValues(tenantA, tagA, []uuid.UUID{ownValue.ID, globalValue.ID})
+			return err
+		}},
+		{"detach from global tag", func() error { return repo.DetachTagValue(tenantA, globalTag, ownValue.ID) }},
+		{"detach global value", func() error { return repo.DetachTagValue(tenantA, tagA, globalValue.ID) }},
+	} {
+		if err := tc.write(); !errors.Is(err, ErrLookupNotWritable) {
+			t.Errorf("%s: err = %v, want ErrLookupNotWritable", tc.name, err)
+		}
+	}
+
+	result, err := repo.AttachTagValues(tenantA, tagA, []uuid.UUID{ownValue.ID})
+	if err != nil || result.Attached != 1 {
+		t.Fatalf("attach own value: result = %+v, err = %v", result, err)
+	}
+	if err := repo.DetachTagValue(tenantA, tagA, ownValue.ID); err != nil {
+		t.Fatalf("detach own value: %v", err)
+	}
+}
+
+func TestCategoryCodesAreUniquePerOrg(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLookupRepository(db)
+	orgA, orgB := uuid.New(), uuid.New()
+	createTestCategory(t, db, nil, "PRIORITY")
+	createTestCategory(t, db, &orgA, "PRIORITY")
+	createTestCategory(t, db, &orgB, "PRIORITY")
+
+	for _, orgID := range []*uuid.UUID{nil, &orgA} {
+		if err := db.Create(&models.LookupCategory{OrgID: orgID, Code: "PRIORITY", Name: "Again"}).Error; err == nil {
+			t.Errorf("second PRIORITY for org %v was stored, want a unique violation", orgID)
+		}
+	}
+
+	perOrg, err := repo.CategoryCodeCollisions(context.Background(), true)
+	if err != nil {
+		t.Fatalf("CategoryCodeCollisions per org: %v", err)
+	}
+	if len(perOrg) != 0 {
+		t.Errorf("per-org collisions = %+v, want none", perOrg)
+	}
+	global, err := repo.CategoryCodeCollisions(context.Background(), false)
+	if err != nil {
+		t.Fatalf("CategoryCodeCollisions: %v", err)
+	}
+	if len(global) != 1 || global[0].Code != "PRIORITY" || global[0].Count != 3 {
+		t.Errorf("global collisions = %+v, want PRIORITY held 3 times", global)
+	}
+}
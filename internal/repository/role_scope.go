@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/automax/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type roleContextKey struct{}
+
+// WithRequesterRole marks ctx as a read on behalf of a requester with role.
+// Value reads then leave out the values whose required_role is set to another
+// role, unless role is models.LookupAdminRole. Contexts without the marker see
+// every value, which is what the admin endpoints rely on.
+func WithRequesterRole(ctx context.Context, role string) context.Context {
+	return context.WithValue(ctx, roleContextKey{}, role)
+}
+
+// RequesterRoleFromContext returns the role carried by ctx and whether ctx is role-scoped.
+func RequesterRoleFromContext(ctx context.Context) (string, bool) {
+	role, ok := ctx.Value(roleContextKey{}).(string)
+	return role, ok
+}
+
+// scopeToRole restricts a query on the lookup_values table to the values the
+// requester in ctx may be offered
+func scopeToRole(ctx context.Context, table string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if condition, args, ok := roleCondition(ctx, table); ok {
+			return db.Where(condition, args...)
+		}
+		return db
+	}
+}
+
+// roleCondition is the SQL condition behind scopeToRole, for places a scope
+// cannot go such as a join's ON clause. ok is false when ctx is not scoped or
+// the requester is an admin.
+func roleCondition(ctx context.Context, table string) (condition string, args []interface{}, ok bool) {
+	role, ok := RequesterRoleFromContext(ctx)
+	if !ok || role == models.LookupAdminRole {
+		return "", nil, false
+	}
+	return "(" + table + ".required_role IS NULL OR " + table + ".required_role = '' OR " + table + ".required_role = ?)",
+		[]interface{}{role}, true
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/automax/backend/internal/models"
+	"github.com/automax/backend/pkg/utils"
+	"gorm.io/gorm"
+)
+
+type tokenIssuanceLogger struct {
+	db *gorm.DB
+}
+
+// NewTokenIssuanceLogger returns a utils.TokenIssuanceLogger that writes each
+// issued token to the token_issuance table
+func NewTokenIssuanceLogger(db *gorm.DB) utils.TokenIssuanceLogger {
+	return &tokenIssuanceLogger{db: db}
+}
+
+func (l *tokenIssuanceLogger) LogTokenIssuance(ctx context.Context, issuance utils.TokenIssuance) error {
+	return l.db.WithContext(ctx).Create(&models.TokenIssuance{
+		UserID:    issuance.UserID,
+		Role:      issuance.Role,
+		JTI:       issuance.JTI,
+		IP:        issuance.IP,
+		IssuedAt:  issuance.IssuedAt,
+		ExpiresAt: issuance.ExpiresAt,
+		KID:       issuance.KID,
+	}).Error
+}
+
+// TokenIssuanceRepository reads the token issuance log
+type TokenIssuanceRepository interface {
+	// CountLiveByKID counts the logged access tokens that have not expired
+	// yet by the kid they were signed with, "" for the base secret
+	CountLiveByKID(ctx context.Context) (map[string]int64, error)
+}
+
+type tokenIssuanceRepository struct {
+	db *gorm.DB
+}
+
+func NewTokenIssuanceRepository(db *gorm.DB) TokenIssuanceRepository {
+	return &tokenIssuanceRepository{db: db}
+}
+
+func (r *tokenIssuanceRepository) CountLiveByKID(ctx context.Context) (map[string]int64, error) {
+	var rows []struct {
+		KID   string `gorm:"column:kid"`
+		Count int64
+	}
+	err := r.db.WithContext(ctx).
+		Model(&models.TokenIssuance{}).
+		Select("COALESCE(kid, '') AS kid, COUNT(*) AS count").
+		Where("expires_at > ?", time.Now()).
+		Group("kid").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		// Tokens logged before kids were recorded have a NULL kid
+		counts[row.KID] += row.Count
+	}
+	return counts, nil
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/automax/backend/internal/models"
+	"github.com/automax/backend/pkg/utils"
+	"github.com/google/uuid"
+)
+
+func TestIssuedAccessTokensAreLogged(t *testing.T) {
+	db := newTestDB(t)
+	jwtManager := utils.NewJWTManager("test-secret", 1)
+	jwtManager.SetIssuanceLogger(NewTokenIssuanceLogger(db))
+	userID := uuid.New()
+	ctx := utils.WithClientIP(context.Background(), "203.0.113.7")
+
+	pair, err := jwtManager.GenerateTokenPair(ctx, userID, "user@example.com", "admin", nil)
+	if err != nil {
+		t.Fatalf("GenerateTokenPair: %v", err)
+	}
+	if _, err := jwtManager.GenerateToken(context.Background(), userID, "user@example.com", "admin", nil); err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	claims, err := jwtManager.ValidateToken(pair.AccessToken)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+
+	var logged []models.TokenIssuance
+	if err := db.Order("ip DESC").Find(&logged).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(logged) != 2 {
+		t.Fatalf("logged %d tokens, want the access tokens of both calls", len(logged))
+	}
+	first := logged[0]
+	if first.UserID != userID || first.Role != "admin" || first.JTI != claims.ID || first.IP != "203.0.113.7" {
+		t.Errorf("logged %+v, want user %s, role admin, jti %s and the client IP", first, userID, claims.ID)
+	}
+	if !first.ExpiresAt.Truncate(time.Second).Equal(claims.ExpiresAt.Time) {
+		t.Errorf("logged expiry %v, want the token's %v", first.ExpiresAt, claims.ExpiresAt.Time)
+	}
+	if logged[1].IP != "" || logged[1].JTI == first.JTI {
+		t.Errorf("second token logged %+v, want its own jti and no IP", logged[1])
+	}
+}
+
+// failingIssuanceLogger rejects every issuance
+type failingIssuanceLogger struct{}
+
+func (failingIssuanceLogger) LogTokenIssuance(ctx context.Context, issuance utils.TokenIssuance) error {
+	return errors.New("audit table unavailable")
+}
+
+func TestFailingIssuanceLoggerDoesNotBlockTokens(t *testing.T) {
+	jwtManager := utils.NewJWTManager("test-secret", 1)
+	jwtManager.SetIssuanceLogger(failingIssuanceLogger{})
+	if _, err := jwtManager.GenerateTokenPair(context.Background(), uuid.New(), "user@example.com", "admin", nil); err != nil {
+		t.Errorf("GenerateTokenPair with a failing logger: %v", err)
+	}
+}
+
+func TestCountLiveByKIDGroupsTokensBySigningKey(t *testing.T) {
+	db := newTestDB(t)
+	jwtManager := utils.NewJWTManager("test-secret", 1)
+	jwtManager.SetIssuanceLogger(NewTokenIssuanceLogger(db))
+	keys := map[string]string{"2026-01": "old-secret", "2026-07": "new-secret"}
+	ctx := context.Background()
+	issue := func(activeKID string, n int) {
+		t.Helper()
+		jwtManager.SetSigningKeys(keys, activeKID)
+		for i := 0; i < n; i++ {
+			if _, err := jwtManager.GenerateToken(ctx, uuid.New(), "user@example.com", "admin", nil); err != nil {
+				t.Fatalf("GenerateToken: %v", err)
+			}
+		}
+	}
+	issue("", 1)
+	issue("2026-01", 2)
+	issue("2026-07", 3)
+	expired := models.TokenIssuance{UserID: uuid.New(), JTI: uuid.NewString(), KID: "2026-01", IssuedAt: time.Now().Add(-2 * time.Hour), ExpiresAt: time.Now().Add(-time.Hour)}
+	if err := db.Create(&expired).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	counts, err := NewTokenIssuanceRepository(db).CountLiveByKID(ctx)
+	if err != nil {
+		t.Fatalf("CountLiveByKID: %v", err)
+	}
+	want := map[string]int64{"": 1, "2026-01": 2, "2026-07": 3}
+	if len(counts) != len(want) {
+		t.Errorf("counts = %v, want %v", counts, want)
+	}
+	for kid, n := range want {
+		if counts[kid] != n {
+			t.Errorf("counts = %v, want %v", counts, want)
+			break
+		}
+	}
+}
@@ -2,7 +2,11 @@ package services
 
 import (
 	"context"
+	"encoding/csv"
 	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/automax/backend/internal/models"
@@ -18,6 +22,7 @@ type ActionLogService interface {
 	GetUserActions(ctx context.Context, userID uuid.UUID, page, limit int) ([]models.ActionLogResponse, int64, error)
 	CleanupOldLogs(ctx context.Context, retentionDays int) (int64, error)
 	GetFilterOptions(ctx context.Context) (*FilterOptions, error)
+	ExportActionLogsCSV(ctx context.Context, filter *models.ActionLogFilter, w io.Writer) error
 }
 
 type LogActionParams struct {
@@ -164,3 +169,72 @@ func (s *actionLogService) GetFilterOptions(ctx context.Context) (*FilterOptions
 		Actions: actions,
 	}, nil
 }
+
+// actionLogExportPageSize is how many rows the CSV export reads per query
+const actionLogExportPageSize = 1000
+
+var actionLogCSVHeader = []string{
+	"id", "created_at", "user_id", "action", "module", "resource_id", "description",
+	"status", "error_msg", "ip_address", "user_agent", "duration_ms", "old_value", "new_value",
+}
+
+// ExportActionLogsCSV writes every log matching filter to w as CSV in created_at
+// order, reading one keyset page at a time so memory use stays flat.
+func (s *actionLogService) ExportActionLogsCSV(ctx context.Context, filter *models.ActionLogFilter, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(actionLogCSVHeader); err != nil {
+		return err
+	}
+
+	var cursor *models.ActionLogCursor
+	for {
+		logs, err := s.repo.ListAfter(ctx, filter, cursor, actionLogExportPageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, l := range logs {
+			record := []string{
+				l.ID.String(),
+				l.CreatedAt.UTC().Format(time.RFC3339),
+				l.UserID.String(),
+				l.Action,
+				l.Module,
+				l.ResourceID,
+				l.Description,
+				l.Status,
+				l.ErrorMsg,
+				l.IPAddress,
+				l.UserAgent,
+				strconv.FormatInt(l.Duration, 10),
+				l.OldValue,
+				l.NewValue,
+			}
+			for i := range record {
+				record[i] = csvSafeCell(record[i])
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+
+		if len(logs) < actionLogExportPageSize {
+			return nil
+		}
+		last := logs[len(logs)-1]
+		cursor = &models.ActionLogCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+}
+
+// csvSafeCell stops a spreadsheet from reading a cell as a formula: anything
+// starting with =, +, - or @ gets a leading quote.
+func csvSafeCell(v string) string {
+	if v != "" && strings.ContainsRune("=+-@", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/automax/backend/internal/models"
+	"github.com/automax/backend/internal/repository"
+	"github.com/google/uuid"
+)
+
+// pagedActionLogRepository serves ListAfter from memory and counts the pages
+// it was asked for.
+type pagedActionLogRepository struct {
+	repository.ActionLogRepository
+	logs  []models.ActionLog
+	pages int
+}
+
+func (r *pagedActionLogRepository) ListAfter(ctx context.Context, filter *models.ActionLogFilter, after *models.ActionLogCursor, limit int) ([]models.ActionLog, error) {
+	r.pages++
+	start := 0
+	if after != nil {
+		for i, l := range r.logs {
+			if l.ID == after.ID {
+				start = i + 1
+				break
+			}
+		}
+	}
+	end := start + limit
+	if end > len(r.logs) {
+		end = len(r.logs)
+	}
+	return r.logs[start:end], nil
+}
+
+func TestExportActionLogsCSVStreamsEveryPage(t *testing.T) {
+	total := actionLogExportPageSize*2 + 7
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := &pagedActionLogRepository{}
+	for i := 0; i < total; i++ {
+		repo.logs = append(repo.logs, models.ActionLog{
+			ID:        uuid.New(),
+			CreatedAt: base.Add(time.Duration(i) * time.Second),
+			UserID:    uuid.New(),
+			Action:    "update",
+			Module:    "lookups",
+		})
+	}
+	repo.logs[0].Description = "=HYPERLINK(\"http://evil\")"
+
+	var buf bytes.Buffer
+	if err := NewActionLogService(repo).ExportActionLogsCSV(context.Background(), &models.ActionLogFilter{}, &buf); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	if len(records) != total+1 {
+		t.Fatalf("got %d rows, want %d plus the header", len(records)-1, total)
+	}
+	if repo.pages != 3 {
+		t.Errorf("read %d pages, want 3", repo.pages)
+	}
+	for i, rec := range records[1:] {
+		if rec[0] != repo.logs[i].ID.String() {
+			t.Fatalf("row %d is %s, want %s", i, rec[0], repo.logs[i].ID)
+		}
+	}
+	if got := records[1][6]; got != "'=HYPERLINK(\"http://evil\")" {
+		t.Errorf("description cell = %q, want it prefixed with a quote", got)
+	}
+}
+
+func TestCSVSafeCell(t *testing.T) {
+	cases := map[string]string{
+		"":         "",
+		"plain":    "plain",
+		"=1+2":     "'=1+2",
+		"+cmd":     "'+cmd",
+		"-1":       "'-1",
+		"@SUM(A1)": "'@SUM(A1)",
+		"a=b":      "a=b",
+	}
+	for in, want := range cases {
+		if got := csvSafeCell(in); got != want {
+			t.Errorf("csvSafeCell(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/automax/backend/internal/repository"
+)
+
+// LookupPurger periodically hard-deletes the lookup categories and values
+// soft-deleted longer ago than the retention window
+type LookupPurger interface {
+	Start(ctx context.Context)
+	Stop()
+}
+
+type lookupPurger struct {
+	repo      repository.LookupRepository
+	retention time.Duration
+	interval  time.Duration
+	stopChan  chan struct{}
+	running   bool
+}
+
+// NewLookupPurger creates a purger that runs every interval, once a day when
+// interval is zero
+func NewLookupPurger(repo repository.LookupRepository, retention, interval time.Duration) LookupPurger {
+	if interval == 0 {
+		interval = 24 * time.Hour
+	}
+
+	return &lookupPurger{
+		repo:      repo,
+		retention: retention,
+		interval:  interval,
+		stopChan:  make(chan struct{}),
+	}
+}
+
+// Start purges once and then on every tick until stopped
+func (p *lookupPurger) Start(ctx context.Context) {
+	if p.running {
+		return
+	}
+
+	p.running = true
+	log.Printf("Lookup purger started with retention %v, interval %v", p.retention, p.interval)
+
+	go func() {
+		p.purge(ctx)
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				p.purge(ctx)
+			case <-p.stopChan:
+				log.Println("Lookup purger stopped")
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop halts the purger
+func (p *lookupPurger) Stop() {
+	if !p.running {
+		return
+	}
+
+	p.running = false
+	close(p.stopChan)
+}
+
+func (p *lookupPurger) purge(ctx context.Context) {
+	result, err := p.repo.PurgeDeletedOlderThan(ctx, p.retention)
+	if err != nil {
+		log.Printf("Lookup purge failed: %v", err)
+		return
+	}
+	if result.Categories > 0 || result.Values > 0 {
+		log.Printf("Lookup purge removed %d categories and %d values", result.Categories, result.Values)
+	}
+}
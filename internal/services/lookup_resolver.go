@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/automax/backend/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrLookupCodeNotFound is returned by LookupResolver when a category code and
+// value code pair does not name an active value.
+var ErrLookupCodeNotFound = errors.New("lookup code not found")
+
+// LookupResolver maps lookup codes to value IDs for server-side callers, so
+// code such as the incident service can refer to "PRIORITY"/"HIGH" without a
+// query per use.
+type LookupResolver interface {
+	// ResolveValueID returns the ID of the active value valueCode in the active
+	// category categoryCode, or an error wrapping ErrLookupCodeNotFound.
+	// Codes are matched case-insensitively.
+	ResolveValueID(ctx context.Context, categoryCode, valueCode string) (uuid.UUID, error)
+	// Invalidate drops every cached mapping. Wire it to lookup writes with
+	// repository.NewInvalidatingLookupRepository.
+	Invalidate()
+}
+
+type lookupResolverEntry struct {
+	id        uuid.UUID
+	expiresAt time.Time
+}
+
+type lookupResolver struct {
+	repo repository.LookupRepository
+	ttl  time.Duration
+
+	mu      sync.RWMutex
+	entries map[string]lookupResolverEntry
+	// generation is bumped by Invalidate so a lookup that started before an
+	// invalidation does not store its possibly stale result
+	generation uint64
+}
+
+// NewLookupResolver returns a LookupResolver that caches successful resolutions
+// for ttl. Misses are not cached, so a value created elsewhere is found on the
+// next call. A ttl of zero disables caching.
+func NewLookupResolver(repo repository.LookupRepository, ttl time.Duration) LookupResolver {
+	return &lookupResolver{
+		repo:    repo,
+		ttl:     ttl,
+		entries: make(map[string]lookupResolverEntry),
+	}
+}
+
+func (r *lookupResolver) ResolveValueID(ctx context.Context, categoryCode, valueCode string) (uuid.UUID, error) {
+	categoryCode = strings.ToUpper(strings.TrimSpace(categoryCode))
+	valueCode = strings.ToUpper(strings.TrimSpace(valueCode))
+	key := lookupResolverKey(ctx, categoryCode, valueCode)
+
+	now := time.Now()
+	r.mu.RLock()
+	entry, ok := r.entries[key]
+	generation := r.generation
+	r.mu.RUnlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.id, nil
+	}
+
+	id, err := r.repo.FindValueIDByCodes(ctx, categoryCode, valueCode)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return uuid.Nil, fmt.Errorf("%w: %s/%s", ErrLookupCodeNotFound, categoryCode, valueCode)
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if r.ttl > 0 {
+		r.mu.Lock()
+		if r.generation == generation {
+			r.evictExpired(now)
+			r.entries[key] = lookupResolverEntry{id: id, expiresAt: now.Add(r.ttl)}
+		}
+		r.mu.Unlock()
+	}
+	return id, nil
+}
+
+func (r *lookupResolver) Invalidate() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = make(map[string]lookupResolverEntry)
+	r.generation++
+}
+
+// evictExpired drops expired entries; callers hold the write lock
+func (r *lookupResolver) evictExpired(now time.Time) {
+	for key, entry := range r.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(r.entries, key)
+		}
+	}
+}
+
+// lookupResolverKey keys cache entries by tenant as well as codes, since a
+// tenant can see its own values next to the global ones.
+func lookupResolverKey(ctx context.Context, categoryCode, valueCode string) string {
+	tenant := "*"
+	if orgID, ok := repository.OrgIDFromContext(ctx); ok {
+		tenant = "global"
+		if orgID != nil {
+			tenant = orgID.String()
+		}
+	}
+	return tenant + "|" + categoryCode + "|" + valueCode
+}
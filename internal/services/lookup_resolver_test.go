@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/automax/backend/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// countingCodeRepository resolves codes from a map and counts the lookups
+type countingCodeRepository struct {
+	repository.LookupRepository
+	ids   map[string]uuid.UUID
+	calls int
+}
+
+func (r *countingCodeRepository) FindValueIDByCodes(ctx context.Context, categoryCode, valueCode string) (uuid.UUID, error) {
+	r.calls++
+	id, ok := r.ids[categoryCode+"/"+valueCode]
+	if !ok {
+		return uuid.Nil, gorm.ErrRecordNotFound
+	}
+	return id, nil
+}
+
+func TestLookupResolverCachesUntilInvalidated(t *testing.T) {
+	high := uuid.New()
+	repo := &countingCodeRepository{ids: map[string]uuid.UUID{"PRIORITY/HIGH": high}}
+	resolver := NewLookupResolver(repo, time.Hour)
+	ctx := context.Background()
+
+	for _, codes := range [][2]string{{"PRIORITY", "HIGH"}, {" priority ", "high"}} {
+		id, err := resolver.ResolveValueID(ctx, codes[0], codes[1])
+		if err != nil || id != high {
+			t.Fatalf("ResolveValueID(%q, %q) = %s, %v, want %s", codes[0], codes[1], id, err, high)
+		}
+	}
+	if repo.calls != 1 {
+		t.Errorf("repository queried %d times, want 1", repo.calls)
+	}
+
+	resolver.Invalidate()
+	if _, err := resolver.ResolveValueID(ctx, "PRIORITY", "HIGH"); err != nil {
+		t.Fatalf("ResolveValueID after Invalidate: %v", err)
+	}
+	if repo.calls != 2 {
+		t.Errorf("repository queried %d times after Invalidate, want 2", repo.calls)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := resolver.ResolveValueID(ctx, "PRIORITY", "NONE"); !errors.Is(err, ErrLookupCodeNotFound) {
+			t.Fatalf("ResolveValueID of an unknown code = %v, want ErrLookupCodeNotFound", err)
+		}
+	}
+	if repo.calls != 4 {
+		t.Errorf("repository queried %d times, want misses left uncached", repo.calls)
+	}
+}
@@ -4,9 +4,9 @@ import (
 	"context"
 	"errors"
 	"mime/multipart"
+	"time"
 
 	"github.com/automax/backend/internal/config"
-	"github.com/automax/backend/internal/database"
 	"github.com/automax/backend/internal/models"
 	"github.com/automax/backend/internal/repository"
 	"github.com/automax/backend/internal/storage"
@@ -20,6 +20,7 @@ type UserService interface {
 	Login(ctx context.Context, req *models.UserLoginRequest) (*models.AuthResponse, error)
 	RefreshToken(ctx context.Context, refreshToken string) (*models.AuthResponse, error)
 	Logout(ctx context.Context, token string) error
+	IntrospectToken(ctx context.Context, token string) (*models.TokenIntrospectionResponse, error)
 	GetProfile(ctx context.Context, userID uuid.UUID) (*models.UserResponse, error)
 	UpdateProfile(ctx context.Context, userID uuid.UUID, req *models.UserUpdateRequest) (*models.UserResponse, error)
 	ChangePassword(ctx context.Context, userID uuid.UUID, req *models.ChangePasswordRequest) error
@@ -33,10 +34,20 @@ type UserService interface {
 	FindMatchingUsers(ctx context.Context, roleID, classificationID, locationID, departmentID, excludeUserID *uuid.UUID) ([]models.UserResponse, error)
 }
 
+// SessionStore keeps user sessions and the token blacklist;
+// database.SessionStore implements it over Redis
+type SessionStore interface {
+	SetUserSession(ctx context.Context, userID string, sessionData interface{}, expiration time.Duration) error
+	DeleteUserSession(ctx context.Context, userID string) error
+	BlacklistToken(ctx context.Context, token string, expiration time.Duration) error
+	ClaimToken(ctx context.Context, token string, expiration time.Duration) (bool, error)
+	IsTokenBlacklisted(ctx context.Context, token string) (bool, error)
+}
+
 type userService struct {
 	userRepo     repository.UserRepository
 	jwtManager   *utils.JWTManager
-	sessionStore *database.SessionStore
+	sessionStore SessionStore
 	storage      *storage.MinIOStorage
 	config       *config.Config
 }
@@ -44,7 +55,7 @@ type userService struct {
 func NewUserService(
 	userRepo repository.UserRepository,
 	jwtManager *utils.JWTManager,
-	sessionStore *database.SessionStore,
+	sessionStore SessionStore,
 	storage *storage.MinIOStorage,
 	cfg *config.Config,
 ) UserService {
@@ -121,7 +132,7 @@ func (s *userService) Register(ctx context.Context, req *models.UserRegisterRequ
 		role = "admin"
 	}
 
-	token, err := s.jwtManager.GenerateToken(user.ID, user.Email, role)
+	token, err := s.jwtManager.GenerateToken(ctx, user.ID, user.Email, role, user.OrgID)
 	if err != nil {
 		return nil, err
 	}
@@ -168,7 +179,7 @@ func (s *userService) Login(ctx context.Context, req *models.UserLoginRequest) (
 		role = user.Roles[0].Code
 	}
 
-	tokenPair, err := s.jwtManager.GenerateTokenPair(user.ID, user.Email, role)
+	tokenPair, err := s.jwtManager.GenerateTokenPair(ctx, user.ID, user.Email, role, user.OrgID)
 	if err != nil {
 		return nil, err
 	}
@@ -190,32 +201,40 @@ func (s *userService) Login(ctx context.Context, req *models.UserLoginRequest) (
 }
 
 func (s *userService) RefreshToken(ctx context.Context, refreshToken string) (*models.AuthResponse, error) {
-	// Validate the refresh token
-	claims, err := s.jwtManager.ValidateRefreshToken(refreshToken)
-	if err != nil {
-		return nil, errors.New("invalid or expired refresh token")
-	}
-
-	// Get user from database
-	user, err := s.userRepo.FindByIDWithRelations(ctx, claims.UserID)
-	if err != nil {
-		return nil, errors.New("user not found")
-	}
+	var user *models.User
+	var role string
+	tokenPair, err := s.jwtManager.RefreshTokenPair(ctx, refreshToken, func(userID uuid.UUID) (*utils.TokenSubject, error) {
+		// Refresh tokens are single use. Claiming blacklists the token and
+		// checks it was not already in one step, so of concurrent refreshes
+		// with the same token only one gets a new pair.
+		claimed, err := s.sessionStore.ClaimToken(ctx, refreshToken, s.jwtManager.GetRefreshTokenExpiration())
+		if err != nil {
+			return nil, err
+		}
+		if !claimed {
+			return nil, utils.ErrInvalidRefreshToken
+		}
 
-	if !user.IsActive {
-		return nil, errors.New("account is deactivated")
-	}
+		// Reload the user so the new access token reflects their current role
+		found, err := s.userRepo.FindByIDWithRelations(ctx, userID)
+		if err != nil {
+			return nil, errors.New("user not found")
+		}
+		if !found.IsActive {
+			return nil, errors.New("account is deactivated")
+		}
 
-	// Determine primary role for JWT
-	role := "user"
-	if user.IsSuperAdmin {
-		role = "admin"
-	} else if len(user.Roles) > 0 {
-		role = user.Roles[0].Code
-	}
+		// Determine primary role for JWT
+		role = "user"
+		if found.IsSuperAdmin {
+			role = "admin"
+		} else if len(found.Roles) > 0 {
+			role = found.Roles[0].Code
+		}
 
-	// Generate new token pair
-	tokenPair, err := s.jwtManager.GenerateTokenPair(user.ID, user.Email, role)
+		user = found
+		return &utils.TokenSubject{Email: found.Email, Role: role, OrgID: found.OrgID}, nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -241,6 +260,44 @@ func (s *userService) Logout(ctx context.Context, token string) error {
 	return s.sessionStore.BlacklistToken(ctx, token, s.jwtManager.GetTokenExpiration())
 }
 
+// IntrospectToken reports whether an access token is currently usable. Invalid,
+// expired and revoked tokens, and tokens of deactivated users, are reported as
+// inactive rather than as errors; only infrastructure failures return an error.
+func (s *userService) IntrospectToken(ctx context.Context, token string) (*models.TokenIntrospectionResponse, error) {
+	inactive := &models.TokenIntrospectionResponse{Active: false}
+
+	isBlacklisted, err := s.sessionStore.IsTokenBlacklisted(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if isBlacklisted {
+		return inactive, nil
+	}
+
+	claims, err := s.jwtManager.ValidateToken(token)
+	if err != nil {
+		return inactive, nil
+	}
+
+	user, err := s.userRepo.FindByIDWithPermissions(ctx, claims.UserID)
+	if err != nil || !user.IsActive {
+		return inactive, nil
+	}
+
+	resp := &models.TokenIntrospectionResponse{
+		Active: true,
+		UserID: &claims.UserID,
+		Email:  claims.Email,
+		Role:   claims.Role,
+		OrgID:  claims.OrgID,
+		Scopes: user.GetPermissions(),
+	}
+	if claims.ExpiresAt != nil {
+		resp.Exp = claims.ExpiresAt.Unix()
+	}
+	return resp, nil
+}
+
 func (s *userService) GetProfile(ctx context.Context, userID uuid.UUID) (*models.UserResponse, error) {
 	user, err := s.userRepo.FindByIDWithRelations(ctx, userID)
 	if err != nil {
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/automax/backend/internal/models"
+	"github.com/automax/backend/internal/repository"
+	"github.com/automax/backend/pkg/utils"
+	"github.com/google/uuid"
+)
+
+// memorySessionStore is a SessionStore kept in memory
+type memorySessionStore struct {
+	mu          sync.Mutex
+	blacklisted map[string]bool
+}
+
+func newMemorySessionStore() *memorySessionStore {
+	return &memorySessionStore{blacklisted: make(map[string]bool)}
+}
+
+func (s *memorySessionStore) SetUserSession(ctx context.Context, userID string, sessionData interface{}, expiration time.Duration) error {
+	return nil
+}
+
+func (s *memorySessionStore) DeleteUserSession(ctx context.Context, userID string) error {
+	return nil
+}
+
+func (s *memorySessionStore) BlacklistToken(ctx context.Context, token string, expiration time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blacklisted[token] = true
+	return nil
+}
+
+func (s *memorySessionStore) ClaimToken(ctx context.Context, token string, expiration time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.blacklisted[token] {
+		return false, nil
+	}
+	s.blacklisted[token] = true
+	return true, nil
+}
+
+func (s *memorySessionStore) IsTokenBlacklisted(ctx context.Context, token string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.blacklisted[token], nil
+}
+
+// singleUserRepository finds one active user by ID
+type singleUserRepository struct {
+	repository.UserRepository
+	user *models.User
+}
+
+func (r *singleUserRepository) FindByIDWithRelations(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	if id != r.user.ID {
+		return nil, errors.New("user not found")
+	}
+	return r.user, nil
+}
+
+func (r *singleUserRepository) FindByIDWithPermissions(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	return r.FindByIDWithRelations(ctx, id)
+}
+
+func TestRefreshTokenReplayedConcurrentlySucceedsOnce(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Email: "agent@example.com", IsActive: true}
+	jwtManager := utils.NewJWTManager("test-secret", 1)
+	service := NewUserService(&singleUserRepository{user: user}, jwtManager, newMemorySessionStore(), nil, nil)
+
+	pair, err := jwtManager.GenerateTokenPair(context.Background(), user.ID, user.Email, "user", nil)
+	if err != nil {
+		t.Fatalf("GenerateTokenPair: %v", err)
+	}
+
+	const attempts = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := service.RefreshToken(context.Background(), pair.RefreshToken)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, utils.ErrInvalidRefreshToken):
+			t.Errorf("replay err = %v, want ErrInvalidRefreshToken", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("%d refreshes succeeded with the same token, want 1", succeeded)
+	}
+
+	if _, err := service.RefreshToken(context.Background(), pair.RefreshToken); !errors.Is(err, utils.ErrInvalidRefreshToken) {
+		t.Errorf("later replay err = %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestRefreshTokensIssuedTogetherRotateIndependently(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Email: "agent@example.com", IsActive: true}
+	jwtManager := utils.NewJWTManager("test-secret", 1)
+	service := NewUserService(&singleUserRepository{user: user}, jwtManager, newMemorySessionStore(), nil, nil)
+
+	// Two logins in the same second
+	first, err := jwtManager.GenerateTokenPair(context.Background(), user.ID, user.Email, "user", nil)
+	if err != nil {
+		t.Fatalf("GenerateTokenPair: %v", err)
+	}
+	second, err := jwtManager.GenerateTokenPair(context.Background(), user.ID, user.Email, "user", nil)
+	if err != nil {
+		t.Fatalf("GenerateTokenPair: %v", err)
+	}
+	if first.RefreshToken == second.RefreshToken {
+		t.Fatal("two pairs issued back-to-back share a refresh token")
+	}
+
+	for i, pair := range []*utils.TokenPair{first, second} {
+		if _, err := service.RefreshToken(context.Background(), pair.RefreshToken); err != nil {
+			t.Errorf("rotating refresh token %d: %v", i+1, err)
+		}
+	}
+}
+
+func TestIntrospectTokenReportsOnlyUsableTokensActive(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Email: "agent@example.com", IsActive: true}
+	jwtManager := utils.NewJWTManager("test-secret", 1)
+	service := NewUserService(&singleUserRepository{user: user}, jwtManager, newMemorySessionStore(), nil, nil)
+	ctx := context.Background()
+
+	pair, err := jwtManager.GenerateTokenPair(ctx, user.ID, user.Email, "user", nil)
+	if err != nil {
+		t.Fatalf("GenerateTokenPair: %v", err)
+	}
+	resp, err := service.IntrospectToken(ctx, pair.AccessToken)
+	if err != nil {
+		t.Fatalf("IntrospectToken: %v", err)
+	}
+	if !resp.Active || resp.UserID == nil || *resp.UserID != user.ID || resp.Email != user.Email || resp.Exp == 0 {
+		t.Errorf("live token = %+v, want it active for the user with an expiry", resp)
+	}
+
+	user.IsActive = false
+	if resp, err := service.IntrospectToken(ctx, pair.AccessToken); err != nil || resp.Active {
+		t.Errorf("token of a deactivated user = %+v, %v, want inactive", resp, err)
+	}
+	user.IsActive = true
+
+	if err := service.Logout(ctx, pair.AccessToken); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	for name, token := range map[string]string{"revoked": pair.AccessToken, "malformed": "not-a-jwt"} {
+		resp, err := service.IntrospectToken(ctx, token)
+		if err != nil {
+			t.Errorf("%s token: %v, want no error", name, err)
+			continue
+		}
+		if resp.Active || resp.UserID != nil {
+			t.Errorf("%s token = %+v, want only active=false", name, resp)
+		}
+	}
+}
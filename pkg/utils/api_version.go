@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"runtime/debug"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// API version headers. Every response carries APIVersionHeader with the
+// version of the response envelope it was written in; clients may pin a
+// version with AcceptVersionHeader so an envelope change does not break them.
+const (
+	APIVersionHeader    = "X-API-Version"
+	AcceptVersionHeader = "Accept-Version"
+)
+
+// APIVersion is the current version of the response envelope. When the
+// envelope changes shape, bump it and keep the old version in
+// SupportedAPIVersions for as long as clients may still ask for it.
+const APIVersion = "1"
+
+// SupportedAPIVersions lists the envelope versions clients may ask for
+var SupportedAPIVersions = []string{APIVersion}
+
+// APIVersionLocal is the fiber local holding the version negotiated for the
+// request
+const APIVersionLocal = "api_version"
+
+// NegotiateAPIVersion picks the envelope version for an Accept-Version value,
+// which may carry a leading "v". An empty value gets the current version; ok
+// is false when the version is not supported.
+func NegotiateAPIVersion(requested string) (version string, ok bool) {
+	requested = strings.TrimPrefix(strings.TrimSpace(requested), "v")
+	if requested == "" {
+		return APIVersion, true
+	}
+	for _, v := range SupportedAPIVersions {
+		if v == requested {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// RequestAPIVersion returns the envelope version negotiated for the request,
+// for the responses whose shape differs between versions
+func RequestAPIVersion(c *fiber.Ctx) string {
+	if version, ok := c.Locals(APIVersionLocal).(string); ok {
+		return version
+	}
+	return APIVersion
+}
+
+// BuildVersion is the release the binary was built from, set with
+// -ldflags "-X github.com/automax/backend/pkg/utils.BuildVersion=1.2.0"
+var BuildVersion = "dev"
+
+// APIVersionInfo lists the API versions served, which is all that clients
+// need to negotiate and all that is published without authentication
+type APIVersionInfo struct {
+	APIVersion           string   `json:"api_version"`
+	SupportedAPIVersions []string `json:"supported_api_versions"`
+}
+
+// ReadAPIVersionInfo returns the API versions served
+func ReadAPIVersionInfo() APIVersionInfo {
+	return APIVersionInfo{APIVersion: APIVersion, SupportedAPIVersions: SupportedAPIVersions}
+}
+
+// BuildInfo describes the running binary and the API versions it serves. It
+// names the exact commit deployed, so it is only shown to admins.
+type BuildInfo struct {
+	APIVersionInfo
+	Version    string `json:"version"`
+	Commit     string `json:"commit,omitempty"`
+	CommitTime string `json:"commit_time,omitempty"`
+	Modified   bool   `json:"modified,omitempty"` // built from a tree with uncommitted changes
+	GoVersion  string `json:"go_version,omitempty"`
+}
+
+// ReadBuildInfo returns the build information, taking the commit from the
+// version control details Go embeds when it builds inside a repository
+func ReadBuildInfo() BuildInfo {
+	info := BuildInfo{
+		APIVersionInfo: ReadAPIVersionInfo(),
+		Version:        BuildVersion,
+	}
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.GoVersion = build.GoVersion
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Commit = setting.Value
+		case "vcs.time":
+			info.CommitTime = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
@@ -0,0 +1,39 @@
+package utils
+
+import "strings"
+
+// Arabic letter folding for search. ArabicFoldFrom lists the letter variants,
+// followed by the diacritics and the tatweel; the variants map one to one onto
+// ArabicFoldTo and the rest are dropped. This is the argument order of SQL
+// translate(), so a column can be folded in the database the same way.
+const (
+	ArabicFoldFrom = "أإآٱىة" + "ًٌٍَُِّْٰـ"
+	ArabicFoldTo   = "اااايه"
+)
+
+var arabicFolds = func() map[rune]rune {
+	to := []rune(ArabicFoldTo)
+	folds := make(map[rune]rune)
+	for i, r := range []rune(ArabicFoldFrom) {
+		if i < len(to) {
+			folds[r] = to[i]
+		} else {
+			folds[r] = -1
+		}
+	}
+	return folds
+}()
+
+// NormalizeSearchQuery prepares typed text for matching: surrounding and
+// repeated whitespace is collapsed, Latin letters are lowercased, and Arabic
+// letter variants and diacritics are folded as ArabicFoldFrom describes, so
+// "أحمر" and "احمر" match alike.
+func NormalizeSearchQuery(s string) string {
+	s = strings.ToLower(strings.Join(strings.Fields(s), " "))
+	return strings.Map(func(r rune) rune {
+		if folded, ok := arabicFolds[r]; ok {
+			return folded
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,9 @@
+package utils
+
+import "strings"
+
+// NormalizeColor trims a color and uppercases it, so "#ff0000 " and "#FF0000"
+// compare equal. It matches the grouping of the lookup color usage report.
+func NormalizeColor(color string) string {
+	return strings.ToUpper(strings.TrimSpace(color))
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MaxModifiedTime returns the latest modification time among items, as read by
+// updatedAt, or the zero time for an empty slice.
+func MaxModifiedTime[T any](items []T, updatedAt func(T) time.Time) time.Time {
+	var latest time.Time
+	for _, item := range items {
+		if t := updatedAt(item); t.After(latest) {
+			latest = t
+		}
+	}
+	return latest
+}
+
+// CheckNotModified sets the Last-Modified header and reports whether the
+// request's If-Modified-Since shows the client already has this version, in
+// which case the handler should answer 304. HTTP dates have one-second
+// resolution, so lastModified is truncated before comparing. If-None-Match
+// takes precedence (RFC 9110), so requests carrying it are left to ETag handling.
+func CheckNotModified(c *fiber.Ctx, lastModified time.Time) bool {
+	if lastModified.IsZero() {
+		return false
+	}
+
+	lastModified = lastModified.UTC().Truncate(time.Second)
+	c.Set(fiber.HeaderLastModified, lastModified.Format(http.TimeFormat))
+
+	if c.Get(fiber.HeaderIfNoneMatch) != "" {
+		return false
+	}
+	since, err := http.ParseTime(c.Get(fiber.HeaderIfModifiedSince))
+	if err != nil {
+		return false
+	}
+	return !lastModified.After(since)
+}
@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRetentionAcceptsDaysAndDurations(t *testing.T) {
+	for in, want := range map[string]time.Duration{
+		"30d":   30 * 24 * time.Hour,
+		" 1d ":  24 * time.Hour,
+		"12h":   12 * time.Hour,
+		"90m":   90 * time.Minute,
+		"1h30m": 90 * time.Minute,
+	} {
+		if got, err := ParseRetention(in); err != nil || got != want {
+			t.Errorf("ParseRetention(%q) = %s, %v, want %s", in, got, err, want)
+		}
+	}
+
+	for _, in := range []string{"", "0d", "-1d", "0h", "-2h", "1.5d", "xd", "30"} {
+		if got, err := ParseRetention(in); err == nil {
+			t.Errorf("ParseRetention(%q) = %s, want an error", in, got)
+		}
+	}
+}
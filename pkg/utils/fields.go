@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ParseFields reads the comma-separated "fields" query parameter used for
+// partial responses. Every name must be a JSON field of responseType (a struct
+// value, as returned for one item); unknown names return a 400 *fiber.Error.
+// It returns nil when no fields were requested.
+func ParseFields(c *fiber.Ctx, responseType interface{}) ([]string, error) {
+	param := strings.TrimSpace(c.Query("fields"))
+	if param == "" {
+		return nil, nil
+	}
+
+	known := jsonFieldNames(reflect.TypeOf(responseType))
+	var fields []string
+	for _, name := range strings.Split(param, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !known[name] {
+			return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unknown field %q", name))
+		}
+		fields = append(fields, name)
+	}
+	return fields, nil
+}
+
+// SelectFields trims data, a response object or a slice of them, to the given
+// JSON fields. The full response is marshaled first, so the output matches
+// what the client would otherwise receive minus the omitted keys. With no
+// fields data is returned unchanged.
+func SelectFields(data interface{}, fields []string) (interface{}, error) {
+	if len(fields) == 0 {
+		return data, nil
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		keep[f] = true
+	}
+	trim := func(obj map[string]interface{}) {
+		for k := range obj {
+			if !keep[k] {
+				delete(obj, k)
+			}
+		}
+	}
+
+	if t := reflect.TypeOf(data); t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		var items []map[string]interface{}
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			trim(item)
+		}
+		if items == nil {
+			items = []map[string]interface{}{}
+		}
+		return items, nil
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, err
+	}
+	trim(obj)
+	return obj, nil
+}
+
+// ParseReturnChanged reads the "return" query parameter of update endpoints:
+// "full" (the default) returns the whole object, "changed" only what the update
+// changed. Other values return a 400 *fiber.Error.
+func ParseReturnChanged(c *fiber.Ctx) (bool, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(c.Query("return"))); mode {
+	case "", "full":
+		return false, nil
+	case "changed":
+		return true, nil
+	default:
+		return false, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("return must be full or changed, got %q", mode))
+	}
+}
+
+// ChangedFields compares two response objects of the same type by their JSON
+// form and returns the fields whose values differ in after, plus the keep
+// fields (such as id) whether they changed or not.
+func ChangedFields(before, after interface{}, keep ...string) (map[string]interface{}, error) {
+	toMap := func(v interface{}) (map[string]interface{}, error) {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		var obj map[string]interface{}
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	}
+
+	old, err := toMap(before)
+	if err != nil {
+		return nil, err
+	}
+	current, err := toMap(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := make(map[string]interface{})
+	for _, k := range keep {
+		if v, ok := current[k]; ok {
+			changed[k] = v
+		}
+	}
+	for k, v := range current {
+		if prev, ok := old[k]; !ok || !reflect.DeepEqual(prev, v) {
+			changed[k] = v
+		}
+	}
+	// Fields left out by omitempty were cleared by the update
+	for k := range old {
+		if _, ok := current[k]; !ok {
+			changed[k] = nil
+		}
+	}
+	return changed, nil
+}
+
+// jsonFieldNames lists the JSON keys encoding/json produces for a struct type,
+// including those of flattened embedded structs
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	names := map[string]bool{}
+	if t.Kind() != reflect.Struct {
+		return names
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			for name := range jsonFieldNames(field.Type) {
+				names[name] = true
+			}
+			continue
+		}
+		if name, skip := JSONFieldName(field); !skip {
+			names[name] = true
+		}
+	}
+	return names
+}
@@ -1,7 +1,11 @@
 package utils
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"log"
+	"sort"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -12,6 +16,8 @@ type JWTClaims struct {
 	UserID uuid.UUID `json:"user_id"`
 	Email  string    `json:"email"`
 	Role   string    `json:"role"`
+	// OrgID is the tenant the user belongs to; nil for users outside any tenant
+	OrgID *uuid.UUID `json:"org_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -20,35 +26,214 @@ type RefreshClaims struct {
 	jwt.RegisteredClaims
 }
 
+// ShareClaims are carried by a lookup value share token
+type ShareClaims struct {
+	ValueID uuid.UUID `json:"value_id"`
+	jwt.RegisteredClaims
+}
+
 type TokenPair struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 	ExpiresIn    int64  `json:"expires_in"` // seconds until access token expires
 }
 
+// ErrInvalidRefreshToken is returned when a refresh token is malformed, expired or revoked
+var ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+
+// ErrInvalidShareToken is returned when a share token is malformed or expired
+var ErrInvalidShareToken = errors.New("invalid or expired share token")
+
+// ErrUnknownSigningKey is returned when an access token names a kid that is
+// not configured, e.g. because its key was retired
+var ErrUnknownSigningKey = errors.New("token signed with an unknown key")
+
+// TokenSubject is the current identity of a user as it should appear in a new access token
+type TokenSubject struct {
+	Email string
+	Role  string
+	OrgID *uuid.UUID
+}
+
+// TokenSubjectLookup loads the current TokenSubject for a user, so refreshed
+// tokens pick up email and role changes made since the last issuance.
+type TokenSubjectLookup func(userID uuid.UUID) (*TokenSubject, error)
+
+// TokenIssuance describes an access token at the moment it was issued
+type TokenIssuance struct {
+	UserID    uuid.UUID
+	Role      string
+	JTI       string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+	IP        string // from WithClientIP; empty when the caller set none
+	KID       string // the signing key; empty for the base secret
+}
+
+// TokenIssuanceLogger records issued access tokens, e.g. for a security audit
+type TokenIssuanceLogger interface {
+	LogTokenIssuance(ctx context.Context, issuance TokenIssuance) error
+}
+
+type clientIPKey struct{}
+
+// WithClientIP returns ctx carrying the IP of the client a token is issued to
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// ClientIPFromContext returns the IP set by WithClientIP, or ""
+func ClientIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
+
 type JWTManager struct {
 	secretKey        []byte
 	refreshSecretKey []byte
+	shareSecretKey   []byte
 	expireHour       int
 	refreshExpireDay int
+	issuanceLogger   TokenIssuanceLogger
+	// signingKeys are the kid-tagged access token keys; activeKID signs new
+	// tokens, or the base secretKey when it is empty
+	signingKeys map[string][]byte
+	activeKID   string
+}
+
+// SigningKeyInfo describes a configured access token key without its secret.
+// The base secret has an empty KID.
+type SigningKeyInfo struct {
+	KID    string `json:"kid"`
+	Active bool   `json:"active"` // signs new access tokens
 }
 
 func NewJWTManager(secret string, expireHour int) *JWTManager {
 	return &JWTManager{
 		secretKey:        []byte(secret),
 		refreshSecretKey: []byte(secret + "_refresh"), // Different secret for refresh tokens
+		shareSecretKey:   []byte(secret + "_share"),   // Share tokens must not pass as access tokens
 		expireHour:       expireHour,
 		refreshExpireDay: 7, // Refresh token valid for 7 days
 	}
 }
 
+// SetSigningKeys configures kid-tagged access token keys for rotation. New
+// access tokens are signed with the key of activeKID and carry it as their kid
+// header; an empty activeKID keeps signing with the base secret. A token is
+// validated with the key its kid names, or the base secret when it has none, so
+// a retired key must stay configured until the tokens it signed have expired.
+func (j *JWTManager) SetSigningKeys(keys map[string]string, activeKID string) {
+	j.signingKeys = make(map[string][]byte, len(keys))
+	for kid, secret := range keys {
+		j.signingKeys[kid] = []byte(secret)
+	}
+	j.activeKID = activeKID
+}
+
+// ValidateKeyRotation checks the signing key configuration: the active kid
+// must name a configured key, and no key may be empty or reuse another's
+// secret. Call it at startup so a botched rotation fails loudly.
+func (j *JWTManager) ValidateKeyRotation() error {
+	if j.activeKID != "" {
+		if _, ok := j.signingKeys[j.activeKID]; !ok {
+			return fmt.Errorf("active signing key %q is not configured", j.activeKID)
+		}
+	}
+	owners := map[string]string{string(j.secretKey): ""}
+	for _, info := range j.SigningKeys() {
+		if info.KID == "" {
+			continue
+		}
+		secret := string(j.signingKeys[info.KID])
+		if secret == "" {
+			return fmt.Errorf("signing key %q has an empty secret", info.KID)
+		}
+		if owner, taken := owners[secret]; taken {
+			if owner == "" {
+				return fmt.Errorf("signing key %q reuses the base secret", info.KID)
+			}
+			return fmt.Errorf("signing keys %q and %q share a secret", owner, info.KID)
+		}
+		owners[secret] = info.KID
+	}
+	return nil
+}
+
+// SigningKeys lists the base secret and the configured kids, sorted by kid
+func (j *JWTManager) SigningKeys() []SigningKeyInfo {
+	keys := []SigningKeyInfo{{KID: "", Active: j.activeKID == ""}}
+	for kid := range j.signingKeys {
+		keys = append(keys, SigningKeyInfo{KID: kid, Active: kid == j.activeKID})
+	}
+	sort.Slice(keys, func(a, b int) bool { return keys[a].KID < keys[b].KID })
+	return keys
+}
+
+// signAccessToken signs claims with the active key and returns the token and
+// the kid it carries
+func (j *JWTManager) signAccessToken(claims JWTClaims) (string, string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	if j.activeKID == "" {
+		signed, err := token.SignedString(j.secretKey)
+		return signed, "", err
+	}
+	token.Header["kid"] = j.activeKID
+	signed, err := token.SignedString(j.signingKeys[j.activeKID])
+	return signed, j.activeKID, err
+}
+
+// accessKey returns the key that verifies an access token
+func (j *JWTManager) accessKey(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, errors.New("invalid signing method")
+	}
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return j.secretKey, nil
+	}
+	key, ok := j.signingKeys[kid]
+	if !ok {
+		return nil, ErrUnknownSigningKey
+	}
+	return key, nil
+}
+
+// SetIssuanceLogger makes the manager report every access token it issues to
+// logger; nil turns reporting off
+func (j *JWTManager) SetIssuanceLogger(logger TokenIssuanceLogger) {
+	j.issuanceLogger = logger
+}
+
+// logIssuance reports a signed access token to the issuance logger. A failing
+// logger is logged and never fails the issuance.
+func (j *JWTManager) logIssuance(ctx context.Context, claims JWTClaims, kid string) {
+	if j.issuanceLogger == nil {
+		return
+	}
+	err := j.issuanceLogger.LogTokenIssuance(ctx, TokenIssuance{
+		UserID:    claims.UserID,
+		Role:      claims.Role,
+		JTI:       claims.ID,
+		IssuedAt:  claims.IssuedAt.Time,
+		ExpiresAt: claims.ExpiresAt.Time,
+		IP:        ClientIPFromContext(ctx),
+		KID:       kid,
+	})
+	if err != nil {
+		log.Printf("failed to record token %s issued to user %s: %v", claims.ID, claims.UserID, err)
+	}
+}
+
 // GenerateToken generates only the access token (for backward compatibility)
-func (j *JWTManager) GenerateToken(userID uuid.UUID, email, role string) (string, error) {
+func (j *JWTManager) GenerateToken(ctx context.Context, userID uuid.UUID, email, role string, orgID *uuid.UUID) (string, error) {
 	claims := JWTClaims{
 		UserID: userID,
 		Email:  email,
 		Role:   role,
+		OrgID:  orgID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(j.expireHour) * time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
@@ -56,18 +241,25 @@ func (j *JWTManager) GenerateToken(userID uuid.UUID, email, role string) (string
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(j.secretKey)
+	tokenString, kid, err := j.signAccessToken(claims)
+	if err != nil {
+		return "", err
+	}
+
+	j.logIssuance(ctx, claims, kid)
+	return tokenString, nil
 }
 
 // GenerateTokenPair generates both access and refresh tokens
-func (j *JWTManager) GenerateTokenPair(userID uuid.UUID, email, role string) (*TokenPair, error) {
+func (j *JWTManager) GenerateTokenPair(ctx context.Context, userID uuid.UUID, email, role string, orgID *uuid.UUID) (*TokenPair, error) {
 	// Generate access token
 	accessClaims := JWTClaims{
 		UserID: userID,
 		Email:  email,
 		Role:   role,
+		OrgID:  orgID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(j.expireHour) * time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
@@ -75,16 +267,18 @@ func (j *JWTManager) GenerateTokenPair(userID uuid.UUID, email, role string) (*T
 		},
 	}
 
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessTokenString, err := accessToken.SignedString(j.secretKey)
+	accessTokenString, kid, err := j.signAccessToken(accessClaims)
 	if err != nil {
 		return nil, err
 	}
 
-	// Generate refresh token
+	// Generate refresh token. The ID keeps two tokens issued to the same user
+	// in the same second distinct, since a used refresh token is claimed by its
+	// exact string.
 	refreshClaims := RefreshClaims{
 		UserID: userID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(j.refreshExpireDay) * 24 * time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
@@ -98,6 +292,7 @@ func (j *JWTManager) GenerateTokenPair(userID uuid.UUID, email, role string) (*T
 		return nil, err
 	}
 
+	j.logIssuance(ctx, accessClaims, kid)
 	return &TokenPair{
 		AccessToken:  accessTokenString,
 		RefreshToken: refreshTokenString,
@@ -106,12 +301,7 @@ func (j *JWTManager) GenerateTokenPair(userID uuid.UUID, email, role string) (*T
 }
 
 func (j *JWTManager) ValidateToken(tokenString string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("invalid signing method")
-		}
-		return j.secretKey, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, j.accessKey)
 
 	if err != nil {
 		return nil, err
@@ -146,6 +336,70 @@ func (j *JWTManager) ValidateRefreshToken(tokenString string) (*RefreshClaims, e
 	return claims, nil
 }
 
+// GenerateShareToken issues a token that resolves to the lookup value valueID
+// without authentication until it expires after ttl
+func (j *JWTManager) GenerateShareToken(valueID uuid.UUID, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	claims := ShareClaims{
+		ValueID: valueID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    "automax",
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(j.shareSecretKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// ValidateShareToken returns the claims of a share token, or
+// ErrInvalidShareToken when it is malformed, signed with another key or expired
+func (j *JWTManager) ValidateShareToken(tokenString string) (*ShareClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &ShareClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("invalid signing method")
+		}
+		return j.shareSecretKey, nil
+	})
+	if err != nil {
+		return nil, ErrInvalidShareToken
+	}
+
+	claims, ok := token.Claims.(*ShareClaims)
+	if !ok || !token.Valid || claims.ValueID == uuid.Nil {
+		return nil, ErrInvalidShareToken
+	}
+	return claims, nil
+}
+
+// RefreshTokenPair validates a refresh token and issues a new access and
+// refresh token for its user. Claims are taken from lookup rather than copied
+// from the old tokens.
+func (j *JWTManager) RefreshTokenPair(ctx context.Context, refreshToken string, lookup TokenSubjectLookup) (*TokenPair, error) {
+	claims, err := j.ValidateRefreshToken(refreshToken)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	subject, err := lookup(claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return j.GenerateTokenPair(ctx, claims.UserID, subject.Email, subject.Role, subject.OrgID)
+}
+
 func (j *JWTManager) GetTokenExpiration() time.Duration {
 	return time.Duration(j.expireHour) * time.Hour
 }
+
+// GetRefreshTokenExpiration returns how long a refresh token stays valid
+func (j *JWTManager) GetRefreshTokenExpiration() time.Duration {
+	return time.Duration(j.refreshExpireDay) * 24 * time.Hour
+}
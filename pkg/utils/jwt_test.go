@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestShareTokensAreNotAccessTokens(t *testing.T) {
+	jwtManager := NewJWTManager("test-secret", 1)
+	valueID := uuid.New()
+
+	share, expiresAt, err := jwtManager.GenerateShareToken(valueID, time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateShareToken: %v", err)
+	}
+	claims, err := jwtManager.ValidateShareToken(share)
+	if err != nil || claims.ValueID != valueID {
+		t.Fatalf("ValidateShareToken = %v, %v, want the value %s", claims, err, valueID)
+	}
+	if until := time.Until(expiresAt); until <= 0 || until > time.Minute {
+		t.Errorf("share token expires in %v, want within the minute", until)
+	}
+	if _, err := jwtManager.ValidateToken(share); err == nil {
+		t.Errorf("a share token validated as an access token")
+	}
+
+	access, err := jwtManager.GenerateToken(context.Background(), uuid.New(), "user@example.com", "admin", nil)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	if _, err := jwtManager.ValidateShareToken(access); !errors.Is(err, ErrInvalidShareToken) {
+		t.Errorf("access token as a share token = %v, want ErrInvalidShareToken", err)
+	}
+
+	expired, _, err := jwtManager.GenerateShareToken(valueID, -time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateShareToken: %v", err)
+	}
+	if _, err := jwtManager.ValidateShareToken(expired); !errors.Is(err, ErrInvalidShareToken) {
+		t.Errorf("expired share token = %v, want ErrInvalidShareToken", err)
+	}
+	if _, err := NewJWTManager("other-secret", 1).ValidateShareToken(share); !errors.Is(err, ErrInvalidShareToken) {
+		t.Errorf("share token under another secret = %v, want ErrInvalidShareToken", err)
+	}
+}
+
+func TestRotatedKeysKeepValidatingUntilRetired(t *testing.T) {
+	jwtManager := NewJWTManager("test-secret", 1)
+	ctx := context.Background()
+	issue := func() string {
+		t.Helper()
+		token, err := jwtManager.GenerateToken(ctx, uuid.New(), "user@example.com", "admin", nil)
+		if err != nil {
+			t.Fatalf("GenerateToken: %v", err)
+		}
+		return token
+	}
+
+	base := issue()
+	jwtManager.SetSigningKeys(map[string]string{"2026-01": "old-secret"}, "2026-01")
+	old := issue()
+	jwtManager.SetSigningKeys(map[string]string{"2026-01": "old-secret", "2026-07": "new-secret"}, "2026-07")
+	current := issue()
+	for name, token := range map[string]string{"base": base, "old": old, "current": current} {
+		if _, err := jwtManager.ValidateToken(token); err != nil {
+			t.Errorf("%s token during the overlap: %v", name, err)
+		}
+	}
+
+	jwtManager.SetSigningKeys(map[string]string{"2026-07": "new-secret"}, "2026-07")
+	if _, err := jwtManager.ValidateToken(old); !errors.Is(err, ErrUnknownSigningKey) {
+		t.Errorf("token of a retired key = %v, want ErrUnknownSigningKey", err)
+	}
+	if _, err := jwtManager.ValidateToken(current); err != nil {
+		t.Errorf("current token after retiring the old key: %v", err)
+	}
+}
+
+func TestValidateKeyRotationRejectsABotchedRotation(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		keys   map[string]string
+		active string
+		ok     bool
+	}{
+		{"base secret only", nil, "", true},
+		{"overlapping keys", map[string]string{"2026-01": "old-secret", "2026-07": "new-secret"}, "2026-07", true},
+		{"active key missing", map[string]string{"2026-01": "old-secret"}, "2026-07", false},
+		{"empty secret", map[string]string{"2026-01": "", "2026-07": "new-secret"}, "2026-07", false},
+		{"reused base secret", map[string]string{"2026-07": "test-secret"}, "2026-07", false},
+		{"shared secret", map[string]string{"2026-01": "same-secret", "2026-07": "same-secret"}, "2026-07", false},
+	} {
+		jwtManager := NewJWTManager("test-secret", 1)
+		jwtManager.SetSigningKeys(tc.keys, tc.active)
+		if err := jwtManager.ValidateKeyRotation(); (err == nil) != tc.ok {
+			t.Errorf("%s: ValidateKeyRotation = %v, want ok %t", tc.name, err, tc.ok)
+		}
+	}
+}
+
+func TestSigningKeysReportTheActiveKey(t *testing.T) {
+	jwtManager := NewJWTManager("test-secret", 1)
+	if keys := jwtManager.SigningKeys(); len(keys) != 1 || keys[0] != (SigningKeyInfo{KID: "", Active: true}) {
+		t.Errorf("SigningKeys without rotation = %+v, want only the active base secret", keys)
+	}
+
+	jwtManager.SetSigningKeys(map[string]string{"2026-07": "new-secret", "2026-01": "old-secret"}, "2026-07")
+	want := []SigningKeyInfo{{KID: ""}, {KID: "2026-01"}, {KID: "2026-07", Active: true}}
+	keys := jwtManager.SigningKeys()
+	if len(keys) != len(want) {
+		t.Fatalf("SigningKeys = %+v, want %+v", keys, want)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Errorf("SigningKeys = %+v, want %+v", keys, want)
+			break
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// NormalizeLocale lowercases a BCP 47 language tag and reports whether it is
+// well-formed, e.g. "fr-CA" becomes "fr-ca".
+func NormalizeLocale(locale string) (string, bool) {
+	locale = strings.ToLower(strings.TrimSpace(strings.ReplaceAll(locale, "_", "-")))
+	return locale, localePattern.MatchString(locale)
+}
+
+// ParseAcceptLanguage returns the locales of an Accept-Language header in order
+// of preference, normalized with NormalizeLocale. A regional tag is followed by
+// its base language ("fr-ca" then "fr") unless the header lists it itself.
+// Wildcards, q=0 entries and malformed tags are dropped.
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		locale string
+		q      float64
+	}
+
+	var entries []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		locale, ok := NormalizeLocale(tag)
+		if !ok {
+			continue
+		}
+
+		q := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+		entries = append(entries, weighted{locale: locale, q: q})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].q > entries[j].q })
+
+	listed := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		listed[e.locale] = true
+	}
+
+	seen := make(map[string]bool, len(entries))
+	locales := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if !seen[e.locale] {
+			seen[e.locale] = true
+			locales = append(locales, e.locale)
+		}
+		if base, _, regional := strings.Cut(e.locale, "-"); regional && !listed[base] && !seen[base] {
+			seen[base] = true
+			locales = append(locales, base)
+		}
+	}
+	return locales
+}
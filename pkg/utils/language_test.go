@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	cases := []struct {
+		header string
+		want   []string
+	}{
+		{"", []string{}},
+		{"fr-CA", []string{"fr-ca", "fr"}},
+		{"de;q=0.5, fr-CA, fr;q=0.8", []string{"fr-ca", "fr", "de"}},
+		{"ar, *;q=0.1, en;q=0, not a tag", []string{"ar"}},
+		{"es_MX;q=0.9", []string{"es-mx", "es"}},
+	}
+	for _, tc := range cases {
+		if got := ParseAcceptLanguage(tc.header); fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("ParseAcceptLanguage(%q) = %v, want %v", tc.header, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// DefaultListLimit is the page size used when a list request sets none
+	DefaultListLimit = 20
+	// MaxListLimit caps the page size a client can ask for
+	MaxListLimit = 100
+)
+
+// ListOptions holds the query parameters shared by the list endpoints:
+// page, limit, search, active, sort and order.
+type ListOptions struct {
+	Page   int
+	Limit  int
+	Search string
+	// Active filters on is_active; nil lists both active and inactive rows
+	Active *bool
+	// Sort is the requested sort field, validated by the repository against
+	// the columns it allows
+	Sort string
+	// Desc is true when order=desc
+	Desc bool
+	// SkipTotal asks for a page without counting all matches, set from
+	// include_total=false by the lists that support it; see ParseIncludeTotal
+	SkipTotal bool
+}
+
+// ParseListOptions reads the common list query parameters. Page and a limit
+// below 1 fall back to sensible values rather than being rejected. A limit
+// above MaxListLimit is clamped to it with clampLimit and otherwise returns a
+// 400 *fiber.Error, as do values that cannot be parsed at all.
+func ParseListOptions(c *fiber.Ctx, clampLimit bool) (ListOptions, error) {
+	opts := ListOptions{
+		Page:   1,
+		Limit:  DefaultListLimit,
+		Search: strings.TrimSpace(c.Query("search")),
+		Sort:   strings.TrimSpace(c.Query("sort")),
+	}
+
+	if page := c.Query("page"); page != "" {
+		p, err := strconv.Atoi(page)
+		if err != nil {
+			return opts, fiber.NewError(fiber.StatusBadRequest, "page must be an integer")
+		}
+		opts.Page = p
+	}
+	if opts.Page < 1 {
+		opts.Page = 1
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil {
+			return opts, fiber.NewError(fiber.StatusBadRequest, "limit must be an integer")
+		}
+		opts.Limit = l
+	}
+	if opts.Limit < 1 {
+		opts.Limit = DefaultListLimit
+	}
+	if opts.Limit > MaxListLimit {
+		if !clampLimit {
+			return opts, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("limit exceeds maximum of %d", MaxListLimit))
+		}
+		opts.Limit = MaxListLimit
+	}
+
+	if active := c.Query("active"); active != "" {
+		a, err := strconv.ParseBool(active)
+		if err != nil {
+			return opts, fiber.NewError(fiber.StatusBadRequest, "active must be true or false")
+		}
+		opts.Active = &a
+	}
+
+	switch order := strings.ToLower(c.Query("order")); order {
+	case "", "asc":
+	case "desc":
+		opts.Desc = true
+	default:
+		return opts, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("order must be asc or desc, got %q", order))
+	}
+
+	return opts, nil
+}
+
+// ParseIncludeTotal reads include_total into opts.SkipTotal. Lists over large
+// tables offer it so clients can trade the exact total for a cheaper has_more.
+func ParseIncludeTotal(c *fiber.Ctx, opts *ListOptions) error {
+	include := c.Query("include_total")
+	if include == "" {
+		return nil
+	}
+	b, err := strconv.ParseBool(include)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "include_total must be true or false")
+	}
+	opts.SkipTotal = !b
+	return nil
+}
+
+// Offset returns the number of rows to skip for the current page
+func (o ListOptions) Offset() int {
+	return (o.Page - 1) * o.Limit
+}
+
+// PageOf returns the items of the current page of an already filtered and
+// ordered slice, for lists that are paginated in memory
+func PageOf[T any](items []T, opts ListOptions) []T {
+	start := min(opts.Offset(), len(items))
+	end := min(start+opts.Limit, len(items))
+	return items[start:end]
+}
+
+// OrderBy returns an ORDER BY clause for the requested sort. columns maps the
+// sort names clients may use to SQL columns; an empty or unknown sort falls
+// back to fallback, so user input never reaches the query directly.
+func (o ListOptions) OrderBy(columns map[string]string, fallback string) string {
+	column, ok := columns[o.Sort]
+	if !ok {
+		return fallback
+	}
+	if o.Desc {
+		return column + " DESC"
+	}
+	return column + " ASC"
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// parseListQuery runs ParseListOptions on a request with query
+func parseListQuery(t *testing.T, query string, clampLimit bool) (ListOptions, error) {
+	t.Helper()
+	var opts ListOptions
+	var parseErr error
+	app := fiber.New()
+	app.Get("/items", func(c *fiber.Ctx) error {
+		opts, parseErr = ParseListOptions(c, clampLimit)
+		return nil
+	})
+	if _, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/items?"+query, nil)); err != nil {
+		t.Fatalf("GET /items?%s: %v", query, err)
+	}
+	return opts, parseErr
+}
+
+func TestParseListOptions(t *testing.T) {
+	opts, err := parseListQuery(t, "", false)
+	if err != nil {
+		t.Fatalf("no query: %v", err)
+	}
+	if opts.Page != 1 || opts.Limit != DefaultListLimit || opts.Active != nil || opts.Desc {
+		t.Errorf("defaults = %+v, want page 1, the default limit and no filters", opts)
+	}
+
+	opts, err = parseListQuery(t, "page=3&limit=5&search=+high+&active=false&sort=code&order=DESC", false)
+	if err != nil {
+		t.Fatalf("full query: %v", err)
+	}
+	if opts.Page != 3 || opts.Limit != 5 || opts.Search != "high" || opts.Active == nil || *opts.Active || opts.Sort != "code" || !opts.Desc {
+		t.Errorf("parsed = %+v", opts)
+	}
+	if opts.Offset() != 10 {
+		t.Errorf("Offset = %d, want 10", opts.Offset())
+	}
+
+	for _, query := range []string{"page=x", "limit=x", "active=maybe", "order=up"} {
+		_, err := parseListQuery(t, query, false)
+		var fiberErr *fiber.Error
+		if !errors.As(err, &fiberErr) || fiberErr.Code != fiber.StatusBadRequest {
+			t.Errorf("%s: err = %v, want a 400", query, err)
+		}
+	}
+}
+
+func TestListOptionsOrderByOnlyUsesKnownColumns(t *testing.T) {
+	columns := map[string]string{"code": "lookup_values.code"}
+	for _, tc := range []struct {
+		opts ListOptions
+		want string
+	}{
+		{ListOptions{Sort: "code"}, "lookup_values.code ASC"},
+		{ListOptions{Sort: "code", Desc: true}, "lookup_values.code DESC"},
+		{ListOptions{Sort: "code; DROP TABLE lookup_values"}, "sort_order ASC"},
+		{ListOptions{}, "sort_order ASC"},
+	} {
+		if got := tc.opts.OrderBy(columns, "sort_order ASC"); got != tc.want {
+			t.Errorf("OrderBy(%q, desc=%v) = %q, want %q", tc.opts.Sort, tc.opts.Desc, got, tc.want)
+		}
+	}
+}
+
+func TestParseListOptionsRejectsOrClampsAnOversizedLimit(t *testing.T) {
+	atCap := fmt.Sprintf("limit=%d", MaxListLimit)
+	overCap := fmt.Sprintf("limit=%d", MaxListLimit+1)
+
+	for _, clamp := range []bool{false, true} {
+		opts, err := parseListQuery(t, atCap, clamp)
+		if err != nil || opts.Limit != MaxListLimit {
+			t.Errorf("clamp=%v %s = %d, %v, want the cap accepted", clamp, atCap, opts.Limit, err)
+		}
+	}
+
+	_, err := parseListQuery(t, overCap, false)
+	var fiberErr *fiber.Error
+	want := fmt.Sprintf("limit exceeds maximum of %d", MaxListLimit)
+	if !errors.As(err, &fiberErr) || fiberErr.Code != fiber.StatusBadRequest || fiberErr.Message != want {
+		t.Errorf("rejecting %s: err = %v, want a 400 %q", overCap, err, want)
+	}
+
+	opts, err := parseListQuery(t, "limit=10000", true)
+	if err != nil || opts.Limit != MaxListLimit {
+		t.Errorf("clamping limit=10000 = %d, %v, want %d", opts.Limit, err, MaxListLimit)
+	}
+}
+
+func TestPageOfCutsTheRequestedPage(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+	for _, tc := range []struct {
+		page, limit int
+		want        string
+	}{
+		{1, 2, "[1 2]"},
+		{3, 2, "[5]"},
+		{4, 2, "[]"},
+		{1, 10, "[1 2 3 4 5]"},
+	} {
+		if got := fmt.Sprint(PageOf(items, ListOptions{Page: tc.page, Limit: tc.limit})); got != tc.want {
+			t.Errorf("PageOf page %d limit %d = %s, want %s", tc.page, tc.limit, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MergePatchContentType is the media type of RFC 7386 JSON merge patches
+const MergePatchContentType = "application/merge-patch+json"
+
+// MergePatch applies an RFC 7386 merge patch to target, both decoded JSON:
+// object members set to null are removed, other members are merged
+// recursively, and any non-object patch replaces target outright.
+func MergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = MergePatch(targetObj[key], value)
+	}
+	return targetObj
+}
+
+// ApplyMergePatch applies the merge patch in body to the struct dst points to
+// and returns the top-level fields the patch names. A field may be set to null
+// only if it is a pointer or map, or a string not tagged validate:"required";
+// it is then reset to its zero value. Unknown fields, null on other fields and values
+// of the wrong type return a 400 *fiber.Error. dst is not validated.
+func ApplyMergePatch(body []byte, dst interface{}) (map[string]bool, error) {
+	var patch map[string]interface{}
+	if err := json.Unmarshal(body, &patch); err != nil || patch == nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Request body must be a JSON object")
+	}
+
+	t := reflect.TypeOf(dst).Elem()
+	fields := make(map[string]reflect.StructField, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if name, skip := JSONFieldName(t.Field(i)); !skip {
+			fields[name] = t.Field(i)
+		}
+	}
+
+	touched := make(map[string]bool, len(patch))
+	for name, value := range patch {
+		field, ok := fields[name]
+		if !ok {
+			return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("unknown field %q", name))
+		}
+		if value == nil && !nullableField(field) {
+			return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("%s cannot be cleared", name))
+		}
+		touched[name] = true
+	}
+
+	raw, err := json.Marshal(dst)
+	if err != nil {
+		return nil, err
+	}
+	var current interface{}
+	if err := json.Unmarshal(raw, &current); err != nil {
+		return nil, err
+	}
+	merged, err := json.Marshal(MergePatch(current, patch))
+	if err != nil {
+		return nil, err
+	}
+
+	// Decode into a fresh struct so removed members end up as zero values
+	result := reflect.New(t)
+	if err := json.Unmarshal(merged, result.Interface()); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("%s must be a %s", typeErr.Field, typeErr.Type))
+		}
+		return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	reflect.ValueOf(dst).Elem().Set(result.Elem())
+	return touched, nil
+}
+
+func nullableField(field reflect.StructField) bool {
+	switch field.Type.Kind() {
+	case reflect.Ptr, reflect.Map:
+		return true
+	case reflect.String:
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			if rule == "required" {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMergePatchFollowsRFC7386(t *testing.T) {
+	cases := []struct{ target, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`["a","b"]`, `{"a":"b"}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tc := range cases {
+		var target, patch interface{}
+		if err := json.Unmarshal([]byte(tc.target), &target); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal([]byte(tc.patch), &patch); err != nil {
+			t.Fatal(err)
+		}
+		got, _ := json.Marshal(MergePatch(target, patch))
+		if string(got) != tc.want {
+			t.Errorf("MergePatch(%s, %s) = %s, want %s", tc.target, tc.patch, got, tc.want)
+		}
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	type doc struct {
+		Name        string  `json:"name" validate:"required"`
+		Description string  `json:"description"`
+		Parent      *string `json:"parent"`
+		Order       int     `json:"order"`
+	}
+	parent := "root"
+	current := func() doc { return doc{Name: "High", Description: "Urgent", Parent: &parent, Order: 3} }
+
+	d := current()
+	touched, err := ApplyMergePatch([]byte(`{"description":null,"parent":null,"order":5}`), &d)
+	if err != nil {
+		t.Fatalf("ApplyMergePatch: %v", err)
+	}
+	if d.Name != "High" || d.Description != "" || d.Parent != nil || d.Order != 5 {
+		t.Errorf("patched = %+v, want name kept, description and parent cleared, order 5", d)
+	}
+	if len(touched) != 3 || !touched["description"] || !touched["parent"] || !touched["order"] || touched["name"] {
+		t.Errorf("touched = %v, want description, parent and order", touched)
+	}
+
+	for _, body := range []string{`{"name":null}`, `{"order":null}`, `{"colour":"red"}`, `{"order":"high"}`, `[1]`, `null`, `{`} {
+		d := current()
+		if _, err := ApplyMergePatch([]byte(body), &d); err == nil {
+			t.Errorf("ApplyMergePatch(%s) succeeded, want a 400", body)
+		}
+		if d != current() {
+			t.Errorf("ApplyMergePatch(%s) changed the document to %+v", body, d)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package utils
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const openAPIVersion = "3.1.0"
+
+// OpenAPIInfo is the info block of a generated OpenAPI document.
+type OpenAPIInfo struct {
+	Title       string
+	Version     string
+	Description string
+}
+
+// OpenAPIOperation documents a single route. Request and Response are sample
+// values (usually zero structs) whose types are reflected into schemas, so the
+// document follows the models as they change.
+type OpenAPIOperation struct {
+	Summary string
+	Tag     string
+	// Status is the success status code, 200 when zero
+	Status int
+	// Request is the JSON body type, nil when the route takes no body
+	Request interface{}
+	// OptionalBody marks a request body the route also accepts empty
+	OptionalBody bool
+	// Response is the type of the envelope's data field, nil when there is none
+	Response interface{}
+	// Paginated marks responses written with PaginatedSuccessResponse
+	Paginated bool
+	// ContentType marks a response written without the envelope: a download
+	// such as CSV or zip, or a bare JSON document described by Response
+	ContentType string
+	// Query maps query parameter names to their descriptions
+	Query map[string]string
+	// Public marks a route that needs no bearer token
+	Public bool
+}
+
+// GenerateOpenAPI builds an OpenAPI 3 document for the registered routes that
+// have an entry in operations, keyed by "METHOD /full/path" as registered in
+// fiber (e.g. "GET /api/v1/lookups/:code"). Routes without an entry are left out.
+func GenerateOpenAPI(info OpenAPIInfo, routes []fiber.Route, operations map[string]OpenAPIOperation) JSONSchema {
+	components := JSONSchema{
+		"Response":                schemaForType(reflect.TypeOf(Response{}), map[reflect.Type]bool{}),
+		"ValidationErrorResponse": schemaForType(reflect.TypeOf(ValidationErrorResponse{}), map[reflect.Type]bool{}),
+		"PaginatedResponse":       schemaForType(reflect.TypeOf(PaginatedResponse{}), map[reflect.Type]bool{}),
+	}
+	paths := JSONSchema{}
+
+	for _, route := range routes {
+		op, ok := operations[route.Method+" "+route.Path]
+		if !ok {
+			continue
+		}
+
+		path, params := openAPIPath(route.Path)
+		item, _ := paths[path].(JSONSchema)
+		if item == nil {
+			item = JSONSchema{}
+			paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = openAPIOperation(op, params, components)
+	}
+
+	doc := JSONSchema{
+		"openapi": openAPIVersion,
+		"info": JSONSchema{
+			"title":       info.Title,
+			"version":     info.Version,
+			"description": info.Description,
+		},
+		"paths": paths,
+		"components": JSONSchema{
+			"schemas": components,
+			"responses": JSONSchema{
+				"Error": JSONSchema{
+					"description": "Error envelope",
+					"content":     jsonContent(JSONSchema{"$ref": "#/components/schemas/Response"}),
+				},
+				"ValidationError": JSONSchema{
+					"description": "Request body failed validation",
+					"content":     jsonContent(JSONSchema{"$ref": "#/components/schemas/ValidationErrorResponse"}),
+				},
+			},
+			"securitySchemes": JSONSchema{
+				"bearerAuth": JSONSchema{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+		"security": []JSONSchema{{"bearerAuth": []string{}}},
+	}
+	return doc
+}
+
+func openAPIOperation(op OpenAPIOperation, pathParams []string, components JSONSchema) JSONSchema {
+	operation := JSONSchema{"summary": op.Summary}
+	if op.Tag != "" {
+		operation["tags"] = []string{op.Tag}
+	}
+	if op.Public {
+		operation["security"] = []JSONSchema{}
+	}
+
+	parameters := []JSONSchema{}
+	for _, name := range pathParams {
+		parameters = append(parameters, JSONSchema{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   JSONSchema{"type": "string"},
+		})
+	}
+	queryNames := make([]string, 0, len(op.Query))
+	for name := range op.Query {
+		queryNames = append(queryNames, name)
+	}
+	sort.Strings(queryNames)
+	for _, name := range queryNames {
+		parameters = append(parameters, JSONSchema{
+			"name":        name,
+			"in":          "query",
+			"description": op.Query[name],
+			"schema":      JSONSchema{"type": "string"},
+		})
+	}
+	if len(parameters) > 0 {
+		operation["parameters"] = parameters
+	}
+
+	if op.Request != nil {
+		operation["requestBody"] = JSONSchema{
+			"required": !op.OptionalBody,
+			"content":  jsonContent(openAPISchemaRef(reflect.TypeOf(op.Request), components)),
+		}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = fiber.StatusOK
+	}
+	success := JSONSchema{"description": http.StatusText(status)}
+	switch {
+	case op.ContentType != "" && op.Response != nil:
+		success["content"] = JSONSchema{op.ContentType: JSONSchema{"schema": openAPISchemaRef(reflect.TypeOf(op.Response), components)}}
+	case op.ContentType != "":
+		success["content"] = JSONSchema{op.ContentType: JSONSchema{"schema": JSONSchema{"type": "string", "format": "binary"}}}
+	case op.Response != nil:
+		envelope := "#/components/schemas/Response"
+		if op.Paginated {
+			envelope = "#/components/schemas/PaginatedResponse"
+		}
+		success["content"] = jsonContent(JSONSchema{
+			"allOf": []JSONSchema{
+				{"$ref": envelope},
+				{"properties": JSONSchema{"data": openAPISchemaRef(reflect.TypeOf(op.Response), components)}},
+			},
+		})
+	default:
+		success["content"] = jsonContent(JSONSchema{"$ref": "#/components/schemas/Response"})
+	}
+
+	responses := JSONSchema{
+		strconv.Itoa(status): success,
+		"4XX":                JSONSchema{"$ref": "#/components/responses/Error"},
+		"5XX":                JSONSchema{"$ref": "#/components/responses/Error"},
+	}
+	if op.Request != nil {
+		responses["400"] = JSONSchema{"$ref": "#/components/responses/ValidationError"}
+	}
+	operation["responses"] = responses
+
+	return operation
+}
+
+// openAPISchemaRef returns a schema for t, registering named structs under
+// components/schemas and referring to them so shared types appear once.
+func openAPISchemaRef(t reflect.Type, components JSONSchema) JSONSchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8:
+		return JSONSchema{"type": "array", "items": openAPISchemaRef(t.Elem(), components)}
+	case t.Kind() == reflect.Map:
+		return JSONSchema{"type": "object", "additionalProperties": openAPISchemaRef(t.Elem(), components)}
+	case t.Kind() == reflect.Struct && t.Name() != "" && t != timeType && t != uuidType:
+		if _, ok := components[t.Name()]; !ok {
+			components[t.Name()] = schemaForType(t, map[reflect.Type]bool{})
+		}
+		return JSONSchema{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		return schemaForType(t, map[reflect.Type]bool{})
+	}
+}
+
+// openAPIPath converts a fiber path ("/values/:value_id") to OpenAPI form
+// ("/values/{value_id}") and returns the path parameter names in order.
+func openAPIPath(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	var params []string
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(segment, ":"), "?")
+		params = append(params, name)
+		segments[i] = "{" + name + "}"
+	}
+	return strings.Join(segments, "/"), params
+}
+
+func jsonContent(schema JSONSchema) JSONSchema {
+	return JSONSchema{fiber.MIMEApplicationJSON: JSONSchema{"schema": schema}}
+}
@@ -0,0 +1,19 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ParseUUIDParam parses the named route parameter as a UUID. On failure it
+// returns a 400 *fiber.Error naming the parameter, which handlers return as-is
+// so the app's error handler renders it.
+func ParseUUIDParam(c *fiber.Ctx, name string) (uuid.UUID, error) {
+	id, err := uuid.Parse(c.Params(name))
+	if err != nil {
+		return uuid.Nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("%s must be a valid UUID", name))
+	}
+	return id, nil
+}
@@ -1,24 +1,80 @@
 package utils
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 type Response struct {
-	Success bool        `json:"success"`
-	Message string      `json:"message,omitempty"`
-	Data    interface{} `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
+	Success  bool        `json:"success"`
+	Message  string      `json:"message,omitempty"`
+	Data     interface{} `json:"data,omitempty"`
+	Error    string      `json:"error,omitempty"`
+	Code     string      `json:"code,omitempty"`
+	Warnings []string    `json:"warnings,omitempty"`
+	// CorrelationID identifies the server log entry of an internal error
+	CorrelationID string `json:"correlation_id,omitempty"`
 }
 
+// ValidationErrorResponse is the body of every validation failure, single or
+// bulk. Details is always present, empty when no single field is at fault, so
+// clients can render all validation errors with one component.
 type ValidationErrorResponse struct {
 	Success bool              `json:"success"`
 	Error   string            `json:"error"`
-	Details []ValidationError `json:"details,omitempty"`
+	Code    string            `json:"code"`
+	Details []ValidationError `json:"details"`
+}
+
+// Error codes sent in the code field of error responses, so clients can branch
+// without parsing messages. The status codes follow one convention:
+//   - 400 for input that cannot be parsed or fails field validation
+//   - 403 when the caller lacks the permission or role for the action
+//   - 404 when the addressed resource does not exist
+//   - 409 when the request conflicts with existing data, e.g. a taken code
+//   - 422 for well-formed requests that break a business rule, e.g. deleting
+//     a system category or changing a locked default
+const (
+	ErrCodeBadRequest   = "bad_request"
+	ErrCodeValidation   = "validation_failed"
+	ErrCodeUnauthorized = "unauthorized"
+	ErrCodeForbidden    = "forbidden"
+	ErrCodeNotFound     = "not_found"
+	ErrCodeConflict     = "conflict"
+	ErrCodeBusinessRule = "business_rule_violation"
+	ErrCodeInternal     = "internal_error"
+)
+
+// ErrorCodeForStatus returns the error code sent with an error status. Statuses
+// without a dedicated code use their snake_cased status text.
+func ErrorCodeForStatus(status int) string {
+	switch status {
+	case fiber.StatusBadRequest:
+		return ErrCodeBadRequest
+	case fiber.StatusUnauthorized:
+		return ErrCodeUnauthorized
+	case fiber.StatusForbidden:
+		return ErrCodeForbidden
+	case fiber.StatusNotFound:
+		return ErrCodeNotFound
+	case fiber.StatusConflict:
+		return ErrCodeConflict
+	case fiber.StatusUnprocessableEntity:
+		return ErrCodeBusinessRule
+	case fiber.StatusInternalServerError:
+		return ErrCodeInternal
+	}
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
 }
 
 type ValidationError struct {
@@ -26,23 +82,142 @@ type ValidationError struct {
 	Message string `json:"message"`
 }
 
+// Headers of raw mode. RawResponseHeader set to true asks for the data without
+// the envelope, like ?envelope=false; WarningsHeader then carries each warning
+// the envelope would have held.
+const (
+	RawResponseHeader = "X-Raw"
+	WarningsHeader    = "X-Warnings"
+)
+
+// RawResponseRequested reports whether the client asked for raw mode, with
+// ?envelope=false or an X-Raw: true header. Error responses keep the envelope
+// either way.
+func RawResponseRequested(c *fiber.Ctx) bool {
+	if raw, err := strconv.ParseBool(c.Get(RawResponseHeader)); err == nil && raw {
+		return true
+	}
+	return !c.QueryBool("envelope", true)
+}
+
+// successJSON writes envelope, or only data in raw mode
+func successJSON(c *fiber.Ctx, statusCode int, envelope, data interface{}) error {
+	c.Vary(RawResponseHeader)
+	if RawResponseRequested(c) {
+		return c.Status(statusCode).JSON(data)
+	}
+	return c.Status(statusCode).JSON(envelope)
+}
+
 func SuccessResponse(c *fiber.Ctx, statusCode int, message string, data interface{}) error {
-	return c.Status(statusCode).JSON(Response{
+	return successJSON(c, statusCode, Response{
 		Success: true,
 		Message: message,
 		Data:    data,
+	}, data)
+}
+
+// SuccessResponseWithWarnings is a SuccessResponse that also reports non-blocking
+// problems the client should surface, e.g. incomplete translations.
+func SuccessResponseWithWarnings(c *fiber.Ctx, statusCode int, message string, data interface{}, warnings []string) error {
+	if RawResponseRequested(c) {
+		for _, warning := range warnings {
+			c.Response().Header.Add(WarningsHeader, warning)
+		}
+	}
+	return successJSON(c, statusCode, Response{
+		Success:  true,
+		Message:  message,
+		Data:     data,
+		Warnings: warnings,
+	}, data)
+}
+
+// ExposeInternalErrors makes InternalErrorResponse send the underlying error to
+// the client instead of a generic message. Meant for local development only,
+// as driver errors can name tables, constraints or connection details.
+var ExposeInternalErrors = false
+
+// CorrelationIDHeader carries the correlation ID of an internal error response
+const CorrelationIDHeader = "X-Correlation-ID"
+
+// RequestTimeoutMessage answers a request canceled by its deadline
+const RequestTimeoutMessage = "The request took too long and was canceled"
+
+// InternalErrorResponse logs err with a new correlation ID and answers 500 with
+// a generic message and that ID, so support can find the log entry without the
+// client seeing driver or query details. An err caused by the request deadline
+// is answered with a 504 instead.
+func InternalErrorResponse(c *fiber.Ctx, err error) error {
+	if err == nil {
+		err = errors.New("unknown error")
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorResponse(c, fiber.StatusGatewayTimeout, RequestTimeoutMessage)
+	}
+	correlationID := uuid.NewString()
+	log.Printf("internal error %s: %s %s: %v", correlationID, c.Method(), c.Path(), err)
+
+	message := "Internal server error"
+	if ExposeInternalErrors {
+		message = err.Error()
+	}
+	c.Set(CorrelationIDHeader, correlationID)
+	return c.Status(fiber.StatusInternalServerError).JSON(Response{
+		Success:       false,
+		Error:         message,
+		Code:          ErrCodeInternal,
+		CorrelationID: correlationID,
 	})
 }
 
+// ErrorResponse sends a user-facing error message. A 500 goes through
+// InternalErrorResponse, so the message is logged rather than sent.
 func ErrorResponse(c *fiber.Ctx, statusCode int, message string) error {
+	if statusCode == fiber.StatusInternalServerError {
+		return InternalErrorResponse(c, errors.New(message))
+	}
 	return c.Status(statusCode).JSON(Response{
 		Success: false,
 		Error:   message,
+		Code:    ErrorCodeForStatus(statusCode),
+	})
+}
+
+// ErrorResponseWithData returns an error response that also carries details the
+// client can act on, e.g. the existing resource behind a conflict.
+func ErrorResponseWithData(c *fiber.Ctx, statusCode int, message string, data interface{}) error {
+	return c.Status(statusCode).JSON(Response{
+		Success: false,
+		Error:   message,
+		Code:    ErrorCodeForStatus(statusCode),
+		Data:    data,
 	})
 }
 
 // ValidationErrorResponse formats validation errors in a user-friendly way
 func FormatValidationError(c *fiber.Ctx, err error) error {
+	return ValidationFailedResponse(c, ValidationDetails(err))
+}
+
+// ValidationFailedResponse answers 400 in the FormatValidationError format for
+// field errors found outside the validator, e.g. rules stored in the database
+func ValidationFailedResponse(c *fiber.Ctx, errors []ValidationError) error {
+	summary := ValidationSummary(errors)
+	if errors == nil {
+		errors = []ValidationError{}
+	}
+
+	return c.Status(fiber.StatusBadRequest).JSON(ValidationErrorResponse{
+		Success: false,
+		Error:   summary,
+		Code:    ErrCodeValidation,
+		Details: errors,
+	})
+}
+
+// ValidationDetails lists the field errors of a validator error
+func ValidationDetails(err error) []ValidationError {
 	var errors []ValidationError
 
 	if validationErrors, ok := err.(validator.ValidationErrors); ok {
@@ -55,8 +230,11 @@ func FormatValidationError(c *fiber.Ctx, err error) error {
 			})
 		}
 	}
+	return errors
+}
 
-	// Create a summary message
+// ValidationSummary joins the messages of field errors into one line
+func ValidationSummary(errors []ValidationError) string {
 	var summaryParts []string
 	for _, e := range errors {
 		summaryParts = append(summaryParts, e.Message)
@@ -65,12 +243,7 @@ func FormatValidationError(c *fiber.Ctx, err error) error {
 	if summary == "" {
 		summary = "Validation failed"
 	}
-
-	return c.Status(fiber.StatusBadRequest).JSON(ValidationErrorResponse{
-		Success: false,
-		Error:   summary,
-		Details: errors,
-	})
+	return summary
 }
 
 // getValidationMessage returns a user-friendly message for validation errors
@@ -90,6 +263,12 @@ func getValidationMessage(e validator.FieldError) string {
 		return fmt.Sprintf("%s must be a valid UUID", field)
 	case "oneof":
 		return fmt.Sprintf("%s must be one of: %s", field, e.Param())
+	case "regexp":
+		return fmt.Sprintf("%s must be a valid regular expression", field)
+	case "lookupcode":
+		return fmt.Sprintf("%s may only contain letters, digits, underscores and hyphens", field)
+	case "arabic":
+		return fmt.Sprintf("%s must be written in Arabic", field)
 	default:
 		return fmt.Sprintf("%s is invalid", field)
 	}
@@ -116,18 +295,98 @@ type PaginatedResponse struct {
 	TotalPages int         `json:"total_pages"`
 }
 
+// PaginatedSuccessResponse writes a paginated body and mirrors the pagination in
+// X-Total-Count, X-Page, X-Limit, X-Total-Pages and Link headers for
+// header-driven clients. In raw mode the headers are the only pagination info.
 func PaginatedSuccessResponse(c *fiber.Ctx, data interface{}, page, limit int, total int64) error {
 	totalPages := int(total) / limit
 	if int(total)%limit != 0 {
 		totalPages++
 	}
 
-	return c.Status(fiber.StatusOK).JSON(PaginatedResponse{
+	c.Set("X-Total-Count", strconv.FormatInt(total, 10))
+	c.Set("X-Page", strconv.Itoa(page))
+	c.Set("X-Limit", strconv.Itoa(limit))
+	c.Set("X-Total-Pages", strconv.Itoa(totalPages))
+	if link := paginationLinkHeader(c, page, totalPages, page < totalPages); link != "" {
+		c.Set(fiber.HeaderLink, link)
+	}
+
+	return successJSON(c, fiber.StatusOK, PaginatedResponse{
 		Success:    true,
 		Data:       data,
 		Page:       page,
 		Limit:      limit,
 		TotalItems: total,
 		TotalPages: totalPages,
-	})
+	}, data)
+}
+
+// UncountedPaginatedResponse is a page served without counting all matches;
+// HasMore tells whether a next page exists
+type UncountedPaginatedResponse struct {
+	Success bool        `json:"success"`
+	Data    interface{} `json:"data"`
+	Page    int         `json:"page"`
+	Limit   int         `json:"limit"`
+	HasMore bool        `json:"has_more"`
+}
+
+// HasMoreHeader carries has_more for pages served without a total
+const HasMoreHeader = "X-Has-More"
+
+// UncountedPaginatedSuccessResponse writes a page without totals, mirroring
+// the pagination in X-Page, X-Limit, X-Has-More and a Link header that has no
+// last relation.
+func UncountedPaginatedSuccessResponse(c *fiber.Ctx, data interface{}, page, limit int, hasMore bool) error {
+	c.Set("X-Page", strconv.Itoa(page))
+	c.Set("X-Limit", strconv.Itoa(limit))
+	c.Set(HasMoreHeader, strconv.FormatBool(hasMore))
+	if link := paginationLinkHeader(c, page, -1, hasMore); link != "" {
+		c.Set(fiber.HeaderLink, link)
+	}
+
+	return successJSON(c, fiber.StatusOK, UncountedPaginatedResponse{
+		Success: true,
+		Data:    data,
+		Page:    page,
+		Limit:   limit,
+		HasMore: hasMore,
+	}, data)
+}
+
+// paginationLinkHeader builds an RFC 8288 Link header with first, prev, next
+// and last URLs. Each URL is the current request with only its page query
+// parameter replaced. A totalPages of -1 means the total is unknown: last is
+// left out and hasNext alone decides next.
+func paginationLinkHeader(c *fiber.Ctx, page, totalPages int, hasNext bool) string {
+	if totalPages == 0 {
+		return ""
+	}
+
+	u, err := url.Parse(c.OriginalURL())
+	if err != nil {
+		return ""
+	}
+	query := u.Query()
+	pageURL := func(p int) string {
+		query.Set("page", strconv.Itoa(p))
+		return c.BaseURL() + u.Path + "?" + query.Encode()
+	}
+
+	links := []string{fmt.Sprintf(`<%s>; rel="first"`, pageURL(1))}
+	if page > 1 {
+		prev := page - 1
+		if totalPages > 0 && prev > totalPages {
+			prev = totalPages
+		}
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, pageURL(prev)))
+	}
+	if hasNext {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, pageURL(page+1)))
+	}
+	if totalPages > 0 {
+		links = append(links, fmt.Sprintf(`<%s>; rel="last"`, pageURL(totalPages)))
+	}
+	return strings.Join(links, ", ")
 }
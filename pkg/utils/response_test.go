@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestPaginatedSuccessResponseMirrorsThePageInHeaders(t *testing.T) {
+	app := fiber.New()
+	app.Get("/items", func(c *fiber.Ctx) error {
+		return PaginatedSuccessResponse(c, []string{"a"}, c.QueryInt("page"), 10, 25)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "http://api.test/items?q=x&page=2", nil))
+	if err != nil {
+		t.Fatalf("GET /items: %v", err)
+	}
+	for header, want := range map[string]string{
+		"X-Total-Count": "25",
+		"X-Page":        "2",
+		"X-Limit":       "10",
+		"X-Total-Pages": "3",
+		fiber.HeaderLink: `<http://api.test/items?page=1&q=x>; rel="first", ` +
+			`<http://api.test/items?page=1&q=x>; rel="prev", ` +
+			`<http://api.test/items?page=3&q=x>; rel="next", ` +
+			`<http://api.test/items?page=3&q=x>; rel="last"`,
+	} {
+		if got := resp.Header.Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, "http://api.test/items?page=3", nil))
+	if err != nil {
+		t.Fatalf("GET /items: %v", err)
+	}
+	want := `<http://api.test/items?page=1>; rel="first", <http://api.test/items?page=2>; rel="prev", <http://api.test/items?page=3>; rel="last"`
+	if got := resp.Header.Get(fiber.HeaderLink); got != want {
+		t.Errorf("last page Link = %q, want %q", got, want)
+	}
+}
+
+func TestInternalErrorResponseHidesTheErrorBehindACorrelationID(t *testing.T) {
+	app := fiber.New()
+	app.Get("/items", func(c *fiber.Ctx) error {
+		return InternalErrorResponse(c, errors.New(`pq: relation "lookup_values" does not exist`))
+	})
+	app.Get("/missing", func(c *fiber.Ctx) error {
+		return ErrorResponse(c, fiber.StatusNotFound, "Value not found")
+	})
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/items", nil))
+	if err != nil {
+		t.Fatalf("GET /items: %v", err)
+	}
+	raw, _ := io.ReadAll(resp.Body)
+	var body Response
+	if err := json.Unmarshal(raw, &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusInternalServerError || strings.Contains(string(raw), "lookup_values") {
+		t.Errorf("500 body = %s, want the driver error left out", raw)
+	}
+	if body.CorrelationID == "" || resp.Header.Get(CorrelationIDHeader) != body.CorrelationID {
+		t.Errorf("correlation ID body=%q header=%q, want the same ID in both", body.CorrelationID, resp.Header.Get(CorrelationIDHeader))
+	}
+
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/missing", nil))
+	if err != nil {
+		t.Fatalf("GET /missing: %v", err)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.Error != "Value not found" {
+		t.Errorf("404 error = %q, want the message kept for the client", body.Error)
+	}
+}
+
+func TestRawModeDropsTheEnvelopeOnlyFromSuccesses(t *testing.T) {
+	app := fiber.New()
+	app.Get("/items", func(c *fiber.Ctx) error {
+		return PaginatedSuccessResponse(c, []string{"a", "b"}, 1, 2, 3)
+	})
+	app.Post("/items", func(c *fiber.Ctx) error {
+		return SuccessResponseWithWarnings(c, fiber.StatusCreated, "Created", map[string]string{"code": "A"}, []string{"name_ar is missing"})
+	})
+	app.Get("/missing", func(c *fiber.Ctx) error {
+		return ErrorResponse(c, fiber.StatusNotFound, "Value not found")
+	})
+	send := func(method, path string, header map[string]string) (*http.Response, string) {
+		t.Helper()
+		req := httptest.NewRequest(method, path, nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return resp, strings.TrimSpace(string(body))
+	}
+
+	resp, body := send(fiber.MethodGet, "/items", nil)
+	if !strings.HasPrefix(body, `{"success":true`) || !strings.Contains(body, `"data":["a","b"]`) {
+		t.Errorf("default body = %s, want the envelope", body)
+	}
+	if resp.Header.Get(fiber.HeaderVary) != RawResponseHeader {
+		t.Errorf("Vary = %q, want %s", resp.Header.Get(fiber.HeaderVary), RawResponseHeader)
+	}
+
+	for _, tc := range []struct {
+		path   string
+		header map[string]string
+	}{
+		{"/items?envelope=false", nil},
+		{"/items", map[string]string{RawResponseHeader: "true"}},
+	} {
+		resp, body := send(fiber.MethodGet, tc.path, tc.header)
+		if body != `["a","b"]` {
+			t.Errorf("raw GET %s %v = %s, want only the data", tc.path, tc.header, body)
+		}
+		if resp.Header.Get("X-Total-Count") != "3" || resp.Header.Get("X-Total-Pages") != "2" {
+			t.Errorf("raw GET %s: X-Total-Count=%q X-Total-Pages=%q, want the page in headers", tc.path, resp.Header.Get("X-Total-Count"), resp.Header.Get("X-Total-Pages"))
+		}
+	}
+
+	resp, body = send(fiber.MethodPost, "/items?envelope=false", nil)
+	if resp.StatusCode != fiber.StatusCreated || body != `{"code":"A"}` {
+		t.Errorf("raw POST = %d %s, want 201 with only the data", resp.StatusCode, body)
+	}
+	if resp.Header.Get(WarningsHeader) != "name_ar is missing" {
+		t.Errorf("raw %s = %q, want the warning", WarningsHeader, resp.Header.Get(WarningsHeader))
+	}
+
+	_, body = send(fiber.MethodGet, "/missing?envelope=false", nil)
+	if !strings.Contains(body, `"error":"Value not found"`) {
+		t.Errorf("raw error body = %s, want the envelope kept", body)
+	}
+}
+
+func TestValidationFailedResponseAlwaysSendsDetails(t *testing.T) {
+	app := fiber.New()
+	app.Get("/items", func(c *fiber.Ctx) error {
+		return ValidationFailedResponse(c, nil)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/items", nil))
+	if err != nil {
+		t.Fatalf("GET /items: %v", err)
+	}
+	raw, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusBadRequest || !strings.Contains(string(raw), `"details":[]`) || !strings.Contains(string(raw), `"code":"`+ErrCodeValidation+`"`) {
+		t.Errorf("failure without field errors = %d %s, want 400 with an empty details array", resp.StatusCode, raw)
+	}
+}
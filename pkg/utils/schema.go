@@ -0,0 +1,211 @@
+package utils
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JSONSchema is a JSON Schema (draft 2020-12) document or sub-schema.
+type JSONSchema map[string]interface{}
+
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	uuidType = reflect.TypeOf(uuid.UUID{})
+)
+
+// GenerateJSONSchema builds a JSON Schema for the given struct value from its
+// json and validate tags, so the schema always matches what the validator enforces.
+func GenerateJSONSchema(title string, v interface{}) JSONSchema {
+	schema := schemaForType(reflect.TypeOf(v), map[reflect.Type]bool{})
+	schema["$schema"] = jsonSchemaDialect
+	if title != "" {
+		schema["title"] = title
+	}
+	return schema
+}
+
+// schemaForType builds the schema for t. seen holds the structs currently being
+// expanded so self-referencing types (a value embedding its category, which
+// lists its values) stop at a plain object instead of recursing forever.
+func schemaForType(t reflect.Type, seen map[reflect.Type]bool) JSONSchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return JSONSchema{"type": "string", "format": "date-time"}
+	case uuidType:
+		return JSONSchema{"type": "string", "format": "uuid"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return JSONSchema{"type": "string"}
+	case reflect.Bool:
+		return JSONSchema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return JSONSchema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return JSONSchema{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// Raw JSON / byte payloads are free-form
+			return JSONSchema{}
+		}
+		return JSONSchema{"type": "array", "items": schemaForType(t.Elem(), seen)}
+	case reflect.Map:
+		return JSONSchema{"type": "object", "additionalProperties": schemaForType(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return JSONSchema{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		return schemaForStruct(t, seen)
+	default:
+		return JSONSchema{}
+	}
+}
+
+func schemaForStruct(t reflect.Type, seen map[reflect.Type]bool) JSONSchema {
+	properties := JSONSchema{}
+	required := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, skip := JSONFieldName(field)
+		if skip {
+			continue
+		}
+
+		// Embedded structs without a json name are flattened, like encoding/json does
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			embedded := schemaForType(field.Type, seen)
+			if props, ok := embedded["properties"].(JSONSchema); ok {
+				for k, v := range props {
+					properties[k] = v
+				}
+			}
+			if req, ok := embedded["required"].([]string); ok {
+				required = append(required, req...)
+			}
+			continue
+		}
+
+		prop := schemaForType(field.Type, seen)
+		if applyValidateTag(prop, field.Type, field.Tag.Get("validate")) {
+			required = append(required, name)
+		}
+		properties[name] = prop
+	}
+
+	schema := JSONSchema{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// JSONFieldName returns the name encoding/json uses for a struct field and
+// whether the field is skipped entirely.
+func JSONFieldName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", true
+	}
+	name := strings.Split(tag, ",")[0]
+	if name == "" {
+		name = field.Name
+	}
+	return name, false
+}
+
+// applyValidateTag translates validator rules into schema keywords and reports
+// whether the field is required.
+func applyValidateTag(prop JSONSchema, t reflect.Type, tag string) bool {
+	if tag == "" {
+		return false
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	isString := t.Kind() == reflect.String
+	isArray := t.Kind() == reflect.Slice || t.Kind() == reflect.Array
+
+	required := false
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		if name == "dive" {
+			// Remaining rules apply to the elements, not the field itself
+			break
+		}
+		switch name {
+		case "required":
+			required = true
+		case "min", "max", "len":
+			n, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				continue
+			}
+			switch {
+			case isString:
+				if name != "max" {
+					prop["minLength"] = int(n)
+				}
+				if name != "min" {
+					prop["maxLength"] = int(n)
+				}
+			case isArray:
+				if name != "max" {
+					prop["minItems"] = int(n)
+				}
+				if name != "min" {
+					prop["maxItems"] = int(n)
+				}
+			default:
+				if name != "max" {
+					prop["minimum"] = n
+				}
+				if name != "min" {
+					prop["maximum"] = n
+				}
+			}
+		case "oneof":
+			enum := []interface{}{}
+			for _, option := range strings.Fields(param) {
+				enum = append(enum, option)
+			}
+			prop["enum"] = enum
+		case "email":
+			prop["format"] = "email"
+		case "uuid":
+			prop["format"] = "uuid"
+		case "url":
+			prop["format"] = "uri"
+		case "regexp":
+			prop["format"] = "regex"
+		case "lookupcode":
+			prop["pattern"] = lookupCodePattern.String()
+		case "hexcolor":
+			prop["pattern"] = "^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$"
+		}
+	}
+	return required
+}
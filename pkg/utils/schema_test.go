@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type schemaTestItem struct {
+	Label string `json:"label" validate:"required,max=20"`
+}
+
+type schemaTestRequest struct {
+	Code     string           `json:"code" validate:"required,min=2,max=50"`
+	Status   string           `json:"status" validate:"omitempty,oneof=active archived"`
+	Count    int              `json:"count" validate:"min=1,max=10"`
+	Color    string           `json:"color" validate:"hexcolor"`
+	ParentID *uuid.UUID       `json:"parent_id"`
+	At       time.Time        `json:"at"`
+	Tags     []string         `json:"tags" validate:"max=5,dive,max=30"`
+	Items    []schemaTestItem `json:"items"`
+	Secret   string           `json:"-"`
+}
+
+func TestGenerateJSONSchemaFollowsTheValidateTags(t *testing.T) {
+	schema := GenerateJSONSchema("schemaTestRequest", schemaTestRequest{})
+	if schema["$schema"] != jsonSchemaDialect || schema["title"] != "schemaTestRequest" {
+		t.Errorf("schema header = %v %v", schema["$schema"], schema["title"])
+	}
+	if fmt.Sprint(schema["required"]) != "[code]" {
+		t.Errorf("required = %v, want [code]", schema["required"])
+	}
+	props := schema["properties"].(JSONSchema)
+	if _, ok := props["Secret"]; ok {
+		t.Error(`a json:"-" field is in the schema`)
+	}
+
+	cases := []struct {
+		field, keyword string
+		want           interface{}
+	}{
+		{"code", "minLength", 2},
+		{"code", "maxLength", 50},
+		{"status", "enum", []interface{}{"active", "archived"}},
+		{"count", "type", "integer"},
+		{"count", "minimum", 1.0},
+		{"count", "maximum", 10.0},
+		{"color", "pattern", "^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$"},
+		{"parent_id", "format", "uuid"},
+		{"at", "format", "date-time"},
+		{"tags", "maxItems", 5},
+	}
+	for _, tc := range cases {
+		prop := props[tc.field].(JSONSchema)
+		if fmt.Sprint(prop[tc.keyword]) != fmt.Sprint(tc.want) {
+			t.Errorf("%s.%s = %v, want %v", tc.field, tc.keyword, prop[tc.keyword], tc.want)
+		}
+	}
+
+	// Rules after dive apply to the elements, not the array
+	if _, ok := props["tags"].(JSONSchema)["maxLength"]; ok {
+		t.Error("tags got the element rule maxLength")
+	}
+	item := props["items"].(JSONSchema)["items"].(JSONSchema)
+	if fmt.Sprint(item["required"]) != "[label]" {
+		t.Errorf("items required = %v, want [label]", item["required"])
+	}
+}
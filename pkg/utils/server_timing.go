@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ServerTimingHeader reports server-side durations to browser dev tools
+const ServerTimingHeader = "Server-Timing"
+
+// ServerTiming adds up the database time of a request and reports it with the
+// total time since StartServerTiming in a Server-Timing header. A disabled
+// ServerTiming writes no header.
+type ServerTiming struct {
+	enabled bool
+	start   time.Time
+	db      time.Duration
+}
+
+// StartServerTiming starts timing a request
+func StartServerTiming(enabled bool) *ServerTiming {
+	return &ServerTiming{enabled: enabled, start: time.Now()}
+}
+
+// DB adds the time elapsed since start, taken before a query, to the database
+// time
+func (t *ServerTiming) DB(start time.Time) {
+	t.db += time.Since(start)
+}
+
+// Write sets the Server-Timing header, e.g. "db;dur=12.3, total;dur=15.1"
+// with durations in milliseconds. Call it right before sending the response.
+func (t *ServerTiming) Write(c *fiber.Ctx) {
+	if !t.enabled {
+		return
+	}
+	c.Set(ServerTimingHeader, fmt.Sprintf("db;dur=%.1f, total;dur=%.1f", milliseconds(t.db), milliseconds(time.Since(t.start))))
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
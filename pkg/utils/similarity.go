@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// NearDuplicateThreshold is the NameSimilarity from which two names are
+// treated as likely duplicates, e.g. "Hight" and "High" (0.8)
+const NearDuplicateThreshold = 0.8
+
+// NameSimilarity returns how alike two names are, from 0 (nothing in common)
+// to 1 (equal), as 1 - Levenshtein distance / length of the longer name. Case
+// and surrounding or repeated whitespace are ignored.
+func NameSimilarity(a, b string) float64 {
+	a = normalizeName(a)
+	b = normalizeName(b)
+	longest := utf8.RuneCountInString(a)
+	if n := utf8.RuneCountInString(b); n > longest {
+		longest = n
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(a, b))/float64(longest)
+}
+
+func normalizeName(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// levenshtein counts the single-rune insertions, deletions and substitutions
+// needed to turn a into b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
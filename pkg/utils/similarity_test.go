@@ -0,0 +1,26 @@
+package utils
+
+import "testing"
+
+func TestNameSimilarity(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want float64
+	}{
+		{"High", "High", 1},
+		{"  high ", "HIGH", 1},
+		{"Hight", "High", 0.8},
+		{"In  progress", "in progress", 1},
+		{"Low", "High", 0},
+		{"", "", 1},
+		{"مرتفع", "مرتفعة", 1 - 1.0/6},
+	}
+	for _, tc := range cases {
+		if got := NameSimilarity(tc.a, tc.b); got < tc.want-1e-9 || got > tc.want+1e-9 {
+			t.Errorf("NameSimilarity(%q, %q) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+	if NameSimilarity("Hight", "High") < NearDuplicateThreshold {
+		t.Errorf("a one-letter typo of a four-letter name is below NearDuplicateThreshold")
+	}
+}
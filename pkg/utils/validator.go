@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"reflect"
+	"regexp"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// lookupCodePattern is the shape of a lookup code: letters, digits,
+// underscores and hyphens, starting with a letter or digit
+var lookupCodePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// customValidations are the validator tags this project adds on top of the
+// built-in ones. Register new rules here so every handler gets them.
+var customValidations = map[string]validator.Func{
+	// regexp: the string compiles as a full-match pattern
+	"regexp": func(fl validator.FieldLevel) bool {
+		_, err := CompileFullMatch(fl.Field().String())
+		return err == nil
+	},
+	// lookupcode: the string is a valid lookup code
+	"lookupcode": func(fl validator.FieldLevel) bool {
+		return lookupCodePattern.MatchString(fl.Field().String())
+	},
+	// arabic: the string contains Arabic script; pair with required or
+	// omitempty to decide whether it may be empty
+	"arabic": func(fl validator.FieldLevel) bool {
+		for _, r := range fl.Field().String() {
+			if unicode.Is(unicode.Arabic, r) {
+				return true
+			}
+		}
+		return false
+	},
+}
+
+// NewValidator returns a validator with the custom validations registered and
+// field errors named after the JSON field, e.g. name_ar rather than NameAr
+func NewValidator() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, skip := JSONFieldName(field)
+		if skip {
+			return ""
+		}
+		return name
+	})
+	for tag, fn := range customValidations {
+		if err := validate.RegisterValidation(tag, fn); err != nil {
+			panic("utils: registering validation " + tag + ": " + err.Error())
+		}
+	}
+	return validate
+}
+
+// CompileFullMatch compiles pattern so that it must match the whole input
+func CompileFullMatch(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
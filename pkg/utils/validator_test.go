@@ -0,0 +1,32 @@
+package utils
+
+import "testing"
+
+type customRules struct {
+	Code    string `json:"code" validate:"lookupcode"`
+	Pattern string `json:"value_code_pattern" validate:"regexp"`
+	NameAr  string `json:"name_ar" validate:"omitempty,arabic"`
+}
+
+func TestNewValidatorRegistersTheCustomRules(t *testing.T) {
+	validate := NewValidator()
+
+	if err := validate.Struct(customRules{Code: "HIGH_1", Pattern: "[A-Z]{2}", NameAr: "عالي"}); err != nil {
+		t.Fatalf("valid struct: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		rules customRules
+		want  ValidationError
+	}{
+		{"code with a space", customRules{Code: "HIGH 1", Pattern: "x"}, ValidationError{"code", "code may only contain letters, digits, underscores and hyphens"}},
+		{"pattern that doesn't compile", customRules{Code: "HIGH", Pattern: "[A-Z"}, ValidationError{"value_code_pattern", "value_code_pattern must be a valid regular expression"}},
+		{"latin Arabic name", customRules{Code: "HIGH", Pattern: "x", NameAr: "High"}, ValidationError{"name_ar", "name_ar must be written in Arabic"}},
+	} {
+		details := ValidationDetails(validate.Struct(tc.rules))
+		if len(details) != 1 || details[0] != tc.want {
+			t.Errorf("%s: details = %+v, want %+v", tc.name, details, tc.want)
+		}
+	}
+}